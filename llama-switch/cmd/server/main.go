@@ -2,46 +2,116 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
-	"os"
-	"os/signal"
 	"path/filepath"
-	"syscall"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 
 	"llama-switch/internal/config"
+	"llama-switch/internal/daemon"
 	"llama-switch/internal/handler"
+	"llama-switch/internal/logging"
+	"llama-switch/internal/proxy"
 	"llama-switch/internal/service"
+	"llama-switch/internal/tlsserver"
 )
 
 func main() {
+	forceTakeover := flag.Bool("force-takeover", false, "take over the pid file even if another switcher instance appears to be running")
+	configFile := flag.String("config", "", "path to a YAML or JSON config file (default: config.yaml, config.yml or config.json in the working or program directory)")
+	flag.Parse()
+
+	// 检测运行模式（终端、systemd或Windows服务），作为Windows服务运行时会切换到程序目录
+	d, err := daemon.Start("llama-switch")
+	if err != nil {
+		logging.Fatal("Failed to start", "error", err)
+	}
+	slog.Info("Running mode", "mode", d.Mode())
+
 	// 加载配置
-	cfg, err := config.LoadConfig()
+	cfg, err := config.LoadConfig(*configFile)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v\n", err)
+		logging.Fatal("Failed to load config", "error", err)
 	}
 
 	// 验证配置
 	if err := config.ValidateConfig(cfg); err != nil {
-		log.Fatalf("Invalid configuration: %v\n", err)
+		logging.Fatal("Invalid configuration", "error", err)
+	}
+
+	// 按日志配置设置级别、格式和输出位置
+	logFile, err := logging.Setup(cfg)
+	if err != nil {
+		logging.Fatal("Failed to set up logging", "error", err)
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+
+	// 创建PID锁文件，防止多个实例同时管理同一持久化配置
+	pidFile, err := service.AcquirePIDFile(cfg, *forceTakeover)
+	if err != nil {
+		logging.Fatal("Failed to start", "error", err)
 	}
+	defer pidFile.Release()
+	slog.Info("PID file", "file", pidFile.Path())
 
 	// 初始化模型服务 (启用自动恢复)
 	modelService := service.NewModelService(cfg, true)
 
 	// 输出持久化配置路径
-	configDir := filepath.Join(filepath.Dir(cfg.ModelsDir), "config")
-	configPath := filepath.Join(configDir, "model_persistent.json")
-	log.Printf("Persistent config location: %s", configPath)
+	slog.Info("Persistent config location", "file", filepath.Join(config.PersistentDir(cfg), config.ConfigFileName))
 
 	// 启动时恢复之前运行的模型
 	if err := modelService.RestoreModels(); err != nil {
-		log.Printf("Warning: Failed to restore models: %v", err)
+		slog.Warn("Failed to restore models", "error", err)
 	}
 
+	// 创建取消上下文，用于控制关闭流程
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 收到SIGHUP时重新加载配置，失败时保持当前配置
+	go func() {
+		for range d.ReloadRequested() {
+			if _, err := modelService.ReloadConfig(); err != nil {
+				slog.Error("Failed to reload configuration", "error", err)
+			}
+		}
+	}()
+
+	// 启动模型实例健康检查
+	modelService.StartHealthMonitor(ctx)
+
+	// 启动模型进程资源采样
+	modelService.StartResourceSampler(ctx)
+
+	// 启动运行状态校正
+	modelService.StartReconciler(ctx)
+
+	// 启动RPC池端点健康检查
+	modelService.StartRPCMonitor(ctx)
+
+	// 加载声明式模型定义，启动定义中和自动启动列表中的模型
+	modelService.StartModelDefinitions(ctx)
+
 	// 初始化基准测试服务
-	benchmarkService := service.NewBenchmarkService(cfg)
+	benchmarkService := service.NewBenchmarkService(cfg, modelService)
+
+	// 启动定时基准测试
+	benchmarkService.Schedules().Start(ctx)
+
+	// 启动已结束基准测试任务的清理
+	benchmarkService.StartTaskPruner(ctx)
 
 	// 创建处理器
 	h := handler.NewHandlerWithService(cfg, modelService, benchmarkService)
@@ -49,99 +119,461 @@ func main() {
 	// 设置带日志的路由
 	mux := http.NewServeMux()
 
-	// 请求日志中间件
+	// 请求日志中间件，为请求分配请求ID，同时按客户端地址过滤管理API请求、限制请求频率、记录修改类请求的审计日志、
+	// 按路由所需的角色检查API密钥、重放带幂等键的重试请求，并为已被v2替代的v1路由添加弃用提示
 	loggingMiddleware := func(next http.HandlerFunc) http.HandlerFunc {
+		next = h.DeprecateV1(h.IPFilter(h.RateLimit(h.Audit(h.Authorize(h.Idempotency(next))))))
 		return func(w http.ResponseWriter, r *http.Request) {
-			log.Printf("Incoming request: %s %s", r.Method, r.URL.Path)
+			r = logging.TagRequest(w, r)
+			logging.FromContext(r.Context()).Info("Incoming request", "method", r.Method, "path", r.URL.Path)
 			next(w, r)
 		}
 	}
 
+	// 功能发现路由
+	mux.HandleFunc("/api/v1/capabilities", loggingMiddleware(h.GetCapabilities))
+
+	// API文档路由
+	mux.HandleFunc("/api/v1/openapi.json", loggingMiddleware(h.GetOpenAPI))
+	mux.HandleFunc("/docs", loggingMiddleware(h.GetAPIDocs))
+
+	// 管理路由
+	mux.HandleFunc("/api/v1/admin/reload", loggingMiddleware(h.ReloadConfig))
+	mux.HandleFunc("/api/v1/config", loggingMiddleware(h.GetConfig))
+	mux.HandleFunc("/api/v1/config/env", loggingMiddleware(h.GetConfigEnv))
+	mux.HandleFunc("/api/v1/config/schema", loggingMiddleware(h.GetConfigSchema))
+	mux.HandleFunc("/api/v1/config/validate", loggingMiddleware(h.ValidateConfig))
+	mux.HandleFunc("/api/v1/config/defaults", loggingMiddleware(h.ConfigDefaults))
+	mux.HandleFunc("/api/v1/audit", loggingMiddleware(h.GetAudit))
+
 	// 模型服务相关路由
 	mux.HandleFunc("/api/v1/models", loggingMiddleware(h.GetModelList)) // 获取模型列表
 	mux.HandleFunc("/api/v1/model/switch", loggingMiddleware(h.SwitchModel))
 	mux.HandleFunc("/api/v1/model/stop", loggingMiddleware(h.StopModel))
+	mux.HandleFunc("/api/v1/model/definitions", loggingMiddleware(h.GetModelDefinitions))
+	mux.HandleFunc("/api/v1/model/{name}/logs", loggingMiddleware(h.GetModelLogs))
+	mux.HandleFunc("/api/v1/model/{name}/logs/stream", loggingMiddleware(h.StreamModelLogs))
+	mux.HandleFunc("/api/v1/model/{name}/resources", loggingMiddleware(h.GetModelResources))
+	mux.HandleFunc("/api/v1/model/{name}/vram/history", loggingMiddleware(h.GetModelVRAMHistory))
 	mux.HandleFunc("/api/v1/model/status", loggingMiddleware(h.GetModelStatus))
+	mux.HandleFunc("/api/v1/downloads", loggingMiddleware(h.GetDownloads))
+	mux.HandleFunc("/api/v1/operations/{id}", loggingMiddleware(h.GetOperation))
+	mux.HandleFunc("/api/v1/events", loggingMiddleware(h.GetEvents))
+	mux.HandleFunc("POST /api/v1/webhooks", loggingMiddleware(h.CreateWebhook))
+	mux.HandleFunc("GET /api/v1/webhooks", loggingMiddleware(h.ListWebhooks))
+	mux.HandleFunc("GET /api/v1/webhooks/{id}", loggingMiddleware(h.GetWebhook))
+	mux.HandleFunc("DELETE /api/v1/webhooks/{id}", loggingMiddleware(h.DeleteWebhook))
+	mux.HandleFunc("GET /api/v1/webhooks/{id}/deliveries", loggingMiddleware(h.GetWebhookDeliveries))
+	mux.HandleFunc("POST /api/v1/webhooks/{id}/test", loggingMiddleware(h.TestWebhook))
+	mux.HandleFunc("/api/v1/gpu", loggingMiddleware(h.GetGPUInventory))
+	mux.HandleFunc("/api/v1/rpc/pools", loggingMiddleware(h.GetRPCPools))
+	mux.HandleFunc("/api/v1/tenants", loggingMiddleware(h.GetTenants))
 
 	// 基准测试相关路由
 	mux.HandleFunc("/api/v1/benchmark", loggingMiddleware(h.StartBenchmark))
+	mux.HandleFunc("/api/v1/benchmark/serving", loggingMiddleware(h.StartServingBenchmark))
 	mux.HandleFunc("/api/v1/benchmark/status", loggingMiddleware(h.GetBenchmarkStatus))
+	mux.HandleFunc("/api/v1/benchmark/reproduce", loggingMiddleware(h.ReproduceBenchmark))
+	mux.HandleFunc("/api/v1/benchmark/history", loggingMiddleware(h.GetBenchmarkHistory))
+	mux.HandleFunc("/api/v1/benchmark/presets", loggingMiddleware(h.ListBenchmarkPresets))
+	mux.HandleFunc("/api/v1/benchmark/{task_id}", loggingMiddleware(h.DeleteBenchmark))
+	mux.HandleFunc("/api/v1/benchmark/{task_id}/export", loggingMiddleware(h.ExportBenchmark))
+	mux.HandleFunc("/api/v1/benchmark/{task_id}/output", loggingMiddleware(h.GetBenchmarkOutput))
+	mux.HandleFunc("/api/v1/benchmark/schedules", loggingMiddleware(h.ListBenchmarkSchedules))
+	mux.HandleFunc("/api/v1/benchmark/schedules/set", loggingMiddleware(h.SetBenchmarkSchedule))
+	mux.HandleFunc("/api/v1/benchmark/schedules/remove", loggingMiddleware(h.RemoveBenchmarkSchedule))
+	mux.HandleFunc("/api/v1/benchmark/baselines", loggingMiddleware(h.ListBenchmarkBaselines))
+	mux.HandleFunc("/api/v1/benchmark/baselines/set", loggingMiddleware(h.SetBenchmarkBaseline))
+	mux.HandleFunc("/api/v1/benchmark/baselines/remove", loggingMiddleware(h.RemoveBenchmarkBaseline))
+	mux.HandleFunc("/api/v1/benchmark/all", loggingMiddleware(h.StartBenchmarkSuite))
+	mux.HandleFunc("/api/v1/benchmark/all/status", loggingMiddleware(h.GetBenchmarkSuite))
+
+	// v2资源路由
+	mux.HandleFunc("GET /api/v2/models", loggingMiddleware(h.ListModelsV2))
+	mux.HandleFunc("GET /api/v2/models/{name}", loggingMiddleware(h.GetModelV2))
+	mux.HandleFunc("PUT /api/v2/models/{name}", loggingMiddleware(h.PutModelV2))
+	mux.HandleFunc("DELETE /api/v2/models/{name}", loggingMiddleware(h.DeleteModelV2))
+	mux.HandleFunc("GET /api/v2/benchmarks", loggingMiddleware(h.ListBenchmarksV2))
+	mux.HandleFunc("POST /api/v2/benchmarks", loggingMiddleware(h.CreateBenchmarkV2))
+	mux.HandleFunc("GET /api/v2/benchmarks/{id}", loggingMiddleware(h.GetBenchmarkV2))
+	mux.HandleFunc("DELETE /api/v2/benchmarks/{id}", loggingMiddleware(h.DeleteBenchmarkV2))
 
-	// 添加健康检查端点
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+	// 准确性冒烟测试相关路由
+	mux.HandleFunc("/api/v1/evals", loggingMiddleware(h.ListEvalSuites))
+	mux.HandleFunc("/api/v1/evals/set", loggingMiddleware(h.SetEvalSuite))
+	mux.HandleFunc("/api/v1/evals/remove", loggingMiddleware(h.RemoveEvalSuite))
+	mux.HandleFunc("/api/v1/evals/run", loggingMiddleware(h.RunEvalSuite))
+	mux.HandleFunc("/api/v1/evals/runs", loggingMiddleware(h.GetEvalRuns))
+
+	// 模型别名相关路由
+	mux.HandleFunc("/api/v1/aliases", loggingMiddleware(h.ListAliases))
+	mux.HandleFunc("/api/v1/aliases/set", loggingMiddleware(h.SetAlias))
+	mux.HandleFunc("/api/v1/aliases/remove", loggingMiddleware(h.RemoveAlias))
+	mux.HandleFunc("/api/v1/aliases/changelog", loggingMiddleware(h.GetAliasChangelog))
+
+	// 流量路由相关路由
+	mux.HandleFunc("/api/v1/routes", loggingMiddleware(h.SetRoute))
+	mux.HandleFunc("/api/v1/routes/status", loggingMiddleware(h.GetRoutes))
+	mux.HandleFunc("/api/v1/routes/remove", loggingMiddleware(h.RemoveRoute))
+
+	// 分时共享相关路由（实验性）
+	if cfg.TimeShare.Enabled {
+		mux.HandleFunc("/api/v1/timeshare", loggingMiddleware(h.RegisterTimeShare))
+		mux.HandleFunc("/api/v1/timeshare/status", loggingMiddleware(h.GetTimeShareStatus))
+		mux.HandleFunc("/api/v1/timeshare/swap", loggingMiddleware(h.SwapTimeShare))
+		mux.HandleFunc("/api/v1/timeshare/remove", loggingMiddleware(h.RemoveTimeShare))
+	}
+
+	// 公开状态页（无需认证，只读）
+	if cfg.StatusPage.Enabled {
+		mux.HandleFunc("/status", loggingMiddleware(h.GetPublicStatusPage))
+		mux.HandleFunc("/status.json", loggingMiddleware(h.GetPublicStatus))
+	}
+
+	// Prometheus指标（与管理API一样受地址过滤和API密钥保护）
+	if cfg.Metrics.Enabled {
+		modelService.RegisterMetrics(prometheus.DefaultRegisterer)
+		mux.HandleFunc("/metrics", loggingMiddleware(h.GetMetrics))
+	}
+
+	// 健康检查端点
+	health := func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
-	})
+	}
+	mux.HandleFunc("/health", health)
 
-	log.Println("Registered API endpoints:")
-	log.Println("GET    /api/v1/models") // 获取模型列表
-	log.Println("POST   /api/v1/model/switch")
-	log.Println("POST   /api/v1/model/stop")
-	log.Println("GET    /api/v1/model/status")
-	log.Println("POST   /api/v1/benchmark")
-	log.Println("GET    /api/v1/benchmark/status")
-	log.Println("GET    /health")
+	// 推理代理路由，配置了PROXY_PORT时在单独的地址上监听（例如代理监听0.0.0.0，管理API只监听127.0.0.1）
+	var proxyServer *http.Server
+	if cfg.Proxy.Enabled {
+		p := proxy.NewProxy(cfg, modelService)
+		if cfg.Proxy.Port != 0 {
+			proxyMux := http.NewServeMux()
+			proxyMux.HandleFunc("/v1/", loggingMiddleware(p.ServeHTTP))
+			proxyMux.HandleFunc("/health", health)
+			proxyHost := cfg.Proxy.Host
+			if proxyHost == "" {
+				proxyHost = cfg.Server.Host
+			}
+			proxyServer = &http.Server{
+				Addr:    fmt.Sprintf("%s:%d", proxyHost, cfg.Proxy.Port),
+				Handler: corsMiddleware(cfg, proxyMux),
+			}
+		} else {
+			mux.HandleFunc("/v1/", loggingMiddleware(p.ServeHTTP))
+		}
+	}
+
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/capabilities")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/openapi.json")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/docs")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/admin/reload")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/config")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/config/env")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/config/schema")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/config/validate")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/config/defaults")
+	slog.Debug("Registered API endpoint", "method", "PATCH", "path", "/api/v1/config/defaults")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/audit")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/models") // 获取模型列表
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/model/switch")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/model/stop")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/model/definitions")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/model/{name}/logs")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/model/{name}/logs/stream")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/model/{name}/resources")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/model/{name}/vram/history")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/model/status")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/downloads")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/operations/{id}")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/events")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/webhooks")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/webhooks")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/webhooks/{id}")
+	slog.Debug("Registered API endpoint", "method", "DELETE", "path", "/api/v1/webhooks/{id}")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/webhooks/{id}/deliveries")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/webhooks/{id}/test")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/gpu")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/rpc/pools")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/tenants")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/benchmark")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/benchmark/serving")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/benchmark/status")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/benchmark/reproduce")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/benchmark/history")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/benchmark/presets")
+	slog.Debug("Registered API endpoint", "method", "DELETE", "path", "/api/v1/benchmark/{task_id}")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/benchmark/{task_id}/export")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/benchmark/{task_id}/output")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/benchmark/schedules")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/benchmark/schedules/set")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/benchmark/schedules/remove")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/benchmark/baselines")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/benchmark/baselines/set")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/benchmark/baselines/remove")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/benchmark/all")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/benchmark/all/status")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v2/models")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v2/models/{name}")
+	slog.Debug("Registered API endpoint", "method", "PUT", "path", "/api/v2/models/{name}")
+	slog.Debug("Registered API endpoint", "method", "DELETE", "path", "/api/v2/models/{name}")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v2/benchmarks")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v2/benchmarks")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v2/benchmarks/{id}")
+	slog.Debug("Registered API endpoint", "method", "DELETE", "path", "/api/v2/benchmarks/{id}")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/evals")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/evals/set")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/evals/remove")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/evals/run")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/evals/runs")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/aliases")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/aliases/set")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/aliases/remove")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/aliases/changelog")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/routes")
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/routes/status")
+	slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/routes/remove")
+	if cfg.TimeShare.Enabled {
+		slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/timeshare")
+		slog.Debug("Registered API endpoint", "method", "GET", "path", "/api/v1/timeshare/status")
+		slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/timeshare/swap")
+		slog.Debug("Registered API endpoint", "method", "POST", "path", "/api/v1/timeshare/remove")
+	}
+	if cfg.StatusPage.Enabled {
+		slog.Debug("Registered API endpoint", "method", "GET", "path", "/status")
+		slog.Debug("Registered API endpoint", "method", "GET", "path", "/status.json")
+	}
+	if cfg.Metrics.Enabled {
+		slog.Debug("Registered API endpoint", "method", "GET", "path", "/metrics")
+	}
+	if cfg.Proxy.Enabled && proxyServer == nil {
+		slog.Debug("Registered API endpoint", "method", "ANY", "path", "/v1/*")
+	}
+	slog.Debug("Registered API endpoint", "method", "GET", "path", "/health")
 
 	// 创建服务器
 	server := &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-		Handler: mux,
+		Handler: corsMiddleware(cfg, mux),
 	}
 
-	// 创建取消上下文，用于控制关闭流程
-	_, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// 配置了证书时使用HTTPS，证书文件修改后自动重新加载
+	var redirectServer *http.Server
+	if cfg.Security.SSLCert != "" {
+		certs, err := tlsserver.Load(cfg.Security.SSLCert, cfg.Security.SSLKey, cfg.Security.SSLClientCA)
+		if err != nil {
+			logging.Fatal("Failed to load TLS certificate", "error", err)
+		}
+		server.TLSConfig = certs.TLSConfig()
+		if proxyServer != nil {
+			proxyServer.TLSConfig = server.TLSConfig
+		}
+		if certs.ClientAuth() {
+			slog.Info("Client certificates required", "client_ca", cfg.Security.SSLClientCA)
+		}
+		if cfg.Security.RedirectPort != 0 {
+			redirectServer = &http.Server{
+				Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Security.RedirectPort),
+				Handler: tlsserver.RedirectHandler(cfg.Server.Port),
+			}
+		}
+	}
 
-	// 打印配置信息
-	log.Print(cfg.String())
+	// 打印配置信息（完整配置只在debug级别输出）
+	slog.Info("Configuration loaded", "file", cfg.File, "profile", cfg.Profile, "env_file", cfg.EnvFile, "log_level", cfg.Log.Level, "log_format", cfg.Log.Format)
+	slog.Debug("Configuration", "config", cfg.String())
 
-	// 设置优雅关闭
+	// 关闭开始时结束实时日志流，它们不会自行结束
+	server.RegisterOnShutdown(h.CloseStreams)
+
+	// 设置优雅关闭：收到中断信号或服务管理器的停止请求时，先等待进行中的请求完成，再停止所有模型
+	shutdownDone := make(chan struct{})
 	go func() {
-		sigChan := make(chan os.Signal, 1)
-		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-		<-sigChan
+		<-d.StopRequested()
+		d.Stopping()
 
-		log.Println("Initiating graceful shutdown...")
+		slog.Info("Initiating graceful shutdown")
 
 		// 取消上下文
 		cancel()
 
-		// 停止模型服务
-		if _, err := h.ModelService.StopAllModel(); err != nil {
-			log.Printf("Error stopping model service: %v\n", err)
+		// 停止接受新连接，等待进行中的请求（包括代理的流式响应）完成，超时后断开剩余的连接
+		drainTimeout := time.Duration(cfg.Server.ShutdownTimeout) * time.Second
+		slog.Info("Waiting for in-flight requests to finish", "timeout", drainTimeout)
+		drainCtx, drainCancel := context.WithTimeout(context.Background(), drainTimeout)
+		var drained sync.WaitGroup
+		for _, s := range []*http.Server{server, proxyServer, redirectServer} {
+			if s == nil {
+				continue
+			}
+			drained.Add(1)
+			go func() {
+				defer drained.Done()
+				if err := s.Shutdown(drainCtx); err != nil {
+					slog.Warn("In-flight requests did not finish in time, closing connections", "addr", s.Addr, "error", err)
+					s.Close()
+				}
+			}()
 		}
+		drained.Wait()
+		drainCancel()
 
-		// 清理基准测试服务
+		// 清理基准测试服务（先于模型停止，以便继续执行被暂停的模型后正常退出）
 		h.BenchmarkService.Cleanup()
 
-		// 关闭HTTP服务器
-		if err := server.Close(); err != nil {
-			log.Printf("Error during server shutdown: %v\n", err)
+		// 停止模型服务
+		if _, err := h.ModelService.StopAllModel(); err != nil {
+			slog.Error("Error stopping model service", "error", err)
 		}
 
-		log.Println("Server shutdown completed")
+		slog.Info("Server shutdown completed")
+		close(shutdownDone)
 	}()
 
 	// 打印注册的路由
-	log.Println("Registered routes:")
 	for _, route := range []struct {
 		path    string
 		handler string
 	}{
+		{"/api/v1/capabilities", "GetCapabilities"},
+		{"/api/v1/openapi.json", "GetOpenAPI"},
+		{"/docs", "GetAPIDocs"},
+		{"/api/v1/admin/reload", "ReloadConfig"},
+		{"/api/v1/config", "GetConfig"},
+		{"/api/v1/config/env", "GetConfigEnv"},
+		{"/api/v1/config/schema", "GetConfigSchema"},
+		{"/api/v1/config/validate", "ValidateConfig"},
+		{"/api/v1/config/defaults", "ConfigDefaults"},
+		{"/api/v1/audit", "GetAudit"},
 		{"/api/v1/models", "GetModelList"},
 		{"/api/v1/model/switch", "SwitchModel"},
 		{"/api/v1/model/stop", "StopModel"},
+		{"/api/v1/model/definitions", "GetModelDefinitions"},
 		{"/api/v1/model/status", "GetModelStatus"},
+		{"/api/v1/operations/{id}", "GetOperation"},
+		{"/api/v1/webhooks", "CreateWebhook, ListWebhooks"},
+		{"/api/v1/webhooks/{id}", "GetWebhook, DeleteWebhook"},
+		{"/api/v1/webhooks/{id}/deliveries", "GetWebhookDeliveries"},
+		{"/api/v1/webhooks/{id}/test", "TestWebhook"},
 		{"/api/v1/benchmark", "StartBenchmark"},
+		{"/api/v1/benchmark/serving", "StartServingBenchmark"},
 		{"/api/v1/benchmark/status", "GetBenchmarkStatus"},
+		{"/api/v1/benchmark/reproduce", "ReproduceBenchmark"},
+		{"/api/v1/benchmark/history", "GetBenchmarkHistory"},
+		{"/api/v1/benchmark/presets", "ListBenchmarkPresets"},
+		{"/api/v1/benchmark/{task_id}", "DeleteBenchmark"},
+		{"/api/v1/benchmark/{task_id}/export", "ExportBenchmark"},
+		{"/api/v1/benchmark/{task_id}/output", "GetBenchmarkOutput"},
+		{"/api/v1/benchmark/schedules", "ListBenchmarkSchedules"},
+		{"/api/v1/benchmark/schedules/set", "SetBenchmarkSchedule"},
+		{"/api/v1/benchmark/schedules/remove", "RemoveBenchmarkSchedule"},
+		{"/api/v1/benchmark/baselines", "ListBenchmarkBaselines"},
+		{"/api/v1/benchmark/baselines/set", "SetBenchmarkBaseline"},
+		{"/api/v1/benchmark/baselines/remove", "RemoveBenchmarkBaseline"},
+		{"/api/v1/benchmark/all", "StartBenchmarkSuite"},
+		{"/api/v1/benchmark/all/status", "GetBenchmarkSuite"},
+		{"/api/v2/models", "ListModelsV2"},
+		{"/api/v2/models/{name}", "GetModelV2, PutModelV2, DeleteModelV2"},
+		{"/api/v2/benchmarks", "ListBenchmarksV2, CreateBenchmarkV2"},
+		{"/api/v2/benchmarks/{id}", "GetBenchmarkV2, DeleteBenchmarkV2"},
 	} {
-		log.Printf("  %-25s -> %s\n", route.path, route.handler)
+		slog.Debug("Registered route", "path", route.path, "handler", route.handler)
 	}
 
 	// 启动服务器
-	log.Printf("Server starting on %s:%d\n", cfg.Server.Host, cfg.Server.Port)
-	if err := server.ListenAndServe(); err != http.ErrServerClosed {
-		log.Printf("Server error: %v\n", err)
+	scheme := "http"
+	if server.TLSConfig != nil {
+		scheme = "https"
+	}
+	slog.Info("Server starting", "url", fmt.Sprintf("%s://%s:%d", scheme, cfg.Server.Host, cfg.Server.Port))
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		slog.Error("Server error", "error", err)
+		cancel()
+		d.Done()
+		return
+	}
+
+	// 推理代理的单独监听地址，监听失败时与管理API一样退出
+	if proxyServer != nil {
+		proxyListener, err := net.Listen("tcp", proxyServer.Addr)
+		if err != nil {
+			slog.Error("Proxy server error", "error", err)
+			cancel()
+			d.Done()
+			return
+		}
+		slog.Info("Inference proxy listening", "url", fmt.Sprintf("%s://%s/v1/", scheme, proxyServer.Addr))
+		go func() {
+			var err error
+			if proxyServer.TLSConfig != nil {
+				err = proxyServer.ServeTLS(proxyListener, "", "")
+			} else {
+				err = proxyServer.Serve(proxyListener)
+			}
+			if err != http.ErrServerClosed {
+				slog.Error("Proxy server error", "error", err)
+			}
+		}()
+	}
+
+	// HTTP到HTTPS的重定向，监听失败不影响主服务器
+	if redirectServer != nil {
+		slog.Info("Redirecting HTTP to HTTPS", "addr", redirectServer.Addr)
+		go func() {
+			if err := redirectServer.ListenAndServe(); err != http.ErrServerClosed {
+				slog.Error("HTTP redirect server error", "error", err)
+			}
+		}()
+	}
+
+	// 开始监听后通知服务管理器已就绪
+	d.Ready()
+	serve := server.Serve
+	if server.TLSConfig != nil {
+		serve = func(l net.Listener) error { return server.ServeTLS(l, "", "") }
+	}
+	if err := serve(listener); err != http.ErrServerClosed {
+		slog.Error("Server error", "error", err)
 		cancel() // 确保在服务器错误时也能触发清理
+	} else {
+		<-shutdownDone
 	}
+	d.Done()
+}
+
+// corsMiddleware 跨域请求中间件：来源在CORS_ALLOWED_ORIGINS中时添加CORS响应头，
+// 并直接响应浏览器的预检请求（OPTIONS），预检请求不携带API密钥，因此在认证之前处理
+func corsMiddleware(cfg *config.Config, next http.Handler) http.Handler {
+	if len(cfg.CORS.AllowedOrigins) == 0 {
+		return next
+	}
+	anyOrigin := slices.Contains(cfg.CORS.AllowedOrigins, "*")
+	methods := strings.Join(cfg.CORS.AllowedMethods, ", ")
+	headers := strings.Join(cfg.CORS.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.CORS.MaxAge))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+		allowed := origin != "" && (anyOrigin || slices.ContainsFunc(cfg.CORS.AllowedOrigins, func(o string) bool {
+			return strings.EqualFold(strings.TrimSuffix(o, "/"), origin)
+		}))
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			// 允许网页读取限流、v2资源位置和弃用提示相关的响应头
+			w.Header().Set("Access-Control-Expose-Headers", "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After, Location, Deprecation, Link, Idempotent-Replayed, X-Total-Count, X-Request-ID")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if allowed {
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
 }
@@ -0,0 +1,221 @@
+// Package apierror 定义API错误码：服务层返回带错误码的错误，处理器据此选择HTTP状态码并生成
+// RFC 7807格式（application/problem+json）的错误响应，客户端可以按错误码而不是错误信息处理失败
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"llama-switch/internal/model"
+)
+
+// Code 错误码，写在错误响应的code字段中，取值保持稳定
+type Code string
+
+// 具体的错误码
+const (
+	CodeModelNotFound        Code = "MODEL_NOT_FOUND"        // 模型未运行或不存在
+	CodeModelFileNotFound    Code = "MODEL_FILE_NOT_FOUND"   // 模型文件不存在
+	CodeModelAlreadyRunning  Code = "MODEL_ALREADY_RUNNING"  // 同名模型已在运行
+	CodeModelBusy            Code = "MODEL_BUSY"             // 模型正在使用，拒绝停止或驱逐
+	CodeModelDraining        Code = "MODEL_DRAINING"         // 模型正在排空，不再接受新请求
+	CodeModelStartupFailed   Code = "MODEL_STARTUP_FAILED"   // 模型进程启动后未能就绪
+	CodeInsufficientVRAM     Code = "INSUFFICIENT_VRAM"      // 显存不足
+	CodeInsufficientRAM      Code = "INSUFFICIENT_RAM"       // 主机内存不足
+	CodeQuotaExceeded        Code = "QUOTA_EXCEEDED"         // 超出租户的显存配额
+	CodePortInUse            Code = "PORT_IN_USE"            // 端口已被占用或没有可用端口
+	CodeBinaryMissing        Code = "BINARY_MISSING"         // llama-server或llama-bench不存在
+	CodeConcurrencyLimit     Code = "CONCURRENCY_LIMIT"      // 模型达到并发上限
+	CodeBenchmarkNotFound    Code = "BENCHMARK_NOT_FOUND"    // 基准测试任务不存在
+	CodeBenchmarkConflict    Code = "BENCHMARK_CONFLICT"     // 相同GPU上已有基准测试
+	CodeBenchmarkActive      Code = "BENCHMARK_ACTIVE"       // 基准测试仍在排队或运行
+	CodeBenchmarkNotFinished Code = "BENCHMARK_NOT_FINISHED" // 基准测试尚未结束
+	CodeOperationNotFound    Code = "OPERATION_NOT_FOUND"    // 异步操作不存在或已被清理
+	CodeWebhookNotFound      Code = "WEBHOOK_NOT_FOUND"      // 事件订阅不存在
+	CodeInvalidAPIKey        Code = "INVALID_API_KEY"        // 缺少API密钥或密钥无效
+	CodeRateLimited          Code = "RATE_LIMITED"           // 超过请求频率限制
+	CodeIdempotencyKeyReused Code = "IDEMPOTENCY_KEY_REUSED" // 幂等键已用于内容不同的请求
+)
+
+// 没有具体错误码时按HTTP状态码使用的通用错误码
+const (
+	CodeInvalidRequest   Code = "INVALID_REQUEST"
+	CodeUnauthorized     Code = "UNAUTHORIZED"
+	CodeForbidden        Code = "FORBIDDEN"
+	CodeNotFound         Code = "NOT_FOUND"
+	CodeMethodNotAllowed Code = "METHOD_NOT_ALLOWED"
+	CodeRequestTimeout   Code = "REQUEST_TIMEOUT"
+	CodeConflict         Code = "CONFLICT"
+	CodePayloadTooLarge  Code = "PAYLOAD_TOO_LARGE"
+	CodeInvalidConfig    Code = "INVALID_CONFIG"
+	CodeInternal         Code = "INTERNAL_ERROR"
+	CodeUpstream         Code = "UPSTREAM_ERROR"
+	CodeUnavailable      Code = "SERVICE_UNAVAILABLE"
+)
+
+// codeInfo 错误码的标题和HTTP状态码
+type codeInfo struct {
+	title  string
+	status int
+}
+
+// codes 全部错误码
+var codes = map[Code]codeInfo{
+	CodeModelNotFound:        {"Model not found", http.StatusNotFound},
+	CodeModelFileNotFound:    {"Model file not found", http.StatusNotFound},
+	CodeModelAlreadyRunning:  {"Model already running", http.StatusConflict},
+	CodeModelBusy:            {"Model in use", http.StatusConflict},
+	CodeModelDraining:        {"Model draining", http.StatusServiceUnavailable},
+	CodeModelStartupFailed:   {"Model failed to start", http.StatusBadGateway},
+	CodeInsufficientVRAM:     {"Insufficient VRAM", http.StatusConflict},
+	CodeInsufficientRAM:      {"Insufficient RAM", http.StatusConflict},
+	CodeQuotaExceeded:        {"Tenant quota exceeded", http.StatusForbidden},
+	CodePortInUse:            {"Port in use", http.StatusConflict},
+	CodeBinaryMissing:        {"llama.cpp binary missing", http.StatusInternalServerError},
+	CodeConcurrencyLimit:     {"Concurrency limit reached", http.StatusTooManyRequests},
+	CodeBenchmarkNotFound:    {"Benchmark task not found", http.StatusNotFound},
+	CodeBenchmarkConflict:    {"Benchmark already running on these GPUs", http.StatusConflict},
+	CodeBenchmarkActive:      {"Benchmark task still active", http.StatusConflict},
+	CodeBenchmarkNotFinished: {"Benchmark task not finished", http.StatusConflict},
+	CodeOperationNotFound:    {"Operation not found", http.StatusNotFound},
+	CodeWebhookNotFound:      {"Webhook not found", http.StatusNotFound},
+	CodeInvalidAPIKey:        {"Invalid API key", http.StatusUnauthorized},
+	CodeRateLimited:          {"Rate limit exceeded", http.StatusTooManyRequests},
+	CodeIdempotencyKeyReused: {"Idempotency key reused", http.StatusUnprocessableEntity},
+
+	CodeInvalidRequest:   {"Invalid request", http.StatusBadRequest},
+	CodeUnauthorized:     {"Unauthorized", http.StatusUnauthorized},
+	CodeForbidden:        {"Forbidden", http.StatusForbidden},
+	CodeNotFound:         {"Not found", http.StatusNotFound},
+	CodeMethodNotAllowed: {"Method not allowed", http.StatusMethodNotAllowed},
+	CodeRequestTimeout:   {"Request timeout", http.StatusRequestTimeout},
+	CodeConflict:         {"Conflict", http.StatusConflict},
+	CodePayloadTooLarge:  {"Payload too large", http.StatusRequestEntityTooLarge},
+	CodeInvalidConfig:    {"Invalid configuration", http.StatusUnprocessableEntity},
+	CodeInternal:         {"Internal error", http.StatusInternalServerError},
+	CodeUpstream:         {"Upstream error", http.StatusBadGateway},
+	CodeUnavailable:      {"Service unavailable", http.StatusServiceUnavailable},
+}
+
+// statusCodes 各HTTP状态码对应的通用错误码
+var statusCodes = map[int]Code{
+	http.StatusBadRequest:            CodeInvalidRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusRequestTimeout:        CodeRequestTimeout,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusUnprocessableEntity:   CodeInvalidConfig,
+	http.StatusTooManyRequests:       CodeRateLimited,
+	http.StatusBadGateway:            CodeUpstream,
+	http.StatusServiceUnavailable:    CodeUnavailable,
+}
+
+// Status 错误码对应的HTTP状态码，未知错误码为500
+func (c Code) Status() int {
+	if info, ok := codes[c]; ok {
+		return info.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Title 错误码的简短说明，写在错误响应的title字段中
+func (c Code) Title() string {
+	if info, ok := codes[c]; ok {
+		return info.title
+	}
+	return http.StatusText(c.Status())
+}
+
+// Type 错误类型的URI，写在错误响应的type字段中
+func (c Code) Type() string {
+	return "urn:llama-switch:error:" + string(c)
+}
+
+// ForStatus 没有具体错误码时按HTTP状态码使用的通用错误码，其他状态码使用INTERNAL_ERROR
+func ForStatus(status int) Code {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	return CodeInternal
+}
+
+// Codes 全部错误码，按名称排序
+func Codes() []Code {
+	all := make([]Code, 0, len(codes))
+	for code := range codes {
+		all = append(all, code)
+	}
+	slices.Sort(all)
+	return all
+}
+
+// Coder 携带错误码的错误，服务层的ModelBusyError等错误类型实现此接口
+type Coder interface {
+	ErrorCode() Code
+}
+
+// Error 带错误码的错误，错误信息与被包装的错误相同
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string   { return e.Err.Error() }
+func (e *Error) Unwrap() error   { return e.Err }
+func (e *Error) ErrorCode() Code { return e.Code }
+
+// New 创建带错误码的错误，format和args与fmt.Errorf相同
+func New(code Code, format string, args ...interface{}) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// CodeOf 获取错误链中的错误码，没有时返回空
+func CodeOf(err error) Code {
+	var coder Coder
+	if errors.As(err, &coder) {
+		return coder.ErrorCode()
+	}
+	return ""
+}
+
+// NewProblem 创建错误响应，status为0时使用错误码的HTTP状态码
+func NewProblem(code Code, status int, message string, data interface{}) *model.Problem {
+	if status == 0 {
+		status = code.Status()
+	}
+	return &model.Problem{
+		Type:    code.Type(),
+		Title:   code.Title(),
+		Status:  status,
+		Detail:  message,
+		Code:    string(code),
+		Success: false,
+		Message: message,
+		Error:   message,
+		Data:    data,
+	}
+}
+
+// FromError 由服务层返回的错误创建错误响应：错误带有错误码时使用错误码的HTTP状态码，
+// 否则使用status和对应的通用错误码
+func FromError(err error, status int) *model.Problem {
+	code := CodeOf(err)
+	if code == "" {
+		return NewProblem(ForStatus(status), status, err.Error(), nil)
+	}
+	return NewProblem(code, 0, err.Error(), nil)
+}
+
+// Write 以application/problem+json返回错误响应
+func Write(w http.ResponseWriter, problem *model.Problem) {
+	data, _ := json.Marshal(problem)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	w.Write(data)
+}
@@ -0,0 +1,79 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type busyError struct{}
+
+func (busyError) Error() string   { return "model busy" }
+func (busyError) ErrorCode() Code { return CodeModelBusy }
+
+func TestCodeOf(t *testing.T) {
+	err := New(CodeInsufficientVRAM, "need %d MB", 100)
+	if err.Error() != "need 100 MB" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	// 错误码在包装后仍可获取
+	if code := CodeOf(fmt.Errorf("Failed to start model: %w", err)); code != CodeInsufficientVRAM {
+		t.Errorf("wrapped code = %q", code)
+	}
+	if code := CodeOf(fmt.Errorf("switch: %w", busyError{})); code != CodeModelBusy {
+		t.Errorf("Coder code = %q", code)
+	}
+	if code := CodeOf(errors.New("plain")); code != "" {
+		t.Errorf("plain error code = %q", code)
+	}
+}
+
+func TestFromError(t *testing.T) {
+	// 带错误码时使用错误码的状态码
+	p := FromError(fmt.Errorf("start: %w", New(CodePortInUse, "port 8080 in use")), http.StatusInternalServerError)
+	if p.Status != http.StatusConflict || p.Code != "PORT_IN_USE" || p.Detail != "start: port 8080 in use" || p.Error != p.Detail {
+		t.Errorf("coded problem = %+v", p)
+	}
+	// 没有错误码时使用给定状态码和通用错误码
+	p = FromError(errors.New("bad json"), http.StatusBadRequest)
+	if p.Status != http.StatusBadRequest || p.Code != "INVALID_REQUEST" {
+		t.Errorf("generic problem = %+v", p)
+	}
+	if p = FromError(errors.New("boom"), http.StatusInternalServerError); p.Code != "INTERNAL_ERROR" {
+		t.Errorf("internal problem code = %q", p.Code)
+	}
+}
+
+func TestCodes(t *testing.T) {
+	for _, code := range Codes() {
+		if code.Title() == "" || code.Status() < 400 {
+			t.Errorf("%s: title %q status %d", code, code.Title(), code.Status())
+		}
+	}
+	for status, code := range statusCodes {
+		if code.Status() != status {
+			t.Errorf("ForStatus(%d) = %s with status %d", status, code, code.Status())
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Write(rec, NewProblem(CodeModelNotFound, 0, "model x not found", nil))
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("status %d content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["type"] != "urn:llama-switch:error:MODEL_NOT_FOUND" || body["success"] != false || body["status"] != float64(404) {
+		t.Errorf("body = %v", body)
+	}
+	if _, ok := body["data"]; ok {
+		t.Error("data should be omitted when empty")
+	}
+}
@@ -0,0 +1,102 @@
+// Package auth 定义API密钥的角色（只读、操作员、管理员）和各API路由所需的最低角色
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Role API密钥的角色，权限依次增加，高级角色包含低级角色的全部权限
+type Role int
+
+const (
+	RoleNone     Role = iota // 无需认证
+	RoleReadOnly             // 只读：查看状态、列表、配置和日志
+	RoleOperator             // 操作员：另外可以切换和停止模型、运行基准测试和冒烟测试
+	RoleAdmin                // 管理员：另外可以修改配置、别名、路由、定时任务和删除基准测试记录
+)
+
+// roleNames 角色在配置和API中的名称
+var roleNames = map[Role]string{
+	RoleNone:     "none",
+	RoleReadOnly: "readonly",
+	RoleOperator: "operator",
+	RoleAdmin:    "admin",
+}
+
+// String 角色名称
+func (r Role) String() string {
+	if name, ok := roleNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("role(%d)", int(r))
+}
+
+// Allows 检查角色是否满足required
+func (r Role) Allows(required Role) bool {
+	return r >= required
+}
+
+// ParseRole 解析角色名称（admin、operator、readonly，不区分大小写，也接受read-only）
+func ParseRole(name string) (Role, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "admin":
+		return RoleAdmin, nil
+	case "operator":
+		return RoleOperator, nil
+	case "readonly", "read-only":
+		return RoleReadOnly, nil
+	}
+	return RoleNone, fmt.Errorf("unknown role %q (expected admin, operator or readonly)", name)
+}
+
+// routeRoles 不使用默认角色的路由，按"方法 路由模式"索引
+var routeRoles = map[string]Role{
+	// API文档不包含任何部署信息，供生成客户端和文档页面使用
+	"GET /api/v1/openapi.json": RoleNone,
+
+	// Prometheus指标包含模型名称和GPU状态，与状态查询接口相同
+	"GET /metrics":  RoleReadOnly,
+	"HEAD /metrics": RoleReadOnly,
+
+	// 审计日志包含调用方身份和请求内容
+	"GET /api/v1/audit": RoleAdmin,
+
+	// 订阅地址中可能包含令牌
+	"GET /api/v1/webhooks":                 RoleAdmin,
+	"GET /api/v1/webhooks/{id}":            RoleAdmin,
+	"GET /api/v1/webhooks/{id}/deliveries": RoleAdmin,
+
+	// 不修改任何状态的POST请求
+	"POST /api/v1/config/validate": RoleReadOnly,
+
+	// 切换和停止模型、运行测试
+	"POST /api/v1/model/switch":        RoleOperator,
+	"POST /api/v1/model/stop":          RoleOperator,
+	"POST /api/v1/benchmark":           RoleOperator,
+	"POST /api/v1/benchmark/serving":   RoleOperator,
+	"POST /api/v1/benchmark/reproduce": RoleOperator,
+	"POST /api/v1/benchmark/all":       RoleOperator,
+	"POST /api/v1/evals/run":           RoleOperator,
+	"POST /api/v1/timeshare/swap":      RoleOperator,
+	"PUT /api/v2/models/{name}":        RoleOperator,
+	"DELETE /api/v2/models/{name}":     RoleOperator,
+	"POST /api/v2/benchmarks":          RoleOperator,
+}
+
+// Required 获取请求所需的最低角色，pattern为匹配到的路由模式（http.Request.Pattern）
+// 除routeRoles列出的/metrics外，/api/以外的路由（推理代理、公开状态页、健康检查）无需认证；API路由中
+// 未在routeRoles列出的GET和HEAD请求需要只读角色，其他请求需要管理员角色，新增的修改类路由默认只有管理员可以访问
+func Required(method, pattern string) Role {
+	if role, ok := routeRoles[method+" "+pattern]; ok {
+		return role
+	}
+	if !strings.HasPrefix(pattern, "/api/") {
+		return RoleNone
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return RoleReadOnly
+	}
+	return RoleAdmin
+}
@@ -0,0 +1,69 @@
+package auth
+
+import "testing"
+
+func TestParseRole(t *testing.T) {
+	tests := []struct {
+		name string
+		want Role
+		ok   bool
+	}{
+		{"admin", RoleAdmin, true},
+		{"Operator", RoleOperator, true},
+		{"readonly", RoleReadOnly, true},
+		{"read-only", RoleReadOnly, true},
+		{"root", RoleNone, false},
+		{"", RoleNone, false},
+	}
+	for _, tt := range tests {
+		role, err := ParseRole(tt.name)
+		if role != tt.want || (err == nil) != tt.ok {
+			t.Errorf("ParseRole(%q) = %v, %v; want %v", tt.name, role, err, tt.want)
+		}
+	}
+}
+
+func TestRequired(t *testing.T) {
+	tests := []struct {
+		method, pattern string
+		want            Role
+	}{
+		{"GET", "/api/v1/model/status", RoleReadOnly},
+		{"GET", "/api/v1/models", RoleReadOnly},
+		{"GET", "/api/v1/config", RoleReadOnly},
+		{"GET", "/api/v1/model/{name}/logs", RoleReadOnly},
+		{"HEAD", "/api/v1/events", RoleReadOnly},
+		{"POST", "/api/v1/config/validate", RoleReadOnly},
+		{"POST", "/api/v1/model/switch", RoleOperator},
+		{"POST", "/api/v1/model/stop", RoleOperator},
+		{"POST", "/api/v1/benchmark", RoleOperator},
+		{"POST", "/api/v1/evals/run", RoleOperator},
+		{"POST", "/api/v1/admin/reload", RoleAdmin},
+		{"PATCH", "/api/v1/config/defaults", RoleAdmin},
+		{"GET", "/api/v1/config/defaults", RoleReadOnly},
+		{"DELETE", "/api/v1/benchmark/{task_id}", RoleAdmin},
+		{"POST", "/api/v1/aliases/set", RoleAdmin},
+		{"POST", "/api/v1/routes", RoleAdmin},
+		{"GET", "/api/v1/openapi.json", RoleNone},
+		{"GET", "/api/v2/models/{name}", RoleReadOnly},
+		{"PUT", "/api/v2/models/{name}", RoleOperator},
+		{"DELETE", "/api/v2/models/{name}", RoleOperator},
+		{"POST", "/api/v2/benchmarks", RoleOperator},
+		{"DELETE", "/api/v2/benchmarks/{id}", RoleAdmin},
+		{"GET", "/api/v1/webhooks/{id}", RoleAdmin},
+		{"POST", "/api/v1/webhooks", RoleAdmin},
+		{"POST", "/v1/", RoleNone},
+		{"GET", "/status.json", RoleNone},
+		{"GET", "/metrics", RoleReadOnly},
+		{"GET", "", RoleNone},
+	}
+	for _, tt := range tests {
+		if got := Required(tt.method, tt.pattern); got != tt.want {
+			t.Errorf("Required(%s %s) = %v, want %v", tt.method, tt.pattern, got, tt.want)
+		}
+	}
+
+	if !RoleAdmin.Allows(RoleOperator) || !RoleOperator.Allows(RoleReadOnly) || RoleReadOnly.Allows(RoleOperator) || RoleOperator.Allows(RoleAdmin) {
+		t.Error("role ordering is wrong")
+	}
+}
@@ -0,0 +1,372 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // RS256、PS256、ES256使用的哈希
+	_ "crypto/sha512" // RS384/512、PS384/512、ES384/512使用的哈希
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWKS缓存：定期重新获取，遇到未知的kid时提前重新获取（身份提供方轮换了密钥），但不早于jwksMinRefresh
+const (
+	jwksMaxAge     = time.Hour
+	jwksMinRefresh = time.Minute
+	jwksTimeout    = 10 * time.Second
+)
+
+// JWTOptions 外部身份提供方签发的JWT的验证参数
+type JWTOptions struct {
+	Issuer    string        // 必须与iss声明一致
+	Audience  string        // 必须包含在aud声明中
+	JWKSURL   string        // 签名公钥的JWKS地址，为空时从Issuer的OIDC发现文档（/.well-known/openid-configuration）获取
+	ClockSkew time.Duration // 检查exp和nbf时允许的时钟偏差
+	Client    *http.Client  // 获取JWKS使用的客户端，nil时使用10秒超时的客户端
+}
+
+// Claims JWT的声明
+type Claims map[string]interface{}
+
+// Subject sub声明
+func (c Claims) Subject() string {
+	sub, _ := c["sub"].(string)
+	return sub
+}
+
+// Strings 获取声明的字符串值：name可用点号访问嵌套对象（如Keycloak的realm_access.roles），
+// 值可以是字符串数组、字符串或空格分隔的字符串（如scope）
+func (c Claims) Strings(name string) []string {
+	var value interface{} = map[string]interface{}(c)
+	for _, part := range strings.Split(name, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[part]
+	}
+	switch value := value.(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// jwtAlgorithm 支持的签名算法
+type jwtAlgorithm struct {
+	hash crypto.Hash
+	kind string // RSA、PSS或EC
+}
+
+// jwtAlgorithms 支持的签名算法，只接受身份提供方的非对称签名，拒绝none和HS*
+var jwtAlgorithms = map[string]jwtAlgorithm{
+	"RS256": {crypto.SHA256, "RSA"},
+	"RS384": {crypto.SHA384, "RSA"},
+	"RS512": {crypto.SHA512, "RSA"},
+	"PS256": {crypto.SHA256, "PSS"},
+	"PS384": {crypto.SHA384, "PSS"},
+	"PS512": {crypto.SHA512, "PSS"},
+	"ES256": {crypto.SHA256, "EC"},
+	"ES384": {crypto.SHA384, "EC"},
+	"ES512": {crypto.SHA512, "EC"},
+}
+
+// JWTValidator 验证外部身份提供方签发的JWT：签名公钥从JWKS获取并缓存，检查iss、aud、exp和nbf
+type JWTValidator struct {
+	opts JWTOptions
+	now  func() time.Time
+
+	mu      sync.Mutex
+	jwksURL string                      // JWKS地址（OIDC发现的结果）
+	keys    map[string]crypto.PublicKey // 签名公钥，按kid索引
+	fetched time.Time                   // 上次获取JWKS的时间
+}
+
+// NewJWTValidator 创建JWT验证器，JWKS在第一次验证时获取
+func NewJWTValidator(opts JWTOptions) *JWTValidator {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: jwksTimeout}
+	}
+	return &JWTValidator{opts: opts, now: time.Now, jwksURL: opts.JWKSURL}
+}
+
+// LooksLikeJWT 检查凭据是否为JWT格式（三段base64url，头部以{"开始），用于与静态API密钥区分
+func LooksLikeJWT(token string) bool {
+	return strings.HasPrefix(token, "eyJ") && strings.Count(token, ".") == 2
+}
+
+// Validate 验证JWT的签名和声明，返回声明
+func (v *JWTValidator) Validate(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed token header: %v", err)
+	}
+	alg, supported := jwtAlgorithms[header.Alg]
+	if !supported {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature: %v", err)
+	}
+
+	key, err := v.key(header.Kid, alg.kind)
+	if err != nil {
+		return nil, err
+	}
+	hasher := alg.hash.New()
+	hasher.Write([]byte(parts[0] + "." + parts[1]))
+	if err := verifySignature(alg, key, hasher.Sum(nil), signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %v", err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// checkClaims 检查签发方、受众和有效期
+func (v *JWTValidator) checkClaims(claims Claims) error {
+	if iss, _ := claims["iss"].(string); iss != v.opts.Issuer {
+		return fmt.Errorf("token issued by %q, expected %q", iss, v.opts.Issuer)
+	}
+	if !slices.Contains(claims.Strings("aud"), v.opts.Audience) {
+		return fmt.Errorf("token is not intended for audience %q", v.opts.Audience)
+	}
+	now := v.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("token has no expiry")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(v.opts.ClockSkew)) {
+		return errors.New("token has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0).Add(-v.opts.ClockSkew)) {
+		return errors.New("token is not valid yet")
+	}
+	return nil
+}
+
+// key 获取签名公钥：kid为空时使用唯一一个类型匹配的密钥；缓存过期或kid未知时重新获取JWKS
+func (v *JWTValidator) key(kid, kind string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	age := v.now().Sub(v.fetched)
+	key, found := v.lookup(kid, kind)
+	if v.keys == nil || age > jwksMaxAge || (!found && age > jwksMinRefresh) {
+		if err := v.fetchKeys(); err != nil {
+			// 获取失败时继续使用缓存的密钥
+			if !found {
+				return nil, fmt.Errorf("failed to fetch signing keys: %v", err)
+			}
+			return key, nil
+		}
+		key, found = v.lookup(kid, kind)
+	}
+	if !found {
+		return nil, fmt.Errorf("no signing key found for kid %q", kid)
+	}
+	return key, nil
+}
+
+// lookup 在缓存的密钥中查找（调用方需持有锁）
+func (v *JWTValidator) lookup(kid, kind string) (crypto.PublicKey, bool) {
+	matches := func(key crypto.PublicKey) bool {
+		_, isEC := key.(*ecdsa.PublicKey)
+		return isEC == (kind == "EC")
+	}
+	if kid != "" {
+		key, exists := v.keys[kid]
+		return key, exists && matches(key)
+	}
+	var candidate crypto.PublicKey
+	for _, key := range v.keys {
+		if matches(key) {
+			if candidate != nil {
+				return nil, false
+			}
+			candidate = key
+		}
+	}
+	return candidate, candidate != nil
+}
+
+// fetchKeys 获取JWKS，未配置JWKS地址时先获取OIDC发现文档（调用方需持有锁）
+func (v *JWTValidator) fetchKeys() error {
+	v.fetched = v.now()
+	if v.jwksURL == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.getJSON(strings.TrimSuffix(v.opts.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+			return err
+		}
+		if discovery.JWKSURI == "" {
+			return errors.New("OpenID configuration has no jwks_uri")
+		}
+		v.jwksURL = discovery.JWKSURI
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(v.jwksURL, &jwks); err != nil {
+		return err
+	}
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// 忽略不支持的密钥类型，其他密钥仍然可用
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	v.keys = keys
+	return nil
+}
+
+// getJSON 获取并解析JSON文档
+func (v *JWTValidator) getJSON(url string, out interface{}) error {
+	resp, err := v.opts.Client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response from %s: %v", url, err)
+	}
+	return nil
+}
+
+// jsonWebKey JWKS中的一个公钥（RFC 7517）
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey 解析RSA或EC公钥
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// verifySignature 验证签名
+func verifySignature(alg jwtAlgorithm, key crypto.PublicKey, digest, signature []byte) error {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		var err error
+		if alg.kind == "PSS" {
+			err = rsa.VerifyPSS(key, alg.hash, digest, signature, nil)
+		} else {
+			err = rsa.VerifyPKCS1v15(key, alg.hash, digest, signature)
+		}
+		if err != nil {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		// JWS的ECDSA签名为定长的r和s拼接（RFC 7518 3.4）
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid token signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	}
+	return errors.New("unsupported signing key")
+}
+
+// decodeSegment 解码base64url编码的JSON段
+func decodeSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// decodeBigInt 解码base64url编码的大端整数
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
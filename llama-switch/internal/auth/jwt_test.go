@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testIssuer 测试用的身份提供方：提供OIDC发现文档和JWKS，并签发令牌
+type testIssuer struct {
+	server   *httptest.Server
+	rsaKey   *rsa.PrivateKey
+	ecKey    *ecdsa.PrivateKey
+	jwksHits int
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+
+	b64 := func(data []byte) string { return base64.RawURLEncoding.EncodeToString(data) }
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": issuer.server.URL, "jwks_uri": issuer.server.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.jwksHits++
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+			{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+		}})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+// sign 签发令牌
+func (i *testIssuer) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var signature []byte
+	var err error
+	switch alg {
+	case "RS256":
+		digest := crypto.SHA256.New()
+		digest.Write([]byte(input))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, i.rsaKey, crypto.SHA256, digest.Sum(nil))
+	case "PS384":
+		digest := crypto.SHA384.New()
+		digest.Write([]byte(input))
+		signature, err = rsa.SignPSS(rand.Reader, i.rsaKey, crypto.SHA384, digest.Sum(nil), nil)
+	case "ES256":
+		digest := crypto.SHA256.New()
+		digest.Write([]byte(input))
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, i.ecKey, digest.Sum(nil))
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	case "none":
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTValidator(t *testing.T) {
+	issuer := newTestIssuer(t)
+	now := time.Now()
+	v := NewJWTValidator(JWTOptions{Issuer: issuer.server.URL, Audience: "llama-switch", ClockSkew: time.Minute})
+
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":   issuer.server.URL,
+			"aud":   []string{"other", "llama-switch"},
+			"sub":   "alice",
+			"exp":   now.Add(time.Hour).Unix(),
+			"roles": []string{"operator"},
+		}
+		for k, value := range changes {
+			if value == nil {
+				delete(c, k)
+			} else {
+				c[k] = value
+			}
+		}
+		return c
+	}
+
+	valid := []string{
+		issuer.sign(t, "RS256", "rsa-1", claims(nil)),
+		issuer.sign(t, "PS384", "rsa-1", claims(nil)),
+		issuer.sign(t, "ES256", "ec-1", claims(map[string]interface{}{"aud": "llama-switch"})),
+		issuer.sign(t, "ES256", "", claims(nil)),                                                                   // 没有kid时使用唯一的EC密钥
+		issuer.sign(t, "RS256", "rsa-1", claims(map[string]interface{}{"exp": now.Add(-30 * time.Second).Unix()})), // 时钟偏差内
+	}
+	for i, token := range valid {
+		if !LooksLikeJWT(token) {
+			t.Errorf("token %d not recognized as JWT", i)
+		}
+		got, err := v.Validate(token)
+		if err != nil {
+			t.Errorf("valid token %d rejected: %v", i, err)
+			continue
+		}
+		if got.Subject() != "alice" {
+			t.Errorf("token %d subject = %q", i, got.Subject())
+		}
+	}
+
+	invalid := map[string]string{
+		"wrong issuer":   issuer.sign(t, "RS256", "rsa-1", claims(map[string]interface{}{"iss": "https://evil.example.com"})),
+		"wrong audience": issuer.sign(t, "RS256", "rsa-1", claims(map[string]interface{}{"aud": "other"})),
+		"expired":        issuer.sign(t, "RS256", "rsa-1", claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()})),
+		"no expiry":      issuer.sign(t, "RS256", "rsa-1", claims(map[string]interface{}{"exp": nil})),
+		"not yet valid":  issuer.sign(t, "RS256", "rsa-1", claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})),
+		"alg none":       issuer.sign(t, "none", "rsa-1", claims(nil)),
+		"key mismatch":   issuer.sign(t, "ES256", "rsa-1", claims(nil)),
+		"unknown kid":    issuer.sign(t, "RS256", "rsa-2", claims(nil)),
+		"malformed":      "eyJhbGciOiJSUzI1NiJ9.not-base64!.sig",
+	}
+	tampered := strings.Split(valid[0], ".")
+	tampered[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"iss":"` + issuer.server.URL + `","aud":"llama-switch","exp":9999999999,"roles":["admin"]}`))
+	invalid["tampered"] = strings.Join(tampered, ".")
+	for name, token := range invalid {
+		if _, err := v.Validate(token); err == nil {
+			t.Errorf("%s: token accepted", name)
+		}
+	}
+
+	// 未知的kid不会在一分钟内反复获取JWKS
+	if issuer.jwksHits != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", issuer.jwksHits)
+	}
+}
+
+func TestClaimsStrings(t *testing.T) {
+	var claims Claims
+	json.Unmarshal([]byte(`{"roles":["admin","ops"],"scope":"read write","realm_access":{"roles":["operator"]},"n":1}`), &claims)
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"roles", []string{"admin", "ops"}},
+		{"scope", []string{"read", "write"}},
+		{"realm_access.roles", []string{"operator"}},
+		{"realm_access.missing", nil},
+		{"n", nil},
+		{"missing", nil},
+	}
+	for _, tt := range tests {
+		if got := claims.Strings(tt.name); !slices.Equal(got, tt.want) {
+			t.Errorf("Strings(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLooksLikeJWT(t *testing.T) {
+	for key, want := range map[string]bool{
+		"eyJhbGciOiJSUzI1NiJ9.eyJzdWIiOiJhIn0.c2ln": true,
+		"my-static-api-key":                         false,
+		"eyJ-but.no-third-part":                     false,
+	} {
+		if got := LooksLikeJWT(key); got != want {
+			t.Errorf("LooksLikeJWT(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
@@ -1,33 +1,66 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
+
+	"llama-switch/internal/auth"
+	"llama-switch/internal/ipfilter"
+	"llama-switch/internal/units"
 )
 
 // Config 应用程序的主配置结构
 type Config struct {
+	// File 加载的配置文件路径，未使用配置文件时为空
+	File string `json:"-"`
+
+	// Profile 使用的配置文件中的环境配置（LLAMA_SWITCH_PROFILE），未使用时为空
+	Profile string `json:"-"`
+
+	// EnvFile 加载的.env文件路径，未加载时为空
+	EnvFile string `json:"-"`
+
+	// sources 配置项路径到来源（配置文件、环境变量或运行时修改）的映射，未记录的配置项为默认值
+	sources map[string]string
+
 	// LLamaPath llama.cpp二进制文件路径
 	LLamaPath struct {
-		Server string `json:"server"` // llama-server路径
-		Bench  string `json:"bench"`  // llama-bench路径
+		Server   string            `json:"server"`   // llama-server路径
+		Bench    string            `json:"bench"`    // llama-bench路径
+		Profiles map[string]string `json:"profiles"` // 其他llama-server构建（如cuda、vulkan、cpu），按名称选择
 	} `json:"llama_path"`
 
 	// ModelsDir 模型文件目录
 	ModelsDir string `json:"models_dir"`
 
+	// PersistentDir 模型持久化配置（model_persistent.json）的保存目录，为空时使用程序目录下的config目录
+	PersistentDir string `json:"persistent_dir"`
+
 	// Server API服务器配置
 	Server struct {
-		Host    string `json:"host"`
-		Port    int    `json:"port"`
-		Timeout int    `json:"timeout"`
+		Host            string        `json:"host"`
+		Port            int           `json:"port"`
+		Timeout         units.Seconds `json:"timeout"`
+		ShutdownTimeout units.Seconds `json:"shutdown_timeout"` // 关闭时等待进行中的请求（包括代理的流式响应）完成的时间（秒），超时后断开连接
+		PIDFile         string        `json:"pid_file"`         // switcher自身的PID锁文件（为空时使用持久化配置目录下的llama-switch.pid）
 	} `json:"server"`
 
+	// ModelPorts 未指定端口的模型实例的端口分配范围
+	ModelPorts struct {
+		RangeStart int `json:"range_start"` // 起始端口
+		RangeEnd   int `json:"range_end"`   // 结束端口（包含）
+	} `json:"model_ports"`
+
 	// DefaultModel 默认模型配置
 	DefaultModel struct {
 		Threads    int `json:"threads"`
@@ -42,6 +75,10 @@ type Config struct {
 		SplitMode string `json:"split_mode"`
 		MainGPU   int    `json:"main_gpu"`
 		FlashAttn bool   `json:"flash_attn"`
+		Provider  string `json:"provider"`  // 显存查询使用的GPU工具：auto/nvidia/amd/intel/apple
+		Placement string `json:"placement"` // 多GPU时的模型放置策略：bestfit/none
+		// AutoTensorSplit 模型跨多个GPU运行且请求未指定tensor_split时按各GPU可用显存计算
+		AutoTensorSplit bool `json:"auto_tensor_split"`
 	} `json:"gpu"`
 
 	// Cache 缓存配置
@@ -59,136 +96,681 @@ type Config struct {
 
 	// Log 日志配置
 	Log struct {
-		Level         string `json:"level"`
-		File          string `json:"file"`
-		EnableConsole bool   `json:"enable_console"`
+		Level         string `json:"level"`          // 日志级别（debug/info/warn/error），可在运行时重新加载
+		File          string `json:"file"`           // 日志文件（追加写入），为空时只输出到控制台
+		EnableConsole bool   `json:"enable_console"` // 配置了日志文件时是否同时输出到控制台
+		Format        string `json:"format"`         // 日志格式：text或json（便于日志采集系统解析）
 	} `json:"log"`
 
+	// ModelLog 模型实例输出日志配置
+	ModelLog struct {
+		Dir       string          `json:"dir"`         // 日志目录（为空时使用程序目录下的logs目录）
+		MaxSizeMB units.Megabytes `json:"max_size_mb"` // 单个日志文件的大小上限（MB），超过后轮转
+		MaxFiles  int             `json:"max_files"`   // 保留的轮转文件数
+		Console   bool            `json:"console"`     // 是否同时输出到控制台
+	} `json:"model_log"`
+
+	// Proxy 推理代理配置
+	Proxy struct {
+		Enabled            bool          `json:"enabled"`             // 是否启用/v1推理代理
+		Host               string        `json:"host"`                // 单独监听推理代理的地址，为空时使用server.host
+		Port               int           `json:"port"`                // 单独监听推理代理的端口，0表示与管理API使用同一端口
+		DefaultConcurrency int           `json:"default_concurrency"` // 未设置parallel时每个模型的并发上限
+		QueueSize          int           `json:"queue_size"`          // 超出并发上限时的最大排队请求数
+		QueueTimeout       units.Seconds `json:"queue_timeout"`       // 排队等待超时时间（秒）
+	} `json:"proxy"`
+
+	// Embedding 嵌入请求路由配置
+	Embedding struct {
+		BatchEnabled   bool               `json:"batch_enabled"`    // 是否合并小的嵌入请求后再转发
+		BatchWindowMS  units.Milliseconds `json:"batch_window_ms"`  // 合并等待窗口（毫秒）
+		BatchMaxInputs int                `json:"batch_max_inputs"` // 单个合并批次的最大输入条数
+	} `json:"embedding"`
+
+	// Download 模型下载配置
+	Download struct {
+		HFEndpoint    string `json:"hf_endpoint"`    // Hugging Face下载地址（可设置为镜像站）
+		MaxConcurrent int    `json:"max_concurrent"` // 同时进行的最大下载数，超出时排队
+	} `json:"download"`
+
+	// Rerank 重排序请求路由配置
+	Rerank struct {
+		DefaultModel   string        `json:"default_model"`   // 无重排序模型运行时自动启动的模型文件（为空时不自动启动）
+		DefaultName    string        `json:"default_name"`    // 自动启动的重排序模型名称
+		DefaultPort    int           `json:"default_port"`    // 自动启动的重排序模型端口
+		GPULayers      int           `json:"gpu_layers"`      // 自动启动的重排序模型GPU层数
+		StartupTimeout units.Seconds `json:"startup_timeout"` // 等待自动启动的模型就绪的超时时间（秒）
+	} `json:"rerank"`
+
+	// SwitchGuard 切换保护配置：拒绝停止/驱逐正在使用的模型
+	SwitchGuard struct {
+		Enabled      bool          `json:"enabled"`       // 是否启用切换保护
+		IdleSeconds  units.Seconds `json:"idle_seconds"`  // 模型在最近多少秒内处理过请求时视为正在使用
+		DrainTimeout units.Seconds `json:"drain_timeout"` // 停止请求指定drain时等待进行中请求完成的默认超时（秒）
+	} `json:"switch_guard"`
+
+	// RAM 主机内存准入检查配置
+	RAM struct {
+		CheckEnabled bool            `json:"check_enabled"` // 启动模型前是否检查主机可用内存
+		ReserveMB    units.Megabytes `json:"reserve_mb"`    // 为系统和其他进程保留的内存(MB)
+	} `json:"ram"`
+
+	// Eviction 显存不足时驱逐模型的配置
+	Eviction struct {
+		Policy         string             `json:"policy"`           // 驱逐顺序：largest/lru/priority
+		PollIntervalMS units.Milliseconds `json:"poll_interval_ms"` // 停止模型后轮询可用显存/内存的间隔（毫秒）
+		StablePolls    int                `json:"stable_polls"`     // 连续多少次读数不变时认为已释放完毕
+		ReleaseTimeout units.Seconds      `json:"release_timeout"`  // 等待释放的最长时间（秒）
+	} `json:"eviction"`
+
+	// RPC 远程rpc-server池配置
+	RPC struct {
+		Pools          map[string]string `json:"pools"`           // RPC池，名称=端点列表（host:port@显存MB或带单位如24g，多个端点用+分隔）
+		HealthInterval units.Seconds     `json:"health_interval"` // 探测端点的间隔（秒），0表示只在切换时探测
+	} `json:"rpc"`
+
+	// HealthCheck 模型实例健康检查配置
+	HealthCheck struct {
+		Interval units.Seconds `json:"interval"` // 探测间隔（秒），0表示禁用后台探测
+		Timeout  units.Seconds `json:"timeout"`  // 单次探测超时时间（秒）
+	} `json:"health_check"`
+
+	// Watchdog 卡死实例的看门狗配置
+	Watchdog struct {
+		Failures int  `json:"failures"`  // 进程存活但连续多少次健康探测失败时结束实例，0表示禁用
+		Restart  bool `json:"restart"`   // 结束后是否以原配置重新启动
+		LogLines int  `json:"log_lines"` // 诊断快照中保存的最近日志行数
+	} `json:"watchdog"`
+
+	// Events 运行事件日志配置
+	Events struct {
+		File    string `json:"file"`    // 事件日志文件（JSON Lines），为空时使用程序目录下的events.jsonl
+		History int    `json:"history"` // 内存中保留供查询的最近事件数
+	} `json:"events"`
+
+	// Audit 修改类API请求的审计日志配置
+	Audit struct {
+		Enabled bool   `json:"enabled"` // 记录切换、停止、配置修改、启动基准测试等修改类请求
+		File    string `json:"file"`    // 审计日志文件（JSON Lines，只追加），为空时使用程序目录下的audit.jsonl
+	} `json:"audit"`
+
+	// Startup 模型实例启动阶段配置
+	Startup struct {
+		Timeout     units.Seconds `json:"timeout"`      // 等待实例就绪的超时时间（秒），0表示不等待
+		OutputLines int           `json:"output_lines"` // 启动失败时返回的最近输出行数
+	} `json:"startup"`
+
+	// Stop 停止模型进程的默认方式
+	Stop struct {
+		Signal      string        `json:"signal"`       // 请求进程优雅退出的信号
+		GracePeriod units.Seconds `json:"grace_period"` // 等待进程退出的时间（秒），超时后强制结束
+	} `json:"stop"`
+
+	// Reconcile 运行状态校正配置
+	Reconcile struct {
+		Interval units.Seconds `json:"interval"` // 校正间隔（秒），0表示禁用
+	} `json:"reconcile"`
+
+	// WorkDir 模型进程工作目录配置
+	WorkDir struct {
+		Root    string `json:"root"`    // 为每个模型创建工作目录的父目录（为空时使用程序目录下的workdirs目录）
+		Sandbox bool   `json:"sandbox"` // 限制模型参数中的文件路径只能位于工作目录或模型目录中
+	} `json:"work_dir"`
+
+	// ModelEnv 模型进程环境变量配置
+	ModelEnv struct {
+		Allowlist []string `json:"allowlist"` // 切换请求允许设置的环境变量，以*结尾表示前缀匹配
+	} `json:"model_env"`
+
+	// ExtraArgs 切换请求中extra_args的配置
+	ExtraArgs struct {
+		Allowlist []string `json:"allowlist"` // 允许原样传给llama-server的参数名（如--spec-replace），以*结尾表示前缀匹配，为空时不允许extra_args
+	} `json:"extra_args"`
+
+	// Limits 模型进程资源限制配置
+	Limits struct {
+		CgroupRoot string `json:"cgroup_root"` // Linux下为模型创建cgroup的父目录（需可写并已委派cpu/memory/cpuset控制器）
+	} `json:"limits"`
+
+	// Resources 模型进程资源采样配置
+	Resources struct {
+		SampleInterval units.Seconds   `json:"sample_interval"` // 采样间隔（秒），0表示禁用
+		HistorySize    int             `json:"history_size"`    // 每个模型保留的采样数
+		VRAMLowMB      units.Megabytes `json:"vram_low_mb"`     // GPU可用显存低于该值(MB)时记录vram_low事件并发送vram.low通知，0表示不检测
+	} `json:"resources"`
+
+	// VRAMHistory 模型显存使用历史配置
+	VRAMHistory struct {
+		Dir        string        `json:"dir"`         // 历史文件目录（为空时使用程序目录下的vram_history目录）
+		Interval   units.Seconds `json:"interval"`    // 记录间隔（秒），0表示禁用
+		MaxSamples int           `json:"max_samples"` // 每个模型保留的样本数
+	} `json:"vram_history"`
+
+	// Thermal GPU温度和降频告警配置
+	Thermal struct {
+		TempLimitC      int `json:"temp_limit_c"`     // 视为过热的GPU温度（摄氏度），0表示只按GPU工具报告的降频原因判断
+		ThrottleSamples int `json:"throttle_samples"` // 连续多少次采样处于降频状态时告警
+	} `json:"thermal"`
+
+	// TimeShare 分时共享GPU配置（实验性）
+	TimeShare struct {
+		Enabled     bool          `json:"enabled"`      // 是否启用分时共享
+		SlotDir     string        `json:"slot_dir"`     // 插槽缓存保存目录
+		SwapTimeout units.Seconds `json:"swap_timeout"` // 切换时等待模型就绪的超时时间（秒）
+	} `json:"timeshare"`
+
+	// StatusPage 公开状态页配置
+	StatusPage struct {
+		Enabled bool   `json:"enabled"` // 是否启用无需认证的只读状态页
+		Title   string `json:"title"`   // 状态页标题
+	} `json:"status_page"`
+
+	// Metrics Prometheus指标配置
+	Metrics struct {
+		Enabled   bool `json:"enabled"`   // 是否在/metrics导出Prometheus指标（配置了API密钥时需要只读角色）
+		Instances bool `json:"instances"` // 是否同时导出以metrics参数启动的模型实例的指标（加上model_name标签）
+	} `json:"metrics"`
+
+	// ModelDefs 声明式模型定义配置
+	ModelDefs struct {
+		Dir      string        `json:"dir"`      // 模型定义文件目录（为空时使用配置文件所在目录下的models.d，未使用配置文件时为程序目录下的models.d）
+		Interval units.Seconds `json:"interval"` // 检查目录变化的间隔（秒），0表示只在启动时加载
+	} `json:"model_defs"`
+
+	// Autostart 启动时自动启动的模型列表
+	Autostart struct {
+		Models          []string      `json:"models"`           // 按顺序启动的模型名称（模型定义或持久化配置中的模型），name@延迟表示启动前等待的时间（秒数或带单位如30s）
+		CapacityTimeout units.Seconds `json:"capacity_timeout"` // 显存不足时等待的最长时间（秒），超时后跳过该模型，0表示不等待
+	} `json:"autostart"`
+
+	// Alias 模型别名配置
+	Alias struct {
+		File       string `json:"file"`        // 别名及变更记录的保存文件（为空时使用程序目录下的config/aliases.json）
+		WebhookURL string `json:"webhook_url"` // 别名变更时通知的Webhook地址
+	} `json:"alias"`
+
+	// Webhooks 事件订阅配置
+	Webhooks struct {
+		File        string        `json:"file"`         // 订阅的保存文件（为空时使用程序目录下的config/webhooks.json）
+		MaxAttempts int           `json:"max_attempts"` // 每条通知最多投递的次数（包括第一次）
+		RetryDelay  units.Seconds `json:"retry_delay"`  // 第一次重试前等待的时间（秒），之后每次翻倍
+	} `json:"webhooks"`
+
+	// Eval 准确性冒烟测试配置
+	Eval struct {
+		File    string        `json:"file"`    // 测试集的保存文件（为空时使用程序目录下的config/evals.json）
+		Timeout units.Seconds `json:"timeout"` // 单个用例的超时时间（秒）
+	} `json:"eval"`
+
+	// Benchmark 基准测试配置
+	Benchmark struct {
+		ManifestDir         string          `json:"manifest_dir"`         // 可复现清单的保存目录（为空时使用程序目录下的config/benchmarks）
+		HistoryFile         string          `json:"history_file"`         // 已结束任务的历史记录文件（为空时使用程序目录下的benchmark_history.jsonl）
+		ScheduleFile        string          `json:"schedule_file"`        // 定时基准测试的保存文件（为空时使用程序目录下的config/benchmark_schedules.json）
+		MaxTasks            int             `json:"max_tasks"`            // 内存中保留的任务数上限，超出时清理最早结束的任务，0表示不限制
+		TaskMaxAge          units.Seconds   `json:"task_max_age"`         // 已结束任务在内存中保留的时间（秒），0表示不限制
+		WebhookURLs         []string        `json:"webhook_urls"`         // 每个任务结束（完成、失败或取消）时POST通知的地址
+		BaselineFile        string          `json:"baseline_file"`        // 固定基线的保存文件（为空时使用程序目录下的config/benchmark_baselines.json）
+		RegressionThreshold int             `json:"regression_threshold"` // 吞吐量低于固定基线超过该百分比时标记为性能下降
+		OutputLimitKB       units.Kilobytes `json:"output_limit_kb"`      // 每个任务的stdout和stderr在内存中各保留的最大字节数(KB)，完整输出保存在磁盘上，0表示不限制
+	} `json:"benchmark"`
+
+	// SMTP 邮件通知配置（用于定时基准测试的性能下降通知）
+	SMTP struct {
+		Addr     string `json:"addr"`     // SMTP服务器地址(host:port)，为空时不发送邮件
+		Username string `json:"username"` // 认证用户名，为空时不认证
+		Password string `json:"-"`        // 认证密码
+		From     string `json:"from"`     // 发件人地址
+	} `json:"smtp"`
+
+	// CORS 跨域请求配置，允许浏览器中的网页直接调用API
+	CORS struct {
+		AllowedOrigins []string      `json:"allowed_origins"` // 允许的来源（如https://dashboard.example.com），*表示任意来源，为空时不启用
+		AllowedMethods []string      `json:"allowed_methods"` // 允许的请求方法
+		AllowedHeaders []string      `json:"allowed_headers"` // 允许的请求头
+		MaxAge         units.Seconds `json:"max_age"`         // 浏览器缓存预检结果的时间（秒）
+	} `json:"cors"`
+
+	// RateLimit 管理API请求频率限制：令牌桶按API密钥（未携带有效密钥时按客户端IP）分别计数
+	RateLimit struct {
+		PerMinute       int `json:"per_minute"`        // 每分钟允许的请求数，0表示不限制
+		Burst           int `json:"burst"`             // 允许的突发请求数，0表示与per_minute相同
+		SwitchPerMinute int `json:"switch_per_minute"` // 切换请求每分钟允许的次数（单独计数），0表示与其他请求一起计数
+		SwitchBurst     int `json:"switch_burst"`      // 切换请求允许的突发次数，0表示与switch_per_minute相同
+	} `json:"rate_limit"`
+
+	// Idempotency 切换和停止请求的幂等键（Idempotency-Key请求头）
+	Idempotency struct {
+		TTL     units.Seconds `json:"ttl"`      // 缓存第一次请求响应的时间（秒），0表示不接受幂等键
+		MaxKeys int           `json:"max_keys"` // 最多保留的幂等键数量，超过时删除最早过期的键
+	} `json:"idempotency"`
+
+	// Tenants 多租户显存配额配置
+	Tenants struct {
+		APIKeys    map[string]string `json:"api_keys"`    // 租户名称=API密钥，切换请求通过密钥确定所属租户
+		VRAMQuotas map[string]string `json:"vram_quotas"` // 租户名称=显存配额（MB，或带单位如24g），未设置的租户不限制
+	} `json:"tenants"`
+
 	// Security 安全配置
 	Security struct {
-		APIKey  string `json:"api_key"`
-		SSLKey  string `json:"ssl_key"`
-		SSLCert string `json:"ssl_cert"`
+		APIKey       string            `json:"api_key"`             // 管理员API密钥
+		APIKeys      map[string]string `json:"api_keys"`            // 名称=API密钥，角色由roles指定
+		Roles        map[string]string `json:"roles"`               // 名称=角色（admin、operator、readonly），未指定的密钥为readonly
+		SSLKey       string            `json:"ssl_key"`             // 私钥文件，与证书文件同时设置时使用HTTPS
+		SSLCert      string            `json:"ssl_cert"`            // 证书文件，修改后自动重新加载
+		SSLClientCA  string            `json:"ssl_client_ca"`       // 客户端CA证书文件，设置后要求客户端提供由其签发的证书（mTLS）
+		RedirectPort int               `json:"http_redirect_port"`  // 启用HTTPS时在该端口上将HTTP请求重定向到HTTPS，0表示不监听
+		AllowedCIDRs []string          `json:"admin_allowed_cidrs"` // 允许访问管理API的客户端网段（CIDR或IP），为空时不限制
+		DeniedCIDRs  []string          `json:"admin_denied_cidrs"`  // 禁止访问管理API的客户端网段，优先于允许列表
 	} `json:"security"`
+
+	// JWT 外部身份提供方（OIDC）签发的JWT，作为静态API密钥之外的认证方式，设置issuer后启用
+	JWT struct {
+		Issuer      string            `json:"issuer"`       // 签发方，必须与令牌的iss声明一致
+		Audience    string            `json:"audience"`     // 受众，令牌的aud声明必须包含该值
+		JWKSURL     string            `json:"jwks_url"`     // 签名公钥的JWKS地址，为空时通过签发方的OIDC发现文档获取
+		RoleClaim   string            `json:"role_claim"`   // 包含角色的声明，嵌套声明用点号分隔（如realm_access.roles）
+		RoleMap     map[string]string `json:"role_map"`     // 声明值=角色（admin、operator、readonly），为空时声明值直接作为角色名称
+		DefaultRole string            `json:"default_role"` // 声明中没有可映射的角色时使用的角色，为空时拒绝请求
+		ClockSkew   units.Seconds     `json:"clock_skew"`   // 检查令牌有效期时允许的时钟偏差（秒）
+	} `json:"jwt"`
 }
 
-// LoadConfig 加载配置
-func LoadConfig() (*Config, error) {
+// LoadConfig 加载配置：默认值、配置文件（YAML或JSON）、环境变量、运行时修改的默认配置依次覆盖
+// configFile为空时在工作目录和程序目录下查找config.yaml、config.yml或config.json
+func LoadConfig(configFile string) (*Config, error) {
 	// 获取当前工作目录
 	wd, err := os.Getwd()
 	if err != nil {
-		fmt.Printf("Warning: Failed to get working directory: %v\n", err)
+		slog.Warn("Failed to get working directory", "error", err)
 		wd = "."
 	}
 
-	// 尝试加载.env文件
+	if configFile == "" {
+		configFile = FindConfigFile()
+	}
+
+	// 尝试加载.env文件，使用配置文件时不加载示例文件，避免其中的值覆盖配置文件
 	envPaths := []string{
-		filepath.Join(wd, "llama-switch", ".env"),         // 开发环境路径
-		filepath.Join(wd, ".env"),                         // 当前目录
-		filepath.Join(wd, "llama-switch", ".env.example"), // 示例文件
+		filepath.Join(wd, "llama-switch", ".env"), // 开发环境路径
+		filepath.Join(wd, ".env"),                 // 当前目录
+	}
+	if configFile == "" {
+		envPaths = append(envPaths, filepath.Join(wd, "llama-switch", ".env.example")) // 示例文件
 	}
 
-	var loaded bool
+	// 查找过程只在debug级别输出，加载结果记录在EnvFile中由调用方报告，重新加载配置时不重复输出
+	var envFile string
 	for _, path := range envPaths {
-		fmt.Printf("Trying to load .env from: %s\n", path)
-		if err := godotenv.Load(path); err == nil {
-			fmt.Printf("Successfully loaded .env from: %s\n", path)
-			loaded = true
+		err := loadEnvFile(path)
+		if err == nil {
+			envFile = path
 			break
+		}
+		if os.IsNotExist(err) {
+			slog.Debug("No .env file", "path", path)
 		} else {
-			fmt.Printf("Failed to load .env from %s: %v\n", path, err)
+			slog.Warn("Failed to load .env file", "path", path, "error", err)
 		}
 	}
 
-	if !loaded {
-		fmt.Printf("Warning: Could not load any .env file (tried: %v)\n", envPaths)
+	if envFile == "" {
+		slog.Debug("No .env file loaded", "tried", envPaths)
+		// 重新加载时.env文件已被删除，之前从中设置的环境变量不再生效
+		for key := range dotenvKeys {
+			os.Unsetenv(key)
+			delete(dotenvKeys, key)
+		}
 	}
 
-	cfg := &Config{}
+	cfg := defaultConfig()
+	cfg.EnvFile = envFile
+
+	// 加载配置文件，文件中未出现的配置项保持默认值；LLAMA_SWITCH_PROFILE选择文件中的环境配置（如dev、staging、prod）
+	profile := os.Getenv("LLAMA_SWITCH_PROFILE")
+	if configFile != "" {
+		if err := loadConfigFile(configFile, profile, cfg); err != nil {
+			return nil, err
+		}
+		cfg.File = configFile
+		cfg.Profile = profile
+	} else if profile != "" {
+		return nil, fmt.Errorf("LLAMA_SWITCH_PROFILE is set to %s but no config file was found", profile)
+	}
+
+	// 环境变量覆盖默认值和配置文件，大小和时间类的值无效时在最后一并报告
+	envErrs := applyEnv(cfg)
+	if len(envErrs) > 0 {
+		return nil, errors.Join(envErrs...)
+	}
+
+	// 通过PATCH /api/v1/config/defaults修改的默认配置优先于配置文件和环境变量
+	loadDefaultsOverrides(cfg)
 
-	// 加载二进制文件路径
-	cfg.LLamaPath.Server = getEnv("LLAMA_SERVER_PATH", "E:/Downloads/llama-b5293-bin-win-cuda-cu12.4-x64/llama-server.exe")
-	cfg.LLamaPath.Bench = getEnv("LLAMA_BENCH_PATH", "E:/Downloads/llama-b5293-bin-win-cuda-cu12.4-x64/llama-bench.exe")
-
-	// 加载模型目录
-	cfg.ModelsDir = getEnv("MODELS_DIR", "E:/develop/Models/DeepSeek-R1-Distill-Qwen-32B-GGUF")
-
-	// 加载服务器配置
-	cfg.Server.Host = getEnv("SERVER_HOST", "127.0.0.1")
-	cfg.Server.Port = getEnvInt("SERVER_PORT", 8080)
-	cfg.Server.Timeout = getEnvInt("SERVER_TIMEOUT", 600)
-
-	// 加载默认模型配置
-	cfg.DefaultModel.Threads = getEnvInt("DEFAULT_THREADS", 8)
-	cfg.DefaultModel.CtxSize = getEnvInt("DEFAULT_CTX_SIZE", 4096)
-	cfg.DefaultModel.BatchSize = getEnvInt("DEFAULT_BATCH_SIZE", 512)
-	cfg.DefaultModel.UBatchSize = getEnvInt("DEFAULT_UBATCH_SIZE", 512)
-
-	// 加载GPU配置
-	cfg.GPU.Layers = getEnvInt("DEFAULT_GPU_LAYERS", 99)
-	cfg.GPU.SplitMode = getEnv("DEFAULT_SPLIT_MODE", "layer")
-	cfg.GPU.MainGPU = getEnvInt("DEFAULT_MAIN_GPU", 0)
-	cfg.GPU.FlashAttn = getEnvBool("ENABLE_FLASH_ATTN", true)
-
-	// 加载缓存配置
-	cfg.Cache.TypeK = getEnv("DEFAULT_CACHE_TYPE_K", "f16")
-	cfg.Cache.TypeV = getEnv("DEFAULT_CACHE_TYPE_V", "f16")
-
-	// 加载内存管理配置
-	cfg.Memory.Mlock = getEnvBool("ENABLE_MLOCK", false)
-	cfg.Memory.Mmap = getEnvBool("ENABLE_MMAP", true)
-	cfg.Memory.Numa = getEnv("NUMA_STRATEGY", "")
-
-	// 加载日志配置
-	cfg.Log.Level = getEnv("LOG_LEVEL", "info")
-	cfg.Log.File = getEnv("LOG_FILE", "")
-	cfg.Log.EnableConsole = getEnvBool("ENABLE_CONSOLE_LOG", true)
-
-	// 加载安全配置
-	cfg.Security.APIKey = getEnv("API_KEY", "")
-	cfg.Security.SSLKey = getEnv("SSL_KEY_FILE", "")
-	cfg.Security.SSLCert = getEnv("SSL_CERT_FILE", "")
+	// 未配置的二进制文件和模型目录在PATH和各平台的常见位置中查找，找不到时由ValidateConfig报告查找过的位置
+	if cfg.LLamaPath.Server == "" {
+		cfg.LLamaPath.Server, _ = FindBinary("llama-server")
+	}
+	if cfg.LLamaPath.Bench == "" {
+		cfg.LLamaPath.Bench, _ = FindBinary("llama-bench")
+	}
+	if cfg.ModelsDir == "" {
+		cfg.ModelsDir, _ = FindModelsDir()
+	}
 
 	return cfg, nil
 }
 
-// 辅助函数：获取环境变量，如果不存在则返回默认值
-func getEnv(key string, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+var (
+	// processEnv 首次加载配置前进程已有的环境变量，.env文件不覆盖它们
+	processEnv     map[string]bool
+	processEnvOnce sync.Once
+	// dotenvKeys 上一次从.env文件设置的环境变量，重新加载时删除文件中已不存在的项
+	dotenvKeys = make(map[string]bool)
+)
+
+// loadEnvFile 将.env文件中的变量设置到进程环境中，进程启动时已有的环境变量优先
+// 与godotenv.Load不同，重新加载配置时会以文件中的新值覆盖上一次从.env文件设置的值
+func loadEnvFile(path string) error {
+	processEnvOnce.Do(func() {
+		processEnv = make(map[string]bool)
+		for _, item := range os.Environ() {
+			name, _, _ := strings.Cut(item, "=")
+			processEnv[name] = true
+		}
+	})
+
+	values, err := godotenv.Read(path)
+	if err != nil {
+		return err
 	}
-	return defaultValue
+	for key := range dotenvKeys {
+		if _, exists := values[key]; !exists {
+			os.Unsetenv(key)
+			delete(dotenvKeys, key)
+		}
+	}
+	for key, value := range values {
+		if processEnv[key] {
+			continue
+		}
+		os.Setenv(key, value)
+		dotenvKeys[key] = true
+	}
+	return nil
 }
 
-// 辅助函数：获取整数类型的环境变量
-func getEnvInt(key string, defaultValue int) int {
-	if value, exists := os.LookupEnv(key); exists {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
+// defaultConfig 未设置配置文件和环境变量时使用的默认配置
+func defaultConfig() *Config {
+	cfg := &Config{}
+
+	// 二进制文件路径和模型目录未配置时由LoadConfig查找
+	cfg.LLamaPath.Profiles = map[string]string{}
+
+	// 服务器配置
+	cfg.Server.Host = "127.0.0.1"
+	cfg.Server.Port = 8080
+	cfg.Server.Timeout = 600
+	cfg.Server.ShutdownTimeout = 30
+
+	// 模型实例端口分配范围
+	cfg.ModelPorts.RangeStart = 8100
+	cfg.ModelPorts.RangeEnd = 8199
+
+	// 默认模型配置
+	cfg.DefaultModel.Threads = 8
+	cfg.DefaultModel.CtxSize = 4096
+	cfg.DefaultModel.BatchSize = 512
+	cfg.DefaultModel.UBatchSize = 512
+
+	// GPU配置
+	cfg.GPU.Layers = 99
+	cfg.GPU.SplitMode = "layer"
+	cfg.GPU.FlashAttn = true
+	cfg.GPU.Provider = "auto"
+	cfg.GPU.Placement = "bestfit"
+
+	// 缓存配置
+	cfg.Cache.TypeK = "f16"
+	cfg.Cache.TypeV = "f16"
+
+	// 内存管理配置
+	cfg.Memory.Mmap = true
+
+	// 日志配置
+	cfg.Log.Level = "info"
+	cfg.Log.EnableConsole = true
+	cfg.Log.Format = "text"
+
+	// 模型实例输出日志配置
+	cfg.ModelLog.MaxSizeMB = 10
+	cfg.ModelLog.MaxFiles = 3
+
+	// 推理代理配置
+	cfg.Proxy.Enabled = true
+	cfg.Proxy.DefaultConcurrency = 1
+	cfg.Proxy.QueueSize = 8
+	cfg.Proxy.QueueTimeout = 30
+
+	// 嵌入请求路由配置
+	cfg.Embedding.BatchWindowMS = 10
+	cfg.Embedding.BatchMaxInputs = 64
+
+	// 模型下载配置
+	cfg.Download.HFEndpoint = "https://huggingface.co"
+	cfg.Download.MaxConcurrent = 1
+
+	// 重排序请求路由配置
+	cfg.Rerank.DefaultName = "reranker"
+	cfg.Rerank.StartupTimeout = 120
+
+	// 切换保护配置
+	cfg.SwitchGuard.Enabled = true
+	cfg.SwitchGuard.IdleSeconds = 60
+	cfg.SwitchGuard.DrainTimeout = 30
+
+	// 主机内存检查配置
+	cfg.RAM.CheckEnabled = true
+	cfg.RAM.ReserveMB = 1024
+
+	// 驱逐策略配置
+	cfg.Eviction.Policy = "largest"
+	cfg.Eviction.PollIntervalMS = 250
+	cfg.Eviction.StablePolls = 3
+	cfg.Eviction.ReleaseTimeout = 10
+
+	// RPC池配置
+	cfg.RPC.Pools = map[string]string{}
+	cfg.RPC.HealthInterval = 15
+
+	// 健康检查配置
+	cfg.HealthCheck.Interval = 10
+	cfg.HealthCheck.Timeout = 3
+
+	// 看门狗配置
+	cfg.Watchdog.Failures = 3
+	cfg.Watchdog.Restart = true
+	cfg.Watchdog.LogLines = 50
+
+	// 事件日志配置
+	cfg.Events.History = 200
+
+	// 审计日志配置
+	cfg.Audit.Enabled = true
+
+	// 启动阶段配置
+	cfg.Startup.Timeout = 300
+	cfg.Startup.OutputLines = 20
+
+	// 模型停止配置
+	cfg.Stop.Signal = "SIGTERM"
+	cfg.Stop.GracePeriod = 10
+
+	// 运行状态校正配置
+	cfg.Reconcile.Interval = 30
+
+	// 声明式模型定义配置
+	cfg.ModelDefs.Interval = 10
+
+	// 模型环境变量配置
+	cfg.ModelEnv.Allowlist = []string{"CUDA_VISIBLE_DEVICES", "HIP_VISIBLE_DEVICES", "ROCR_VISIBLE_DEVICES", "ONEAPI_DEVICE_SELECTOR", "GGML_*"}
+
+	// 资源限制配置
+	cfg.Limits.CgroupRoot = "/sys/fs/cgroup/llama-switch"
+
+	// 资源采样配置
+	cfg.Resources.SampleInterval = 5
+	cfg.Resources.HistorySize = 120
+
+	// 显存使用历史配置
+	cfg.VRAMHistory.Interval = 60
+	cfg.VRAMHistory.MaxSamples = 10080
+
+	// GPU温度告警配置
+	cfg.Thermal.TempLimitC = 85
+	cfg.Thermal.ThrottleSamples = 3
+
+	// 分时共享配置
+	cfg.TimeShare.SlotDir = filepath.Join(os.TempDir(), "llama-switch", "slots")
+	cfg.TimeShare.SwapTimeout = 300
+
+	// 公开状态页配置
+	cfg.StatusPage.Title = "LLM Service Status"
+
+	// Prometheus指标配置
+	cfg.Metrics.Enabled = true
+	cfg.Metrics.Instances = true
+
+	// 事件订阅配置
+	cfg.Webhooks.MaxAttempts = 5
+	cfg.Webhooks.RetryDelay = 2
+
+	// 准确性冒烟测试配置
+	cfg.Eval.Timeout = 120
+
+	// 基准测试配置
+	cfg.Benchmark.MaxTasks = 100
+	cfg.Benchmark.TaskMaxAge = 86400
+	cfg.Benchmark.RegressionThreshold = 5
+	cfg.Benchmark.OutputLimitKB = 1024
+
+	// 跨域请求配置
+	cfg.CORS.AllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	cfg.CORS.AllowedHeaders = []string{"Authorization", "Content-Type", "X-API-Key", "Idempotency-Key", "Last-Event-ID", "X-Request-ID"}
+	cfg.CORS.MaxAge = 600
+
+	// 幂等键配置
+	cfg.Idempotency.TTL = 3600
+	cfg.Idempotency.MaxKeys = 1000
+
+	// 多租户配置
+	cfg.Tenants.APIKeys = map[string]string{}
+	cfg.Tenants.VRAMQuotas = map[string]string{}
+
+	// 访问控制配置
+	cfg.Security.APIKeys = map[string]string{}
+	cfg.Security.Roles = map[string]string{}
+
+	// JWT认证配置
+	cfg.JWT.RoleClaim = "roles"
+	cfg.JWT.RoleMap = map[string]string{}
+	cfg.JWT.ClockSkew = 60
+
+	return cfg
+}
+
+// RPCEndpoint RPC池中的一个rpc-server端点
+type RPCEndpoint struct {
+	Address  string // rpc-server地址（host:port）
+	MemoryMB int    // 端点可供模型使用的显存(MB)
+}
+
+// ParseRPCPool 解析RPC池的端点列表，格式为host:port@显存（MB，或带单位如24g），多个端点用+分隔
+func ParseRPCPool(spec string) ([]RPCEndpoint, error) {
+	var endpoints []RPCEndpoint
+	for _, item := range strings.Split(spec, "+") {
+		address, memory, _ := strings.Cut(strings.TrimSpace(item), "@")
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return nil, fmt.Errorf("invalid RPC endpoint %q: %v", item, err)
+		}
+		memoryMB, err := units.ParseSize(memory, units.MB)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory for RPC endpoint %q: %v", item, err)
 		}
+		if memoryMB <= 0 {
+			return nil, fmt.Errorf("invalid memory for RPC endpoint %q", item)
+		}
+		endpoints = append(endpoints, RPCEndpoint{Address: address, MemoryMB: int(memoryMB)})
 	}
-	return defaultValue
+	return endpoints, nil
+}
+
+// AutostartEntry 自动启动列表中的一个模型
+type AutostartEntry struct {
+	Name  string        // 模型名称
+	Delay time.Duration // 启动前等待的时间
 }
 
-// 辅助函数：获取布尔类型的环境变量
-func getEnvBool(key string, defaultValue bool) bool {
-	if value, exists := os.LookupEnv(key); exists {
-		return strings.ToLower(value) == "true" || value == "1"
+// ParseAutostart 解析自动启动列表，每项为模型名称或name@延迟（秒数，或带单位如30s、2m）
+func ParseAutostart(models []string) ([]AutostartEntry, error) {
+	entries := make([]AutostartEntry, 0, len(models))
+	seen := make(map[string]bool)
+	for _, item := range models {
+		name, delay, hasDelay := strings.Cut(strings.TrimSpace(item), "@")
+		if name == "" {
+			return nil, fmt.Errorf("invalid autostart entry %q: model name is required", item)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("model %s is listed more than once", name)
+		}
+		seen[name] = true
+		entry := AutostartEntry{Name: name}
+		if hasDelay {
+			seconds, err := units.ParseDuration(delay, time.Second)
+			if err != nil {
+				return nil, fmt.Errorf("invalid start delay for autostart entry %q: %v", item, err)
+			}
+			if seconds < 0 {
+				return nil, fmt.Errorf("invalid start delay for autostart entry %q", item)
+			}
+			entry.Delay = time.Duration(seconds) * time.Second
+		}
+		entries = append(entries, entry)
 	}
-	return defaultValue
+	return entries, nil
 }
 
 // ValidateConfig 验证配置
 func ValidateConfig(cfg *Config) error {
 	// 验证文件路径
+	if cfg.LLamaPath.Server == "" {
+		_, probed := FindBinary("llama-server")
+		return fmt.Errorf("llama-server not found, set LLAMA_SERVER_PATH or llama_path.server in the config file (looked in: %s)", strings.Join(probed, ", "))
+	}
 	if !fileExists(cfg.LLamaPath.Server) {
 		return fmt.Errorf("llama-server not found at: %s", cfg.LLamaPath.Server)
 	}
+	for name, path := range cfg.LLamaPath.Profiles {
+		if name == "" || path == "" {
+			return fmt.Errorf("invalid llama-server profile: %s=%s", name, path)
+		}
+		if !fileExists(path) {
+			return fmt.Errorf("llama-server for profile %s not found at: %s", name, path)
+		}
+	}
+	if cfg.LLamaPath.Bench == "" {
+		_, probed := FindBinary("llama-bench")
+		return fmt.Errorf("llama-bench not found, set LLAMA_BENCH_PATH or llama_path.bench in the config file (looked in: %s)", strings.Join(probed, ", "))
+	}
 	if !fileExists(cfg.LLamaPath.Bench) {
 		return fmt.Errorf("llama-bench not found at: %s", cfg.LLamaPath.Bench)
 	}
 
 	// 验证模型目录
+	if cfg.ModelsDir == "" {
+		_, probed := FindModelsDir()
+		return fmt.Errorf("models directory not found, set MODELS_DIR or models_dir in the config file (looked in: %s)", strings.Join(probed, ", "))
+	}
 	if !directoryExists(cfg.ModelsDir) {
 		return fmt.Errorf("models directory not found at: %s", cfg.ModelsDir)
 	}
@@ -202,6 +784,14 @@ func ValidateConfig(cfg *Config) error {
 	if cfg.Server.Timeout < 0 {
 		return fmt.Errorf("invalid timeout value: %d", cfg.Server.Timeout)
 	}
+	if cfg.Server.ShutdownTimeout < 0 {
+		return fmt.Errorf("invalid shutdown timeout value: %d", cfg.Server.ShutdownTimeout)
+	}
+
+	// 验证模型实例端口分配范围
+	if cfg.ModelPorts.RangeStart < 1 || cfg.ModelPorts.RangeEnd > 65535 || cfg.ModelPorts.RangeStart > cfg.ModelPorts.RangeEnd {
+		return fmt.Errorf("invalid model port range: %d-%d", cfg.ModelPorts.RangeStart, cfg.ModelPorts.RangeEnd)
+	}
 
 	// 验证模型参数
 	if cfg.DefaultModel.Threads < -1 {
@@ -225,6 +815,13 @@ func ValidateConfig(cfg *Config) error {
 	if !validSplitModes[cfg.GPU.SplitMode] {
 		return fmt.Errorf("invalid split mode: %s", cfg.GPU.SplitMode)
 	}
+	validProviders := map[string]bool{"auto": true, "nvidia": true, "amd": true, "intel": true, "apple": true}
+	if !validProviders[cfg.GPU.Provider] {
+		return fmt.Errorf("invalid GPU provider: %s", cfg.GPU.Provider)
+	}
+	if cfg.GPU.Placement != "bestfit" && cfg.GPU.Placement != "none" {
+		return fmt.Errorf("invalid GPU placement: %s", cfg.GPU.Placement)
+	}
 
 	// 验证缓存类型
 	validCacheTypes := map[string]bool{
@@ -258,6 +855,340 @@ func ValidateConfig(cfg *Config) error {
 	if !validLogLevels[strings.ToLower(cfg.Log.Level)] {
 		return fmt.Errorf("invalid log level: %s", cfg.Log.Level)
 	}
+	if cfg.Log.Format != "text" && cfg.Log.Format != "json" {
+		return fmt.Errorf("invalid log format: %s (must be text or json)", cfg.Log.Format)
+	}
+
+	// 验证模型实例输出日志配置
+	if cfg.ModelLog.MaxSizeMB <= 0 {
+		return fmt.Errorf("invalid model log max size: %d", cfg.ModelLog.MaxSizeMB)
+	}
+	if cfg.ModelLog.MaxFiles < 0 {
+		return fmt.Errorf("invalid model log max files: %d", cfg.ModelLog.MaxFiles)
+	}
+
+	// 验证推理代理配置
+	if cfg.Proxy.DefaultConcurrency < 0 {
+		return fmt.Errorf("invalid proxy default concurrency: %d", cfg.Proxy.DefaultConcurrency)
+	}
+	if cfg.Proxy.QueueSize < 0 {
+		return fmt.Errorf("invalid proxy queue size: %d", cfg.Proxy.QueueSize)
+	}
+	if cfg.Proxy.QueueTimeout < 0 {
+		return fmt.Errorf("invalid proxy queue timeout: %d", cfg.Proxy.QueueTimeout)
+	}
+	if cfg.Proxy.Port != 0 && (cfg.Proxy.Port < 1 || cfg.Proxy.Port > 65535 || cfg.Proxy.Port == cfg.Server.Port) {
+		return fmt.Errorf("invalid proxy port: %d (must differ from the server port)", cfg.Proxy.Port)
+	}
+	if cfg.Proxy.Host != "" && cfg.Proxy.Port == 0 {
+		return fmt.Errorf("proxy host specified but proxy port is not set")
+	}
+
+	// 验证嵌入请求合并配置
+	if cfg.Embedding.BatchEnabled {
+		if cfg.Embedding.BatchWindowMS <= 0 {
+			return fmt.Errorf("invalid embedding batch window: %d", cfg.Embedding.BatchWindowMS)
+		}
+		if cfg.Embedding.BatchMaxInputs <= 0 {
+			return fmt.Errorf("invalid embedding batch max inputs: %d", cfg.Embedding.BatchMaxInputs)
+		}
+	}
+
+	// 验证模型下载配置
+	if cfg.Download.HFEndpoint == "" {
+		return fmt.Errorf("hugging face endpoint is required")
+	}
+	if cfg.Download.MaxConcurrent <= 0 {
+		return fmt.Errorf("invalid max concurrent downloads: %d", cfg.Download.MaxConcurrent)
+	}
+
+	// 验证默认重排序模型配置
+	if cfg.Rerank.DefaultModel != "" {
+		if cfg.Rerank.DefaultName == "" {
+			return fmt.Errorf("default reranker name is required")
+		}
+		if cfg.Rerank.DefaultPort < 0 || cfg.Rerank.DefaultPort > 65535 {
+			return fmt.Errorf("invalid default reranker port: %d", cfg.Rerank.DefaultPort)
+		}
+		if cfg.Rerank.GPULayers < 0 {
+			return fmt.Errorf("invalid default reranker GPU layers: %d", cfg.Rerank.GPULayers)
+		}
+		if cfg.Rerank.StartupTimeout <= 0 {
+			return fmt.Errorf("invalid reranker startup timeout: %d", cfg.Rerank.StartupTimeout)
+		}
+	}
+
+	// 验证切换保护配置
+	if cfg.SwitchGuard.IdleSeconds < 0 {
+		return fmt.Errorf("invalid switch guard idle seconds: %d", cfg.SwitchGuard.IdleSeconds)
+	}
+	if cfg.SwitchGuard.DrainTimeout <= 0 {
+		return fmt.Errorf("invalid switch guard drain timeout: %d", cfg.SwitchGuard.DrainTimeout)
+	}
+
+	// 验证主机内存检查配置
+	if cfg.RAM.ReserveMB < 0 {
+		return fmt.Errorf("invalid RAM reserve: %d", cfg.RAM.ReserveMB)
+	}
+
+	// 验证驱逐策略配置
+	validPolicies := map[string]bool{"largest": true, "lru": true, "priority": true}
+	if !validPolicies[cfg.Eviction.Policy] {
+		return fmt.Errorf("invalid eviction policy: %s", cfg.Eviction.Policy)
+	}
+	if cfg.Eviction.PollIntervalMS <= 0 {
+		return fmt.Errorf("invalid eviction poll interval: %d", cfg.Eviction.PollIntervalMS)
+	}
+	if cfg.Eviction.StablePolls <= 0 {
+		return fmt.Errorf("invalid eviction stable polls: %d", cfg.Eviction.StablePolls)
+	}
+	if cfg.Eviction.ReleaseTimeout <= 0 {
+		return fmt.Errorf("invalid eviction release timeout: %d", cfg.Eviction.ReleaseTimeout)
+	}
+
+	// 验证自动启动配置
+	if _, err := ParseAutostart(cfg.Autostart.Models); err != nil {
+		return fmt.Errorf("invalid autostart list: %v", err)
+	}
+	if cfg.Autostart.CapacityTimeout < 0 {
+		return fmt.Errorf("invalid autostart capacity timeout: %d", cfg.Autostart.CapacityTimeout)
+	}
+
+	// 验证RPC池配置
+	for name, spec := range cfg.RPC.Pools {
+		if name == "" {
+			return fmt.Errorf("invalid RPC pool: %s=%s", name, spec)
+		}
+		if _, err := ParseRPCPool(spec); err != nil {
+			return fmt.Errorf("invalid RPC pool %s: %v", name, err)
+		}
+	}
+	if cfg.RPC.HealthInterval < 0 {
+		return fmt.Errorf("invalid RPC health interval: %d", cfg.RPC.HealthInterval)
+	}
+
+	// 验证健康检查配置
+	if cfg.HealthCheck.Interval < 0 {
+		return fmt.Errorf("invalid health check interval: %d", cfg.HealthCheck.Interval)
+	}
+	if cfg.HealthCheck.Timeout <= 0 {
+		return fmt.Errorf("invalid health check timeout: %d", cfg.HealthCheck.Timeout)
+	}
+
+	// 验证看门狗配置
+	if cfg.Watchdog.Failures < 0 {
+		return fmt.Errorf("invalid watchdog failure threshold: %d", cfg.Watchdog.Failures)
+	}
+	if cfg.Watchdog.LogLines < 0 {
+		return fmt.Errorf("invalid watchdog log lines: %d", cfg.Watchdog.LogLines)
+	}
+
+	// 验证事件日志配置
+	if cfg.Events.History <= 0 {
+		return fmt.Errorf("invalid event history size: %d", cfg.Events.History)
+	}
+
+	// 验证启动阶段配置
+	if cfg.Startup.Timeout < 0 {
+		return fmt.Errorf("invalid model startup timeout: %d", cfg.Startup.Timeout)
+	}
+	if cfg.Startup.OutputLines <= 0 {
+		return fmt.Errorf("invalid model startup output lines: %d", cfg.Startup.OutputLines)
+	}
+
+	// 验证模型停止配置
+	switch cfg.Stop.Signal {
+	case "SIGTERM", "SIGINT", "SIGQUIT", "SIGHUP":
+	default:
+		return fmt.Errorf("invalid model stop signal: %s", cfg.Stop.Signal)
+	}
+	if cfg.Stop.GracePeriod <= 0 {
+		return fmt.Errorf("invalid model stop grace period: %d", cfg.Stop.GracePeriod)
+	}
+
+	// 验证运行状态校正配置
+	if cfg.Reconcile.Interval < 0 {
+		return fmt.Errorf("invalid reconcile interval: %d", cfg.Reconcile.Interval)
+	}
+
+	// 验证声明式模型定义配置
+	if cfg.ModelDefs.Interval < 0 {
+		return fmt.Errorf("invalid model definitions check interval: %d", cfg.ModelDefs.Interval)
+	}
+
+	// 验证资源采样配置
+	if cfg.Resources.SampleInterval < 0 {
+		return fmt.Errorf("invalid resource sample interval: %d", cfg.Resources.SampleInterval)
+	}
+	if cfg.Resources.HistorySize <= 0 {
+		return fmt.Errorf("invalid resource history size: %d", cfg.Resources.HistorySize)
+	}
+	if cfg.Resources.VRAMLowMB < 0 {
+		return fmt.Errorf("invalid VRAM low threshold: %d", cfg.Resources.VRAMLowMB)
+	}
+	if cfg.VRAMHistory.Interval < 0 {
+		return fmt.Errorf("invalid VRAM history interval: %d", cfg.VRAMHistory.Interval)
+	}
+	if cfg.VRAMHistory.MaxSamples <= 0 {
+		return fmt.Errorf("invalid VRAM history max samples: %d", cfg.VRAMHistory.MaxSamples)
+	}
+	if cfg.Thermal.TempLimitC < 0 {
+		return fmt.Errorf("invalid GPU temperature limit: %d", cfg.Thermal.TempLimitC)
+	}
+	if cfg.Thermal.ThrottleSamples <= 0 {
+		return fmt.Errorf("invalid GPU throttle samples: %d", cfg.Thermal.ThrottleSamples)
+	}
+
+	// 验证分时共享配置
+	if cfg.TimeShare.Enabled {
+		if !filepath.IsAbs(cfg.TimeShare.SlotDir) {
+			return fmt.Errorf("timeshare slot directory must be absolute: %s", cfg.TimeShare.SlotDir)
+		}
+		if cfg.TimeShare.SwapTimeout <= 0 {
+			return fmt.Errorf("invalid timeshare swap timeout: %d", cfg.TimeShare.SwapTimeout)
+		}
+	}
+
+	// 验证事件订阅配置
+	if cfg.Webhooks.MaxAttempts <= 0 {
+		return fmt.Errorf("invalid webhook max attempts: %d", cfg.Webhooks.MaxAttempts)
+	}
+	if cfg.Webhooks.RetryDelay < 0 {
+		return fmt.Errorf("invalid webhook retry delay: %d", cfg.Webhooks.RetryDelay)
+	}
+
+	// 验证准确性冒烟测试配置
+	if cfg.Eval.Timeout <= 0 {
+		return fmt.Errorf("invalid eval timeout: %d", cfg.Eval.Timeout)
+	}
+
+	// 验证基准测试任务保留配置
+	if cfg.Benchmark.MaxTasks < 0 {
+		return fmt.Errorf("invalid benchmark max tasks: %d", cfg.Benchmark.MaxTasks)
+	}
+	if cfg.Benchmark.TaskMaxAge < 0 {
+		return fmt.Errorf("invalid benchmark task max age: %d", cfg.Benchmark.TaskMaxAge)
+	}
+	for _, webhookURL := range cfg.Benchmark.WebhookURLs {
+		if u, err := url.Parse(webhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid benchmark webhook url: %s", webhookURL)
+		}
+	}
+	if cfg.Benchmark.RegressionThreshold < 0 || cfg.Benchmark.RegressionThreshold > 100 {
+		return fmt.Errorf("invalid benchmark regression threshold: %d (should be between 0 and 100)", cfg.Benchmark.RegressionThreshold)
+	}
+	if cfg.Benchmark.OutputLimitKB < 0 {
+		return fmt.Errorf("invalid benchmark output limit: %d", cfg.Benchmark.OutputLimitKB)
+	}
+
+	// 验证跨域请求配置
+	for _, origin := range cfg.CORS.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return fmt.Errorf("invalid CORS origin: %s (expected scheme://host[:port] or *)", origin)
+		}
+	}
+	if cfg.CORS.MaxAge < 0 {
+		return fmt.Errorf("invalid CORS max age: %d", cfg.CORS.MaxAge)
+	}
+
+	// 验证请求频率限制配置
+	if cfg.RateLimit.PerMinute < 0 || cfg.RateLimit.Burst < 0 {
+		return fmt.Errorf("invalid rate limit: %d per minute, burst %d", cfg.RateLimit.PerMinute, cfg.RateLimit.Burst)
+	}
+	if cfg.RateLimit.SwitchPerMinute < 0 || cfg.RateLimit.SwitchBurst < 0 {
+		return fmt.Errorf("invalid switch rate limit: %d per minute, burst %d", cfg.RateLimit.SwitchPerMinute, cfg.RateLimit.SwitchBurst)
+	}
+
+	// 验证幂等键配置
+	if cfg.Idempotency.TTL < 0 || cfg.Idempotency.MaxKeys < 0 {
+		return fmt.Errorf("invalid idempotency settings: ttl %d, max keys %d", cfg.Idempotency.TTL, cfg.Idempotency.MaxKeys)
+	}
+
+	// 验证多租户配置
+	keys := make(map[string]bool, len(cfg.Tenants.APIKeys))
+	for name, key := range cfg.Tenants.APIKeys {
+		if name == "" || key == "" {
+			return fmt.Errorf("invalid tenant API key entry for tenant: %s", name)
+		}
+		if keys[key] || key == cfg.Security.APIKey {
+			return fmt.Errorf("API key for tenant %s is not unique", name)
+		}
+		keys[key] = true
+	}
+	// 验证访问控制配置，密钥不能与租户和管理员的密钥重复
+	for name, key := range cfg.Security.APIKeys {
+		if name == "" || key == "" {
+			return fmt.Errorf("invalid API key entry: %s", name)
+		}
+		if keys[key] || key == cfg.Security.APIKey {
+			return fmt.Errorf("API key %s is not unique", name)
+		}
+		keys[key] = true
+	}
+	for name, role := range cfg.Security.Roles {
+		if _, exists := cfg.Security.APIKeys[name]; !exists {
+			return fmt.Errorf("role set for unknown API key: %s", name)
+		}
+		if _, err := auth.ParseRole(role); err != nil {
+			return fmt.Errorf("invalid role for API key %s: %v", name, err)
+		}
+	}
+	// 验证JWT认证配置
+	if cfg.JWT.Issuer != "" {
+		if u, err := url.Parse(cfg.JWT.Issuer); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("invalid JWT issuer: %s", cfg.JWT.Issuer)
+		}
+		if cfg.JWT.Audience == "" {
+			return fmt.Errorf("JWT_AUDIENCE is required when JWT_ISSUER is set")
+		}
+		if cfg.JWT.JWKSURL != "" {
+			if u, err := url.Parse(cfg.JWT.JWKSURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return fmt.Errorf("invalid JWT JWKS URL: %s", cfg.JWT.JWKSURL)
+			}
+		}
+		if cfg.JWT.RoleClaim == "" {
+			return fmt.Errorf("JWT role claim must not be empty")
+		}
+		for value, role := range cfg.JWT.RoleMap {
+			if _, err := auth.ParseRole(role); err != nil {
+				return fmt.Errorf("invalid role for JWT claim value %s: %v", value, err)
+			}
+		}
+		if cfg.JWT.DefaultRole != "" {
+			if _, err := auth.ParseRole(cfg.JWT.DefaultRole); err != nil {
+				return fmt.Errorf("invalid JWT default role: %v", err)
+			}
+		}
+		if cfg.JWT.ClockSkew < 0 {
+			return fmt.Errorf("invalid JWT clock skew: %d", cfg.JWT.ClockSkew)
+		}
+	}
+	for name, quota := range cfg.Tenants.VRAMQuotas {
+		if _, exists := cfg.Tenants.APIKeys[name]; !exists {
+			return fmt.Errorf("VRAM quota set for unknown tenant: %s", name)
+		}
+		mb, err := units.ParseSize(quota, units.MB)
+		if err != nil {
+			return fmt.Errorf("invalid VRAM quota for tenant %s: %v", name, err)
+		}
+		if mb < 0 {
+			return fmt.Errorf("invalid VRAM quota for tenant %s: %s", name, quota)
+		}
+	}
+
+	// 验证邮件通知配置
+	if cfg.SMTP.Addr != "" {
+		if _, _, err := net.SplitHostPort(cfg.SMTP.Addr); err != nil {
+			return fmt.Errorf("invalid SMTP address %s: %v", cfg.SMTP.Addr, err)
+		}
+		if cfg.SMTP.From == "" {
+			return fmt.Errorf("SMTP_FROM is required when SMTP_ADDR is set")
+		}
+	}
 
 	// 验证SSL配置
 	if cfg.Security.SSLKey != "" && cfg.Security.SSLCert == "" {
@@ -266,6 +1197,22 @@ func ValidateConfig(cfg *Config) error {
 	if cfg.Security.SSLCert != "" && cfg.Security.SSLKey == "" {
 		return fmt.Errorf("SSL certificate file specified but key file is missing")
 	}
+	if cfg.Security.SSLClientCA != "" && cfg.Security.SSLCert == "" {
+		return fmt.Errorf("SSL client CA file specified but HTTPS is not enabled")
+	}
+	if cfg.Security.RedirectPort != 0 {
+		if cfg.Security.SSLCert == "" {
+			return fmt.Errorf("HTTP redirect port specified but HTTPS is not enabled")
+		}
+		if cfg.Security.RedirectPort < 1 || cfg.Security.RedirectPort > 65535 || cfg.Security.RedirectPort == cfg.Server.Port || cfg.Security.RedirectPort == cfg.Proxy.Port {
+			return fmt.Errorf("invalid HTTP redirect port: %d", cfg.Security.RedirectPort)
+		}
+	}
+
+	// 验证管理API的客户端网段
+	if _, err := ipfilter.New(cfg.Security.AllowedCIDRs, cfg.Security.DeniedCIDRs); err != nil {
+		return fmt.Errorf("invalid admin CIDR list: %v", err)
+	}
 
 	return nil
 }
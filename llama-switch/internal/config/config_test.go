@@ -0,0 +1,55 @@
+package config
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigEnvFileIsQuiet(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envFile, []byte("STATUS_PAGE_TITLE=From dotenv\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	t.Cleanup(func() { os.Unsetenv("STATUS_PAGE_TITLE") })
+
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	defer slog.SetDefault(previous)
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+
+	// 启动和重新加载配置都不在info级别或标准输出中报告.env的查找过程，加载结果由调用方报告
+	var configs []*Config
+	for i := 0; i < 2; i++ {
+		cfg, err := LoadConfig("")
+		if err != nil {
+			os.Stdout = stdout
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+		configs = append(configs, cfg)
+	}
+	os.Stdout = stdout
+	w.Close()
+	printed, _ := io.ReadAll(r)
+
+	for _, cfg := range configs {
+		if cfg.EnvFile != envFile || cfg.StatusPage.Title != "From dotenv" {
+			t.Errorf("env_file = %q, title = %q; want %s loaded", cfg.EnvFile, cfg.StatusPage.Title, envFile)
+		}
+	}
+	if len(printed) > 0 || logs.Len() > 0 {
+		t.Errorf("LoadConfig printed %q and logged %q", printed, logs.String())
+	}
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"llama-switch/internal/model"
+)
+
+// DefaultsFileName 运行时修改的默认模型配置的持久化文件名
+const DefaultsFileName = "defaults.json"
+
+// defaultsFields 可在运行时修改的默认配置项（按字段路径前缀匹配），即切换时用于填充请求中未指定参数的配置
+var defaultsFields = []string{
+	"default_model.",
+	"gpu.layers",
+	"gpu.split_mode",
+	"gpu.main_gpu",
+	"gpu.flash_attn",
+	"cache.",
+	"memory.",
+}
+
+// DefaultsOverrides 运行时修改的默认配置：覆盖项的结构与配置文件相同，优先于配置文件和环境变量
+type DefaultsOverrides struct {
+	Overrides map[string]interface{}  `json:"overrides"` // 累计的覆盖项
+	Changelog []*model.DefaultsChange `json:"changelog"` // 修改记录（按时间顺序）
+}
+
+// DefaultsPath 运行时修改的默认配置文件路径，与模型持久化配置在同一目录
+func DefaultsPath(cfg *Config) string {
+	return filepath.Join(PersistentDir(cfg), DefaultsFileName)
+}
+
+// LoadDefaultsOverrides 读取运行时修改的默认配置，文件不存在时返回空的覆盖项
+func LoadDefaultsOverrides(path string) (*DefaultsOverrides, error) {
+	overrides := &DefaultsOverrides{Overrides: map[string]interface{}{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return overrides, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read defaults file %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse defaults file %s: %v", path, err)
+	}
+	if overrides.Overrides == nil {
+		overrides.Overrides = map[string]interface{}{}
+	}
+	return overrides, nil
+}
+
+// Save 保存运行时修改的默认配置，先写入临时文件再重命名，避免写入中断导致文件损坏
+func (o *DefaultsOverrides) Save(path string) error {
+	data, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode defaults: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create defaults directory: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write defaults file: %v", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Merge 将一次修改合并到累计的覆盖项中
+func (o *DefaultsOverrides) Merge(patch map[string]interface{}) {
+	mergeSection(o.Overrides, patch)
+}
+
+// mergeSection 按键递归合并，patch中的值覆盖dst中的同名项
+func mergeSection(dst, patch map[string]interface{}) {
+	for key, value := range patch {
+		section, isSection := value.(map[string]interface{})
+		existing, hasSection := dst[key].(map[string]interface{})
+		if isSection && hasSection {
+			mergeSection(existing, section)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// ApplyDefaults 将默认配置的修改应用到cfg，修改的结构与配置文件相同（如{"default_model":{"ctx_size":8192}}）
+// 包含不存在的配置项或不可在运行时修改的配置项时返回错误且不修改cfg
+func ApplyDefaults(cfg *Config, patch map[string]interface{}) error {
+	for field := range flattenSection("", patch) {
+		if !matchField(defaultsFields, field) {
+			return fmt.Errorf("%s is not a default model setting (editable: %s)", field, strings.Join(defaultsFields, ", "))
+		}
+	}
+
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	// 先解码到副本中检查类型和字段名，成功后再修改cfg
+	next := *cfg
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&next); err != nil {
+		return fmt.Errorf("invalid defaults: %v", err)
+	}
+	configMu.Lock()
+	defer configMu.Unlock()
+	cfg.DefaultModel = next.DefaultModel
+	cfg.GPU = next.GPU
+	cfg.Cache = next.Cache
+	cfg.Memory = next.Memory
+	// 来源映射可能与配置的副本共享，复制后再记录
+	cfg.sources = maps.Clone(cfg.sources)
+	cfg.setSections(patch, SourceRuntime)
+	return nil
+}
+
+// flattenSection 将嵌套的配置片段展开为字段路径到值的映射
+func flattenSection(prefix string, section map[string]interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for key, value := range section {
+		field := key
+		if prefix != "" {
+			field = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			for k, v := range flattenSection(field, nested) {
+				fields[k] = v
+			}
+			continue
+		}
+		fields[field] = value
+	}
+	return fields
+}
+
+// loadDefaultsOverrides 加载配置时应用运行时修改的默认配置，文件无法读取或内容无效时忽略并给出警告
+func loadDefaultsOverrides(cfg *Config) {
+	path := DefaultsPath(cfg)
+	overrides, err := LoadDefaultsOverrides(path)
+	if err != nil {
+		slog.Warn("Ignoring runtime defaults", "error", err)
+		return
+	}
+	if len(overrides.Overrides) == 0 {
+		return
+	}
+	if err := ApplyDefaults(cfg, overrides.Overrides); err != nil {
+		slog.Warn("Ignoring runtime defaults", "file", path, "error", err)
+		return
+	}
+	slog.Debug("Applied runtime defaults", "file", path)
+}
+
+// Defaults 获取当前的默认模型配置，按字段路径索引
+func Defaults(cfg *Config) map[string]interface{} {
+	defaults := make(map[string]interface{})
+	for field, value := range flattenConfig(cfg) {
+		if matchField(defaultsFields, field) {
+			defaults[field] = value
+		}
+	}
+	return defaults
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// binarySearchDirs 各平台查找llama.cpp二进制文件的常见位置（PATH之后），不存在的环境变量对应的位置被忽略
+func binarySearchDirs() []string {
+	var dirs []string
+	if exePath, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(exePath))
+	}
+	if wd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, filepath.Join(wd, "build", "bin"))
+	}
+	home, _ := os.UserHomeDir()
+
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"LOCALAPPDATA", "ProgramFiles"} {
+			if base := os.Getenv(env); base != "" {
+				dirs = append(dirs, filepath.Join(base, "llama.cpp"))
+			}
+		}
+		if home != "" {
+			dirs = append(dirs, filepath.Join(home, "llama.cpp", "build", "bin", "Release"))
+		}
+	case "darwin":
+		dirs = append(dirs, "/opt/homebrew/bin", "/usr/local/bin")
+		if home != "" {
+			dirs = append(dirs, filepath.Join(home, "llama.cpp", "build", "bin"))
+		}
+	default:
+		dirs = append(dirs, "/usr/local/bin", "/usr/bin", "/opt/llama.cpp/bin", "/opt/llama.cpp/build/bin")
+		if home != "" {
+			dirs = append(dirs, filepath.Join(home, ".local", "bin"), filepath.Join(home, "llama.cpp", "build", "bin"))
+		}
+	}
+	return dirs
+}
+
+// FindBinary 查找llama.cpp二进制文件（如llama-server）：先在PATH中查找，再查找各平台的常见安装位置
+// 找到时返回其路径，否则返回空字符串；probed为依次检查过的位置，用于提示用户
+func FindBinary(name string) (path string, probed []string) {
+	probed = append(probed, "$PATH/"+name)
+	if path, err := exec.LookPath(name); err == nil {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		return path, probed
+	}
+
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	for _, dir := range binarySearchDirs() {
+		candidate := filepath.Join(dir, name)
+		probed = append(probed, candidate)
+		if fileExists(candidate) {
+			return candidate, probed
+		}
+	}
+	return "", probed
+}
+
+// FindModelsDir 查找模型目录：工作目录、程序目录和用户主目录下的models目录
+func FindModelsDir() (path string, probed []string) {
+	var dirs []string
+	if wd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, filepath.Join(wd, "models"))
+	}
+	if exePath, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Join(filepath.Dir(exePath), "models"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, "models"))
+	}
+	for _, dir := range dirs {
+		probed = append(probed, dir)
+		if directoryExists(dir) {
+			return dir, probed
+		}
+	}
+	return "", probed
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// isolateSearch 使用空的PATH和临时的用户主目录，返回主目录
+func isolateSearch(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("PATH", t.TempDir())
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("LOCALAPPDATA", filepath.Join(home, "AppData", "Local"))
+	t.Setenv("ProgramFiles", filepath.Join(home, "Program Files"))
+	return home
+}
+
+// writeExecutable 创建可执行文件，Windows上加上.exe扩展名
+func writeExecutable(t *testing.T, dir, name string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFindBinaryInPath(t *testing.T) {
+	isolateSearch(t)
+	binDir := t.TempDir()
+	want := writeExecutable(t, binDir, "llama-discover-test")
+	t.Setenv("PATH", binDir)
+
+	path, probed := FindBinary("llama-discover-test")
+	if path != want || !slices.Equal(probed, []string{"$PATH/llama-discover-test"}) {
+		t.Errorf("FindBinary = %s, %q; want %s found in PATH", path, probed, want)
+	}
+}
+
+func TestFindBinaryWellKnownLocations(t *testing.T) {
+	home := isolateSearch(t)
+
+	// 各平台在PATH之后查找的位置（不包括程序目录和工作目录下的build/bin）
+	var wellKnown []string
+	var buildDir string
+	switch runtime.GOOS {
+	case "windows":
+		wellKnown = []string{
+			filepath.Join(home, "AppData", "Local", "llama.cpp"),
+			filepath.Join(home, "Program Files", "llama.cpp"),
+		}
+		buildDir = filepath.Join(home, "llama.cpp", "build", "bin", "Release")
+	case "darwin":
+		wellKnown = []string{"/opt/homebrew/bin", "/usr/local/bin"}
+		buildDir = filepath.Join(home, "llama.cpp", "build", "bin")
+	default:
+		wellKnown = []string{"/usr/local/bin", "/usr/bin", "/opt/llama.cpp/bin", "/opt/llama.cpp/build/bin", filepath.Join(home, ".local", "bin")}
+		buildDir = filepath.Join(home, "llama.cpp", "build", "bin")
+	}
+	wellKnown = append(wellKnown, buildDir)
+
+	// 找不到时返回依次检查过的全部位置
+	path, probed := FindBinary("llama-discover-test")
+	if path != "" {
+		t.Fatalf("FindBinary found %s with an empty PATH", path)
+	}
+	if len(probed) == 0 || probed[0] != "$PATH/llama-discover-test" {
+		t.Fatalf("probed = %q, want PATH first", probed)
+	}
+	var dirs []string
+	for _, candidate := range probed[1:] {
+		dirs = append(dirs, filepath.Dir(candidate))
+	}
+	if !slices.Equal(dirs[len(dirs)-len(wellKnown):], wellKnown) {
+		t.Errorf("probed dirs = %q, want them to end with %q", dirs, wellKnown)
+	}
+
+	// 用户主目录下自行编译的llama.cpp
+	want := writeExecutable(t, buildDir, "llama-discover-test")
+	if path, probed := FindBinary("llama-discover-test"); path != want || probed[len(probed)-1] != want {
+		t.Errorf("FindBinary = %s, %q; want %s", path, probed, want)
+	}
+}
+
+func TestValidateConfigReportsProbedPaths(t *testing.T) {
+	isolateSearch(t)
+	if path, _ := FindBinary("llama-server"); path != "" {
+		t.Skipf("llama-server installed at %s", path)
+	}
+
+	err := ValidateConfig(&Config{})
+	if err == nil {
+		t.Fatal("ValidateConfig succeeded without llama-server")
+	}
+	_, probed := FindBinary("llama-server")
+	want := "llama-server not found, set LLAMA_SERVER_PATH or llama_path.server in the config file (looked in: " + strings.Join(probed, ", ") + ")"
+	if err.Error() != want {
+		t.Errorf("ValidateConfig error = %q, want %q", err, want)
+	}
+}
+
+func TestFindModelsDir(t *testing.T) {
+	home := isolateSearch(t)
+	want := filepath.Join(home, "models")
+
+	// 工作目录、程序目录和用户主目录依次查找
+	path, probed := FindModelsDir()
+	if path != "" || len(probed) != 3 || probed[2] != want {
+		t.Errorf("FindModelsDir = %s, %q; want nothing found, ending with %s", path, probed, want)
+	}
+
+	if err := os.Mkdir(want, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if path, _ := FindModelsDir(); path != want {
+		t.Errorf("FindModelsDir = %s, want %s", path, want)
+	}
+}
@@ -0,0 +1,463 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"llama-switch/internal/model"
+	"llama-switch/internal/units"
+)
+
+// 配置项的来源
+const (
+	SourceDefault = "default" // 程序默认值
+	SourceFile    = "file"    // 配置文件（包括LLAMA_SWITCH_PROFILE选择的环境配置）
+	SourceEnv     = "env"     // 环境变量（包括.env文件）
+	SourceRuntime = "runtime" // 通过PATCH /api/v1/config/defaults在运行时修改
+)
+
+// envVar 一个环境变量及其覆盖的配置项
+type envVar struct {
+	name        string              // 环境变量名
+	field       string              // 配置项路径，与配置文件中的字段路径相同（如server.port）
+	description string              // 说明，由GET /api/v1/config/env返回
+	transform   func(string) string // 赋值前对值的转换（如统一大小写），为nil时不转换
+	secret      bool                // 值不在GET /api/v1/config/env中显示
+}
+
+// envVars 环境变量表，LoadConfig按顺序用其中的环境变量覆盖默认值和配置文件
+// 值按配置项的类型解析：列表为逗号分隔，映射为逗号分隔的NAME=VALUE，大小和时间可以带单位
+var envVars = []envVar{
+	// 二进制文件路径
+	{name: "LLAMA_SERVER_PATH", field: "llama_path.server", description: "Path to the llama-server binary (searched in PATH and common locations when empty)"},
+	{name: "LLAMA_BENCH_PATH", field: "llama_path.bench", description: "Path to the llama-bench binary (searched in PATH and common locations when empty)"},
+	{name: "LLAMA_SERVER_PROFILES", field: "llama_path.profiles", description: "Alternative llama-server builds selectable by backend_profile, as name=path pairs"},
+
+	// 模型目录和持久化配置目录
+	{name: "MODELS_DIR", field: "models_dir", description: "Directory containing model files"},
+	{name: "PERSISTENT_DIR", field: "persistent_dir", description: "Directory for model_persistent.json and runtime defaults (program directory/config when empty)"},
+
+	// 服务器配置
+	{name: "SERVER_HOST", field: "server.host", description: "API server listen address"},
+	{name: "SERVER_PORT", field: "server.port", description: "API server listen port"},
+	{name: "SERVER_TIMEOUT", field: "server.timeout", description: "API server timeout in seconds"},
+	{name: "SERVER_SHUTDOWN_TIMEOUT", field: "server.shutdown_timeout", description: "Time to wait for in-flight requests to finish on shutdown before closing connections"},
+	{name: "SERVER_PID_FILE", field: "server.pid_file", description: "PID lock file of the switcher itself"},
+
+	// 模型实例端口分配范围
+	{name: "MODEL_PORT_RANGE_START", field: "model_ports.range_start", description: "First port assigned to model instances without an explicit port"},
+	{name: "MODEL_PORT_RANGE_END", field: "model_ports.range_end", description: "Last port (inclusive) assigned to model instances without an explicit port"},
+
+	// 默认模型配置
+	{name: "DEFAULT_THREADS", field: "default_model.threads", description: "Default number of generation threads"},
+	{name: "DEFAULT_CTX_SIZE", field: "default_model.ctx_size", description: "Default context size"},
+	{name: "DEFAULT_BATCH_SIZE", field: "default_model.batch_size", description: "Default logical batch size"},
+	{name: "DEFAULT_UBATCH_SIZE", field: "default_model.ubatch_size", description: "Default physical (micro) batch size"},
+
+	// GPU配置
+	{name: "DEFAULT_GPU_LAYERS", field: "gpu.layers", description: "Default number of layers offloaded to the GPU"},
+	{name: "DEFAULT_SPLIT_MODE", field: "gpu.split_mode", description: "Default multi-GPU split mode (none/layer/row)"},
+	{name: "DEFAULT_MAIN_GPU", field: "gpu.main_gpu", description: "Default main GPU index"},
+	{name: "ENABLE_FLASH_ATTN", field: "gpu.flash_attn", description: "Enable flash attention by default"},
+	{name: "GPU_PROVIDER", field: "gpu.provider", description: "GPU tool used to query VRAM (auto/nvidia/amd/intel/apple)", transform: strings.ToLower},
+	{name: "GPU_PLACEMENT", field: "gpu.placement", description: "Multi-GPU model placement strategy (bestfit/none)", transform: strings.ToLower},
+	{name: "GPU_AUTO_TENSOR_SPLIT", field: "gpu.auto_tensor_split", description: "Compute tensor_split from free VRAM when a model spans several GPUs"},
+
+	// 缓存配置
+	{name: "DEFAULT_CACHE_TYPE_K", field: "cache.type_k", description: "Default KV cache type for K"},
+	{name: "DEFAULT_CACHE_TYPE_V", field: "cache.type_v", description: "Default KV cache type for V"},
+
+	// 内存管理配置
+	{name: "ENABLE_MLOCK", field: "memory.mlock", description: "Lock model memory by default"},
+	{name: "ENABLE_MMAP", field: "memory.mmap", description: "Memory-map model files by default"},
+	{name: "NUMA_STRATEGY", field: "memory.numa", description: "Default NUMA strategy"},
+
+	// 日志配置
+	{name: "LOG_LEVEL", field: "log.level", description: "Log level"},
+	{name: "LOG_FILE", field: "log.file", description: "Log file"},
+	{name: "ENABLE_CONSOLE_LOG", field: "log.enable_console", description: "Also log to the console"},
+	{name: "LOG_FORMAT", field: "log.format", description: "Log output format: text or json"},
+
+	// 模型实例输出日志配置
+	{name: "MODEL_LOG_DIR", field: "model_log.dir", description: "Directory for model instance logs (program directory/logs when empty)"},
+	{name: "MODEL_LOG_MAX_SIZE_MB", field: "model_log.max_size_mb", description: "Size in MB at which a model log file is rotated"},
+	{name: "MODEL_LOG_MAX_FILES", field: "model_log.max_files", description: "Number of rotated model log files to keep"},
+	{name: "MODEL_LOG_CONSOLE", field: "model_log.console", description: "Also write model output to the console"},
+
+	// 推理代理配置
+	{name: "PROXY_ENABLED", field: "proxy.enabled", description: "Enable the /v1 inference proxy"},
+	{name: "PROXY_HOST", field: "proxy.host", description: "Listen address of a separate inference proxy listener (server host when empty)"},
+	{name: "PROXY_PORT", field: "proxy.port", description: "Port of a separate inference proxy listener, 0 to serve the proxy on the API server port"},
+	{name: "PROXY_DEFAULT_CONCURRENCY", field: "proxy.default_concurrency", description: "Per-model concurrency limit when parallel is not set"},
+	{name: "PROXY_QUEUE_SIZE", field: "proxy.queue_size", description: "Maximum number of requests queued beyond the concurrency limit"},
+	{name: "PROXY_QUEUE_TIMEOUT", field: "proxy.queue_timeout", description: "Queue wait timeout in seconds"},
+
+	// 嵌入请求路由配置
+	{name: "EMBEDDING_BATCH_ENABLED", field: "embedding.batch_enabled", description: "Merge small embedding requests before forwarding"},
+	{name: "EMBEDDING_BATCH_WINDOW_MS", field: "embedding.batch_window_ms", description: "Embedding batching window in milliseconds"},
+	{name: "EMBEDDING_BATCH_MAX_INPUTS", field: "embedding.batch_max_inputs", description: "Maximum inputs in one merged embedding batch"},
+
+	// 模型下载配置
+	{name: "HF_ENDPOINT", field: "download.hf_endpoint", description: "Hugging Face endpoint used for downloads (may be a mirror)"},
+	{name: "DOWNLOAD_MAX_CONCURRENT", field: "download.max_concurrent", description: "Maximum concurrent downloads"},
+
+	// 重排序请求路由配置
+	{name: "RERANK_DEFAULT_MODEL", field: "rerank.default_model", description: "Model file started automatically when no reranker is running"},
+	{name: "RERANK_DEFAULT_NAME", field: "rerank.default_name", description: "Name of the automatically started reranker"},
+	{name: "RERANK_DEFAULT_PORT", field: "rerank.default_port", description: "Port of the automatically started reranker"},
+	{name: "RERANK_GPU_LAYERS", field: "rerank.gpu_layers", description: "GPU layers of the automatically started reranker"},
+	{name: "RERANK_STARTUP_TIMEOUT", field: "rerank.startup_timeout", description: "Seconds to wait for the automatically started reranker to become ready"},
+
+	// 切换保护配置
+	{name: "SWITCH_GUARD_ENABLED", field: "switch_guard.enabled", description: "Refuse to stop or evict models that are in use"},
+	{name: "SWITCH_GUARD_IDLE_SECONDS", field: "switch_guard.idle_seconds", description: "A model that served a request within this many seconds is in use"},
+	{name: "SWITCH_GUARD_DRAIN_TIMEOUT", field: "switch_guard.drain_timeout", description: "Default seconds to wait for in-flight requests when stopping with drain"},
+
+	// 主机内存检查配置
+	{name: "RAM_CHECK_ENABLED", field: "ram.check_enabled", description: "Check available host memory before starting a model"},
+	{name: "RAM_RESERVE_MB", field: "ram.reserve_mb", description: "Host memory in MB reserved for the system and other processes"},
+
+	// 驱逐策略配置
+	{name: "EVICTION_POLICY", field: "eviction.policy", description: "Eviction order when VRAM is short (largest/lru/priority)", transform: strings.ToLower},
+	{name: "EVICTION_POLL_INTERVAL_MS", field: "eviction.poll_interval_ms", description: "Milliseconds between free memory polls after stopping a model"},
+	{name: "EVICTION_STABLE_POLLS", field: "eviction.stable_polls", description: "Consecutive unchanged readings after which memory is considered released"},
+	{name: "EVICTION_RELEASE_TIMEOUT", field: "eviction.release_timeout", description: "Maximum seconds to wait for memory to be released"},
+
+	// RPC池配置
+	{name: "RPC_POOLS", field: "rpc.pools", description: "Remote rpc-server pools, as name=host:port@MB+host:port@MB pairs"},
+	{name: "RPC_HEALTH_INTERVAL", field: "rpc.health_interval", description: "Seconds between RPC endpoint probes (0 probes only when switching)"},
+
+	// 健康检查配置
+	{name: "HEALTH_CHECK_INTERVAL", field: "health_check.interval", description: "Seconds between model health probes (0 disables background probes)"},
+	{name: "HEALTH_CHECK_TIMEOUT", field: "health_check.timeout", description: "Timeout of a single health probe in seconds"},
+
+	// 看门狗配置
+	{name: "WATCHDOG_FAILURES", field: "watchdog.failures", description: "Consecutive failed probes after which a live but stuck instance is killed (0 disables)"},
+	{name: "WATCHDOG_RESTART", field: "watchdog.restart", description: "Restart instances killed by the watchdog"},
+	{name: "WATCHDOG_LOG_LINES", field: "watchdog.log_lines", description: "Recent log lines saved in the watchdog diagnostic snapshot"},
+
+	// 事件日志配置
+	{name: "EVENTS_FILE", field: "events.file", description: "Event log file (program directory/events.jsonl when empty)"},
+	{name: "EVENTS_HISTORY", field: "events.history", description: "Number of recent events kept in memory"},
+
+	// 审计日志配置
+	{name: "AUDIT_ENABLED", field: "audit.enabled", description: "Record mutating API requests in the audit log"},
+	{name: "AUDIT_FILE", field: "audit.file", description: "Audit log file (program directory/audit.jsonl when empty)"},
+
+	// 启动阶段配置
+	{name: "MODEL_STARTUP_TIMEOUT", field: "startup.timeout", description: "Seconds to wait for an instance to become ready (0 does not wait)"},
+	{name: "MODEL_STARTUP_OUTPUT_LINES", field: "startup.output_lines", description: "Recent output lines returned when startup fails"},
+
+	// 模型停止配置
+	{name: "MODEL_STOP_SIGNAL", field: "stop.signal", description: "Signal asking a model process to exit gracefully", transform: strings.ToUpper},
+	{name: "MODEL_STOP_GRACE_PERIOD", field: "stop.grace_period", description: "Seconds to wait for a model process to exit before killing it"},
+
+	// 运行状态校正配置
+	{name: "RECONCILE_INTERVAL", field: "reconcile.interval", description: "Seconds between state reconciliation runs (0 disables)"},
+
+	// 模型工作目录配置
+	{name: "MODEL_WORKDIR_ROOT", field: "work_dir.root", description: "Parent directory of per-model working directories"},
+	{name: "MODEL_SANDBOX", field: "work_dir.sandbox", description: "Restrict file paths in model parameters to the working and models directories"},
+
+	// 模型环境变量和额外参数配置
+	{name: "MODEL_ENV_ALLOWLIST", field: "model_env.allowlist", description: "Environment variables switch requests may set (trailing * matches a prefix)"},
+	{name: "EXTRA_ARGS_ALLOWLIST", field: "extra_args.allowlist", description: "llama-server flags allowed in extra_args (trailing * matches a prefix)"},
+
+	// 资源限制配置
+	{name: "CGROUP_ROOT", field: "limits.cgroup_root", description: "Parent cgroup for model processes on Linux"},
+
+	// 资源采样配置
+	{name: "RESOURCE_SAMPLE_INTERVAL", field: "resources.sample_interval", description: "Seconds between resource samples (0 disables)"},
+	{name: "RESOURCE_HISTORY_SIZE", field: "resources.history_size", description: "Resource samples kept per model"},
+	{name: "VRAM_LOW_MB", field: "resources.vram_low_mb", description: "Free VRAM of a GPU below which a vram.low event is sent (0 disables)"},
+
+	// 显存使用历史配置
+	{name: "VRAM_HISTORY_DIR", field: "vram_history.dir", description: "Directory for VRAM usage history"},
+	{name: "VRAM_HISTORY_INTERVAL", field: "vram_history.interval", description: "Seconds between VRAM history samples (0 disables)"},
+	{name: "VRAM_HISTORY_MAX_SAMPLES", field: "vram_history.max_samples", description: "VRAM history samples kept per model"},
+
+	// GPU温度告警配置
+	{name: "GPU_TEMP_LIMIT_C", field: "thermal.temp_limit_c", description: "GPU temperature in Celsius considered overheating (0 uses throttle reasons only)"},
+	{name: "GPU_THROTTLE_SAMPLES", field: "thermal.throttle_samples", description: "Consecutive throttled samples before alerting"},
+
+	// 分时共享配置
+	{name: "TIMESHARE_ENABLED", field: "timeshare.enabled", description: "Enable GPU time sharing (experimental)"},
+	{name: "TIMESHARE_SLOT_DIR", field: "timeshare.slot_dir", description: "Directory for saved slot caches"},
+	{name: "TIMESHARE_SWAP_TIMEOUT", field: "timeshare.swap_timeout", description: "Seconds to wait for a model to become ready when swapping"},
+
+	// 公开状态页配置
+	{name: "STATUS_PAGE_ENABLED", field: "status_page.enabled", description: "Enable the unauthenticated read-only status page"},
+	{name: "STATUS_PAGE_TITLE", field: "status_page.title", description: "Status page title"},
+
+	// Prometheus指标配置
+	{name: "METRICS_ENABLED", field: "metrics.enabled", description: "Expose Prometheus metrics at /metrics (requires the read-only role when API keys are configured)"},
+	{name: "METRICS_INSTANCES", field: "metrics.instances", description: "Also re-export metrics of instances started with metrics enabled, labelled by model_name"},
+
+	// 声明式模型定义配置
+	{name: "MODEL_DEFS_DIR", field: "model_defs.dir", description: "Directory of declarative model definitions (models.d next to the config file when empty)"},
+	{name: "MODEL_DEFS_INTERVAL", field: "model_defs.interval", description: "Seconds between checks for definition changes (0 loads only at startup)"},
+
+	// 自动启动配置
+	{name: "AUTOSTART_MODELS", field: "autostart.models", description: "Models started in order at startup; name@delay waits before starting"},
+	{name: "AUTOSTART_CAPACITY_TIMEOUT", field: "autostart.capacity_timeout", description: "Maximum seconds to wait for VRAM before skipping an autostart model"},
+
+	// 模型别名配置
+	{name: "ALIAS_FILE", field: "alias.file", description: "File storing aliases and their changelog"},
+	{name: "ALIAS_WEBHOOK_URL", field: "alias.webhook_url", description: "Webhook notified when an alias changes"},
+
+	// 事件订阅配置
+	{name: "WEBHOOKS_FILE", field: "webhooks.file", description: "File storing webhook subscriptions"},
+	{name: "WEBHOOK_MAX_ATTEMPTS", field: "webhooks.max_attempts", description: "Delivery attempts per webhook notification, including the first"},
+	{name: "WEBHOOK_RETRY_DELAY", field: "webhooks.retry_delay", description: "Seconds before the first webhook retry, doubled after each attempt"},
+
+	// 准确性冒烟测试配置
+	{name: "EVAL_FILE", field: "eval.file", description: "File storing eval suites"},
+	{name: "EVAL_TIMEOUT", field: "eval.timeout", description: "Timeout of a single eval case in seconds"},
+
+	// 基准测试配置
+	{name: "BENCHMARK_MANIFEST_DIR", field: "benchmark.manifest_dir", description: "Directory for reproducible benchmark manifests"},
+	{name: "BENCHMARK_HISTORY_FILE", field: "benchmark.history_file", description: "History file of finished benchmark tasks"},
+	{name: "BENCHMARK_SCHEDULE_FILE", field: "benchmark.schedule_file", description: "File storing scheduled benchmarks"},
+	{name: "BENCHMARK_MAX_TASKS", field: "benchmark.max_tasks", description: "Maximum benchmark tasks kept in memory (0 is unlimited)"},
+	{name: "BENCHMARK_TASK_MAX_AGE", field: "benchmark.task_max_age", description: "Seconds finished benchmark tasks are kept in memory (0 is unlimited)"},
+	{name: "BENCHMARK_WEBHOOK_URLS", field: "benchmark.webhook_urls", description: "URLs notified when a benchmark task finishes"},
+	{name: "BENCHMARK_BASELINE_FILE", field: "benchmark.baseline_file", description: "File storing pinned benchmark baselines"},
+	{name: "BENCHMARK_REGRESSION_THRESHOLD", field: "benchmark.regression_threshold", description: "Percentage below the baseline flagged as a regression"},
+	{name: "BENCHMARK_OUTPUT_LIMIT_KB", field: "benchmark.output_limit_kb", description: "KB of stdout and stderr kept in memory per benchmark task (0 is unlimited)"},
+
+	// 邮件通知配置
+	{name: "SMTP_ADDR", field: "smtp.addr", description: "SMTP server (host:port) for regression emails"},
+	{name: "SMTP_USERNAME", field: "smtp.username", description: "SMTP username"},
+	{name: "SMTP_PASSWORD", field: "smtp.password", description: "SMTP password (environment only)", secret: true},
+	{name: "SMTP_FROM", field: "smtp.from", description: "Sender address of notification emails"},
+
+	// 跨域请求配置
+	{name: "CORS_ALLOWED_ORIGINS", field: "cors.allowed_origins", description: "Origins allowed to call the API from a browser (* for any), empty to disable CORS"},
+	{name: "CORS_ALLOWED_METHODS", field: "cors.allowed_methods", description: "Methods allowed in cross-origin requests"},
+	{name: "CORS_ALLOWED_HEADERS", field: "cors.allowed_headers", description: "Request headers allowed in cross-origin requests"},
+	{name: "CORS_MAX_AGE", field: "cors.max_age", description: "Seconds browsers may cache a preflight response"},
+
+	// 请求频率限制配置
+	{name: "RATE_LIMIT_PER_MINUTE", field: "rate_limit.per_minute", description: "Management API requests allowed per minute per API key or client IP, 0 for unlimited"},
+	{name: "RATE_LIMIT_BURST", field: "rate_limit.burst", description: "Burst of management API requests, 0 for the per-minute limit"},
+	{name: "RATE_LIMIT_SWITCH_PER_MINUTE", field: "rate_limit.switch_per_minute", description: "Switch requests allowed per minute, counted separately; 0 to count them with other requests"},
+	{name: "RATE_LIMIT_SWITCH_BURST", field: "rate_limit.switch_burst", description: "Burst of switch requests, 0 for the switch per-minute limit"},
+
+	// 幂等键配置
+	{name: "IDEMPOTENCY_TTL", field: "idempotency.ttl", description: "How long responses to switch and stop requests with an Idempotency-Key are replayed, 0 to disable"},
+	{name: "IDEMPOTENCY_MAX_KEYS", field: "idempotency.max_keys", description: "Maximum number of idempotency keys kept"},
+
+	// 多租户配置
+	{name: "TENANT_API_KEYS", field: "tenants.api_keys", description: "Tenant API keys, as tenant=key pairs", secret: true},
+	{name: "TENANT_VRAM_QUOTAS", field: "tenants.vram_quotas", description: "Tenant VRAM quotas in MB, as tenant=quota pairs"},
+
+	// 安全配置
+	{name: "API_KEY", field: "security.api_key", description: "Administrator API key", secret: true},
+	{name: "API_KEYS", field: "security.api_keys", description: "Additional API keys, as name=key pairs", secret: true},
+	{name: "API_KEY_ROLES", field: "security.roles", description: "Roles of the API_KEYS entries (admin, operator, readonly), as name=role pairs"},
+	{name: "SSL_KEY_FILE", field: "security.ssl_key", description: "TLS private key file"},
+	{name: "SSL_CERT_FILE", field: "security.ssl_cert", description: "TLS certificate file"},
+	{name: "SSL_CLIENT_CA_FILE", field: "security.ssl_client_ca", description: "CA file for verifying client certificates (mTLS)"},
+	{name: "HTTP_REDIRECT_PORT", field: "security.http_redirect_port", description: "Port that redirects plain HTTP to HTTPS, 0 to disable"},
+	{name: "ADMIN_ALLOWED_CIDRS", field: "security.admin_allowed_cidrs", description: "Client networks (CIDRs or IPs) allowed to use the management API, empty for any"},
+	{name: "ADMIN_DENIED_CIDRS", field: "security.admin_denied_cidrs", description: "Client networks denied access to the management API, checked before the allowlist"},
+
+	// JWT认证配置
+	{name: "JWT_ISSUER", field: "jwt.issuer", description: "Issuer of accepted JWTs; enables JWT authentication"},
+	{name: "JWT_AUDIENCE", field: "jwt.audience", description: "Audience that accepted JWTs must contain"},
+	{name: "JWT_JWKS_URL", field: "jwt.jwks_url", description: "JWKS URL of the signing keys, empty to use OIDC discovery on the issuer"},
+	{name: "JWT_ROLE_CLAIM", field: "jwt.role_claim", description: "Claim holding the roles, dot-separated for nested claims"},
+	{name: "JWT_ROLE_MAP", field: "jwt.role_map", description: "Claim values mapped to roles (admin, operator, readonly), as value=role pairs"},
+	{name: "JWT_DEFAULT_ROLE", field: "jwt.default_role", description: "Role for valid tokens without a mapped role, empty to reject them"},
+	{name: "JWT_CLOCK_SKEW", field: "jwt.clock_skew", description: "Clock skew allowed when checking token expiry"},
+}
+
+// applyEnv 用环境变量表中已设置的环境变量覆盖cfg并记录来源，大小和时间类的值无效时返回所有错误
+// 整数和布尔值的解析与之前相同：无效的整数被忽略，布尔值只有true和1为真
+func applyEnv(cfg *Config) []error {
+	var errs []error
+	root := reflect.ValueOf(cfg).Elem()
+	for _, env := range envVars {
+		value, exists := os.LookupEnv(env.name)
+		if !exists {
+			continue
+		}
+		if env.transform != nil {
+			value = env.transform(value)
+		}
+		field, ok := configField(root, env.field)
+		if !ok {
+			panic(fmt.Sprintf("environment variable %s refers to unknown config field %s", env.name, env.field))
+		}
+		if err := setEnvValue(field, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", env.name, err))
+			continue
+		}
+		cfg.setSource(env.field, SourceEnv)
+	}
+	return errs
+}
+
+// setEnvValue 按配置项的类型解析环境变量的值
+func setEnvValue(field reflect.Value, value string) error {
+	switch field.Type() {
+	case reflect.TypeFor[units.Megabytes]():
+		return setParsed(field, value, func(s string) (int64, error) { return units.ParseSize(s, units.MB) })
+	case reflect.TypeFor[units.Kilobytes]():
+		return setParsed(field, value, func(s string) (int64, error) { return units.ParseSize(s, units.KB) })
+	case reflect.TypeFor[units.Seconds]():
+		return setParsed(field, value, func(s string) (int64, error) { return units.ParseDuration(s, time.Second) })
+	case reflect.TypeFor[units.Milliseconds]():
+		return setParsed(field, value, func(s string) (int64, error) { return units.ParseDuration(s, time.Millisecond) })
+	case reflect.TypeFor[[]string]():
+		field.Set(reflect.ValueOf(splitList(value)))
+		return nil
+	case reflect.TypeFor[map[string]string]():
+		field.Set(reflect.ValueOf(splitMap(value)))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		if n, err := strconv.Atoi(value); err == nil {
+			field.SetInt(int64(n))
+		}
+	case reflect.Bool:
+		field.SetBool(strings.ToLower(value) == "true" || value == "1")
+	default:
+		return fmt.Errorf("unsupported config field type %s", field.Type())
+	}
+	return nil
+}
+
+// setParsed 用parse解析大小或时间并赋值
+func setParsed(field reflect.Value, value string, parse func(string) (int64, error)) error {
+	n, err := parse(value)
+	if err != nil {
+		return err
+	}
+	field.SetInt(n)
+	return nil
+}
+
+// splitList 解析逗号分隔的列表，忽略空项
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// splitMap 解析以逗号分隔的NAME=VALUE列表
+func splitMap(value string) map[string]string {
+	values := make(map[string]string)
+	for _, item := range splitList(value) {
+		name, value, _ := strings.Cut(item, "=")
+		values[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return values
+}
+
+// configField 按配置项路径（json标签，json标签为-的字段按小写的字段名）查找配置结构中的字段
+func configField(v reflect.Value, path string) (reflect.Value, bool) {
+	for _, name := range strings.Split(path, ".") {
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		found := false
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if tag == name || (tag == "-" && strings.ToLower(field.Name) == name) {
+				v, found = v.Field(i), true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, false
+		}
+	}
+	return v, true
+}
+
+// setSource 记录配置项的来源，之后的来源覆盖之前的来源
+func (c *Config) setSource(field, source string) {
+	if c.sources == nil {
+		c.sources = make(map[string]string)
+	}
+	c.sources[field] = source
+}
+
+// setSections 记录配置文件片段中出现的所有配置项的来源
+func (c *Config) setSections(section map[string]interface{}, source string) {
+	for field := range flattenSection("", section) {
+		c.setSource(field, source)
+	}
+}
+
+// source 获取配置项的来源：映射和结构类配置项取其中最后设置的来源，未记录时为默认值
+func (c *Config) source(field string) string {
+	if source, exists := c.sources[field]; exists {
+		return source
+	}
+	result := SourceDefault
+	for key, source := range c.sources {
+		if strings.HasPrefix(key, field+".") && sourceRank(source) > sourceRank(result) {
+			result = source
+		}
+	}
+	return result
+}
+
+// sourceRank 来源的优先级，与LoadConfig的覆盖顺序相同
+func sourceRank(source string) int {
+	return slices.Index([]string{SourceDefault, SourceFile, SourceEnv, SourceRuntime}, source)
+}
+
+// EnvVars 获取所有可识别的环境变量、对应的配置项、当前值及其来源，密钥类的值以******代替
+func EnvVars(cfg *Config) []model.EnvVarInfo {
+	root := reflect.ValueOf(cfg).Elem()
+	vars := make([]model.EnvVarInfo, 0, len(envVars))
+	for _, env := range envVars {
+		info := model.EnvVarInfo{
+			Name:        env.name,
+			Field:       env.field,
+			Description: env.description,
+			Source:      cfg.source(env.field),
+		}
+		if field, ok := configField(root, env.field); ok {
+			info.Value = envValue(field)
+		}
+		if env.secret && info.Value != "" {
+			info.Value = "******"
+		}
+		vars = append(vars, info)
+	}
+	return vars
+}
+
+// envValue 将配置项的当前值格式化为环境变量的写法
+func envValue(field reflect.Value) string {
+	switch v := field.Interface().(type) {
+	case []string:
+		return strings.Join(v, ",")
+	case map[string]string:
+		items := make([]string, 0, len(v))
+		for _, name := range slices.Sorted(maps.Keys(v)) {
+			items = append(items, name+"="+v[name])
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(field.Interface())
+}
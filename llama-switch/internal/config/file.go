@@ -0,0 +1,164 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFileNames 未通过--config指定时依次查找的配置文件名
+var configFileNames = []string{"config.yaml", "config.yml", "config.json"}
+
+// FindConfigFile 在工作目录和程序目录下查找配置文件，都不存在时返回空字符串
+func FindConfigFile() string {
+	var dirs []string
+	if wd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, wd)
+	}
+	if exePath, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(exePath))
+	}
+	for _, dir := range dirs {
+		for _, name := range configFileNames {
+			if path := filepath.Join(dir, name); fileExists(path) {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// loadConfigFile 从YAML或JSON配置文件加载配置，覆盖cfg中的对应项；profile不为空时再用文件中同名环境配置覆盖
+func loadConfigFile(path, profile string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+	if err := decodeConfigFile(data, filepath.Ext(path), profile, cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// decodeConfigFile 解析配置文件内容：顶层配置项覆盖cfg，profile不为空时再用profiles中的同名环境配置覆盖
+// 环境配置的结构与顶层相同，只需包含与顶层不同的配置项
+func decodeConfigFile(data []byte, format, profile string, cfg *Config) error {
+	var doc map[string]interface{}
+	if err := Decode(data, format, &doc); err != nil {
+		return err
+	}
+	profiles, err := configProfiles(doc)
+	if err != nil {
+		return err
+	}
+	delete(doc, "profiles")
+	if err := decodeSection(doc, cfg); err != nil {
+		return err
+	}
+	cfg.setSections(doc, SourceFile)
+	if profile == "" {
+		return nil
+	}
+
+	section, exists := profiles[profile]
+	if !exists {
+		return fmt.Errorf("profile %s not found (available: %s)", profile, strings.Join(slices.Sorted(maps.Keys(profiles)), ", "))
+	}
+	if err := decodeSection(section, cfg); err != nil {
+		return fmt.Errorf("profile %s: %v", profile, err)
+	}
+	cfg.setSections(section, SourceFile)
+	return nil
+}
+
+// configProfiles 获取配置文件中的环境配置（profiles），按名称索引
+func configProfiles(doc map[string]interface{}) (map[string]map[string]interface{}, error) {
+	profiles := make(map[string]map[string]interface{})
+	if doc["profiles"] == nil {
+		return profiles, nil
+	}
+	sections, ok := doc["profiles"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("profiles must be a mapping of profile names to config overrides")
+	}
+	for name, section := range sections {
+		switch v := section.(type) {
+		case map[string]interface{}:
+			profiles[name] = v
+		case nil:
+			profiles[name] = map[string]interface{}{}
+		default:
+			return nil, fmt.Errorf("profile %s must be a mapping of config overrides", name)
+		}
+	}
+	return profiles, nil
+}
+
+// decodeSection 将已解析的配置项覆盖到cfg，未知字段视为错误
+func decodeSection(section map[string]interface{}, cfg *Config) error {
+	if len(section) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(section)
+	if err != nil {
+		return err
+	}
+	return Decode(data, ".json", cfg)
+}
+
+// Decode 按格式（.yaml、.yml或.json）解析配置内容到v，覆盖v中的对应项
+// 两种格式使用相同的字段名（即结构体的json标签），未知字段视为错误以便发现拼写错误
+func Decode(data []byte, format string, v interface{}) error {
+	switch strings.ToLower(format) {
+	case ".json":
+	case ".yaml", ".yml":
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		if doc == nil {
+			return nil
+		}
+		var err error
+		if data, err = json.Marshal(normalizeYAML(doc)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported config file format: %s (expected .yaml, .yml or .json)", format)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// normalizeYAML 将YAML中非字符串键的映射转换为字符串键，以便编码为JSON
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeYAML(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalizeYAML(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYAML(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
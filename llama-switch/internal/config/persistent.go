@@ -1,11 +1,14 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"llama-switch/internal/model"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 )
@@ -45,19 +48,89 @@ func NewPersistentManager(cfg *Config) *PersistentManager {
 	}
 }
 
-// LoadConfig 加载配置
-func (pm *PersistentManager) LoadConfig() (*PersistentModelConfig, error) {
-	pm.mu.RLock()
-	defer pm.mu.RUnlock()
+// PersistentDir 模型持久化配置的保存目录：PERSISTENT_DIR，未设置时为程序目录下的config目录
+func PersistentDir(cfg *Config) string {
+	if cfg.PersistentDir != "" {
+		return cfg.PersistentDir
+	}
+	return legacyPersistentDir()
+}
 
-	// 获取程序运行目录
+// legacyPersistentDir 程序目录下的config目录，未设置PERSISTENT_DIR时的保存目录
+func legacyPersistentDir() string {
 	exePath, err := os.Executable()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get executable path: %v", err)
+		return "config"
+	}
+	return filepath.Join(filepath.Dir(exePath), "config")
+}
+
+// Migrate 设置了PERSISTENT_DIR且其中还没有持久化配置时，将原位置（程序目录或模型目录上级目录下的config目录）
+// 中的model_persistent.json及其备份复制到新目录，原文件重命名为.migrated；原位置只读时保留原文件
+func (pm *PersistentManager) Migrate() error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	if pm.config.PersistentDir == "" {
+		return nil
+	}
+	target := filepath.Join(pm.config.PersistentDir, ConfigFileName)
+	if _, err := os.Stat(target); err == nil {
+		return nil
+	}
+
+	candidates := []string{legacyPersistentDir()}
+	if pm.config.ModelsDir != "" {
+		candidates = append(candidates, filepath.Join(filepath.Dir(pm.config.ModelsDir), "config"))
 	}
+	for _, dir := range candidates {
+		source := filepath.Join(dir, ConfigFileName)
+		if sameFile(source, target) {
+			continue
+		}
+		data, err := os.ReadFile(source)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read persistent config %s: %v", source, err)
+		}
+
+		if err := os.MkdirAll(pm.config.PersistentDir, 0755); err != nil {
+			return fmt.Errorf("failed to create persistent config directory: %v", err)
+		}
+		if backup, err := os.ReadFile(source + BackupSuffix); err == nil {
+			if err := os.WriteFile(target+BackupSuffix, backup, 0644); err != nil {
+				return fmt.Errorf("failed to migrate persistent config backup: %v", err)
+			}
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			return fmt.Errorf("failed to migrate persistent config: %v", err)
+		}
+		slog.Info("Migrated persistent config", "from", source, "to", target)
+
+		if err := os.Rename(source, source+".migrated"); err != nil {
+			slog.Warn("Could not rename migrated persistent config", "file", source, "error", err)
+		}
+		return nil
+	}
+	return nil
+}
+
+// sameFile 检查两个路径是否指向同一文件（目标文件尚不存在时比较绝对路径）
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// LoadConfig 加载配置，旧版本的配置升级后写回配置文件
+func (pm *PersistentManager) LoadConfig() (*PersistentModelConfig, error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
 
 	// 确保配置目录存在
-	configDir := filepath.Join(filepath.Dir(exePath), "config")
+	configDir := PersistentDir(pm.config)
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create config directory: %v", err)
 	}
@@ -85,19 +158,97 @@ func (pm *PersistentManager) LoadConfig() (*PersistentModelConfig, error) {
 		}
 	}
 
+	// 旧版本的配置按顺序升级到当前版本，升级前备份原文件
+	data, err = pm.migrateSchema(configPath, data)
+	if err != nil {
+		return nil, err
+	}
+
 	// 解析配置
 	var config PersistentModelConfig
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %v", err)
 	}
 
-	// 版本兼容性检查
-	if config.Version != ConfigVersion {
-		// TODO: 实现版本迁移逻辑
-		return nil, fmt.Errorf("unsupported config version: %s", config.Version)
+	return &config, nil
+}
+
+// schemaMigration 将持久化配置从一个版本升级到下一个版本，在解码后的JSON文档上修改
+type schemaMigration struct {
+	from    string
+	to      string
+	migrate func(doc map[string]interface{}) error
+}
+
+// schemaMigrations 按版本顺序排列的升级步骤，最后一步的to为ConfigVersion
+// 修改持久化配置的结构时提高ConfigVersion，并在末尾追加从上一版本升级的步骤
+var schemaMigrations = []schemaMigration{
+	// 早期版本没有记录版本号，models可能缺失
+	{from: "", to: "1.0.0", migrate: func(doc map[string]interface{}) error {
+		if _, ok := doc["models"].(map[string]interface{}); !ok {
+			doc["models"] = map[string]interface{}{}
+		}
+		return nil
+	}},
+}
+
+// MigrationBackupPath 升级前备份原文件的路径，如model_persistent.json.v1.0.0.backup
+func MigrationBackupPath(configPath, version string) string {
+	if version == "" {
+		version = "unversioned"
+	} else {
+		version = "v" + version
 	}
+	return configPath + "." + version + BackupSuffix
+}
 
-	return &config, nil
+// migrateSchema 将持久化配置升级到ConfigVersion：先备份原文件（已有同版本的备份时保留），
+// 再依次执行升级步骤并写回配置文件。版本高于当前版本或没有升级路径时返回错误且不修改文件
+func (pm *PersistentManager) migrateSchema(configPath string, data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // 保持整数精度
+	var doc map[string]interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %v", err)
+	}
+	original, _ := doc["version"].(string)
+	if original == ConfigVersion {
+		return data, nil
+	}
+
+	version := original
+	for version != ConfigVersion {
+		i := slices.IndexFunc(schemaMigrations, func(m schemaMigration) bool { return m.from == version })
+		if i < 0 {
+			return nil, fmt.Errorf("unsupported config version: %s (supported: up to %s)", version, ConfigVersion)
+		}
+		step := schemaMigrations[i]
+		if err := step.migrate(doc); err != nil {
+			return nil, fmt.Errorf("failed to migrate config from version %q to %s: %v", step.from, step.to, err)
+		}
+		doc["version"] = step.to
+		version = step.to
+	}
+
+	migrated, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize migrated config: %v", err)
+	}
+	backupPath := MigrationBackupPath(configPath, original)
+	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+		if err := os.WriteFile(backupPath, data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to back up config before migration: %v", err)
+		}
+	}
+	tmp := configPath + ".tmp"
+	if err := os.WriteFile(tmp, migrated, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write migrated config: %v", err)
+	}
+	if err := os.Rename(tmp, configPath); err != nil {
+		return nil, fmt.Errorf("failed to write migrated config: %v", err)
+	}
+	slog.Info("Migrated persistent config version", "file", configPath, "from", original, "to", ConfigVersion, "backup", backupPath)
+	return migrated, nil
 }
 
 // SaveConfig 保存配置
@@ -114,14 +265,8 @@ func (pm *PersistentManager) SaveConfig(config *PersistentModelConfig) error {
 		return fmt.Errorf("failed to serialize config: %v", err)
 	}
 
-	// 获取程序运行目录
-	exePath, err := os.Executable()
-	if err != nil {
-		return fmt.Errorf("failed to get executable path: %v", err)
-	}
-
 	// 确保配置目录存在并设置配置路径
-	configDir := filepath.Join(filepath.Dir(exePath), "config")
+	configDir := PersistentDir(pm.config)
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %v", err)
 	}
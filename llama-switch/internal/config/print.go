@@ -1,8 +1,13 @@
 package config
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
+
+	"llama-switch/internal/units"
 )
 
 // String 返回格式化的配置信息
@@ -12,20 +17,38 @@ func (c *Config) String() string {
 	// 添加标题
 	sb.WriteString("\n=== Configuration ===\n\n")
 
+	// 配置文件
+	if c.File != "" {
+		sb.WriteString(fmt.Sprintf("Config File: %s\n", c.File))
+		if c.Profile != "" {
+			sb.WriteString(fmt.Sprintf("Config Profile: %s\n", c.Profile))
+		}
+		sb.WriteString("\n")
+	}
+
 	// LLama.cpp 路径
 	sb.WriteString("LLama.cpp Paths:\n")
 	sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Server Binary", c.LLamaPath.Server))
 	sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Bench Binary", c.LLamaPath.Bench))
+	for _, name := range slices.Sorted(maps.Keys(c.LLamaPath.Profiles)) {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Profile "+name, c.LLamaPath.Profiles[name]))
+	}
 	sb.WriteString("\n")
 
 	// 模型目录
-	sb.WriteString(fmt.Sprintf("Models Directory: %s\n\n", c.ModelsDir))
+	sb.WriteString(fmt.Sprintf("Models Directory: %s\n", c.ModelsDir))
+	sb.WriteString(fmt.Sprintf("Persistent Directory: %s\n\n", PersistentDir(c)))
 
 	// 服务器配置
 	sb.WriteString("Server Configuration:\n")
 	sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Host", c.Server.Host))
 	sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "Port", c.Server.Port))
 	sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Timeout", c.Server.Timeout))
+	sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Shutdown Drain", c.Server.ShutdownTimeout))
+	if c.Server.PIDFile != "" {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "PID File", c.Server.PIDFile))
+	}
+	sb.WriteString(fmt.Sprintf("  %-15s: %d-%d\n", "Model Ports", c.ModelPorts.RangeStart, c.ModelPorts.RangeEnd))
 	sb.WriteString("\n")
 
 	// 默认模型配置
@@ -42,6 +65,9 @@ func (c *Config) String() string {
 	sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Split Mode", c.GPU.SplitMode))
 	sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "Main GPU", c.GPU.MainGPU))
 	sb.WriteString(fmt.Sprintf("  %-15s: %v\n", "Flash Attention", c.GPU.FlashAttn))
+	sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "GPU Provider", c.GPU.Provider))
+	sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "GPU Placement", c.GPU.Placement))
+	sb.WriteString(fmt.Sprintf("  %-15s: %v\n", "Auto Split", c.GPU.AutoTensorSplit))
 	sb.WriteString("\n")
 
 	// 缓存配置
@@ -66,8 +92,391 @@ func (c *Config) String() string {
 		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Log File", c.Log.File))
 	}
 	sb.WriteString(fmt.Sprintf("  %-15s: %v\n", "Console Log", c.Log.EnableConsole))
+	sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Log Format", c.Log.Format))
+	sb.WriteString("\n")
+
+	// 模型实例输出日志配置
+	sb.WriteString("Model Log Configuration:\n")
+	if c.ModelLog.Dir != "" {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Log Directory", c.ModelLog.Dir))
+	} else {
+		sb.WriteString("  Log Directory  : [Default]\n")
+	}
+	sb.WriteString(fmt.Sprintf("  %-15s: %d MB x %d\n", "Rotation", c.ModelLog.MaxSizeMB, c.ModelLog.MaxFiles))
+	sb.WriteString(fmt.Sprintf("  %-15s: %v\n", "Console", c.ModelLog.Console))
+	sb.WriteString("\n")
+
+	// 推理代理配置
+	sb.WriteString("Proxy Configuration:\n")
+	sb.WriteString(fmt.Sprintf("  %-15s: %v\n", "Enabled", c.Proxy.Enabled))
+	if c.Proxy.Port != 0 {
+		host := c.Proxy.Host
+		if host == "" {
+			host = c.Server.Host
+		}
+		sb.WriteString(fmt.Sprintf("  %-15s: %s:%d\n", "Listen", host, c.Proxy.Port))
+	}
+	sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "Concurrency", c.Proxy.DefaultConcurrency))
+	sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "Queue Size", c.Proxy.QueueSize))
+	sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Queue Timeout", c.Proxy.QueueTimeout))
+	sb.WriteString("\n")
+
+	// 嵌入请求路由配置
+	sb.WriteString("Embedding Routing:\n")
+	sb.WriteString(fmt.Sprintf("  %-15s: %v\n", "Batching", c.Embedding.BatchEnabled))
+	if c.Embedding.BatchEnabled {
+		sb.WriteString(fmt.Sprintf("  %-15s: %d ms\n", "Batch Window", c.Embedding.BatchWindowMS))
+		sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "Max Inputs", c.Embedding.BatchMaxInputs))
+	}
+	sb.WriteString("\n")
+
+	// 模型下载配置
+	sb.WriteString("Download Configuration:\n")
+	sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "HF Endpoint", c.Download.HFEndpoint))
+	sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "Max Concurrent", c.Download.MaxConcurrent))
+	sb.WriteString("\n")
+
+	// 重排序请求路由配置
+	sb.WriteString("Rerank Routing:\n")
+	if c.Rerank.DefaultModel != "" {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s (%s)\n", "Default Model", c.Rerank.DefaultName, c.Rerank.DefaultModel))
+		sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "Default Port", c.Rerank.DefaultPort))
+		sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "GPU Layers", c.Rerank.GPULayers))
+		sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Startup Timeout", c.Rerank.StartupTimeout))
+	} else {
+		sb.WriteString("  Default Model  : [Not Set]\n")
+	}
+	sb.WriteString("\n")
+
+	// 切换保护配置
+	sb.WriteString("Switch Guard:\n")
+	sb.WriteString(fmt.Sprintf("  %-15s: %v\n", "Enabled", c.SwitchGuard.Enabled))
+	if c.SwitchGuard.Enabled {
+		sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Idle Window", c.SwitchGuard.IdleSeconds))
+	}
+	sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Drain Timeout", c.SwitchGuard.DrainTimeout))
+	sb.WriteString("\n")
+
+	// 主机内存检查配置
+	sb.WriteString("RAM Check:\n")
+	sb.WriteString(fmt.Sprintf("  %-15s: %v\n", "Enabled", c.RAM.CheckEnabled))
+	sb.WriteString(fmt.Sprintf("  %-15s: %dMB\n", "Reserve", c.RAM.ReserveMB))
+	sb.WriteString("\n")
+
+	// 驱逐策略配置
+	sb.WriteString("Eviction:\n")
+	sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Policy", c.Eviction.Policy))
+	sb.WriteString(fmt.Sprintf("  %-15s: %dms x %d stable\n", "Release Poll", c.Eviction.PollIntervalMS, c.Eviction.StablePolls))
+	sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Release Timeout", c.Eviction.ReleaseTimeout))
+	sb.WriteString("\n")
+
+	// RPC池配置
+	if len(c.RPC.Pools) > 0 {
+		sb.WriteString("RPC Pools:\n")
+		for _, name := range slices.Sorted(maps.Keys(c.RPC.Pools)) {
+			sb.WriteString(fmt.Sprintf("  %-15s: %s\n", name, c.RPC.Pools[name]))
+		}
+		sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Health Interval", c.RPC.HealthInterval))
+		sb.WriteString("\n")
+	}
+
+	// 健康检查配置
+	sb.WriteString("Health Check:\n")
+	if c.HealthCheck.Interval > 0 {
+		sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Interval", c.HealthCheck.Interval))
+	} else {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Interval", "disabled"))
+	}
+	sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Timeout", c.HealthCheck.Timeout))
+	sb.WriteString("\n")
+
+	// 看门狗配置
+	sb.WriteString("Watchdog:\n")
+	if c.Watchdog.Failures > 0 && c.HealthCheck.Interval > 0 {
+		sb.WriteString(fmt.Sprintf("  %-15s: %d consecutive probes\n", "Failures", c.Watchdog.Failures))
+		sb.WriteString(fmt.Sprintf("  %-15s: %v\n", "Restart", c.Watchdog.Restart))
+		sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "Log Lines", c.Watchdog.LogLines))
+	} else {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Failures", "disabled"))
+	}
+	sb.WriteString("\n")
+
+	// 事件日志配置
+	sb.WriteString("Events:\n")
+	eventsFile := c.Events.File
+	if eventsFile == "" {
+		eventsFile = "(program directory)/events.jsonl"
+	}
+	sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "File", eventsFile))
+	sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "History", c.Events.History))
+	sb.WriteString("\n")
+
+	// 审计日志配置
+	sb.WriteString("Audit Log:\n")
+	if c.Audit.Enabled {
+		auditFile := c.Audit.File
+		if auditFile == "" {
+			auditFile = "(program directory)/audit.jsonl"
+		}
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "File", auditFile))
+	} else {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "File", "disabled"))
+	}
+	sb.WriteString("\n")
+
+	// 启动阶段配置
+	sb.WriteString("Model Startup:\n")
+	if c.Startup.Timeout > 0 {
+		sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Timeout", c.Startup.Timeout))
+	} else {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Timeout", "disabled"))
+	}
+	sb.WriteString(fmt.Sprintf("  %-15s: %d lines\n", "Output Lines", c.Startup.OutputLines))
+	sb.WriteString("\n")
+
+	// 模型停止配置
+	sb.WriteString("Model Stop:\n")
+	sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Signal", c.Stop.Signal))
+	sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Grace Period", c.Stop.GracePeriod))
+	sb.WriteString("\n")
+
+	// 运行状态校正配置
+	sb.WriteString("Reconciliation:\n")
+	if c.Reconcile.Interval > 0 {
+		sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Interval", c.Reconcile.Interval))
+	} else {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Interval", "disabled"))
+	}
+	sb.WriteString("\n")
+
+	// 声明式模型定义配置
+	sb.WriteString("Model Definitions:\n")
+	if c.ModelDefs.Dir != "" {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Directory", c.ModelDefs.Dir))
+	}
+	if c.ModelDefs.Interval > 0 {
+		sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Interval", c.ModelDefs.Interval))
+	} else {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Interval", "startup only"))
+	}
+	sb.WriteString("\n")
+
+	// 自动启动配置
+	if len(c.Autostart.Models) > 0 {
+		sb.WriteString("Autostart:\n")
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Models", strings.Join(c.Autostart.Models, ", ")))
+		if c.Autostart.CapacityTimeout > 0 {
+			sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Capacity Wait", c.Autostart.CapacityTimeout))
+		}
+		sb.WriteString("\n")
+	}
+
+	// 显存使用历史配置
+	sb.WriteString("VRAM History:\n")
+	if c.VRAMHistory.Interval > 0 {
+		sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Interval", c.VRAMHistory.Interval))
+		sb.WriteString(fmt.Sprintf("  %-15s: %d samples\n", "Max Samples", c.VRAMHistory.MaxSamples))
+		if c.VRAMHistory.Dir != "" {
+			sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Directory", c.VRAMHistory.Dir))
+		}
+	} else {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Interval", "disabled"))
+	}
+	sb.WriteString("\n")
+
+	// GPU温度告警配置
+	sb.WriteString("GPU Thermal:\n")
+	if c.Thermal.TempLimitC > 0 {
+		sb.WriteString(fmt.Sprintf("  %-15s: %d°C\n", "Temp Limit", c.Thermal.TempLimitC))
+	} else {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Temp Limit", "disabled"))
+	}
+	sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "Samples", c.Thermal.ThrottleSamples))
+	sb.WriteString("\n")
+
+	// 模型工作目录配置
+	sb.WriteString("Model Work Directory:\n")
+	if c.WorkDir.Root != "" {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Root", c.WorkDir.Root))
+	}
+	sb.WriteString(fmt.Sprintf("  %-15s: %v\n", "Sandbox", c.WorkDir.Sandbox))
+	sb.WriteString("\n")
+
+	// 模型环境变量配置
+	sb.WriteString("Model Environment:\n")
+	sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Allowlist", strings.Join(c.ModelEnv.Allowlist, ", ")))
+	sb.WriteString("\n")
+
+	// 额外参数配置
+	sb.WriteString("Extra Args:\n")
+	if len(c.ExtraArgs.Allowlist) > 0 {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Allowlist", strings.Join(c.ExtraArgs.Allowlist, ", ")))
+	} else {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Allowlist", "none (extra_args disabled)"))
+	}
+	sb.WriteString("\n")
+
+	// 资源限制配置
+	sb.WriteString("Resource Limits:\n")
+	sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Cgroup Root", c.Limits.CgroupRoot))
+	sb.WriteString("\n")
+
+	// 资源采样配置
+	sb.WriteString("Resource Sampling:\n")
+	if c.Resources.SampleInterval > 0 {
+		sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Interval", c.Resources.SampleInterval))
+		sb.WriteString(fmt.Sprintf("  %-15s: %d samples\n", "History", c.Resources.HistorySize))
+		if c.Resources.VRAMLowMB > 0 {
+			sb.WriteString(fmt.Sprintf("  %-15s: %d MB\n", "VRAM Low", c.Resources.VRAMLowMB))
+		}
+	} else {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Interval", "disabled"))
+	}
+	sb.WriteString("\n")
+
+	// 分时共享配置
+	sb.WriteString("TimeShare Configuration:\n")
+	sb.WriteString(fmt.Sprintf("  %-15s: %v\n", "Enabled", c.TimeShare.Enabled))
+	if c.TimeShare.Enabled {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Slot Directory", c.TimeShare.SlotDir))
+		sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Swap Timeout", c.TimeShare.SwapTimeout))
+	}
+	sb.WriteString("\n")
+
+	// 公开状态页配置
+	sb.WriteString("Status Page:\n")
+	sb.WriteString(fmt.Sprintf("  %-15s: %v\n", "Enabled", c.StatusPage.Enabled))
+	if c.StatusPage.Enabled {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Title", c.StatusPage.Title))
+	}
+	sb.WriteString("\n")
+
+	// Prometheus指标配置
+	sb.WriteString("Metrics:\n")
+	sb.WriteString(fmt.Sprintf("  %-15s: %v\n", "Enabled", c.Metrics.Enabled))
+	sb.WriteString(fmt.Sprintf("  %-15s: %v\n", "Instances", c.Metrics.Instances))
 	sb.WriteString("\n")
 
+	// 模型别名配置
+	sb.WriteString("Alias Configuration:\n")
+	if c.Alias.File != "" {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Alias File", c.Alias.File))
+	} else {
+		sb.WriteString("  Alias File     : [Default]\n")
+	}
+	if c.Alias.WebhookURL != "" {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Webhook URL", c.Alias.WebhookURL))
+	}
+	sb.WriteString("\n")
+
+	// 事件订阅配置
+	sb.WriteString("Webhooks:\n")
+	if c.Webhooks.File != "" {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Webhooks File", c.Webhooks.File))
+	} else {
+		sb.WriteString("  Webhooks File  : [Default]\n")
+	}
+	sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "Max Attempts", c.Webhooks.MaxAttempts))
+	sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Retry Delay", c.Webhooks.RetryDelay))
+	sb.WriteString("\n")
+
+	// 准确性冒烟测试配置
+	sb.WriteString("Eval Configuration:\n")
+	if c.Eval.File != "" {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Eval File", c.Eval.File))
+	} else {
+		sb.WriteString("  Eval File      : [Default]\n")
+	}
+	sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Case Timeout", c.Eval.Timeout))
+	sb.WriteString("\n")
+
+	// 基准测试配置
+	sb.WriteString("Benchmark Configuration:\n")
+	if c.Benchmark.ManifestDir != "" {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Manifest Dir", c.Benchmark.ManifestDir))
+	} else {
+		sb.WriteString("  Manifest Dir   : [Default]\n")
+	}
+	if c.Benchmark.HistoryFile != "" {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "History File", c.Benchmark.HistoryFile))
+	} else {
+		sb.WriteString("  History File   : [Default]\n")
+	}
+	if c.Benchmark.ScheduleFile != "" {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Schedule File", c.Benchmark.ScheduleFile))
+	} else {
+		sb.WriteString("  Schedule File  : [Default]\n")
+	}
+	sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "Max Tasks", c.Benchmark.MaxTasks))
+	sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Task Max Age", c.Benchmark.TaskMaxAge))
+	if c.Benchmark.BaselineFile != "" {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Baseline File", c.Benchmark.BaselineFile))
+	} else {
+		sb.WriteString("  Baseline File  : [Default]\n")
+	}
+	sb.WriteString(fmt.Sprintf("  %-15s: %d%%\n", "Regression", c.Benchmark.RegressionThreshold))
+	sb.WriteString(fmt.Sprintf("  %-15s: %d KB\n", "Output Limit", c.Benchmark.OutputLimitKB))
+	if len(c.Benchmark.WebhookURLs) > 0 {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Webhook URLs", strings.Join(c.Benchmark.WebhookURLs, ", ")))
+	}
+	sb.WriteString("\n")
+
+	// 邮件通知配置（不打印密码）
+	if c.SMTP.Addr != "" {
+		sb.WriteString("SMTP Configuration:\n")
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Address", c.SMTP.Addr))
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "From", c.SMTP.From))
+		if c.SMTP.Username != "" {
+			sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Username", c.SMTP.Username))
+		}
+		sb.WriteString("\n")
+	}
+
+	// 跨域请求配置
+	if len(c.CORS.AllowedOrigins) > 0 {
+		sb.WriteString("CORS:\n")
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Origins", strings.Join(c.CORS.AllowedOrigins, ", ")))
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Methods", strings.Join(c.CORS.AllowedMethods, ", ")))
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Headers", strings.Join(c.CORS.AllowedHeaders, ", ")))
+		sb.WriteString("\n")
+	}
+
+	// 请求频率限制配置
+	if c.RateLimit.PerMinute > 0 || c.RateLimit.SwitchPerMinute > 0 {
+		sb.WriteString("Rate Limit:\n")
+		if c.RateLimit.PerMinute > 0 {
+			sb.WriteString(fmt.Sprintf("  %-15s: %d/min, burst %d\n", "Requests", c.RateLimit.PerMinute, cmp.Or(c.RateLimit.Burst, c.RateLimit.PerMinute)))
+		}
+		if c.RateLimit.SwitchPerMinute > 0 {
+			sb.WriteString(fmt.Sprintf("  %-15s: %d/min, burst %d\n", "Switch", c.RateLimit.SwitchPerMinute, cmp.Or(c.RateLimit.SwitchBurst, c.RateLimit.SwitchPerMinute)))
+		}
+		sb.WriteString("\n")
+	}
+
+	// 幂等键配置
+	sb.WriteString("Idempotency Keys:\n")
+	if c.Idempotency.TTL > 0 {
+		sb.WriteString(fmt.Sprintf("  %-15s: %d seconds\n", "Replay Window", c.Idempotency.TTL))
+		sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "Max Keys", c.Idempotency.MaxKeys))
+	} else {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Replay Window", "disabled"))
+	}
+	sb.WriteString("\n")
+
+	// 多租户配置（不打印API密钥）
+	if len(c.Tenants.APIKeys) > 0 {
+		sb.WriteString("Tenants:\n")
+		for _, name := range slices.Sorted(maps.Keys(c.Tenants.APIKeys)) {
+			if quota, exists := c.Tenants.VRAMQuotas[name]; exists {
+				mb, _ := units.ParseSize(quota, units.MB)
+				sb.WriteString(fmt.Sprintf("  %-15s: %dMB VRAM\n", name, mb))
+			} else {
+				sb.WriteString(fmt.Sprintf("  %-15s: unlimited\n", name))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
 	// 安全配置
 	sb.WriteString("Security Configuration:\n")
 	if c.Security.APIKey != "" {
@@ -75,13 +484,42 @@ func (c *Config) String() string {
 	} else {
 		sb.WriteString("  API Key        : [Not Set]\n")
 	}
+	for _, name := range slices.Sorted(maps.Keys(c.Security.APIKeys)) {
+		role := c.Security.Roles[name]
+		if role == "" {
+			role = "readonly"
+		}
+		sb.WriteString(fmt.Sprintf("  %-15s: [Set] (%s)\n", "Key "+name, role))
+	}
 	if c.Security.SSLKey != "" && c.Security.SSLCert != "" {
 		sb.WriteString("  SSL            : Enabled\n")
 		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "SSL Key", c.Security.SSLKey))
 		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "SSL Cert", c.Security.SSLCert))
+		if c.Security.SSLClientCA != "" {
+			sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Client CA", c.Security.SSLClientCA))
+		}
+		if c.Security.RedirectPort != 0 {
+			sb.WriteString(fmt.Sprintf("  %-15s: %d\n", "HTTP Redirect", c.Security.RedirectPort))
+		}
 	} else {
 		sb.WriteString("  SSL            : Disabled\n")
 	}
+	if len(c.Security.AllowedCIDRs) > 0 {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Admin Allowed", strings.Join(c.Security.AllowedCIDRs, ", ")))
+	}
+	if len(c.Security.DeniedCIDRs) > 0 {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "Admin Denied", strings.Join(c.Security.DeniedCIDRs, ", ")))
+	}
+	if c.JWT.Issuer != "" {
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "JWT Issuer", c.JWT.Issuer))
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "JWT Audience", c.JWT.Audience))
+		if c.JWT.JWKSURL != "" {
+			sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "JWKS URL", c.JWT.JWKSURL))
+		}
+		sb.WriteString(fmt.Sprintf("  %-15s: %s\n", "JWT Role Claim", c.JWT.RoleClaim))
+	} else {
+		sb.WriteString("  JWT            : Disabled\n")
+	}
 	sb.WriteString("\n")
 
 	sb.WriteString("===================\n")
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"llama-switch/internal/model"
+)
+
+// reloadableFields 运行时重新加载后立即生效的配置项（按字段路径前缀匹配），
+// 其他配置项在启动时使用（如监听地址、后台任务间隔、持久化文件路径），变化后需要重启switcher
+var reloadableFields = []string{
+	"llama_path.",
+	"models_dir",
+	"log.level",
+	"default_model.",
+	"gpu.layers",
+	"gpu.split_mode",
+	"gpu.main_gpu",
+	"gpu.flash_attn",
+	"gpu.placement",
+	"gpu.auto_tensor_split",
+	"cache.",
+	"memory.",
+	"model_env.",
+	"extra_args.",
+	"tenants.",
+	"security.api_key",
+	"security.api_keys.",
+	"security.roles.",
+}
+
+// secretFields 值不在变化报告中显示的配置项
+var secretFields = []string{"security.api_key", "security.api_keys.", "tenants.api_keys."}
+
+// configMu 保护共享配置中可在运行时修改的配置项：重新加载配置和修改默认配置时持有写锁，
+// 与之并发读取这些配置项的代码通过Snapshot获取副本
+var configMu sync.RWMutex
+
+// Snapshot 返回配置的副本，之后重新加载配置不会修改该副本。
+// 修改配置时映射和切片整体替换而不原地修改，因此副本与原配置共用它们是安全的
+func (c *Config) Snapshot() *Config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	snapshot := *c
+	return &snapshot
+}
+
+// Reload 将next中可在运行时生效的配置项复制到cfg，返回两者之间所有变化的配置项
+// cfg由各服务共享，正在运行的模型不受影响，新配置从下一次切换或请求开始使用；
+// 修改cfg的调用方之间需要自行串行化
+func Reload(cfg, next *Config) []model.ConfigChange {
+	changes := Diff(cfg, next)
+
+	configMu.Lock()
+	defer configMu.Unlock()
+	cfg.File = next.File
+	cfg.sources = next.sources
+	cfg.LLamaPath = next.LLamaPath
+	cfg.ModelsDir = next.ModelsDir
+	cfg.Log.Level = next.Log.Level
+	cfg.DefaultModel = next.DefaultModel
+	cfg.GPU.Layers = next.GPU.Layers
+	cfg.GPU.SplitMode = next.GPU.SplitMode
+	cfg.GPU.MainGPU = next.GPU.MainGPU
+	cfg.GPU.FlashAttn = next.GPU.FlashAttn
+	cfg.GPU.Placement = next.GPU.Placement
+	cfg.GPU.AutoTensorSplit = next.GPU.AutoTensorSplit
+	cfg.Cache = next.Cache
+	cfg.Memory = next.Memory
+	cfg.ModelEnv = next.ModelEnv
+	cfg.ExtraArgs = next.ExtraArgs
+	cfg.Tenants = next.Tenants
+	cfg.Security.APIKey = next.Security.APIKey
+	cfg.Security.APIKeys = next.Security.APIKeys
+	cfg.Security.Roles = next.Security.Roles
+	return changes
+}
+
+// Diff 比较两份配置，返回按字段路径排序的变化，Applied表示该配置项是否可在运行时生效
+func Diff(old, next *Config) []model.ConfigChange {
+	before, after := flattenConfig(old), flattenConfig(next)
+	fields := make(map[string]bool, len(before))
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+
+	changes := []model.ConfigChange{}
+	for _, field := range slices.Sorted(maps.Keys(fields)) {
+		oldValue, newValue := before[field], after[field]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if matchField(secretFields, field) {
+			oldValue, newValue = redact(oldValue), redact(newValue)
+		}
+		changes = append(changes, model.ConfigChange{
+			Field:   field,
+			Old:     oldValue,
+			New:     newValue,
+			Applied: matchField(reloadableFields, field),
+		})
+	}
+	return changes
+}
+
+// flattenConfig 将配置按json标签展开为字段路径到值的映射，映射类配置项展开到每个键，列表作为整体比较
+func flattenConfig(cfg *Config) map[string]interface{} {
+	data, _ := json.Marshal(cfg)
+	var doc map[string]interface{}
+	json.Unmarshal(data, &doc)
+
+	fields := make(map[string]interface{})
+	var flatten func(prefix string, value interface{})
+	flatten = func(prefix string, value interface{}) {
+		if m, ok := value.(map[string]interface{}); ok {
+			for key, item := range m {
+				flatten(prefix+"."+key, item)
+			}
+			return
+		}
+		fields[prefix] = value
+	}
+	for key, value := range doc {
+		flatten(key, value)
+	}
+	return fields
+}
+
+// matchField 检查字段路径是否匹配列表中的某一项：以.结尾的项按前缀匹配，其余按完整路径匹配
+func matchField(patterns []string, field string) bool {
+	for _, pattern := range patterns {
+		if field == pattern || (strings.HasSuffix(pattern, ".") && strings.HasPrefix(field, pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact 隐藏密钥类配置项的值，未设置的值保持为空以便看出是新增还是删除
+func redact(value interface{}) interface{} {
+	if value == nil || value == "" {
+		return value
+	}
+	return "******"
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// jsonMarshalerType 自定义JSON编码的类型（如GPULayers），无法从Go类型推断其JSON结构
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// JSONSchema 根据结构体的json标签生成JSON Schema（draft 2020-12），供界面生成表单和客户端校验
+// 对象不允许未知字段，与配置文件的解析规则一致；自定义JSON编码的类型由overrides提供，键为Go类型名
+func JSONSchema(v interface{}, title string, overrides map[string]map[string]interface{}) map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(v), overrides)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = title
+	return schema
+}
+
+// typeSchema 生成单个Go类型的JSON Schema
+func typeSchema(t reflect.Type, overrides map[string]map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if override, exists := overrides[t.Name()]; exists && t.Name() != "" {
+		schema := make(map[string]interface{}, len(override))
+		for key, value := range override {
+			schema[key] = value
+		}
+		return schema
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), overrides)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), overrides)}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" && field.Anonymous {
+				// 没有json标签的嵌入结构体，字段平铺在外层对象中
+				if embedded, ok := typeSchema(field.Type, overrides)["properties"].(map[string]interface{}); ok {
+					maps.Copy(properties, embedded)
+					continue
+				}
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = typeSchema(field.Type, overrides)
+		}
+		return map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+	default:
+		// interface{}等任意值
+		return map[string]interface{}{}
+	}
+}
+
+// Redacted 获取隐藏了密钥的配置，结构与配置文件相同
+func Redacted(cfg *Config) map[string]interface{} {
+	data, _ := json.Marshal(cfg)
+	var doc map[string]interface{}
+	json.Unmarshal(data, &doc)
+
+	var walk func(prefix string, m map[string]interface{})
+	walk = func(prefix string, m map[string]interface{}) {
+		for key, value := range m {
+			field := prefix + key
+			if nested, ok := value.(map[string]interface{}); ok {
+				walk(field+".", nested)
+				continue
+			}
+			if matchField(secretFields, field) {
+				m[key] = redact(value)
+			}
+		}
+	}
+	walk("", doc)
+	return doc
+}
+
+// ValidateConfigFile 验证配置文件内容（YAML或JSON，format为文件扩展名），未出现的配置项使用默认值，不读取环境变量
+// profile为空时分别验证顶层配置和每个环境配置，否则只验证使用该环境配置的结果
+func ValidateConfigFile(data []byte, format, profile string) error {
+	profiles := []string{profile}
+	if profile == "" {
+		var doc map[string]interface{}
+		if err := Decode(data, format, &doc); err != nil {
+			return err
+		}
+		sections, err := configProfiles(doc)
+		if err != nil {
+			return err
+		}
+		profiles = append(profiles, slices.Sorted(maps.Keys(sections))...)
+	}
+
+	for _, name := range profiles {
+		cfg := defaultConfig()
+		if err := decodeConfigFile(data, format, name, cfg); err != nil {
+			return err
+		}
+		if err := ValidateConfig(cfg); err != nil {
+			if name != "" {
+				return fmt.Errorf("profile %s: %v", name, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package config
+
+import "runtime/debug"
+
+// Version switcher版本号，发布构建时通过 -ldflags "-X llama-switch/internal/config.Version=v1.2.3" 设置
+var Version = "dev"
+
+// BuildVersion 获取switcher版本号，未设置时使用构建信息中的VCS修订号
+func BuildVersion() string {
+	if Version != "dev" {
+		return Version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return Version
+	}
+
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value
+		}
+	}
+	if revision == "" {
+		return Version
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified == "true" {
+		revision += "-dirty"
+	}
+	return Version + "+" + revision
+}
@@ -0,0 +1,170 @@
+// Package daemon 处理switcher作为系统服务（systemd或Windows服务）运行时与服务管理器的交互：
+// 就绪通知、看门狗心跳，以及将服务管理器的停止请求和中断信号统一为一个停止通知
+package daemon
+
+import (
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 运行模式
+const (
+	ModeConsole        = "console"         // 直接在终端中运行
+	ModeSystemd        = "systemd"         // 作为systemd的Type=notify服务运行
+	ModeWindowsService = "windows-service" // 作为Windows服务运行
+)
+
+// Daemon switcher进程与服务管理器的交互
+type Daemon struct {
+	mode     string
+	stop     chan struct{} // 收到停止请求时关闭
+	stopOnce sync.Once
+	ready    chan struct{} // 开始提供服务时关闭
+	readyMu  sync.Once
+	done     chan struct{} // 停止完成时关闭
+	doneOnce sync.Once
+	exited   chan struct{} // Windows服务处理程序返回时关闭，其他模式为nil
+	reload   chan struct{} // 收到SIGHUP时发送，处理前再次收到的信号合并为一次
+	notify   string        // systemd通知套接字地址
+}
+
+// Start 检测运行模式并开始监听停止请求
+// 作为Windows服务运行时向服务控制管理器注册，name为服务名称
+func Start(name string) (*Daemon, error) {
+	d := &Daemon{
+		mode:   ModeConsole,
+		stop:   make(chan struct{}),
+		ready:  make(chan struct{}),
+		done:   make(chan struct{}),
+		reload: make(chan struct{}, 1),
+	}
+	if socket := os.Getenv("NOTIFY_SOCKET"); socket != "" {
+		d.mode = ModeSystemd
+		d.notify = socket
+	}
+	if err := d.startPlatform(name); err != nil {
+		return nil, err
+	}
+
+	// systemd通过SIGTERM停止服务，终端中通过Ctrl+C中断
+	go func() {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-sigChan
+		slog.Info("Received signal, shutting down", "signal", sig)
+		d.requestStop()
+	}()
+
+	// SIGHUP请求重新加载配置（systemd的ExecReload=/bin/kill -HUP $MAINPID）
+	go func() {
+		hupChan := make(chan os.Signal, 1)
+		signal.Notify(hupChan, syscall.SIGHUP)
+		for range hupChan {
+			slog.Info("Received signal, reloading configuration", "signal", syscall.SIGHUP)
+			select {
+			case d.reload <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return d, nil
+}
+
+// Mode 获取运行模式
+func (d *Daemon) Mode() string {
+	return d.mode
+}
+
+// StopRequested 获取停止通知，收到中断信号或服务管理器的停止请求时关闭
+func (d *Daemon) StopRequested() <-chan struct{} {
+	return d.stop
+}
+
+// ReloadRequested 获取重新加载配置的通知，每次收到SIGHUP时发送
+func (d *Daemon) ReloadRequested() <-chan struct{} {
+	return d.reload
+}
+
+// Ready 通知服务管理器已开始提供服务，systemd下同时开始看门狗心跳
+func (d *Daemon) Ready() {
+	d.readyMu.Do(func() {
+		close(d.ready)
+		if d.mode != ModeSystemd {
+			return
+		}
+		d.sdNotify("READY=1\nMAINPID=" + strconv.Itoa(os.Getpid()))
+		if interval := watchdogInterval(); interval > 0 {
+			go d.watchdog(interval)
+		}
+	})
+}
+
+// Stopping 通知服务管理器正在停止（停止所有模型可能需要较长时间）
+func (d *Daemon) Stopping() {
+	if d.mode == ModeSystemd {
+		d.sdNotify("STOPPING=1")
+	}
+}
+
+// Done 通知停止已完成；作为Windows服务运行时等待服务控制管理器确认服务已停止
+func (d *Daemon) Done() {
+	d.doneOnce.Do(func() { close(d.done) })
+	if d.exited != nil {
+		<-d.exited
+	}
+}
+
+// requestStop 触发停止通知
+func (d *Daemon) requestStop() {
+	d.stopOnce.Do(func() { close(d.stop) })
+}
+
+// watchdog 按看门狗超时的一半间隔发送心跳，直到停止完成
+func (d *Daemon) watchdog(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-ticker.C:
+			d.sdNotify("WATCHDOG=1")
+		}
+	}
+}
+
+// watchdogInterval 根据systemd设置的WATCHDOG_USEC计算心跳间隔，未启用看门狗时返回0
+func watchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	// WATCHDOG_PID指定了其他进程时看门狗不针对本进程
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+// sdNotify 向systemd通知套接字发送状态（见sd_notify(3)），以@开头的地址为抽象命名空间套接字
+func (d *Daemon) sdNotify(state string) {
+	socket := d.notify
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		slog.Warn("Failed to notify systemd", "error", err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		slog.Warn("Failed to notify systemd", "error", err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package daemon
+
+// startPlatform 非Windows平台无需额外注册
+func (d *Daemon) startPlatform(name string) error {
+	return nil
+}
@@ -0,0 +1,57 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSystemdNotify(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets not available: %v", err)
+	}
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", socket)
+	t.Setenv("WATCHDOG_USEC", "100000")
+	d, err := Start("llama-switch")
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if d.Mode() != ModeSystemd {
+		t.Fatalf("Expected systemd mode, got %s", d.Mode())
+	}
+
+	read := func() string {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		buf := make([]byte, 256)
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("Failed to read notification: %v", err)
+		}
+		return string(buf[:n])
+	}
+
+	d.Ready()
+	if state := read(); !strings.HasPrefix(state, "READY=1\n") {
+		t.Errorf("Expected READY notification, got %q", state)
+	}
+	// 看门狗按WATCHDOG_USEC的一半间隔发送心跳
+	if state := read(); state != "WATCHDOG=1" {
+		t.Errorf("Expected watchdog ping, got %q", state)
+	}
+
+	d.Stopping()
+	d.Done()
+	for {
+		if state := read(); state == "STOPPING=1" {
+			break
+		}
+	}
+}
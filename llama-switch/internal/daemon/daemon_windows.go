@@ -0,0 +1,77 @@
+//go:build windows
+
+package daemon
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// startPlatform 作为Windows服务运行时向服务控制管理器注册
+// 服务的工作目录为系统目录，因此切换到程序所在目录以便找到.env和config目录
+func (d *Daemon) startPlatform(name string) error {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return fmt.Errorf("failed to detect windows service: %v", err)
+	}
+	if !isService {
+		return nil
+	}
+
+	d.mode = ModeWindowsService
+	if exePath, err := os.Executable(); err == nil {
+		if err := os.Chdir(filepath.Dir(exePath)); err != nil {
+			slog.Warn("Failed to change to program directory", "error", err)
+		}
+	}
+
+	d.exited = make(chan struct{})
+	go func() {
+		defer close(d.exited)
+		if err := svc.Run(name, &serviceHandler{daemon: d}); err != nil {
+			slog.Error("Windows service failed", "error", err)
+			d.requestStop()
+		}
+	}()
+	return nil
+}
+
+// serviceHandler Windows服务控制请求处理程序
+type serviceHandler struct {
+	daemon *Daemon
+}
+
+// Execute 报告服务状态并将停止和关机请求转为停止通知，停止完成后返回
+func (h *serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	d := h.daemon
+	const accepts = svc.AcceptStop | svc.AcceptShutdown
+	changes <- svc.Status{State: svc.StartPending}
+
+	ready := d.ready
+	for {
+		select {
+		case <-ready:
+			changes <- svc.Status{State: svc.Running, Accepts: accepts}
+			ready = nil
+		case <-d.stop:
+			// 停止请求（或启动失败）后等待模型全部停止
+			changes <- svc.Status{State: svc.StopPending}
+			<-d.done
+			return false, 0
+		case <-d.done:
+			return false, 0
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				changes <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				slog.Info("Received windows service request, shutting down", "request", request.Cmd)
+				d.requestStop()
+			}
+		}
+	}
+}
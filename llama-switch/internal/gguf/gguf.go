@@ -0,0 +1,344 @@
+// Package gguf 读取GGUF模型文件头部的元数据和张量信息（不读取张量数据）
+package gguf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// magic GGUF文件头的魔数（小端序"GGUF"）
+const magic = 0x46554747
+
+// 元数据值类型
+const (
+	typeUint8   = 0
+	typeInt8    = 1
+	typeUint16  = 2
+	typeInt16   = 3
+	typeUint32  = 4
+	typeInt32   = 5
+	typeFloat32 = 6
+	typeBool    = 7
+	typeString  = 8
+	typeArray   = 9
+	typeUint64  = 10
+	typeInt64   = 11
+	typeFloat64 = 12
+)
+
+// maxArrayValues 数组元数据最多保留的元素个数，更长的数组（如词表）只记录长度
+const maxArrayValues = 4096
+
+// maxStringLen 单个字符串的最大长度，防止损坏的文件导致分配过大的内存
+const maxStringLen = 64 * 1024 * 1024
+
+// maxArrayDepth 数组元数据的最大嵌套层数，防止损坏的文件导致无限递归
+const maxArrayDepth = 4
+
+// Array 数组类型的元数据
+type Array struct {
+	Type   uint32        // 元素类型
+	Len    uint64        // 元素个数
+	Values []interface{} // 元素值，数组过长或元素为字符串时为空
+}
+
+// TensorInfo 张量信息
+type TensorInfo struct {
+	Name string
+	Dims []uint64
+	Type uint32 // ggml类型
+}
+
+// Elements 张量的元素个数
+func (t TensorInfo) Elements() uint64 {
+	n := uint64(1)
+	for _, d := range t.Dims {
+		n *= d
+	}
+	return n
+}
+
+// Bytes 张量数据占用的字节数，未知类型返回0
+func (t TensorInfo) Bytes() uint64 {
+	size, ok := typeSizes[t.Type]
+	if !ok {
+		return 0
+	}
+	return t.Elements() / size.block * size.bytes
+}
+
+// Layer 张量所属的层（名称形如blk.N.*），不属于任何层时返回-1
+func (t TensorInfo) Layer() int {
+	rest, ok := strings.CutPrefix(t.Name, "blk.")
+	if !ok {
+		return -1
+	}
+	index, _, _ := strings.Cut(rest, ".")
+	n, err := strconv.Atoi(index)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// File GGUF文件的头部信息
+type File struct {
+	Version  uint32
+	Metadata map[string]interface{}
+	Tensors  []TensorInfo
+}
+
+// Open 读取GGUF文件的头部信息
+func Open(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GGUF header of %s: %v", path, err)
+	}
+	return file, nil
+}
+
+// Read 从r读取GGUF头部信息
+func Read(r io.Reader) (*File, error) {
+	d := &decoder{r: bufio.NewReaderSize(r, 1<<16)}
+
+	if d.u32() != magic {
+		if d.err != nil {
+			return nil, d.err
+		}
+		return nil, fmt.Errorf("not a GGUF file")
+	}
+	file := &File{Version: d.u32(), Metadata: make(map[string]interface{})}
+	if d.err == nil && (file.Version < 2 || file.Version > 3) {
+		return nil, fmt.Errorf("unsupported GGUF version: %d", file.Version)
+	}
+
+	tensorCount, kvCount := d.u64(), d.u64()
+	for i := uint64(0); i < kvCount && d.err == nil; i++ {
+		key := d.str()
+		file.Metadata[key] = d.value(d.u32())
+	}
+	for i := uint64(0); i < tensorCount && d.err == nil; i++ {
+		t := TensorInfo{Name: d.str()}
+		nDims := d.u32()
+		if nDims > 8 {
+			return nil, fmt.Errorf("invalid dimension count %d for tensor %s", nDims, t.Name)
+		}
+		t.Dims = make([]uint64, nDims)
+		for j := range t.Dims {
+			t.Dims[j] = d.u64()
+		}
+		t.Type = d.u32()
+		d.u64() // 数据偏移
+		file.Tensors = append(file.Tensors, t)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return file, nil
+}
+
+// Uint 获取整数类型的元数据
+func (f *File) Uint(key string) (uint64, bool) {
+	return toUint(f.Metadata[key])
+}
+
+// String 获取字符串类型的元数据
+func (f *File) String(key string) string {
+	s, _ := f.Metadata[key].(string)
+	return s
+}
+
+// ArrayLen 获取数组类型元数据的长度
+func (f *File) ArrayLen(key string) (uint64, bool) {
+	a, ok := f.Metadata[key].(*Array)
+	if !ok {
+		return 0, false
+	}
+	return a.Len, true
+}
+
+// toUint 将整数类型的元数据值转换为uint64
+func toUint(v interface{}) (uint64, bool) {
+	switch n := v.(type) {
+	case uint8:
+		return uint64(n), true
+	case int8:
+		return uint64(n), n >= 0
+	case uint16:
+		return uint64(n), true
+	case int16:
+		return uint64(n), n >= 0
+	case uint32:
+		return uint64(n), true
+	case int32:
+		return uint64(n), n >= 0
+	case uint64:
+		return n, true
+	case int64:
+		return uint64(n), n >= 0
+	}
+	return 0, false
+}
+
+// UintArray 获取整数数组类型的元数据（如按层设置的head_count_kv）
+func (f *File) UintArray(key string) ([]uint64, bool) {
+	a, ok := f.Metadata[key].(*Array)
+	if !ok || uint64(len(a.Values)) != a.Len {
+		return nil, false
+	}
+	values := make([]uint64, len(a.Values))
+	for i, v := range a.Values {
+		n, ok := toUint(v)
+		if !ok {
+			return nil, false
+		}
+		values[i] = n
+	}
+	return values, true
+}
+
+// decoder 按小端序读取GGUF的基本类型，出错后后续读取均返回零值
+type decoder struct {
+	r     *bufio.Reader
+	err   error
+	depth int // 当前数组的嵌套层数
+}
+
+func (d *decoder) read(n int) []byte {
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		d.err = fmt.Errorf("unexpected end of header: %v", err)
+	}
+	return buf
+}
+
+func (d *decoder) u8() uint8   { return d.read(1)[0] }
+func (d *decoder) u16() uint16 { return binary.LittleEndian.Uint16(d.read(2)) }
+func (d *decoder) u32() uint32 { return binary.LittleEndian.Uint32(d.read(4)) }
+func (d *decoder) u64() uint64 { return binary.LittleEndian.Uint64(d.read(8)) }
+
+func (d *decoder) str() string {
+	n := d.u64()
+	if d.err != nil {
+		return ""
+	}
+	if n > maxStringLen {
+		d.err = fmt.Errorf("string length %d exceeds limit", n)
+		return ""
+	}
+	return string(d.read(int(n)))
+}
+
+// skip 跳过n个字节
+func (d *decoder) skip(n uint64) {
+	if d.err != nil {
+		return
+	}
+	if n > math.MaxInt64 {
+		d.err = fmt.Errorf("skip length %d exceeds limit", n)
+		return
+	}
+	if _, err := io.CopyN(io.Discard, d.r, int64(n)); err != nil {
+		d.err = fmt.Errorf("unexpected end of header: %v", err)
+	}
+}
+
+// value 读取指定类型的元数据值
+func (d *decoder) value(t uint32) interface{} {
+	switch t {
+	case typeUint8:
+		return d.u8()
+	case typeInt8:
+		return int8(d.u8())
+	case typeUint16:
+		return d.u16()
+	case typeInt16:
+		return int16(d.u16())
+	case typeUint32:
+		return d.u32()
+	case typeInt32:
+		return int32(d.u32())
+	case typeFloat32:
+		return math.Float32frombits(d.u32())
+	case typeBool:
+		return d.u8() != 0
+	case typeString:
+		return d.str()
+	case typeUint64:
+		return d.u64()
+	case typeInt64:
+		return int64(d.u64())
+	case typeFloat64:
+		return math.Float64frombits(d.u64())
+	case typeArray:
+		return d.array()
+	}
+	if d.err == nil {
+		d.err = fmt.Errorf("unknown metadata type: %d", t)
+	}
+	return nil
+}
+
+// fixedSizes 定长元数据类型的字节数
+var fixedSizes = map[uint32]uint64{
+	typeUint8: 1, typeInt8: 1, typeBool: 1,
+	typeUint16: 2, typeInt16: 2,
+	typeUint32: 4, typeInt32: 4, typeFloat32: 4,
+	typeUint64: 8, typeInt64: 8, typeFloat64: 8,
+}
+
+// array 读取数组元数据，过长的定长数组直接跳过，字符串数组只记录长度
+func (d *decoder) array() *Array {
+	a := &Array{Type: d.u32(), Len: d.u64()}
+	if d.err != nil {
+		return a
+	}
+	if d.depth >= maxArrayDepth {
+		d.err = fmt.Errorf("array nesting exceeds %d levels", maxArrayDepth)
+		return a
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+
+	size, fixed := fixedSizes[a.Type]
+	switch {
+	case fixed && a.Len > math.MaxInt64/size:
+		d.err = fmt.Errorf("array length %d exceeds limit", a.Len)
+	case fixed && a.Len > maxArrayValues:
+		d.skip(a.Len * size)
+	case fixed:
+		a.Values = make([]interface{}, a.Len)
+		for i := range a.Values {
+			a.Values[i] = d.value(a.Type)
+		}
+	case a.Type == typeString:
+		for i := uint64(0); i < a.Len && d.err == nil; i++ {
+			n := d.u64()
+			if n > maxStringLen {
+				d.err = fmt.Errorf("string length %d exceeds limit", n)
+				break
+			}
+			d.skip(n)
+		}
+	default:
+		for i := uint64(0); i < a.Len && d.err == nil; i++ {
+			d.value(a.Type)
+		}
+	}
+	return a
+}
@@ -0,0 +1,184 @@
+package gguf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// writer 构造测试用的GGUF头部
+type writer struct {
+	bytes.Buffer
+}
+
+func (w *writer) u32(v uint32) { binary.Write(w, binary.LittleEndian, v) }
+func (w *writer) u64(v uint64) { binary.Write(w, binary.LittleEndian, v) }
+func (w *writer) str(s string) { w.u64(uint64(len(s))); w.WriteString(s) }
+
+func TestRead(t *testing.T) {
+	var w writer
+	w.u32(magic)
+	w.u32(3)
+	w.u64(2) // 张量个数
+	w.u64(4) // 元数据个数
+
+	w.str("general.architecture")
+	w.u32(typeString)
+	w.str("llama")
+
+	w.str("llama.block_count")
+	w.u32(typeUint32)
+	w.u32(32)
+
+	w.str("llama.attention.head_count_kv")
+	w.u32(typeArray)
+	w.u32(typeInt32)
+	w.u64(2)
+	w.u32(8)
+	w.u32(4)
+
+	w.str("tokenizer.ggml.tokens")
+	w.u32(typeArray)
+	w.u32(typeString)
+	w.u64(3)
+	for _, tok := range []string{"<s>", "a", "b"} {
+		w.str(tok)
+	}
+
+	w.str("blk.7.attn_q.weight")
+	w.u32(2)
+	w.u64(4096)
+	w.u64(4096)
+	w.u32(TypeQ4_0)
+	w.u64(0)
+
+	w.str("output.weight")
+	w.u32(1)
+	w.u64(64)
+	w.u32(TypeF16)
+	w.u64(0)
+
+	f, err := Read(&w)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got := f.String("general.architecture"); got != "llama" {
+		t.Errorf("architecture = %q, want llama", got)
+	}
+	if n, ok := f.Uint("llama.block_count"); !ok || n != 32 {
+		t.Errorf("block_count = %d, %v, want 32", n, ok)
+	}
+	if kv, ok := f.UintArray("llama.attention.head_count_kv"); !ok || len(kv) != 2 || kv[0] != 8 || kv[1] != 4 {
+		t.Errorf("head_count_kv = %v, %v, want [8 4]", kv, ok)
+	}
+	if n, ok := f.ArrayLen("tokenizer.ggml.tokens"); !ok || n != 3 {
+		t.Errorf("tokens length = %d, %v, want 3", n, ok)
+	}
+
+	if len(f.Tensors) != 2 {
+		t.Fatalf("got %d tensors, want 2", len(f.Tensors))
+	}
+	q := f.Tensors[0]
+	if q.Layer() != 7 {
+		t.Errorf("layer = %d, want 7", q.Layer())
+	}
+	if want := uint64(4096 * 4096 / 32 * 18); q.Bytes() != want {
+		t.Errorf("Q4_0 bytes = %d, want %d", q.Bytes(), want)
+	}
+	if out := f.Tensors[1]; out.Layer() != -1 || out.Bytes() != 128 {
+		t.Errorf("output tensor layer/bytes = %d/%d, want -1/128", out.Layer(), out.Bytes())
+	}
+}
+
+func TestReadRejectsNonGGUF(t *testing.T) {
+	if _, err := Read(bytes.NewReader([]byte("not a model file"))); err == nil {
+		t.Error("expected error for non-GGUF input")
+	}
+}
+
+func TestReadArrayLimits(t *testing.T) {
+	// header 构造只有一个数组元数据的头部，array写入数组类型之后的部分
+	header := func(array func(w *writer)) *writer {
+		w := &writer{}
+		w.u32(magic)
+		w.u32(3)
+		w.u64(0) // 张量个数
+		w.u64(1) // 元数据个数
+		w.str("test.array")
+		w.u32(typeArray)
+		array(w)
+		return w
+	}
+	// nested 写入depth层嵌套的数组，最内层为两个uint32
+	var nested func(w *writer, depth int)
+	nested = func(w *writer, depth int) {
+		if depth == 1 {
+			w.u32(typeUint32)
+			w.u64(2)
+			w.u32(1)
+			w.u32(2)
+			return
+		}
+		w.u32(typeArray)
+		w.u64(1)
+		nested(w, depth-1)
+	}
+
+	tests := []struct {
+		name  string
+		array func(w *writer)
+		err   string
+	}{
+		{"nested within limit", func(w *writer) { nested(w, maxArrayDepth) }, ""},
+		{"nested too deep", func(w *writer) { nested(w, maxArrayDepth+1) }, "array nesting exceeds"},
+		{"deeply nested", func(w *writer) { nested(w, 1000) }, "array nesting exceeds"},
+		// 元素个数乘以元素大小溢出为8，修复前会跳过8个字节后当作正常的数组
+		{"length overflows skip size", func(w *writer) {
+			w.u32(typeUint64)
+			w.u64(1<<61 + 1)
+			w.u64(0)
+		}, "array length 2305843009213693953 exceeds limit"},
+		{"length exceeds int64", func(w *writer) {
+			w.u32(typeUint8)
+			w.u64(1 << 63)
+		}, "array length 9223372036854775808 exceeds limit"},
+		{"long array skipped", func(w *writer) {
+			w.u32(typeUint8)
+			w.u64(maxArrayValues + 1)
+			w.Write(make([]byte, maxArrayValues+1))
+		}, ""},
+	}
+	for _, tt := range tests {
+		f, err := Read(header(tt.array))
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: Read failed: %v", tt.name, err)
+		case tt.err == "" && f.Metadata["test.array"] == nil:
+			t.Errorf("%s: array metadata missing", tt.name)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: Read error = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestCacheTypeBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		want uint64
+		ok   bool
+	}{
+		{"", 2048, true},
+		{"f16", 2048, true},
+		{"f32", 4096, true},
+		{"q8_0", 1088, true},
+		{"q4_0", 576, true},
+		{"bogus", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := CacheTypeBytes(tt.name, 1024)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("CacheTypeBytes(%q) = %d, %v, want %d, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
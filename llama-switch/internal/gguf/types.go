@@ -0,0 +1,80 @@
+package gguf
+
+// typeSize ggml类型的量化块大小（元素个数）和每块字节数
+type typeSize struct {
+	block uint64
+	bytes uint64
+}
+
+// ggml类型
+const (
+	TypeF32  = 0
+	TypeF16  = 1
+	TypeQ4_0 = 2
+	TypeQ4_1 = 3
+	TypeQ5_0 = 6
+	TypeQ5_1 = 7
+	TypeQ8_0 = 8
+	TypeBF16 = 30
+)
+
+// typeSizes ggml类型的存储大小，与ggml.c中的type_traits一致
+var typeSizes = map[uint32]typeSize{
+	TypeF32:  {1, 4},
+	TypeF16:  {1, 2},
+	TypeQ4_0: {32, 18},
+	TypeQ4_1: {32, 20},
+	TypeQ5_0: {32, 22},
+	TypeQ5_1: {32, 24},
+	TypeQ8_0: {32, 34},
+	9:        {32, 36},   // Q8_1
+	10:       {256, 84},  // Q2_K
+	11:       {256, 110}, // Q3_K
+	12:       {256, 144}, // Q4_K
+	13:       {256, 176}, // Q5_K
+	14:       {256, 210}, // Q6_K
+	15:       {256, 292}, // Q8_K
+	16:       {256, 66},  // IQ2_XXS
+	17:       {256, 74},  // IQ2_XS
+	18:       {256, 98},  // IQ3_XXS
+	19:       {256, 50},  // IQ1_S
+	20:       {32, 18},   // IQ4_NL
+	21:       {256, 110}, // IQ3_S
+	22:       {256, 82},  // IQ2_S
+	23:       {256, 136}, // IQ4_XS
+	24:       {1, 1},     // I8
+	25:       {1, 2},     // I16
+	26:       {1, 4},     // I32
+	27:       {1, 8},     // I64
+	28:       {1, 8},     // F64
+	29:       {256, 56},  // IQ1_M
+	TypeBF16: {1, 2},
+	34:       {256, 54}, // TQ1_0
+	35:       {256, 66}, // TQ2_0
+}
+
+// cacheTypes llama-server的--cache-type-k/--cache-type-v取值对应的ggml类型
+var cacheTypes = map[string]uint32{
+	"f32":    TypeF32,
+	"f16":    TypeF16,
+	"bf16":   TypeBF16,
+	"q8_0":   TypeQ8_0,
+	"q4_0":   TypeQ4_0,
+	"q4_1":   TypeQ4_1,
+	"iq4_nl": 20,
+	"q5_0":   TypeQ5_0,
+	"q5_1":   TypeQ5_1,
+}
+
+// CacheTypeBytes 计算n个元素以KV缓存类型name存储时占用的字节数，name为空时按f16计算
+func CacheTypeBytes(name string, n uint64) (uint64, bool) {
+	if name == "" {
+		name = "f16"
+	}
+	t, ok := cacheTypes[name]
+	if !ok {
+		return 0, false
+	}
+	size := typeSizes[t]
+	return (n + size.block - 1) / size.block * size.bytes, true
+}
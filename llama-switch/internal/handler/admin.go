@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"llama-switch/internal/model"
+)
+
+// ReloadConfig 重新加载配置处理器，返回与当前配置相比变化的配置项
+func (h *Handler) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	reload, err := h.ModelService.ReloadConfig()
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	message := "Configuration reloaded, no changes"
+	if len(reload.Changes) > 0 {
+		message = fmt.Sprintf("Configuration reloaded, %d setting(s) changed", len(reload.Changes))
+	}
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(true, message, reload, ""))
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"llama-switch/internal/logging"
+	"llama-switch/internal/model"
+)
+
+// ListAliases 获取别名列表处理器
+func (h *Handler) ListAliases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		"Aliases retrieved successfully",
+		h.ModelService.Aliases().List(),
+		"",
+	))
+}
+
+// SetAlias 设置或重新指向别名处理器
+func (h *Handler) SetAlias(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req model.AliasUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Author == "" {
+		req.Author = r.RemoteAddr
+	}
+
+	change, err := h.ModelService.Aliases().Set(&req)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to set alias", "alias", req.Alias, "error", err)
+		h.respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Alias '%s' now points to '%s'", change.Alias, change.To),
+		change,
+		"",
+	))
+}
+
+// RemoveAlias 删除别名处理器
+func (h *Handler) RemoveAlias(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req model.AliasUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Author == "" {
+		req.Author = r.RemoteAddr
+	}
+
+	change, err := h.ModelService.Aliases().Remove(req.Alias, req.Author, req.Reason)
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Alias '%s' removed", req.Alias),
+		change,
+		"",
+	))
+}
+
+// GetAliasChangelog 获取别名变更记录处理器，可通过alias参数过滤
+func (h *Handler) GetAliasChangelog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		"Alias changelog retrieved successfully",
+		h.ModelService.Aliases().Changelog(r.URL.Query().Get("alias")),
+		"",
+	))
+}
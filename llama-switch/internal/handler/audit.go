@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"llama-switch/internal/auth"
+	"llama-switch/internal/listing"
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+)
+
+// maxAuditBody 审计日志摘要读取的最大请求体大小，更大的请求体不记录摘要
+const maxAuditBody = 64 * 1024
+
+// defaultAuditLimit 审计日志查询默认返回的最近条数
+const defaultAuditLimit = 100
+
+// Audit 审计日志中间件：修改类API请求（需要操作员或管理员角色的请求）在处理结束后记录调用方、
+// 请求体摘要和结果，包括认证失败和角色不足被拒绝的请求
+func (h *Handler) Audit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		auditLog := h.ModelService.Audit()
+		path := routePath(r)
+		if auditLog == nil || !isMutatingRequest(r.Method, path) {
+			next(w, r)
+			return
+		}
+
+		start := time.Now()
+		var body []byte
+		if r.Body != nil && r.ContentLength <= maxAuditBody {
+			data, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBody+1))
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), r.Body))
+			if err == nil && len(data) <= maxAuditBody {
+				body = data
+			}
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+
+		key := apiKeyFromRequest(r)
+		entry := model.AuditEntry{
+			Time:       start.Format(time.RFC3339),
+			Caller:     h.ModelService.CallerForKey(key),
+			ClientIP:   clientIP(r),
+			Method:     r.Method,
+			Path:       r.URL.RequestURI(),
+			Route:      path,
+			ModelName:  r.PathValue("name"),
+			Request:    service.SummarizePayload(body),
+			Status:     recorder.status,
+			Outcome:    service.AuditOutcome(recorder.status),
+			DurationMS: time.Since(start).Milliseconds(),
+		}
+		if h.ModelService.AuthEnabled() {
+			if role, err := h.ModelService.RoleForKey(key); err == nil {
+				entry.Role = role.String()
+			}
+		}
+		if name, ok := entry.Request["model_name"].(string); ok && entry.ModelName == "" {
+			entry.ModelName = name
+		}
+		if entry.Outcome != service.AuditSuccess {
+			entry.Code, entry.Error = responseError(recorder.body.Bytes())
+		}
+		auditLog.Record(entry)
+	}
+}
+
+// isMutatingRequest 是否为记录到审计日志的请求：需要操作员或管理员角色的API请求，
+// 不修改状态的POST请求（如验证配置）只需要只读角色，不记录
+func isMutatingRequest(method, path string) bool {
+	if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+		return false
+	}
+	return strings.HasPrefix(path, "/api/") && auth.Required(method, path) >= auth.RoleOperator
+}
+
+// clientIP 请求的客户端IP
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// responseError 从错误响应（APIResponse或problem+json）中提取错误码和错误信息
+func responseError(body []byte) (string, string) {
+	var resp struct {
+		Code   string `json:"code"`
+		Error  string `json:"error"`
+		Detail string `json:"detail"`
+	}
+	json.Unmarshal(body, &resp)
+	if resp.Error == "" {
+		resp.Error = resp.Detail
+	}
+	return resp.Code, resp.Error
+}
+
+// GetAudit 查询审计日志处理器，可按since（RFC3339）、caller、model_name和outcome过滤，
+// 默认返回最近100条，可用limit、offset和sort（time、caller、status、duration_ms）分页排序
+func (h *Handler) GetAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	auditLog := h.ModelService.Audit()
+	if auditLog == nil {
+		h.respondWithError(w, http.StatusNotFound, "Audit log is disabled (AUDIT_ENABLED=false)")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := service.AuditFilter{
+		Caller:    query.Get("caller"),
+		ModelName: query.Get("model_name"),
+		Outcome:   query.Get("outcome"),
+	}
+	if value := query.Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid since value: %s (expected RFC3339)", value))
+			return
+		}
+		filter.Since = since
+	}
+	params, ok := h.parseListParams(w, r, defaultAuditLimit, "time", "caller", "status", "duration_ms")
+	if !ok {
+		return
+	}
+	switch filter.Outcome {
+	case "", service.AuditSuccess, service.AuditDenied, service.AuditFailure:
+	default:
+		h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid outcome value: %s (expected success, denied or failure)", filter.Outcome))
+		return
+	}
+
+	entries, err := auditLog.Query(filter)
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+	page, total := listing.Apply(entries, params, map[string]func(a, b model.AuditEntry) int{
+		"time":        func(a, b model.AuditEntry) int { return strings.Compare(a.Time, b.Time) },
+		"caller":      func(a, b model.AuditEntry) int { return strings.Compare(a.Caller, b.Caller) },
+		"status":      func(a, b model.AuditEntry) int { return cmp.Compare(a.Status, b.Status) },
+		"duration_ms": func(a, b model.AuditEntry) int { return cmp.Compare(a.DurationMS, b.DurationMS) },
+	}, true)
+	setTotalCount(w, total)
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Retrieved %d of %d audit entries", len(page), total),
+		page,
+		"",
+	))
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"llama-switch/internal/auth"
+)
+
+// Authorize 权限检查中间件：启用认证时检查请求的API密钥是否具有路由所需的角色，
+// 没有密钥或密钥无效时返回401，角色不足时返回403
+func (h *Handler) Authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		required := auth.Required(r.Method, routePath(r))
+		if required == auth.RoleNone || !h.ModelService.AuthEnabled() {
+			next(w, r)
+			return
+		}
+
+		role, err := h.ModelService.RoleForKey(apiKeyFromRequest(r))
+		if err != nil {
+			h.respondWithServiceError(w, http.StatusUnauthorized, err)
+			return
+		}
+		if !role.Allows(required) {
+			h.respondWithError(w, http.StatusForbidden, fmt.Sprintf("%s %s requires the %s role (API key has %s)", r.Method, r.URL.Path, required, role))
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"llama-switch/internal/logging"
+	"llama-switch/internal/model"
+)
+
+// ListBenchmarkBaselines 获取各模型固定基线列表处理器
+func (h *Handler) ListBenchmarkBaselines(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		"Benchmark baselines retrieved successfully",
+		h.BenchmarkService.Baselines().List(),
+		"",
+	))
+}
+
+// SetBenchmarkBaseline 将已完成的任务固定为其模型的基线处理器
+func (h *Handler) SetBenchmarkBaseline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		TaskID string `json:"task_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.TaskID == "" {
+		h.respondWithError(w, http.StatusBadRequest, "task_id is required")
+		return
+	}
+
+	baseline, err := h.BenchmarkService.Baselines().Pin(req.TaskID)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to pin benchmark baseline", "task_id", req.TaskID, "error", err)
+		h.respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Task %s pinned as baseline for %s", baseline.TaskID, baseline.ModelPath),
+		baseline,
+		"",
+	))
+}
+
+// RemoveBenchmarkBaseline 取消模型的固定基线处理器
+func (h *Handler) RemoveBenchmarkBaseline(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		ModelPath string `json:"model_path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.BenchmarkService.Baselines().Unpin(req.ModelPath); err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Baseline for %s removed", req.ModelPath),
+		nil,
+		"",
+	))
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"llama-switch/internal/service"
+)
+
+// ExportBenchmark 以CSV/Markdown/JSON格式导出已结束基准测试的结果处理器，作为附件下载
+func (h *Handler) ExportBenchmark(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	taskID := r.PathValue("task_id")
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = service.ExportFormatJSON
+	}
+	if err := service.ValidateExportFormat(format); err != nil {
+		h.respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	export, err := h.BenchmarkService.Export(taskID)
+	if errors.Is(err, service.ErrBenchmarkNotFinished) {
+		h.respondWithServiceError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	data, contentType, err := service.RenderBenchmarkExport(export, format)
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"benchmark-%s.%s\"", export.TaskID, format))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+
+	"llama-switch/internal/service"
+)
+
+// GetBenchmarkOutput 获取llama-bench任务保存在磁盘上的完整输出处理器，运行中的任务返回目前为止的输出
+func (h *Handler) GetBenchmarkOutput(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	stream := r.URL.Query().Get("stream")
+	if stream == "" {
+		stream = service.OutputStdout
+	}
+	if stream != service.OutputStdout && stream != service.OutputStderr {
+		h.respondWithError(w, http.StatusBadRequest, "invalid stream: "+stream+" (expected stdout or stderr)")
+		return
+	}
+
+	file, err := h.BenchmarkService.OpenOutput(r.PathValue("task_id"), stream)
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, file)
+}
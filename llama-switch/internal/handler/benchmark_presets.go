@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+)
+
+// ListBenchmarkPresets 获取内置基准测试预设处理器
+func (h *Handler) ListBenchmarkPresets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		"Benchmark presets retrieved successfully",
+		service.BenchmarkPresets(),
+		"",
+	))
+}
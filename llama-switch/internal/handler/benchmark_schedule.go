@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"llama-switch/internal/logging"
+	"llama-switch/internal/model"
+)
+
+// ListBenchmarkSchedules 获取定时基准测试列表处理器
+func (h *Handler) ListBenchmarkSchedules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		"Benchmark schedules retrieved successfully",
+		h.BenchmarkService.Schedules().List(),
+		"",
+	))
+}
+
+// SetBenchmarkSchedule 添加或替换定时基准测试处理器
+func (h *Handler) SetBenchmarkSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var schedule model.BenchmarkSchedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	status, err := h.BenchmarkService.Schedules().Set(&schedule)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to set benchmark schedule", "schedule", schedule.Name, "error", err)
+		h.respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Benchmark schedule '%s' set, next run at %s", schedule.Name, status.NextRun),
+		status,
+		"",
+	))
+}
+
+// RemoveBenchmarkSchedule 删除定时基准测试处理器
+func (h *Handler) RemoveBenchmarkSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.BenchmarkService.Schedules().Remove(req.Name); err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Benchmark schedule '%s' removed", req.Name),
+		nil,
+		"",
+	))
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+)
+
+// StartServingBenchmark 启动端到端服务基准测试处理器，进度和结果通过/api/v1/benchmark/status查询
+func (h *Handler) StartServingBenchmark(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var cfg model.ServingBenchmarkConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.BenchmarkService.ValidateServingConfig(&cfg); err != nil {
+		h.respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	taskID, err := h.BenchmarkService.StartServingBenchmark(&cfg)
+	var conflictErr *service.BenchmarkConflictError
+	if errors.As(err, &conflictErr) {
+		apierror.Write(w, benchmarkConflictProblem(conflictErr))
+		return
+	}
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		"Serving benchmark started successfully",
+		map[string]string{"task_id": taskID},
+		"",
+	))
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/logging"
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+)
+
+// StartBenchmarkSuite 对模型目录下的所有模型依次运行标准基准测试处理器，请求体可省略
+func (h *Handler) StartBenchmarkSuite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var cfg model.BenchmarkConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	suiteID, err := h.BenchmarkService.StartSuite(&cfg)
+	var conflictErr *service.BenchmarkConflictError
+	if errors.As(err, &conflictErr) {
+		apierror.Write(w, benchmarkConflictProblem(conflictErr))
+		return
+	}
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to start benchmark suite", "error", err)
+		h.respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		"Benchmark suite started successfully",
+		map[string]string{"suite_id": suiteID},
+		"",
+	))
+}
+
+// GetBenchmarkSuite 获取测试套件进度和排行榜处理器，sort指定排行榜排序方式
+func (h *Handler) GetBenchmarkSuite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	suiteID := query.Get("suite_id")
+	if suiteID == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Suite ID is required")
+		return
+	}
+	sortBy := query.Get("sort")
+	if err := service.ValidateLeaderboardSort(sortBy); err != nil {
+		h.respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	suite, err := h.BenchmarkService.GetSuite(suiteID, sortBy)
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Benchmark suite %s", suite.Status),
+		suite,
+		"",
+	))
+}
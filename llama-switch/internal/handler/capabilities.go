@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+	"runtime"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+)
+
+// GetCapabilities 获取部署启用的可选功能处理器
+func (h *Handler) GetCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		"Capabilities retrieved successfully",
+		capabilities(h.config.Snapshot(), h.ModelService),
+		"",
+	))
+}
+
+// capabilities 根据配置生成功能发现信息
+func capabilities(cfg *config.Config, s *service.ModelService) *model.Capabilities {
+	return &model.Capabilities{
+		Version:         config.BuildVersion(),
+		Platform:        runtime.GOOS + "/" + runtime.GOARCH,
+		GPUVendors:      service.DetectGPUVendors(),
+		GPUProvider:     s.GPU().Vendor(),
+		LlamaServer:     s.ServerVersion(),
+		BackendProfiles: s.ProfileVersions(),
+		Features: map[string]bool{
+			"proxy":               cfg.Proxy.Enabled,
+			"embedding_batching":  cfg.Proxy.Enabled && cfg.Embedding.BatchEnabled,
+			"rerank_autostart":    cfg.Proxy.Enabled && cfg.Rerank.DefaultModel != "",
+			"routing":             cfg.Proxy.Enabled,
+			"request_transforms":  cfg.Proxy.Enabled,
+			"aliases":             true,
+			"evals":               true,
+			"alias_webhooks":      cfg.Alias.WebhookURL != "",
+			"downloads":           true,
+			"timeshare":           cfg.TimeShare.Enabled,
+			"status_page":         cfg.StatusPage.Enabled,
+			"metrics":             cfg.Metrics.Enabled,
+			"switch_guard":        cfg.SwitchGuard.Enabled,
+			"model_logs":          true,
+			"switch_dry_run":      true,
+			"async_switch":        true,
+			"gpu_placement":       cfg.GPU.Placement == "bestfit",
+			"gpu_inventory":       true,
+			"rpc_pools":           len(cfg.RPC.Pools) > 0,
+			"tenant_quotas":       len(cfg.Tenants.APIKeys) > 0,
+			"auto_offload":        true,
+			"auto_tensor_split":   true,
+			"vram_reservation":    true,
+			"ram_admission":       cfg.RAM.CheckEnabled,
+			"health_checks":       cfg.HealthCheck.Interval > 0,
+			"resource_sampling":   cfg.Resources.SampleInterval > 0,
+			"vram_history":        cfg.Resources.SampleInterval > 0 && cfg.VRAMHistory.Interval > 0,
+			"gpu_thermal_alerts":  cfg.Resources.SampleInterval > 0,
+			"watchdog":            cfg.HealthCheck.Interval > 0 && cfg.Watchdog.Failures > 0,
+			"events":              true,
+			"webhooks":            true,
+			"event_stream":        true,
+			"vram_low_alerts":     cfg.Resources.SampleInterval > 0 && cfg.Resources.VRAMLowMB > 0,
+			"audit_log":           cfg.Audit.Enabled,
+			"reconciliation":      cfg.Reconcile.Interval > 0,
+			"benchmark":           true,
+			"benchmark_reproduce": true,
+			"benchmark_history":   true,
+			"benchmark_queue":     true,
+			"benchmark_schedules": true,
+			"benchmark_suite":     true,
+			"benchmark_serving":   true,
+			"benchmark_export":    true,
+			"benchmark_retention": cfg.Benchmark.MaxTasks > 0 || cfg.Benchmark.TaskMaxAge > 0,
+			"benchmark_webhooks":  true,
+			"benchmark_baselines": true,
+			"benchmark_rpc":       true,
+			"benchmark_grouped":   true,
+			"benchmark_presets":   true,
+			"benchmark_output":    true,
+			"config_reload":       true,
+			"config_schema":       true,
+			"config_defaults":     true,
+			"config_env":          true,
+			"model_definitions":   true,
+			"cluster":             false,
+			"auth":                cfg.Security.APIKey != "" || len(cfg.Security.APIKeys) > 0 || cfg.JWT.Issuer != "",
+			"jwt":                 cfg.JWT.Issuer != "",
+			"rbac":                true,
+			"cors":                len(cfg.CORS.AllowedOrigins) > 0,
+			"rate_limit":          cfg.RateLimit.PerMinute > 0 || cfg.RateLimit.SwitchPerMinute > 0,
+			"admin_ip_filter":     len(cfg.Security.AllowedCIDRs) > 0 || len(cfg.Security.DeniedCIDRs) > 0,
+			"proxy_listener":      cfg.Proxy.Enabled && cfg.Proxy.Port != 0,
+			"idempotency_keys":    cfg.Idempotency.TTL > 0,
+			"tls":                 cfg.Security.SSLCert != "" && cfg.Security.SSLKey != "",
+			"mtls":                cfg.Security.SSLClientCA != "",
+			"openapi":             true,
+			"problem_json":        true,
+			"api_v2":              true,
+			"list_pagination":     true,
+		},
+	}
+}
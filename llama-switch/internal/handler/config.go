@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/logging"
+	"llama-switch/internal/model"
+)
+
+// maxConfigBodySize 待验证配置内容的最大长度
+const maxConfigBodySize = 1 << 20
+
+// schemaOverrides 自定义JSON编码类型的Schema
+var schemaOverrides = map[string]map[string]interface{}{
+	"GPULayers": {
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "integer"},
+			map[string]interface{}{"const": "auto"},
+		},
+	},
+	"Megabytes":    unitSchema("size in MB, or a string with a unit such as 512m or 8g"),
+	"Kilobytes":    unitSchema("size in KB, or a string with a unit such as 512k or 4m"),
+	"Seconds":      unitSchema("duration in seconds, or a string with a unit such as 30s or 10m"),
+	"Milliseconds": unitSchema("duration in milliseconds, or a string with a unit such as 250ms or 2s"),
+}
+
+// unitSchema 可以是整数或带单位字符串的大小和时间配置项的Schema
+func unitSchema(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "integer"},
+			map[string]interface{}{"type": "string"},
+		},
+	}
+}
+
+// GetConfig 获取当前生效的配置处理器，密钥以******代替
+func (h *Handler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(true, "", config.Redacted(h.config.Snapshot()), ""))
+}
+
+// GetConfigEnv 获取所有可识别的环境变量处理器，包括对应的配置项、说明、当前值及其来源，密钥以******代替
+func (h *Handler) GetConfigEnv(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(true, "", config.EnvVars(h.config.Snapshot()), ""))
+}
+
+// GetConfigSchema 获取服务器配置和模型切换请求体的JSON Schema处理器
+func (h *Handler) GetConfigSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// 配置文件中的profiles为环境名称到配置覆盖项的映射，覆盖项的结构与顶层相同
+	configSchema := config.JSONSchema(config.Config{}, "llama-switch server configuration", schemaOverrides)
+	profileSchema := config.JSONSchema(config.Config{}, "", schemaOverrides)
+	delete(profileSchema, "$schema")
+	delete(profileSchema, "title")
+	configSchema["properties"].(map[string]interface{})["profiles"] = map[string]interface{}{
+		"type":                 "object",
+		"additionalProperties": profileSchema,
+	}
+
+	schemas := map[string]interface{}{
+		"config":       configSchema,
+		"model_config": config.JSONSchema(model.ModelConfig{}, "POST /api/v1/model/switch request body", schemaOverrides),
+	}
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(true, "", schemas, ""))
+}
+
+// ValidateConfig 验证配置文件内容处理器，请求体为YAML或JSON配置文件，不会应用到当前配置
+// 指定profile参数时只验证使用该环境配置的结果，否则验证顶层配置和所有环境配置
+func (h *Handler) ValidateConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxConfigBodySize))
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	// 格式由format参数指定，未指定时按Content-Type判断
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+		if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+			format = "yaml"
+		}
+	}
+
+	if err := config.ValidateConfigFile(data, "."+format, r.URL.Query().Get("profile")); err != nil {
+		h.respondWithServiceError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(true, "Configuration is valid", nil, ""))
+}
+
+// ConfigDefaults 默认模型配置处理器：GET获取当前的默认配置和修改记录，PATCH修改默认配置并持久化
+func (h *Handler) ConfigDefaults(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		defaults, err := h.ModelService.GetDefaults()
+		if err != nil {
+			h.respondWithServiceError(w, http.StatusInternalServerError, err)
+			return
+		}
+		h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(true, "", defaults, ""))
+
+	case http.MethodPatch:
+		var req model.DefaultsUpdateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		// 修改人使用认证得到的调用方身份，请求体中的author只作为备注
+		req.Caller = h.ModelService.CallerForKey(apiKeyFromRequest(r))
+
+		change, err := h.ModelService.UpdateDefaults(&req)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("Failed to update default model settings", "error", err)
+			h.respondWithServiceError(w, http.StatusBadRequest, err)
+			return
+		}
+		message := "Default model settings unchanged"
+		if len(change.Changes) > 0 {
+			message = fmt.Sprintf("Updated %d default model setting(s)", len(change.Changes))
+		}
+		h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(true, message, change, ""))
+
+	default:
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
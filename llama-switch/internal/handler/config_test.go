@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+)
+
+func TestGetConfigRedactsSecrets(t *testing.T) {
+	dir := t.TempDir()
+	// 环境变量优先于配置文件，清除可能由系统设置的SSL变量
+	t.Setenv("SSL_CERT_FILE", "")
+	t.Setenv("SSL_KEY_FILE", "")
+	for _, name := range []string{"llama-server", "llama-bench"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "config.yaml")
+	data := fmt.Sprintf(`llama_path:
+  server: %[1]s/llama-server
+  bench: %[1]s/llama-bench
+models_dir: %[1]s
+security:
+  api_keys:
+    ops: ops-secret
+tenants:
+  api_keys:
+    team-a: tenant-secret
+`, dir)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("API_KEY", "admin-secret")
+	t.Setenv("SMTP_PASSWORD", "smtp-secret")
+	t.Setenv("HF_TOKEN", "hf_secret")
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	h := &Handler{config: cfg}
+
+	rec := httptest.NewRecorder()
+	h.GetConfig(rec, httptest.NewRequest("GET", "/api/v1/config", nil))
+	if rec.Code != 200 {
+		t.Fatalf("GET /api/v1/config = %d: %s", rec.Code, rec.Body)
+	}
+	body := rec.Body.String()
+	for _, secret := range []string{"admin-secret", "ops-secret", "tenant-secret", "smtp-secret", "hf_secret"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("response contains secret %q: %s", secret, body)
+		}
+	}
+
+	var resp struct {
+		Data struct {
+			Security struct {
+				APIKey  string            `json:"api_key"`
+				APIKeys map[string]string `json:"api_keys"`
+			} `json:"security"`
+			Tenants struct {
+				APIKeys map[string]string `json:"api_keys"`
+			} `json:"tenants"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	// 密钥以******代替，仍可看出是否已设置
+	if resp.Data.Security.APIKey != "******" || resp.Data.Security.APIKeys["ops"] != "******" || resp.Data.Tenants.APIKeys["team-a"] != "******" {
+		t.Errorf("secrets not redacted: %+v", resp.Data)
+	}
+}
+
+// isolateConfig 把服务写入的各个文件放到临时目录下，避免写入程序目录
+func isolateConfig(cfg *config.Config, dir string) {
+	cfg.PersistentDir = dir
+	cfg.ModelLog.Dir = filepath.Join(dir, "logs")
+	cfg.Events.File = filepath.Join(dir, "events.jsonl")
+	cfg.Alias.File = filepath.Join(dir, "aliases.json")
+	cfg.Eval.File = filepath.Join(dir, "evals.json")
+	cfg.Webhooks.File = filepath.Join(dir, "webhooks.json")
+	cfg.VRAMHistory.Dir = filepath.Join(dir, "vram_history")
+	cfg.ModelDefs.Dir = filepath.Join(dir, "models.d")
+}
+
+func TestConfigDefaultsRecordsAuthenticatedCaller(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SSL_CERT_FILE", "")
+	t.Setenv("SSL_KEY_FILE", "")
+	for _, name := range []string{"llama-server", "llama-bench"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "config.yaml")
+	data := fmt.Sprintf(`llama_path:
+  server: %[1]s/llama-server
+  bench: %[1]s/llama-bench
+models_dir: %[1]s
+security:
+  api_keys:
+    ops: ops-secret
+`, dir)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	isolateConfig(cfg, filepath.Join(dir, "state"))
+	h := NewHandlerWithService(cfg, service.NewModelService(cfg, false), nil)
+
+	// 请求体中的author与认证的密钥不同：修改人记录为密钥的身份，author只作为备注
+	body := `{"defaults":{"default_model":{"ctx_size":8192}},"author":"alice","reason":"more context"}`
+	req := httptest.NewRequest("PATCH", "/api/v1/config/defaults", strings.NewReader(body))
+	req.Header.Set("X-API-Key", "ops-secret")
+	rec := httptest.NewRecorder()
+	h.ConfigDefaults(rec, req)
+	if rec.Code != 200 {
+		t.Fatalf("PATCH /api/v1/config/defaults = %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Data model.DefaultsChange `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if resp.Data.Author != "key:ops" || resp.Data.Note != "alice" {
+		t.Errorf("change author = %q, note = %q; want key:ops with note alice", resp.Data.Author, resp.Data.Note)
+	}
+
+	defaults, err := h.ModelService.GetDefaults()
+	if err != nil {
+		t.Fatalf("GetDefaults failed: %v", err)
+	}
+	if len(defaults.Changelog) != 1 || defaults.Changelog[0].Author != "key:ops" || defaults.Changelog[0].Note != "alice" {
+		t.Errorf("changelog = %+v, want one change by key:ops", defaults.Changelog)
+	}
+}
+
+func TestGetConfigSchemaCoversModelConfig(t *testing.T) {
+	h := &Handler{config: &config.Config{}}
+	rec := httptest.NewRecorder()
+	h.GetConfigSchema(rec, httptest.NewRequest("GET", "/api/v1/config/schema", nil))
+	if rec.Code != 200 {
+		t.Fatalf("GET /api/v1/config/schema = %d: %s", rec.Code, rec.Body)
+	}
+	var resp struct {
+		Data map[string]map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+
+	// 切换请求体的每个字段（包括config中的llama-server参数）都出现在Schema中
+	checkSchemaFields(t, "model_config", resp.Data["model_config"], reflect.TypeOf(model.ModelConfig{}))
+	checkSchemaFields(t, "config", resp.Data["config"], reflect.TypeOf(config.Config{}))
+}
+
+// checkSchemaFields 检查结构体的每个json字段都出现在Schema的properties中，嵌套结构体递归检查
+func checkSchemaFields(t *testing.T, path string, schema map[string]interface{}, typ reflect.Type) {
+	t.Helper()
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		t.Errorf("%s: schema has no properties", path)
+		return
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if name == "" && field.Anonymous && fieldType.Kind() == reflect.Struct {
+			checkSchemaFields(t, path, schema, fieldType)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fieldSchema, ok := properties[name].(map[string]interface{})
+		if !ok {
+			t.Errorf("%s.%s missing from schema", path, name)
+			continue
+		}
+		if _, nested := fieldSchema["properties"]; nested && fieldType.Kind() == reflect.Struct {
+			checkSchemaFields(t, path+"."+name, fieldSchema, fieldType)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+
+	"llama-switch/internal/model"
+)
+
+// GetDownloads 获取模型下载进度处理器
+func (h *Handler) GetDownloads(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		"Downloads retrieved successfully",
+		h.ModelService.Downloads().List(),
+		"",
+	))
+}
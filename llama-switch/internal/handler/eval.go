@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"llama-switch/internal/logging"
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+)
+
+// ListEvalSuites 获取准确性冒烟测试集列表处理器
+func (h *Handler) ListEvalSuites(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		"Eval suites retrieved successfully",
+		h.ModelService.Evals().List(),
+		"",
+	))
+}
+
+// SetEvalSuite 添加或替换准确性冒烟测试集处理器
+func (h *Handler) SetEvalSuite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var suite model.EvalSuite
+	if err := json.NewDecoder(r.Body).Decode(&suite); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.ModelService.Evals().Set(&suite); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to set eval suite", "suite", suite.Name, "error", err)
+		h.respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Eval suite '%s' set with %d cases", suite.Name, len(suite.Cases)),
+		&suite,
+		"",
+	))
+}
+
+// RemoveEvalSuite 删除准确性冒烟测试集处理器
+func (h *Handler) RemoveEvalSuite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.ModelService.Evals().Remove(req.Name); err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Eval suite '%s' removed", req.Name),
+		nil,
+		"",
+	))
+}
+
+// RunEvalSuite 对运行中的模型运行准确性冒烟测试集处理器，等待所有用例完成后返回结果
+func (h *Handler) RunEvalSuite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req model.EvalRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Suite == "" || req.Model == "" {
+		h.respondWithError(w, http.StatusBadRequest, "suite and model are required")
+		return
+	}
+
+	run, err := h.ModelService.Evals().Run(r.Context(), req.Suite, req.Model, service.EvalTriggerManual)
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		run.Failed == 0,
+		fmt.Sprintf("Eval suite '%s' on model '%s': %d passed, %d failed", run.Suite, run.ModelName, run.Passed, run.Failed),
+		run,
+		"",
+	))
+}
+
+// GetEvalRuns 获取最近的准确性冒烟测试结果处理器，可按测试集和模型过滤
+func (h *Handler) GetEvalRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	runs := h.ModelService.Evals().Runs(query.Get("suite"), query.Get("model"))
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Retrieved %d eval runs", len(runs)),
+		runs,
+		"",
+	))
+}
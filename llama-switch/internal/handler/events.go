@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+)
+
+// GetEvents 获取最近的运行事件处理器，可按model_name过滤，limit限制返回条数
+// 请求头Accept为text/event-stream时改为以SSE流实时推送事件总线上的系统事件
+func (h *Handler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		h.streamEvents(w, r)
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit value: %s", value))
+			return
+		}
+		limit = n
+	}
+
+	events := h.ModelService.Events().Recent(r.URL.Query().Get("model_name"), limit)
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Retrieved %d events", len(events)),
+		events,
+		"",
+	))
+}
+
+// streamEvents 以SSE流推送系统事件，types按事件类型过滤（逗号分隔）
+// 客户端重连时携带Last-Event-ID请求头，先补发断开期间仍保留的事件
+func (h *Handler) streamEvents(w http.ResponseWriter, r *http.Request) {
+	var types []string
+	if value := r.URL.Query().Get("types"); value != "" {
+		for _, t := range strings.Split(value, ",") {
+			t = strings.TrimSpace(t)
+			if !slices.Contains(service.WebhookEvents, t) {
+				h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("unknown event type: %s (expected one of %s)", t, strings.Join(service.WebhookEvents, ", ")))
+				return
+			}
+			types = append(types, t)
+		}
+	}
+	var lastID int64
+	if value := r.Header.Get("Last-Event-ID"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id < 0 {
+			h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid Last-Event-ID: %s", value))
+			return
+		}
+		lastID = id
+	}
+
+	missed, events, unsubscribe := h.ModelService.EventBus().Subscribe(lastID)
+	defer unsubscribe()
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(e model.SystemEvent) {
+		if len(types) > 0 && !slices.Contains(types, e.Event) {
+			return
+		}
+		data, _ := json.Marshal(e)
+		fmt.Fprintf(w, "id: %d\ndata: %s\n\n", e.ID, data)
+	}
+	for _, e := range missed {
+		send(e)
+	}
+	// 先发送注释行，让客户端尽快确认连接已建立
+	fmt.Fprint(w, ": connected\n\n")
+	controller.Flush()
+
+	keepAlive := time.NewTicker(logStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.closing:
+			return
+		case e := <-events:
+			send(e)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"llama-switch/internal/model"
+)
+
+// GetGPUInventory 获取GPU清单处理器：每个GPU的型号、显存、温度、使用率和使用该GPU的受管模型
+func (h *Handler) GetGPUInventory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	inventory, err := h.ModelService.GPUInventory()
+	if err != nil {
+		h.respondWithError(w, http.StatusServiceUnavailable, fmt.Sprintf("Failed to query GPUs: %v", err))
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Retrieved %d GPUs", len(inventory.GPUs)),
+		inventory,
+		"",
+	))
+}
@@ -1,41 +1,90 @@
 package handler
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"llama-switch/internal/apierror"
 	"llama-switch/internal/config"
+	"llama-switch/internal/idempotency"
+	"llama-switch/internal/ipfilter"
+	"llama-switch/internal/listing"
+	"llama-switch/internal/logging"
 	"llama-switch/internal/model"
+	"llama-switch/internal/ratelimit"
 	"llama-switch/internal/service"
+	"llama-switch/internal/units"
 )
 
 // Handler HTTP处理器
 type Handler struct {
 	ModelService     *service.ModelService
 	BenchmarkService *service.BenchmarkService
+	config           *config.Config
+
+	limiter       *ratelimit.Limiter // 管理API的请求频率限制，nil表示不限制
+	switchLimiter *ratelimit.Limiter // 切换请求单独的频率限制，nil表示与其他请求一起计数
+	idempotency   *idempotency.Store // 切换和停止请求的幂等键缓存，nil表示不接受幂等键
+	ipFilter      *ipfilter.Filter   // 管理API的客户端地址过滤，nil表示不限制
+
+	closing     chan struct{} // 关闭时关闭，结束实时日志流等长连接
+	closingOnce sync.Once
 }
 
 // NewHandler 创建新的HTTP处理器
 func NewHandler(cfg *config.Config) *Handler {
-	return NewHandlerWithService(cfg,
-		service.NewModelService(cfg, true), // 默认启用自动恢复
-		service.NewBenchmarkService(cfg))
+	modelService := service.NewModelService(cfg, true) // 默认启用自动恢复
+	return NewHandlerWithService(cfg, modelService, service.NewBenchmarkService(cfg, modelService))
 }
 
 // NewHandlerWithService 创建带有自定义服务的HTTP处理器
 func NewHandlerWithService(cfg *config.Config, modelService *service.ModelService, benchmarkService *service.BenchmarkService) *Handler {
 	// 启动时恢复之前运行的模型
 	if err := modelService.RestoreModels(); err != nil {
-		log.Printf("Warning: Failed to restore models: %v", err)
+		slog.Warn("Failed to restore models", "error", err)
 	}
 
+	// 网段已在加载配置时验证
+	filter, _ := ipfilter.New(cfg.Security.AllowedCIDRs, cfg.Security.DeniedCIDRs)
+
 	return &Handler{
 		ModelService:     modelService,
 		BenchmarkService: benchmarkService,
+		config:           cfg,
+		limiter:          ratelimit.New(cfg.RateLimit.PerMinute, cfg.RateLimit.Burst),
+		switchLimiter:    ratelimit.New(cfg.RateLimit.SwitchPerMinute, cfg.RateLimit.SwitchBurst),
+		idempotency:      idempotency.New(time.Duration(cfg.Idempotency.TTL)*time.Second, cfg.Idempotency.MaxKeys),
+		ipFilter:         filter,
+		closing:          make(chan struct{}),
+	}
+}
+
+// CloseStreams 结束所有实时日志流和事件流。服务器关闭时调用，否则这些长连接会一直占用到排空超时
+func (h *Handler) CloseStreams() {
+	h.closingOnce.Do(func() { close(h.closing) })
+}
+
+// specifiedConfigFields 获取切换请求config中显式指定的字段
+func specifiedConfigFields(body []byte) map[string]bool {
+	var req struct {
+		Config map[string]json.RawMessage `json:"config"`
 	}
+	json.Unmarshal(body, &req)
+	fields := make(map[string]bool, len(req.Config))
+	for field := range req.Config {
+		fields[field] = true
+	}
+	return fields
 }
 
 // SwitchModel 切换模型处理器
@@ -45,8 +94,13 @@ func (h *Handler) SwitchModel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
 	var cfg model.ModelConfig
-	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+	if err := json.Unmarshal(body, &cfg); err != nil {
 		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
@@ -57,61 +111,165 @@ func (h *Handler) SwitchModel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 验证ForceVRAM参数
-	if cfg.ForceVRAM && cfg.Config.NGPULayers <= 0 {
-		h.respondWithError(w, http.StatusBadRequest,
-			"ForceVRAM requires NGPULayers > 0")
+	// 只指定了模型名称时使用模型定义目录（models.d）中的同名定义
+	specified := specifiedConfigFields(body)
+	if cfg.ModelPath == "" {
+		if def, defSpecified, exists := h.ModelService.Definitions().Get(cfg.ModelName); exists {
+			cfg, specified = *def, defSpecified
+		}
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	async, _ := strconv.ParseBool(r.URL.Query().Get("async"))
+	if async && dryRun {
+		h.respondWithError(w, http.StatusBadRequest, "async and dry_run cannot be combined")
 		return
 	}
 
-	if err := h.ModelService.ValidateModelConfig(&cfg); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, err.Error())
+	// 异步切换在校验通过后立即返回操作，通过/api/v1/operations/{id}查询加载进度
+	if async {
+		op, problem := h.switchModelAsync(r, &cfg, specified)
+		if problem != nil {
+			apierror.Write(w, problem)
+			return
+		}
+		w.Header().Set("Location", "/api/v1/operations/"+op.ID)
+		h.respondWithJSON(w, http.StatusAccepted, model.NewAPIResponse(
+			true,
+			fmt.Sprintf("Switching to model '%s' in the background", cfg.ModelName),
+			op,
+			"",
+		))
 		return
 	}
 
-	// 记录请求日志和当前运行模型
-	currentModels := h.ModelService.GetModelStatus("")
-	log.Printf("Current running models (%d):", len(currentModels))
-	for i, m := range currentModels {
-		log.Printf("  [%d] %s (PID: %d, VRAM: %dMB)", i+1, m.ModelName, m.ProcessID, m.VRAMUsage)
+	result, problem := h.switchModel(r, &cfg, specified, dryRun)
+	if problem != nil {
+		apierror.Write(w, problem)
+		return
 	}
-	log.Printf("Starting model switch: %s (%s)", cfg.ModelName, cfg.ModelPath)
 
-	if _, err := h.ModelService.StartModel(&cfg); err != nil {
-		log.Printf("Failed to start model %s: %v", cfg.ModelName, err)
-		h.respondWithError(w, http.StatusInternalServerError,
-			fmt.Sprintf("Failed to start model: %v", err))
+	// dry run只返回将使用的启动命令，不启动进程
+	if dryRun {
+		h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+			true,
+			fmt.Sprintf("Dry run for model '%s', nothing was started", cfg.ModelName),
+			result.preview,
+			"",
+		))
 		return
 	}
 
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Model '%s' switched successfully", cfg.ModelName),
+		map[string]interface{}{
+			"model":     result.status,
+			"load_time": result.loadTime.String(),
+		},
+		"",
+	))
+}
+
+// switchResult 模型切换的结果
+type switchResult struct {
+	status   *model.ModelStatus    // 启动后的模型状态
+	preview  *model.CommandPreview // dry run时将使用的启动命令
+	loadTime time.Duration         // 启动耗时
+}
+
+// switchModel 填充默认参数、校验并启动模型，v1和v2的切换接口共用，失败时返回错误响应
+// specified为请求中显式指定的启动参数，dryRun时只生成启动命令预览
+func (h *Handler) switchModel(r *http.Request, cfg *model.ModelConfig, specified map[string]bool, dryRun bool) (*switchResult, *model.Problem) {
+	if problem := h.prepareSwitch(r, cfg, specified); problem != nil {
+		return nil, problem
+	}
+	if dryRun {
+		preview, err := h.ModelService.PreviewModel(cfg)
+		if err != nil {
+			return nil, apierror.FromError(err, http.StatusBadRequest)
+		}
+		return &switchResult{preview: preview}, nil
+	}
+	return h.runSwitch(logging.FromContext(r.Context()), cfg)
+}
+
+// switchModelAsync 校验切换请求后在后台启动模型，返回跟踪加载进度的操作；校验失败时直接返回错误响应
+func (h *Handler) switchModelAsync(r *http.Request, cfg *model.ModelConfig, specified map[string]bool) (*model.Operation, *model.Problem) {
+	if problem := h.prepareSwitch(r, cfg, specified); problem != nil {
+		return nil, problem
+	}
+	logger := logging.FromContext(r.Context())
+	return h.ModelService.Operations().Switch(cfg.ModelName, func() (*model.ModelStatus, time.Duration, *model.Problem) {
+		result, problem := h.runSwitch(logger, cfg)
+		if problem != nil {
+			return nil, 0, problem
+		}
+		return result.status, result.loadTime, nil
+	}), nil
+}
+
+// prepareSwitch 填充默认参数、确定租户并校验切换请求
+func (h *Handler) prepareSwitch(r *http.Request, cfg *model.ModelConfig, specified map[string]bool) *model.Problem {
+	// 未指定的启动参数使用全局默认配置，填充后的配置随模型一起持久化
+	h.ModelService.ApplyModelDefaults(cfg, specified)
+
+	// 配置了租户时由API密钥确定模型所属的租户，忽略请求体中的tenant
+	tenant, err := h.ModelService.TenantForKey(apiKeyFromRequest(r))
+	if err != nil {
+		return apierror.FromError(err, http.StatusUnauthorized)
+	}
+	cfg.Tenant = tenant
+
+	// 验证ForceVRAM参数
+	if cfg.ForceVRAM && cfg.Config.NGPULayers <= 0 {
+		return apierror.NewProblem(apierror.CodeInvalidRequest, 0, "ForceVRAM requires NGPULayers > 0", nil)
+	}
+
+	if err := h.ModelService.ValidateModelConfig(cfg); err != nil {
+		return apierror.FromError(err, http.StatusBadRequest)
+	}
+	return nil
+}
+
+// runSwitch 启动已校验的模型并等待就绪，logger带有发起切换的请求ID
+func (h *Handler) runSwitch(logger *slog.Logger, cfg *model.ModelConfig) (*switchResult, *model.Problem) {
+	// 记录请求日志和当前运行模型
+	logger = logger.With("model_name", cfg.ModelName)
+	logger.Debug("Current running models", "models", describeModels(h.ModelService.GetModelStatus("")))
+	logger.Info("Starting model switch", "model_path", cfg.ModelPath)
+
+	startTime := time.Now()
+	if _, err := h.ModelService.StartModel(cfg); err != nil {
+		logger.Error("Failed to start model", "error", err)
+		var startupErr *service.ModelStartupError
+		if errors.As(err, &startupErr) {
+			return nil, startupProblem(startupErr)
+		}
+		return nil, apierror.FromError(fmt.Errorf("Failed to start model: %w", err), http.StatusInternalServerError)
+	}
+
 	// 获取并验证模型状态
 	statuses := h.ModelService.GetModelStatus(cfg.ModelName)
 	if len(statuses) == 0 {
 		errMsg := fmt.Sprintf("Model %s failed to start (no status available)", cfg.ModelName)
-		log.Println(errMsg)
-		h.respondWithError(w, http.StatusInternalServerError, errMsg)
-		return
+		logger.Error("Model failed to start, no status available")
+		return nil, apierror.NewProblem(apierror.CodeInternal, 0, errMsg, nil)
 	}
 
 	// 确保模型已正确加载
 	if !statuses[0].Running {
 		errMsg := fmt.Sprintf("Model %s is not running after start", cfg.ModelName)
-		log.Println(errMsg)
-		h.respondWithError(w, http.StatusInternalServerError, errMsg)
-		return
+		logger.Error("Model is not running after start")
+		return nil, apierror.NewProblem(apierror.CodeInternal, 0, errMsg, nil)
 	}
 
-	log.Printf("Model %s started successfully (PID: %d)", cfg.ModelName, statuses[0].ProcessID)
+	logger.Info("Model started successfully", "pid", statuses[0].ProcessID)
 
-	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
-		true,
-		fmt.Sprintf("Model '%s' switched successfully", cfg.ModelName),
-		map[string]interface{}{
-			"model":     statuses[0],
-			"load_time": time.Since(time.Now()).String(),
-		},
-		"",
-	))
+	// 在后台运行适用于该模型的准确性冒烟测试集，失败时记录eval_failed事件
+	h.ModelService.Evals().RunAfterSwitch(cfg.ModelName)
+
+	return &switchResult{status: statuses[0], loadTime: time.Since(startTime)}, nil
 }
 
 // StopModel 停止模型处理器
@@ -133,78 +291,86 @@ func (h *Handler) StopModel(w http.ResponseWriter, r *http.Request) {
 		h.respondWithError(w, http.StatusBadRequest, "Model name is required")
 		return
 	}
+	if req.DrainTimeout < 0 {
+		h.respondWithError(w, http.StatusBadRequest, "drain_timeout must not be negative")
+		return
+	}
 
-	// 获取当前模型状态
-	var targetStatus *model.ModelStatus
-	if modelName != "" {
-		// 检查指定模型是否存在
-		statuses := h.ModelService.GetModelStatus(modelName)
-		if len(statuses) == 0 {
-			h.respondWithError(w, http.StatusNotFound,
-				fmt.Sprintf("Model '%s' not found or not running", modelName))
-			return
-		}
-		targetStatus = statuses[0]
+	status, vramFreed, problem := h.stopModel(logging.FromContext(r.Context()), req)
+	if problem != nil {
+		apierror.Write(w, problem)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Model '%s' stopped successfully", modelName),
+		map[string]interface{}{
+			"stopped_model": status,
+			"stop_time":     time.Now().Format(time.RFC3339),
+			"vram_freed":    vramFreed,
+		},
+		"",
+	))
+}
+
+// stopModel 停止模型，v1和v2的停止接口共用，返回停止的模型状态和释放的显存(MB)，失败时返回错误响应
+func (h *Handler) stopModel(logger *slog.Logger, req model.ModelStopRequest) (*model.ModelStatus, int, *model.Problem) {
+	modelName := req.ModelName
+	logger = logger.With("model_name", modelName)
+
+	// 检查指定模型是否存在
+	statuses := h.ModelService.GetModelStatus(modelName)
+	if len(statuses) == 0 {
+		return nil, 0, apierror.NewProblem(apierror.CodeModelNotFound, 0,
+			fmt.Sprintf("Model '%s' not found or not running", modelName), nil)
 	}
+	targetStatus := statuses[0]
 
-	// 获取并记录当前所有运行模型
-	currentModels := h.ModelService.GetModelStatus("")
-	log.Printf("Current running models before stopping (%d):", len(currentModels))
-	for i, m := range currentModels {
-		log.Printf("  [%d] Model: %s", i+1, m.ModelName)
-		log.Printf("     PID: %d", m.ProcessID)
-		log.Printf("     VRAM: %dMB", m.VRAMUsage)
-		log.Printf("     StartTime: %s", m.StartTime)
-		log.Printf("     Port: %d", m.Port)
+	// 切换保护：拒绝停止正在使用的模型，除非指定force或drain
+	if !req.Force && !req.Drain {
+		var busyErr *service.ModelBusyError
+		if err := h.ModelService.CheckIdle(modelName); errors.As(err, &busyErr) {
+			logger.Warn("Refusing to stop model", "reason", err)
+			return nil, 0, busyProblem(busyErr)
+		}
 	}
-	log.Printf("Stopping model: %s", modelName)
+
+	// 记录当前所有运行模型
+	logger.Debug("Current running models before stopping", "models", describeModels(h.ModelService.GetModelStatus("")))
+	logger.Info("Stopping model")
 
 	var err error
 	var status *model.ModelStatus
-	// 按名称停止特定模型
-	status, err = h.ModelService.StopModel(modelName)
+	if req.Drain && !req.Force {
+		// 等待进行中的请求完成后停止
+		timeout := time.Duration(h.config.SwitchGuard.DrainTimeout) * time.Second
+		if req.DrainTimeout > 0 {
+			timeout = time.Duration(req.DrainTimeout) * time.Second
+		}
+		status, err = h.ModelService.DrainAndStopModel(modelName, timeout)
+	} else {
+		// 按名称停止特定模型
+		status, err = h.ModelService.StopModel(modelName)
+	}
 
 	if err != nil {
-		log.Printf("Failed to stop model %s: %v", modelName, err)
-		h.respondWithError(w, http.StatusInternalServerError, err.Error())
-		return
+		logger.Error("Failed to stop model", "error", err)
+		return nil, 0, apierror.FromError(err, http.StatusInternalServerError)
 	}
 
 	// 验证模型是否真的已停止
 	time.Sleep(100 * time.Millisecond) // 给进程一点时间完全退出
-	statuses := h.ModelService.GetModelStatus(modelName)
+	statuses = h.ModelService.GetModelStatus(modelName)
 	if len(statuses) > 0 && statuses[0].Running {
 		errMsg := fmt.Sprintf("Model '%s' is still running after stop request", modelName)
-		log.Println(errMsg)
-		h.respondWithError(w, http.StatusInternalServerError, errMsg)
-		return
-	}
-
-	msg := "Model stopped successfully"
-	if modelName != "" {
-		msg = fmt.Sprintf("Model '%s' stopped successfully", modelName)
+		logger.Error("Model is still running after stop request")
+		return nil, 0, apierror.NewProblem(apierror.CodeInternal, 0, errMsg, nil)
 	}
 
 	// 记录成功日志
-	log.Printf("Successfully stopped model: %s", modelName)
-
-	// 构建响应数据
-	responseData := map[string]interface{}{
-		"stopped_model": status,
-		"stop_time":     time.Now().Format(time.RFC3339),
-	}
-
-	// 只有在有目标状态时才添加显存信息
-	if targetStatus != nil {
-		responseData["vram_freed"] = targetStatus.VRAMUsage
-	}
-
-	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
-		true,
-		msg,
-		responseData,
-		"",
-	))
+	logger.Info("Model stopped successfully")
+	return status, targetStatus.VRAMUsage, nil
 }
 
 // GetModelStatus 获取模型状态处理器
@@ -215,32 +381,25 @@ func (h *Handler) GetModelStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 解析查询参数
-	modelName := r.URL.Query().Get("model_name")
-
-	// 获取并记录当前所有运行模型
-	currentModels := h.ModelService.GetModelStatus("")
-	log.Printf("Current running models (%d):", len(currentModels))
-	for i, m := range currentModels {
-		log.Printf("  [%d] Model: %s", i+1, m.ModelName)
-		log.Printf("     PID: %d", m.ProcessID)
-		log.Printf("     VRAM: %dMB", m.VRAMUsage)
-		log.Printf("     StartTime: %s", m.StartTime)
-		log.Printf("     Port: %d", m.Port)
-	}
-
+	query := r.URL.Query()
+	modelName := query.Get("model_name")
+	// 使用过滤、排序或分页参数时总是返回列表
+	listMode := modelName == "" && (hasListParams(query) || query.Has("running") || query.Has("name"))
+
+	// 记录当前所有运行模型
+	logger := logging.FromContext(r.Context())
+	logger.Debug("Current running models", "models", describeModels(h.ModelService.GetModelStatus("")))
 	if modelName != "" {
-		log.Printf("Requesting status for specific model: %s", modelName)
-	} else {
-		log.Printf("Requesting status for all models")
+		logger = logger.With("model_name", modelName)
 	}
 
 	// 获取模型状态
 	statuses := h.ModelService.GetModelStatus(modelName)
-	if len(statuses) == 0 {
+	if len(statuses) == 0 && !listMode {
 		if modelName != "" {
 			msg := fmt.Sprintf("Model '%s' not found", modelName)
-			log.Println(msg)
-			h.respondWithError(w, http.StatusNotFound, msg)
+			logger.Info("Model not found")
+			apierror.Write(w, apierror.NewProblem(apierror.CodeModelNotFound, 0, msg, nil))
 			return
 		}
 		h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
@@ -252,37 +411,36 @@ func (h *Handler) GetModelStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 查询所有模型时按运行状态和名称过滤，并排序分页
+	if modelName == "" {
+		var ok bool
+		if statuses, _, ok = h.listModelStatuses(w, r, statuses); !ok {
+			return
+		}
+	}
+
 	// 收集性能指标
 	responseData := make([]map[string]interface{}, 0, len(statuses))
 	for _, status := range statuses {
-		// TODO: 实现真实的进程资源使用统计
-		// 这里使用模拟数据作为示例
-		cpuUsage := "15%"   // 模拟CPU使用率
-		memUsage := "256MB" // 模拟内存使用
-
 		modelInfo := map[string]interface{}{
-			"model": status,
-			"performance": map[string]string{
-				"cpu_usage":    cpuUsage,
-				"memory_usage": memUsage,
-				"vram_usage":   fmt.Sprintf("%dMB", status.VRAMUsage),
-				"uptime":       time.Since(time.Now()).String(),
-			},
+			"model":       status,
+			"performance": h.modelPerformance(status),
 			"timestamps": map[string]string{
 				"start_time":  status.StartTime,
 				"last_update": time.Now().Format(time.RFC3339),
 			},
+			"requests": h.ModelService.Tracker().Stats(status.ModelName),
 		}
 		responseData = append(responseData, modelInfo)
 	}
 
 	// 如果是单个模型查询，直接返回单个对象
-	var data interface{} = responseData[0]
-	if modelName == "" && len(responseData) > 1 {
-		data = responseData
+	var data interface{} = responseData
+	if modelName != "" || (len(responseData) == 1 && !listMode) {
+		data = responseData[0]
 	}
 
-	log.Printf("Returning status for %d models", len(statuses))
+	logger.Debug("Returning model status", "count", len(statuses))
 	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
 		true,
 		"Model status retrieved successfully",
@@ -291,6 +449,25 @@ func (h *Handler) GetModelStatus(w http.ResponseWriter, r *http.Request) {
 	))
 }
 
+// modelPerformance 汇总模型进程的资源使用：CPU和内存取自后台资源采样器的最近一次采样，
+// 尚未采样或采样已禁用时为n/a；运行时间从启动时间算起
+func (h *Handler) modelPerformance(status *model.ModelStatus) map[string]string {
+	performance := map[string]string{
+		"cpu_usage":    "n/a",
+		"memory_usage": "n/a",
+		"vram_usage":   fmt.Sprintf("%dMB", status.VRAMUsage),
+		"uptime":       "n/a",
+	}
+	if sample := h.ModelService.Resources().Latest(status.ModelName); sample != nil {
+		performance["cpu_usage"] = fmt.Sprintf("%.1f%%", sample.CPUPercent)
+		performance["memory_usage"] = fmt.Sprintf("%dMB", sample.RSSMB)
+	}
+	if started, err := time.Parse(time.RFC3339, status.StartTime); err == nil && status.Running {
+		performance["uptime"] = time.Since(started).Truncate(time.Second).String()
+	}
+	return performance
+}
+
 // StartBenchmark 启动基准测试处理器
 func (h *Handler) StartBenchmark(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -304,21 +481,75 @@ func (h *Handler) StartBenchmark(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.BenchmarkService.ValidateBenchmarkConfig(&cfg); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, err.Error())
+	taskID, problem := h.startBenchmark(&cfg)
+	if problem != nil {
+		apierror.Write(w, problem)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		"Benchmark started successfully",
+		map[string]string{"task_id": taskID},
+		"",
+	))
+}
+
+// startBenchmark 校验并提交基准测试任务，v1和v2共用，返回任务ID，失败时返回错误响应
+func (h *Handler) startBenchmark(cfg *model.BenchmarkConfig) (string, *model.Problem) {
+	if err := h.BenchmarkService.ValidateBenchmarkConfig(cfg); err != nil {
+		return "", apierror.FromError(err, http.StatusBadRequest)
+	}
+
+	taskID, err := h.BenchmarkService.StartBenchmark(cfg)
+	var conflictErr *service.BenchmarkConflictError
+	if errors.As(err, &conflictErr) {
+		return "", benchmarkConflictProblem(conflictErr)
+	}
+	if err != nil {
+		return "", apierror.FromError(err, http.StatusInternalServerError)
+	}
+	return taskID, nil
+}
+
+// ReproduceBenchmark 以原任务完全相同的配置重新运行基准测试处理器
+func (h *Handler) ReproduceBenchmark(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req model.BenchmarkReproduceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.TaskID == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Task ID is required")
+		return
+	}
+
+	original, err := h.BenchmarkService.LoadManifest(req.TaskID)
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
 		return
 	}
 
-	taskID, err := h.BenchmarkService.StartBenchmark(&cfg)
+	taskID, differences, err := h.BenchmarkService.Reproduce(req.TaskID)
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		h.respondWithServiceError(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
 		true,
-		"Benchmark started successfully",
-		map[string]string{"task_id": taskID},
+		"Benchmark reproduction started successfully",
+		map[string]interface{}{
+			"task_id":          taskID,
+			"reproduced_from":  req.TaskID,
+			"differences":      differences,
+			"original_results": original.Results,
+		},
 		"",
 	))
 }
@@ -338,7 +569,15 @@ func (h *Handler) GetBenchmarkStatus(w http.ResponseWriter, r *http.Request) {
 
 	status, err := h.BenchmarkService.GetStatus(taskID)
 	if err != nil {
-		h.respondWithError(w, http.StatusNotFound, err.Error())
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+	switch r.URL.Query().Get("results") {
+	case "", "flat":
+	case "grouped":
+		status = service.GroupedStatus(status)
+	default:
+		h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid results value: %s (expected flat or grouped)", r.URL.Query().Get("results")))
 		return
 	}
 
@@ -350,16 +589,157 @@ func (h *Handler) GetBenchmarkStatus(w http.ResponseWriter, r *http.Request) {
 	))
 }
 
-// respondWithError 返回错误响应
-func (h *Handler) respondWithError(w http.ResponseWriter, code int, message string) {
-	h.respondWithJSON(w, code, model.NewAPIResponse(
-		false,
-		message,
+// DeleteBenchmark 从内存中删除已结束的基准测试任务处理器，排队或运行中的任务返回409
+func (h *Handler) DeleteBenchmark(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	taskID := r.PathValue("task_id")
+	err := h.BenchmarkService.DeleteTask(taskID)
+	if errors.Is(err, service.ErrBenchmarkActive) {
+		h.respondWithServiceError(w, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Benchmark task '%s' deleted", taskID),
 		nil,
-		message,
+		"",
+	))
+}
+
+// GetBenchmarkHistory 查询已结束基准测试历史记录处理器，支持按模型、时间范围和测试类型过滤
+func (h *Handler) GetBenchmarkHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := service.BenchmarkHistoryFilter{
+		Model:    query.Get("model"),
+		TestType: query.Get("test_type"),
+		GPU:      query.Get("gpu"),
+		Build:    query.Get("build"),
+		RPC:      query.Get("rpc"),
+		Status:   query.Get("status"),
+	}
+	var err error
+	if filter.Since, err = parseTimeParam(query.Get("since"), false); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid since value: %s", query.Get("since")))
+		return
+	}
+	if filter.Until, err = parseTimeParam(query.Get("until"), true); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid until value: %s", query.Get("until")))
+		return
+	}
+	params, ok := h.parseListParams(w, r, 0, "start_time", "model", "duration", "tokens_per_sec")
+	if !ok {
+		return
+	}
+
+	records, err := h.BenchmarkService.History().Query(filter)
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+	// 未指定排序时limit和offset从最新的记录算起
+	tokensPerSec := func(record *model.BenchmarkRecord) float64 {
+		best := 0.0
+		for _, result := range record.Results {
+			if strings.HasPrefix(result.TestType, filter.TestType) {
+				best = max(best, result.TokensPerSecond)
+			}
+		}
+		return best
+	}
+	page, total := listing.Apply(records, params, map[string]func(a, b *model.BenchmarkRecord) int{
+		"start_time": func(a, b *model.BenchmarkRecord) int { return strings.Compare(a.StartTime, b.StartTime) },
+		"model": func(a, b *model.BenchmarkRecord) int {
+			return strings.Compare(filepath.Base(a.ModelPath), filepath.Base(b.ModelPath))
+		},
+		"duration":       func(a, b *model.BenchmarkRecord) int { return cmp.Compare(a.DurationSec, b.DurationSec) },
+		"tokens_per_sec": func(a, b *model.BenchmarkRecord) int { return cmp.Compare(tokensPerSec(a), tokensPerSec(b)) },
+	}, true)
+	setTotalCount(w, total)
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Retrieved %d of %d benchmark records", len(page), total),
+		page,
+		"",
 	))
 }
 
+// parseTimeParam 解析RFC3339时间或YYYY-MM-DD日期参数，endOfDay时日期取当天结束时刻，空值返回零值
+func parseTimeParam(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// respondWithError 返回错误响应，错误码为HTTP状态码对应的通用错误码
+func (h *Handler) respondWithError(w http.ResponseWriter, status int, message string) {
+	apierror.Write(w, apierror.NewProblem(apierror.ForStatus(status), status, message, nil))
+}
+
+// respondWithServiceError 返回服务层错误的响应：错误带有错误码时使用错误码及其HTTP状态码，否则使用status
+func (h *Handler) respondWithServiceError(w http.ResponseWriter, status int, err error) {
+	apierror.Write(w, apierror.FromError(err, status))
+}
+
+// busyProblem 模型正在使用的错误响应，附带进行中的会话数
+func busyProblem(err *service.ModelBusyError) *model.Problem {
+	data := map[string]interface{}{
+		"model_name":      err.ModelName,
+		"active_sessions": err.ActiveSessions,
+	}
+	if !err.LastActive.IsZero() {
+		data["last_active"] = err.LastActive.Format(time.RFC3339)
+	}
+	return apierror.NewProblem(apierror.CodeModelBusy, 0, err.Error(), data)
+}
+
+// benchmarkConflictProblem 相同GPU上已有基准测试的错误响应，附带冲突的任务ID
+func benchmarkConflictProblem(err *service.BenchmarkConflictError) *model.Problem {
+	return apierror.NewProblem(apierror.CodeBenchmarkConflict, 0, err.Error(), map[string]interface{}{
+		"conflicting_task_id": err.TaskID,
+		"conflicting_status":  err.Status,
+		"gpus":                err.GPUs,
+	})
+}
+
+// startupProblem 模型启动失败的错误响应，附带失败原因和实例最近的输出
+func startupProblem(err *service.ModelStartupError) *model.Problem {
+	data := map[string]interface{}{
+		"model_name":      err.ModelName,
+		"reason":          err.Reason,
+		"elapsed_seconds": err.Elapsed.Seconds(),
+		"last_output":     err.LastOutput,
+	}
+	if err.ExitStatus != "" {
+		data["exit_status"] = err.ExitStatus
+	}
+	return apierror.NewProblem(apierror.CodeModelStartupFailed, 0, fmt.Sprintf("Failed to start model: %v", err), data)
+}
+
 // respondWithJSON 返回JSON响应
 func (h *Handler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, _ := json.Marshal(payload)
@@ -375,22 +755,63 @@ func (h *Handler) GetModelList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	params, ok := h.parseListParams(w, r, 0, "name", "size")
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	var minSize, maxSize int64
+	for name, target := range map[string]*int64{"min_size": &minSize, "max_size": &maxSize} {
+		if value := query.Get(name); value != "" {
+			mb, err := units.ParseSize(value, units.MB)
+			if err != nil || mb < 0 {
+				h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid %s value: %s (expected MB or a value like 8g)", name, value))
+				return
+			}
+			*target = mb * units.MB
+		}
+	}
+
 	// 获取模型列表
 	models, err := h.ModelService.GetModelList()
 	if err != nil {
-		log.Printf("Failed to get model list: %v", err)
+		logging.FromContext(r.Context()).Error("Failed to get model list", "error", err)
 		h.respondWithError(w, http.StatusInternalServerError,
 			fmt.Sprintf("Failed to get model list: %v", err))
 		return
 	}
 
 	// 记录找到的模型数量
-	log.Printf("Found %d GGUF models in models directory", len(models))
+	logging.FromContext(r.Context()).Debug("Found GGUF models in models directory", "count", len(models))
+
+	// 按名称（不区分大小写的子串）和文件大小过滤
+	nameFilter := strings.ToLower(query.Get("name"))
+	matched := make([]model.ModelInfo, 0, len(models))
+	for _, m := range models {
+		if (nameFilter == "" || strings.Contains(strings.ToLower(m.Name), nameFilter)) &&
+			(minSize == 0 || m.Size >= minSize) && (maxSize == 0 || m.Size <= maxSize) {
+			matched = append(matched, m)
+		}
+	}
+	page, total := listing.Apply(matched, params, map[string]func(a, b model.ModelInfo) int{
+		"name": func(a, b model.ModelInfo) int { return strings.Compare(a.Name, b.Name) },
+		"size": func(a, b model.ModelInfo) int { return cmp.Compare(a.Size, b.Size) },
+	}, false)
+	setTotalCount(w, total)
 
 	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
 		true,
-		fmt.Sprintf("Found %d GGUF models", len(models)),
-		models,
+		fmt.Sprintf("Found %d GGUF models", total),
+		page,
 		"",
 	))
 }
+
+// describeModels 描述模型的名称、进程ID和显存占用，用于调试日志
+func describeModels(statuses []*model.ModelStatus) []string {
+	described := make([]string, len(statuses))
+	for i, m := range statuses {
+		described[i] = fmt.Sprintf("%s (PID: %d, VRAM: %dMB)", m.ModelName, m.ProcessID, m.VRAMUsage)
+	}
+	return described
+}
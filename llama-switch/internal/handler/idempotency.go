@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/idempotency"
+)
+
+// maxIdempotencyKeyLength 幂等键的最大长度
+const maxIdempotencyKeyLength = 255
+
+// idempotentHeaders 重放时恢复的响应头，其他响应头（RateLimit-*、Deprecation等）由外层中间件重新生成
+var idempotentHeaders = []string{"Content-Type", "Location"}
+
+// Idempotency 幂等键中间件：切换和停止请求带有Idempotency-Key头时，相同客户端以相同的键和请求内容重试
+// 返回第一次请求的响应（带Idempotent-Replayed: true头），不会重复启动模型或返回MODEL_ALREADY_RUNNING；
+// 第一次请求仍在执行时等待其结束。相同的键用于内容不同的请求时返回422。服务端错误（5xx）不缓存，重试时重新执行
+func (h *Handler) Idempotency(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || h.idempotency == nil || !isIdempotentRequest(r.Method, routePath(r)) {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			h.respondWithError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// 幂等键按客户端区分，请求内容包括方法、路径、查询参数和请求体
+		sum := sha256.Sum256([]byte(r.Method + " " + r.URL.RequestURI() + "\n" + string(body)))
+		storeKey := h.rateLimitClient(r) + " " + key
+		cached, owner, err := h.idempotency.Begin(r.Context(), storeKey, hex.EncodeToString(sum[:]))
+		if errors.Is(err, idempotency.ErrKeyReused) {
+			apierror.Write(w, apierror.NewProblem(apierror.CodeIdempotencyKeyReused, 0,
+				"Idempotency-Key "+key+" was already used for a different request", nil))
+			return
+		}
+		if err != nil {
+			// 客户端在等待第一次请求结束时断开
+			return
+		}
+		if !owner {
+			for _, name := range idempotentHeaders {
+				if value := cached.Header.Get(name); value != "" {
+					w.Header().Set(name, value)
+				}
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(cached.Status)
+			w.Write(cached.Body)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			if recorder.status >= http.StatusInternalServerError {
+				h.idempotency.Complete(storeKey, nil)
+				return
+			}
+			header := make(http.Header)
+			for _, name := range idempotentHeaders {
+				if value := w.Header().Get(name); value != "" {
+					header.Set(name, value)
+				}
+			}
+			h.idempotency.Complete(storeKey, &idempotency.Response{Status: recorder.status, Header: header, Body: recorder.body.Bytes()})
+		}()
+		next(recorder, r)
+	}
+}
+
+// isIdempotentRequest 是否为接受幂等键的请求：v1和v2的切换和停止模型
+func isIdempotentRequest(method, path string) bool {
+	return isSwitchRequest(method, path) ||
+		(method == http.MethodPost && path == "/api/v1/model/stop") ||
+		(method == http.MethodDelete && path == "/api/v2/models/{name}")
+}
+
+// responseRecorder 在写出响应的同时记录状态码和响应体
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
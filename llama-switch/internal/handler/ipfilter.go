@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"llama-switch/internal/apierror"
+)
+
+// IPFilter 管理API的客户端地址过滤中间件：配置了ADMIN_ALLOWED_CIDRS或ADMIN_DENIED_CIDRS时，
+// 不允许的客户端访问管理API、API文档和Prometheus指标返回403。推理代理、公开状态页和健康检查不受限制。
+// 只按连接的来源地址判断，不信任X-Forwarded-For等可伪造的请求头
+func (h *Handler) IPFilter(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.ipFilter == nil || !isManagementRoute(routePath(r)) {
+			next(w, r)
+			return
+		}
+		if ip := clientIP(r); !h.ipFilter.Allowed(ip) {
+			apierror.Write(w, apierror.NewProblem(apierror.CodeForbidden, 0,
+				"Client address "+ip+" is not allowed to access the management API", nil))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// isManagementRoute 是否为管理API路由（包括API文档页面和Prometheus指标）
+func isManagementRoute(path string) bool {
+	return strings.HasPrefix(path, "/api/") || path == "/docs" || path == "/metrics"
+}
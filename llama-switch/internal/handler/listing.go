@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"llama-switch/internal/listing"
+	"llama-switch/internal/model"
+)
+
+// parseListParams 解析列表接口的limit、offset和sort参数，参数无效时返回400
+func (h *Handler) parseListParams(w http.ResponseWriter, r *http.Request, defaultLimit int, sortKeys ...string) (listing.Params, bool) {
+	params, err := listing.Parse(r.URL.Query(), defaultLimit, sortKeys...)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, err.Error())
+		return params, false
+	}
+	return params, true
+}
+
+// setTotalCount 设置X-Total-Count响应头：过滤后、分页前的总条数
+func setTotalCount(w http.ResponseWriter, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+}
+
+// hasListParams 请求是否指定了分页或排序参数
+func hasListParams(query url.Values) bool {
+	return query.Has("limit") || query.Has("offset") || query.Has("sort")
+}
+
+// listModelStatuses 按running和name（不区分大小写的子串）过滤模型状态，并按name、start_time、vram或port
+// 排序分页，设置X-Total-Count并返回当前页和总数；参数无效时返回400
+func (h *Handler) listModelStatuses(w http.ResponseWriter, r *http.Request, statuses []*model.ModelStatus) ([]*model.ModelStatus, int, bool) {
+	query := r.URL.Query()
+	params, ok := h.parseListParams(w, r, 0, "name", "start_time", "vram", "port")
+	if !ok {
+		return nil, 0, false
+	}
+	var running *bool
+	if value := query.Get("running"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid running value: %s", value))
+			return nil, 0, false
+		}
+		running = &b
+	}
+
+	name := strings.ToLower(query.Get("name"))
+	matched := make([]*model.ModelStatus, 0, len(statuses))
+	for _, status := range statuses {
+		if (running == nil || status.Running == *running) && strings.Contains(strings.ToLower(status.ModelName), name) {
+			matched = append(matched, status)
+		}
+	}
+	page, total := listing.Apply(matched, params, map[string]func(a, b *model.ModelStatus) int{
+		"name":       func(a, b *model.ModelStatus) int { return strings.Compare(a.ModelName, b.ModelName) },
+		"start_time": func(a, b *model.ModelStatus) int { return strings.Compare(a.StartTime, b.StartTime) },
+		"vram":       func(a, b *model.ModelStatus) int { return cmp.Compare(a.VRAMUsage, b.VRAMUsage) },
+		"port":       func(a, b *model.ModelStatus) int { return cmp.Compare(a.Port, b.Port) },
+	}, false)
+	setTotalCount(w, total)
+	return page, total, true
+}
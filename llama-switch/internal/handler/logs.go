@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// 日志查看参数
+const (
+	defaultLogTail     = 100
+	maxLogTail         = 10000
+	logStreamKeepAlive = 15 * time.Second
+)
+
+// GetModelLogs 获取模型实例输出日志的最后N行处理器
+func (h *Handler) GetModelLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	name := r.PathValue("name")
+	tail, err := parseTail(r, defaultLogTail)
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	lines, err := h.ModelService.Logs().Tail(name, tail)
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Retrieved %d log lines for model '%s'", len(lines), name),
+		map[string]interface{}{
+			"model_name": name,
+			"lines":      lines,
+		},
+		"",
+	))
+}
+
+// StreamModelLogs 以SSE流实时推送模型实例输出日志处理器
+// 先发送最后tail行（默认0），之后推送新产生的日志行
+func (h *Handler) StreamModelLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	name := r.PathValue("name")
+	tail, err := parseTail(r, 0)
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// 先订阅再读取历史日志，避免遗漏两者之间产生的行
+	lines, unsubscribe := h.ModelService.Logs().Subscribe(name)
+	defer unsubscribe()
+
+	var history []string
+	if tail > 0 {
+		history, _ = h.ModelService.Logs().Tail(name, tail)
+	}
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	for _, line := range history {
+		fmt.Fprintf(w, "data: %s\n\n", line)
+	}
+	controller.Flush()
+
+	keepAlive := time.NewTicker(logStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.closing:
+			return
+		case line := <-lines:
+			fmt.Fprintf(w, "data: %s\n\n", line)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// parseTail 解析tail查询参数
+func parseTail(r *http.Request, defaultTail int) (int, error) {
+	value := r.URL.Query().Get("tail")
+	if value == "" {
+		return defaultTail, nil
+	}
+	tail, err := strconv.Atoi(value)
+	if err != nil || tail < 0 || tail > maxLogTail {
+		return 0, fmt.Errorf("invalid tail value: %s (should be between 0 and %d)", value, maxLogTail)
+	}
+	return tail, nil
+}
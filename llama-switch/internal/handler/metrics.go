@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsHandler 导出默认注册表中指标的处理器
+var metricsHandler = promhttp.Handler()
+
+// GetMetrics 以Prometheus文本格式导出switcher指标处理器
+func (h *Handler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	metricsHandler.ServeHTTP(w, r)
+}
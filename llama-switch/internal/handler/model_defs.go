@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"llama-switch/internal/model"
+)
+
+// GetModelDefinitions 获取模型定义目录中已加载的声明式模型定义处理器
+func (h *Handler) GetModelDefinitions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	list := h.ModelService.Definitions().List()
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Found %d model definitions", len(list.Definitions)),
+		list,
+		"",
+	))
+}
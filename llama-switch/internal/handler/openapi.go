@@ -0,0 +1,564 @@
+package handler
+
+import (
+	"cmp"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/auth"
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+)
+
+// apiOperation OpenAPI文档中的一个API操作，请求体和响应数据以Go类型的零值表示，由结构体的json标签生成Schema
+type apiOperation struct {
+	method   string
+	path     string
+	tag      string
+	summary  string
+	params   []apiParam
+	request  interface{}                   // 请求体，nil表示没有请求体
+	response interface{}                   // 成功响应中data字段的内容（v2为响应体本身），nil表示没有数据
+	status   int                           // 成功响应的状态码，0表示200
+	produces string                        // 成功响应不是JSON时的Content-Type（导出、输出、SSE流、HTML页面）
+	enabled  func(cfg *config.Config) bool // 路由按配置注册时的条件，nil表示总是注册
+}
+
+// apiParam 路径或查询参数
+type apiParam struct {
+	name        string
+	in          string // path、query或header
+	typ         string // string/integer/boolean
+	description string
+	enum        []string
+	required    bool
+}
+
+// schema 直接给出的JSON Schema，用于无法从Go类型推断的内容
+type schema map[string]interface{}
+
+// fields 处理器中临时组装的对象（map[string]interface{}），值为字段内容的Go类型零值或schema
+type fields map[string]interface{}
+
+// oneOf 多种可能结构之一，元素与fields的值相同
+type oneOf []interface{}
+
+// pathParam 路径参数
+func pathParam(name, description string) apiParam {
+	return apiParam{name: name, in: "path", typ: "string", description: description}
+}
+
+// requiredQueryParam 必填的字符串查询参数
+func requiredQueryParam(name, description string) apiParam {
+	return apiParam{name: name, in: "query", typ: "string", description: description, required: true}
+}
+
+// queryParam 可选的查询参数
+func queryParam(name, typ, description string, enum ...string) apiParam {
+	return apiParam{name: name, in: "query", typ: typ, description: description, enum: enum}
+}
+
+// listParams 列表接口的limit、offset和sort参数，sort的取值为排序键，加-前缀表示降序
+func listParams(limitDescription string, sortKeys ...string) []apiParam {
+	sortValues := make([]string, 0, 2*len(sortKeys))
+	for _, key := range sortKeys {
+		sortValues = append(sortValues, key, "-"+key)
+	}
+	return []apiParam{
+		queryParam("limit", "integer", limitDescription),
+		queryParam("offset", "integer", "Number of entries to skip; the total before paging is returned in X-Total-Count"),
+		queryParam("sort", "string", "Sort key, prefixed with - for descending order", sortValues...),
+	}
+}
+
+// 常用的请求和响应内容
+var (
+	idempotencyKey = apiParam{name: "Idempotency-Key", in: "header", typ: "string",
+		description: "Unique key for this request; retries with the same key replay the first response"}
+	taskIDData   = fields{"task_id": ""}
+	nameRequest  = fields{"name": ""}
+	stringSchema = schema{"type": "string"}
+	timeShareOn  = func(cfg *config.Config) bool { return cfg.TimeShare.Enabled }
+	statusPageOn = func(cfg *config.Config) bool { return cfg.StatusPage.Enabled }
+	metricsOn    = func(cfg *config.Config) bool { return cfg.Metrics.Enabled }
+)
+
+// modelStatusEntry GET /api/v1/model/status返回的单个模型状态
+var modelStatusEntry = fields{
+	"model":       model.ModelStatus{},
+	"performance": map[string]string{},
+	"timestamps":  map[string]string{},
+	"requests":    service.RequestStats{},
+}
+
+// apiOperations 管理API的全部操作，新增路由时同时在此登记
+var apiOperations = []apiOperation{
+	// 功能发现和管理
+	{method: "GET", path: "/api/v1/capabilities", tag: "config", summary: "List the optional features enabled in this deployment", response: model.Capabilities{}},
+	{method: "POST", path: "/api/v1/admin/reload", tag: "config", summary: "Reload the configuration file and environment variables", response: model.ConfigReload{}},
+	{method: "GET", path: "/api/v1/openapi.json", tag: "config", summary: "Get this OpenAPI document", produces: "application/json"},
+	{method: "GET", path: "/api/v1/config", tag: "config", summary: "Get the effective configuration with secrets redacted", response: config.Config{}},
+	{method: "GET", path: "/api/v1/config/env", tag: "config", summary: "List recognised environment variables and their current values", response: []model.EnvVarInfo{}},
+	{method: "GET", path: "/api/v1/config/schema", tag: "config", summary: "Get JSON Schemas for the configuration file and the switch request", response: fields{"config": schema{}, "model_config": schema{}}},
+	{method: "POST", path: "/api/v1/config/validate", tag: "config", summary: "Validate a YAML or JSON configuration file without applying it",
+		params: []apiParam{
+			queryParam("format", "string", "Body format, defaults to the Content-Type", "json", "yaml"),
+			queryParam("profile", "string", "Only validate the result of applying this profile"),
+		},
+		request: schema{"type": "object"}},
+	{method: "GET", path: "/api/v1/config/defaults", tag: "config", summary: "Get the default model settings and their changelog", response: model.ModelDefaults{}},
+	{method: "PATCH", path: "/api/v1/config/defaults", tag: "config", summary: "Change and persist default model settings", request: model.DefaultsUpdateRequest{}, response: model.DefaultsChange{}},
+
+	{method: "GET", path: "/api/v1/audit", tag: "config", summary: "Query the audit log of mutating API requests",
+		params: append([]apiParam{
+			queryParam("since", "string", "Only return entries at or after this RFC3339 time"),
+			queryParam("caller", "string", "Only return entries of this caller, e.g. key:ci or jwt:alice"),
+			queryParam("model_name", "string", "Only return entries for this model"),
+			queryParam("outcome", "string", "Only return entries with this outcome", "success", "denied", "failure"),
+		}, listParams("Maximum number of entries, default 100, 0 for all; without sort the most recent are returned",
+			"time", "caller", "status", "duration_ms")...),
+		response: []model.AuditEntry{}},
+
+	// 模型管理
+	{method: "GET", path: "/api/v1/models", tag: "models", summary: "List GGUF models in the models directory",
+		params: append([]apiParam{
+			queryParam("name", "string", "Only return models whose name contains this string (case-insensitive)"),
+			queryParam("min_size", "string", "Minimum file size, e.g. 4GB (MB when no unit)"),
+			queryParam("max_size", "string", "Maximum file size, e.g. 8GB (MB when no unit)"),
+		}, listParams("Maximum number of models, default all", "name", "size")...),
+		response: []model.ModelInfo{}},
+	{method: "POST", path: "/api/v1/model/switch", tag: "models", summary: "Start a model, or preview its command line with dry_run",
+		params: []apiParam{
+			queryParam("dry_run", "boolean", "Only return the command that would be run"),
+			queryParam("async", "boolean", "Return an operation with status 202 right away instead of waiting for the model to load"),
+			idempotencyKey,
+		},
+		request: model.ModelConfig{},
+		response: oneOf{
+			fields{"model": model.ModelStatus{}, "load_time": ""},
+			model.CommandPreview{},
+			model.Operation{},
+		}},
+	{method: "POST", path: "/api/v1/model/stop", tag: "models", summary: "Stop a model, or all models when model_name is empty",
+		params: []apiParam{idempotencyKey}, request: model.ModelStopRequest{},
+		response: fields{"stopped_model": model.ModelStatus{}, "stop_time": "", "vram_freed": 0}},
+	{method: "GET", path: "/api/v1/model/definitions", tag: "models", summary: "List model definitions loaded from the definitions directory", response: model.ModelDefinitionList{}},
+	{method: "GET", path: "/api/v1/model/status", tag: "models", summary: "Get the status of one or all running models",
+		params: append([]apiParam{
+			queryParam("model_name", "string", "Only return this model"),
+			queryParam("running", "boolean", "Only return running (true) or stopped (false) models"),
+			queryParam("name", "string", "Only return models whose name contains this string (case-insensitive); always returns an array"),
+		}, listParams("Maximum number of models, default all; always returns an array", "name", "start_time", "vram", "port")...),
+		response: oneOf{modelStatusEntry, []fields{modelStatusEntry}}},
+	{method: "GET", path: "/api/v1/model/{name}/logs", tag: "models", summary: "Get the last lines of a model instance's output",
+		params:   []apiParam{pathParam("name", "Model name"), queryParam("tail", "integer", "Number of lines, default 100")},
+		response: fields{"model_name": "", "lines": []string{}}},
+	{method: "GET", path: "/api/v1/model/{name}/logs/stream", tag: "models", summary: "Stream a model instance's output as server-sent events",
+		params:   []apiParam{pathParam("name", "Model name"), queryParam("tail", "integer", "Number of earlier lines to send first, default 0")},
+		produces: "text/event-stream"},
+	{method: "GET", path: "/api/v1/model/{name}/resources", tag: "models", summary: "Get recent CPU, memory and VRAM samples of a model process",
+		params:   []apiParam{pathParam("name", "Model name")},
+		response: fields{"model_name": "", "samples": []model.ResourceSample{}}},
+	{method: "GET", path: "/api/v1/model/{name}/vram/history", tag: "models", summary: "Get the persisted VRAM history and trend of a model",
+		params: []apiParam{
+			pathParam("name", "Model name"),
+			queryParam("since", "string", "Only return samples after this RFC3339 time"),
+			queryParam("limit", "integer", "Maximum number of samples"),
+		},
+		response: fields{"model_name": "", "samples": []model.VRAMSample{}, "trend": model.VRAMTrend{}}},
+	{method: "GET", path: "/api/v1/downloads", tag: "models", summary: "List model downloads", response: []model.DownloadStatus{}},
+	{method: "GET", path: "/api/v1/operations/{id}", tag: "models", summary: "Get the state and load progress of an async switch",
+		params: []apiParam{pathParam("id", "Operation ID")}, response: model.Operation{}},
+	{method: "GET", path: "/api/v1/events", tag: "models", summary: "List recent model lifecycle events, or stream system events as server-sent events with Accept: text/event-stream",
+		params: []apiParam{
+			queryParam("model_name", "string", "Only return events of this model"),
+			queryParam("limit", "integer", "Maximum number of events"),
+			queryParam("types", "string", "Stream only: comma-separated event types, e.g. model.started,model.crashed"),
+			{name: "Last-Event-ID", in: "header", typ: "integer", description: "Stream only: resend retained events after this ID on reconnect"},
+		},
+		response: []model.Event{}},
+	{method: "POST", path: "/api/v1/webhooks", tag: "models", summary: "Subscribe a URL to lifecycle events with HMAC-signed notifications",
+		request: model.WebhookRequest{}, response: model.WebhookSubscription{}},
+	{method: "GET", path: "/api/v1/webhooks", tag: "models", summary: "List webhook subscriptions and their delivery counters", response: []model.WebhookSubscription{}},
+	{method: "GET", path: "/api/v1/webhooks/{id}", tag: "models", summary: "Get a webhook subscription",
+		params: []apiParam{pathParam("id", "Webhook ID")}, response: model.WebhookSubscription{}},
+	{method: "DELETE", path: "/api/v1/webhooks/{id}", tag: "models", summary: "Delete a webhook subscription",
+		params: []apiParam{pathParam("id", "Webhook ID")}},
+	{method: "GET", path: "/api/v1/webhooks/{id}/deliveries", tag: "models", summary: "List recent deliveries of a webhook, newest first",
+		params: []apiParam{pathParam("id", "Webhook ID")}, response: []model.WebhookDelivery{}},
+	{method: "POST", path: "/api/v1/webhooks/{id}/test", tag: "models", summary: "Queue a webhook.test notification to a subscription",
+		params: []apiParam{pathParam("id", "Webhook ID")}, response: model.WebhookDelivery{}},
+	{method: "GET", path: "/api/v1/gpu", tag: "models", summary: "Get the GPU inventory", response: model.GPUInventory{}},
+	{method: "GET", path: "/api/v1/rpc/pools", tag: "models", summary: "List RPC pools and the health of their endpoints", response: []model.RPCPoolStatus{}},
+	{method: "GET", path: "/api/v1/tenants", tag: "models", summary: "List tenants and their quota usage", response: []model.TenantStatus{}},
+
+	// 基准测试
+	{method: "POST", path: "/api/v1/benchmark", tag: "benchmark", summary: "Start a llama-bench run", request: model.BenchmarkConfig{}, response: taskIDData},
+	{method: "POST", path: "/api/v1/benchmark/serving", tag: "benchmark", summary: "Start a serving benchmark against llama-server", request: model.ServingBenchmarkConfig{}, response: taskIDData},
+	{method: "GET", path: "/api/v1/benchmark/status", tag: "benchmark", summary: "Get the status and results of a benchmark task",
+		params: []apiParam{
+			requiredQueryParam("task_id", "Task ID"),
+			queryParam("results", "string", "Result layout", "flat", "grouped"),
+		},
+		response: model.BenchmarkStatus{}},
+	{method: "POST", path: "/api/v1/benchmark/reproduce", tag: "benchmark", summary: "Re-run a benchmark with the exact configuration of an earlier task", request: model.BenchmarkReproduceRequest{},
+		response: fields{"task_id": "", "reproduced_from": "", "differences": []string{}, "original_results": []model.BenchmarkResults{}}},
+	{method: "GET", path: "/api/v1/benchmark/history", tag: "benchmark", summary: "Query finished benchmark records",
+		params: append([]apiParam{
+			queryParam("model", "string", "Model path or name"),
+			queryParam("test_type", "string", "Test type"),
+			queryParam("gpu", "string", "GPU name"),
+			queryParam("build", "string", "llama.cpp build"),
+			queryParam("rpc", "string", "RPC pool"),
+			queryParam("since", "string", "RFC3339 time or YYYY-MM-DD date"),
+			queryParam("until", "string", "RFC3339 time or YYYY-MM-DD date"),
+			queryParam("status", "string", "Task status", "completed", "failed", "cancelled"),
+		}, listParams("Maximum number of records, default all; without sort the most recent are returned",
+			"start_time", "model", "duration", "tokens_per_sec")...),
+		response: []model.BenchmarkRecord{}},
+	{method: "GET", path: "/api/v1/benchmark/presets", tag: "benchmark", summary: "List benchmark presets", response: []model.BenchmarkPreset{}},
+	{method: "DELETE", path: "/api/v1/benchmark/{task_id}", tag: "benchmark", summary: "Delete a finished benchmark task",
+		params: []apiParam{pathParam("task_id", "Task ID")}},
+	{method: "GET", path: "/api/v1/benchmark/{task_id}/export", tag: "benchmark", summary: "Download the results of a finished benchmark",
+		params: []apiParam{
+			pathParam("task_id", "Task ID"),
+			queryParam("format", "string", "Export format, default json", service.ExportFormatJSON, service.ExportFormatCSV, service.ExportFormatMarkdown),
+		},
+		produces: "application/octet-stream"},
+	{method: "GET", path: "/api/v1/benchmark/{task_id}/output", tag: "benchmark", summary: "Get the raw llama-bench output of a task",
+		params: []apiParam{
+			pathParam("task_id", "Task ID"),
+			queryParam("stream", "string", "Output stream, default stdout", service.OutputStdout, service.OutputStderr),
+		},
+		produces: "text/plain"},
+	{method: "GET", path: "/api/v1/benchmark/schedules", tag: "benchmark", summary: "List benchmark schedules", response: []model.BenchmarkScheduleStatus{}},
+	{method: "POST", path: "/api/v1/benchmark/schedules/set", tag: "benchmark", summary: "Create or replace a benchmark schedule", request: model.BenchmarkSchedule{}, response: model.BenchmarkScheduleStatus{}},
+	{method: "POST", path: "/api/v1/benchmark/schedules/remove", tag: "benchmark", summary: "Remove a benchmark schedule", request: nameRequest},
+	{method: "GET", path: "/api/v1/benchmark/baselines", tag: "benchmark", summary: "List pinned benchmark baselines", response: []model.BenchmarkBaseline{}},
+	{method: "POST", path: "/api/v1/benchmark/baselines/set", tag: "benchmark", summary: "Pin a finished task as the baseline for its model", request: fields{"task_id": ""}, response: model.BenchmarkBaseline{}},
+	{method: "POST", path: "/api/v1/benchmark/baselines/remove", tag: "benchmark", summary: "Remove the baseline of a model", request: fields{"model_path": ""}},
+	{method: "POST", path: "/api/v1/benchmark/all", tag: "benchmark", summary: "Benchmark every GGUF model in the models directory", request: model.BenchmarkConfig{}, response: fields{"suite_id": ""}},
+	{method: "GET", path: "/api/v1/benchmark/all/status", tag: "benchmark", summary: "Get the progress and leaderboard of a benchmark suite",
+		params: []apiParam{
+			requiredQueryParam("suite_id", "Suite ID"),
+			queryParam("sort", "string", "Leaderboard order", service.LeaderboardSortTGPerGB, service.LeaderboardSortPPPerGB, service.LeaderboardSortTG, service.LeaderboardSortPP),
+		},
+		response: model.BenchmarkSuite{}},
+
+	// 准确性冒烟测试
+	{method: "GET", path: "/api/v1/evals", tag: "evals", summary: "List eval suites", response: []model.EvalSuite{}},
+	{method: "POST", path: "/api/v1/evals/set", tag: "evals", summary: "Create or replace an eval suite", request: model.EvalSuite{}, response: model.EvalSuite{}},
+	{method: "POST", path: "/api/v1/evals/remove", tag: "evals", summary: "Remove an eval suite", request: nameRequest},
+	{method: "POST", path: "/api/v1/evals/run", tag: "evals", summary: "Run an eval suite against a running model", request: model.EvalRunRequest{}, response: model.EvalRun{}},
+	{method: "GET", path: "/api/v1/evals/runs", tag: "evals", summary: "List recent eval runs",
+		params: []apiParam{
+			queryParam("suite", "string", "Only return runs of this suite"),
+			queryParam("model", "string", "Only return runs against this model"),
+		},
+		response: []model.EvalRun{}},
+
+	// 别名和路由
+	{method: "GET", path: "/api/v1/aliases", tag: "routing", summary: "List model aliases", response: []model.AliasInfo{}},
+	{method: "POST", path: "/api/v1/aliases/set", tag: "routing", summary: "Create or repoint an alias", request: model.AliasUpdateRequest{}, response: model.AliasChange{}},
+	{method: "POST", path: "/api/v1/aliases/remove", tag: "routing", summary: "Remove an alias", request: model.AliasUpdateRequest{}, response: model.AliasChange{}},
+	{method: "GET", path: "/api/v1/aliases/changelog", tag: "routing", summary: "Get the alias changelog",
+		params:   []apiParam{queryParam("alias", "string", "Only return changes of this alias")},
+		response: []model.AliasChange{}},
+	{method: "POST", path: "/api/v1/routes", tag: "routing", summary: "Create or replace a canary route", request: model.RouteConfig{}, response: model.RouteStatus{}},
+	{method: "GET", path: "/api/v1/routes/status", tag: "routing", summary: "List canary routes and their traffic", response: []model.RouteStatus{}},
+	{method: "POST", path: "/api/v1/routes/remove", tag: "routing", summary: "Remove a canary route", request: nameRequest},
+
+	// v2资源
+	{method: "GET", path: "/api/v2/models", tag: "v2", summary: "List running and persisted models",
+		params: append([]apiParam{
+			queryParam("running", "boolean", "Only return running (true) or stopped (false) models"),
+			queryParam("name", "string", "Only return models whose name contains this string (case-insensitive)"),
+		}, listParams("Maximum number of models, default all", "name", "start_time", "vram", "port")...),
+		response: ModelResourceList{}},
+	{method: "GET", path: "/api/v2/models/{name}", tag: "v2", summary: "Get a running or persisted model",
+		params: []apiParam{pathParam("name", "Model name")}, response: ModelResource{}},
+	{method: "PUT", path: "/api/v2/models/{name}", tag: "v2", summary: "Start a model, or preview its command line with dry_run",
+		params: []apiParam{
+			pathParam("name", "Model name"),
+			queryParam("dry_run", "boolean", "Only return the command that would be run, with status 200"),
+			queryParam("async", "boolean", "Return an operation with status 202 right away instead of waiting for the model to load"),
+			idempotencyKey,
+		},
+		request: model.ModelConfig{}, response: oneOf{ModelResource{}, model.CommandPreview{}, model.Operation{}}, status: http.StatusCreated},
+	{method: "DELETE", path: "/api/v2/models/{name}", tag: "v2", summary: "Stop a model",
+		params: []apiParam{
+			pathParam("name", "Model name"),
+			queryParam("force", "boolean", "Stop immediately, skipping the switch guard and draining"),
+			queryParam("drain", "boolean", "Wait for in-flight requests before stopping"),
+			queryParam("drain_timeout", "string", "Drain timeout in seconds or with a unit such as 2m"),
+			idempotencyKey,
+		},
+		response: model.ModelStatus{}},
+	{method: "GET", path: "/api/v2/benchmarks", tag: "v2", summary: "List queued, running and recently finished benchmark tasks",
+		params: append([]apiParam{
+			queryParam("status", "string", "Task status", "queued", "running", "completed", "failed", "cancelled"),
+		}, listParams("Maximum number of tasks, default all", "start_time", "status", "progress")...),
+		response: BenchmarkList{}},
+	{method: "POST", path: "/api/v2/benchmarks", tag: "v2", summary: "Start a llama-bench run", request: model.BenchmarkConfig{}, response: model.BenchmarkStatus{}, status: http.StatusAccepted},
+	{method: "GET", path: "/api/v2/benchmarks/{id}", tag: "v2", summary: "Get a benchmark task",
+		params: []apiParam{
+			pathParam("id", "Task ID"),
+			queryParam("results", "string", "Result layout, default grouped", "grouped", "flat"),
+		},
+		response: model.BenchmarkStatus{}},
+	{method: "DELETE", path: "/api/v2/benchmarks/{id}", tag: "v2", summary: "Delete a finished benchmark task",
+		params: []apiParam{pathParam("id", "Task ID")}, status: http.StatusNoContent},
+
+	// 分时共享
+	{method: "POST", path: "/api/v1/timeshare", tag: "timeshare", summary: "Register a timeshare group", request: model.TimeShareConfig{}, response: model.TimeShareStatus{}, enabled: timeShareOn},
+	{method: "GET", path: "/api/v1/timeshare/status", tag: "timeshare", summary: "List timeshare groups", response: []model.TimeShareStatus{}, enabled: timeShareOn},
+	{method: "POST", path: "/api/v1/timeshare/swap", tag: "timeshare", summary: "Swap the resident model of a timeshare group", request: model.TimeShareSwapRequest{}, response: model.TimeShareStatus{}, enabled: timeShareOn},
+	{method: "POST", path: "/api/v1/timeshare/remove", tag: "timeshare", summary: "Remove a timeshare group", request: model.TimeShareSwapRequest{}, enabled: timeShareOn},
+
+	// 公开状态页
+	{method: "GET", path: "/status", tag: "status", summary: "Public status page", produces: "text/html", enabled: statusPageOn},
+	{method: "GET", path: "/status.json", tag: "status", summary: "Public status of the running models", response: PublicStatus{}, enabled: statusPageOn},
+	{method: "GET", path: "/metrics", tag: "status", summary: "Prometheus metrics of the switcher", produces: "text/plain", enabled: metricsOn},
+	{method: "GET", path: "/health", tag: "status", summary: "Liveness check", produces: "text/plain"},
+}
+
+// openAPIBuilder 生成OpenAPI文档，命名的结构体类型只生成一次，放在components.schemas中按类型名引用
+type openAPIBuilder struct {
+	schemas map[string]interface{}
+}
+
+// OpenAPI 根据apiOperations和当前配置生成OpenAPI 3.1文档，按配置未注册的路由不包含在内
+func OpenAPI(cfg *config.Config) map[string]interface{} {
+	b := &openAPIBuilder{schemas: map[string]interface{}{}}
+	b.schemas["APIResponse"] = b.component(reflect.TypeOf(model.APIResponse{}))
+	b.schemas["Problem"] = b.component(reflect.TypeOf(model.Problem{}))
+	b.schemas["Problem"].(map[string]interface{})["properties"].(map[string]interface{})["code"] = map[string]interface{}{
+		"type": "string",
+		"enum": apierror.Codes(),
+	}
+
+	paths := make(map[string]interface{})
+	for _, op := range apiOperations {
+		if op.enabled != nil && !op.enabled(cfg) {
+			continue
+		}
+		item, ok := paths[op.path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[op.path] = item
+		}
+		item[strings.ToLower(op.method)] = b.operation(op)
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":       "llama-switch API",
+			"version":     config.BuildVersion(),
+			"description": "Management API for switching, monitoring and benchmarking llama.cpp models. Successful /api/v1 responses use the APIResponse envelope and the data field of each operation is described in its success response; /api/v2 returns the resource itself. /api/v1 operations replaced by /api/v2 resources are marked deprecated. Failures are RFC 7807 application/problem+json documents with a stable code such as MODEL_NOT_FOUND or INSUFFICIENT_VRAM, and keep the success, message and error fields of the envelope.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": b.schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+	}
+}
+
+// operation 生成单个操作
+func (b *openAPIBuilder) operation(op apiOperation) map[string]interface{} {
+	operation := map[string]interface{}{
+		"summary":     op.summary,
+		"tags":        []string{op.tag},
+		"operationId": operationID(op.method, op.path),
+	}
+	if _, replaced := v1Successors[op.path]; replaced {
+		operation["deprecated"] = true
+	}
+	// 启用认证时需要的最低角色
+	if role := auth.Required(op.method, op.path); role != auth.RoleNone {
+		operation["x-required-role"] = role.String()
+		operation["security"] = []interface{}{
+			map[string]interface{}{"bearer": []string{}},
+			map[string]interface{}{"apiKey": []string{}},
+		}
+	}
+
+	if len(op.params) > 0 {
+		params := make([]interface{}, 0, len(op.params))
+		for _, p := range op.params {
+			s := schema{"type": p.typ}
+			if len(p.enum) > 0 {
+				s["enum"] = p.enum
+			}
+			params = append(params, map[string]interface{}{
+				"name":        p.name,
+				"in":          p.in,
+				"description": p.description,
+				"required":    p.in == "path" || p.required,
+				"schema":      s,
+			})
+		}
+		operation["parameters"] = params
+	}
+
+	if op.request != nil {
+		content := map[string]interface{}{"application/json": map[string]interface{}{"schema": b.schemaOf(op.request)}}
+		if op.path == "/api/v1/config/validate" {
+			content["application/yaml"] = map[string]interface{}{"schema": stringSchema}
+		}
+		operation["requestBody"] = map[string]interface{}{"required": true, "content": content}
+	}
+
+	success := map[string]interface{}{"description": "Success"}
+	switch {
+	case op.produces != "":
+		success["content"] = map[string]interface{}{op.produces: map[string]interface{}{"schema": stringSchema}}
+	case strings.HasPrefix(op.path, "/api/v2/"):
+		// v2直接返回资源
+		if op.response != nil {
+			success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": b.schemaOf(op.response)}}
+		}
+	default:
+		envelope := interface{}(ref("APIResponse"))
+		if op.response != nil {
+			envelope = map[string]interface{}{
+				"allOf": []interface{}{
+					ref("APIResponse"),
+					map[string]interface{}{"properties": map[string]interface{}{"data": b.schemaOf(op.response)}},
+				},
+			}
+		}
+		success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": envelope}}
+	}
+	status := cmp.Or(op.status, http.StatusOK)
+	operation["responses"] = map[string]interface{}{
+		strconv.Itoa(status): success,
+		"default": map[string]interface{}{
+			"description": "Error, with the reason in detail and a stable error code in code",
+			"content":     map[string]interface{}{"application/problem+json": map[string]interface{}{"schema": ref("Problem")}},
+		},
+	}
+	return operation
+}
+
+// schemaOf 获取请求体或响应数据的Schema
+func (b *openAPIBuilder) schemaOf(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case schema:
+		return v
+	case fields:
+		properties := make(map[string]interface{}, len(v))
+		for name, value := range v {
+			properties[name] = b.schemaOf(value)
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	case []fields:
+		return map[string]interface{}{"type": "array", "items": b.schemaOf(v[0])}
+	case oneOf:
+		variants := make([]interface{}, len(v))
+		for i, value := range v {
+			variants[i] = b.schemaOf(value)
+		}
+		return map[string]interface{}{"oneOf": variants}
+	}
+	return b.typeSchema(reflect.TypeOf(v))
+}
+
+// typeSchema 获取Go类型的Schema，命名的结构体引用components中的定义
+func (b *openAPIBuilder) typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return map[string]interface{}{"type": "array", "items": b.typeSchema(t.Elem())}
+	case t.Kind() == reflect.Struct && t.Name() != "" && !t.Implements(jsonMarshalerType):
+		if _, exists := b.schemas[t.Name()]; !exists {
+			b.schemas[t.Name()] = b.component(t)
+		}
+		return ref(t.Name())
+	}
+	return b.component(t)
+}
+
+// component 由结构体的json标签生成类型的Schema，与GET /api/v1/config/schema使用相同的规则
+func (b *openAPIBuilder) component(t reflect.Type) map[string]interface{} {
+	s := config.JSONSchema(reflect.New(t).Interface(), "", schemaOverrides)
+	delete(s, "$schema")
+	delete(s, "title")
+	return s
+}
+
+// jsonMarshalerType 自定义JSON编码的类型（如time.Time），不作为components中的对象引用
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// ref 引用components.schemas中的定义
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// operationID 由方法和路径生成操作ID，如GET /api/v1/model/{name}/logs生成getModelNameLogs，
+// GET /api/v2/models/{name}生成getV2ModelsName
+func operationID(method, path string) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(method))
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "/api/v1"), "/api")
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return !('a' <= r && r <= 'z' || '0' <= r && r <= '9') }) {
+		id.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return id.String()
+}
+
+// GetOpenAPI 获取管理API的OpenAPI文档处理器，可用于生成客户端
+func (h *Handler) GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, OpenAPI(h.config.Snapshot()))
+}
+
+// swaggerUIPage 交互式API文档页面，从CDN加载Swagger UI并读取/api/v1/openapi.json
+// 启用认证时在页面的Authorize中填入API密钥后再调用接口
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>llama-switch API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+window.ui = SwaggerUIBundle({
+  url: "/api/v1/openapi.json",
+  dom_id: "#swagger-ui",
+  persistAuthorization: true
+});
+</script>
+</body>
+</html>
+`
+
+// GetAPIDocs 交互式API文档页面处理器
+func (h *Handler) GetAPIDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(swaggerUIPage))
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+
+	"llama-switch/internal/model"
+)
+
+// GetOperation 获取异步操作（async=true的切换请求）的状态和加载进度
+func (h *Handler) GetOperation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	op, err := h.ModelService.Operations().Get(r.PathValue("id"))
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(true, "Operation retrieved successfully", op, ""))
+}
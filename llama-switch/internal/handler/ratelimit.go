@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"llama-switch/internal/apierror"
+)
+
+// RateLimit 请求频率限制中间件：管理API请求按API密钥（未携带有效密钥时按客户端IP）消耗令牌，
+// 切换请求配置了单独的限制时使用单独的令牌桶。响应中带有RateLimit-Limit、RateLimit-Remaining和
+// RateLimit-Reset头，超过限制时返回429和Retry-After
+func (h *Handler) RateLimit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := routePath(r)
+		if !strings.HasPrefix(path, "/api/") {
+			next(w, r)
+			return
+		}
+		limiter := h.limiter
+		if h.switchLimiter != nil && isSwitchRequest(r.Method, path) {
+			limiter = h.switchLimiter
+		}
+		if limiter == nil {
+			next(w, r)
+			return
+		}
+
+		result := limiter.Allow(h.rateLimitClient(r))
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+		if !result.Allowed {
+			retryAfter := seconds(result.RetryAfter)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			message := fmt.Sprintf("Rate limit exceeded, retry in %d second(s)", retryAfter)
+			apierror.Write(w, apierror.NewProblem(apierror.CodeRateLimited, 0, message, map[string]interface{}{
+				"limit":       result.Limit,
+				"retry_after": retryAfter,
+			}))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// isSwitchRequest 是否为切换（启动）模型的请求
+func isSwitchRequest(method, path string) bool {
+	return (method == http.MethodPost && path == "/api/v1/model/switch") ||
+		(method == http.MethodPut && path == "/api/v2/models/{name}")
+}
+
+// rateLimitClient 限流的计数对象：有效的API密钥（以哈希值区分），否则为客户端IP，
+// 避免使用随机的无效密钥绕过按IP的限制
+func (h *Handler) rateLimitClient(r *http.Request) string {
+	if key := apiKeyFromRequest(r); key != "" {
+		if _, err := h.ModelService.RoleForKey(key); err == nil {
+			sum := sha256.Sum256([]byte(key))
+			return "key:" + hex.EncodeToString(sum[:8])
+		}
+	}
+	return "ip:" + clientIP(r)
+}
+
+// seconds 向上取整的秒数
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
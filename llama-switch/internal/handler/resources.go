@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+)
+
+// GetModelResources 获取模型进程资源使用采样历史处理器
+func (h *Handler) GetModelResources(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	name := r.PathValue("name")
+	samples := h.ModelService.Resources().History(name)
+	if len(samples) == 0 {
+		h.respondWithError(w, http.StatusNotFound,
+			fmt.Sprintf("No resource samples for model '%s'", name))
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Retrieved %d resource samples for model '%s'", len(samples), name),
+		map[string]interface{}{
+			"model_name": name,
+			"samples":    samples,
+		},
+		"",
+	))
+}
+
+// GetModelVRAMHistory 获取模型持久化的显存使用历史和最近一次运行的趋势处理器，
+// since（RFC3339）只返回此后的样本，limit限制返回条数
+func (h *Handler) GetModelVRAMHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var since time.Time
+	if value := r.URL.Query().Get("since"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid since value: %s", value))
+			return
+		}
+		since = t
+	}
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid limit value: %s", value))
+			return
+		}
+		limit = n
+	}
+
+	name := r.PathValue("name")
+	samples, err := h.ModelService.VRAMHistory().Samples(name, since, limit)
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if len(samples) == 0 {
+		h.respondWithError(w, http.StatusNotFound,
+			fmt.Sprintf("No VRAM history for model '%s'", name))
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Retrieved %d VRAM samples for model '%s'", len(samples), name),
+		map[string]interface{}{
+			"model_name": name,
+			"samples":    samples,
+			"trend":      service.VRAMTrend(samples),
+		},
+		"",
+	))
+}
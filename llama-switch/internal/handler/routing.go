@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"llama-switch/internal/logging"
+	"llama-switch/internal/model"
+)
+
+// SetRoute 创建或更新流量路由规则处理器
+func (h *Handler) SetRoute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var cfg model.RouteConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	status, err := h.ModelService.Routes().Set(&cfg)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to set route", "route", cfg.Name, "error", err)
+		h.respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Route '%s' set: %d%% of traffic to '%s'", cfg.Name, cfg.Weight, cfg.Canary),
+		status,
+		"",
+	))
+}
+
+// GetRoutes 获取流量路由规则及对比统计处理器
+func (h *Handler) GetRoutes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		"Routes retrieved successfully",
+		h.ModelService.Routes().List(),
+		"",
+	))
+}
+
+// RemoveRoute 删除流量路由规则处理器
+func (h *Handler) RemoveRoute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var cfg model.RouteConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.ModelService.Routes().Remove(cfg.Name); err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Route '%s' removed", cfg.Name),
+		nil,
+		"",
+	))
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"llama-switch/internal/model"
+)
+
+// GetRPCPools 获取RPC池处理器：每个池中rpc-server端点的健康状态、显存容量和使用该池的模型
+func (h *Handler) GetRPCPools(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	pools := h.ModelService.RPCPools()
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Retrieved %d RPC pools", len(pools)),
+		pools,
+		"",
+	))
+}
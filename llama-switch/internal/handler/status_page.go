@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"llama-switch/internal/logging"
+	"llama-switch/internal/service"
+)
+
+// PublicModelStatus 状态页中展示的模型信息（不包含路径、端口等内部细节）
+type PublicModelStatus struct {
+	Name    string   `json:"name"`              // 模型名称
+	Aliases []string `json:"aliases,omitempty"` // 指向该模型的别名
+	Health  string   `json:"health"`            // 健康状态：healthy/loading/unhealthy/offline
+	Since   string   `json:"since,omitempty"`   // 启动时间
+}
+
+// PublicStatus 公开状态页数据
+type PublicStatus struct {
+	Title     string              `json:"title"`      // 状态页标题
+	Status    string              `json:"status"`     // 整体状态：operational/degraded/unavailable
+	Models    []PublicModelStatus `json:"models"`     // 模型状态列表
+	UpdatedAt string              `json:"updated_at"` // 更新时间
+}
+
+// statusPageTemplate 状态页HTML模板
+var statusPageTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="30">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 720px; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 8px; border-bottom: 1px solid #ddd; }
+.healthy { color: #1a7f37; } .loading { color: #9a6700; }
+.unhealthy, .offline { color: #cf222e; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Overall status: <strong>{{.Status}}</strong></p>
+<table>
+<tr><th>Model</th><th>Aliases</th><th>Status</th><th>Since</th></tr>
+{{range .Models}}<tr><td>{{.Name}}</td><td>{{range $i, $a := .Aliases}}{{if $i}}, {{end}}{{$a}}{{end}}</td><td class="{{.Health}}">{{.Health}}</td><td>{{.Since}}</td></tr>
+{{else}}<tr><td colspan="4">No models configured</td></tr>
+{{end}}</table>
+<p><small>Last updated: {{.UpdatedAt}}</small></p>
+</body>
+</html>
+`))
+
+// GetPublicStatus 获取公开状态页JSON处理器
+func (h *Handler) GetPublicStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, h.collectPublicStatus(r))
+}
+
+// GetPublicStatusPage 获取公开状态页HTML处理器
+func (h *Handler) GetPublicStatusPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusPageTemplate.Execute(w, h.collectPublicStatus(r)); err != nil {
+		logging.FromContext(r.Context()).Error("Failed to render status page", "error", err)
+	}
+}
+
+// collectPublicStatus 收集所有模型的公开状态
+func (h *Handler) collectPublicStatus(r *http.Request) *PublicStatus {
+	statuses := h.ModelService.GetModelStatus("")
+	monitored := h.config.Snapshot().HealthCheck.Interval > 0
+
+	models := make([]PublicModelStatus, len(statuses))
+	var wg sync.WaitGroup
+	for i, status := range statuses {
+		models[i] = PublicModelStatus{
+			Name:    status.ModelName,
+			Aliases: h.ModelService.Aliases().AliasesOf(status.ModelName),
+			Health:  service.HealthOffline,
+		}
+		if !status.Running {
+			continue
+		}
+		models[i].Since = status.StartTime
+
+		// 状态页无需认证，使用后台健康检查最近一次的结果，不为每个请求探测实例；
+		// 尚未探测过的实例视为正在加载，只有禁用健康检查时才直接探测
+		switch {
+		case status.Health != nil:
+			models[i].Health = status.Health.Status
+		case monitored:
+			models[i].Health = service.HealthLoading
+		default:
+			wg.Add(1)
+			go func(i int, name string) {
+				defer wg.Done()
+				models[i].Health = h.ModelService.ProbeHealth(r.Context(), name)
+			}(i, status.ModelName)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].Name < models[j].Name
+	})
+
+	// 计算整体状态（已停止的模型不影响整体状态）
+	healthy, running := 0, 0
+	for _, m := range models {
+		if m.Health == service.HealthOffline {
+			continue
+		}
+		running++
+		if m.Health == service.HealthHealthy {
+			healthy++
+		}
+	}
+	overall := "operational"
+	switch {
+	case healthy == 0:
+		overall = "unavailable"
+	case healthy < running:
+		overall = "degraded"
+	}
+
+	return &PublicStatus{
+		Title:     h.config.StatusPage.Title,
+		Status:    overall,
+		Models:    models,
+		UpdatedAt: time.Now().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,106 @@
+//go:build !windows
+
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+)
+
+func TestPublicStatusUsesCachedHealth(t *testing.T) {
+	// 模拟llama-server，统计健康探测次数
+	var probes atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+		switch r.URL.Path {
+		case "/health":
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/slots":
+			w.Write([]byte(`[{"id":0,"is_processing":false}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer backend.Close()
+	_, portStr, _ := net.SplitHostPort(backend.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	// 切换器重启前启动的实例，恢复时直接接管
+	instance := exec.Command("sh", "-c", "sleep 30; true", "llama-server", "--model", "/models/chat.gguf", "--port", portStr)
+	if err := instance.Start(); err != nil {
+		t.Skipf("sh not available: %v", err)
+	}
+	defer func() {
+		instance.Process.Kill()
+		instance.Wait()
+	}()
+
+	dir := t.TempDir()
+	cfg := &config.Config{}
+	isolateConfig(cfg, dir)
+	cfg.HealthCheck.Interval = 10
+	cfg.StatusPage.Title = "Status"
+	status := &model.ModelStatus{
+		ModelName: "chat",
+		ModelPath: "/models/chat.gguf",
+		Host:      "127.0.0.1",
+		Port:      port,
+		ProcessID: instance.Process.Pid,
+		Running:   true,
+		StartTime: time.Now().Format(time.RFC3339),
+	}
+	if err := config.NewPersistentManager(cfg).UpdateModelConfig("chat", &model.ModelConfig{ModelName: "chat", ModelPath: "/models/chat.gguf"}, status); err != nil {
+		t.Fatal(err)
+	}
+	modelService := service.NewModelService(cfg, true)
+	h := NewHandlerWithService(cfg, modelService, nil)
+	if names := modelService.GetRunningModelNames(); len(names) != 1 {
+		t.Fatalf("running models = %v, want the adopted instance", names)
+	}
+
+	getStatus := func() *PublicStatus {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.GetPublicStatus(rec, httptest.NewRequest("GET", "/status.json", nil))
+		var resp PublicStatus
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("invalid response: %v: %s", err, rec.Body)
+		}
+		return &resp
+	}
+
+	// 后台健康检查尚未探测时不为请求探测实例
+	if resp := getStatus(); len(resp.Models) != 1 || resp.Models[0].Health != service.HealthLoading {
+		t.Errorf("status before health check = %+v, want chat loading", resp)
+	}
+	if n := probes.Load(); n != 0 {
+		t.Errorf("status page probed the instance %d times", n)
+	}
+
+	modelService.Health().CheckAll(context.Background())
+	checked := probes.Load()
+	if checked == 0 {
+		t.Fatal("health check did not probe the instance")
+	}
+	for i := 0; i < 3; i++ {
+		resp := getStatus()
+		if len(resp.Models) != 1 || resp.Models[0].Health != service.HealthHealthy || resp.Status != "operational" {
+			t.Errorf("status after health check = %+v, want chat healthy", resp)
+		}
+	}
+	if n := probes.Load(); n != checked {
+		t.Errorf("status page probed the instance %d more times", n-checked)
+	}
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"llama-switch/internal/model"
+)
+
+// GetTenants 获取租户处理器：每个租户的显存配额、已用显存和运行中的模型
+func (h *Handler) GetTenants(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	tenants := h.ModelService.Tenants()
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Retrieved %d tenants", len(tenants)),
+		tenants,
+		"",
+	))
+}
+
+// apiKeyFromRequest 从Authorization: Bearer或X-API-Key请求头中获取API密钥
+func apiKeyFromRequest(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if key, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(key)
+		}
+	}
+	return r.Header.Get("X-API-Key")
+}
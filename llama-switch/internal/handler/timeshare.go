@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"llama-switch/internal/logging"
+	"llama-switch/internal/model"
+)
+
+// RegisterTimeShare 注册分时共享组处理器
+func (h *Handler) RegisterTimeShare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var cfg model.TimeShareConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	status, err := h.ModelService.TimeShare().Register(&cfg)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to register timeshare group", "group", cfg.Name, "error", err)
+		h.respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Timeshare group '%s' registered successfully", cfg.Name),
+		status,
+		"",
+	))
+}
+
+// GetTimeShareStatus 获取分时共享组状态处理器
+func (h *Handler) GetTimeShareStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		"Timeshare status retrieved successfully",
+		h.ModelService.TimeShare().Status(),
+		"",
+	))
+}
+
+// SwapTimeShare 手动切换分时共享组驻留模型处理器
+func (h *Handler) SwapTimeShare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req model.TimeShareSwapRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Name == "" {
+		h.respondWithError(w, http.StatusBadRequest, "Group name is required")
+		return
+	}
+
+	status, err := h.ModelService.TimeShare().Swap(r.Context(), req.Name, req.ModelName)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to swap timeshare group", "group", req.Name, "error", err)
+		h.respondWithServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Model '%s' is now resident in group '%s'", status.Resident, req.Name),
+		status,
+		"",
+	))
+}
+
+// RemoveTimeShare 移除分时共享组处理器
+func (h *Handler) RemoveTimeShare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req model.TimeShareSwapRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.ModelService.TimeShare().Remove(req.Name); err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Timeshare group '%s' removed", req.Name),
+		nil,
+		"",
+	))
+}
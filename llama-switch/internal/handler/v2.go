@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/listing"
+	"llama-switch/internal/logging"
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+	"llama-switch/internal/units"
+)
+
+// /api/v2 以资源组织管理API：模型为/api/v2/models/{name}，基准测试为/api/v2/benchmarks/{id}。
+// 成功时直接返回资源本身（不再使用APIResponse结构），失败时返回application/problem+json，
+// 与v1共用切换、停止和基准测试的实现
+
+// ModelResource v2中的模型资源：模型状态和请求统计
+type ModelResource struct {
+	*model.ModelStatus
+	Requests service.RequestStats `json:"requests"` // 请求统计
+}
+
+// ModelResourceList v2中的模型列表
+type ModelResourceList struct {
+	Items []*ModelResource `json:"items"`
+	Total int              `json:"total"` // 过滤后、分页前的总数
+}
+
+// BenchmarkList v2中的基准测试任务列表
+type BenchmarkList struct {
+	Items []*model.BenchmarkStatus `json:"items"`
+	Total int                      `json:"total"` // 过滤后、分页前的总数
+}
+
+// modelResource 构建模型资源
+func (h *Handler) modelResource(status *model.ModelStatus) *ModelResource {
+	return &ModelResource{ModelStatus: status, Requests: h.ModelService.Tracker().Stats(status.ModelName)}
+}
+
+// ListModelsV2 列出运行中和持久化配置中的模型，支持与v1状态查询相同的过滤、排序和分页参数
+func (h *Handler) ListModelsV2(w http.ResponseWriter, r *http.Request) {
+	statuses, total, ok := h.listModelStatuses(w, r, h.ModelService.GetModelStatus(""))
+	if !ok {
+		return
+	}
+	list := &ModelResourceList{Items: []*ModelResource{}, Total: total}
+	for _, status := range statuses {
+		list.Items = append(list.Items, h.modelResource(status))
+	}
+	h.respondWithJSON(w, http.StatusOK, list)
+}
+
+// GetModelV2 获取单个模型，已停止但仍在持久化配置中的模型running为false
+func (h *Handler) GetModelV2(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	statuses := h.ModelService.GetModelStatus(name)
+	if len(statuses) == 0 {
+		apierror.Write(w, apierror.NewProblem(apierror.CodeModelNotFound, 0, fmt.Sprintf("Model '%s' not found", name), nil))
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, h.modelResource(statuses[0]))
+}
+
+// PutModelV2 启动模型，请求体与v1的切换请求相同，model_name可省略（使用路径中的名称）；
+// 只指定名称（请求体为空）时使用模型定义目录中的同名定义。指定dry_run=true时只返回启动命令预览，
+// 指定async=true时校验后立即返回202和操作，Location为/api/v1/operations/{id}
+func (h *Handler) PutModelV2(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	var cfg model.ModelConfig
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &cfg); err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	if cfg.ModelName != "" && cfg.ModelName != name {
+		h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("model_name %q does not match the model in the path (%s)", cfg.ModelName, name))
+		return
+	}
+	cfg.ModelName = name
+
+	specified := specifiedConfigFields(body)
+	if cfg.ModelPath == "" {
+		if def, defSpecified, exists := h.ModelService.Definitions().Get(name); exists {
+			cfg, specified = *def, defSpecified
+		}
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	async, _ := strconv.ParseBool(r.URL.Query().Get("async"))
+	if async && dryRun {
+		h.respondWithError(w, http.StatusBadRequest, "async and dry_run cannot be combined")
+		return
+	}
+	if async {
+		op, problem := h.switchModelAsync(r, &cfg, specified)
+		if problem != nil {
+			apierror.Write(w, problem)
+			return
+		}
+		w.Header().Set("Location", "/api/v1/operations/"+op.ID)
+		h.respondWithJSON(w, http.StatusAccepted, op)
+		return
+	}
+
+	result, problem := h.switchModel(r, &cfg, specified, dryRun)
+	if problem != nil {
+		apierror.Write(w, problem)
+		return
+	}
+	if dryRun {
+		h.respondWithJSON(w, http.StatusOK, result.preview)
+		return
+	}
+	w.Header().Set("Location", "/api/v2/models/"+name)
+	h.respondWithJSON(w, http.StatusCreated, h.modelResource(result.status))
+}
+
+// DeleteModelV2 停止模型，查询参数force、drain和drain_timeout与v1停止请求的字段相同，返回停止时的模型状态
+func (h *Handler) DeleteModelV2(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := model.ModelStopRequest{ModelName: r.PathValue("name")}
+	var err error
+	if value := query.Get("force"); value != "" {
+		if req.Force, err = strconv.ParseBool(value); err != nil {
+			h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid force value: %s", value))
+			return
+		}
+	}
+	if value := query.Get("drain"); value != "" {
+		if req.Drain, err = strconv.ParseBool(value); err != nil {
+			h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid drain value: %s", value))
+			return
+		}
+	}
+	if value := query.Get("drain_timeout"); value != "" {
+		seconds, err := units.ParseDuration(value, time.Second)
+		if err != nil || seconds < 0 {
+			h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid drain_timeout value: %s", value))
+			return
+		}
+		req.DrainTimeout = units.Seconds(seconds)
+	}
+
+	status, _, problem := h.stopModel(logging.FromContext(r.Context()), req)
+	if problem != nil {
+		apierror.Write(w, problem)
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, status)
+}
+
+// ListBenchmarksV2 列出内存中的基准测试任务，默认按开始时间从新到旧排列，
+// 可按status过滤，并用limit、offset和sort（start_time、status、progress）分页排序
+func (h *Handler) ListBenchmarksV2(w http.ResponseWriter, r *http.Request) {
+	params, ok := h.parseListParams(w, r, 0, "start_time", "status", "progress")
+	if !ok {
+		return
+	}
+	status := r.URL.Query().Get("status")
+	tasks := make([]*model.BenchmarkStatus, 0)
+	for _, task := range h.BenchmarkService.Tasks() {
+		if status == "" || task.Status == status {
+			tasks = append(tasks, task)
+		}
+	}
+	page, total := listing.Apply(tasks, params, map[string]func(a, b *model.BenchmarkStatus) int{
+		"start_time": func(a, b *model.BenchmarkStatus) int { return strings.Compare(a.StartTime, b.StartTime) },
+		"status":     func(a, b *model.BenchmarkStatus) int { return strings.Compare(a.Status, b.Status) },
+		"progress":   func(a, b *model.BenchmarkStatus) int { return cmp.Compare(a.Progress, b.Progress) },
+	}, false)
+	setTotalCount(w, total)
+	h.respondWithJSON(w, http.StatusOK, &BenchmarkList{Items: page, Total: total})
+}
+
+// CreateBenchmarkV2 提交基准测试任务，请求体与v1相同，返回202和任务的当前状态，Location为任务资源
+func (h *Handler) CreateBenchmarkV2(w http.ResponseWriter, r *http.Request) {
+	var cfg model.BenchmarkConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	taskID, problem := h.startBenchmark(&cfg)
+	if problem != nil {
+		apierror.Write(w, problem)
+		return
+	}
+	status, err := h.BenchmarkService.GetStatus(taskID)
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Location", "/api/v2/benchmarks/"+taskID)
+	h.respondWithJSON(w, http.StatusAccepted, status)
+}
+
+// GetBenchmarkV2 获取基准测试任务，结果默认按模型分组（results=grouped），results=flat时返回all_results
+func (h *Handler) GetBenchmarkV2(w http.ResponseWriter, r *http.Request) {
+	status, err := h.BenchmarkService.GetStatus(r.PathValue("id"))
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+	switch r.URL.Query().Get("results") {
+	case "", "grouped":
+		status = service.GroupedStatus(status)
+	case "flat":
+	default:
+		h.respondWithError(w, http.StatusBadRequest, fmt.Sprintf("invalid results value: %s (expected grouped or flat)", r.URL.Query().Get("results")))
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, status)
+}
+
+// DeleteBenchmarkV2 删除已结束的基准测试任务，排队或运行中的任务返回409
+func (h *Handler) DeleteBenchmarkV2(w http.ResponseWriter, r *http.Request) {
+	if err := h.BenchmarkService.DeleteTask(r.PathValue("id")); err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
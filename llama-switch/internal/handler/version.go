@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// v1DeprecatedSince 已有v2替代接口的v1路由的弃用时间（v2发布的日期），写在Deprecation响应头中
+var v1DeprecatedSince = time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+// v1Successors 已被v2资源替代的v1路由，按路由模式索引，值为v2中对应的资源
+var v1Successors = map[string]string{
+	"/api/v1/model/switch":        "/api/v2/models",
+	"/api/v1/model/stop":          "/api/v2/models",
+	"/api/v1/model/status":        "/api/v2/models",
+	"/api/v1/benchmark":           "/api/v2/benchmarks",
+	"/api/v1/benchmark/status":    "/api/v2/benchmarks",
+	"/api/v1/benchmark/{task_id}": "/api/v2/benchmarks",
+}
+
+// DeprecateV1 弃用提示中间件：已有v2替代接口的v1路由在响应中带有Deprecation头（RFC 9745）
+// 和指向v2资源的Link头（rel="successor-version"），v1接口的行为不变
+func (h *Handler) DeprecateV1(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if successor, exists := v1Successors[routePath(r)]; exists {
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", v1DeprecatedSince.Unix()))
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+		}
+		next(w, r)
+	}
+}
+
+// routePath 匹配到的路由模式的路径部分，v2的路由模式带有请求方法（如"GET /api/v2/models/{name}"）
+func routePath(r *http.Request) string {
+	if _, path, found := strings.Cut(r.Pattern, " "); found {
+		return path
+	}
+	return r.Pattern
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"llama-switch/internal/logging"
+	"llama-switch/internal/model"
+)
+
+// CreateWebhook 注册事件订阅处理器，响应中的secret只返回这一次
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req model.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	sub, err := h.ModelService.Webhooks().Create(&req)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("Failed to register webhook", "url", req.URL, "error", err)
+		h.respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Webhook '%s' registered", sub.ID),
+		sub,
+		"",
+	))
+}
+
+// ListWebhooks 获取所有事件订阅及其投递统计处理器
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	subs := h.ModelService.Webhooks().List()
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Found %d webhooks", len(subs)),
+		subs,
+		"",
+	))
+}
+
+// GetWebhook 获取单个事件订阅处理器
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	sub, err := h.ModelService.Webhooks().Get(r.PathValue("id"))
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(true, "Webhook retrieved successfully", sub, ""))
+}
+
+// DeleteWebhook 删除事件订阅处理器
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.ModelService.Webhooks().Delete(id); err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(true, fmt.Sprintf("Webhook '%s' removed", id), nil, ""))
+}
+
+// GetWebhookDeliveries 获取事件订阅最近的投递记录处理器（最新的在前）
+func (h *Handler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	deliveries, err := h.ModelService.Webhooks().Deliveries(r.PathValue("id"))
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+	h.respondWithJSON(w, http.StatusOK, model.NewAPIResponse(
+		true,
+		fmt.Sprintf("Retrieved %d deliveries", len(deliveries)),
+		deliveries,
+		"",
+	))
+}
+
+// TestWebhook 向事件订阅发送一条webhook.test通知处理器，投递结果通过投递记录查询
+func (h *Handler) TestWebhook(w http.ResponseWriter, r *http.Request) {
+	delivery, err := h.ModelService.Webhooks().Test(r.PathValue("id"))
+	if err != nil {
+		h.respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+	h.respondWithJSON(w, http.StatusAccepted, model.NewAPIResponse(true, "Test notification queued", delivery, ""))
+}
@@ -0,0 +1,117 @@
+// Package idempotency 按幂等键缓存请求的响应：客户端超时后带相同的Idempotency-Key重试时返回第一次请求的响应，
+// 而不是再次执行请求
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrKeyReused 幂等键已用于内容不同的请求
+var ErrKeyReused = errors.New("idempotency key was already used for a different request")
+
+// Response 缓存的响应
+type Response struct {
+	Status int
+	Header http.Header // 需要重放的响应头（Content-Type、Location等）
+	Body   []byte
+}
+
+// entry 一个幂等键的记录：请求执行期间response为nil，done在请求结束时关闭
+type entry struct {
+	fingerprint string
+	response    *Response
+	done        chan struct{}
+	expires     time.Time
+}
+
+// Store 幂等键缓存：键在第一次请求开始时记录，请求结束后响应保留ttl，最多保留maxKeys个键
+type Store struct {
+	ttl     time.Duration
+	maxKeys int
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// New 创建幂等键缓存，ttl不大于0时返回nil，表示不缓存
+func New(ttl time.Duration, maxKeys int) *Store {
+	if ttl <= 0 {
+		return nil
+	}
+	return &Store{ttl: ttl, maxKeys: maxKeys, now: time.Now, entries: make(map[string]*entry)}
+}
+
+// Begin 开始一个带幂等键的请求，fingerprint标识请求内容（方法、路径和请求体）：
+// 键未使用过时记录键并返回owner为true，调用方执行请求后必须调用Complete；
+// 键对应的请求已结束时返回缓存的响应；仍在执行时等待其结束（ctx取消时返回ctx的错误）；
+// 键已用于内容不同的请求时返回ErrKeyReused
+func (s *Store) Begin(ctx context.Context, key, fingerprint string) (response *Response, owner bool, err error) {
+	s.mu.Lock()
+	now := s.now()
+	s.prune(now)
+	e, exists := s.entries[key]
+	if !exists {
+		s.entries[key] = &entry{fingerprint: fingerprint, done: make(chan struct{})}
+		s.mu.Unlock()
+		return nil, true, nil
+	}
+	s.mu.Unlock()
+
+	if e.fingerprint != fingerprint {
+		return nil, false, ErrKeyReused
+	}
+	select {
+	case <-e.done:
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+	if e.response == nil {
+		// 第一次请求的响应未被缓存（如服务端错误），本次请求重新执行
+		return s.Begin(ctx, key, fingerprint)
+	}
+	return e.response, false, nil
+}
+
+// Complete 记录请求的响应并唤醒等待的重试请求；response为nil时不缓存，删除键，之后的重试重新执行请求
+func (s *Store) Complete(key string, response *Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, exists := s.entries[key]
+	if !exists {
+		return
+	}
+	if response == nil {
+		delete(s.entries, key)
+	} else {
+		e.response = response
+		e.expires = s.now().Add(s.ttl)
+	}
+	close(e.done)
+}
+
+// prune 删除过期的键，超过maxKeys时删除最早过期的键（调用方需持有锁）
+func (s *Store) prune(now time.Time) {
+	for key, e := range s.entries {
+		if e.response != nil && now.After(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+	for s.maxKeys > 0 && len(s.entries) >= s.maxKeys {
+		oldest := ""
+		for key, e := range s.entries {
+			if e.response != nil && (oldest == "" || e.expires.Before(s.entries[oldest].expires)) {
+				oldest = key
+			}
+		}
+		if oldest == "" {
+			// 只剩执行中的请求，不能删除
+			return
+		}
+		delete(s.entries, oldest)
+	}
+}
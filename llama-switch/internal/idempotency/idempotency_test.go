@@ -0,0 +1,92 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	if New(0, 10) != nil {
+		t.Error("New(0) should disable idempotency keys")
+	}
+
+	now := time.Unix(1700000000, 0)
+	s := New(time.Hour, 0)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, owner, err := s.Begin(ctx, "k1", "switch chat"); !owner || err != nil {
+		t.Fatalf("first request: owner=%v err=%v", owner, err)
+	}
+	s.Complete("k1", &Response{Status: http.StatusOK, Body: []byte("ok")})
+
+	// 相同的请求返回缓存的响应，内容不同的请求被拒绝
+	resp, owner, err := s.Begin(ctx, "k1", "switch chat")
+	if owner || err != nil || resp == nil || string(resp.Body) != "ok" {
+		t.Fatalf("retry = %+v, %v, %v", resp, owner, err)
+	}
+	if _, _, err := s.Begin(ctx, "k1", "switch other"); !errors.Is(err, ErrKeyReused) {
+		t.Errorf("reused key error = %v", err)
+	}
+
+	// 过期后重新执行
+	now = now.Add(2 * time.Hour)
+	if _, owner, _ := s.Begin(ctx, "k1", "switch other"); !owner {
+		t.Error("expired key was not released")
+	}
+}
+
+func TestStoreWaitsForInFlightRequest(t *testing.T) {
+	s := New(time.Hour, 0)
+	ctx := context.Background()
+	s.Begin(ctx, "k", "fp")
+
+	result := make(chan *Response)
+	go func() {
+		resp, _, _ := s.Begin(ctx, "k", "fp")
+		result <- resp
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.Complete("k", &Response{Status: http.StatusCreated})
+	if resp := <-result; resp == nil || resp.Status != http.StatusCreated {
+		t.Fatalf("waiting retry got %+v", resp)
+	}
+
+	// 等待时请求被取消
+	s.Begin(ctx, "k2", "fp")
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, _, err := s.Begin(canceled, "k2", "fp"); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled wait error = %v", err)
+	}
+
+	// 未缓存的响应：等待的重试成为新的执行者
+	owners := make(chan bool)
+	go func() {
+		_, owner, _ := s.Begin(ctx, "k2", "fp")
+		owners <- owner
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.Complete("k2", nil)
+	if !<-owners {
+		t.Error("retry after an uncached response did not run the request")
+	}
+}
+
+func TestStoreMaxKeys(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	s := New(time.Hour, 2)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+	for _, key := range []string{"a", "b", "c"} {
+		s.Begin(ctx, key, "fp")
+		s.Complete(key, &Response{Status: http.StatusOK})
+		now = now.Add(time.Second)
+	}
+	if _, exists := s.entries["a"]; exists || len(s.entries) != 2 {
+		t.Errorf("oldest key not evicted: %d entries", len(s.entries))
+	}
+}
@@ -0,0 +1,49 @@
+package ipfilter
+
+import "testing"
+
+func TestFilter(t *testing.T) {
+	if f, err := New(nil, nil); f != nil || err != nil {
+		t.Fatalf("New(nil, nil) = %v, %v; want nil filter", f, err)
+	}
+	var disabled *Filter
+	if !disabled.Allowed("203.0.113.7") {
+		t.Error("nil filter should allow every address")
+	}
+
+	f, err := New([]string{"10.0.0.0/8", "127.0.0.1", "::1", " 192.168.1.0/24 "}, []string{"10.0.5.0/24", "::ffff:192.168.1.66/128"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"10.0.5.9", false}, // 拒绝列表优先
+		{"127.0.0.1", true},
+		{"::ffff:127.0.0.1", true}, // IPv4映射的IPv6地址
+		{"::1", true},
+		{"192.168.1.20", true},
+		{"192.168.1.66", false},
+		{"172.16.0.1", false}, // 不在允许列表中
+		{"not-an-ip", false},
+	}
+	for _, tt := range tests {
+		if got := f.Allowed(tt.ip); got != tt.want {
+			t.Errorf("Allowed(%q) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+
+	// 只有拒绝列表时允许其他地址
+	f, _ = New(nil, []string{"203.0.113.0/24"})
+	if f.Allowed("203.0.113.7") || !f.Allowed("198.51.100.1") {
+		t.Error("deny-only filter")
+	}
+
+	for _, invalid := range []string{"10.0.0.0/33", "10.0.0", "example.com"} {
+		if _, err := New([]string{invalid}, nil); err == nil {
+			t.Errorf("New(%q) should fail", invalid)
+		}
+	}
+}
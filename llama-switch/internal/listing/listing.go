@@ -0,0 +1,69 @@
+// Package listing 列表接口的分页和排序：limit、offset和sort查询参数
+package listing
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Params 分页和排序参数
+type Params struct {
+	Limit  int    // 最多返回的条数，0表示不限制
+	Offset int    // 跳过的条数
+	Sort   string // 排序键，为空时使用列表的默认顺序
+	Desc   bool   // 是否降序（sort参数以-开头）
+}
+
+// Parse 解析limit、offset和sort查询参数：limit省略时为defaultLimit，
+// sort为keys之一，加-前缀表示降序（如-tokens_per_sec）
+func Parse(query url.Values, defaultLimit int, keys ...string) (Params, error) {
+	p := Params{Limit: defaultLimit}
+	for name, target := range map[string]*int{"limit": &p.Limit, "offset": &p.Offset} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("invalid %s value: %s", name, value)
+		}
+		*target = n
+	}
+	if value := query.Get("sort"); value != "" {
+		key, desc := strings.CutPrefix(value, "-")
+		if !slices.Contains(keys, key) {
+			return p, fmt.Errorf("invalid sort value: %s (expected one of %s, optionally prefixed with -)", value, strings.Join(keys, ", "))
+		}
+		p.Sort, p.Desc = key, desc
+	}
+	return p, nil
+}
+
+// Apply 按参数排序并分页，返回当前页和分页前的总数。compare为各排序键的比较函数，排序是稳定的，
+// 相等的元素保持原有顺序。未指定sort且fromEnd为true时（按时间顺序追加的记录），
+// offset和limit从列表末尾开始计数：offset=0、limit=N返回最近的N条，仍按原有顺序排列
+func Apply[T any](items []T, p Params, compare map[string]func(a, b T) int, fromEnd bool) ([]T, int) {
+	total := len(items)
+	if cmp := compare[p.Sort]; cmp != nil {
+		items = slices.Clone(items)
+		slices.SortStableFunc(items, func(a, b T) int {
+			if p.Desc {
+				return cmp(b, a)
+			}
+			return cmp(a, b)
+		})
+		fromEnd = false
+	}
+
+	start, end := min(p.Offset, total), total
+	if p.Limit > 0 {
+		end = min(start+p.Limit, total)
+	}
+	if fromEnd {
+		start, end = total-end, total-start
+	}
+	return items[start:end], total
+}
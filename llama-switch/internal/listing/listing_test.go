@@ -0,0 +1,54 @@
+package listing
+
+import (
+	"cmp"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	p, err := Parse(url.Values{"limit": {"10"}, "offset": {"20"}, "sort": {"-size"}}, 100, "name", "size")
+	if err != nil || p != (Params{Limit: 10, Offset: 20, Sort: "size", Desc: true}) {
+		t.Errorf("Parse = %+v, %v", p, err)
+	}
+	if p, err := Parse(url.Values{}, 100, "name"); err != nil || p != (Params{Limit: 100}) {
+		t.Errorf("Parse defaults = %+v, %v", p, err)
+	}
+	for _, query := range []url.Values{{"limit": {"-1"}}, {"offset": {"x"}}, {"sort": {"size"}}} {
+		if _, err := Parse(query, 0, "name"); err == nil {
+			t.Errorf("Parse(%v) should fail", query)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	items := []int{3, 1, 4, 1, 5, 9, 2, 6}
+	compare := map[string]func(a, b int) int{"value": cmp.Compare[int]}
+
+	tests := []struct {
+		params  Params
+		fromEnd bool
+		want    []int
+	}{
+		{Params{}, false, items},
+		{Params{Limit: 3}, false, []int{3, 1, 4}},
+		{Params{Limit: 3, Offset: 6}, false, []int{2, 6}},
+		{Params{Offset: 20}, false, []int{}},
+		{Params{Limit: 3, Sort: "value"}, false, []int{1, 1, 2}},
+		{Params{Limit: 2, Offset: 1, Sort: "value", Desc: true}, false, []int{6, 5}},
+		{Params{Limit: 3}, true, []int{9, 2, 6}},            // 最近的3条
+		{Params{Limit: 3, Offset: 3}, true, []int{4, 1, 5}}, // 再往前3条
+		{Params{Limit: 3, Offset: 7}, true, []int{3}},
+		{Params{Limit: 2, Sort: "value"}, true, []int{1, 1}}, // 指定排序后从开头计数
+	}
+	for _, tt := range tests {
+		got, total := Apply(items, tt.params, compare, tt.fromEnd)
+		if !slices.Equal(got, tt.want) || total != len(items) {
+			t.Errorf("Apply(%+v, fromEnd=%v) = %v, %d; want %v, %d", tt.params, tt.fromEnd, got, total, tt.want, len(items))
+		}
+	}
+	if items[0] != 3 {
+		t.Error("Apply modified the input")
+	}
+}
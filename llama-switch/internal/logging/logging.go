@@ -0,0 +1,139 @@
+// Package logging 配置switcher的结构化日志（log/slog）：按Log配置设置级别、输出格式（text或json）
+// 和输出位置，并为每个API请求分配请求ID，使同一请求的日志可以关联起来
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"llama-switch/internal/config"
+)
+
+// 日志输出格式
+const (
+	FormatText = "text" // key=value格式，便于直接阅读
+	FormatJSON = "json" // 每行一个JSON对象，便于日志采集系统解析
+)
+
+// RequestIDHeader 携带请求ID的HTTP头，客户端提供时沿用，否则由switcher生成
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength 沿用客户端请求ID的最大长度
+const maxRequestIDLength = 128
+
+// level 默认日志器的级别，重新加载配置时可直接修改
+var level = new(slog.LevelVar)
+
+// Setup 按配置设置默认的slog日志器，标准库log包的输出也经由它以info级别写出。
+// 配置了日志文件时追加写入该文件，未配置日志文件或启用了控制台日志时同时写到标准错误；
+// 返回的日志文件需要在退出时关闭（未配置时为nil）
+func Setup(cfg *config.Config) (io.Closer, error) {
+	var writers []io.Writer
+	var file *os.File
+	if cfg.Log.File != "" {
+		f, err := os.OpenFile(cfg.Log.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %v", err)
+		}
+		file = f
+		writers = append(writers, f)
+	}
+	if file == nil || cfg.Log.EnableConsole {
+		writers = append(writers, os.Stderr)
+	}
+
+	SetLevel(cfg.Log.Level)
+	slog.SetDefault(slog.New(NewHandler(io.MultiWriter(writers...), cfg.Log.Format)))
+	if file == nil {
+		return nil, nil
+	}
+	return file, nil
+}
+
+// NewHandler 创建按指定格式写到w的日志处理器，级别跟随SetLevel
+func NewHandler(w io.Writer, format string) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	if strings.EqualFold(format, FormatJSON) {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// SetLevel 设置默认日志器的级别（debug/info/warn/error），无法识别时使用info
+func SetLevel(name string) {
+	level.Set(ParseLevel(name))
+}
+
+// ParseLevel 解析日志级别名称，无法识别时返回info
+func ParseLevel(name string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+type requestIDKey struct{}
+
+// WithRequestID 返回携带请求ID的上下文
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 获取上下文中的请求ID，没有时返回空字符串
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// FromContext 获取带有上下文中请求ID字段的日志器
+func FromContext(ctx context.Context) *slog.Logger {
+	if id := RequestID(ctx); id != "" {
+		return slog.Default().With("request_id", id)
+	}
+	return slog.Default()
+}
+
+// TagRequest 为请求分配请求ID：沿用客户端提供的有效X-Request-ID，否则生成新的ID，
+// 并在响应头中返回，返回携带请求ID上下文的请求
+func TagRequest(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := r.Header.Get(RequestIDHeader)
+	if !validRequestID(id) {
+		id = NewRequestID()
+	}
+	w.Header().Set(RequestIDHeader, id)
+	return r.WithContext(WithRequestID(r.Context(), id))
+}
+
+// NewRequestID 生成随机的请求ID
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID 客户端提供的请求ID只能包含可打印ASCII字符，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Fatal 以error级别记录日志后退出程序
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"llama-switch/internal/config"
+)
+
+func TestSetupJSONFile(t *testing.T) {
+	previous := slog.Default()
+	defer func() {
+		slog.SetDefault(previous)
+		SetLevel("info")
+	}()
+
+	cfg := &config.Config{}
+	cfg.Log.Level = "warn"
+	cfg.Log.Format = FormatJSON
+	cfg.Log.File = filepath.Join(t.TempDir(), "switch.log")
+	closer, err := Setup(cfg)
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	defer closer.Close()
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	FromContext(ctx).Info("filtered by level")
+	FromContext(ctx).Warn("Model stopped", "model_name", "chat")
+	SetLevel("debug")
+	log.Printf("from the log package")
+	slog.Debug("debug after reload")
+
+	data, err := os.ReadFile(cfg.Log.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 log lines, got %d:\n%s", len(lines), data)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("log line is not JSON: %s", lines[0])
+	}
+	if entry["level"] != "WARN" || entry["msg"] != "Model stopped" || entry["model_name"] != "chat" || entry["request_id"] != "req-1" {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+	if !strings.Contains(lines[1], `"level":"INFO","msg":"from the log package"`) || !strings.Contains(lines[2], `"level":"DEBUG"`) {
+		t.Errorf("unexpected lines:\n%s", data)
+	}
+}
+
+func TestTagRequest(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/v1/models", nil)
+	req.Header.Set(RequestIDHeader, "client-id-42")
+	if id := RequestID(TagRequest(rec, req).Context()); id != "client-id-42" || rec.Header().Get(RequestIDHeader) != id {
+		t.Errorf("client request ID not kept: %q, header %q", id, rec.Header().Get(RequestIDHeader))
+	}
+
+	for _, invalid := range []string{"", "has space", "line\nbreak", strings.Repeat("x", maxRequestIDLength+1)} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/v1/chat/completions", nil)
+		req.Header.Set(RequestIDHeader, invalid)
+		id := RequestID(TagRequest(rec, req).Context())
+		if id == invalid || len(id) != 16 || rec.Header().Get(RequestIDHeader) != id {
+			t.Errorf("request ID for %q = %q, header %q", invalid, id, rec.Header().Get(RequestIDHeader))
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]slog.Level{
+		"debug": slog.LevelDebug, "INFO": slog.LevelInfo, "warn": slog.LevelWarn, "error": slog.LevelError, "bogus": slog.LevelInfo,
+	} {
+		if got := ParseLevel(name); got != want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
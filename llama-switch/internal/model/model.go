@@ -2,18 +2,34 @@ package model
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+
+	"llama-switch/internal/units"
 )
 
 // ModelConfig 模型服务配置
 type ModelConfig struct {
-	ModelPath string `json:"model_path"` // 模型文件路径
-	ModelName string `json:"model_name"` // 模型名称标识
-	ForceVRAM bool   `json:"force_vram"` // 是否强制使用显存
-	Config    struct {
+	ModelPath      string            `json:"model_path"`                // 模型文件路径
+	ModelName      string            `json:"model_name"`                // 模型名称标识
+	ForceVRAM      bool              `json:"force_vram"`                // 是否强制使用显存
+	ForceRAM       bool              `json:"force_ram,omitempty"`       // 主机内存不足时是否驱逐其他模型
+	Force          bool              `json:"force,omitempty"`           // 释放显存时是否驱逐正在使用的模型
+	Pinned         bool              `json:"pinned,omitempty"`          // 固定模型，释放显存时不会被驱逐
+	Transform      *TransformConfig  `json:"transform,omitempty"`       // 代理请求转换配置
+	Limits         *ResourceLimits   `json:"limits,omitempty"`          // 由switcher强制执行的进程资源限制
+	Env            map[string]string `json:"env,omitempty"`             // 为模型进程设置的环境变量（需在允许列表中）
+	ExtraArgs      []string          `json:"extra_args,omitempty"`      // 原样附加到llama-server命令行的参数（参数名需在允许列表中）
+	Stop           *StopConfig       `json:"stop,omitempty"`            // 停止模型进程的方式，未设置的字段使用全局配置
+	BackendProfile string            `json:"backend_profile,omitempty"` // 使用的llama-server构建（LLAMA_SERVER_PROFILES中的名称），为空时使用LLAMA_SERVER_PATH
+	RPCPool        string            `json:"rpc_pool,omitempty"`        // 使用的远程rpc-server池（RPC_POOLS中的名称），健康的端点作为--rpc传入
+	Tenant         string            `json:"tenant,omitempty"`          // 所属租户，由切换请求的API密钥确定（请求体中的值会被忽略）
+	NoDefaults     bool              `json:"no_defaults,omitempty"`     // 不使用全局默认配置填充config中未指定的参数
+	Config         struct {
 		// 服务器配置
-		Host    string `json:"host"`    // 监听地址
-		Port    int    `json:"port"`    // 服务端口
-		Timeout int    `json:"timeout"` // 超时时间（秒）
+		Host    string        `json:"host"`    // 监听地址
+		Port    int           `json:"port"`    // 服务端口
+		Timeout units.Seconds `json:"timeout"` // 超时时间（秒）
 
 		// 系统资源配置
 		Threads      int    `json:"threads"`       // 生成时的线程数
@@ -21,7 +37,7 @@ type ModelConfig struct {
 		CPUMask      string `json:"cpu_mask"`      // CPU亲和性掩码
 		CPURange     string `json:"cpu_range"`     // CPU范围
 		CPUStrict    int    `json:"cpu_strict"`    // CPU严格模式
-		Priority     int    `json:"priority"`      // 进程优先级
+		Priority     int    `json:"priority"`      // 进程优先级 (-1|0|1|2|3)
 		Poll         int    `json:"poll"`          // 轮询级别
 
 		// 模型参数
@@ -32,11 +48,11 @@ type ModelConfig struct {
 		Keep       int `json:"keep"`        // 保留初始提示的token数
 
 		// GPU相关配置
-		NGPULayers  int    `json:"n_gpu_layers"` // GPU层数
-		SplitMode   string `json:"split_mode"`   // GPU分割模式
-		TensorSplit string `json:"tensor_split"` // 张量分割比例
-		MainGPU     int    `json:"main_gpu"`     // 主GPU
-		Device      string `json:"device"`       // 设备列表
+		NGPULayers  GPULayers `json:"n_gpu_layers"` // GPU层数，"auto"表示按可用显存计算
+		SplitMode   string    `json:"split_mode"`   // GPU分割模式
+		TensorSplit string    `json:"tensor_split"` // 张量分割比例
+		MainGPU     int       `json:"main_gpu"`     // 主GPU
+		Device      string    `json:"device"`       // 设备列表
 
 		// 内存管理
 		Mlock       bool   `json:"mlock"`         // 锁定内存
@@ -201,8 +217,14 @@ type ModelConfig struct {
 
 // BenchmarkConfig 基准测试配置
 type BenchmarkConfig struct {
-	ModelPath string `json:"model_path"` // 模型文件路径
-	Config    struct {
+	ModelPath   string   `json:"model_path"`             // 模型文件路径
+	GPUs        []int    `json:"gpus,omitempty"`         // 使用的GPU编号，为空表示所有GPU；使用相同GPU的测试依次排队执行
+	Exclusive   string   `json:"exclusive,omitempty"`    // 独占GPU的方式：none（默认，与服务中的模型共享）、stop（停止模型，结束后重新启动）、pause（暂停模型进程，结束后继续）
+	Force       bool     `json:"force,omitempty"`        // 相同GPU上已有排队或运行中的测试时仍然提交（排在其后执行），否则返回冲突错误
+	WebhookURLs []string `json:"webhook_urls,omitempty"` // 任务结束（完成、失败或取消）时POST通知的地址，在BENCHMARK_WEBHOOK_URLS之外追加
+	Preset      string   `json:"preset,omitempty"`       // 预设名称（quick/standard/long-context/batch-throughput），config中未指定的提示、生成、深度、批处理和重复次数使用预设的值
+	RPCPool     string   `json:"rpc_pool,omitempty"`     // 使用的远程rpc-server池（RPC_POOLS中的名称），健康的端点作为--rpc传入，不能与config.rpc同时设置
+	Config      struct {
 		// 基本参数
 		NPrompt    int    `json:"n_prompt"`    // 提示token数量
 		NGen       int    `json:"n_gen"`       // 生成token数量
@@ -227,6 +249,7 @@ type BenchmarkConfig struct {
 		MainGPU     int    `json:"main_gpu"`      // 主GPU
 		NoKVOffload int    `json:"no_kv_offload"` // 禁用KV卸载 (0|1)
 		FlashAttn   int    `json:"flash_attn"`    // 启用Flash Attention (0|1)
+		RPC         string `json:"rpc"`           // 逗号分隔的rpc-server列表（host:port），为空时只使用本机设备
 
 		// 内存管理
 		Mmap int    `json:"mmap"` // 内存映射 (0|1)
@@ -238,9 +261,9 @@ type BenchmarkConfig struct {
 		OverrideTensors string `json:"override_tensors"` // 覆盖张量 (格式: "<tensor name pattern>=<buffer type>;...")
 
 		// 测试控制
-		Repetitions int `json:"repetitions"` // 重复次数
-		Priority    int `json:"priority"`    // 进程优先级 (0|1|2|3)
-		Delay       int `json:"delay"`       // 延迟（秒）
+		Repetitions int           `json:"repetitions"` // 重复次数
+		Priority    int           `json:"priority"`    // 进程优先级 (0|1|2|3)
+		Delay       units.Seconds `json:"delay"`       // 延迟（秒）
 
 		// 输出控制
 		Output    string `json:"output"`     // 输出格式 (csv|json|jsonl|md|sql)
@@ -259,30 +282,764 @@ type ModelInfo struct {
 
 // ModelStatus 模型服务状态
 type ModelStatus struct {
-	Running   bool   `json:"running"`    // 是否正在运行
-	ModelName string `json:"model_name"` // 模型名称标识
-	ModelPath string `json:"model_path"` // 当前运行的模型路径
-	Port      int    `json:"port"`       // 当前服务端口
-	StartTime string `json:"start_time"` // 服务启动时间
-	StopTime  string `json:"stop_time"`  // 服务停止时间
-	ProcessID int    `json:"process_id"` // 进程ID
-	VRAMUsage int    `json:"vram_usage"` // 显存使用量(MB)
+	Running      bool   `json:"running"`                   // 是否正在运行
+	ModelName    string `json:"model_name"`                // 模型名称标识
+	ModelPath    string `json:"model_path"`                // 当前运行的模型路径
+	Host         string `json:"host"`                      // 当前服务监听地址
+	Port         int    `json:"port"`                      // 当前服务端口
+	StartTime    string `json:"start_time"`                // 服务启动时间
+	StopTime     string `json:"stop_time"`                 // 服务停止时间
+	ProcessID    int    `json:"process_id"`                // 进程ID
+	VRAMUsage    int    `json:"vram_usage"`                // 显存使用量(MB)
+	VRAMMeasured bool   `json:"vram_measured,omitempty"`   // 显存使用量是否为GPU工具报告的实际值（否则为估算值）
+	WorkDir      string `json:"work_dir,omitempty"`        // 实例的工作目录
+	Backend      string `json:"backend_profile,omitempty"` // 使用的llama-server构建配置
+	GPUs         []int  `json:"gpus,omitempty"`            // 自动放置时分配的GPU编号
+	Pinned       bool   `json:"pinned,omitempty"`          // 是否为固定模型（不会被驱逐）
+	RPCPool      string `json:"rpc_pool,omitempty"`        // 使用的RPC池
+	RPCUsageMB   int    `json:"rpc_usage_mb,omitempty"`    // 估算的RPC池显存占用(MB)
+	Tenant       string `json:"tenant,omitempty"`          // 所属租户
+
+	Offload      *OffloadDecision `json:"offload,omitempty"`       // n_gpu_layers为auto时计算出的卸载层数
+	VRAMEstimate *VRAMEstimate    `json:"vram_estimate,omitempty"` // 启动前显存检查使用的估算
+
+	Health    *ModelHealth    `json:"health,omitempty"`    // 最近一次健康检查结果（仅运行中的模型）
+	Resources *ResourceSample `json:"resources,omitempty"` // 最近一次资源采样结果（仅运行中的模型）
+}
+
+// ResourceLimits 模型进程的资源限制，Linux通过cgroup v2、Windows通过Job Object强制执行
+type ResourceLimits struct {
+	CPUs        float64         `json:"cpus,omitempty"`         // 可使用的CPU核数（可为小数），0表示不限制
+	MemoryMB    units.Megabytes `json:"memory_mb,omitempty"`    // 内存上限(MB)，0表示不限制
+	CPUAffinity []int           `json:"cpu_affinity,omitempty"` // 允许运行的CPU编号，为空表示不限制
+}
+
+// StopConfig 停止模型进程的方式
+type StopConfig struct {
+	Signal      string        `json:"signal,omitempty"`       // 请求进程优雅退出的信号（SIGTERM/SIGINT/SIGQUIT/SIGHUP）
+	GracePeriod units.Seconds `json:"grace_period,omitempty"` // 等待进程退出的时间（秒），超时后强制结束
+}
+
+// CommandPreview 切换请求将使用的启动命令（dry run）
+type CommandPreview struct {
+	Binary   string   `json:"binary"`             // llama-server可执行文件路径
+	Args     []string `json:"args"`               // 命令行参数
+	Env      []string `json:"env"`                // 在switcher自身环境变量基础上追加的环境变量
+	Dir      string   `json:"dir"`                // 工作目录
+	Command  string   `json:"command"`            // 完整命令行
+	Download string   `json:"download,omitempty"` // 启动前将下载的Hugging Face模型文件
+	Warnings []string `json:"warnings,omitempty"` // 实际启动时可能失败的原因
+
+	VRAMEstimate  *VRAMEstimate    `json:"vram_estimate,omitempty"`   // 显存估算（模型文件可读时）
+	RAMEstimateMB int              `json:"ram_estimate_mb,omitempty"` // 主机内存估算(MB)（模型文件可读时）
+	Offload       *OffloadDecision `json:"offload,omitempty"`         // n_gpu_layers为auto时按当前可用显存计算的卸载层数
+}
+
+// VRAMEstimate 启动模型前的显存估算
+type VRAMEstimate struct {
+	Source          string `json:"source"`                     // 估算来源：measured/gguf/heuristic
+	TotalMB         int    `json:"total_mb"`                   // 估算的总显存(MB)
+	WeightsMB       int    `json:"weights_mb,omitempty"`       // 卸载到GPU的权重
+	KVCacheMB       int    `json:"kv_cache_mb,omitempty"`      // GPU上的KV缓存
+	ComputeMB       int    `json:"compute_mb,omitempty"`       // 计算缓冲区
+	OverheadMB      int    `json:"overhead_mb,omitempty"`      // GPU运行时自身的占用
+	Layers          int    `json:"layers,omitempty"`           // 模型层数
+	OffloadedLayers int    `json:"offloaded_layers,omitempty"` // 卸载到GPU的层数
+	ContextSize     int    `json:"context_size,omitempty"`     // 计算KV缓存使用的上下文大小
+	Error           string `json:"error,omitempty"`            // 无法读取GGUF元数据的原因（heuristic）
+}
+
+// GPULayersAuto n_gpu_layers为"auto"时的取值，启动时按可用显存计算能放下的最大层数
+const GPULayersAuto GPULayers = -1
+
+// GPULayers 卸载到GPU的层数，JSON中可以是整数或"auto"
+type GPULayers int
+
+// UnmarshalJSON 解析整数或"auto"
+func (n *GPULayers) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		if s != "auto" {
+			return fmt.Errorf("invalid n_gpu_layers: %q (should be a number or \"auto\")", s)
+		}
+		*n = GPULayersAuto
+		return nil
+	}
+	var v int
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("invalid n_gpu_layers: %s", data)
+	}
+	*n = GPULayers(v)
+	return nil
+}
+
+// MarshalJSON auto序列化为"auto"，其余为整数
+func (n GPULayers) MarshalJSON() ([]byte, error) {
+	if n == GPULayersAuto {
+		return []byte(`"auto"`), nil
+	}
+	return json.Marshal(int(n))
+}
+
+// OffloadDecision n_gpu_layers为auto时的计算结果
+type OffloadDecision struct {
+	Layers      int `json:"layers"`       // 卸载到GPU的层数（等于模型层数+1时输出层也在GPU上）
+	TotalLayers int `json:"total_layers"` // 模型层数
+	FreeVRAMMB  int `json:"free_vram_mb"` // 计算时的可用显存(MB)
+	VRAMMB      int `json:"vram_mb"`      // 按该层数估算的显存(MB)
+}
+
+// GPUInfo 单个GPU的状态
+type GPUInfo struct {
+	Index           int      `json:"index"`                      // GPU编号（与GPU工具的编号一致）
+	Name            string   `json:"name"`                       // GPU型号
+	MemoryTotalMB   int      `json:"memory_total_mb"`            // 总显存(MB)
+	MemoryUsedMB    int      `json:"memory_used_mb"`             // 已用显存(MB)
+	MemoryFreeMB    int      `json:"memory_free_mb"`             // 可用显存(MB)
+	TemperatureC    *float64 `json:"temperature_c,omitempty"`    // 温度（摄氏度），GPU工具不支持时省略
+	Utilization     *float64 `json:"utilization,omitempty"`      // 使用率（百分比），GPU工具不支持时省略
+	PowerW          *float64 `json:"power_w,omitempty"`          // 当前功耗（瓦），GPU工具不支持时省略
+	PowerLimitW     *float64 `json:"power_limit_w,omitempty"`    // 功耗上限（瓦），GPU工具不支持时省略
+	ThrottleReasons []string `json:"throttle_reasons,omitempty"` // 当前生效的降频原因（如hw_thermal_slowdown）
+	Models          []string `json:"models"`                     // 使用该GPU的受管模型
+}
+
+// RPCEndpointStatus RPC池中rpc-server端点的状态
+type RPCEndpointStatus struct {
+	Address   string `json:"address"`              // rpc-server地址（host:port）
+	MemoryMB  int    `json:"memory_mb"`            // 配置的可用显存(MB)
+	Healthy   bool   `json:"healthy"`              // 最近一次探测是否可以连接
+	CheckedAt string `json:"checked_at,omitempty"` // 最近一次探测时间
+	Error     string `json:"error,omitempty"`      // 探测失败的原因
+}
+
+// RPCPoolStatus RPC池的状态和容量
+type RPCPoolStatus struct {
+	Name      string              `json:"name"`      // 池名称
+	Endpoints []RPCEndpointStatus `json:"endpoints"` // 池中的端点
+	TotalMB   int                 `json:"total_mb"`  // 健康端点的显存总量(MB)
+	UsedMB    int                 `json:"used_mb"`   // 运行中和正在启动的模型估算占用的显存(MB)
+	FreeMB    int                 `json:"free_mb"`   // 可分配的显存(MB)
+	Models    []string            `json:"models"`    // 使用该池的运行中模型
+}
+
+// TenantStatus 租户的显存配额和使用情况
+type TenantStatus struct {
+	Name    string   `json:"name"`     // 租户名称
+	QuotaMB int      `json:"quota_mb"` // 显存配额(MB)，0表示不限制
+	UsedMB  int      `json:"used_mb"`  // 运行中和正在启动的模型占用的显存(MB)，包含RPC池中的部分
+	Models  []string `json:"models"`   // 租户的运行中模型
+}
+
+// ThermalState 基准测试期间GPU的温度、功耗和降频情况
+type ThermalState struct {
+	Samples          int      `json:"samples"`                     // 采样次数
+	MaxTemperatureC  *float64 `json:"max_temperature_c,omitempty"` // 所有GPU的最高温度（摄氏度）
+	MaxPowerW        *float64 `json:"max_power_w,omitempty"`       // 单个GPU的最高功耗（瓦）
+	ThrottledSamples int      `json:"throttled_samples"`           // 有GPU处于降频状态的采样次数
+	Throttled        bool     `json:"throttled"`                   // 是否出现持续降频（结果可能偏低）
+	ThrottleReasons  []string `json:"throttle_reasons,omitempty"`  // 出现过的降频原因
+}
+
+// GPUInventory GPU清单
+type GPUInventory struct {
+	Provider  string    `json:"provider"`   // 查询使用的GPU厂商工具
+	SampledAt string    `json:"sampled_at"` // 采样时间
+	GPUs      []GPUInfo `json:"gpus"`
+}
+
+// ResourceSample 模型进程的资源使用采样
+type ResourceSample struct {
+	Time        string  `json:"time"`          // 采样时间
+	CPUPercent  float64 `json:"cpu_percent"`   // CPU使用率（相对单核，多核时可超过100）
+	RSSMB       int     `json:"rss_mb"`        // 常驻内存(MB)
+	GPUMemoryMB int     `json:"gpu_memory_mb"` // 进程占用的显存(MB)，无法获取时为0
+}
+
+// VRAMSample 持久化的模型显存使用样本
+type VRAMSample struct {
+	Time      string `json:"time"`               // 采样时间
+	ProcessID int    `json:"process_id"`         // 实例进程ID，用于区分不同的运行
+	VRAMMB    int    `json:"vram_mb"`            // 进程占用的显存(MB)
+	CtxSize   int    `json:"ctx_size,omitempty"` // 实例的上下文大小
+	Parallel  int    `json:"parallel,omitempty"` // 实例的并行槽位数
+}
+
+// VRAMTrend 最近一次运行的显存使用趋势
+type VRAMTrend struct {
+	Since           string  `json:"since"`              // 最近一次运行的第一条样本时间
+	Samples         int     `json:"samples"`            // 最近一次运行的样本数
+	MinMB           int     `json:"min_mb"`             // 最小显存占用(MB)
+	MaxMB           int     `json:"max_mb"`             // 最大显存占用(MB)
+	AvgMB           int     `json:"avg_mb"`             // 平均显存占用(MB)
+	LatestMB        int     `json:"latest_mb"`          // 最近一次的显存占用(MB)
+	GrowthMBPerHour float64 `json:"growth_mb_per_hour"` // 按线性回归计算的每小时增长(MB)
+}
+
+// ModelHealth 模型实例健康检查结果
+type ModelHealth struct {
+	Status              string `json:"status"`                     // 健康状态：healthy/loading/unhealthy
+	LastCheck           string `json:"last_check"`                 // 最近一次探测时间
+	LastHealthy         string `json:"last_healthy,omitempty"`     // 最近一次健康的时间
+	Error               string `json:"error,omitempty"`            // 最近一次探测失败的原因
+	ConsecutiveFailures int    `json:"consecutive_failures"`       // 连续探测失败次数
+	SlotsTotal          int    `json:"slots_total,omitempty"`      // 插槽总数
+	SlotsProcessing     int    `json:"slots_processing,omitempty"` // 正在处理请求的插槽数
+}
+
+// Event 运行事件（如看门狗处理卡死的实例），按行记录在事件日志中
+type Event struct {
+	Time      string      `json:"time"`                 // 事件时间
+	Type      string      `json:"type"`                 // 事件类型
+	ModelName string      `json:"model_name,omitempty"` // 相关的模型
+	Message   string      `json:"message"`              // 事件描述
+	Data      interface{} `json:"data,omitempty"`       // 事件详情
+}
+
+// AuditEntry 审计日志中的一条修改类API请求（切换、停止、配置修改、启动基准测试等）
+type AuditEntry struct {
+	Time       string                 `json:"time"`                 // 收到请求的时间
+	Caller     string                 `json:"caller"`               // 调用方身份：api_key、key:<名称>、tenant:<名称>、jwt:<subject>，未认证时为anonymous
+	Role       string                 `json:"role,omitempty"`       // 调用方的角色，未启用认证或认证失败时为空
+	ClientIP   string                 `json:"client_ip"`            // 客户端IP
+	Method     string                 `json:"method"`               // 请求方法
+	Path       string                 `json:"path"`                 // 请求路径（包括查询参数）
+	Route      string                 `json:"route"`                // 匹配到的路由
+	ModelName  string                 `json:"model_name,omitempty"` // 操作的模型
+	Request    map[string]interface{} `json:"request,omitempty"`    // 请求体摘要，密钥类字段已隐藏
+	Status     int                    `json:"status"`               // 响应状态码
+	Outcome    string                 `json:"outcome"`              // 结果：success、denied（401/403）或failure
+	Code       string                 `json:"code,omitempty"`       // 失败时的错误码
+	Error      string                 `json:"error,omitempty"`      // 失败时的错误信息
+	DurationMS int64                  `json:"duration_ms"`          // 处理耗时（毫秒）
+}
+
+// WebhookRequest 注册事件订阅的请求
+type WebhookRequest struct {
+	URL         string   `json:"url"`                   // 接收通知的地址（http或https）
+	Events      []string `json:"events"`                // 订阅的事件类型，为空表示所有事件
+	Secret      string   `json:"secret,omitempty"`      // 签名密钥，为空时自动生成
+	Description string   `json:"description,omitempty"` // 说明
+}
+
+// WebhookSubscription 事件订阅：事件发生时向URL POST带HMAC签名的通知
+type WebhookSubscription struct {
+	ID           string           `json:"id"`                      // 订阅ID
+	URL          string           `json:"url"`                     // 接收通知的地址
+	Events       []string         `json:"events"`                  // 订阅的事件类型，为空表示所有事件
+	Secret       string           `json:"secret,omitempty"`        // 签名密钥，只在注册时返回
+	Description  string           `json:"description,omitempty"`   // 说明
+	CreatedAt    string           `json:"created_at"`              // 注册时间
+	Delivered    int              `json:"delivered"`               // 本次运行中投递成功的通知数
+	Failed       int              `json:"failed"`                  // 本次运行中重试后仍失败的通知数
+	LastDelivery *WebhookDelivery `json:"last_delivery,omitempty"` // 最近一条通知的投递状态
+}
+
+// WebhookDelivery 一条通知的投递状态
+type WebhookDelivery struct {
+	ID          string `json:"id"`                     // 通知ID（X-Webhook-Delivery请求头）
+	Event       string `json:"event"`                  // 事件类型
+	Status      string `json:"status"`                 // 投递状态：pending/delivered/failed
+	Attempts    int    `json:"attempts"`               // 已尝试的次数
+	StatusCode  int    `json:"status_code,omitempty"`  // 最近一次尝试的响应状态码
+	Error       string `json:"error,omitempty"`        // 最近一次尝试的失败原因
+	CreatedAt   string `json:"created_at"`             // 事件发生时间
+	LastAttempt string `json:"last_attempt,omitempty"` // 最近一次尝试的时间
+	NextAttempt string `json:"next_attempt,omitempty"` // 下次重试的时间（pending时）
+}
+
+// SystemEvent 事件总线上的一条系统事件，通过/api/v1/events的SSE流推送
+type SystemEvent struct {
+	ID    int64           `json:"id"`             // 递增的事件序号（SSE的id字段）
+	Event string          `json:"event"`          // 事件类型，与事件订阅的类型相同
+	Time  string          `json:"time"`           // 事件时间
+	Data  json.RawMessage `json:"data,omitempty"` // 事件详情，与事件订阅通知的data相同
+}
+
+// WatchdogSnapshot 看门狗结束卡死实例前采集的诊断信息
+type WatchdogSnapshot struct {
+	ProcessID    int          `json:"process_id"`              // 实例进程ID
+	Health       *ModelHealth `json:"health"`                  // 最近一次健康检查结果
+	LastLogs     []string     `json:"last_logs,omitempty"`     // 实例日志的最后几行
+	Metrics      string       `json:"metrics,omitempty"`       // /metrics的输出（启用metrics时）
+	MetricsError string       `json:"metrics_error,omitempty"` // 获取/metrics失败的原因
 }
 
 // ModelStopRequest 停止模型请求
 type ModelStopRequest struct {
-	ModelName string `json:"model_name"` // 模型名称标识
+	ModelName    string        `json:"model_name"`    // 模型名称标识
+	Force        bool          `json:"force"`         // 是否立即停止，跳过切换保护和排空
+	Drain        bool          `json:"drain"`         // 是否先等待进行中的请求完成再停止
+	DrainTimeout units.Seconds `json:"drain_timeout"` // 排空超时时间（秒），0表示使用默认值
+}
+
+// TimeShareConfig 分时共享组配置
+type TimeShareConfig struct {
+	Name     string         `json:"name"`     // 共享组名称
+	Models   []*ModelConfig `json:"models"`   // 交替驻留的两个模型配置
+	Interval units.Seconds  `json:"interval"` // 定时切换间隔（秒），0表示仅按需切换
+}
+
+// TimeShareStatus 分时共享组状态
+type TimeShareStatus struct {
+	Name     string   `json:"name"`      // 共享组名称
+	Models   []string `json:"models"`    // 组内模型名称
+	Resident string   `json:"resident"`  // 当前驻留的模型
+	Interval int      `json:"interval"`  // 定时切换间隔（秒）
+	Swaps    int      `json:"swaps"`     // 累计切换次数
+	LastSwap string   `json:"last_swap"` // 最后一次切换时间
+}
+
+// TimeShareSwapRequest 分时共享切换请求
+type TimeShareSwapRequest struct {
+	Name      string `json:"name"`       // 共享组名称
+	ModelName string `json:"model_name"` // 目标模型（为空时切换到另一个模型）
+}
+
+// TransformConfig 代理请求转换配置，在转发到llama-server前改写JSON请求体
+type TransformConfig struct {
+	SystemPrompt string   `json:"system_prompt,omitempty"` // 替换chat请求的系统提示（没有系统提示时插入到最前）
+	Stop         []string `json:"stop,omitempty"`          // 追加的停止序列
+	StripFields  []string `json:"strip_fields,omitempty"`  // 转发前删除的顶层字段
+	WebhookURL   string   `json:"webhook_url,omitempty"`   // 外部转换服务地址，接收请求体并返回改写后的请求体
+}
+
+// RouteConfig 流量路由规则：将逻辑模型名称的部分流量分配给新版本模型
+type RouteConfig struct {
+	Name    string `json:"name"`    // 逻辑模型名称（请求中的model字段）
+	Primary string `json:"primary"` // 当前版本模型
+	Canary  string `json:"canary"`  // 新版本模型
+	Weight  int    `json:"weight"`  // 分配给新版本的流量百分比（0-100）
+	Shadow  bool   `json:"shadow"`  // 影子模式：始终由当前版本响应，按比例复制请求到新版本且丢弃其响应
+}
+
+// RouteTargetStats 路由目标的请求统计
+type RouteTargetStats struct {
+	Model           string  `json:"model"`             // 模型名称
+	Role            string  `json:"role"`              // 角色：primary/canary
+	Requests        int64   `json:"requests"`          // 请求数
+	Errors          int64   `json:"errors"`            // 失败请求数（5xx或连接失败）
+	AvgLatencyMS    float64 `json:"avg_latency_ms"`    // 平均延迟（毫秒）
+	P95LatencyMS    float64 `json:"p95_latency_ms"`    // P95延迟（毫秒，基于最近的请求）
+	TokensPerSecond float64 `json:"tokens_per_second"` // 生成吞吐量（基于响应中的usage.completion_tokens）
+}
+
+// RouteStatus 流量路由规则状态
+type RouteStatus struct {
+	RouteConfig
+	Stats     []*RouteTargetStats `json:"stats"`      // 各目标的统计
+	CreatedAt string              `json:"created_at"` // 规则创建时间
+}
+
+// DownloadStatus 模型文件下载状态
+type DownloadStatus struct {
+	Repo       string  `json:"repo"`               // Hugging Face仓库
+	File       string  `json:"file"`               // 仓库中的文件
+	Path       string  `json:"path"`               // 本地保存路径
+	Status     string  `json:"status"`             // 下载状态：queued/downloading/completed/failed
+	Downloaded int64   `json:"downloaded"`         // 已下载字节数
+	Total      int64   `json:"total"`              // 文件总字节数（未知时为0）
+	Progress   float64 `json:"progress"`           // 进度（0-100）
+	StartTime  string  `json:"start_time"`         // 开始时间
+	EndTime    string  `json:"end_time,omitempty"` // 结束时间
+	Error      string  `json:"error,omitempty"`    // 错误信息
+}
+
+// Operation 异步操作（async=true的切换请求）的状态
+type Operation struct {
+	ID        string       `json:"id"`                  // 操作ID
+	Type      string       `json:"type"`                // 操作类型：switch
+	ModelName string       `json:"model_name"`          // 模型名称
+	State     string       `json:"state"`               // 状态：pending/loading/ready/failed
+	Stage     string       `json:"stage,omitempty"`     // 从实例输出识别的加载阶段
+	Progress  float64      `json:"progress"`            // 按加载阶段估算的进度（0-100）
+	LastLog   string       `json:"last_log,omitempty"`  // 实例最近输出的一行
+	CreatedAt string       `json:"created_at"`          // 创建时间
+	EndTime   string       `json:"end_time,omitempty"`  // 结束时间
+	LoadTime  string       `json:"load_time,omitempty"` // 加载耗时（ready时）
+	Model     *ModelStatus `json:"model,omitempty"`     // 就绪的模型状态（ready时）
+	Error     *Problem     `json:"error,omitempty"`     // 失败原因，与同步切换的错误响应相同（failed时）
+}
+
+// ModelDefinition 模型定义目录（models.d）中的声明式模型定义，字段与切换请求相同
+type ModelDefinition struct {
+	ModelConfig
+	Autostart bool   `json:"autostart,omitempty"` // switcher启动时和定义变化后自动启动
+	File      string `json:"file,omitempty"`      // 定义所在的文件（由switcher填写）
+}
+
+// ModelDefinitionError 无法加载的模型定义文件
+type ModelDefinitionError struct {
+	File  string `json:"file"`  // 定义文件
+	Error string `json:"error"` // 错误原因
+}
+
+// ModelDefinitionList 模型定义目录中已加载的定义
+type ModelDefinitionList struct {
+	Dir         string                  `json:"dir"`              // 模型定义目录
+	Definitions []*ModelDefinition      `json:"definitions"`      // 按模型名称排序的定义
+	Errors      []*ModelDefinitionError `json:"errors,omitempty"` // 无法加载的文件，之前加载成功的定义继续有效
+}
+
+// AliasUpdateRequest 设置或重新指向别名的请求
+type AliasUpdateRequest struct {
+	Alias  string `json:"alias"`  // 别名，如default-chat
+	Target string `json:"target"` // 指向的实际模型名称
+	Author string `json:"author"` // 变更人（为空时使用请求来源地址）
+	Reason string `json:"reason"` // 变更原因
+}
+
+// AliasInfo 别名当前指向
+type AliasInfo struct {
+	Alias     string `json:"alias"`      // 别名
+	Target    string `json:"target"`     // 指向的实际模型名称
+	UpdatedAt string `json:"updated_at"` // 最后变更时间
+}
+
+// AliasChange 别名变更记录
+type AliasChange struct {
+	Alias  string `json:"alias"`  // 别名
+	From   string `json:"from"`   // 变更前指向的模型（首次创建时为空）
+	To     string `json:"to"`     // 变更后指向的模型（删除时为空）
+	Author string `json:"author"` // 变更人
+	Reason string `json:"reason"` // 变更原因
+	Time   string `json:"time"`   // 变更时间
+}
+
+// EnvVarInfo 一个可识别的环境变量及其对应配置项的当前值
+type EnvVarInfo struct {
+	Name        string `json:"name"`        // 环境变量名
+	Field       string `json:"field"`       // 对应的配置项，与配置文件中的字段路径相同
+	Description string `json:"description"` // 说明
+	Value       string `json:"value"`       // 当前生效的值（环境变量的写法），密钥以******代替
+	Source      string `json:"source"`      // 值的来源：default/file/env/runtime
+}
+
+// DefaultsUpdateRequest 修改默认模型配置的请求
+type DefaultsUpdateRequest struct {
+	Defaults map[string]interface{} `json:"defaults"` // 修改的配置项，结构与配置文件相同（如{"default_model":{"ctx_size":8192}}）
+	Author   string                 `json:"author"`   // 客户端填写的修改人，只作为备注记录
+	Reason   string                 `json:"reason"`   // 修改原因
+	Caller   string                 `json:"-"`        // 认证得到的调用方身份，由处理器设置
+}
+
+// DefaultsChange 默认模型配置的修改记录
+type DefaultsChange struct {
+	Changes []ConfigChange `json:"changes"`        // 变化的配置项
+	Author  string         `json:"author"`         // 修改人（认证得到的调用方身份，与审计日志的caller相同）
+	Note    string         `json:"note,omitempty"` // 客户端在请求中填写的修改人
+	Reason  string         `json:"reason"`         // 修改原因
+	Time    string         `json:"time"`           // 修改时间
+}
+
+// ModelDefaults 当前的默认模型配置和运行时的修改记录
+type ModelDefaults struct {
+	Defaults  map[string]interface{} `json:"defaults"`  // 切换时用于填充未指定参数的配置项，按字段路径索引
+	Overrides map[string]interface{} `json:"overrides"` // 运行时修改并持久化的覆盖项
+	File      string                 `json:"file"`      // 覆盖项的持久化文件
+	Changelog []*DefaultsChange      `json:"changelog"` // 修改记录（按时间顺序）
+}
+
+// Capabilities 部署的功能发现信息，客户端据此调整界面而不必探测接口
+type Capabilities struct {
+	Version         string                         `json:"version"`                    // switcher版本
+	Platform        string                         `json:"platform"`                   // 运行平台（os/arch）
+	GPUVendors      []string                       `json:"gpu_vendors"`                // 检测到的GPU厂商
+	GPUProvider     string                         `json:"gpu_provider"`               // 显存查询使用的GPU厂商工具
+	LlamaServer     *LlamaServerVersion            `json:"llama_server,omitempty"`     // 检测到的llama-server版本
+	BackendProfiles map[string]*LlamaServerVersion `json:"backend_profiles,omitempty"` // 各llama-server构建配置检测到的版本
+	Features        map[string]bool                `json:"features"`                   // 可选子系统是否启用
+}
+
+// LlamaServerVersion llama-server的构建信息
+type LlamaServerVersion struct {
+	Build     int    `json:"build"`                // 构建号
+	Commit    string `json:"commit"`               // 提交哈希
+	BuiltWith string `json:"built_with,omitempty"` // 编译器和目标平台
 }
 
 // BenchmarkStatus 基准测试状态
 type BenchmarkStatus struct {
 	TaskID     string              `json:"task_id"`               // 任务ID
-	Status     string              `json:"status"`                // 任务状态：pending/running/completed/failed/cancelled
+	Status     string              `json:"status"`                // 任务状态：queued/running/completed/failed/cancelled
 	Progress   float64             `json:"progress"`              // 进度（0-100）
 	StartTime  string              `json:"start_time"`            // 开始时间
 	EndTime    string              `json:"end_time"`              // 结束时间（如果已完成）
-	AllResults []*BenchmarkResults `json:"all_results,omitempty"` // 所有测试结果
+	AllResults []*BenchmarkResults `json:"all_results,omitempty"` // 所有测试结果（已弃用，建议查询时指定results=grouped获取models）
+	Manifest   *BenchmarkManifest  `json:"manifest,omitempty"`    // 可复现清单
+	Thermal    *ThermalState       `json:"thermal,omitempty"`     // 测试期间的GPU温度和降频情况
 	CancelFunc context.CancelFunc  `json:"-"`                     // 取消函数（不序列化）
+
+	QueuePosition   int      `json:"queue_position,omitempty"`   // 排队位置（从1开始，仅queued状态）
+	DisplacedModels []string `json:"displaced_models,omitempty"` // 为独占GPU而停止或暂停的模型
+
+	Serving []*ServingBenchmarkLevel `json:"serving,omitempty"` // 服务基准测试结果（仅服务基准测试）
+
+	Baseline *BaselineComparison `json:"baseline,omitempty"` // 与该模型固定基线的比较（模型有基线且测试完成时）
+
+	Models []*BenchmarkModelResult `json:"models,omitempty"` // 按模型分组的测试结果（查询时指定results=grouped，此时省略all_results）
+}
+
+// BenchmarkPreset 基准测试预设：展开为llama-bench的参数组合
+type BenchmarkPreset struct {
+	Name        string `json:"name"`                  // 预设名称
+	Description string `json:"description"`           // 说明
+	NPrompt     []int  `json:"n_prompt,omitempty"`    // 提示token数量
+	NGen        []int  `json:"n_gen,omitempty"`       // 生成token数量
+	NDepth      []int  `json:"n_depth,omitempty"`     // KV缓存深度
+	BatchSize   []int  `json:"batch_size,omitempty"`  // 批处理大小
+	UBatchSize  []int  `json:"ubatch_size,omitempty"` // 微批处理大小
+	Repetitions int    `json:"repetitions"`           // 重复次数
+}
+
+// BenchmarkModelResult 按模型分组的llama-bench测试结果
+type BenchmarkModelResult struct {
+	Model       string               `json:"model"`        // 模型名称
+	Size        string               `json:"size"`         // 模型大小
+	Params      string               `json:"params"`       // 模型参数
+	Backend     string               `json:"backend"`      // 使用的后端
+	GPULayers   int                  `json:"gpu_layers"`   // GPU层数
+	MMap        bool                 `json:"mmap"`         // 是否使用内存映射
+	TestResults []BenchmarkTestEntry `json:"test_results"` // 该模型的各项测试（pp、tg等）
+}
+
+// BenchmarkTestEntry 分组中的单条测试结果
+type BenchmarkTestEntry struct {
+	TestType        string  `json:"test_type"`         // 测试类型，如pp512、tg128
+	TokensPerSecond float64 `json:"tokens_per_second"` // 每秒处理的token数
+	Variation       float64 `json:"variation"`         // 性能波动
+}
+
+// ServingBenchmarkConfig 端到端服务基准测试配置：通过llama-server加载模型并发送并发的流式补全请求
+type ServingBenchmarkConfig struct {
+	ModelPath    string      `json:"model_path"`             // 模型文件路径
+	GPUs         []int       `json:"gpus,omitempty"`         // 使用的GPU编号，与BenchmarkConfig相同
+	Exclusive    string      `json:"exclusive,omitempty"`    // 独占GPU的方式，与BenchmarkConfig相同
+	Force        bool        `json:"force,omitempty"`        // 相同GPU上已有测试时仍然提交，与BenchmarkConfig相同
+	WebhookURLs  []string    `json:"webhook_urls,omitempty"` // 任务结束时POST通知的地址，与BenchmarkConfig相同
+	Concurrency  []int       `json:"concurrency"`            // 依次测试的并发请求数，默认[1, 4]
+	PromptTokens []int       `json:"prompt_tokens"`          // 依次测试的提示长度（token），默认[512]
+	MaxTokens    int         `json:"max_tokens"`             // 每个请求生成的token数，默认128
+	Requests     int         `json:"requests"`               // 每组并发数和提示长度发送的请求数，默认为并发数的4倍
+	Server       ModelConfig `json:"server"`                 // llama-server启动参数，与切换模型请求相同（model_path、host和port被忽略）
+}
+
+// LatencyStats 延迟统计（毫秒）
+type LatencyStats struct {
+	Mean float64 `json:"mean"` // 平均值
+	P50  float64 `json:"p50"`  // 50百分位
+	P90  float64 `json:"p90"`  // 90百分位
+	P99  float64 `json:"p99"`  // 99百分位
+	Max  float64 `json:"max"`  // 最大值
+}
+
+// ServingBenchmarkLevel 一组并发数和提示长度的服务基准测试结果
+type ServingBenchmarkLevel struct {
+	Concurrency           int          `json:"concurrency"`              // 并发请求数
+	PromptTokens          int          `json:"prompt_tokens"`            // 提示长度（token）
+	Requests              int          `json:"requests"`                 // 发送的请求数
+	Failed                int          `json:"failed"`                   // 失败的请求数
+	DurationSec           float64      `json:"duration_sec"`             // 总耗时（秒）
+	OutputTokens          int          `json:"output_tokens"`            // 成功请求生成的token总数
+	OutputTokensPerSecond float64      `json:"output_tokens_per_second"` // 负载下的总生成吞吐量
+	RequestsPerSecond     float64      `json:"requests_per_second"`      // 每秒完成的成功请求数
+	TTFT                  LatencyStats `json:"ttft_ms"`                  // 首token延迟
+	ITL                   LatencyStats `json:"itl_ms"`                   // token间延迟
+	Latency               LatencyStats `json:"latency_ms"`               // 请求总延迟
+	Errors                []string     `json:"errors,omitempty"`         // 失败原因（去重，最多保留几条）
+}
+
+// BenchmarkSchedule 定时基准测试
+type BenchmarkSchedule struct {
+	Name         string          `json:"name"`                    // 名称
+	Cron         string          `json:"cron"`                    // cron表达式（分 时 日 月 周），或@hourly/@daily/@weekly/@monthly
+	Benchmark    BenchmarkConfig `json:"benchmark"`               // 测试配置，与POST /api/v1/benchmark的请求体相同
+	ThresholdPct float64         `json:"threshold_pct,omitempty"` // 吞吐量低于滚动基线超过该百分比时通知，0表示不检查
+	BaselineRuns int             `json:"baseline_runs,omitempty"` // 滚动基线包含的最近成功次数（默认5）
+	WebhookURL   string          `json:"webhook_url,omitempty"`   // 吞吐量下降时POST通知的地址
+	Email        []string        `json:"email,omitempty"`         // 吞吐量下降时通知的邮箱（需配置SMTP）
+}
+
+// BenchmarkScheduleStatus 定时基准测试及其最近一次运行情况
+type BenchmarkScheduleStatus struct {
+	BenchmarkSchedule
+	NextRun    string                    `json:"next_run"`               // 下次运行时间
+	LastRun    string                    `json:"last_run,omitempty"`     // 最近一次运行时间
+	LastTaskID string                    `json:"last_task_id,omitempty"` // 最近一次运行的任务ID
+	LastStatus string                    `json:"last_status,omitempty"`  // 最近一次运行的任务状态
+	LastError  string                    `json:"last_error,omitempty"`   // 最近一次运行的错误
+	LastAlert  *BenchmarkRegressionAlert `json:"last_alert,omitempty"`   // 最近一次性能下降通知
+}
+
+// BenchmarkRegression 单个测试类型相对滚动基线的吞吐量下降
+type BenchmarkRegression struct {
+	TestType        string  `json:"test_type"`         // 测试类型
+	BaselineTPS     float64 `json:"baseline_tps"`      // 滚动基线的平均吞吐量
+	TokensPerSecond float64 `json:"tokens_per_second"` // 本次吞吐量
+	DropPct         float64 `json:"drop_pct"`          // 下降百分比
+	BaselineRuns    int     `json:"baseline_runs"`     // 基线包含的运行次数
+}
+
+// BenchmarkRegressionAlert 定时基准测试的性能下降通知
+type BenchmarkRegressionAlert struct {
+	Schedule    string                `json:"schedule"`    // 定时任务名称
+	TaskID      string                `json:"task_id"`     // 本次运行的任务ID
+	ModelPath   string                `json:"model_path"`  // 模型文件路径
+	Time        string                `json:"time"`        // 检测时间
+	Regressions []BenchmarkRegression `json:"regressions"` // 超过阈值的吞吐量下降
+}
+
+// BenchmarkRecord 持久化的已结束基准测试任务
+type BenchmarkRecord struct {
+	TaskID      string              `json:"task_id"`           // 任务ID
+	Status      string              `json:"status"`            // 任务状态：completed/failed/cancelled
+	ModelPath   string              `json:"model_path"`        // 模型文件路径
+	Config      *BenchmarkConfig    `json:"config,omitempty"`  // 请求的测试配置（复现的任务没有）
+	Args        []string            `json:"args"`              // llama-bench命令行参数
+	StartTime   string              `json:"start_time"`        // 开始时间
+	EndTime     string              `json:"end_time"`          // 结束时间
+	DurationSec float64             `json:"duration_sec"`      // 运行时长（秒）
+	Output      string              `json:"output,omitempty"`  // llama-bench的原始输出
+	Error       string              `json:"error,omitempty"`   // 失败原因
+	Results     []*BenchmarkResults `json:"results,omitempty"` // 解析后的测试结果
+	Thermal     *ThermalState       `json:"thermal,omitempty"` // 测试期间的GPU温度和降频情况
+
+	Serving     []*ServingBenchmarkLevel `json:"serving,omitempty"`     // 服务基准测试结果（仅服务基准测试）
+	Environment *BenchmarkEnvironment    `json:"environment,omitempty"` // 测试开始时的硬件和构建环境
+	RPCWorkers  []string                 `json:"rpc_workers,omitempty"` // 参与测试的rpc-server，为空表示单机测试
+	Baseline    *BaselineComparison      `json:"baseline,omitempty"`    // 与该模型固定基线的比较
+}
+
+// BenchmarkBaseline 固定为某个模型基线的已完成测试
+type BenchmarkBaseline struct {
+	ModelPath string              `json:"model_path"` // 模型文件路径
+	TaskID    string              `json:"task_id"`    // 作为基线的任务ID
+	PinnedAt  string              `json:"pinned_at"`  // 固定时间
+	Args      []string            `json:"args"`       // 基线任务的llama-bench命令行参数
+	Results   []*BenchmarkResults `json:"results"`    // 基线任务的测试结果
+}
+
+// BaselineComparison 测试结果与固定基线的比较
+type BaselineComparison struct {
+	TaskID       string          `json:"task_id"`       // 基线任务ID
+	ThresholdPct float64         `json:"threshold_pct"` // 判定为性能下降的百分比阈值
+	Deltas       []BaselineDelta `json:"deltas"`        // 各测试类型的变化，基线中没有的测试类型不比较
+	Regressed    bool            `json:"regressed"`     // 是否有测试类型的吞吐量下降超过阈值
+}
+
+// BaselineDelta 单个测试类型相对固定基线的吞吐量变化
+type BaselineDelta struct {
+	TestType        string  `json:"test_type"`         // 测试类型
+	TokensPerSecond float64 `json:"tokens_per_second"` // 本次吞吐量
+	BaselineTPS     float64 `json:"baseline_tps"`      // 基线吞吐量
+	DeltaPct        float64 `json:"delta_pct"`         // 变化百分比，负数表示下降
+	Regression      bool    `json:"regression"`        // 下降是否超过阈值
+}
+
+// BenchmarkExport 导出的已结束基准测试结果，包含设备和构建信息
+type BenchmarkExport struct {
+	TaskID        string                   `json:"task_id"`                  // 任务ID
+	Status        string                   `json:"status"`                   // 任务状态：completed/failed/cancelled
+	ModelPath     string                   `json:"model_path"`               // 模型文件路径
+	StartTime     string                   `json:"start_time"`               // 开始时间
+	EndTime       string                   `json:"end_time"`                 // 结束时间
+	Build         BenchmarkBuildInfo       `json:"build"`                    // llama.cpp构建信息
+	Devices       []string                 `json:"devices,omitempty"`        // GPU设备
+	Backends      []string                 `json:"backends,omitempty"`       // 加载的后端
+	DriverVersion string                   `json:"driver_version,omitempty"` // GPU驱动版本
+	CUDAVersion   string                   `json:"cuda_version,omitempty"`   // 驱动支持的CUDA版本
+	Host          *HostInfo                `json:"host,omitempty"`           // 主机信息（来自环境快照）
+	Args          []string                 `json:"args,omitempty"`           // 命令行参数
+	Error         string                   `json:"error,omitempty"`          // 失败原因
+	Results       []*BenchmarkResults      `json:"results,omitempty"`        // llama-bench测试结果
+	Serving       []*ServingBenchmarkLevel `json:"serving,omitempty"`        // 服务基准测试结果
+}
+
+// BenchmarkBuildInfo llama.cpp构建信息
+type BenchmarkBuildInfo struct {
+	Commit  string `json:"commit,omitempty"`  // 提交哈希（来自llama-bench输出）
+	Number  string `json:"number,omitempty"`  // 构建号（来自llama-bench输出）
+	Version string `json:"version,omitempty"` // llama-server --version报告的版本（来自环境快照）
+}
+
+// BenchmarkManifest 基准测试可复现清单，记录测试时的完整环境
+type BenchmarkManifest struct {
+	TaskID          string              `json:"task_id"`                   // 任务ID
+	CreatedAt       string              `json:"created_at"`                // 创建时间
+	ReproducedFrom  string              `json:"reproduced_from,omitempty"` // 复现的原任务ID
+	SwitcherVersion string              `json:"switcher_version"`          // switcher版本
+	ModelPath       string              `json:"model_path"`                // 模型文件路径
+	ModelSize       int64               `json:"model_size"`                // 模型文件大小（字节）
+	Quantization    string              `json:"quantization"`              // 量化类型（从文件名推断）
+	Binary          string              `json:"binary"`                    // llama-bench路径（服务基准测试为llama-server路径）
+	VisibleGPUs     []int               `json:"visible_gpus,omitempty"`    // 测试使用的GPU编号（为空表示所有GPU）
+	RPCPool         string              `json:"rpc_pool,omitempty"`        // 使用的RPC池
+	RPCWorkers      []string            `json:"rpc_workers,omitempty"`     // 参与测试的rpc-server（从--rpc参数获取，为空表示单机测试）
+	Args            []string            `json:"args"`                      // 完整命令行参数
+	Results         []*BenchmarkResults `json:"results,omitempty"`         // 测试结果（仅保存在清单文件中）
+	Thermal         *ThermalState       `json:"thermal,omitempty"`         // 测试期间的GPU温度和降频情况（仅保存在清单文件中）
+
+	Serving *ServingBenchmarkConfig `json:"serving,omitempty"` // 服务基准测试配置（仅服务基准测试，Binary为llama-server）
+
+	BenchmarkEnvironment // 测试开始时的环境快照（字段平铺在清单中）
+}
+
+// BenchmarkEnvironment 测试时的硬件和构建环境快照，随结果保存以便跨硬件或构建比较历史结果
+type BenchmarkEnvironment struct {
+	LlamaCppBuild string   `json:"llama_cpp_build"`        // llama.cpp构建版本
+	GPUs          []string `json:"gpus"`                   // GPU型号
+	DriverVersion string   `json:"driver_version"`         // GPU驱动版本
+	CUDAVersion   string   `json:"cuda_version,omitempty"` // 驱动支持的CUDA版本（NVIDIA）
+	Host          HostInfo `json:"host"`                   // 主机信息
+}
+
+// HostInfo 主机信息
+type HostInfo struct {
+	Hostname string `json:"hostname"`            // 主机名
+	OS       string `json:"os"`                  // 操作系统
+	Arch     string `json:"arch"`                // CPU架构
+	CPUs     int    `json:"cpus"`                // 逻辑CPU数
+	CPUModel string `json:"cpu_model,omitempty"` // CPU型号
+	MemoryMB int    `json:"memory_mb,omitempty"` // 物理内存总量(MB)
+}
+
+// BenchmarkReproduceRequest 复现基准测试请求
+type BenchmarkReproduceRequest struct {
+	TaskID string `json:"task_id"` // 要复现的任务ID
+}
+
+// BenchmarkSuite 对模型目录下所有模型依次运行的标准基准测试
+type BenchmarkSuite struct {
+	SuiteID     string                       `json:"suite_id"`              // 套件ID
+	Status      string                       `json:"status"`                // 套件状态：running/completed/cancelled
+	StartTime   string                       `json:"start_time"`            // 开始时间
+	EndTime     string                       `json:"end_time"`              // 结束时间（如果已结束）
+	Models      []*BenchmarkSuiteModel       `json:"models"`                // 各模型的测试情况，按执行顺序排列
+	Leaderboard []*BenchmarkLeaderboardEntry `json:"leaderboard,omitempty"` // 已完成模型的排行榜（查询时生成）
+}
+
+// BenchmarkSuiteModel 套件中单个模型的测试情况
+type BenchmarkSuiteModel struct {
+	Name              string  `json:"name"`                 // 模型文件名
+	ModelPath         string  `json:"model_path"`           // 模型完整路径
+	Size              int64   `json:"size"`                 // 模型文件大小(字节)
+	TaskID            string  `json:"task_id,omitempty"`    // 基准测试任务ID（开始测试后才有）
+	Status            string  `json:"status"`               // 测试状态：pending/queued/running/completed/failed/cancelled
+	Error             string  `json:"error,omitempty"`      // 失败原因
+	PPTokensPerSecond float64 `json:"pp_tokens_per_second"` // 提示处理吞吐量
+	TGTokensPerSecond float64 `json:"tg_tokens_per_second"` // 生成吞吐量
+}
+
+// BenchmarkLeaderboardEntry 排行榜条目，每GB吞吐量按模型文件大小（GiB）计算
+type BenchmarkLeaderboardEntry struct {
+	Rank              int     `json:"rank"`                 // 排名（从1开始）
+	Name              string  `json:"name"`                 // 模型文件名
+	SizeGB            float64 `json:"size_gb"`              // 模型文件大小(GiB)
+	PPTokensPerSecond float64 `json:"pp_tokens_per_second"` // 提示处理吞吐量
+	TGTokensPerSecond float64 `json:"tg_tokens_per_second"` // 生成吞吐量
+	PPPerGB           float64 `json:"pp_per_gb"`            // 每GB提示处理吞吐量
+	TGPerGB           float64 `json:"tg_per_gb"`            // 每GB生成吞吐量
+	TaskID            string  `json:"task_id"`              // 基准测试任务ID
 }
 
 // BenchmarkResults 基准测试结果
@@ -301,6 +1058,69 @@ type BenchmarkResults struct {
 	MemoryUsed      int64   `json:"memory_used"`       // 使用的内存（字节）
 }
 
+// EvalSuite 准确性冒烟测试集：一组提示及其期望的回答，用于发现损坏的对话模板或有问题的量化
+type EvalSuite struct {
+	Name        string      `json:"name"`                 // 测试集名称
+	Models      []string    `json:"models,omitempty"`     // 适用的模型名称，为空表示所有模型
+	RunOnSwitch bool        `json:"run_on_switch"`        // 模型切换成功后是否自动运行
+	Cases       []*EvalCase `json:"cases"`                // 测试用例
+	UpdatedAt   string      `json:"updated_at,omitempty"` // 最后修改时间
+	LastRun     *EvalRun    `json:"last_run,omitempty"`   // 最近一次运行结果（仅查询时返回）
+}
+
+// EvalCase 单个测试用例，contains和regex都设置时需同时满足
+type EvalCase struct {
+	Name      string   `json:"name"`                 // 用例名称
+	System    string   `json:"system,omitempty"`     // 系统提示
+	Prompt    string   `json:"prompt"`               // 用户提示
+	Contains  []string `json:"contains,omitempty"`   // 回答必须包含的字符串（不区分大小写）
+	Regex     string   `json:"regex,omitempty"`      // 回答必须匹配的正则表达式
+	MaxTokens int      `json:"max_tokens,omitempty"` // 最多生成的token数，默认128
+}
+
+// EvalRunRequest 运行测试集的请求
+type EvalRunRequest struct {
+	Suite string `json:"suite"` // 测试集名称
+	Model string `json:"model"` // 运行中的模型名称（也可以是别名）
+}
+
+// EvalRun 测试集的一次运行结果
+type EvalRun struct {
+	Suite     string            `json:"suite"`      // 测试集名称
+	ModelName string            `json:"model_name"` // 模型名称
+	Trigger   string            `json:"trigger"`    // 触发方式：manual/switch
+	StartTime string            `json:"start_time"` // 开始时间
+	EndTime   string            `json:"end_time"`   // 结束时间
+	Passed    int               `json:"passed"`     // 通过的用例数
+	Failed    int               `json:"failed"`     // 失败的用例数
+	Results   []*EvalCaseResult `json:"results"`    // 各用例结果
+}
+
+// EvalCaseResult 单个用例的运行结果
+type EvalCaseResult struct {
+	Name      string   `json:"name"`               // 用例名称
+	Passed    bool     `json:"passed"`             // 是否通过
+	Output    string   `json:"output"`             // 模型的回答
+	Failures  []string `json:"failures,omitempty"` // 未满足的断言
+	Error     string   `json:"error,omitempty"`    // 请求失败原因
+	LatencyMS int64    `json:"latency_ms"`         // 请求耗时（毫秒）
+}
+
+// ConfigReload 重新加载配置的结果
+type ConfigReload struct {
+	File            string         `json:"file,omitempty"`   // 加载的配置文件，未使用配置文件时省略
+	Changes         []ConfigChange `json:"changes"`          // 与当前配置相比变化的配置项
+	RestartRequired bool           `json:"restart_required"` // 是否有需要重启switcher才能生效的变化
+}
+
+// ConfigChange 一个配置项的变化，密钥类配置项的值以******代替
+type ConfigChange struct {
+	Field   string      `json:"field"`         // 配置项，与配置文件中的字段路径相同（如default_model.ctx_size）
+	Old     interface{} `json:"old,omitempty"` // 原值
+	New     interface{} `json:"new,omitempty"` // 新值
+	Applied bool        `json:"applied"`       // 是否已生效，为false时需要重启switcher
+}
+
 // APIResponse API通用响应结构
 type APIResponse struct {
 	Success bool        `json:"success"`         // 是否成功
@@ -309,6 +1129,20 @@ type APIResponse struct {
 	Message string      `json:"message"`         // 响应消息
 }
 
+// Problem RFC 7807格式的错误响应（application/problem+json），code为稳定的错误码，
+// 同时保留APIResponse的字段，按success和error判断失败的客户端无需修改
+type Problem struct {
+	Type    string      `json:"type"`           // 错误类型的URI
+	Title   string      `json:"title"`          // 错误类型的简短说明
+	Status  int         `json:"status"`         // HTTP状态码
+	Detail  string      `json:"detail"`         // 本次错误的详细信息
+	Code    string      `json:"code"`           // 错误码，如MODEL_NOT_FOUND
+	Success bool        `json:"success"`        // 始终为false
+	Message string      `json:"message"`        // 与detail相同
+	Error   string      `json:"error"`          // 与detail相同
+	Data    interface{} `json:"data,omitempty"` // 附加的错误详情
+}
+
 // NewAPIResponse 创建新的API响应
 func NewAPIResponse(success bool, message string, data interface{}, err string) *APIResponse {
 	return &APIResponse{
@@ -0,0 +1,335 @@
+package proxy
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+)
+
+// embeddingClient 转发合并后嵌入请求的HTTP客户端
+var embeddingClient = &http.Client{Timeout: 5 * time.Minute}
+
+// serveEmbeddings 在运行中的嵌入模型池内路由/v1/embeddings请求
+func (p *Proxy) serveEmbeddings(w http.ResponseWriter, r *http.Request, body []byte) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	modelName, err := p.resolveEmbeddingModel(r, body)
+	if err != nil {
+		respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := p.modelService.TimeShare().EnsureResident(r.Context(), modelName); err != nil {
+		respondWithResidentError(w, modelName, err)
+		return
+	}
+
+	backend, err := p.modelService.GetBackend(modelName)
+	if err != nil {
+		respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+	if !backend.Config.Config.Embedding {
+		respondWithError(w, http.StatusBadRequest,
+			fmt.Sprintf("model '%s' was not started with embedding=true", modelName))
+		return
+	}
+
+	// 小请求合并后转发（配置了请求转换的模型逐个转发）
+	if p.batcher != nil && backend.Config.Transform == nil && len(p.transformers) == 0 {
+		if inputs, format, ok := p.batcher.batchable(fields); ok {
+			result := p.batcher.submit(r.Context(), backend, inputs, format)
+			w.Header().Set("Content-Type", cmp.Or(result.contentType, "application/json"))
+			w.WriteHeader(result.status)
+			w.Write(result.body)
+			return
+		}
+	}
+
+	p.forward(w, r, backend, body)
+}
+
+// resolveEmbeddingModel 确定嵌入请求的目标模型，未指定时从嵌入模型池中选择
+func (p *Proxy) resolveEmbeddingModel(r *http.Request, body []byte) (string, error) {
+	if name := requestedModelName(r, body); name != "" {
+		return p.modelService.Aliases().Resolve(name), nil
+	}
+
+	name, err := p.selectPoolModel("embedding", func(cfg *model.ModelConfig) bool {
+		return cfg.Config.Embedding
+	})
+	if err == nil && name == "" {
+		err = fmt.Errorf("no embedding models running")
+	}
+	return name, err
+}
+
+// selectPoolModel 在满足条件的运行中模型中选择唯一的目标模型，没有匹配的模型时返回空字符串
+func (p *Proxy) selectPoolModel(kind string, match func(cfg *model.ModelConfig) bool) (string, error) {
+	pool := p.modelService.GetBackendsWhere(match)
+	switch len(pool) {
+	case 0:
+		return "", nil
+	case 1:
+		return pool[0].ModelName, nil
+	}
+
+	names := make([]string, 0, len(pool))
+	for _, b := range pool {
+		names = append(names, b.ModelName)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("model field is required, available %s models: %s", kind, strings.Join(names, ", "))
+}
+
+// embeddingResult 合并请求拆分后返回给单个调用方的结果
+type embeddingResult struct {
+	status      int
+	body        []byte
+	contentType string // 为空时为application/json
+}
+
+// embeddingWaiter 等待合并批次结果的调用方
+type embeddingWaiter struct {
+	offset int
+	count  int
+	done   chan *embeddingResult
+}
+
+// embeddingBatch 同一模型、同一编码格式的待转发批次
+type embeddingBatch struct {
+	backend *service.Backend
+	format  string
+	inputs  []string
+	waiters []*embeddingWaiter
+	timer   *time.Timer
+}
+
+// slotAcquirer 申请模型的并发槽位（RequestTracker，或处理分时共享组的TimeShareManager）
+type slotAcquirer interface {
+	Acquire(ctx context.Context, name string) (func(), error)
+}
+
+// embeddingBatcher 将短时间内的小嵌入请求合并为一次后端调用
+type embeddingBatcher struct {
+	tracker   slotAcquirer
+	window    time.Duration
+	maxInputs int
+	mu        sync.Mutex
+	pending   map[string]*embeddingBatch
+}
+
+// newEmbeddingBatcher 创建嵌入请求合并器
+func newEmbeddingBatcher(tracker slotAcquirer, window time.Duration, maxInputs int) *embeddingBatcher {
+	return &embeddingBatcher{
+		tracker:   tracker,
+		window:    window,
+		maxInputs: maxInputs,
+		pending:   make(map[string]*embeddingBatch),
+	}
+}
+
+// batchable 判断请求是否可合并：只包含model/input/encoding_format字段且输入为文本
+func (b *embeddingBatcher) batchable(fields map[string]json.RawMessage) ([]string, string, bool) {
+	for key := range fields {
+		if key != "model" && key != "input" && key != "encoding_format" {
+			return nil, "", false
+		}
+	}
+
+	var format string
+	if raw, exists := fields["encoding_format"]; exists {
+		if err := json.Unmarshal(raw, &format); err != nil {
+			return nil, "", false
+		}
+	}
+
+	raw := fields["input"]
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}, format, true
+	}
+	var multiple []string
+	if err := json.Unmarshal(raw, &multiple); err != nil || len(multiple) == 0 || len(multiple) > b.maxInputs {
+		return nil, "", false
+	}
+	return multiple, format, true
+}
+
+// submit 将输入加入批次并等待结果
+func (b *embeddingBatcher) submit(ctx context.Context, backend *service.Backend, inputs []string, format string) *embeddingResult {
+	key := backend.ModelName + "|" + format
+	waiter := &embeddingWaiter{count: len(inputs), done: make(chan *embeddingResult, 1)}
+
+	b.mu.Lock()
+	batch := b.pending[key]
+	if batch != nil && len(batch.inputs)+len(inputs) > b.maxInputs {
+		// 当前批次已满，立即转发并开启新批次
+		b.detach(key, batch)
+		go b.flush(batch)
+		batch = nil
+	}
+	if batch == nil {
+		batch = &embeddingBatch{backend: backend, format: format}
+		b.pending[key] = batch
+		batch.timer = time.AfterFunc(b.window, func() {
+			b.mu.Lock()
+			detached := b.detach(key, batch)
+			b.mu.Unlock()
+			if detached {
+				b.flush(batch)
+			}
+		})
+	}
+	waiter.offset = len(batch.inputs)
+	batch.inputs = append(batch.inputs, inputs...)
+	batch.waiters = append(batch.waiters, waiter)
+	if len(batch.inputs) >= b.maxInputs {
+		b.detach(key, batch)
+		go b.flush(batch)
+	}
+	b.mu.Unlock()
+
+	select {
+	case result := <-waiter.done:
+		return result
+	case <-ctx.Done():
+		return problemResult(apierror.NewProblem(apierror.CodeRequestTimeout, 0, "request cancelled", nil))
+	}
+}
+
+// detach 将批次从待转发列表中移除（调用方需持有锁）
+func (b *embeddingBatcher) detach(key string, batch *embeddingBatch) bool {
+	if b.pending[key] != batch {
+		return false
+	}
+	delete(b.pending, key)
+	batch.timer.Stop()
+	return true
+}
+
+// flush 转发批次并将结果拆分给各调用方
+func (b *embeddingBatcher) flush(batch *embeddingBatch) {
+	release, err := b.tracker.Acquire(context.Background(), batch.backend.ModelName)
+	if err != nil {
+		batch.fail(apierror.FromError(err, http.StatusTooManyRequests))
+		return
+	}
+	defer release()
+
+	payload := map[string]interface{}{
+		"model": batch.backend.ModelName,
+		"input": batch.inputs,
+	}
+	if batch.format != "" {
+		payload["encoding_format"] = batch.format
+	}
+	reqBody, _ := json.Marshal(payload)
+
+	resp, err := embeddingClient.Post(batch.backend.URL.JoinPath("v1", "embeddings").String(),
+		"application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		slog.Warn("Embedding batch failed", "model_name", batch.backend.ModelName, "error", err)
+		batch.fail(apierror.NewProblem(apierror.CodeUpstream, 0, fmt.Sprintf("Failed to reach model '%s': %v", batch.backend.ModelName, err), nil))
+		return
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		batch.fail(apierror.NewProblem(apierror.CodeUpstream, 0, err.Error(), nil))
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		for _, waiter := range batch.waiters {
+			waiter.done <- &embeddingResult{status: resp.StatusCode, body: respBody}
+		}
+		return
+	}
+
+	var parsed struct {
+		Model string                       `json:"model"`
+		Data  []map[string]json.RawMessage `json:"data"`
+		Usage struct {
+			PromptTokens int `json:"prompt_tokens"`
+			TotalTokens  int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(respBody, &parsed); err != nil || len(parsed.Data) != len(batch.inputs) {
+		batch.fail(apierror.NewProblem(apierror.CodeUpstream, 0, "unexpected embedding response from backend", nil))
+		return
+	}
+
+	// 按index排序后拆分
+	byIndex := make([]map[string]json.RawMessage, len(parsed.Data))
+	for i, item := range parsed.Data {
+		index := i
+		if raw, exists := item["index"]; exists {
+			json.Unmarshal(raw, &index)
+		}
+		if index < 0 || index >= len(byIndex) {
+			batch.fail(apierror.NewProblem(apierror.CodeUpstream, 0, "unexpected embedding index from backend", nil))
+			return
+		}
+		byIndex[index] = item
+	}
+	// 重复的index或null元素会留下空位，不能拆分给调用方
+	for _, item := range byIndex {
+		if item == nil {
+			batch.fail(apierror.NewProblem(apierror.CodeUpstream, 0, "unexpected embedding index from backend", nil))
+			return
+		}
+	}
+
+	total := len(batch.inputs)
+	for _, waiter := range batch.waiters {
+		data := make([]map[string]json.RawMessage, 0, waiter.count)
+		for i := 0; i < waiter.count; i++ {
+			item := byIndex[waiter.offset+i]
+			item["index"], _ = json.Marshal(i)
+			data = append(data, item)
+		}
+		// 合并批次的token用量按输入条数比例分摊
+		usage := map[string]int{
+			"prompt_tokens": parsed.Usage.PromptTokens * waiter.count / total,
+			"total_tokens":  parsed.Usage.TotalTokens * waiter.count / total,
+		}
+		body, _ := json.Marshal(map[string]interface{}{
+			"object": "list",
+			"model":  parsed.Model,
+			"data":   data,
+			"usage":  usage,
+		})
+		waiter.done <- &embeddingResult{status: http.StatusOK, body: body}
+	}
+}
+
+// fail 向批次中所有调用方返回错误
+func (batch *embeddingBatch) fail(problem *model.Problem) {
+	for _, waiter := range batch.waiters {
+		waiter.done <- problemResult(problem)
+	}
+}
+
+// problemResult 构建错误响应结果
+func problemResult(problem *model.Problem) *embeddingResult {
+	body, _ := json.Marshal(problem)
+	return &embeddingResult{status: problem.Status, body: body, contentType: "application/problem+json"}
+}
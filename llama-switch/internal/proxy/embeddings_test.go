@@ -0,0 +1,129 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"llama-switch/internal/service"
+)
+
+func TestEmbeddingBatcher_Batchable(t *testing.T) {
+	b := newEmbeddingBatcher(service.NewRequestTracker(1, time.Second), 10*time.Millisecond, 2)
+
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"model":"emb","input":"hello"}`, 1},
+		{`{"input":["a","b"],"encoding_format":"float"}`, 2},
+		{`{"input":["a","b","c"]}`, 0},      // 超出单批上限
+		{`{"input":[1,2,3]}`, 0},            // token输入不合并
+		{`{"input":"a","dimensions":8}`, 0}, // 含其他参数不合并
+	}
+	for _, tt := range tests {
+		var fields map[string]json.RawMessage
+		json.Unmarshal([]byte(tt.body), &fields)
+		inputs, _, ok := b.batchable(fields)
+		if (tt.want > 0) != ok || len(inputs) != tt.want {
+			t.Errorf("batchable(%s) = %v, %v; want %d inputs", tt.body, inputs, ok, tt.want)
+		}
+	}
+}
+
+func TestEmbeddingBatcher_SplitsBatchedResponse(t *testing.T) {
+	var calls int32
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		var req struct {
+			Input []string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		// 以逆序返回，验证按index拆分
+		data := make([]map[string]interface{}, 0, len(req.Input))
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			data = append(data, map[string]interface{}{"index": i, "embedding": []string{req.Input[i]}})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"model": "emb",
+			"data":  data,
+			"usage": map[string]int{"prompt_tokens": 4, "total_tokens": 4},
+		})
+	}))
+	defer backendServer.Close()
+
+	target, _ := url.Parse(backendServer.URL)
+	backend := &service.Backend{ModelName: "emb", URL: target}
+	b := newEmbeddingBatcher(service.NewRequestTracker(1, time.Second), 50*time.Millisecond, 16)
+
+	requests := [][]string{{"a"}, {"b", "c", "d"}}
+	results := make([]*embeddingResult, len(requests))
+	var wg sync.WaitGroup
+	for i, inputs := range requests {
+		wg.Add(1)
+		go func(i int, inputs []string) {
+			defer wg.Done()
+			results[i] = b.submit(context.Background(), backend, inputs, "")
+		}(i, inputs)
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected 1 backend call, got %d", n)
+	}
+	for i, inputs := range requests {
+		if results[i].status != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d: %s", i, results[i].status, results[i].body)
+		}
+		var resp struct {
+			Data []struct {
+				Index     int      `json:"index"`
+				Embedding []string `json:"embedding"`
+			} `json:"data"`
+		}
+		json.Unmarshal(results[i].body, &resp)
+		if len(resp.Data) != len(inputs) {
+			t.Fatalf("request %d: expected %d embeddings, got %d", i, len(inputs), len(resp.Data))
+		}
+		for j, item := range resp.Data {
+			if item.Index != j || fmt.Sprint(item.Embedding) != fmt.Sprint([]string{inputs[j]}) {
+				t.Errorf("request %d: unexpected item %d: %+v", i, j, item)
+			}
+		}
+	}
+}
+
+func TestEmbeddingBatcher_RejectsIncompleteResponse(t *testing.T) {
+	for name, data := range map[string]string{
+		"duplicate index": `[{"index":0,"embedding":[1]},{"index":0,"embedding":[2]}]`,
+		"null element":    `[{"index":0,"embedding":[1]},null]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"model":"emb","data":%s,"usage":{"prompt_tokens":2,"total_tokens":2}}`, data)
+			}))
+			defer backendServer.Close()
+
+			target, _ := url.Parse(backendServer.URL)
+			backend := &service.Backend{ModelName: "emb", URL: target}
+			b := newEmbeddingBatcher(service.NewRequestTracker(1, time.Second), 10*time.Millisecond, 16)
+
+			result := b.submit(context.Background(), backend, []string{"a", "b"}, "")
+			if result.status == http.StatusOK {
+				t.Fatalf("expected an upstream error, got 200: %s", result.body)
+			}
+			if !strings.Contains(string(result.body), "unexpected embedding index") {
+				t.Errorf("unexpected body: %s", result.body)
+			}
+		})
+	}
+}
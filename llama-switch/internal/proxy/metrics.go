@@ -0,0 +1,50 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 推理请求的耗时桶（秒）：流式生成可能持续数分钟
+var proxyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+var (
+	proxyRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "llama_switch_proxy_requests_total",
+		Help: "Inference requests forwarded to model instances by model and response status code.",
+	}, []string{"model_name", "code"})
+	proxyDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "llama_switch_proxy_request_duration_seconds",
+		Help:    "Time taken to forward inference requests, including streamed responses.",
+		Buckets: proxyBuckets,
+	}, []string{"model_name"})
+)
+
+func init() {
+	prometheus.MustRegister(proxyRequests, proxyDuration)
+}
+
+// statusRecorder 记录转发请求的响应状态码（未调用WriteHeader时为200）
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap 返回原始ResponseWriter，使流式响应的Flush可以透传
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// observeRequest 记录一次转发请求的状态码和耗时
+func observeRequest(modelName string, status int, start time.Time) {
+	proxyRequests.WithLabelValues(modelName, strconv.Itoa(status)).Inc()
+	proxyDuration.WithLabelValues(modelName).Observe(time.Since(start).Seconds())
+}
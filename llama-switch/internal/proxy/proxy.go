@@ -0,0 +1,321 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/config"
+	"llama-switch/internal/logging"
+	"llama-switch/internal/service"
+)
+
+// maxBodySize 代理请求体的最大长度
+const maxBodySize = 32 << 20
+
+// Proxy OpenAI兼容接口的推理代理，按请求中的model字段转发到对应的llama-server实例
+type Proxy struct {
+	config       *config.Config
+	modelService *service.ModelService
+	batcher      *embeddingBatcher
+	transformers []Transformer
+}
+
+// NewProxy 创建新的推理代理
+func NewProxy(cfg *config.Config, modelService *service.ModelService) *Proxy {
+	p := &Proxy{
+		config:       cfg,
+		modelService: modelService,
+	}
+	if cfg.Embedding.BatchEnabled {
+		p.batcher = newEmbeddingBatcher(modelService.TimeShare(),
+			time.Duration(cfg.Embedding.BatchWindowMS)*time.Millisecond, cfg.Embedding.BatchMaxInputs)
+	}
+	return p
+}
+
+// ServeHTTP 处理推理请求
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/v1/models" && r.Method == http.MethodGet {
+		p.listModels(w)
+		return
+	}
+
+	if isWebSocketUpgrade(r) {
+		p.serveWebSocket(w, r)
+		return
+	}
+
+	// 读取请求体以获取目标模型
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	if len(body) > maxBodySize {
+		respondWithError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+		return
+	}
+
+	switch r.URL.Path {
+	case "/v1/embeddings":
+		p.serveEmbeddings(w, r, body)
+		return
+	case "/v1/rerank", "/v1/reranking":
+		p.serveRerank(w, r, body)
+		return
+	}
+
+	// 逻辑模型名称命中流量路由规则时按规则分配
+	if name := requestedModelName(r, body); name != "" {
+		if decision, ok := p.modelService.Routes().Pick(name); ok {
+			p.serveRoute(w, r, body, decision)
+			return
+		}
+	}
+
+	modelName, err := p.resolveModelName(r, body)
+	if err != nil {
+		respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// 分时共享组中的模型按需切换为驻留状态
+	if err := p.modelService.TimeShare().EnsureResident(r.Context(), modelName); err != nil {
+		respondWithResidentError(w, modelName, err)
+		return
+	}
+
+	backend, err := p.modelService.GetBackend(modelName)
+	if err != nil {
+		respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	p.forward(w, r, backend, body)
+}
+
+// forward 申请并发槽位后将请求经转换链转发到模型实例
+func (p *Proxy) forward(w http.ResponseWriter, r *http.Request, backend *service.Backend, body []byte) {
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	w = rec
+	start := time.Now()
+	defer func() { observeRequest(backend.ModelName, rec.status, start) }()
+
+	release, err := p.modelService.TimeShare().Acquire(r.Context(), backend.ModelName)
+	if err != nil {
+		var limitErr *service.ConcurrencyLimitError
+		if errors.As(err, &limitErr) {
+			respondWithLimitError(w, limitErr)
+			return
+		}
+		var drainErr *service.ModelDrainingError
+		if errors.As(err, &drainErr) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(drainErr.RetryAfter.Seconds()))))
+		}
+		respondWithServiceError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	defer release()
+
+	// 分时共享组中的模型可能在申请槽位前被切换出去又切换回来，使用当前的实例
+	if current, err := p.modelService.GetBackend(backend.ModelName); err == nil {
+		backend = current
+	}
+
+	body, err = p.transform(r.Context(), r.URL.Path, backend, body)
+	if err != nil {
+		respondWithServiceError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+
+	p.reverseProxy(backend, backend.ModelName).ServeHTTP(w, r)
+}
+
+// serveWebSocket 将WebSocket升级请求透明地隧道转发到模型实例
+// WebSocket连接为长连接会话，不计入并发槽位
+func (p *Proxy) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	modelName, err := p.resolveModelName(r, nil)
+	if err != nil {
+		respondWithServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := p.modelService.TimeShare().EnsureResident(r.Context(), modelName); err != nil {
+		respondWithResidentError(w, modelName, err)
+		return
+	}
+
+	backend, err := p.modelService.GetBackend(modelName)
+	if err != nil {
+		respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	logging.FromContext(r.Context()).Info("Tunneling WebSocket connection", "model_name", modelName, "path", r.URL.Path)
+	p.reverseProxy(backend, modelName).ServeHTTP(w, r)
+	logging.FromContext(r.Context()).Info("WebSocket connection closed", "model_name", modelName, "path", r.URL.Path)
+}
+
+// newReverseProxy 创建转发到模型实例的反向代理
+// httputil.ReverseProxy会在后端返回101时接管连接并双向转发，从而支持协议升级
+func newReverseProxy(backend *service.Backend, modelName string) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(backend.URL)
+			pr.SetXForwarded()
+		},
+		FlushInterval: -1, // 立即刷新以支持流式响应
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logging.FromContext(r.Context()).Error("Proxy error", "model_name", modelName, "error", err)
+			respondWithError(w, http.StatusBadGateway,
+				fmt.Sprintf("Failed to reach model '%s': %v", modelName, err))
+		},
+	}
+}
+
+// reverseProxy 创建转发到模型实例的反向代理；switcher配置了CORS时去掉模型实例返回的CORS响应头，
+// 避免与switcher添加的响应头重复
+func (p *Proxy) reverseProxy(backend *service.Backend, modelName string) *httputil.ReverseProxy {
+	rp := newReverseProxy(backend, modelName)
+	if len(p.config.CORS.AllowedOrigins) > 0 {
+		rp.ModifyResponse = func(resp *http.Response) error {
+			for name := range resp.Header {
+				if strings.HasPrefix(name, "Access-Control-") {
+					resp.Header.Del(name)
+				}
+			}
+			return nil
+		}
+	}
+	return rp
+}
+
+// isWebSocketUpgrade 判断是否为WebSocket升级请求
+func isWebSocketUpgrade(r *http.Request) bool {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+	for _, v := range r.Header.Values("Connection") {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveModelName 确定请求的目标模型
+// 请求中的模型名可以是别名，返回解析后的实际模型名称
+func (p *Proxy) resolveModelName(r *http.Request, body []byte) (string, error) {
+	if name := requestedModelName(r, body); name != "" {
+		return p.modelService.Aliases().Resolve(name), nil
+	}
+
+	// 未指定模型时，仅有一个运行中的模型则直接使用
+	names := p.modelService.GetRunningModelNames()
+	if len(names) == 1 {
+		return names[0], nil
+	}
+	if len(names) == 0 {
+		return "", fmt.Errorf("no models running")
+	}
+	return "", fmt.Errorf("model field is required when multiple models are running")
+}
+
+// requestedModelName 从请求头、查询参数或请求体中获取请求的模型名
+func requestedModelName(r *http.Request, body []byte) string {
+	if name := r.Header.Get("X-Model-Name"); name != "" {
+		return name
+	}
+	if name := r.URL.Query().Get("model"); name != "" {
+		return name
+	}
+
+	if len(body) > 0 {
+		var req struct {
+			Model string `json:"model"`
+		}
+		if err := json.Unmarshal(body, &req); err == nil {
+			return req.Model
+		}
+	}
+	return ""
+}
+
+// listModels 返回OpenAI格式的运行中模型列表（包含指向运行中模型的别名）
+func (p *Proxy) listModels(w http.ResponseWriter) {
+	type modelEntry struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		OwnedBy string `json:"owned_by"`
+	}
+
+	names := p.modelService.GetRunningModelNames()
+	data := make([]modelEntry, 0, len(names))
+	for _, name := range names {
+		data = append(data, modelEntry{ID: name, Object: "model", OwnedBy: "llama-switch"})
+		for _, alias := range p.modelService.Aliases().AliasesOf(name) {
+			data = append(data, modelEntry{ID: alias, Object: "model", OwnedBy: "llama-switch"})
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   data,
+	})
+}
+
+// respondWithLimitError 返回429及结构化重试信息
+func respondWithLimitError(w http.ResponseWriter, err *service.ConcurrencyLimitError) {
+	retryAfter := int(math.Ceil(err.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	apierror.Write(w, apierror.NewProblem(apierror.CodeConcurrencyLimit, 0, err.Error(), map[string]interface{}{
+		"model_name":  err.ModelName,
+		"limit":       err.Limit,
+		"active":      err.Active,
+		"queued":      err.Queued,
+		"retry_after": retryAfter,
+	}))
+}
+
+// respondWithResidentError 分时共享组中的模型无法切换为驻留状态时返回503，错误码为切换失败的原因（如INSUFFICIENT_VRAM）
+func respondWithResidentError(w http.ResponseWriter, modelName string, err error) {
+	code := apierror.CodeOf(err)
+	if code == "" {
+		code = apierror.CodeUnavailable
+	}
+	apierror.Write(w, apierror.NewProblem(code, http.StatusServiceUnavailable,
+		fmt.Sprintf("Failed to make model '%s' resident: %v", modelName, err), nil))
+}
+
+// respondWithError 返回错误响应，错误码为HTTP状态码对应的通用错误码
+func respondWithError(w http.ResponseWriter, status int, message string) {
+	apierror.Write(w, apierror.NewProblem(apierror.ForStatus(status), status, message, nil))
+}
+
+// respondWithServiceError 返回服务层错误的响应：错误带有错误码时使用错误码及其HTTP状态码，否则使用status
+func respondWithServiceError(w http.ResponseWriter, status int, err error) {
+	apierror.Write(w, apierror.FromError(err, status))
+}
+
+// respondWithJSON 返回JSON响应
+func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+}
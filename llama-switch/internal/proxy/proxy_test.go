@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"llama-switch/internal/service"
+)
+
+func TestIsWebSocketUpgrade(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/ws", nil)
+	if isWebSocketUpgrade(r) {
+		t.Error("plain request detected as upgrade")
+	}
+
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Connection", "keep-alive, Upgrade")
+	if !isWebSocketUpgrade(r) {
+		t.Error("upgrade request not detected")
+	}
+}
+
+func TestReverseProxy_TunnelsUpgrade(t *testing.T) {
+	// 模拟支持协议升级的后端：升级后回显收到的数据
+	backendServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijack failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+		line, _ := rw.ReadString('\n')
+		rw.WriteString("echo:" + line)
+		rw.Flush()
+	}))
+	defer backendServer.Close()
+
+	target, _ := url.Parse(backendServer.URL)
+	backend := &service.Backend{ModelName: "chat", URL: target}
+	proxyServer := httptest.NewServer(newReverseProxy(backend, "chat"))
+	defer proxyServer.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(proxyServer.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("GET /v1/ws HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n"))
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("read response failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected 101, got %d", resp.StatusCode)
+	}
+
+	conn.Write([]byte("hello\n"))
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("read tunneled data failed: %v", err)
+	}
+	if line != "echo:hello\n" {
+		t.Errorf("Unexpected tunneled data: %q", line)
+	}
+}
@@ -0,0 +1,50 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+
+	"llama-switch/internal/model"
+)
+
+// serveRerank 将/v1/rerank请求转发到以reranking=true启动的模型
+// 没有重排序模型运行时自动启动配置的默认重排序模型
+func (p *Proxy) serveRerank(w http.ResponseWriter, r *http.Request, body []byte) {
+	modelName := requestedModelName(r, body)
+	if modelName != "" {
+		modelName = p.modelService.Aliases().Resolve(modelName)
+	} else {
+		name, err := p.selectPoolModel("reranking", func(cfg *model.ModelConfig) bool {
+			return cfg.Config.Reranking
+		})
+		if err != nil {
+			respondWithServiceError(w, http.StatusBadRequest, err)
+			return
+		}
+		if name == "" {
+			if name, err = p.modelService.StartDefaultReranker(r.Context()); err != nil {
+				respondWithServiceError(w, http.StatusServiceUnavailable, err)
+				return
+			}
+		}
+		modelName = name
+	}
+
+	if err := p.modelService.TimeShare().EnsureResident(r.Context(), modelName); err != nil {
+		respondWithResidentError(w, modelName, err)
+		return
+	}
+
+	backend, err := p.modelService.GetBackend(modelName)
+	if err != nil {
+		respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+	if !backend.Config.Config.Reranking {
+		respondWithError(w, http.StatusBadRequest,
+			fmt.Sprintf("model '%s' was not started with reranking=true", modelName))
+		return
+	}
+
+	p.forward(w, r, backend, body)
+}
@@ -0,0 +1,154 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"llama-switch/internal/service"
+)
+
+// maxCaptureSize 为统计生成token数而捕获的响应体上限
+const maxCaptureSize = 1 << 20
+
+// shadowClient 发送影子请求的HTTP客户端
+var shadowClient = &http.Client{Timeout: 10 * time.Minute}
+
+// serveRoute 按路由规则将请求转发到当前版本或新版本模型，并记录各目标的延迟与吞吐量
+func (p *Proxy) serveRoute(w http.ResponseWriter, r *http.Request, body []byte, decision *service.RouteDecision) {
+	routes := p.modelService.Routes()
+	target := p.modelService.Aliases().Resolve(decision.Target)
+
+	if decision.Shadow != "" {
+		// 复制请求信息，影子请求在原请求结束后仍可能在进行
+		go p.mirror(decision, r.Method, r.URL.RequestURI(), r.Header.Clone(), body)
+	}
+
+	if err := p.modelService.TimeShare().EnsureResident(r.Context(), target); err != nil {
+		routes.Record(decision.Route, decision.Target, 0, true, 0)
+		respondWithResidentError(w, target, err)
+		return
+	}
+
+	backend, err := p.modelService.GetBackend(target)
+	if err != nil {
+		routes.Record(decision.Route, decision.Target, 0, true, 0)
+		respondWithServiceError(w, http.StatusNotFound, err)
+		return
+	}
+
+	rec := &routeRecorder{ResponseWriter: w, status: http.StatusOK}
+	start := time.Now()
+	p.forward(rec, r, backend, body)
+	routes.Record(decision.Route, decision.Target, time.Since(start),
+		rec.status >= http.StatusInternalServerError, completionTokens(rec.body.Bytes()))
+}
+
+// mirror 将请求复制到影子模型，丢弃其响应，仅记录统计
+func (p *Proxy) mirror(decision *service.RouteDecision, method, uri string, header http.Header, body []byte) {
+	routes := p.modelService.Routes()
+	target := p.modelService.Aliases().Resolve(decision.Shadow)
+
+	backend, err := p.modelService.GetBackend(target)
+	if err != nil {
+		routes.Record(decision.Route, decision.Shadow, 0, true, 0)
+		return
+	}
+
+	// 影子请求与正常请求一样受新版本模型的并发上限约束
+	release, err := p.modelService.Tracker().Acquire(context.Background(), target)
+	if err != nil {
+		slog.Warn("Dropping shadow request", "model_name", target, "error", err)
+		return
+	}
+	defer release()
+
+	path, _, _ := strings.Cut(uri, "?")
+	body, err = p.transform(context.Background(), path, backend, body)
+	if err != nil {
+		routes.Record(decision.Route, decision.Shadow, 0, true, 0)
+		return
+	}
+
+	req, err := http.NewRequest(method, backend.URL.String()+uri, bytes.NewReader(body))
+	if err != nil {
+		slog.Warn("Failed to create shadow request", "model_name", target, "error", err)
+		return
+	}
+	req.Header = header
+
+	start := time.Now()
+	resp, err := shadowClient.Do(req)
+	if err != nil {
+		routes.Record(decision.Route, decision.Shadow, time.Since(start), true, 0)
+		return
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxCaptureSize))
+	io.Copy(io.Discard, resp.Body)
+	routes.Record(decision.Route, decision.Shadow, time.Since(start),
+		resp.StatusCode >= http.StatusInternalServerError, completionTokens(data))
+}
+
+// routeRecorder 记录响应状态码并捕获响应体用于统计
+type routeRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *routeRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *routeRecorder) Write(p []byte) (int, error) {
+	if r.body.Len()+len(p) <= maxCaptureSize {
+		r.body.Write(p)
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Unwrap 返回原始ResponseWriter，使流式响应的Flush可以透传
+func (r *routeRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// completionTokens 从JSON响应或SSE流中提取usage.completion_tokens
+func completionTokens(data []byte) int {
+	var resp struct {
+		Usage struct {
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		if err := json.Unmarshal(data, &resp); err == nil {
+			return resp.Usage.CompletionTokens
+		}
+		return 0
+	}
+
+	// 流式响应：usage出现在最后的数据块中
+	tokens := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxCaptureSize)
+	for scanner.Scan() {
+		line, found := bytes.CutPrefix(scanner.Bytes(), []byte("data: "))
+		if !found {
+			continue
+		}
+		if err := json.Unmarshal(line, &resp); err == nil && resp.Usage.CompletionTokens > 0 {
+			tokens = resp.Usage.CompletionTokens
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,21 @@
+package proxy
+
+import "testing"
+
+func TestCompletionTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want int
+	}{
+		{"json", `{"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":42}}`, 42},
+		{"stream", "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\ndata: {\"choices\":[],\"usage\":{\"completion_tokens\":7}}\n\ndata: [DONE]\n\n", 7},
+		{"no usage", `{"choices":[]}`, 0},
+		{"invalid", `not json`, 0},
+	}
+	for _, tt := range tests {
+		if got := completionTokens([]byte(tt.data)); got != tt.want {
+			t.Errorf("%s: completionTokens = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
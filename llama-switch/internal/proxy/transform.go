@@ -0,0 +1,189 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+)
+
+// transformClient 调用外部转换服务的HTTP客户端
+var transformClient = &http.Client{Timeout: 10 * time.Second}
+
+// TransformRequest 待转换的代理请求
+type TransformRequest struct {
+	Model  string                 // 目标模型名称
+	Path   string                 // 请求路径
+	Config *model.TransformConfig // 模型的转换配置（未配置时为nil）
+	Body   map[string]interface{} // 解析后的JSON请求体，转换器可以直接修改或整体替换
+}
+
+// Transformer 请求转换器，在请求转发到llama-server前改写请求体
+type Transformer interface {
+	Transform(ctx context.Context, req *TransformRequest) error
+}
+
+// TransformerFunc 函数形式的请求转换器
+type TransformerFunc func(ctx context.Context, req *TransformRequest) error
+
+// Transform 调用转换函数
+func (f TransformerFunc) Transform(ctx context.Context, req *TransformRequest) error {
+	return f(ctx, req)
+}
+
+// defaultTransformers 按模型转换配置执行的内置转换器，依次为删除字段、系统提示、停止序列和外部转换服务
+var defaultTransformers = []Transformer{
+	TransformerFunc(stripFields),
+	TransformerFunc(rewriteSystemPrompt),
+	TransformerFunc(injectStop),
+	TransformerFunc(callTransformWebhook),
+}
+
+// Use 注册自定义请求转换器，在内置转换器之后按注册顺序执行
+// 需要在代理开始处理请求前调用
+func (p *Proxy) Use(t Transformer) {
+	p.transformers = append(p.transformers, t)
+}
+
+// transform 对转发到模型实例的JSON请求体执行转换链，没有需要执行的转换时原样返回
+func (p *Proxy) transform(ctx context.Context, path string, backend *service.Backend, body []byte) ([]byte, error) {
+	cfg := backend.Config.Transform
+	if cfg == nil && len(p.transformers) == 0 {
+		return body, nil
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return body, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+	req := &TransformRequest{Model: backend.ModelName, Path: path, Config: cfg}
+	if err := decoder.Decode(&req.Body); err != nil {
+		return body, nil
+	}
+
+	chain := p.transformers
+	if cfg != nil {
+		chain = append(append([]Transformer{}, defaultTransformers...), p.transformers...)
+	}
+	for _, t := range chain {
+		if err := t.Transform(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+
+	transformed, err := json.Marshal(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize transformed request: %v", err)
+	}
+	return transformed, nil
+}
+
+// stripFields 删除配置的顶层字段
+func stripFields(ctx context.Context, req *TransformRequest) error {
+	for _, field := range req.Config.StripFields {
+		delete(req.Body, field)
+	}
+	return nil
+}
+
+// rewriteSystemPrompt 替换chat请求的系统提示，没有系统提示时插入到消息最前
+func rewriteSystemPrompt(ctx context.Context, req *TransformRequest) error {
+	if req.Config.SystemPrompt == "" {
+		return nil
+	}
+	messages, ok := req.Body["messages"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	if len(messages) > 0 {
+		if first, ok := messages[0].(map[string]interface{}); ok && first["role"] == "system" {
+			first["content"] = req.Config.SystemPrompt
+			return nil
+		}
+	}
+	system := map[string]interface{}{"role": "system", "content": req.Config.SystemPrompt}
+	req.Body["messages"] = append([]interface{}{system}, messages...)
+	return nil
+}
+
+// injectStop 将配置的停止序列合并到请求的stop字段
+func injectStop(ctx context.Context, req *TransformRequest) error {
+	if len(req.Config.Stop) == 0 {
+		return nil
+	}
+
+	var stops []interface{}
+	switch existing := req.Body["stop"].(type) {
+	case string:
+		stops = append(stops, existing)
+	case []interface{}:
+		stops = append(stops, existing...)
+	}
+
+	for _, stop := range req.Config.Stop {
+		found := false
+		for _, s := range stops {
+			if s == stop {
+				found = true
+				break
+			}
+		}
+		if !found {
+			stops = append(stops, stop)
+		}
+	}
+	req.Body["stop"] = stops
+	return nil
+}
+
+// callTransformWebhook 将请求体发送到外部转换服务，使用其返回的请求体替换原请求体
+// 转换服务返回204表示不做修改
+func callTransformWebhook(ctx context.Context, req *TransformRequest) error {
+	if req.Config.WebhookURL == "" {
+		return nil
+	}
+
+	payload, err := json.Marshal(req.Body)
+	if err != nil {
+		return fmt.Errorf("failed to serialize request for transform webhook: %v", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.Config.WebhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create transform webhook request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Model-Name", req.Model)
+	httpReq.Header.Set("X-Request-Path", req.Path)
+
+	resp, err := transformClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("transform webhook failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("transform webhook returned status %d", resp.StatusCode)
+	}
+
+	decoder := json.NewDecoder(io.LimitReader(resp.Body, maxBodySize))
+	decoder.UseNumber()
+	var body map[string]interface{}
+	if err := decoder.Decode(&body); err != nil {
+		return fmt.Errorf("invalid transform webhook response: %v", err)
+	}
+	req.Body = body
+	return nil
+}
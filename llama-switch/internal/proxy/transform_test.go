@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"llama-switch/internal/model"
+	"llama-switch/internal/service"
+)
+
+func TestTransform_BuiltinRules(t *testing.T) {
+	p := &Proxy{}
+	backend := &service.Backend{
+		ModelName: "llama-7b",
+		Config: &model.ModelConfig{Transform: &model.TransformConfig{
+			SystemPrompt: "You are concise.",
+			Stop:         []string{"<|im_end|>", "###"},
+			StripFields:  []string{"user"},
+		}},
+	}
+
+	body := `{"model":"llama-7b","user":"u1","seed":12345678901234567,"stop":"###",` +
+		`"messages":[{"role":"system","content":"old"},{"role":"user","content":"hi"}]}`
+	out, err := p.transform(context.Background(), "/v1/chat/completions", backend, []byte(body))
+	if err != nil {
+		t.Fatalf("transform failed: %v", err)
+	}
+
+	var got map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	decoder.UseNumber()
+	if err := decoder.Decode(&got); err != nil {
+		t.Fatalf("invalid output: %v", err)
+	}
+	if _, exists := got["user"]; exists {
+		t.Error("Expected user field to be stripped")
+	}
+	if got["seed"].(json.Number).String() != "12345678901234567" {
+		t.Errorf("Large integer not preserved: %v", got["seed"])
+	}
+	if !reflect.DeepEqual(got["stop"], []interface{}{"###", "<|im_end|>"}) {
+		t.Errorf("stop = %v", got["stop"])
+	}
+	messages := got["messages"].([]interface{})
+	if len(messages) != 2 || messages[0].(map[string]interface{})["content"] != "You are concise." {
+		t.Errorf("messages = %v", messages)
+	}
+
+	// 没有系统提示时插入到最前
+	out, _ = p.transform(context.Background(), "/v1/chat/completions", backend,
+		[]byte(`{"messages":[{"role":"user","content":"hi"}]}`))
+	json.Unmarshal(out, &got)
+	if messages := got["messages"].([]interface{}); len(messages) != 2 || messages[0].(map[string]interface{})["role"] != "system" {
+		t.Errorf("Expected system prompt to be inserted, got %v", messages)
+	}
+}
+
+func TestTransform_WebhookAndCustomTransformer(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Model-Name") != "llama-7b" || r.Header.Get("X-Request-Path") != "/completion" {
+			t.Errorf("Unexpected webhook headers: %v", r.Header)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		body["prompt"] = "rewritten: " + body["prompt"].(string)
+		json.NewEncoder(w).Encode(body)
+	}))
+	defer webhook.Close()
+
+	p := &Proxy{}
+	p.Use(TransformerFunc(func(ctx context.Context, req *TransformRequest) error {
+		req.Body["n_predict"] = 16
+		return nil
+	}))
+	backend := &service.Backend{
+		ModelName: "llama-7b",
+		Config:    &model.ModelConfig{Transform: &model.TransformConfig{WebhookURL: webhook.URL}},
+	}
+
+	out, err := p.transform(context.Background(), "/completion", backend, []byte(`{"prompt":"hello"}`))
+	if err != nil {
+		t.Fatalf("transform failed: %v", err)
+	}
+	var got map[string]interface{}
+	json.Unmarshal(out, &got)
+	if got["prompt"] != "rewritten: hello" || got["n_predict"] != float64(16) {
+		t.Errorf("Unexpected transformed body: %v", got)
+	}
+
+	// 未配置转换且没有自定义转换器时原样转发
+	plain := &service.Backend{ModelName: "other", Config: &model.ModelConfig{}}
+	body := []byte(`{"prompt":"hello"}`)
+	if out, _ := (&Proxy{}).transform(context.Background(), "/completion", plain, body); string(out) != string(body) {
+		t.Errorf("Expected body unchanged, got %s", out)
+	}
+
+	webhook.Close()
+	if _, err := p.transform(context.Background(), "/completion", backend, body); err == nil {
+		t.Error("Expected error when webhook is unreachable")
+	}
+}
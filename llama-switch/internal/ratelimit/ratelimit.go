@@ -0,0 +1,103 @@
+// Package ratelimit 按客户端分别计数的令牌桶请求频率限制
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// pruneInterval 清理已回满的令牌桶的间隔，回满的桶与新建的桶等价
+const pruneInterval = time.Minute
+
+// Limiter 令牌桶限流器：每个客户端一个容量为burst的桶，按每分钟perMinute个的速度补充
+type Limiter struct {
+	perMinute int
+	burst     int
+	now       func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+// bucket 一个客户端的令牌桶
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Result 一次请求的限流结果，用于生成RateLimit响应头
+type Result struct {
+	Allowed    bool
+	Limit      int           // 桶的容量
+	Remaining  int           // 本次请求后剩余的令牌数
+	Reset      time.Duration // 令牌桶回满所需的时间
+	RetryAfter time.Duration // 被拒绝时到下一个令牌可用的时间
+}
+
+// New 创建限流器，perMinute为每分钟允许的请求数，burst为允许的突发请求数（0表示与perMinute相同）；
+// perMinute不大于0时返回nil，表示不限制
+func New(perMinute, burst int) *Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = perMinute
+	}
+	return &Limiter{perMinute: perMinute, burst: burst, now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// Allow 为client消耗一个令牌，令牌不足时拒绝
+func (l *Limiter) Allow(client string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+	b, exists := l.buckets[client]
+	if !exists {
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.last = now
+
+	result := Result{Limit: l.burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.duration(1 - b.tokens)
+	}
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = l.duration(float64(l.burst) - b.tokens)
+	return result
+}
+
+// refill 计算补充后的令牌数
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	elapsed := now.Sub(b.last).Minutes()
+	return math.Min(float64(l.burst), b.tokens+elapsed*float64(l.perMinute))
+}
+
+// duration 补充指定数量的令牌所需的时间
+func (l *Limiter) duration(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / float64(l.perMinute) * float64(time.Minute))
+}
+
+// prune 定期删除已回满的令牌桶，避免大量不同客户端占用内存
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < pruneInterval {
+		return
+	}
+	l.lastPrune = now
+	for client, b := range l.buckets {
+		if l.refill(b, now) >= float64(l.burst) {
+			delete(l.buckets, client)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	if New(0, 10) != nil {
+		t.Error("New(0) should disable rate limiting")
+	}
+
+	now := time.Unix(1700000000, 0)
+	l := New(60, 3)
+	l.now = func() time.Time { return now }
+
+	// 突发请求用完桶的容量后拒绝
+	for i := 2; i >= 0; i-- {
+		r := l.Allow("a")
+		if !r.Allowed || r.Remaining != i || r.Limit != 3 {
+			t.Fatalf("request %d = %+v", 3-i, r)
+		}
+	}
+	r := l.Allow("a")
+	if r.Allowed || r.RetryAfter != time.Second || r.Reset != 3*time.Second {
+		t.Fatalf("request over the burst = %+v", r)
+	}
+
+	// 其他客户端单独计数
+	if r := l.Allow("b"); !r.Allowed || r.Remaining != 2 {
+		t.Errorf("other client = %+v", r)
+	}
+
+	// 每秒补充一个令牌
+	now = now.Add(time.Second)
+	if r := l.Allow("a"); !r.Allowed || r.Remaining != 0 {
+		t.Errorf("after refill = %+v", r)
+	}
+	now = now.Add(time.Hour)
+	if r := l.Allow("a"); !r.Allowed || r.Remaining != 2 {
+		t.Errorf("after a long idle period = %+v", r)
+	}
+
+	// 回满的桶被清理
+	now = now.Add(2 * pruneInterval)
+	l.Allow("c")
+	if _, exists := l.buckets["b"]; exists || len(l.buckets) != 1 {
+		t.Errorf("idle buckets not pruned: %v", l.buckets)
+	}
+}
+
+func TestLimiterDefaultBurst(t *testing.T) {
+	l := New(5, 0)
+	for i := 0; i < 5; i++ {
+		if !l.Allow("a").Allowed {
+			t.Fatalf("request %d rejected", i+1)
+		}
+	}
+	if l.Allow("a").Allowed {
+		t.Error("request over the default burst allowed")
+	}
+}
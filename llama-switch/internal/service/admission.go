@@ -0,0 +1,112 @@
+package service
+
+import (
+	"sync"
+)
+
+// admissionController 串行化启动模型时的显存容量决策（查询、驱逐、放置），
+// 并为已通过检查但尚未加载完成的模型预留估算的显存，避免并发切换请求同时通过检查后显存不足
+type admissionController struct {
+	decide sync.Mutex // 容量决策期间持有
+
+	mu       sync.Mutex
+	nextID   int
+	reserved map[int]admissionReservation // 按预留编号保存的预留
+}
+
+// admissionReservation 启动中模型的显存预留
+type admissionReservation struct {
+	tenant  string // 模型所属租户
+	perGPU  []int  // 每个GPU的预留量(MB)
+	totalMB int    // 计入租户配额的总量(MB)，包含RPC池中的部分
+}
+
+// newAdmissionController 创建准入控制器
+func newAdmissionController() *admissionController {
+	return &admissionController{reserved: make(map[int]admissionReservation)}
+}
+
+// reserve 预留每个GPU上的显存，返回用于释放的预留编号
+func (a *admissionController) reserve(tenant string, perGPU []int, totalMB int) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nextID++
+	a.reserved[a.nextID] = admissionReservation{tenant: tenant, perGPU: perGPU, totalMB: totalMB}
+	return a.nextID
+}
+
+// tenantReserved 统计租户启动中模型的预留总量(MB)
+func (a *admissionController) tenantReserved(tenant string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	total := 0
+	for _, r := range a.reserved {
+		if r.tenant == tenant {
+			total += r.totalMB
+		}
+	}
+	return total
+}
+
+// release 释放预留的显存
+func (a *admissionController) release(id int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.reserved, id)
+}
+
+// apply 从各GPU的可用显存中扣除预留量（不小于0）
+func (a *admissionController) apply(freeMemory []int) []int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	available := append([]int(nil), freeMemory...)
+	for _, r := range a.reserved {
+		for i := range available {
+			if i < len(r.perGPU) {
+				available[i] = max(available[i]-r.perGPU[i], 0)
+			}
+		}
+	}
+	return available
+}
+
+// reservation 计算模型在各GPU上的预留量：放置到单个GPU时全部预留在该GPU上，
+// 否则按各GPU的可用显存比例分摊（与llama-server按tensor_split分配的方式一致）
+func reservation(freeMemory []int, gpus []int, required int) []int {
+	perGPU := make([]int, len(freeMemory))
+	if len(perGPU) == 0 || required <= 0 {
+		return perGPU
+	}
+	if len(gpus) > 0 {
+		if gpus[0] < len(perGPU) {
+			perGPU[gpus[0]] = required
+		}
+		return perGPU
+	}
+
+	total := 0
+	for _, free := range freeMemory {
+		total += max(free, 0)
+	}
+	remaining := required
+	for i, free := range freeMemory {
+		if total > 0 {
+			perGPU[i] = required * max(free, 0) / total
+		} else {
+			perGPU[i] = required / len(perGPU)
+		}
+		remaining -= perGPU[i]
+	}
+	// 整除的余数计入第一个GPU
+	perGPU[0] += remaining
+	return perGPU
+}
+
+// freeMemory 获取每个GPU扣除预留后的可用显存(MB)
+func (s *ModelService) freeMemory() ([]int, error) {
+	freeMemory, err := s.gpu.FreeMemory()
+	if err != nil {
+		return nil, err
+	}
+	return s.admission.apply(freeMemory), nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAdmissionReservation(t *testing.T) {
+	tests := []struct {
+		free     []int
+		gpus     []int
+		required int
+		want     []int
+	}{
+		{[]int{8000, 4000}, []int{1}, 3000, []int{0, 3000}},
+		{[]int{6000, 3000}, nil, 3000, []int{2000, 1000}},
+		{[]int{1000, 1000, 1000}, nil, 1000, []int{334, 333, 333}},
+		{[]int{0, 0}, nil, 1001, []int{501, 500}},
+		{[]int{8000}, nil, 0, []int{0}},
+	}
+	for _, tt := range tests {
+		if got := reservation(tt.free, tt.gpus, tt.required); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("reservation(%v, %v, %d) = %v, want %v", tt.free, tt.gpus, tt.required, got, tt.want)
+		}
+	}
+
+	a := newAdmissionController()
+	first := a.reserve("team-a", []int{3000, 0}, 3000)
+	a.reserve("", []int{1000, 5000}, 7000)
+	if got := a.apply([]int{8000, 4000}); !reflect.DeepEqual(got, []int{4000, 0}) {
+		t.Errorf("apply with reservations = %v, want [4000 0]", got)
+	}
+	if got := a.tenantReserved("team-a"); got != 3000 {
+		t.Errorf("tenantReserved(team-a) = %d, want 3000", got)
+	}
+	a.release(first)
+	if got := a.apply([]int{8000, 4000}); !reflect.DeepEqual(got, []int{7000, 0}) {
+		t.Errorf("apply after release = %v, want [7000 0]", got)
+	}
+}
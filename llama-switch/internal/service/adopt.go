@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"llama-switch/internal/config"
+)
+
+// adoptModel 接管switcher重启前启动、仍在运行的模型实例，不重新启动进程
+// 进程ID可能已被其他进程复用，因此先通过命令行（无法获取时通过端口）确认是同一个实例
+func (s *ModelService) adoptModel(name string, item config.ModelConfigItem) bool {
+	pid := item.LastStatus.ProcessID
+	if pid <= 0 || !processAlive(pid) {
+		return false
+	}
+	if err := s.matchInstance(pid, item); err != nil {
+		slog.Info("Process is not the model, not adopting it", "pid", pid, "model_name", name, "reason", err)
+		return false
+	}
+
+	status := item.LastStatus
+	status.ModelName = name
+	status.Running = true
+	status.StopTime = ""
+	s.processManager.AddModel(pid, &status)
+	s.processManager.SetStopOptions(pid, s.stopOptions(item.ModelConfig))
+	s.setRunningConfig(name, item.ModelConfig)
+	s.setConcurrencyLimit(item.ModelConfig)
+
+	slog.Info("Adopted running model", "model_name", name, "pid", pid, "port", status.Port)
+	return true
+}
+
+// matchInstance 检查进程是否为持久化状态中记录的模型实例
+func (s *ModelService) matchInstance(pid int, item config.ModelConfigItem) error {
+	port := item.LastStatus.Port
+	cmdline, err := processCommandLine(pid)
+	if err != nil {
+		// 无法读取命令行时，以记录的端口上是否有llama-server响应为准
+		if port > 0 && probeLlamaServer(item.LastStatus.Host, port) {
+			return nil
+		}
+		return fmt.Errorf("command line unavailable (%v) and no llama-server on port %d", err, port)
+	}
+
+	if path := item.LastStatus.ModelPath; path != "" && !strings.Contains(cmdline, path) {
+		return fmt.Errorf("command line does not reference %s", path)
+	}
+	if port > 0 && !hasArg(strings.Fields(cmdline), "--port", strconv.Itoa(port)) {
+		return fmt.Errorf("command line does not use port %d", port)
+	}
+	return nil
+}
+
+// hasArg 检查参数列表中是否包含指定的选项及其值
+func hasArg(args []string, flag, value string) bool {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == flag && args[i+1] == value {
+			return true
+		}
+	}
+	return false
+}
+
+// probeLlamaServer 检查指定端口上是否有llama-server响应/health（加载中返回503也算）
+func probeLlamaServer(host string, port int) bool {
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = defaultBackendHost
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthProbeTimeout)
+	defer cancel()
+
+	url := "http://" + net.JoinHostPort(host, strconv.Itoa(port)) + "/health"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := backendClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusServiceUnavailable
+}
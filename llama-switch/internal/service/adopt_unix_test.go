@@ -0,0 +1,68 @@
+//go:build !windows
+
+package service
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestAdoptModel(t *testing.T) {
+	// 模拟switcher重启前启动的实例：命令行中包含模型路径和端口
+	previous := NewProcessManager()
+	args := []string{"-c", "sleep 30; true", "llama-server", "--model", "/models/chat.gguf", "--port", "8123"}
+	if err := previous.StartProcess("sh", args, ProcessOptions{Output: io.Discard}); err != nil {
+		t.Skipf("sh not available: %v", err)
+	}
+	pid := previous.GetPID()
+	defer previous.StopProcess()
+
+	cfg := &config.Config{}
+	s := &ModelService{
+		config:         cfg,
+		processManager: NewProcessManager(),
+		tracker:        NewRequestTracker(0, time.Second),
+		configs:        make(map[string]*model.ModelConfig),
+	}
+	item := config.ModelConfigItem{
+		ModelConfig: &model.ModelConfig{ModelName: "chat", ModelPath: "chat.gguf"},
+		LastStatus: model.ModelStatus{
+			ModelName: "chat",
+			ModelPath: "/models/chat.gguf",
+			Port:      8123,
+			ProcessID: pid,
+			Running:   true,
+			StartTime: "2024-01-01T00:00:00Z",
+		},
+	}
+
+	// 进程ID相同但命令行不符（进程ID被复用）时不接管
+	reused := item
+	reused.LastStatus.Port = 8124
+	if s.adoptModel("chat", reused) {
+		t.Fatal("Expected process with a different port not to be adopted")
+	}
+
+	if !s.adoptModel("chat", item) {
+		t.Fatal("Expected running instance to be adopted")
+	}
+	status := s.processManager.FindModel("chat")
+	if status == nil || status.ProcessID != pid || status.StartTime != item.LastStatus.StartTime {
+		t.Fatalf("Unexpected adopted status: %+v", status)
+	}
+	if names := s.GetRunningModelNames(); len(names) != 1 || names[0] != "chat" {
+		t.Errorf("Expected adopted model to be running, got %v", names)
+	}
+
+	// 接管的进程不是子进程，也可以正常停止
+	if _, err := s.processManager.StopModel("chat"); err != nil {
+		t.Fatalf("StopModel failed: %v", err)
+	}
+	if processAlive(pid) {
+		t.Errorf("Adopted process %d still running after stop", pid)
+	}
+}
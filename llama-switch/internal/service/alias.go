@@ -0,0 +1,259 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// aliasFileName 别名持久化文件名
+const aliasFileName = "aliases.json"
+
+// webhookClient 发送变更通知的HTTP客户端
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// aliasState 别名持久化结构
+type aliasState struct {
+	Aliases   map[string]*model.AliasInfo `json:"aliases"`   // 别名到当前指向的映射
+	Changelog []*model.AliasChange        `json:"changelog"` // 变更记录（按时间顺序）
+}
+
+// AliasManager 别名管理器：将稳定的逻辑名称（如default-chat）映射到实际模型，并记录每次重新指向
+type AliasManager struct {
+	mu         sync.RWMutex
+	path       string
+	webhookURL string
+	state      aliasState
+}
+
+// NewAliasManager 创建别名管理器并加载已保存的别名
+func NewAliasManager(path, webhookURL string) *AliasManager {
+	m := &AliasManager{
+		path:       path,
+		webhookURL: webhookURL,
+		state:      aliasState{Aliases: make(map[string]*model.AliasInfo)},
+	}
+	if err := m.load(); err != nil {
+		slog.Warn("Failed to load aliases", "file", path, "error", err)
+	}
+	return m
+}
+
+// defaultAliasPath 默认别名文件路径：与持久化配置同在程序目录下的config目录
+func defaultAliasPath() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return filepath.Join("config", aliasFileName)
+	}
+	return filepath.Join(filepath.Dir(exePath), "config", aliasFileName)
+}
+
+// Set 设置别名指向，指向发生变化时记录变更并发送通知
+func (m *AliasManager) Set(req *model.AliasUpdateRequest) (*model.AliasChange, error) {
+	if req.Alias == "" {
+		return nil, fmt.Errorf("alias is required")
+	}
+	if req.Target == "" {
+		return nil, fmt.Errorf("target model is required")
+	}
+	if req.Alias == req.Target {
+		return nil, fmt.Errorf("alias cannot point to itself")
+	}
+
+	m.mu.Lock()
+	if _, isAlias := m.state.Aliases[req.Target]; isAlias {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("target '%s' is an alias, aliases cannot be chained", req.Target)
+	}
+	// 已有别名指向该名称时，将其设为别名同样会形成链
+	for _, existing := range m.state.Aliases {
+		if existing.Target == req.Alias {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("alias '%s' points to '%s', aliases cannot be chained", existing.Alias, req.Alias)
+		}
+	}
+
+	var from string
+	if current, exists := m.state.Aliases[req.Alias]; exists {
+		if current.Target == req.Target {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("alias '%s' already points to '%s'", req.Alias, req.Target)
+		}
+		from = current.Target
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	change := &model.AliasChange{
+		Alias:  req.Alias,
+		From:   from,
+		To:     req.Target,
+		Author: req.Author,
+		Reason: req.Reason,
+		Time:   now,
+	}
+	m.state.Aliases[req.Alias] = &model.AliasInfo{Alias: req.Alias, Target: req.Target, UpdatedAt: now}
+	m.state.Changelog = append(m.state.Changelog, change)
+	err := m.save()
+	m.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Alias re-pointed", "alias", req.Alias, "from", from, "to", req.Target, "author", req.Author)
+	go m.notify(change)
+	return change, nil
+}
+
+// Remove 删除别名并记录变更
+func (m *AliasManager) Remove(alias, author, reason string) (*model.AliasChange, error) {
+	m.mu.Lock()
+	current, exists := m.state.Aliases[alias]
+	if !exists {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("alias '%s' not found", alias)
+	}
+
+	change := &model.AliasChange{
+		Alias:  alias,
+		From:   current.Target,
+		Author: author,
+		Reason: reason,
+		Time:   time.Now().Format(time.RFC3339),
+	}
+	delete(m.state.Aliases, alias)
+	m.state.Changelog = append(m.state.Changelog, change)
+	err := m.save()
+	m.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Alias removed", "alias", alias, "author", author)
+	go m.notify(change)
+	return change, nil
+}
+
+// Resolve 将别名解析为实际模型名称，非别名原样返回
+func (m *AliasManager) Resolve(name string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if info, exists := m.state.Aliases[name]; exists {
+		return info.Target
+	}
+	return name
+}
+
+// List 获取所有别名，按名称排序
+func (m *AliasManager) List() []*model.AliasInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	aliases := make([]*model.AliasInfo, 0, len(m.state.Aliases))
+	for _, info := range m.state.Aliases {
+		copied := *info
+		aliases = append(aliases, &copied)
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].Alias < aliases[j].Alias
+	})
+	return aliases
+}
+
+// AliasesOf 获取指向指定模型的所有别名
+func (m *AliasManager) AliasesOf(target string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var names []string
+	for name, info := range m.state.Aliases {
+		if info.Target == target {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Changelog 获取变更记录（最新的在前），alias为空时返回所有别名的记录
+func (m *AliasManager) Changelog(alias string) []*model.AliasChange {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	changes := make([]*model.AliasChange, 0)
+	for i := len(m.state.Changelog) - 1; i >= 0; i-- {
+		change := m.state.Changelog[i]
+		if alias == "" || change.Alias == alias {
+			copied := *change
+			changes = append(changes, &copied)
+		}
+	}
+	return changes
+}
+
+// load 从文件加载别名
+func (m *AliasManager) load() error {
+	data, err := os.ReadFile(m.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read alias file: %v", err)
+	}
+
+	var state aliasState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse alias file: %v", err)
+	}
+	if state.Aliases == nil {
+		state.Aliases = make(map[string]*model.AliasInfo)
+	}
+	m.state = state
+	return nil
+}
+
+// save 保存别名到文件（调用方需持有锁）
+func (m *AliasManager) save() error {
+	data, err := json.MarshalIndent(&m.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize aliases: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("failed to create alias directory: %v", err)
+	}
+	if err := os.WriteFile(m.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write alias file: %v", err)
+	}
+	return nil
+}
+
+// notify 向配置的Webhook发送别名变更通知
+func (m *AliasManager) notify(change *model.AliasChange) {
+	if m.webhookURL == "" {
+		return
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"event":  "alias.changed",
+		"change": change,
+	})
+	resp, err := webhookClient.Post(m.webhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		slog.Warn("Failed to send alias webhook", "alias", change.Alias, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Warn("Alias webhook returned an error status", "alias", change.Alias, "status", resp.StatusCode)
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+func TestAliasManager_RepointRecordsChangelog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aliases.json")
+	m := NewAliasManager(path, "")
+
+	if _, err := m.Set(&model.AliasUpdateRequest{Alias: "default-chat", Target: "qwen-q4", Author: "alice"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := m.Set(&model.AliasUpdateRequest{Alias: "default-chat", Target: "qwen-q8", Author: "bob", Reason: "better quality"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, err := m.Set(&model.AliasUpdateRequest{Alias: "default-chat", Target: "qwen-q8"}); err == nil {
+		t.Error("Expected error when alias already points to target")
+	}
+	// 别名不能指向别名，已被别名指向的模型名称也不能再设为别名
+	if _, err := m.Set(&model.AliasUpdateRequest{Alias: "fast-chat", Target: "default-chat"}); err == nil {
+		t.Error("Expected error when target is an alias")
+	}
+	if _, err := m.Set(&model.AliasUpdateRequest{Alias: "qwen-q8", Target: "llama-8b"}); err == nil || !strings.Contains(err.Error(), "cannot be chained") {
+		t.Errorf("Expected chaining error when an alias points to the new alias, got %v", err)
+	}
+	if got := m.Resolve("qwen-q8"); got != "qwen-q8" {
+		t.Errorf("Rejected alias was stored: qwen-q8 resolves to %s", got)
+	}
+
+	if got := m.Resolve("default-chat"); got != "qwen-q8" {
+		t.Errorf("Resolve = %s, want qwen-q8", got)
+	}
+	if got := m.Resolve("qwen-q4"); got != "qwen-q4" {
+		t.Errorf("Resolve of non-alias = %s, want unchanged", got)
+	}
+
+	changes := m.Changelog("default-chat")
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changelog entries, got %d", len(changes))
+	}
+	latest := changes[0]
+	if latest.From != "qwen-q4" || latest.To != "qwen-q8" || latest.Author != "bob" || latest.Reason != "better quality" {
+		t.Errorf("Unexpected latest change: %+v", latest)
+	}
+
+	// 重新加载后别名与变更记录保持不变
+	reloaded := NewAliasManager(path, "")
+	if got := reloaded.Resolve("default-chat"); got != "qwen-q8" {
+		t.Errorf("Reloaded Resolve = %s, want qwen-q8", got)
+	}
+	if len(reloaded.Changelog("")) != 2 {
+		t.Errorf("Reloaded changelog length = %d, want 2", len(reloaded.Changelog("")))
+	}
+}
+
+func TestAliasManager_WebhookNotified(t *testing.T) {
+	received := make(chan model.AliasChange, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Event  string            `json:"event"`
+			Change model.AliasChange `json:"change"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload.Change
+	}))
+	defer server.Close()
+
+	m := NewAliasManager(filepath.Join(t.TempDir(), "aliases.json"), server.URL)
+	if _, err := m.Set(&model.AliasUpdateRequest{Alias: "default-chat", Target: "qwen-q4", Author: "alice"}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	select {
+	case change := <-received:
+		if change.Alias != "default-chat" || change.To != "qwen-q4" {
+			t.Errorf("Unexpected webhook change: %+v", change)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Webhook not called")
+	}
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"llama-switch/internal/model"
+)
+
+// 参数传入条件
+const (
+	flagPositive    = iota // 数值大于0、字符串非空或布尔值为true时传入（默认）
+	flagNonZero            // 数值不为0时传入
+	flagNonNegative        // 数值大于等于0时传入
+	flagAlways             // 始终传入
+)
+
+// serverFlag ModelConfig.Config中的一个字段与llama-server参数的对应关系
+// 布尔字段只传入参数名，浮点数保留两位小数
+type serverFlag struct {
+	field string // Config中字段的json名称
+	flag  string // llama-server参数
+	when  int    // 传入条件
+	skip  string // 不传入的特殊值（如tensor_split的auto）
+}
+
+// serverFlags llama-server参数映射表，按表中顺序生成命令行参数；支持新的参数时在Config中增加字段并在此登记
+var serverFlags = []serverFlag{
+	// 服务器配置
+	{field: "host", flag: "--host"},
+	{field: "port", flag: "--port", when: flagAlways},
+	{field: "timeout", flag: "--timeout"},
+
+	// 系统资源配置
+	{field: "threads", flag: "--threads"},
+	{field: "threads_batch", flag: "--threads-batch"},
+	{field: "cpu_mask", flag: "--cpu-mask"},
+	{field: "cpu_range", flag: "--cpu-range"},
+	{field: "cpu_strict", flag: "--cpu-strict"},
+	{field: "priority", flag: "--prio", when: flagNonZero},
+	{field: "poll", flag: "--poll", when: flagNonNegative},
+
+	// 模型参数
+	{field: "ctx_size", flag: "--ctx-size"},
+	{field: "batch_size", flag: "--batch-size"},
+	{field: "ubatch_size", flag: "--ubatch-size"},
+	{field: "n_predict", flag: "--n-predict", when: flagNonZero},
+	{field: "keep", flag: "--keep", when: flagNonZero},
+
+	// GPU相关配置
+	{field: "n_gpu_layers", flag: "--n-gpu-layers"},
+	{field: "split_mode", flag: "--split-mode"},
+	{field: "tensor_split", flag: "--tensor-split", skip: TensorSplitAuto},
+	{field: "main_gpu", flag: "--main-gpu", when: flagNonNegative},
+	{field: "device", flag: "--device"},
+
+	// 内存管理
+	{field: "mlock", flag: "--mlock"},
+	{field: "no_mmap", flag: "--no-mmap"},
+	{field: "numa", flag: "--numa"},
+	{field: "no_kv_offload", flag: "--no-kv-offload"},
+
+	// 缓存配置
+	{field: "cache_type_k", flag: "--cache-type-k"},
+	{field: "cache_type_v", flag: "--cache-type-v"},
+	{field: "defrag_thold", flag: "--defrag-thold"},
+
+	// 性能优化
+	{field: "flash_attn", flag: "--flash-attn"},
+	{field: "no_perf", flag: "--no-perf"},
+
+	// RoPE配置
+	{field: "rope_scaling", flag: "--rope-scaling"},
+	{field: "rope_scale", flag: "--rope-scale"},
+	{field: "rope_freq_base", flag: "--rope-freq-base"},
+	{field: "rope_freq_scale", flag: "--rope-freq-scale"},
+
+	// YaRN配置
+	{field: "yarn_orig_ctx", flag: "--yarn-orig-ctx"},
+	{field: "yarn_ext_factor", flag: "--yarn-ext-factor", when: flagNonNegative},
+	{field: "yarn_attn_factor", flag: "--yarn-attn-factor"},
+	{field: "yarn_beta_slow", flag: "--yarn-beta-slow"},
+	{field: "yarn_beta_fast", flag: "--yarn-beta-fast"},
+
+	// 其他功能
+	{field: "verbose", flag: "--verbose"},
+	{field: "log_file", flag: "--log-file"},
+	{field: "static_path", flag: "--path"},
+	{field: "api_key", flag: "--api-key"},
+	{field: "ssl_key", flag: "--ssl-key-file"},
+	{field: "ssl_cert", flag: "--ssl-cert-file"},
+
+	// 新增参数 - 通用参数
+	{field: "help", flag: "--help"},
+	{field: "version", flag: "--version"},
+	{field: "completion_bash", flag: "--completion-bash"},
+	{field: "verbose_prompt", flag: "--verbose-prompt"},
+	{field: "escape", flag: "--escape"},
+	{field: "no_escape", flag: "--no-escape"},
+	{field: "dump_kv_cache", flag: "--dump-kv-cache"},
+	{field: "check_tensors", flag: "--check-tensors"},
+	{field: "rpc", flag: "--rpc"},
+	{field: "parallel", flag: "--parallel"},
+	{field: "override_tensor", flag: "--override-tensor"},
+	{field: "list_devices", flag: "--list-devices"},
+	{field: "lora", flag: "--lora"},
+	{field: "lora_scaled", flag: "--lora-scaled"},
+	{field: "control_vector", flag: "--control-vector"},
+	{field: "control_vector_scaled", flag: "--control-vector-scaled"},
+	{field: "control_vector_layer_range", flag: "--control-vector-layer-range"},
+	{field: "model_url", flag: "--model-url"},
+	{field: "hf_repo", flag: "--hf-repo"},
+	{field: "hf_repo_draft", flag: "--hf-repo-draft"},
+	{field: "hf_file", flag: "--hf-file"},
+	{field: "hf_repo_v", flag: "--hf-repo-v"},
+	{field: "hf_file_v", flag: "--hf-file-v"},
+	{field: "hf_token", flag: "--hf-token"},
+	{field: "log_disable", flag: "--log-disable"},
+	{field: "log_colors", flag: "--log-colors"},
+	{field: "log_verbose", flag: "--log-verbose"},
+	{field: "log_verbosity", flag: "--log-verbosity"},
+	{field: "log_prefix", flag: "--log-prefix"},
+	{field: "log_timestamps", flag: "--log-timestamps"},
+	{field: "samplers", flag: "--samplers"},
+	{field: "seed", flag: "--seed"},
+	{field: "sampler_seq", flag: "--sampler-seq"},
+	{field: "ignore_eos", flag: "--ignore-eos"},
+	{field: "temp", flag: "--temp"},
+	{field: "top_k", flag: "--top-k"},
+	{field: "top_p", flag: "--top-p"},
+	{field: "min_p", flag: "--min-p"},
+	{field: "xtc_probability", flag: "--xtc-probability"},
+	{field: "xtc_threshold", flag: "--xtc-threshold"},
+	{field: "typical", flag: "--typical"},
+	{field: "repeat_last_n", flag: "--repeat-last-n"},
+	{field: "repeat_penalty", flag: "--repeat-penalty"},
+	{field: "presence_penalty", flag: "--presence-penalty"},
+	{field: "frequency_penalty", flag: "--frequency-penalty"},
+	{field: "dry_multiplier", flag: "--dry-multiplier"},
+	{field: "dry_base", flag: "--dry-base"},
+	{field: "dry_allowed_length", flag: "--dry-allowed-length"},
+	{field: "dry_penalty_last_n", flag: "--dry-penalty-last-n"},
+	{field: "dry_sequence_breaker", flag: "--dry-sequence-breaker"},
+	{field: "dynatemp_range", flag: "--dynatemp-range"},
+	{field: "dynatemp_exp", flag: "--dynatemp-exp"},
+	{field: "mirostat", flag: "--mirostat"},
+	{field: "mirostat_lr", flag: "--mirostat-lr"},
+	{field: "mirostat_ent", flag: "--mirostat-ent"},
+	{field: "logit_bias", flag: "--logit-bias"},
+	{field: "grammar", flag: "--grammar"},
+	{field: "grammar_file", flag: "--grammar-file"},
+	{field: "json_schema", flag: "--json-schema"},
+	{field: "json_schema_file", flag: "--json-schema-file"},
+	{field: "no_context_shift", flag: "--no-context-shift"},
+	{field: "special", flag: "--special"},
+	{field: "no_warmup", flag: "--no-warmup"},
+	{field: "spm_infill", flag: "--spm-infill"},
+	{field: "pooling", flag: "--pooling"},
+	{field: "cont_batching", flag: "--cont-batching"},
+	{field: "no_cont_batching", flag: "--no-cont-batching"},
+	{field: "alias", flag: "--alias"},
+	{field: "no_webui", flag: "--no-webui"},
+	{field: "embedding", flag: "--embedding"},
+	{field: "reranking", flag: "--reranking"},
+	{field: "api_key_file", flag: "--api-key-file"},
+	{field: "threads_http", flag: "--threads-http"},
+	{field: "cache_reuse", flag: "--cache-reuse"},
+	{field: "metrics", flag: "--metrics"},
+	{field: "slots", flag: "--slots"},
+	{field: "props", flag: "--props"},
+	{field: "no_slots", flag: "--no-slots"},
+	{field: "slot_save_path", flag: "--slot-save-path"},
+	{field: "jinja", flag: "--jinja"},
+	{field: "reasoning_format", flag: "--reasoning-format"},
+	{field: "chat_template", flag: "--chat-template"},
+	{field: "chat_template_file", flag: "--chat-template-file"},
+	{field: "slot_prompt_similarity", flag: "--slot-prompt-similarity"},
+	{field: "lora_init_without_apply", flag: "--lora-init-without-apply"},
+	{field: "draft_max", flag: "--draft-max"},
+	{field: "draft_min", flag: "--draft-min"},
+	{field: "draft_p_min", flag: "--draft-p-min"},
+	{field: "ctx_size_draft", flag: "--ctx-size-draft"},
+	{field: "device_draft", flag: "--device-draft"},
+	{field: "n_gpu_layers_draft", flag: "--n-gpu-layers-draft"},
+	{field: "model_draft", flag: "--model-draft"},
+	{field: "model_vocoder", flag: "--model-vocoder"},
+	{field: "tts_use_guide_tokens", flag: "--tts-use-guide-tokens"},
+	{field: "embd_bge_small_en_default", flag: "--embd-bge-small-en-default"},
+	{field: "embd_e5_small_en_default", flag: "--embd-e5-small-en-default"},
+	{field: "embd_gte_small_default", flag: "--embd-gte-small-default"},
+	{field: "fim_qwen_1_5b_default", flag: "--fim-qwen-1-5b-default"},
+	{field: "fim_qwen_3b_default", flag: "--fim-qwen-3b-default"},
+	{field: "fim_qwen_7b_default", flag: "--fim-qwen-7b-default"},
+	{field: "fim_qwen_7b_spec", flag: "--fim-qwen-7b-spec"},
+	{field: "fim_qwen_14b_spec", flag: "--fim-qwen-14b-spec"},
+}
+
+// configFieldIndex Config中json名称到字段下标的映射
+var configFieldIndex = sync.OnceValue(func() map[string]int {
+	t := reflect.TypeOf(model.ModelConfig{}.Config)
+	index := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		index[name] = i
+	}
+	return index
+})
+
+// buildServerArgs 根据模型配置构建llama-server的命令行参数，extra_args附加在最后
+func buildServerArgs(cfg *model.ModelConfig, modelPath string, port int) []string {
+	args := []string{
+		"--model", modelPath,
+	}
+
+	c := cfg.Config
+	c.Port = port
+	values := reflect.ValueOf(c)
+	for _, f := range serverFlags {
+		value := values.Field(configFieldIndex()[f.field])
+		if arg, ok := flagValue(value, f); ok {
+			args = append(args, f.flag)
+			if arg != "" {
+				args = append(args, arg)
+			}
+		}
+	}
+
+	return append(args, cfg.ExtraArgs...)
+}
+
+// flagValue 按传入条件判断字段是否传入，返回参数值（布尔字段为空字符串）
+func flagValue(value reflect.Value, f serverFlag) (string, bool) {
+	switch value.Kind() {
+	case reflect.Bool:
+		return "", value.Bool()
+	case reflect.String:
+		s := value.String()
+		return s, s != "" && s != f.skip
+	case reflect.Int, reflect.Int64:
+		n := value.Int()
+		return strconv.FormatInt(n, 10), flagEnabled(f.when, float64(n))
+	case reflect.Float64:
+		x := value.Float()
+		return fmt.Sprintf("%.2f", x), flagEnabled(f.when, x)
+	}
+	return "", false
+}
+
+// flagEnabled 数值字段是否满足传入条件
+func flagEnabled(when int, n float64) bool {
+	switch when {
+	case flagNonZero:
+		return n != 0
+	case flagNonNegative:
+		return n >= 0
+	case flagAlways:
+		return true
+	default:
+		return n > 0
+	}
+}
+
+// validateExtraArgs 检查extra_args：以参数名开头，参数名在允许列表中，且不是由config字段生成的参数
+func (s *ModelService) validateExtraArgs(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if !isFlag(args[0]) {
+		return fmt.Errorf("extra_args must start with a flag, got %q", args[0])
+	}
+
+	allowlist := s.config.Snapshot().ExtraArgs.Allowlist
+	managed := map[string]string{"--model": "model_path", "-m": "model_path"}
+	for _, f := range serverFlags {
+		managed[f.flag] = "config." + f.field
+	}
+	for _, arg := range args {
+		if strings.ContainsRune(arg, 0) {
+			return fmt.Errorf("invalid extra argument: %q", arg)
+		}
+		if !isFlag(arg) {
+			continue
+		}
+		name, _, _ := strings.Cut(arg, "=")
+		if field, exists := managed[name]; exists {
+			return fmt.Errorf("flag %s cannot be passed in extra_args, use %s instead", name, field)
+		}
+		if !flagAllowed(name, allowlist) {
+			if len(allowlist) == 0 {
+				return fmt.Errorf("extra_args are disabled, add %s to EXTRA_ARGS_ALLOWLIST to allow it", name)
+			}
+			return fmt.Errorf("flag %s is not allowed in extra_args (allowed: %s)",
+				name, strings.Join(allowlist, ", "))
+		}
+	}
+	return nil
+}
+
+// isFlag 参数是否为参数名（以-开头且不是负数）
+func isFlag(arg string) bool {
+	if !strings.HasPrefix(arg, "-") {
+		return false
+	}
+	_, err := strconv.ParseFloat(arg, 64)
+	return err != nil
+}
+
+// flagAllowed 参数名是否在允许列表中，以*结尾的项表示前缀匹配
+func flagAllowed(name string, allowlist []string) bool {
+	for _, allowed := range allowlist {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestServerFlagsCoverConfig(t *testing.T) {
+	// 不对应llama-server参数的字段
+	unmapped := map[string]bool{"mmproj": true}
+
+	mapped := make(map[string]bool)
+	for _, f := range serverFlags {
+		if _, exists := configFieldIndex()[f.field]; !exists {
+			t.Errorf("flag %s refers to unknown config field %s", f.flag, f.field)
+		}
+		if mapped[f.field] {
+			t.Errorf("config field %s is mapped more than once", f.field)
+		}
+		mapped[f.field] = true
+	}
+	for field := range configFieldIndex() {
+		if !mapped[field] && !unmapped[field] {
+			t.Errorf("config field %s has no llama-server flag in serverFlags", field)
+		}
+	}
+}
+
+func TestBuildServerArgs(t *testing.T) {
+	cfg := &model.ModelConfig{ExtraArgs: []string{"--spec-replace", "a", "b"}}
+	cfg.Config.Host = "127.0.0.1"
+	cfg.Config.Port = 9999 // 使用分配的端口
+	cfg.Config.CtxSize = 4096
+	cfg.Config.Poll = -1
+	cfg.Config.MainGPU = 0
+	cfg.Config.TensorSplit = TensorSplitAuto
+	cfg.Config.FlashAttn = true
+	cfg.Config.Temp = 0.7
+
+	got := buildServerArgs(cfg, "/models/m.gguf", 8100)
+	want := []string{
+		"--model", "/models/m.gguf",
+		"--host", "127.0.0.1", "--port", "8100",
+		"--ctx-size", "4096",
+		"--main-gpu", "0",
+		"--flash-attn",
+		"--yarn-ext-factor", "0.00",
+		"--temp", "0.70",
+		"--spec-replace", "a", "b",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildServerArgs =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestValidateExtraArgs(t *testing.T) {
+	cfg := &config.Config{}
+	s := &ModelService{config: cfg}
+	if err := s.validateExtraArgs([]string{"--spec-replace", "a", "b"}); err == nil || !strings.Contains(err.Error(), "EXTRA_ARGS_ALLOWLIST") {
+		t.Errorf("extra_args accepted without an allowlist: %v", err)
+	}
+
+	cfg.ExtraArgs.Allowlist = []string{"--spec-replace", "--override-kv", "--lora-*"}
+	for _, args := range [][]string{
+		{"--spec-replace", "a", "b"},
+		{"--override-kv=tokenizer.ggml.add_bos_token=bool:false"},
+		{"--lora-base", "/models/base.gguf", "--spec-replace", "-1", "x"},
+	} {
+		if err := s.validateExtraArgs(args); err != nil {
+			t.Errorf("validateExtraArgs(%v) failed: %v", args, err)
+		}
+	}
+	for _, args := range [][]string{
+		{"value-first"},
+		{"--rpc-layers", "1"},
+		{"--ctx-size", "8192"},
+		{"--model", "/etc/passwd"},
+	} {
+		if err := s.validateExtraArgs(args); err == nil {
+			t.Errorf("validateExtraArgs(%v) succeeded", args)
+		}
+	}
+	if slices.ContainsFunc(serverFlags, func(f serverFlag) bool { return f.flag == "--spec-replace" }) {
+		t.Error("test flag --spec-replace is now mapped, pick another one")
+	}
+}
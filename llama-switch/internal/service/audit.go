@@ -0,0 +1,198 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"llama-switch/internal/model"
+)
+
+// 审计日志中请求的结果
+const (
+	AuditSuccess = "success" // 请求成功（2xx、3xx）
+	AuditDenied  = "denied"  // 认证失败或角色不足（401、403）
+	AuditFailure = "failure" // 其他错误
+)
+
+const (
+	auditFileName     = "audit.jsonl" // 默认审计日志文件名
+	auditMaxString    = 200           // 请求体摘要中字符串保留的最大字符数
+	auditMaxListItems = 10            // 请求体摘要中完整保留的数组最大长度
+)
+
+// auditSensitiveField 请求体中不写入审计日志的字段（API密钥、令牌、密码等）
+var auditSensitiveField = regexp.MustCompile(`(?i)(key|token|secret|password|authorization)`)
+
+// AuditFilter 审计日志的查询条件，零值表示不过滤
+type AuditFilter struct {
+	Since     time.Time // 只返回此后的记录
+	Caller    string    // 调用方身份
+	ModelName string    // 操作的模型
+	Outcome   string    // 结果
+}
+
+// AuditLog 修改类API请求的审计日志：只追加写入JSON Lines文件，查询时读取文件
+type AuditLog struct {
+	path string
+
+	mu sync.Mutex
+}
+
+// NewAuditLog 创建审计日志
+func NewAuditLog(path string) *AuditLog {
+	return &AuditLog{path: path}
+}
+
+// defaultAuditPath 默认审计日志路径：程序目录下的audit.jsonl
+func defaultAuditPath() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return auditFileName
+	}
+	return filepath.Join(filepath.Dir(exePath), auditFileName)
+}
+
+// AuditOutcome 根据响应状态码确定请求的结果
+func AuditOutcome(status int) string {
+	switch {
+	case status < 400:
+		return AuditSuccess
+	case status == 401 || status == 403:
+		return AuditDenied
+	}
+	return AuditFailure
+}
+
+// Record 追加一条记录，写入失败时只输出警告，不影响请求
+func (l *AuditLog) Record(entry model.AuditEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.append(entry); err != nil {
+		slog.Warn("Failed to write audit log", "error", err)
+	}
+}
+
+// append 将记录追加写入文件，文件只允许switcher的运行用户读取
+func (l *AuditLog) append(entry model.AuditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %v", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Query 按条件查询记录（旧的在前），跳过无法解析的行
+func (l *AuditLog) Query(filter AuditFilter) ([]model.AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := []model.AuditEntry{}
+	file, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, fmt.Errorf("failed to read audit log: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var entry model.AuditEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil || !filter.matches(entry) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %v", err)
+	}
+	return entries, nil
+}
+
+// matches 检查记录是否满足查询条件
+func (f AuditFilter) matches(entry model.AuditEntry) bool {
+	if f.Caller != "" && entry.Caller != f.Caller {
+		return false
+	}
+	if f.ModelName != "" && entry.ModelName != f.ModelName {
+		return false
+	}
+	if f.Outcome != "" && entry.Outcome != f.Outcome {
+		return false
+	}
+	if !f.Since.IsZero() {
+		t, err := time.Parse(time.RFC3339, entry.Time)
+		if err != nil || t.Before(f.Since) {
+			return false
+		}
+	}
+	return true
+}
+
+// SummarizePayload 生成请求体的摘要：隐藏密钥类字段，截断过长的字符串，长数组和第二层以下的对象只记录大小。
+// 请求体不是JSON对象时返回nil
+func SummarizePayload(body []byte) map[string]interface{} {
+	var payload map[string]interface{}
+	if json.Unmarshal(body, &payload) != nil || len(payload) == 0 {
+		return nil
+	}
+	return summarizeObject(payload, 0)
+}
+
+// summarizeObject 生成对象的摘要，depth为对象的嵌套层数
+func summarizeObject(object map[string]interface{}, depth int) map[string]interface{} {
+	summary := make(map[string]interface{}, len(object))
+	for key, value := range object {
+		if auditSensitiveField.MatchString(key) {
+			summary[key] = "[redacted]"
+			continue
+		}
+		summary[key] = summarizeValue(value, depth)
+	}
+	return summary
+}
+
+// summarizeValue 生成字段值的摘要
+func summarizeValue(value interface{}, depth int) interface{} {
+	switch v := value.(type) {
+	case string:
+		if utf8.RuneCountInString(v) > auditMaxString {
+			return string([]rune(v)[:auditMaxString]) + "..."
+		}
+		return v
+	case map[string]interface{}:
+		if depth >= 1 {
+			return fmt.Sprintf("{%d fields}", len(v))
+		}
+		return summarizeObject(v, depth+1)
+	case []interface{}:
+		if len(v) > auditMaxListItems {
+			return fmt.Sprintf("[%d items]", len(v))
+		}
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = summarizeValue(item, depth+1)
+		}
+		return items
+	}
+	return value
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+func TestAuditLog(t *testing.T) {
+	l := NewAuditLog(filepath.Join(t.TempDir(), "audit", "audit.jsonl"))
+
+	// 文件不存在时返回空列表
+	entries, err := l.Query(AuditFilter{})
+	if err != nil || entries == nil || len(entries) != 0 {
+		t.Fatalf("Query on missing file = %v, %v", entries, err)
+	}
+
+	now := time.Now()
+	l.Record(model.AuditEntry{Time: now.Add(-2 * time.Hour).Format(time.RFC3339), Caller: "key:ci", ModelName: "chat", Status: 200, Outcome: AuditOutcome(200)})
+	l.Record(model.AuditEntry{Time: now.Add(-time.Hour).Format(time.RFC3339), Caller: "anonymous", ModelName: "chat", Status: 401, Outcome: AuditOutcome(401)})
+	l.Record(model.AuditEntry{Time: now.Format(time.RFC3339), Caller: "key:ci", ModelName: "code", Status: 409, Outcome: AuditOutcome(409)})
+
+	tests := []struct {
+		filter AuditFilter
+		want   []string // 按顺序的调用方/结果
+	}{
+		{AuditFilter{}, []string{"key:ci/success", "anonymous/denied", "key:ci/failure"}},
+		{AuditFilter{Caller: "key:ci"}, []string{"key:ci/success", "key:ci/failure"}},
+		{AuditFilter{ModelName: "chat", Outcome: AuditDenied}, []string{"anonymous/denied"}},
+		{AuditFilter{Since: now.Add(-90 * time.Minute)}, []string{"anonymous/denied", "key:ci/failure"}},
+	}
+	for _, tt := range tests {
+		entries, err := l.Query(tt.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, entry := range entries {
+			got = append(got, entry.Caller+"/"+entry.Outcome)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Query(%+v) = %v, want %v", tt.filter, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Query(%+v) = %v, want %v", tt.filter, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestSummarizePayload(t *testing.T) {
+	long := make([]byte, 300)
+	for i := range long {
+		long[i] = 'a'
+	}
+	summary := SummarizePayload([]byte(`{
+		"model_name": "chat",
+		"api_key": "secret",
+		"config": {"n_gpu_layers": 99, "env": {"HF_TOKEN": "x"}, "hf_token": "x"},
+		"models": ["a", "b"],
+		"prompts": [1,2,3,4,5,6,7,8,9,10,11],
+		"note": "` + string(long) + `"
+	}`))
+
+	if summary["model_name"] != "chat" || summary["api_key"] != "[redacted]" {
+		t.Errorf("summary = %v", summary)
+	}
+	config, _ := summary["config"].(map[string]interface{})
+	if config["n_gpu_layers"] != float64(99) || config["env"] != "{1 fields}" || config["hf_token"] != "[redacted]" {
+		t.Errorf("config summary = %v", config)
+	}
+	if models, _ := summary["models"].([]interface{}); len(models) != 2 || summary["prompts"] != "[11 items]" {
+		t.Errorf("list summary = %v, %v", summary["models"], summary["prompts"])
+	}
+	if note, _ := summary["note"].(string); len(note) != auditMaxString+3 {
+		t.Errorf("long string kept %d characters", len(note))
+	}
+	if SummarizePayload([]byte("not json")) != nil || SummarizePayload(nil) != nil {
+		t.Error("non-JSON body should have no summary")
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/auth"
+	"llama-switch/internal/config"
+)
+
+// AuthEnabled 配置了API_KEY、API_KEYS或JWT_ISSUER时API路由需要认证
+func (s *ModelService) AuthEnabled() bool {
+	cfg := s.config.Snapshot()
+	return cfg.Security.APIKey != "" || len(cfg.Security.APIKeys) > 0 || cfg.JWT.Issuer != ""
+}
+
+// newJWTValidator 配置了JWT_ISSUER时创建JWT验证器
+func newJWTValidator(cfg *config.Config) *auth.JWTValidator {
+	if cfg.JWT.Issuer == "" {
+		return nil
+	}
+	return auth.NewJWTValidator(auth.JWTOptions{
+		Issuer:    cfg.JWT.Issuer,
+		Audience:  cfg.JWT.Audience,
+		JWKSURL:   cfg.JWT.JWKSURL,
+		ClockSkew: time.Duration(cfg.JWT.ClockSkew) * time.Second,
+	})
+}
+
+// RoleForKey 根据API密钥确定角色：API_KEY为管理员，API_KEYS中的密钥为API_KEY_ROLES中指定的角色
+// （未指定时为只读），租户的密钥为操作员，身份提供方签发的JWT按声明映射角色；其他密钥返回ErrUnknownAPIKey
+func (s *ModelService) RoleForKey(key string) (auth.Role, error) {
+	if key == "" {
+		return auth.RoleNone, ErrUnknownAPIKey
+	}
+	cfg := s.config.Snapshot()
+	if admin := cfg.Security.APIKey; admin != "" && subtle.ConstantTimeCompare([]byte(key), []byte(admin)) == 1 {
+		return auth.RoleAdmin, nil
+	}
+	if name, ok := keyName(cfg, key); ok {
+		// 角色已在加载配置时验证
+		role, err := auth.ParseRole(cfg.Security.Roles[name])
+		if err != nil {
+			return auth.RoleReadOnly, nil
+		}
+		return role, nil
+	}
+	for _, tenantKey := range cfg.Tenants.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(tenantKey)) == 1 {
+			return auth.RoleOperator, nil
+		}
+	}
+	if s.jwt != nil && auth.LooksLikeJWT(key) {
+		claims, err := s.jwt.Validate(key)
+		if err != nil {
+			return auth.RoleNone, fmt.Errorf("%w: %v", ErrUnknownAPIKey, err)
+		}
+		return s.jwtRole(claims)
+	}
+	return auth.RoleNone, ErrUnknownAPIKey
+}
+
+// jwtRole 根据JWT_ROLE_CLAIM声明确定角色：声明中有多个值时取权限最高的角色，
+// 没有可映射的值时使用JWT_DEFAULT_ROLE，未设置默认角色时拒绝请求
+func (s *ModelService) jwtRole(claims auth.Claims) (auth.Role, error) {
+	best := auth.RoleNone
+	for _, value := range claims.Strings(s.config.JWT.RoleClaim) {
+		name := value
+		if len(s.config.JWT.RoleMap) > 0 {
+			name = s.config.JWT.RoleMap[value]
+		}
+		if role, err := auth.ParseRole(name); err == nil && role > best {
+			best = role
+		}
+	}
+	if best == auth.RoleNone && s.config.JWT.DefaultRole != "" {
+		// 默认角色已在加载配置时验证
+		best, _ = auth.ParseRole(s.config.JWT.DefaultRole)
+	}
+	if best == auth.RoleNone {
+		return auth.RoleNone, apierror.New(apierror.CodeForbidden, "token for %q has no role in claim %s", claims.Subject(), s.config.JWT.RoleClaim)
+	}
+	return best, nil
+}
+
+// CallerForKey 获取API密钥对应的调用方身份，用于审计日志：API_KEY为api_key，API_KEYS中的密钥为key:<名称>，
+// 租户的密钥为tenant:<租户>，JWT为jwt:<subject>；没有密钥时为anonymous，无效的密钥为unknown
+func (s *ModelService) CallerForKey(key string) string {
+	if key == "" {
+		return "anonymous"
+	}
+	cfg := s.config.Snapshot()
+	if admin := cfg.Security.APIKey; admin != "" && subtle.ConstantTimeCompare([]byte(key), []byte(admin)) == 1 {
+		return "api_key"
+	}
+	if name, ok := keyName(cfg, key); ok {
+		return "key:" + name
+	}
+	for name, tenantKey := range cfg.Tenants.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(tenantKey)) == 1 {
+			return "tenant:" + name
+		}
+	}
+	if s.jwt != nil && auth.LooksLikeJWT(key) {
+		if claims, err := s.jwt.Validate(key); err == nil {
+			return "jwt:" + claims.Subject()
+		}
+	}
+	return "unknown"
+}
+
+// keyName 在API_KEYS中查找密钥对应的名称
+func keyName(cfg *config.Config, key string) (string, bool) {
+	for name, k := range cfg.Security.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(k)) == 1 {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/auth"
+	"llama-switch/internal/config"
+)
+
+func TestRoleForKey(t *testing.T) {
+	cfg := &config.Config{}
+	s := &ModelService{config: cfg}
+	if s.AuthEnabled() {
+		t.Error("auth enabled without API keys")
+	}
+
+	cfg.Security.APIKey = "admin"
+	cfg.Security.APIKeys = map[string]string{"viewer": "ro", "ops": "op", "root": "full"}
+	cfg.Security.Roles = map[string]string{"ops": "operator", "root": "admin"}
+	cfg.Tenants.APIKeys = map[string]string{"team-a": "key-a"}
+	if !s.AuthEnabled() {
+		t.Error("auth not enabled with API keys")
+	}
+
+	tests := []struct {
+		key  string
+		want auth.Role
+		err  error
+	}{
+		{"admin", auth.RoleAdmin, nil},
+		{"full", auth.RoleAdmin, nil},
+		{"op", auth.RoleOperator, nil},
+		{"ro", auth.RoleReadOnly, nil},
+		{"key-a", auth.RoleOperator, nil},
+		{"", auth.RoleNone, ErrUnknownAPIKey},
+		{"other", auth.RoleNone, ErrUnknownAPIKey},
+	}
+	for _, tt := range tests {
+		if role, err := s.RoleForKey(tt.key); role != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("RoleForKey(%q) = %v, %v; want %v, %v", tt.key, role, err, tt.want, tt.err)
+		}
+	}
+
+	// API_KEYS中的密钥不属于任何租户
+	if tenant, err := s.TenantForKey("op"); tenant != "" || err != nil {
+		t.Errorf("TenantForKey(op) = %q, %v", tenant, err)
+	}
+
+	// 审计日志中的调用方身份
+	for key, want := range map[string]string{"admin": "api_key", "op": "key:ops", "key-a": "tenant:team-a", "": "anonymous", "other": "unknown"} {
+		if caller := s.CallerForKey(key); caller != want {
+			t.Errorf("CallerForKey(%q) = %q, want %q", key, caller, want)
+		}
+	}
+}
+
+func TestJWTRole(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.JWT.Issuer = "https://idp.example.com"
+	cfg.JWT.RoleClaim = "realm_access.roles"
+	s := &ModelService{config: cfg}
+	if !s.AuthEnabled() {
+		t.Error("auth not enabled with JWT issuer")
+	}
+
+	claims := func(roles ...interface{}) auth.Claims {
+		return auth.Claims{"sub": "alice", "realm_access": map[string]interface{}{"roles": roles}}
+	}
+	tests := []struct {
+		roleMap     map[string]string
+		defaultRole string
+		claims      auth.Claims
+		want        auth.Role
+	}{
+		// 未设置映射时声明值直接作为角色名称，取权限最高的角色
+		{nil, "", claims("readonly", "operator", "unknown"), auth.RoleOperator},
+		{map[string]string{"llm-admins": "admin", "llm-ops": "operator"}, "", claims("llm-ops", "llm-admins"), auth.RoleAdmin},
+		// 设置映射后未映射的值不作为角色名称
+		{map[string]string{"llm-ops": "operator"}, "readonly", claims("admin"), auth.RoleReadOnly},
+		{nil, "readonly", auth.Claims{"sub": "bob"}, auth.RoleReadOnly},
+		{nil, "", auth.Claims{"sub": "bob"}, auth.RoleNone},
+	}
+	for i, tt := range tests {
+		cfg.JWT.RoleMap = tt.roleMap
+		cfg.JWT.DefaultRole = tt.defaultRole
+		role, err := s.jwtRole(tt.claims)
+		if role != tt.want {
+			t.Errorf("case %d: role = %v, want %v", i, role, tt.want)
+		}
+		if tt.want == auth.RoleNone && apierror.CodeOf(err) != apierror.CodeForbidden {
+			t.Errorf("case %d: error = %v, want FORBIDDEN", i, err)
+		}
+	}
+
+	// 静态密钥之外的非JWT凭据仍然无效
+	s.jwt = newJWTValidator(cfg)
+	if _, err := s.RoleForKey("not-a-token"); !errors.Is(err, ErrUnknownAPIKey) {
+		t.Errorf("RoleForKey(not-a-token) error = %v", err)
+	}
+}
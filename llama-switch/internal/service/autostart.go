@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+// EventAutostartFailed 自动启动列表中的模型未能启动
+const EventAutostartFailed = "autostart_failed"
+
+// capacityPollInterval 等待显存时检查空闲显存的间隔
+const capacityPollInterval = 5 * time.Second
+
+// runAutostart 按顺序启动自动启动列表中尚未运行的模型，每个模型启动前等待其延迟并检查显存是否足够
+// 显存不足时不驱逐其他模型，等待最多AUTOSTART_CAPACITY_TIMEOUT秒后跳过
+func (s *ModelService) runAutostart(ctx context.Context) {
+	entries, err := config.ParseAutostart(s.config.Autostart.Models)
+	if err != nil {
+		slog.Error("Invalid autostart list", "error", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.Delay > 0 {
+			slog.Info("Autostart: waiting before starting model", "model_name", entry.Name, "delay", entry.Delay)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(entry.Delay):
+			}
+		}
+		if s.isRunning(entry.Name) {
+			slog.Info("Autostart: model is already running", "model_name", entry.Name)
+			continue
+		}
+
+		if err := s.autostartModel(ctx, entry.Name); err != nil {
+			message := fmt.Sprintf("Failed to autostart model '%s': %v", entry.Name, err)
+			slog.Error("Failed to autostart model", "model_name", entry.Name, "error", err)
+			s.events.Record(EventAutostartFailed, entry.Name, message, nil)
+		}
+	}
+}
+
+// autostartModel 启动自动启动列表中的一个模型，模型配置来自模型定义或持久化配置
+func (s *ModelService) autostartModel(ctx context.Context, name string) error {
+	cfg, err := s.autostartConfig(name)
+	if err != nil {
+		return err
+	}
+	if err := s.ValidateModelConfig(cfg); err != nil {
+		return err
+	}
+	if err := s.waitCapacity(ctx, cfg); err != nil {
+		return err
+	}
+
+	slog.Info("Autostart: starting model", "model_name", name)
+	_, err = s.StartModel(cfg)
+	return err
+}
+
+// autostartConfig 获取模型的启动配置：优先使用模型定义（填充全局默认配置），其次使用持久化配置中最近一次的配置
+func (s *ModelService) autostartConfig(name string) (*model.ModelConfig, error) {
+	if cfg, specified, exists := s.modelDefs.Get(name); exists {
+		s.ApplyModelDefaults(cfg, specified)
+		return cfg, nil
+	}
+
+	configs, err := s.persistentMgr.GetModelConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load persistent configs: %v", err)
+	}
+	if item, exists := configs[name]; exists && item.ModelConfig != nil {
+		return cloneModelConfig(item.ModelConfig), nil
+	}
+	return nil, fmt.Errorf("model is neither defined in %s nor has a persisted config", s.modelDefs.dir)
+}
+
+// waitCapacity 等待GPU空闲显存足够启动模型，不使用GPU的模型直接返回
+func (s *ModelService) waitCapacity(ctx context.Context, cfg *model.ModelConfig) error {
+	preview, err := s.PreviewModel(cfg)
+	if err != nil {
+		return err
+	}
+	if preview.VRAMEstimate == nil || !(cfg.ForceVRAM || cfg.Config.NGPULayers > 0 || preview.Offload != nil) {
+		return nil
+	}
+	required := preview.VRAMEstimate.TotalMB
+
+	deadline := time.Now().Add(time.Duration(s.config.Autostart.CapacityTimeout) * time.Second)
+	for {
+		available, err := s.getTotalAvailableVRAM()
+		if err != nil {
+			return fmt.Errorf("failed to check VRAM: %v", err)
+		}
+		if available >= required {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return apierror.New(apierror.CodeInsufficientVRAM, "insufficient VRAM (required: %dMB, available: %dMB), skipped without evicting other models", required, available)
+		}
+		slog.Info("Autostart: waiting for VRAM", "model_name", cfg.ModelName, "required_mb", required, "available_mb", available)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(capacityPollInterval):
+		}
+	}
+}
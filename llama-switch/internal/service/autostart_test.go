@@ -0,0 +1,43 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestAutostartConfig(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{PersistentDir: t.TempDir()}
+	cfg.DefaultModel.CtxSize = 4096
+	s := &ModelService{
+		config:        cfg,
+		persistentMgr: config.NewPersistentManager(cfg),
+		events:        NewEventLog(filepath.Join(t.TempDir(), "events.jsonl"), 10),
+	}
+	s.modelDefs = newModelDefinitions(s, dir)
+
+	os.WriteFile(filepath.Join(dir, "defined.yaml"), []byte("model_name: autostart-defined\nmodel_path: d.gguf\n"), 0644)
+	s.modelDefs.scan()
+	persisted := &model.ModelConfig{ModelName: "autostart-persisted", ModelPath: "p.gguf"}
+	if err := s.persistentMgr.UpdateModelConfig(persisted.ModelName, persisted, &model.ModelStatus{ModelName: persisted.ModelName}); err != nil {
+		t.Fatal(err)
+	}
+	defer s.persistentMgr.RemoveModelConfig(persisted.ModelName)
+
+	// 模型定义填充全局默认配置，持久化配置保持原样
+	defined, err := s.autostartConfig("autostart-defined")
+	if err != nil || defined.ModelPath != "d.gguf" || defined.Config.CtxSize != 4096 {
+		t.Errorf("autostartConfig(defined) = %+v, %v", defined, err)
+	}
+	restored, err := s.autostartConfig("autostart-persisted")
+	if err != nil || restored.ModelPath != "p.gguf" || restored.Config.CtxSize != 0 {
+		t.Errorf("autostartConfig(persisted) = %+v, %v", restored, err)
+	}
+	if _, err := s.autostartConfig("autostart-unknown"); err == nil {
+		t.Error("autostartConfig succeeded for an unknown model")
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+)
+
+// llama-server未指定地址和端口时的默认值
+const (
+	defaultBackendHost = "127.0.0.1"
+	defaultBackendPort = 8080
+)
+
+// backendClient 访问模型实例管理端点的HTTP客户端
+var backendClient = &http.Client{Timeout: 60 * time.Second}
+
+// 模型实例健康状态
+const (
+	HealthHealthy   = "healthy"   // 实例可正常处理请求
+	HealthLoading   = "loading"   // 实例正在加载模型
+	HealthUnhealthy = "unhealthy" // 实例无响应或返回错误
+	HealthOffline   = "offline"   // 实例未运行
+)
+
+// healthProbeTimeout 单次健康探测的超时时间
+const healthProbeTimeout = 3 * time.Second
+
+// Backend 运行中模型实例的访问信息
+type Backend struct {
+	ModelName string             // 模型名称标识
+	URL       *url.URL           // 实例的基础访问地址
+	Config    *model.ModelConfig // 实例的启动配置
+}
+
+// GetBackend 获取指定运行中模型的访问地址
+func (s *ModelService) GetBackend(name string) (*Backend, error) {
+	status := s.processManager.FindModel(name)
+	if status == nil || !status.Running {
+		return nil, apierror.New(apierror.CodeModelNotFound, "model '%s' not found or not running", name)
+	}
+
+	host := status.Host
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = defaultBackendHost
+	}
+	port := status.Port
+	if port == 0 {
+		port = defaultBackendPort
+	}
+
+	return &Backend{
+		ModelName: name,
+		URL: &url.URL{
+			Scheme: "http",
+			Host:   net.JoinHostPort(host, strconv.Itoa(port)),
+		},
+		Config: s.runningConfig(name),
+	}, nil
+}
+
+// setRunningConfig 记录运行中模型的启动配置
+func (s *ModelService) setRunningConfig(name string, cfg *model.ModelConfig) {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	s.configs[name] = cfg
+}
+
+// runningConfig 获取运行中模型的启动配置，未记录时从持久化配置中读取
+func (s *ModelService) runningConfig(name string) *model.ModelConfig {
+	s.configMu.RLock()
+	cfg, exists := s.configs[name]
+	s.configMu.RUnlock()
+	if exists {
+		return cfg
+	}
+
+	configs, err := s.persistentMgr.GetModelConfigs()
+	if err != nil {
+		return &model.ModelConfig{ModelName: name}
+	}
+	if item, exists := configs[name]; exists && item.ModelConfig != nil {
+		return item.ModelConfig
+	}
+	return &model.ModelConfig{ModelName: name}
+}
+
+// GetBackendsWhere 获取满足条件的运行中模型实例
+func (s *ModelService) GetBackendsWhere(match func(cfg *model.ModelConfig) bool) []*Backend {
+	var backends []*Backend
+	for _, name := range s.GetRunningModelNames() {
+		backend, err := s.GetBackend(name)
+		if err != nil {
+			continue
+		}
+		if match(backend.Config) {
+			backends = append(backends, backend)
+		}
+	}
+	return backends
+}
+
+// GetRunningModelNames 获取所有运行中模型的名称
+func (s *ModelService) GetRunningModelNames() []string {
+	models := s.processManager.GetRunningModels()
+	names := make([]string, 0, len(models))
+	for _, m := range models {
+		names = append(names, m.ModelName)
+	}
+	return names
+}
+
+// WaitForReady 等待模型实例的/health端点就绪
+func (s *ModelService) WaitForReady(ctx context.Context, name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		backend, err := s.GetBackend(name)
+		if err != nil {
+			return err
+		}
+
+		resp, err := backendClient.Get(backend.URL.JoinPath("health").String())
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("model '%s' not ready after %v", name, timeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// slotAction 对模型实例的插槽执行保存/恢复操作
+func (b *Backend) slotAction(slotID int, action, filename string) error {
+	body, _ := json.Marshal(map[string]string{"filename": filename})
+	u := b.URL.JoinPath("slots", strconv.Itoa(slotID))
+	u.RawQuery = url.Values{"action": {action}}.Encode()
+
+	resp, err := backendClient.Post(u.String(), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("slot %d %s failed: %v", slotID, action, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("slot %d %s failed: %s: %s", slotID, action, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// errSlotsUnavailable 实例未启用/slots端点（llama-server使用--no-slots启动）
+var errSlotsUnavailable = errors.New("slots endpoint not available")
+
+// slotState llama-server /slots端点返回的单个插槽状态
+type slotState struct {
+	ID           int  `json:"id"`
+	IsProcessing bool `json:"is_processing"`
+}
+
+// fetchSlots 查询模型实例的插槽状态
+func (b *Backend) fetchSlots(ctx context.Context) ([]slotState, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.URL.JoinPath("slots").String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := backendClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotImplemented, http.StatusNotFound:
+		return nil, errSlotsUnavailable
+	default:
+		return nil, fmt.Errorf("slots endpoint returned %s", resp.Status)
+	}
+
+	var slots []slotState
+	if err := json.NewDecoder(resp.Body).Decode(&slots); err != nil {
+		return nil, fmt.Errorf("failed to decode slots: %v", err)
+	}
+	return slots, nil
+}
+
+// ProbeHealth 探测模型实例的/health与/slots端点，返回健康状态
+func (s *ModelService) ProbeHealth(ctx context.Context, name string) string {
+	return s.checkHealth(ctx, name).Status
+}
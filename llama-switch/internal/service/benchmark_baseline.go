@@ -0,0 +1,227 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+)
+
+// baselineFileName 固定基线持久化文件名
+const baselineFileName = "benchmark_baselines.json"
+
+// EventBaselineRegression 测试结果的吞吐量低于模型固定基线超过阈值
+const EventBaselineRegression = "benchmark_baseline_regression"
+
+// BenchmarkBaselines 每个模型固定的基准测试基线：之后该模型完成的测试自动与基线比较
+type BenchmarkBaselines struct {
+	path    string
+	history *BenchmarkHistory
+
+	mu        sync.RWMutex
+	baselines map[string]*model.BenchmarkBaseline // 按模型文件路径索引
+}
+
+// NewBenchmarkBaselines 创建固定基线管理器并加载已保存的基线
+func NewBenchmarkBaselines(path string, history *BenchmarkHistory) *BenchmarkBaselines {
+	b := &BenchmarkBaselines{
+		path:      path,
+		history:   history,
+		baselines: make(map[string]*model.BenchmarkBaseline),
+	}
+	if err := b.load(); err != nil {
+		slog.Warn("Failed to load benchmark baselines", "file", path, "error", err)
+	}
+	return b
+}
+
+// defaultBaselinePath 默认固定基线文件路径：程序目录下的config目录
+func defaultBaselinePath() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return filepath.Join("config", baselineFileName)
+	}
+	return filepath.Join(filepath.Dir(exePath), "config", baselineFileName)
+}
+
+// Pin 将历史记录中已完成的任务固定为其模型的基线，替换该模型原有的基线
+func (b *BenchmarkBaselines) Pin(taskID string) (*model.BenchmarkBaseline, error) {
+	record, err := b.history.Find(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, apierror.New(apierror.CodeBenchmarkNotFound, "task not found: %s", taskID)
+	}
+	if record.Status != "completed" || len(record.Results) == 0 {
+		return nil, fmt.Errorf("task %s has no completed llama-bench results (status: %s)", taskID, record.Status)
+	}
+
+	baseline := &model.BenchmarkBaseline{
+		ModelPath: record.ModelPath,
+		TaskID:    record.TaskID,
+		PinnedAt:  time.Now().Format(time.RFC3339),
+		Args:      record.Args,
+		Results:   record.Results,
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.baselines[baseline.ModelPath] = baseline
+	if err := b.save(); err != nil {
+		return nil, err
+	}
+	slog.Info("Benchmark baseline pinned", "model_path", baseline.ModelPath, "task_id", taskID)
+	return baseline, nil
+}
+
+// Unpin 取消模型的固定基线
+func (b *BenchmarkBaselines) Unpin(modelPath string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, exists := b.baselines[modelPath]; !exists {
+		return fmt.Errorf("no baseline pinned for model: %s", modelPath)
+	}
+	delete(b.baselines, modelPath)
+	if err := b.save(); err != nil {
+		return err
+	}
+	slog.Info("Benchmark baseline removed", "model_path", modelPath)
+	return nil
+}
+
+// List 获取所有固定基线，按模型文件路径排序
+func (b *BenchmarkBaselines) List() []*model.BenchmarkBaseline {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	baselines := make([]*model.BenchmarkBaseline, 0, len(b.baselines))
+	for _, modelPath := range slices.Sorted(maps.Keys(b.baselines)) {
+		baselines = append(baselines, b.baselines[modelPath])
+	}
+	return baselines
+}
+
+// Compare 将测试结果与模型的固定基线比较，模型没有基线、任务本身是基线或没有可比较的测试类型时返回nil
+func (b *BenchmarkBaselines) Compare(modelPath, taskID string, results []*model.BenchmarkResults, thresholdPct float64) *model.BaselineComparison {
+	b.mu.RLock()
+	baseline, exists := b.baselines[modelPath]
+	b.mu.RUnlock()
+	if !exists || baseline.TaskID == taskID {
+		return nil
+	}
+
+	deltas := compareBaseline(results, baseline.Results, thresholdPct)
+	if len(deltas) == 0 {
+		return nil
+	}
+	comparison := &model.BaselineComparison{
+		TaskID:       baseline.TaskID,
+		ThresholdPct: thresholdPct,
+		Deltas:       deltas,
+	}
+	for _, delta := range deltas {
+		if delta.Regression {
+			comparison.Regressed = true
+		}
+	}
+	return comparison
+}
+
+// recordBaselineRegression 在日志和事件日志中记录相对固定基线的性能下降
+func (s *BenchmarkService) recordBaselineRegression(job *benchmarkJob, comparison *model.BaselineComparison) {
+	var lines []string
+	for _, delta := range comparison.Deltas {
+		if delta.Regression {
+			lines = append(lines, fmt.Sprintf("%s: %.2f t/s vs baseline %.2f t/s (%.1f%%)",
+				delta.TestType, delta.TokensPerSecond, delta.BaselineTPS, delta.DeltaPct))
+		}
+	}
+	message := fmt.Sprintf("Benchmark %s regressed against baseline %s on %s: %s",
+		job.taskID, comparison.TaskID, filepath.Base(job.manifest.ModelPath), strings.Join(lines, "; "))
+	slog.Warn("Benchmark regressed against baseline", "task_id", job.taskID, "baseline_task_id", comparison.TaskID, "model_path", job.manifest.ModelPath, "regressions", strings.Join(lines, "; "))
+	if s.models != nil {
+		s.models.Events().Record(EventBaselineRegression, "", message, comparison)
+	}
+}
+
+// compareBaseline 按测试类型计算吞吐量变化，同一类型出现多次（如多组参数）时按出现顺序一一对应
+func compareBaseline(results, baseline []*model.BenchmarkResults, thresholdPct float64) []model.BaselineDelta {
+	var deltas []model.BaselineDelta
+	seen := make(map[string]int)
+	for _, current := range results {
+		n := seen[current.TestType]
+		seen[current.TestType]++
+
+		var previous *model.BenchmarkResults
+		for _, candidate := range baseline {
+			if candidate.TestType != current.TestType {
+				continue
+			}
+			if n == 0 {
+				previous = candidate
+				break
+			}
+			n--
+		}
+		if previous == nil || previous.TokensPerSecond <= 0 {
+			continue
+		}
+
+		deltaPct := (current.TokensPerSecond - previous.TokensPerSecond) / previous.TokensPerSecond * 100
+		deltas = append(deltas, model.BaselineDelta{
+			TestType:        current.TestType,
+			TokensPerSecond: current.TokensPerSecond,
+			BaselineTPS:     previous.TokensPerSecond,
+			DeltaPct:        deltaPct,
+			Regression:      -deltaPct > thresholdPct,
+		})
+	}
+	return deltas
+}
+
+// load 从文件加载固定基线
+func (b *BenchmarkBaselines) load() error {
+	data, err := os.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read baseline file: %v", err)
+	}
+
+	var baselines []*model.BenchmarkBaseline
+	if err := json.Unmarshal(data, &baselines); err != nil {
+		return fmt.Errorf("failed to parse baseline file: %v", err)
+	}
+	for _, baseline := range baselines {
+		b.baselines[baseline.ModelPath] = baseline
+	}
+	return nil
+}
+
+// save 保存固定基线到文件（调用方需持有锁）
+func (b *BenchmarkBaselines) save() error {
+	baselines := make([]*model.BenchmarkBaseline, 0, len(b.baselines))
+	for _, modelPath := range slices.Sorted(maps.Keys(b.baselines)) {
+		baselines = append(baselines, b.baselines[modelPath])
+	}
+	data, err := json.MarshalIndent(baselines, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize benchmark baselines: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return fmt.Errorf("failed to create baseline directory: %v", err)
+	}
+	if err := os.WriteFile(b.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write baseline file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"llama-switch/internal/model"
+)
+
+func TestCompareBaseline(t *testing.T) {
+	baseline := []*model.BenchmarkResults{
+		{TestType: "pp512", TokensPerSecond: 200},
+		{TestType: "tg128", TokensPerSecond: 10},
+		{TestType: "tg128", TokensPerSecond: 20},
+	}
+	results := []*model.BenchmarkResults{
+		{TestType: "pp512", TokensPerSecond: 210},
+		{TestType: "tg128", TokensPerSecond: 9},
+		{TestType: "tg128", TokensPerSecond: 19.5},
+		{TestType: "pp1024", TokensPerSecond: 150},
+	}
+
+	deltas := compareBaseline(results, baseline, 5)
+	if len(deltas) != 3 {
+		t.Fatalf("got %d deltas, want 3 (pp1024 has no baseline): %+v", len(deltas), deltas)
+	}
+	want := []struct {
+		deltaPct   float64
+		regression bool
+	}{{5, false}, {-10, true}, {-2.5, false}}
+	for i, w := range want {
+		if math.Abs(deltas[i].DeltaPct-w.deltaPct) > 1e-9 || deltas[i].Regression != w.regression {
+			t.Errorf("delta %d = %+v, want delta_pct %.1f regression %v", i, deltas[i], w.deltaPct, w.regression)
+		}
+	}
+}
+
+func TestBenchmarkBaselines(t *testing.T) {
+	dir := t.TempDir()
+	history := NewBenchmarkHistory(filepath.Join(dir, "history.jsonl"))
+	history.Record(&model.BenchmarkRecord{
+		TaskID:    "base",
+		Status:    "completed",
+		ModelPath: "/models/a.gguf",
+		Results:   []*model.BenchmarkResults{{TestType: "tg128", TokensPerSecond: 10}},
+	})
+	history.Record(&model.BenchmarkRecord{TaskID: "failed", Status: "failed", ModelPath: "/models/a.gguf"})
+
+	path := filepath.Join(dir, "baselines.json")
+	b := NewBenchmarkBaselines(path, history)
+	if _, err := b.Pin("failed"); err == nil {
+		t.Error("Pin succeeded for a failed task")
+	}
+	if _, err := b.Pin("base"); err != nil {
+		t.Fatalf("Pin failed: %v", err)
+	}
+
+	// 基线在重启后仍然有效，基线任务本身不与自己比较
+	loaded := NewBenchmarkBaselines(path, history)
+	results := []*model.BenchmarkResults{{TestType: "tg128", TokensPerSecond: 8}}
+	if comparison := loaded.Compare("/models/a.gguf", "base", results, 5); comparison != nil {
+		t.Errorf("baseline task compared with itself: %+v", comparison)
+	}
+	comparison := loaded.Compare("/models/a.gguf", "new", results, 5)
+	if comparison == nil || !comparison.Regressed || comparison.TaskID != "base" {
+		t.Fatalf("unexpected comparison: %+v", comparison)
+	}
+	if comparison := loaded.Compare("/models/b.gguf", "new", results, 5); comparison != nil {
+		t.Errorf("model without baseline compared: %+v", comparison)
+	}
+
+	if err := loaded.Unpin("/models/a.gguf"); err != nil {
+		t.Fatalf("Unpin failed: %v", err)
+	}
+	if len(NewBenchmarkBaselines(path, history).List()) != 0 {
+		t.Error("baseline still present after Unpin")
+	}
+}
@@ -2,18 +2,24 @@ package service
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
+
+	"llama-switch/internal/apierror"
 )
 
-// StopTask 停止指定的基准测试任务
+// StopTask 停止指定的基准测试任务，排队中的任务直接移出队列
 func (s *BenchmarkService) StopTask(taskID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	status, exists := s.tasks[taskID]
 	if !exists {
-		return fmt.Errorf("task not found: %s", taskID)
+		return apierror.New(apierror.CodeBenchmarkNotFound, "task not found: %s", taskID)
+	}
+
+	if s.cancelQueued(taskID) {
+		return nil
 	}
 
 	if status.Status != "running" {
@@ -28,16 +34,20 @@ func (s *BenchmarkService) StopTask(taskID string) error {
 	// 更新任务状态
 	status.Status = "cancelled"
 	status.EndTime = time.Now().Format(time.RFC3339)
-	log.Printf("Benchmark task cancelled: %s", taskID)
+	slog.Info("Benchmark task cancelled", "task_id", taskID)
 
 	return nil
 }
 
-// StopAllTasks 停止所有正在运行的基准测试任务
+// StopAllTasks 停止所有正在运行和排队的基准测试任务
 func (s *BenchmarkService) StopAllTasks() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	for len(s.queue) > 0 {
+		s.cancelQueued(s.queue[0].taskID)
+	}
+
 	for taskID, status := range s.tasks {
 		if status.Status == "running" {
 			if status.CancelFunc != nil {
@@ -45,13 +55,28 @@ func (s *BenchmarkService) StopAllTasks() {
 			}
 			status.Status = "cancelled"
 			status.EndTime = time.Now().Format(time.RFC3339)
-			log.Printf("Benchmark task cancelled: %s", taskID)
+			slog.Info("Benchmark task cancelled", "task_id", taskID)
 		}
 	}
 }
 
-// Cleanup 清理所有任务资源
+// Cleanup 清理所有任务资源，停止测试套件并等待执行中的任务结束以便继续执行被暂停的模型
 func (s *BenchmarkService) Cleanup() {
-	log.Println("Cleaning up benchmark service...")
+	slog.Info("Cleaning up benchmark service")
+	s.mu.Lock()
+	s.closing = true
+	s.mu.Unlock()
+	s.cancel()
 	s.StopAllTasks()
+
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(benchmarkShutdownTimeout):
+		slog.Warn("Benchmark tasks did not finish in time", "timeout", benchmarkShutdownTimeout)
+	}
 }
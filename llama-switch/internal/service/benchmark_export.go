@@ -0,0 +1,211 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+)
+
+// 导出格式
+const (
+	ExportFormatJSON     = "json"
+	ExportFormatCSV      = "csv"
+	ExportFormatMarkdown = "md"
+)
+
+// ErrBenchmarkNotFinished 任务仍在排队或运行，没有可导出的结果
+var ErrBenchmarkNotFinished = apierror.New(apierror.CodeBenchmarkNotFinished, "benchmark task has not finished")
+
+// ValidateExportFormat 验证导出格式，为空表示JSON
+func ValidateExportFormat(format string) error {
+	switch format {
+	case "", ExportFormatJSON, ExportFormatCSV, ExportFormatMarkdown:
+		return nil
+	}
+	return fmt.Errorf("invalid export format: %s (expected csv, md or json)", format)
+}
+
+// Export 从历史记录中读取已结束任务的结果，构建和设备信息从保存的llama-bench输出中解析，缺失时使用环境快照
+func (s *BenchmarkService) Export(taskID string) (*model.BenchmarkExport, error) {
+	s.mu.RLock()
+	status, exists := s.tasks[taskID]
+	pending := exists && (status.Status == "queued" || status.Status == "running")
+	s.mu.RUnlock()
+	if pending {
+		return nil, ErrBenchmarkNotFinished
+	}
+
+	record, err := s.history.Find(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, apierror.New(apierror.CodeBenchmarkNotFound, "task not found: %s", taskID)
+	}
+
+	export := &model.BenchmarkExport{
+		TaskID:    record.TaskID,
+		Status:    record.Status,
+		ModelPath: record.ModelPath,
+		StartTime: record.StartTime,
+		EndTime:   record.EndTime,
+		Args:      record.Args,
+		Error:     record.Error,
+		Results:   record.Results,
+		Serving:   record.Serving,
+	}
+	if record.Output != "" {
+		if parsed, err := ParseBenchmarkOutput(record.Output); err == nil {
+			export.Build.Commit = parsed.BuildInfo.CommitHash
+			export.Build.Number = parsed.BuildInfo.BuildNumber
+			for _, device := range parsed.DeviceInfo.CUDADevices {
+				export.Devices = append(export.Devices, device.Name)
+			}
+			export.Backends = parsed.DeviceInfo.BackendsLoaded
+		}
+	}
+	// 历史记录中的环境快照优先，旧记录没有时使用可复现清单
+	env := record.Environment
+	if env == nil {
+		if manifest, err := s.LoadManifest(taskID); err == nil {
+			env = &manifest.BenchmarkEnvironment
+		}
+	}
+	if env != nil {
+		export.Build.Version = env.LlamaCppBuild
+		if len(export.Devices) == 0 {
+			export.Devices = env.GPUs
+		}
+		export.DriverVersion = env.DriverVersion
+		export.CUDAVersion = env.CUDAVersion
+		export.Host = &env.Host
+	}
+	return export, nil
+}
+
+// RenderBenchmarkExport 按格式渲染导出结果，返回内容和Content-Type
+func RenderBenchmarkExport(export *model.BenchmarkExport, format string) ([]byte, string, error) {
+	switch format {
+	case "", ExportFormatJSON:
+		data, err := json.MarshalIndent(export, "", "  ")
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to serialize export: %v", err)
+		}
+		return append(data, '\n'), "application/json", nil
+	case ExportFormatCSV:
+		data, err := renderExportCSV(export)
+		return data, "text/csv; charset=utf-8", err
+	case ExportFormatMarkdown:
+		return renderExportMarkdown(export), "text/markdown; charset=utf-8", nil
+	}
+	return nil, "", ValidateExportFormat(format)
+}
+
+// renderExportCSV 每个测试结果一行，构建和设备信息重复在每一行中以便单独使用
+func renderExportCSV(export *model.BenchmarkExport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	common := []string{export.TaskID, export.ModelPath, export.Build.Commit, export.Build.Number, strings.Join(export.Devices, "; ")}
+	header := []string{"task_id", "model_path", "build_commit", "build_number", "devices"}
+
+	if len(export.Serving) > 0 {
+		w.Write(append(header, "concurrency", "prompt_tokens", "requests", "failed", "duration_sec",
+			"output_tokens_per_second", "requests_per_second", "ttft_p50_ms", "ttft_p90_ms", "ttft_p99_ms",
+			"itl_p50_ms", "itl_p90_ms", "itl_p99_ms", "latency_p50_ms", "latency_p99_ms"))
+		for _, l := range export.Serving {
+			w.Write(append(slices.Clone(common), strconv.Itoa(l.Concurrency), strconv.Itoa(l.PromptTokens),
+				strconv.Itoa(l.Requests), strconv.Itoa(l.Failed), formatFloat(l.DurationSec),
+				formatFloat(l.OutputTokensPerSecond), formatFloat(l.RequestsPerSecond),
+				formatFloat(l.TTFT.P50), formatFloat(l.TTFT.P90), formatFloat(l.TTFT.P99),
+				formatFloat(l.ITL.P50), formatFloat(l.ITL.P90), formatFloat(l.ITL.P99),
+				formatFloat(l.Latency.P50), formatFloat(l.Latency.P99)))
+		}
+	} else {
+		w.Write(append(header, "model", "size", "params", "backend", "gpu_layers", "mmap", "test_type",
+			"tokens_per_second", "variation"))
+		for _, r := range export.Results {
+			w.Write(append(slices.Clone(common), r.Model, r.Size, r.Params, r.Backend, strconv.Itoa(r.GPULayers),
+				strconv.FormatBool(r.MMap), r.TestType, formatFloat(r.TokensPerSecond), formatFloat(r.Variation)))
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderExportMarkdown 生成适合粘贴到issue或文档中的Markdown：环境信息列表和结果表格
+func renderExportMarkdown(export *model.BenchmarkExport) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Benchmark %s\n\n", export.TaskID)
+	item := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "- **%s**: %s\n", name, value)
+		}
+	}
+	item("Model", "`"+export.ModelPath+"`")
+	item("Status", export.Status)
+	item("Started", export.StartTime)
+	item("Finished", export.EndTime)
+	build := export.Build.Commit
+	if export.Build.Number != "" {
+		build = fmt.Sprintf("%s (%s)", build, export.Build.Number)
+	}
+	item("Build", strings.TrimSpace(build))
+	item("Version", export.Build.Version)
+	item("Devices", strings.Join(export.Devices, ", "))
+	item("Backends", strings.Join(export.Backends, ", "))
+	item("Driver", export.DriverVersion)
+	item("CUDA", export.CUDAVersion)
+	if h := export.Host; h != nil {
+		item("Host", fmt.Sprintf("%s (%s/%s, %d CPUs)", h.Hostname, h.OS, h.Arch, h.CPUs))
+		item("CPU", h.CPUModel)
+		if h.MemoryMB > 0 {
+			item("Memory", fmt.Sprintf("%d MB", h.MemoryMB))
+		}
+	}
+	if len(export.Args) > 0 {
+		item("Args", "`"+strings.Join(export.Args, " ")+"`")
+	}
+	item("Error", export.Error)
+	b.WriteString("\n")
+
+	row := func(cells ...string) {
+		for i, cell := range cells {
+			cells[i] = strings.ReplaceAll(cell, "|", "\\|")
+		}
+		fmt.Fprintf(&b, "| %s |\n", strings.Join(cells, " | "))
+	}
+	if len(export.Serving) > 0 {
+		row("concurrency", "prompt", "requests", "failed", "tokens/s", "req/s", "TTFT p50 (ms)", "TTFT p99 (ms)", "ITL p50 (ms)", "ITL p99 (ms)")
+		row("---:", "---:", "---:", "---:", "---:", "---:", "---:", "---:", "---:", "---:")
+		for _, l := range export.Serving {
+			row(strconv.Itoa(l.Concurrency), strconv.Itoa(l.PromptTokens), strconv.Itoa(l.Requests), strconv.Itoa(l.Failed),
+				formatFloat(l.OutputTokensPerSecond), formatFloat(l.RequestsPerSecond),
+				formatFloat(l.TTFT.P50), formatFloat(l.TTFT.P99), formatFloat(l.ITL.P50), formatFloat(l.ITL.P99))
+		}
+	} else if len(export.Results) > 0 {
+		row("model", "size", "params", "backend", "ngl", "mmap", "test", "t/s")
+		row("---", "---:", "---:", "---", "---:", "---", "---:", "---:")
+		for _, r := range export.Results {
+			row(r.Model, r.Size, r.Params, r.Backend, strconv.Itoa(r.GPULayers), strconv.FormatBool(r.MMap), r.TestType,
+				fmt.Sprintf("%s ± %s", formatFloat(r.TokensPerSecond), formatFloat(r.Variation)))
+		}
+	} else {
+		b.WriteString("No results.\n")
+	}
+	return []byte(b.String())
+}
+
+// formatFloat 保留两位小数
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
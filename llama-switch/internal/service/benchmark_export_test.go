@@ -0,0 +1,74 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestBenchmarkExport(t *testing.T) {
+	cfg := &config.Config{ModelsDir: t.TempDir()}
+	cfg.Benchmark.HistoryFile = filepath.Join(cfg.ModelsDir, "history.jsonl")
+	s := NewBenchmarkService(cfg, nil)
+
+	output := `[{"build_commit": "abc1234", "build_number": 4567, "gpu_info": "NVIDIA RTX 4090", "backends": "CUDA",
+		"model_type": "qwen2 7B Q4_K_M", "model_size": 4683072512, "model_n_params": 7615616512, "n_gpu_layers": 99,
+		"n_prompt": 512, "n_gen": 0, "avg_ts": 1850.2, "stddev_ts": 3.1}]`
+	results, err := ParseBenchmarkOutput(output)
+	if err != nil {
+		t.Fatalf("ParseBenchmarkOutput failed: %v", err)
+	}
+	test := results.Tests[0]
+	s.history.Record(&model.BenchmarkRecord{
+		TaskID:    "done",
+		Status:    "completed",
+		ModelPath: "/models/qwen2-7b-q4_k_m.gguf",
+		Output:    output,
+		Results: []*model.BenchmarkResults{{Model: test.Model, Size: test.Size, Params: test.Params, Backend: "CUDA|Vulkan",
+			GPULayers: 99, TestType: test.TestType, TokensPerSecond: test.TokensPerSecond, Variation: test.Variation}},
+	})
+	s.tasks["running"] = &model.BenchmarkStatus{TaskID: "running", Status: "running"}
+
+	if _, err := s.Export("running"); !errors.Is(err, ErrBenchmarkNotFinished) {
+		t.Errorf("Export(running) error = %v, want ErrBenchmarkNotFinished", err)
+	}
+	if _, err := s.Export("missing"); err == nil {
+		t.Error("Export(missing) succeeded, want error")
+	}
+
+	export, err := s.Export("done")
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if export.Build.Commit != "abc1234" || export.Build.Number != "4567" || len(export.Devices) != 1 || export.Devices[0] != "NVIDIA RTX 4090" {
+		t.Errorf("export build/devices = %+v, %v", export.Build, export.Devices)
+	}
+
+	data, contentType, err := RenderBenchmarkExport(export, ExportFormatCSV)
+	if err != nil || contentType != "text/csv; charset=utf-8" {
+		t.Fatalf("RenderBenchmarkExport(csv) = %q, %v", contentType, err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("CSV rows = %v, %v", rows, err)
+	}
+	if rows[1][0] != "done" || rows[1][2] != "abc1234" || rows[1][4] != "NVIDIA RTX 4090" || rows[1][11] != "pp512" || rows[1][12] != "1850.20" {
+		t.Errorf("CSV row = %v", rows[1])
+	}
+
+	data, _, _ = RenderBenchmarkExport(export, ExportFormatMarkdown)
+	for _, want := range []string{"- **Build**: abc1234 (4567)", "- **Devices**: NVIDIA RTX 4090", "| CUDA\\|Vulkan |", "| 1850.20 ± 3.10 |"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Markdown missing %q:\n%s", want, data)
+		}
+	}
+
+	if _, _, err := RenderBenchmarkExport(export, "xml"); err == nil {
+		t.Error("RenderBenchmarkExport(xml) succeeded, want error")
+	}
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// benchmarkHistoryFileName 默认基准测试历史记录文件名
+const benchmarkHistoryFileName = "benchmark_history.jsonl"
+
+// BenchmarkHistory 已结束基准测试任务的历史记录：追加写入JSON Lines文件，查询时扫描文件
+type BenchmarkHistory struct {
+	path string
+	mu   sync.Mutex
+}
+
+// BenchmarkHistoryFilter 历史记录查询条件，零值表示不限制
+type BenchmarkHistoryFilter struct {
+	Model    string    // 模型文件名包含的字符串（不区分大小写）
+	Since    time.Time // 开始时间不早于
+	Until    time.Time // 开始时间不晚于
+	TestType string    // 包含该类型（前缀匹配，如pp匹配pp512）的测试结果
+	GPU      string    // 环境中的GPU型号包含的字符串（不区分大小写）
+	Build    string    // 环境中的llama.cpp构建版本包含的字符串
+	RPC      string    // none只匹配单机测试，any只匹配使用rpc-server的测试，其他值匹配包含该地址的测试
+	Status   string    // 任务状态：completed/failed/cancelled
+}
+
+// NewBenchmarkHistory 创建基准测试历史记录
+func NewBenchmarkHistory(path string) *BenchmarkHistory {
+	return &BenchmarkHistory{path: path}
+}
+
+// defaultBenchmarkHistoryPath 默认历史记录路径：程序目录下的benchmark_history.jsonl
+func defaultBenchmarkHistoryPath() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return benchmarkHistoryFileName
+	}
+	return filepath.Join(filepath.Dir(exePath), benchmarkHistoryFileName)
+}
+
+// Record 追加一条已结束任务的记录
+func (h *BenchmarkHistory) Record(record *model.BenchmarkRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.append(record); err != nil {
+		slog.Warn("Failed to write benchmark history", "error", err)
+	}
+}
+
+// append 将记录追加写入文件
+func (h *BenchmarkHistory) append(record *model.BenchmarkRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode benchmark record: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create benchmark history directory: %v", err)
+	}
+	file, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open benchmark history: %v", err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// scan 按写入顺序遍历所有记录，跳过无法解析的行，fn返回false时停止
+func (h *BenchmarkHistory) scan(fn func(record *model.BenchmarkRecord) bool) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	file, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open benchmark history: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record model.BenchmarkRecord
+		if json.Unmarshal(scanner.Bytes(), &record) != nil {
+			continue
+		}
+		if !fn(&record) {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read benchmark history: %v", err)
+	}
+	return nil
+}
+
+// Query 按条件查询历史记录（按写入时间顺序）
+func (h *BenchmarkHistory) Query(filter BenchmarkHistoryFilter) ([]*model.BenchmarkRecord, error) {
+	records := make([]*model.BenchmarkRecord, 0)
+	err := h.scan(func(record *model.BenchmarkRecord) bool {
+		if filter.matches(record) {
+			records = append(records, record)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Find 查找指定任务的记录，不存在时返回nil
+func (h *BenchmarkHistory) Find(taskID string) (*model.BenchmarkRecord, error) {
+	var found *model.BenchmarkRecord
+	err := h.scan(func(record *model.BenchmarkRecord) bool {
+		if record.TaskID == taskID {
+			found = record
+			return false
+		}
+		return true
+	})
+	return found, err
+}
+
+// matches 检查记录是否满足查询条件
+func (f BenchmarkHistoryFilter) matches(record *model.BenchmarkRecord) bool {
+	if f.Status != "" && record.Status != f.Status {
+		return false
+	}
+	if f.Model != "" && !strings.Contains(strings.ToLower(filepath.Base(record.ModelPath)), strings.ToLower(f.Model)) {
+		return false
+	}
+	if !f.Since.IsZero() || !f.Until.IsZero() {
+		start, err := time.Parse(time.RFC3339, record.StartTime)
+		if err != nil || (!f.Since.IsZero() && start.Before(f.Since)) || (!f.Until.IsZero() && start.After(f.Until)) {
+			return false
+		}
+	}
+	if f.GPU != "" || f.Build != "" {
+		// 没有环境快照的旧记录不参与按环境过滤
+		env := record.Environment
+		if env == nil || !strings.Contains(env.LlamaCppBuild, f.Build) {
+			return false
+		}
+		if f.GPU != "" && !slices.ContainsFunc(env.GPUs, func(gpu string) bool {
+			return strings.Contains(strings.ToLower(gpu), strings.ToLower(f.GPU))
+		}) {
+			return false
+		}
+	}
+	switch f.RPC {
+	case "":
+	case "none":
+		if len(record.RPCWorkers) > 0 {
+			return false
+		}
+	case "any":
+		if len(record.RPCWorkers) == 0 {
+			return false
+		}
+	default:
+		if !slices.ContainsFunc(record.RPCWorkers, func(worker string) bool { return strings.Contains(worker, f.RPC) }) {
+			return false
+		}
+	}
+	if f.TestType != "" {
+		for _, result := range record.Results {
+			if strings.HasPrefix(result.TestType, f.TestType) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// benchmarkRecord 根据任务状态生成历史记录
+func benchmarkRecord(status *model.BenchmarkStatus, cfg *model.BenchmarkConfig, manifest *model.BenchmarkManifest,
+	duration time.Duration, output, failure string) *model.BenchmarkRecord {
+	env := manifest.BenchmarkEnvironment
+	return &model.BenchmarkRecord{
+		TaskID:      status.TaskID,
+		Status:      status.Status,
+		ModelPath:   manifest.ModelPath,
+		Config:      cfg,
+		Args:        manifest.Args,
+		StartTime:   status.StartTime,
+		EndTime:     status.EndTime,
+		DurationSec: duration.Seconds(),
+		Output:      output,
+		Error:       failure,
+		Results:     status.AllResults,
+		Thermal:     status.Thermal,
+		Serving:     status.Serving,
+		Environment: &env,
+		Baseline:    status.Baseline,
+		RPCWorkers:  manifest.RPCWorkers,
+	}
+}
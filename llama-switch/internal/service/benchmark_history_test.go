@@ -0,0 +1,76 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestBenchmarkHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history", "benchmarks.jsonl")
+	history := NewBenchmarkHistory(path)
+
+	if records, err := history.Query(BenchmarkHistoryFilter{}); err != nil || len(records) != 0 {
+		t.Fatalf("Query on missing file = %v, %v", records, err)
+	}
+
+	day := func(d int) string { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC).Format(time.RFC3339) }
+	history.Record(&model.BenchmarkRecord{TaskID: "a", Status: "completed", ModelPath: "/models/Qwen-7B-Q4_K_M.gguf", StartTime: day(1),
+		Results:     []*model.BenchmarkResults{{TestType: "pp512"}, {TestType: "tg128"}},
+		Environment: &model.BenchmarkEnvironment{LlamaCppBuild: "5293 (1e333d5b)", GPUs: []string{"NVIDIA GeForce RTX 4090"}}})
+	history.Record(&model.BenchmarkRecord{TaskID: "b", Status: "failed", ModelPath: "/models/llama-8b.gguf", StartTime: day(2), Error: "exit status 1"})
+	history.Record(&model.BenchmarkRecord{TaskID: "c", Status: "completed", ModelPath: "/models/qwen-14b.gguf", StartTime: day(3),
+		Results:     []*model.BenchmarkResults{{TestType: "tg128"}},
+		Environment: &model.BenchmarkEnvironment{LlamaCppBuild: "5300 (abcdef12)", GPUs: []string{"NVIDIA GeForce RTX 3090"}}})
+
+	// 无法解析的行被跳过
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	file.WriteString("not json\n")
+	file.Close()
+
+	tests := []struct {
+		name   string
+		filter BenchmarkHistoryFilter
+		want   []string
+	}{
+		{"all", BenchmarkHistoryFilter{}, []string{"a", "b", "c"}},
+		{"model", BenchmarkHistoryFilter{Model: "qwen"}, []string{"a", "c"}},
+		{"since", BenchmarkHistoryFilter{Since: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)}, []string{"b", "c"}},
+		{"until", BenchmarkHistoryFilter{Until: time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)}, []string{"a", "b"}},
+		{"test type prefix", BenchmarkHistoryFilter{TestType: "pp"}, []string{"a"}},
+		{"test type", BenchmarkHistoryFilter{TestType: "tg128"}, []string{"a", "c"}},
+		{"status", BenchmarkHistoryFilter{Status: "completed"}, []string{"a", "c"}},
+		{"gpu", BenchmarkHistoryFilter{GPU: "rtx 4090"}, []string{"a"}},
+		{"build", BenchmarkHistoryFilter{Build: "5300"}, []string{"c"}},
+	}
+	for _, tt := range tests {
+		records, err := history.Query(tt.filter)
+		if err != nil {
+			t.Fatalf("%s: Query failed: %v", tt.name, err)
+		}
+		var got []string
+		for _, r := range records {
+			got = append(got, r.TaskID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Query = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// 重启后任务状态从历史记录中读取
+	cfg := &config.Config{}
+	cfg.Benchmark.HistoryFile = path
+	s := NewBenchmarkService(cfg, nil)
+	status, err := s.GetStatus("b")
+	if err != nil || status.Status != "failed" || status.StartTime != day(2) {
+		t.Errorf("GetStatus(b) = %+v, %v", status, err)
+	}
+	if _, err := s.GetStatus("missing"); err == nil {
+		t.Error("GetStatus(missing) succeeded, want error")
+	}
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+// versionProbeTimeout 查询llama.cpp和GPU驱动版本的超时时间
+const versionProbeTimeout = 10 * time.Second
+
+// quantizationPattern 从GGUF文件名中识别量化类型，如Q4_K_M、IQ3_XXS、F16、BF16
+var quantizationPattern = regexp.MustCompile(`(?i)(?:^|[-_.])((?:I?Q\d+(?:_[A-Z0-9]+)*)|BF16|F16|F32)(?:[-_.]|$)`)
+
+// cudaVersionPattern 匹配nvidia-smi输出标题中驱动支持的CUDA版本
+var cudaVersionPattern = regexp.MustCompile(`CUDA Version:\s*([0-9.]+)`)
+
+// collectManifest 收集当前环境信息生成可复现清单
+func (s *BenchmarkService) collectManifest(modelPath string, args []string) *model.BenchmarkManifest {
+	manifest := &model.BenchmarkManifest{
+		SwitcherVersion:      config.BuildVersion(),
+		ModelPath:            modelPath,
+		Quantization:         inferQuantization(modelPath),
+		Binary:               s.config.Snapshot().LLamaPath.Bench,
+		Args:                 append([]string(nil), args...),
+		BenchmarkEnvironment: s.snapshotEnvironment(),
+	}
+	if info, err := os.Stat(modelPath); err == nil {
+		manifest.ModelSize = info.Size()
+	}
+	manifest.RPCWorkers = rpcWorkersFromArgs(args)
+	return manifest
+}
+
+// rpcWorkersFromArgs 从llama-bench命令行参数中获取参与测试的rpc-server，复现任务同样适用
+func rpcWorkersFromArgs(args []string) []string {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "--rpc" || args[i] == "-rpc" {
+			workers, _ := parseRPCWorkers(args[i+1])
+			return workers
+		}
+	}
+	return nil
+}
+
+// parseRPCWorkers 解析逗号分隔的rpc-server列表，每项必须是host:port
+func parseRPCWorkers(spec string) ([]string, error) {
+	var workers []string
+	for _, worker := range strings.Split(spec, ",") {
+		if worker = strings.TrimSpace(worker); worker == "" {
+			continue
+		}
+		if _, port, err := net.SplitHostPort(worker); err != nil || port == "" {
+			return nil, fmt.Errorf("invalid rpc server: %s (should be host:port)", worker)
+		}
+		workers = append(workers, worker)
+	}
+	return workers, nil
+}
+
+// snapshotEnvironment 采集当前的GPU、驱动、llama.cpp构建和主机信息
+func (s *BenchmarkService) snapshotEnvironment() model.BenchmarkEnvironment {
+	env := model.BenchmarkEnvironment{
+		LlamaCppBuild: llamaCppBuild(s.config.Snapshot().LLamaPath.Server),
+		CUDAVersion:   cudaVersion(),
+		Host: model.HostInfo{
+			OS:       runtime.GOOS,
+			Arch:     runtime.GOARCH,
+			CPUs:     runtime.NumCPU(),
+			CPUModel: cpuModel(),
+		},
+	}
+	env.Host.Hostname, _ = os.Hostname()
+	if memory, err := totalRAM(); err == nil {
+		env.Host.MemoryMB = memory
+	}
+	env.GPUs, env.DriverVersion = gpuInfo()
+	return env
+}
+
+// cudaVersion 从nvidia-smi的输出标题中获取驱动支持的CUDA版本，没有NVIDIA GPU时返回空字符串
+func cudaVersion() string {
+	ctx, cancel := context.WithTimeout(context.Background(), versionProbeTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "nvidia-smi").Output()
+	if err != nil {
+		return ""
+	}
+	if match := cudaVersionPattern.FindSubmatch(output); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
+
+// llamaCppBuild 通过llama-server --version获取llama.cpp构建版本
+func llamaCppBuild(serverPath string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), versionProbeTimeout)
+	defer cancel()
+
+	// llama-server将版本信息输出到stderr
+	output, err := exec.CommandContext(ctx, serverPath, "--version").CombinedOutput()
+	if err != nil && len(output) == 0 {
+		return "unknown"
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if version, ok := strings.CutPrefix(strings.TrimSpace(line), "version:"); ok {
+			return strings.TrimSpace(version)
+		}
+	}
+	return "unknown"
+}
+
+// gpuInfo 通过nvidia-smi获取GPU型号和驱动版本
+func gpuInfo() ([]string, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), versionProbeTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "nvidia-smi", "--query-gpu=name,driver_version", "--format=csv,noheader").Output()
+	if err != nil {
+		return nil, ""
+	}
+
+	var gpus []string
+	var driver string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, ",")
+		if len(fields) == 0 || strings.TrimSpace(fields[0]) == "" {
+			continue
+		}
+		gpus = append(gpus, strings.TrimSpace(fields[0]))
+		if len(fields) > 1 {
+			driver = strings.TrimSpace(fields[1])
+		}
+	}
+	return gpus, driver
+}
+
+// inferQuantization 从模型文件名推断量化类型，无法识别时返回空字符串
+func inferQuantization(modelPath string) string {
+	name := strings.TrimSuffix(filepath.Base(modelPath), filepath.Ext(modelPath))
+	matches := quantizationPattern.FindAllStringSubmatch(name, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	// 取最后一个匹配，避免将模型名中的片段误认为量化类型
+	return strings.ToUpper(matches[len(matches)-1][1])
+}
+
+// manifestDir 可复现清单保存目录
+func (s *BenchmarkService) manifestDir() string {
+	if s.config.Benchmark.ManifestDir != "" {
+		return s.config.Benchmark.ManifestDir
+	}
+	exePath, err := os.Executable()
+	if err != nil {
+		return filepath.Join("config", "benchmarks")
+	}
+	return filepath.Join(filepath.Dir(exePath), "config", "benchmarks")
+}
+
+// saveManifest 保存可复现清单，测试完成后附带结果再次保存
+func (s *BenchmarkService) saveManifest(manifest *model.BenchmarkManifest, results []*model.BenchmarkResults) {
+	record := *manifest
+	record.Results = results
+
+	data, err := json.MarshalIndent(&record, "", "  ")
+	if err != nil {
+		slog.Warn("Failed to serialize benchmark manifest", "task_id", manifest.TaskID, "error", err)
+		return
+	}
+	dir := s.manifestDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		slog.Warn("Failed to create benchmark manifest directory", "error", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifest.TaskID+".json"), data, 0644); err != nil {
+		slog.Warn("Failed to write benchmark manifest", "task_id", manifest.TaskID, "error", err)
+	}
+}
+
+// LoadManifest 加载指定任务的可复现清单（包含测试结果）
+func (s *BenchmarkService) LoadManifest(taskID string) (*model.BenchmarkManifest, error) {
+	// 任务ID作为文件名，拒绝包含路径的ID
+	if taskID == "" || filepath.Base(taskID) != taskID {
+		return nil, fmt.Errorf("invalid task id: %s", taskID)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.manifestDir(), taskID+".json"))
+	if os.IsNotExist(err) {
+		return nil, apierror.New(apierror.CodeBenchmarkNotFound, "manifest not found for task: %s", taskID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %v", err)
+	}
+
+	var manifest model.BenchmarkManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %v", err)
+	}
+	return &manifest, nil
+}
+
+// Reproduce 以原任务完全相同的命令行参数重新运行基准测试
+// 返回新任务ID以及当前环境与原清单的差异
+func (s *BenchmarkService) Reproduce(taskID string) (string, []string, error) {
+	original, err := s.LoadManifest(taskID)
+	if err != nil {
+		return "", nil, err
+	}
+	if original.Serving == nil && len(original.Args) == 0 {
+		return "", nil, fmt.Errorf("manifest for task %s has no arguments", taskID)
+	}
+
+	// 服务基准测试按原配置重新运行，llama-server的命令行参数在执行时按新分配的端口生成
+	args := original.Args
+	if original.Serving != nil {
+		args = nil
+	}
+	current := s.collectManifest(original.ModelPath, args)
+	current.ReproducedFrom = taskID
+	current.VisibleGPUs = original.VisibleGPUs
+	if original.Serving != nil {
+		current.Binary = s.config.Snapshot().LLamaPath.Server
+	}
+	differences := diffManifests(original, current)
+	for _, diff := range differences {
+		slog.Info("Reproducing benchmark, environment differs", "task_id", taskID, "diff", diff)
+	}
+
+	var newTaskID string
+	if original.Serving != nil {
+		newTaskID, err = s.runServing(original.Serving, current)
+	} else {
+		newTaskID, err = s.run(nil, original.Args, current)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	return newTaskID, differences, nil
+}
+
+// diffManifests 比较两份清单的环境信息，返回可读的差异列表
+func diffManifests(original, current *model.BenchmarkManifest) []string {
+	differences := make([]string, 0)
+	compare := func(name, before, after string) {
+		if before != after {
+			differences = append(differences, fmt.Sprintf("%s: %q -> %q", name, before, after))
+		}
+	}
+
+	compare("llama_cpp_build", original.LlamaCppBuild, current.LlamaCppBuild)
+	compare("switcher_version", original.SwitcherVersion, current.SwitcherVersion)
+	compare("gpus", strings.Join(original.GPUs, ", "), strings.Join(current.GPUs, ", "))
+	compare("driver_version", original.DriverVersion, current.DriverVersion)
+	compare("cuda_version", original.CUDAVersion, current.CUDAVersion)
+	compare("cpu_model", original.Host.CPUModel, current.Host.CPUModel)
+	compare("binary", original.Binary, current.Binary)
+	compare("hostname", original.Host.Hostname, current.Host.Hostname)
+	compare("os", original.Host.OS+"/"+original.Host.Arch, current.Host.OS+"/"+current.Host.Arch)
+	if original.Host.CPUs != current.Host.CPUs {
+		differences = append(differences, fmt.Sprintf("cpus: %d -> %d", original.Host.CPUs, current.Host.CPUs))
+	}
+	if original.Host.MemoryMB != current.Host.MemoryMB {
+		differences = append(differences, fmt.Sprintf("memory_mb: %d -> %d", original.Host.MemoryMB, current.Host.MemoryMB))
+	}
+	if original.ModelSize != current.ModelSize {
+		differences = append(differences, fmt.Sprintf("model_size: %d -> %d", original.ModelSize, current.ModelSize))
+	}
+	return differences
+}
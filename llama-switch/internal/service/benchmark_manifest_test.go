@@ -0,0 +1,107 @@
+package service
+
+import (
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestInferQuantization(t *testing.T) {
+	tests := map[string]string{
+		"E:/models/qwen2.5-7b-instruct-q4_k_m.gguf":       "Q4_K_M",
+		"DeepSeek-R1-Distill-Qwen-32B-Q8_0.gguf":          "Q8_0",
+		"Meta-Llama-3-8B-Instruct.IQ3_XXS.gguf":           "IQ3_XXS",
+		"mistral-7b-v0.1.F16.gguf":                        "F16",
+		"Qwen2.5-72B-Instruct-Q4_K_M-00001-of-00002.gguf": "Q4_K_M",
+		"bge-reranker-v2-m3.gguf":                         "",
+		"qwen2-7b.gguf":                                   "",
+	}
+	for path, want := range tests {
+		if got := inferQuantization(path); got != want {
+			t.Errorf("inferQuantization(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestBenchmarkManifest_SaveLoadAndDiff(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Benchmark.ManifestDir = t.TempDir()
+	s := NewBenchmarkService(cfg, nil)
+
+	original := &model.BenchmarkManifest{
+		TaskID: "task-1",
+		Args:   []string{"--model", "m.gguf", "--n-gpu-layers", "99"},
+		BenchmarkEnvironment: model.BenchmarkEnvironment{
+			LlamaCppBuild: "5293 (1e333d5b)",
+			GPUs:          []string{"NVIDIA GeForce RTX 4090"},
+			DriverVersion: "560.94",
+			CUDAVersion:   "12.6",
+			Host:          model.HostInfo{Hostname: "bench", OS: "windows", Arch: "amd64", CPUs: 32, CPUModel: "AMD Ryzen 9 7950X", MemoryMB: 65536},
+		},
+	}
+	s.saveManifest(original, []*model.BenchmarkResults{{TestType: "pp512", TokensPerSecond: 212.13}})
+
+	loaded, err := s.LoadManifest("task-1")
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+	if len(loaded.Args) != 4 || loaded.Args[3] != "99" || len(loaded.Results) != 1 || loaded.CUDAVersion != "12.6" || loaded.Host.MemoryMB != 65536 {
+		t.Errorf("Unexpected loaded manifest: %+v", loaded)
+	}
+	if _, err := s.LoadManifest("../task-1"); err == nil {
+		t.Error("Expected error for task id containing a path")
+	}
+
+	current := *loaded
+	if diffs := diffManifests(loaded, &current); len(diffs) != 0 {
+		t.Errorf("Expected no differences, got %v", diffs)
+	}
+	current.LlamaCppBuild = "5300 (abcdef12)"
+	current.DriverVersion = "565.90"
+	current.CUDAVersion = "12.7"
+	current.Host.MemoryMB = 131072
+	if diffs := diffManifests(loaded, &current); len(diffs) != 4 {
+		t.Errorf("Expected 4 differences, got %v", diffs)
+	}
+}
+
+func TestBenchmarkRPCWorkers(t *testing.T) {
+	cfg := &model.BenchmarkConfig{ModelPath: "m.gguf"}
+	cfg.Config.RPC = "192.168.1.10:50052, 192.168.1.11:50052"
+	args := benchmarkArgs("/models/m.gguf", cfg)
+	workers := rpcWorkersFromArgs(args)
+	if len(workers) != 2 || workers[0] != "192.168.1.10:50052" || workers[1] != "192.168.1.11:50052" {
+		t.Errorf("rpcWorkersFromArgs(%v) = %v", args, workers)
+	}
+	if workers := rpcWorkersFromArgs([]string{"--model", "m.gguf"}); workers != nil {
+		t.Errorf("single-node run has rpc workers: %v", workers)
+	}
+
+	s := &BenchmarkService{}
+	if err := s.ValidateBenchmarkConfig(cfg); err != nil {
+		t.Errorf("valid rpc list rejected: %v", err)
+	}
+	cfg.Config.RPC = "192.168.1.10"
+	if err := s.ValidateBenchmarkConfig(cfg); err == nil {
+		t.Error("rpc server without port accepted")
+	}
+	cfg.Config.RPC = ""
+	cfg.RPCPool = "lab"
+	if err := s.ValidateBenchmarkConfig(cfg); err == nil {
+		t.Error("rpc pool accepted without RPC pools configured")
+	}
+
+	// 按是否使用rpc-server过滤历史记录
+	local := &model.BenchmarkRecord{TaskID: "local"}
+	remote := &model.BenchmarkRecord{TaskID: "remote", RPCWorkers: workers}
+	for _, tt := range []struct {
+		rpc           string
+		local, remote bool
+	}{{"", true, true}, {"none", true, false}, {"any", false, true}, {"192.168.1.11", false, true}, {"10.0.0.1", false, false}} {
+		filter := BenchmarkHistoryFilter{RPC: tt.rpc}
+		if filter.matches(local) != tt.local || filter.matches(remote) != tt.remote {
+			t.Errorf("rpc=%q: local %v remote %v, want %v %v", tt.rpc, filter.matches(local), filter.matches(remote), tt.local, tt.remote)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// 基准测试输出流
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+)
+
+// cappedBuffer 只在内存中保留最近limit字节的输出，超出部分从头部丢弃；limit<=0时不限制
+type cappedBuffer struct {
+	limit   int
+	data    []byte
+	dropped int64
+}
+
+// Write 追加输出，超出上限时丢弃最旧的字节
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.data = append(b.data, p...)
+	if b.limit > 0 && len(b.data) > b.limit {
+		excess := len(b.data) - b.limit
+		b.dropped += int64(excess)
+		b.data = append(b.data[:0], b.data[excess:]...)
+	}
+	return len(p), nil
+}
+
+// String 获取保留的输出，有丢弃时在开头注明丢弃的字节数
+func (b *cappedBuffer) String() string {
+	if b.dropped > 0 {
+		return fmt.Sprintf("[... %d bytes truncated ...]\n%s", b.dropped, b.data)
+	}
+	return string(b.data)
+}
+
+// benchmarkOutput 一个输出流的捕获：内存中的有限缓冲加上磁盘上的完整输出文件
+type benchmarkOutput struct {
+	buffer *cappedBuffer
+	file   *os.File // 创建失败时为nil，只保留内存中的输出
+}
+
+// newBenchmarkOutput 创建任务输出流的捕获，完整输出写入清单目录下的output/<task_id>.<stream>.log
+func (s *BenchmarkService) newBenchmarkOutput(taskID, stream string) *benchmarkOutput {
+	output := &benchmarkOutput{buffer: &cappedBuffer{limit: int(s.config.Benchmark.OutputLimitKB) * 1024}}
+	path := s.outputPath(taskID, stream)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		slog.Warn("Failed to create benchmark output directory", "error", err)
+		return output
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		slog.Warn("Failed to create benchmark output file", "file", path, "error", err)
+		return output
+	}
+	output.file = file
+	return output
+}
+
+// writer 返回同时写入内存缓冲和输出文件的Writer
+func (o *benchmarkOutput) writer() io.Writer {
+	if o.file == nil {
+		return o.buffer
+	}
+	return io.MultiWriter(o.buffer, o.file)
+}
+
+// close 关闭输出文件
+func (o *benchmarkOutput) close() {
+	if o.file != nil {
+		o.file.Close()
+	}
+}
+
+// full 读取完整输出，文件不可用时返回内存中保留的部分
+func (o *benchmarkOutput) full() string {
+	if o.file == nil {
+		return o.buffer.String()
+	}
+	data, err := os.ReadFile(o.file.Name())
+	if err != nil {
+		slog.Warn("Failed to read benchmark output file", "file", o.file.Name(), "error", err)
+		return o.buffer.String()
+	}
+	return string(data)
+}
+
+// outputPath 任务输出文件路径
+func (s *BenchmarkService) outputPath(taskID, stream string) string {
+	return filepath.Join(s.manifestDir(), "output", fmt.Sprintf("%s.%s.log", taskID, stream))
+}
+
+// OpenOutput 打开任务保存在磁盘上的完整输出，运行中的任务返回目前为止的输出
+func (s *BenchmarkService) OpenOutput(taskID, stream string) (*os.File, error) {
+	if stream != OutputStdout && stream != OutputStderr {
+		return nil, fmt.Errorf("invalid stream: %s (expected stdout or stderr)", stream)
+	}
+	if taskID == "" || taskID != filepath.Base(taskID) {
+		return nil, fmt.Errorf("invalid task id: %s", taskID)
+	}
+	file, err := os.Open(s.outputPath(taskID, stream))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no output saved for task: %s", taskID)
+	}
+	return file, err
+}
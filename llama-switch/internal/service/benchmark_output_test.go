@@ -0,0 +1,66 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"llama-switch/internal/config"
+)
+
+func TestCappedBuffer(t *testing.T) {
+	b := &cappedBuffer{limit: 8}
+	fmt.Fprint(b, "0123456789")
+	fmt.Fprint(b, "abc")
+	if got, want := b.String(), "[... 5 bytes truncated ...]\n56789abc"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	unlimited := &cappedBuffer{}
+	fmt.Fprint(unlimited, strings.Repeat("x", 100))
+	if len(unlimited.String()) != 100 {
+		t.Errorf("unlimited buffer kept %d bytes, want 100", len(unlimited.String()))
+	}
+}
+
+func TestBenchmarkOutputFile(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Benchmark.ManifestDir = t.TempDir()
+	cfg.Benchmark.HistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.Benchmark.OutputLimitKB = 1
+	s := NewBenchmarkService(cfg, nil)
+
+	// 内存中只保留最后1KB，磁盘上保存完整输出
+	output := s.newBenchmarkOutput("task", OutputStdout)
+	full := strings.Repeat("line of llama-bench output\n", 100)
+	fmt.Fprint(output.writer(), full)
+	output.close()
+	if output.full() != full {
+		t.Error("full() does not return the complete output")
+	}
+	if !strings.HasPrefix(output.buffer.String(), "[...") {
+		t.Error("in-memory output not truncated")
+	}
+
+	file, err := s.OpenOutput("task", OutputStdout)
+	if err != nil {
+		t.Fatalf("OpenOutput failed: %v", err)
+	}
+	data, _ := io.ReadAll(file)
+	file.Close()
+	if string(data) != full {
+		t.Error("OpenOutput does not return the complete output")
+	}
+
+	for _, c := range []struct{ taskID, stream string }{
+		{"task", "combined"},
+		{"../task", OutputStdout},
+		{"missing", OutputStdout},
+	} {
+		if _, err := s.OpenOutput(c.taskID, c.stream); err == nil {
+			t.Errorf("OpenOutput(%q, %q) succeeded", c.taskID, c.stream)
+		}
+	}
+}
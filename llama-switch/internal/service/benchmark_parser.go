@@ -1,10 +1,15 @@
 package service
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"llama-switch/internal/model"
 )
 
 type BenchmarkResult struct {
@@ -39,27 +44,169 @@ type BenchmarkResult struct {
 	} `json:"build_info"`
 }
 
-type ModelResult struct {
-	Model       string      `json:"model"`
-	Size        string      `json:"size"`
-	Params      string      `json:"params"`
-	Backend     string      `json:"backend"`
-	GPULayers   int         `json:"gpu_layers"`
-	MMap        bool        `json:"mmap"`
-	TestResults []TestEntry `json:"test_results"`
-}
+// ModelResult 按模型分组的测试结果，与状态接口中的models字段结构相同
+type ModelResult = model.BenchmarkModelResult
+
+// TestEntry 分组中的单条测试结果
+type TestEntry = model.BenchmarkTestEntry
 
-type TestEntry struct {
+// benchmarkTest 单条测试结果，与BenchmarkResult.Tests的元素类型一致
+type benchmarkTest struct {
+	Model           string  `json:"model"`
+	Size            string  `json:"size"`
+	Params          string  `json:"params"`
+	Backend         string  `json:"backend"`
+	GPULayers       int     `json:"gpu_layers"`
+	MMap            bool    `json:"mmap"`
 	TestType        string  `json:"test_type"`
 	TokensPerSecond float64 `json:"tokens_per_second"`
 	Variation       float64 `json:"variation"`
 }
 
+// llamaBenchJSONResult llama-bench -o json/jsonl 输出的单条测试记录
+type llamaBenchJSONResult struct {
+	BuildCommit  string  `json:"build_commit"`
+	BuildNumber  int     `json:"build_number"`
+	GPUInfo      string  `json:"gpu_info"`
+	Backends     string  `json:"backends"`
+	ModelType    string  `json:"model_type"`
+	ModelSize    int64   `json:"model_size"`
+	ModelNParams int64   `json:"model_n_params"`
+	NGPULayers   int     `json:"n_gpu_layers"`
+	UseMmap      bool    `json:"use_mmap"`
+	NPrompt      int     `json:"n_prompt"`
+	NGen         int     `json:"n_gen"`
+	NDepth       int     `json:"n_depth"`
+	AvgTS        float64 `json:"avg_ts"`
+	StddevTS     float64 `json:"stddev_ts"`
+}
+
+// ParseBenchmarkOutput 解析llama-bench的输出：优先解析JSON/JSONL格式，
+// 不是JSON时回退到解析markdown表格（兼容不支持或未使用-o json的旧版本）
 func ParseBenchmarkOutput(output string) (*BenchmarkResult, error) {
 	if output == "" {
 		return nil, fmt.Errorf("empty input")
 	}
+	if start := jsonStart(output); start >= 0 {
+		return parseBenchmarkJSON(output[start:])
+	}
+	return parseBenchmarkTable(output)
+}
+
+// jsonStart 查找JSON输出的起始位置（以[或{开头的第一行），不存在时返回-1
+func jsonStart(output string) int {
+	offset := 0
+	for _, line := range strings.SplitAfter(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+			return offset
+		}
+		offset += len(line)
+	}
+	return -1
+}
+
+// parseBenchmarkJSON 解析llama-bench的JSON数组或JSONL输出
+func parseBenchmarkJSON(output string) (*BenchmarkResult, error) {
+	var entries []llamaBenchJSONResult
+	if strings.HasPrefix(strings.TrimSpace(output), "[") {
+		if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &entries); err != nil {
+			return nil, fmt.Errorf("invalid benchmark JSON output: %v", err)
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader([]byte(output)))
+		for {
+			var entry llamaBenchJSONResult
+			err := decoder.Decode(&entry)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid benchmark JSONL output: %v", err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	result := &BenchmarkResult{}
+	modelMap := make(map[string]*ModelResult)
+	for i, entry := range entries {
+		if i == 0 {
+			result.BuildInfo.CommitHash = entry.BuildCommit
+			if entry.BuildNumber > 0 {
+				result.BuildInfo.BuildNumber = strconv.Itoa(entry.BuildNumber)
+			}
+			if entry.Backends != "" {
+				result.DeviceInfo.BackendsLoaded = strings.Split(entry.Backends, ",")
+			}
+			for id, name := range strings.Split(entry.GPUInfo, ",") {
+				if name = strings.TrimSpace(name); name != "" {
+					result.DeviceInfo.CUDADevices = append(result.DeviceInfo.CUDADevices, struct {
+						ID                int    `json:"id"`
+						Name              string `json:"name"`
+						ComputeCapability string `json:"compute_capability"`
+						VMM               bool   `json:"vmm"`
+					}{ID: id, Name: name})
+				}
+			}
+		}
+
+		testType := benchTestType(entry.NPrompt, entry.NGen, entry.NDepth)
+		if entry.ModelType == "" || testType == "" {
+			continue
+		}
+		addBenchmarkTest(result, modelMap, benchmarkTest{
+			Model:           entry.ModelType,
+			Size:            formatModelSize(entry.ModelSize),
+			Params:          formatModelParams(entry.ModelNParams),
+			Backend:         entry.Backends,
+			GPULayers:       entry.NGPULayers,
+			MMap:            entry.UseMmap,
+			TestType:        testType,
+			TokensPerSecond: entry.AvgTS,
+			Variation:       entry.StddevTS,
+		})
+	}
+	return finishBenchmarkResult(result, modelMap)
+}
 
+// benchTestType 按llama-bench表格的命名生成测试类型，如pp512、tg128、pp512+tg128、pp512 @ d4096
+func benchTestType(nPrompt, nGen, nDepth int) string {
+	var testType string
+	switch {
+	case nPrompt > 0 && nGen == 0:
+		testType = fmt.Sprintf("pp%d", nPrompt)
+	case nGen > 0 && nPrompt == 0:
+		testType = fmt.Sprintf("tg%d", nGen)
+	case nPrompt > 0 && nGen > 0:
+		testType = fmt.Sprintf("pp%d+tg%d", nPrompt, nGen)
+	default:
+		return ""
+	}
+	if nDepth > 0 {
+		testType += fmt.Sprintf(" @ d%d", nDepth)
+	}
+	return testType
+}
+
+// formatModelSize 按llama-bench表格的格式显示模型大小
+func formatModelSize(size int64) string {
+	if size < 1024*1024*1024 {
+		return fmt.Sprintf("%.2f MiB", float64(size)/1024/1024)
+	}
+	return fmt.Sprintf("%.2f GiB", float64(size)/1024/1024/1024)
+}
+
+// formatModelParams 按llama-bench表格的格式显示参数量
+func formatModelParams(params int64) string {
+	if params < 1000*1000*1000 {
+		return fmt.Sprintf("%.2f M", float64(params)/1e6)
+	}
+	return fmt.Sprintf("%.2f B", float64(params)/1e9)
+}
+
+// parseBenchmarkTable 用正则解析llama-bench的markdown表格输出
+func parseBenchmarkTable(output string) (*BenchmarkResult, error) {
 	// 检查是否包含必要的内容
 	if !strings.Contains(output, "Device") && !strings.Contains(output, "model") {
 		return nil, fmt.Errorf("invalid benchmark format: missing required content")
@@ -120,18 +267,7 @@ func ParseBenchmarkOutput(output string) (*BenchmarkResult, error) {
 			continue
 		}
 
-		// 填充旧Tests结构
-		test := struct {
-			Model           string  `json:"model"`
-			Size            string  `json:"size"`
-			Params          string  `json:"params"`
-			Backend         string  `json:"backend"`
-			GPULayers       int     `json:"gpu_layers"`
-			MMap            bool    `json:"mmap"`
-			TestType        string  `json:"test_type"`
-			TokensPerSecond float64 `json:"tokens_per_second"`
-			Variation       float64 `json:"variation"`
-		}{
+		addBenchmarkTest(result, modelMap, benchmarkTest{
 			Model:           modelName,
 			Size:            strings.TrimSpace(match[2]),
 			Params:          strings.TrimSpace(match[3]),
@@ -141,52 +277,7 @@ func ParseBenchmarkOutput(output string) (*BenchmarkResult, error) {
 			TestType:        testType,
 			TokensPerSecond: tokensPerSecond,
 			Variation:       variation,
-		}
-		result.Tests = append(result.Tests, test)
-
-		// 填充新Models结构
-		modelKey := fmt.Sprintf("%s|%s|%d|%v",
-			modelName,
-			strings.TrimSpace(match[4]),
-			gpuLayers,
-			mmap)
-
-		if existing, ok := modelMap[modelKey]; ok {
-			// 检查是否已存在相同的测试类型
-			exists := false
-			for _, tr := range existing.TestResults {
-				if tr.TestType == testType {
-					exists = true
-					break
-				}
-			}
-			if !exists {
-				existing.TestResults = append(existing.TestResults, TestEntry{
-					TestType:        testType,
-					TokensPerSecond: tokensPerSecond,
-					Variation:       variation,
-				})
-			}
-		} else {
-			modelMap[modelKey] = &ModelResult{
-				Model:     modelName,
-				Size:      strings.TrimSpace(match[2]),
-				Params:    strings.TrimSpace(match[3]),
-				Backend:   strings.TrimSpace(match[4]),
-				GPULayers: gpuLayers,
-				MMap:      mmap,
-				TestResults: []TestEntry{{
-					TestType:        testType,
-					TokensPerSecond: tokensPerSecond,
-					Variation:       variation,
-				}},
-			}
-		}
-	}
-
-	// 将map转换为slice
-	for _, model := range modelMap {
-		result.Models = append(result.Models, *model)
+		})
 	}
 
 	// 解析构建信息
@@ -197,6 +288,87 @@ func ParseBenchmarkOutput(output string) (*BenchmarkResult, error) {
 		result.BuildInfo.BuildNumber = buildMatch[2]
 	}
 
+	return finishBenchmarkResult(result, modelMap)
+}
+
+// addBenchmarkTest 添加一条测试结果，同时填充旧Tests结构和按模型分组的Models结构
+func addBenchmarkTest(result *BenchmarkResult, modelMap map[string]*ModelResult, test benchmarkTest) {
+	// 填充旧Tests结构
+	result.Tests = append(result.Tests, test)
+
+	// 填充新Models结构
+	modelKey := fmt.Sprintf("%s|%s|%d|%v", test.Model, test.Backend, test.GPULayers, test.MMap)
+	entry := TestEntry{
+		TestType:        test.TestType,
+		TokensPerSecond: test.TokensPerSecond,
+		Variation:       test.Variation,
+	}
+	existing, ok := modelMap[modelKey]
+	if !ok {
+		modelMap[modelKey] = &ModelResult{
+			Model:       test.Model,
+			Size:        test.Size,
+			Params:      test.Params,
+			Backend:     test.Backend,
+			GPULayers:   test.GPULayers,
+			MMap:        test.MMap,
+			TestResults: []TestEntry{entry},
+		}
+		return
+	}
+	// 检查是否已存在相同的测试类型
+	for _, tr := range existing.TestResults {
+		if tr.TestType == test.TestType {
+			return
+		}
+	}
+	existing.TestResults = append(existing.TestResults, entry)
+}
+
+// groupBenchmarkResults 按模型（名称、后端、GPU层数和内存映射，与addBenchmarkTest相同）分组测试结果，
+// 分组和组内结果保持原有顺序
+func groupBenchmarkResults(results []*model.BenchmarkResults) []*model.BenchmarkModelResult {
+	groups := make([]*model.BenchmarkModelResult, 0)
+	index := make(map[string]*model.BenchmarkModelResult)
+	for _, r := range results {
+		key := fmt.Sprintf("%s|%s|%d|%v", r.Model, r.Backend, r.GPULayers, r.MMap)
+		group, exists := index[key]
+		if !exists {
+			group = &model.BenchmarkModelResult{
+				Model:     r.Model,
+				Size:      r.Size,
+				Params:    r.Params,
+				Backend:   r.Backend,
+				GPULayers: r.GPULayers,
+				MMap:      r.MMap,
+			}
+			index[key] = group
+			groups = append(groups, group)
+		}
+		group.TestResults = append(group.TestResults, model.BenchmarkTestEntry{
+			TestType:        r.TestType,
+			TokensPerSecond: r.TokensPerSecond,
+			Variation:       r.Variation,
+		})
+	}
+	return groups
+}
+
+// GroupedStatus 返回按模型分组测试结果的状态副本：填充models并省略已弃用的all_results
+func GroupedStatus(status *model.BenchmarkStatus) *model.BenchmarkStatus {
+	grouped := *status
+	grouped.Models = groupBenchmarkResults(status.AllResults)
+	grouped.AllResults = nil
+	return &grouped
+}
+
+// finishBenchmarkResult 汇总按模型分组的结果并验证至少包含一条有效测试
+func finishBenchmarkResult(result *BenchmarkResult, modelMap map[string]*ModelResult) (*BenchmarkResult, error) {
+	// 将map转换为slice
+	for _, m := range modelMap {
+		result.Models = append(result.Models, *m)
+	}
+
 	// 验证解析结果
 	if len(result.Models) == 0 {
 		return nil, fmt.Errorf("no valid test results found")
@@ -204,8 +376,8 @@ func ParseBenchmarkOutput(output string) (*BenchmarkResult, error) {
 
 	// 验证是否至少有一个测试结果包含有效数据
 	hasValidTest := false
-	for _, model := range result.Models {
-		if len(model.TestResults) > 0 {
+	for _, m := range result.Models {
+		if len(m.TestResults) > 0 {
 			hasValidTest = true
 			break
 		}
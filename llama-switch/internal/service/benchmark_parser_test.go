@@ -1,7 +1,11 @@
 package service
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
+
+	"llama-switch/internal/model"
 )
 
 func TestParseBenchmarkOutput_MultipleTests(t *testing.T) {
@@ -95,3 +99,125 @@ func TestParseBenchmarkOutput_InvalidInput(t *testing.T) {
 		})
 	}
 }
+
+func TestParseBenchmarkOutput_JSON(t *testing.T) {
+	input := `[
+  {
+    "build_commit": "1e333d5b",
+    "build_number": 5293,
+    "gpu_info": "Tesla P40, Tesla P40",
+    "backends": "CUDA,RPC",
+    "model_filename": "qwen2.5-32b-instruct-q4_k_m.gguf",
+    "model_type": "qwen2 32B Q4_K - Medium",
+    "model_size": 19843026944,
+    "model_n_params": 32763876352,
+    "n_gpu_layers": 99,
+    "use_mmap": false,
+    "n_prompt": 512,
+    "n_gen": 0,
+    "avg_ts": 212.25,
+    "stddev_ts": 0.47
+  },
+  {
+    "build_commit": "1e333d5b",
+    "build_number": 5293,
+    "gpu_info": "Tesla P40, Tesla P40",
+    "backends": "CUDA,RPC",
+    "model_type": "qwen2 32B Q4_K - Medium",
+    "model_size": 19843026944,
+    "model_n_params": 32763876352,
+    "n_gpu_layers": 99,
+    "use_mmap": false,
+    "n_prompt": 0,
+    "n_gen": 128,
+    "n_depth": 4096,
+    "avg_ts": 9.48,
+    "stddev_ts": 0.01
+  }
+]`
+
+	result, err := ParseBenchmarkOutput(input)
+	if err != nil {
+		t.Fatalf("ParseBenchmarkOutput failed: %v", err)
+	}
+	if len(result.Tests) != 2 || len(result.Models) != 1 {
+		t.Fatalf("Expected 2 tests in 1 model, got %d tests in %d models", len(result.Tests), len(result.Models))
+	}
+
+	test := result.Tests[0]
+	if test.Model != "qwen2 32B Q4_K - Medium" || test.Size != "18.48 GiB" || test.Params != "32.76 B" ||
+		test.Backend != "CUDA,RPC" || test.GPULayers != 99 || test.MMap || test.TestType != "pp512" ||
+		test.TokensPerSecond != 212.25 || test.Variation != 0.47 {
+		t.Errorf("Unexpected first test: %+v", test)
+	}
+	if result.Tests[1].TestType != "tg128 @ d4096" {
+		t.Errorf("Unexpected second test type: %s", result.Tests[1].TestType)
+	}
+	if result.BuildInfo.CommitHash != "1e333d5b" || result.BuildInfo.BuildNumber != "5293" {
+		t.Errorf("Unexpected build info: %+v", result.BuildInfo)
+	}
+	if len(result.DeviceInfo.CUDADevices) != 2 || result.DeviceInfo.CUDADevices[1].Name != "Tesla P40" {
+		t.Errorf("Unexpected devices: %+v", result.DeviceInfo.CUDADevices)
+	}
+}
+
+func TestParseBenchmarkOutput_JSONL(t *testing.T) {
+	input := `{"model_type": "llama 8B Q8_0", "model_size": 8540770304, "model_n_params": 8030261248, "backends": "CUDA", "n_gpu_layers": 33, "use_mmap": true, "n_prompt": 512, "n_gen": 128, "avg_ts": 95.5, "stddev_ts": 1.2}
+{"model_type": "llama 1B F16", "model_size": 524288000, "model_n_params": 1235814400, "backends": "CUDA", "n_gpu_layers": 17, "use_mmap": true, "n_prompt": 512, "n_gen": 0, "avg_ts": 5000, "stddev_ts": 10}
+`
+
+	result, err := ParseBenchmarkOutput(input)
+	if err != nil {
+		t.Fatalf("ParseBenchmarkOutput failed: %v", err)
+	}
+	if len(result.Tests) != 2 || len(result.Models) != 2 {
+		t.Fatalf("Expected 2 tests in 2 models, got %d tests in %d models", len(result.Tests), len(result.Models))
+	}
+	if result.Tests[0].TestType != "pp512+tg128" || result.Tests[0].Size != "7.95 GiB" || result.Tests[0].Params != "8.03 B" {
+		t.Errorf("Unexpected first test: %+v", result.Tests[0])
+	}
+	if result.Tests[1].Size != "500.00 MiB" || result.Tests[1].Params != "1.24 B" {
+		t.Errorf("Unexpected second test: %+v", result.Tests[1])
+	}
+}
+
+func TestParseBenchmarkOutput_InvalidJSON(t *testing.T) {
+	for _, input := range []string{"[{\"model_type\": ", "[]", "{\"model_type\": \"llama\"}"} {
+		if _, err := ParseBenchmarkOutput(input); err == nil {
+			t.Errorf("ParseBenchmarkOutput(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestGroupedStatus(t *testing.T) {
+	status := &model.BenchmarkStatus{
+		TaskID: "t",
+		Status: "completed",
+		AllResults: []*model.BenchmarkResults{
+			{Model: "qwen2 32B Q4_K - Medium", Backend: "CUDA", GPULayers: 99, TestType: "pp512", TokensPerSecond: 212.13},
+			{Model: "llama 8B Q8_0", Backend: "CUDA", GPULayers: 99, TestType: "pp512", TokensPerSecond: 1500},
+			{Model: "qwen2 32B Q4_K - Medium", Backend: "CUDA", GPULayers: 99, TestType: "tg128", TokensPerSecond: 9.49},
+		},
+	}
+
+	grouped := GroupedStatus(status)
+	if len(status.AllResults) != 3 || status.Models != nil {
+		t.Fatal("GroupedStatus modified the original status")
+	}
+	if grouped.AllResults != nil || len(grouped.Models) != 2 {
+		t.Fatalf("unexpected grouped status: %+v", grouped)
+	}
+	first := grouped.Models[0]
+	if first.Model != "qwen2 32B Q4_K - Medium" || len(first.TestResults) != 2 ||
+		first.TestResults[0].TestType != "pp512" || first.TestResults[1].TestType != "tg128" {
+		t.Errorf("unexpected first model group: %+v", first)
+	}
+
+	data, err := json.Marshal(grouped)
+	if err != nil {
+		t.Fatalf("failed to encode grouped status: %v", err)
+	}
+	if strings.Contains(string(data), "all_results") || !strings.Contains(string(data), `"test_results"`) {
+		t.Errorf("unexpected grouped status JSON: %s", data)
+	}
+}
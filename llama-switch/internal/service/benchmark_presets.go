@@ -0,0 +1,79 @@
+package service
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"llama-switch/internal/model"
+)
+
+// benchmarkPresets 内置的基准测试预设，llama-bench对逗号分隔的每个值组合分别测试
+var benchmarkPresets = map[string]model.BenchmarkPreset{
+	"quick": {
+		Name:        "quick",
+		Description: "Single pp512/tg128 pass with 2 repetitions for a fast sanity check",
+		NPrompt:     []int{512},
+		NGen:        []int{128},
+		Repetitions: 2,
+	},
+	"standard": {
+		Name:        "standard",
+		Description: "Prompt processing at 512 and 2048 tokens plus tg128, 5 repetitions",
+		NPrompt:     []int{512, 2048},
+		NGen:        []int{128},
+		Repetitions: 5,
+	},
+	"long-context": {
+		Name:        "long-context",
+		Description: "pp512/tg128 at KV-cache depths up to 32k to show how throughput falls with context length",
+		NPrompt:     []int{512},
+		NGen:        []int{128},
+		NDepth:      []int{0, 4096, 16384, 32768},
+		Repetitions: 3,
+	},
+	"batch-throughput": {
+		Name:        "batch-throughput",
+		Description: "pp4096 across batch and micro-batch sizes, no generation, to find the best batching for prompt throughput",
+		NPrompt:     []int{4096},
+		NGen:        []int{0},
+		BatchSize:   []int{512, 2048},
+		UBatchSize:  []int{256, 512},
+		Repetitions: 3,
+	},
+}
+
+// BenchmarkPresets 获取所有内置预设，按名称排序
+func BenchmarkPresets() []model.BenchmarkPreset {
+	presets := make([]model.BenchmarkPreset, 0, len(benchmarkPresets))
+	for _, name := range slices.Sorted(maps.Keys(benchmarkPresets)) {
+		presets = append(presets, benchmarkPresets[name])
+	}
+	return presets
+}
+
+// validatePreset 检查预设名称是否存在
+func validatePreset(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, exists := benchmarkPresets[name]; !exists {
+		return fmt.Errorf("unknown benchmark preset: %s (expected one of %s)",
+			name, strings.Join(slices.Sorted(maps.Keys(benchmarkPresets)), ", "))
+	}
+	return nil
+}
+
+// presetValue 请求中显式指定的值优先，未指定（为0）时使用预设的值列表，两者都没有时返回空字符串
+func presetValue(value int, preset []int) string {
+	if value > 0 {
+		return strconv.Itoa(value)
+	}
+	values := make([]string, len(preset))
+	for i, v := range preset {
+		values[i] = strconv.Itoa(v)
+	}
+	return strings.Join(values, ",")
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"llama-switch/internal/model"
+)
+
+func TestBenchmarkPresetArgs(t *testing.T) {
+	cfg := &model.BenchmarkConfig{ModelPath: "m.gguf", Preset: "long-context"}
+	cfg.Config.NGen = 256
+	args := benchmarkArgs("/models/m.gguf", cfg)
+	for flag, want := range map[string]string{
+		"--n-prompt":    "512",
+		"--n-gen":       "256", // 显式指定的值优先
+		"--n-depth":     "0,4096,16384,32768",
+		"--repetitions": "3",
+	} {
+		i := slices.Index(args, flag)
+		if i < 0 || args[i+1] != want {
+			t.Errorf("%s = %v, want %s (args %v)", flag, i, want, args)
+		}
+	}
+	if slices.Contains(args, "--batch-size") {
+		t.Errorf("long-context preset set batch size: %v", args)
+	}
+
+	// batch-throughput显式关闭生成测试
+	args = benchmarkArgs("/models/m.gguf", &model.BenchmarkConfig{Preset: "batch-throughput"})
+	if i := slices.Index(args, "--n-gen"); i < 0 || args[i+1] != "0" {
+		t.Errorf("batch-throughput args missing --n-gen 0: %v", args)
+	}
+
+	s := &BenchmarkService{}
+	for _, preset := range BenchmarkPresets() {
+		if err := s.ValidateBenchmarkConfig(&model.BenchmarkConfig{ModelPath: "m.gguf", Preset: preset.Name}); err != nil {
+			t.Errorf("preset %s rejected: %v", preset.Name, err)
+		}
+	}
+	if err := s.ValidateBenchmarkConfig(&model.BenchmarkConfig{ModelPath: "m.gguf", Preset: "huge"}); err == nil {
+		t.Error("unknown preset accepted")
+	}
+}
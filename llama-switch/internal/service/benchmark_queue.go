@@ -0,0 +1,199 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+)
+
+// 独占GPU的方式
+const (
+	ExclusiveNone  = "none"  // 与服务中的模型共享GPU
+	ExclusiveStop  = "stop"  // 停止使用相同GPU的模型，测试结束后重新启动
+	ExclusivePause = "pause" // 暂停使用相同GPU的模型进程，测试结束后继续
+)
+
+const (
+	benchmarkShutdownTimeout = 30 * time.Second // 关闭时等待执行中的任务结束并恢复被暂停模型的最长时间
+	benchmarkPollInterval    = 5 * time.Second  // 等待任务结束时查询状态的间隔
+)
+
+// benchmarkJob 排队中或执行中的基准测试任务
+type benchmarkJob struct {
+	taskID    string
+	cfg       *model.BenchmarkConfig // 请求的测试配置，复现任务时为nil
+	args      []string
+	manifest  *model.BenchmarkManifest
+	gpus      []int                         // 使用的GPU，为空表示所有GPU
+	exclusive string                        // 独占GPU的方式
+	serving   *model.ServingBenchmarkConfig // 服务基准测试配置，为nil时运行llama-bench
+	force     bool                          // 相同GPU上已有测试时仍然排队，否则提交时返回BenchmarkConflictError
+
+	stopped []*model.ModelConfig // 为独占GPU而停止的模型
+	paused  []string             // 为独占GPU而暂停的模型
+}
+
+// BenchmarkConflictError 相同GPU上已有排队或运行中的基准测试，同时运行会使结果失真
+type BenchmarkConflictError struct {
+	TaskID string // 冲突的任务ID
+	Status string // 冲突任务的状态（queued/running）
+	GPUs   []int  // 冲突任务使用的GPU，为空表示所有GPU
+}
+
+func (e *BenchmarkConflictError) Error() string {
+	gpus := "all GPUs"
+	if len(e.GPUs) > 0 {
+		gpus = fmt.Sprintf("GPUs %v", e.GPUs)
+	}
+	return fmt.Sprintf("benchmark task %s is already %s on %s, use force=true to queue behind it", e.TaskID, e.Status, gpus)
+}
+
+func (e *BenchmarkConflictError) ErrorCode() apierror.Code { return apierror.CodeBenchmarkConflict }
+
+// conflict 查找与任务使用相同GPU的执行中或排队中任务，执行中的任务优先，调用方需持有s.mu
+func (s *BenchmarkService) conflict(job *benchmarkJob) *BenchmarkConflictError {
+	for taskID, active := range s.active {
+		if gpusOverlap(job.gpus, active.gpus) {
+			return &BenchmarkConflictError{TaskID: taskID, Status: "running", GPUs: active.gpus}
+		}
+	}
+	for _, queued := range s.queue {
+		if gpusOverlap(job.gpus, queued.gpus) {
+			return &BenchmarkConflictError{TaskID: queued.taskID, Status: "queued", GPUs: queued.gpus}
+		}
+	}
+	return nil
+}
+
+// validateGPUSelection 验证测试使用的GPU编号和独占方式
+func validateGPUSelection(gpus []int, exclusive string) error {
+	switch exclusive {
+	case "", ExclusiveNone, ExclusiveStop:
+	case ExclusivePause:
+		if runtime.GOOS == "windows" {
+			return fmt.Errorf("exclusive mode pause is not supported on Windows, use stop instead")
+		}
+	default:
+		return fmt.Errorf("invalid exclusive mode: %s (expected none, stop or pause)", exclusive)
+	}
+	for i, gpu := range gpus {
+		if gpu < 0 || slices.Contains(gpus[:i], gpu) {
+			return fmt.Errorf("invalid gpus: %v", gpus)
+		}
+	}
+	return nil
+}
+
+// next 按提交顺序取出可以开始执行的任务并标记为运行中，调用方需持有s.mu：
+// 任务使用的GPU不能与执行中的任务重叠，也不能与排在前面仍在等待的任务重叠，避免后提交的任务插队
+func (s *BenchmarkService) next() []*benchmarkJob {
+	if s.closing {
+		return nil
+	}
+	var started, waiting []*benchmarkJob
+	remaining := s.queue[:0]
+	for _, job := range s.queue {
+		blocked := slices.ContainsFunc(waiting, func(other *benchmarkJob) bool { return gpusOverlap(job.gpus, other.gpus) })
+		for _, active := range s.active {
+			blocked = blocked || gpusOverlap(job.gpus, active.gpus)
+		}
+		if blocked {
+			waiting = append(waiting, job)
+			remaining = append(remaining, job)
+			continue
+		}
+		s.active[job.taskID] = job
+		if status, exists := s.tasks[job.taskID]; exists {
+			status.Status = "running"
+		}
+		started = append(started, job)
+	}
+	s.queue = remaining
+	s.updateQueuePositions()
+	return started
+}
+
+// updateQueuePositions 更新排队任务的位置，调用方需持有s.mu
+func (s *BenchmarkService) updateQueuePositions() {
+	for i, job := range s.queue {
+		if status, exists := s.tasks[job.taskID]; exists {
+			status.QueuePosition = i + 1
+		}
+	}
+	for taskID := range s.active {
+		if status, exists := s.tasks[taskID]; exists {
+			status.QueuePosition = 0
+		}
+	}
+}
+
+// cancelQueued 取消排队中的任务并写入历史记录，任务不在队列中时返回false，调用方需持有s.mu
+func (s *BenchmarkService) cancelQueued(taskID string) bool {
+	for i, job := range s.queue {
+		if job.taskID != taskID {
+			continue
+		}
+		s.queue = slices.Delete(s.queue, i, i+1)
+		s.updateQueuePositions()
+		if status, exists := s.tasks[taskID]; exists {
+			status.Status = "cancelled"
+			status.QueuePosition = 0
+			status.EndTime = time.Now().Format(time.RFC3339)
+			s.finish(job, benchmarkRecord(status, job.cfg, job.manifest, 0, "", "cancelled while queued"))
+		}
+		slog.Info("Queued benchmark task cancelled", "task_id", taskID)
+		return true
+	}
+	return false
+}
+
+// schedule 启动所有可以开始执行的排队任务
+func (s *BenchmarkService) schedule() {
+	s.mu.Lock()
+	jobs := s.next()
+	s.running.Add(len(jobs))
+	s.mu.Unlock()
+	for _, job := range jobs {
+		go s.execute(job)
+	}
+}
+
+// displace 按任务的独占方式停止或暂停使用相同GPU的模型
+func (s *BenchmarkService) displace(job *benchmarkJob) []string {
+	if s.models == nil {
+		return nil
+	}
+	var names []string
+	switch job.exclusive {
+	case ExclusiveStop:
+		job.stopped = s.models.StopModelsOnGPUs(job.gpus)
+		for _, cfg := range job.stopped {
+			names = append(names, cfg.ModelName)
+		}
+	case ExclusivePause:
+		job.paused = s.models.PauseModelsOnGPUs(job.gpus)
+		names = job.paused
+	}
+	return names
+}
+
+// restore 恢复任务停止或暂停的模型；服务关闭时只继续执行被暂停的模型，不重新启动已停止的模型
+func (s *BenchmarkService) restore(job *benchmarkJob) {
+	if s.models == nil || len(job.stopped)+len(job.paused) == 0 {
+		return
+	}
+	slog.Info("Benchmark finished, restoring displaced models", "task_id", job.taskID)
+	s.models.ResumeModels(job.paused)
+
+	s.mu.RLock()
+	closing := s.closing
+	s.mu.RUnlock()
+	if !closing {
+		s.models.RestartModels(job.stopped)
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestGPUsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b []int
+		want bool
+	}{
+		{nil, []int{1}, true},
+		{[]int{0}, nil, true},
+		{[]int{0, 1}, []int{1, 2}, true},
+		{[]int{0}, []int{1}, false},
+	}
+	for _, tt := range tests {
+		if got := gpusOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("gpusOverlap(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestBenchmarkQueue(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Benchmark.HistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := NewBenchmarkService(cfg, nil)
+
+	enqueue := func(taskID string, gpus ...int) {
+		s.tasks[taskID] = &model.BenchmarkStatus{TaskID: taskID, Status: "queued"}
+		s.queue = append(s.queue, &benchmarkJob{taskID: taskID, gpus: gpus, manifest: &model.BenchmarkManifest{}})
+	}
+	ids := func(jobs []*benchmarkJob) []string {
+		var names []string
+		for _, job := range jobs {
+			names = append(names, job.taskID)
+		}
+		return names
+	}
+
+	enqueue("a", 0)
+	enqueue("b", 0)
+	enqueue("c", 1)
+	enqueue("d")
+	enqueue("e", 2)
+
+	// 同一GPU上的任务依次执行，使用所有GPU的任务d阻塞了排在它后面的任务
+	if started := ids(s.next()); !slices.Equal(started, []string{"a", "c"}) {
+		t.Fatalf("first round started %v, want [a c]", started)
+	}
+	for taskID, want := range map[string]int{"a": 0, "b": 1, "d": 2, "e": 3} {
+		if got := s.tasks[taskID].QueuePosition; got != want {
+			t.Errorf("task %s queue position = %d, want %d", taskID, got, want)
+		}
+	}
+	if s.tasks["a"].Status != "running" || s.tasks["b"].Status != "queued" {
+		t.Errorf("unexpected statuses: a=%s b=%s", s.tasks["a"].Status, s.tasks["b"].Status)
+	}
+
+	// 取消排队中的任务后后面的任务前移
+	if !s.cancelQueued("b") || s.tasks["b"].Status != "cancelled" {
+		t.Fatalf("cancelQueued(b) failed, status %s", s.tasks["b"].Status)
+	}
+	if s.cancelQueued("a") {
+		t.Error("cancelQueued(a) succeeded for a running task")
+	}
+	if got := s.tasks["e"].QueuePosition; got != 2 {
+		t.Errorf("task e queue position = %d after cancel, want 2", got)
+	}
+
+	delete(s.active, "a")
+	if started := ids(s.next()); len(started) != 0 {
+		t.Errorf("started %v while task c is still running, want none", started)
+	}
+	delete(s.active, "c")
+	if started := ids(s.next()); !slices.Equal(started, []string{"d"}) {
+		t.Errorf("started %v, want [d]", started)
+	}
+	delete(s.active, "d")
+	if started := ids(s.next()); !slices.Equal(started, []string{"e"}) {
+		t.Errorf("started %v, want [e]", started)
+	}
+}
+
+func TestValidateBenchmarkExclusive(t *testing.T) {
+	s := NewBenchmarkService(&config.Config{}, nil)
+	for _, tt := range []struct {
+		exclusive string
+		gpus      []int
+		valid     bool
+	}{
+		{"", nil, true},
+		{"stop", []int{0, 1}, true},
+		{"evict", nil, false},
+		{"none", []int{0, 0}, false},
+		{"none", []int{-1}, false},
+	} {
+		cfg := &model.BenchmarkConfig{ModelPath: "model.gguf", Exclusive: tt.exclusive, GPUs: tt.gpus}
+		if err := s.ValidateBenchmarkConfig(cfg); (err == nil) != tt.valid {
+			t.Errorf("ValidateBenchmarkConfig(exclusive=%q, gpus=%v) = %v, want valid=%v", tt.exclusive, tt.gpus, err, tt.valid)
+		}
+	}
+}
+
+func TestBenchmarkConflict(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Benchmark.HistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.Benchmark.ManifestDir = t.TempDir()
+	s := NewBenchmarkService(cfg, nil)
+	s.tasks["a"] = &model.BenchmarkStatus{TaskID: "a", Status: "running"}
+	s.active["a"] = &benchmarkJob{taskID: "a", gpus: []int{0}}
+
+	// 相同GPU上已有运行中的任务时返回冲突任务ID
+	_, err := s.enqueue(&benchmarkJob{gpus: []int{0, 1}, manifest: &model.BenchmarkManifest{}})
+	var conflict *BenchmarkConflictError
+	if !errors.As(err, &conflict) || conflict.TaskID != "a" || conflict.Status != "running" {
+		t.Fatalf("enqueue error = %v, want conflict with running task a", err)
+	}
+
+	// 指定force时排在冲突任务后面
+	taskID, err := s.enqueue(&benchmarkJob{gpus: []int{0}, force: true, manifest: &model.BenchmarkManifest{}})
+	if err != nil {
+		t.Fatalf("forced enqueue failed: %v", err)
+	}
+	if status := s.tasks[taskID]; status.Status != "queued" || status.QueuePosition != 1 {
+		t.Errorf("forced task status = %s (position %d), want queued at 1", status.Status, status.QueuePosition)
+	}
+
+	// 排队中的任务同样视为冲突，使用其他GPU的任务不冲突
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c := s.conflict(&benchmarkJob{}); c == nil || c.TaskID != "a" {
+		t.Errorf("conflict for all GPUs = %v, want task a", c)
+	}
+	delete(s.active, "a")
+	if c := s.conflict(&benchmarkJob{gpus: []int{0}}); c == nil || c.TaskID != taskID || c.Status != "queued" {
+		t.Errorf("conflict for GPU 0 = %v, want queued task %s", c, taskID)
+	}
+	if c := s.conflict(&benchmarkJob{gpus: []int{2}}); c != nil {
+		t.Errorf("conflict for GPU 2 = %v, want none", c)
+	}
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"llama-switch/internal/apierror"
+)
+
+// benchmarkPruneInterval 后台清理已结束任务的间隔
+const benchmarkPruneInterval = time.Minute
+
+// ErrBenchmarkActive 任务仍在排队或运行，不能删除
+var ErrBenchmarkActive = apierror.New(apierror.CodeBenchmarkActive, "benchmark task is still queued or running")
+
+// StartTaskPruner 启动后台清理，定期从内存中移除超过保留时间或任务数上限的已结束任务（提交新任务时也会检查），
+// 被移除的任务仍可从历史记录中查询
+func (s *BenchmarkService) StartTaskPruner(ctx context.Context) {
+	if s.config.Benchmark.MaxTasks <= 0 && s.config.Benchmark.TaskMaxAge <= 0 {
+		slog.Info("Benchmark task pruning is disabled")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(benchmarkPruneInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				s.pruneTasks(time.Now())
+				s.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// pruneTasks 移除超过保留时间的已结束任务，任务数仍超过上限时按结束时间从早到晚继续移除，
+// 排队中和执行中的任务（包括已取消但进程尚未退出的任务）不会被移除，调用方需持有s.mu
+func (s *BenchmarkService) pruneTasks(now time.Time) int {
+	maxAge := time.Duration(s.config.Benchmark.TaskMaxAge) * time.Second
+	maxTasks := s.config.Benchmark.MaxTasks
+
+	type finishedTask struct {
+		taskID string
+		ended  time.Time
+	}
+	var finished []finishedTask
+	removed := 0
+	for taskID, status := range s.tasks {
+		if !s.taskFinished(taskID) {
+			continue
+		}
+		ended, err := time.Parse(time.RFC3339, status.EndTime)
+		if err != nil {
+			ended, _ = time.Parse(time.RFC3339, status.StartTime)
+		}
+		if maxAge > 0 && now.Sub(ended) > maxAge {
+			delete(s.tasks, taskID)
+			removed++
+			continue
+		}
+		finished = append(finished, finishedTask{taskID: taskID, ended: ended})
+	}
+
+	if maxTasks > 0 && len(s.tasks) > maxTasks {
+		slices.SortFunc(finished, func(a, b finishedTask) int { return a.ended.Compare(b.ended) })
+		for _, task := range finished {
+			if len(s.tasks) <= maxTasks {
+				break
+			}
+			delete(s.tasks, task.taskID)
+			removed++
+		}
+	}
+	if removed > 0 {
+		slog.Info("Pruned finished benchmark tasks", "count", removed)
+	}
+	return removed
+}
+
+// taskFinished 检查任务是否已结束且不在队列或执行中，调用方需持有s.mu
+func (s *BenchmarkService) taskFinished(taskID string) bool {
+	status, exists := s.tasks[taskID]
+	if !exists || status.Status == "queued" || status.Status == "running" {
+		return false
+	}
+	if _, active := s.active[taskID]; active {
+		return false
+	}
+	return !slices.ContainsFunc(s.queue, func(job *benchmarkJob) bool { return job.taskID == taskID })
+}
+
+// DeleteTask 从内存中删除已结束的任务，排队或运行中的任务需要先停止；历史记录和可复现清单保留
+func (s *BenchmarkService) DeleteTask(taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tasks[taskID]; !exists {
+		return apierror.New(apierror.CodeBenchmarkNotFound, "task not found: %s", taskID)
+	}
+	if !s.taskFinished(taskID) {
+		return ErrBenchmarkActive
+	}
+	delete(s.tasks, taskID)
+	slog.Info("Benchmark task deleted", "task_id", taskID)
+	return nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestPruneTasks(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Benchmark.HistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.Benchmark.MaxTasks = 3
+	cfg.Benchmark.TaskMaxAge = 3600
+	s := NewBenchmarkService(cfg, nil)
+
+	now := time.Date(2024, 5, 3, 12, 0, 0, 0, time.UTC)
+	add := func(taskID, status string, ended time.Duration) {
+		s.tasks[taskID] = &model.BenchmarkStatus{
+			TaskID:  taskID,
+			Status:  status,
+			EndTime: now.Add(-ended).Format(time.RFC3339),
+		}
+	}
+	add("expired", "completed", 2*time.Hour)
+	add("old", "failed", 30*time.Minute)
+	add("recent", "completed", time.Minute)
+	add("queued", "queued", 0)
+	add("stopping", "cancelled", 3*time.Hour)
+	s.active["stopping"] = &benchmarkJob{taskID: "stopping"}
+
+	// 过期任务被移除，任务数仍超过上限时移除最早结束的任务，执行中（已取消但未退出）的任务保留
+	if removed := s.pruneTasks(now); removed != 2 {
+		t.Errorf("pruneTasks removed %d tasks, want 2", removed)
+	}
+	for _, taskID := range []string{"expired", "old"} {
+		if _, exists := s.tasks[taskID]; exists {
+			t.Errorf("task %s was not pruned", taskID)
+		}
+	}
+	for _, taskID := range []string{"recent", "queued", "stopping"} {
+		if _, exists := s.tasks[taskID]; !exists {
+			t.Errorf("task %s was pruned", taskID)
+		}
+	}
+}
+
+func TestDeleteTask(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Benchmark.HistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := NewBenchmarkService(cfg, nil)
+	s.tasks["done"] = &model.BenchmarkStatus{TaskID: "done", Status: "completed"}
+	s.tasks["running"] = &model.BenchmarkStatus{TaskID: "running", Status: "running"}
+
+	if err := s.DeleteTask("running"); !errors.Is(err, ErrBenchmarkActive) {
+		t.Errorf("DeleteTask(running) error = %v, want ErrBenchmarkActive", err)
+	}
+	if err := s.DeleteTask("done"); err != nil {
+		t.Fatalf("DeleteTask(done) failed: %v", err)
+	}
+	if _, exists := s.tasks["done"]; exists {
+		t.Error("deleted task is still in memory")
+	}
+	if err := s.DeleteTask("done"); err == nil {
+		t.Error("DeleteTask succeeded for a missing task")
+	}
+}
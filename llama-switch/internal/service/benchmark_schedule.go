@@ -0,0 +1,422 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// EventBenchmarkRegression 定时基准测试的吞吐量低于滚动基线超过阈值
+const EventBenchmarkRegression = "benchmark_regression"
+
+const (
+	scheduleFileName    = "benchmark_schedules.json" // 定时基准测试持久化文件名
+	scheduleTick        = 30 * time.Second           // 检查到期定时任务的间隔
+	defaultBaselineRuns = 5                          // 滚动基线默认包含的最近成功次数
+)
+
+// scheduleEntry 定时任务及其运行状态
+type scheduleEntry struct {
+	schedule *model.BenchmarkSchedule
+	cron     *cronSchedule
+	status   model.BenchmarkScheduleStatus // 最近一次运行情况（不持久化）
+	nextRun  time.Time
+}
+
+// BenchmarkScheduler 定时基准测试调度器：按cron表达式提交测试（结果照常写入历史记录），
+// 结束后与相同参数的最近几次成功结果比较，吞吐量下降超过阈值时通过Webhook、邮件和事件日志通知
+type BenchmarkScheduler struct {
+	service *BenchmarkService
+	path    string
+
+	mu      sync.Mutex
+	entries map[string]*scheduleEntry
+}
+
+// NewBenchmarkScheduler 创建定时基准测试调度器并加载已保存的定时任务
+func NewBenchmarkScheduler(service *BenchmarkService, path string) *BenchmarkScheduler {
+	s := &BenchmarkScheduler{
+		service: service,
+		path:    path,
+		entries: make(map[string]*scheduleEntry),
+	}
+	if err := s.load(); err != nil {
+		slog.Warn("Failed to load benchmark schedules", "file", path, "error", err)
+	}
+	return s
+}
+
+// defaultSchedulePath 默认定时任务文件路径：程序目录下的config目录
+func defaultSchedulePath() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return filepath.Join("config", scheduleFileName)
+	}
+	return filepath.Join(filepath.Dir(exePath), "config", scheduleFileName)
+}
+
+// validate 验证定时任务配置
+func (s *BenchmarkScheduler) validate(schedule *model.BenchmarkSchedule) (*cronSchedule, error) {
+	if schedule.Name == "" {
+		return nil, fmt.Errorf("schedule name is required")
+	}
+	cron, err := parseCron(schedule.Cron)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.service.ValidateBenchmarkConfig(&schedule.Benchmark); err != nil {
+		return nil, fmt.Errorf("invalid benchmark config: %v", err)
+	}
+	if schedule.ThresholdPct < 0 || schedule.ThresholdPct >= 100 {
+		return nil, fmt.Errorf("invalid threshold_pct: %v (expected 0-100)", schedule.ThresholdPct)
+	}
+	if schedule.BaselineRuns < 0 {
+		return nil, fmt.Errorf("invalid baseline_runs: %d", schedule.BaselineRuns)
+	}
+	if schedule.WebhookURL != "" {
+		if u, err := url.Parse(schedule.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("invalid webhook url: %s", schedule.WebhookURL)
+		}
+	}
+	if len(schedule.Email) > 0 && s.service.config.SMTP.Addr == "" {
+		return nil, fmt.Errorf("email notification requires SMTP_ADDR to be configured")
+	}
+	for _, address := range schedule.Email {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return nil, fmt.Errorf("invalid email address %q: %v", address, err)
+		}
+	}
+	return cron, nil
+}
+
+// Set 添加或替换定时任务
+func (s *BenchmarkScheduler) Set(schedule *model.BenchmarkSchedule) (*model.BenchmarkScheduleStatus, error) {
+	cron, err := s.validate(schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := &scheduleEntry{schedule: schedule, cron: cron, nextRun: cron.next(time.Now())}
+	if previous, exists := s.entries[schedule.Name]; exists {
+		entry.status = previous.status
+	}
+	s.entries[schedule.Name] = entry
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+	slog.Info("Benchmark schedule set", "schedule", schedule.Name, "cron", schedule.Cron, "next_run", entry.nextRun.Format(time.RFC3339))
+	status := entry.snapshot()
+	return &status, nil
+}
+
+// Remove 删除定时任务（不影响已提交的测试）
+func (s *BenchmarkScheduler) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.entries[name]; !exists {
+		return fmt.Errorf("benchmark schedule not found: %s", name)
+	}
+	delete(s.entries, name)
+	if err := s.save(); err != nil {
+		return err
+	}
+	slog.Info("Benchmark schedule removed", "schedule", name)
+	return nil
+}
+
+// List 获取所有定时任务及其最近一次运行情况，按名称排序
+func (s *BenchmarkScheduler) List() []model.BenchmarkScheduleStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	schedules := make([]model.BenchmarkScheduleStatus, 0, len(s.entries))
+	for _, name := range slices.Sorted(maps.Keys(s.entries)) {
+		schedules = append(schedules, s.entries[name].snapshot())
+	}
+	return schedules
+}
+
+// snapshot 生成定时任务的状态副本，调用方需持有锁
+func (e *scheduleEntry) snapshot() model.BenchmarkScheduleStatus {
+	status := e.status
+	status.BenchmarkSchedule = *e.schedule
+	status.NextRun = ""
+	if !e.nextRun.IsZero() {
+		status.NextRun = e.nextRun.Format(time.RFC3339)
+	}
+	return status
+}
+
+// Start 启动后台调度，ctx取消时停止
+func (s *BenchmarkScheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(scheduleTick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				for _, name := range s.due(now) {
+					go s.run(ctx, name)
+				}
+			}
+		}
+	}()
+}
+
+// due 返回到期的定时任务并计算其下次运行时间
+func (s *BenchmarkScheduler) due(now time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for name, entry := range s.entries {
+		if entry.nextRun.IsZero() || entry.nextRun.After(now) {
+			continue
+		}
+		entry.nextRun = entry.cron.next(now)
+		names = append(names, name)
+	}
+	return names
+}
+
+// run 提交定时任务的测试，等待结束后检查吞吐量是否下降
+func (s *BenchmarkScheduler) run(ctx context.Context, name string) {
+	s.mu.Lock()
+	entry, exists := s.entries[name]
+	if !exists {
+		s.mu.Unlock()
+		return
+	}
+	schedule := *entry.schedule
+	s.mu.Unlock()
+
+	slog.Info("Running scheduled benchmark", "schedule", name)
+	// 定时任务到期时与其他测试一样排队，不因相同GPU上有测试而跳过
+	cfg := schedule.Benchmark
+	cfg.Force = true
+	taskID, err := s.service.StartBenchmark(&cfg)
+	s.update(name, func(status *model.BenchmarkScheduleStatus) {
+		status.LastRun = time.Now().Format(time.RFC3339)
+		status.LastTaskID = taskID
+		status.LastStatus = "queued"
+		status.LastError = ""
+		if err != nil {
+			status.LastStatus = "failed"
+			status.LastError = err.Error()
+		}
+	})
+	if err != nil {
+		slog.Error("Failed to start scheduled benchmark", "schedule", name, "error", err)
+		return
+	}
+
+	result, err := s.service.waitTask(ctx, taskID)
+	if err != nil {
+		slog.Error("Failed to wait for scheduled benchmark", "schedule", name, "error", err)
+		return
+	}
+	s.update(name, func(status *model.BenchmarkScheduleStatus) { status.LastStatus = result.Status })
+	if result.Status != "completed" || schedule.ThresholdPct <= 0 {
+		return
+	}
+
+	regressions, err := s.checkRegression(&schedule, result)
+	if err != nil {
+		slog.Error("Failed to check scheduled benchmark for regressions", "schedule", name, "error", err)
+		return
+	}
+	if len(regressions) == 0 {
+		return
+	}
+	alert := &model.BenchmarkRegressionAlert{
+		Schedule:    name,
+		TaskID:      taskID,
+		ModelPath:   result.Manifest.ModelPath,
+		Time:        time.Now().Format(time.RFC3339),
+		Regressions: regressions,
+	}
+	s.update(name, func(status *model.BenchmarkScheduleStatus) { status.LastAlert = alert })
+	s.notify(&schedule, alert)
+}
+
+// update 更新定时任务的运行状态
+func (s *BenchmarkScheduler) update(name string, apply func(status *model.BenchmarkScheduleStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, exists := s.entries[name]; exists {
+		apply(&entry.status)
+	}
+}
+
+// checkRegression 将本次结果与历史记录中相同参数的最近几次成功结果的平均吞吐量比较，返回下降超过阈值的测试类型
+func (s *BenchmarkScheduler) checkRegression(schedule *model.BenchmarkSchedule, result *model.BenchmarkStatus) ([]model.BenchmarkRegression, error) {
+	records, err := s.service.history.Query(BenchmarkHistoryFilter{})
+	if err != nil {
+		return nil, err
+	}
+	runs := schedule.BaselineRuns
+	if runs <= 0 {
+		runs = defaultBaselineRuns
+	}
+	var baseline []*model.BenchmarkRecord
+	for i := len(records) - 1; i >= 0 && len(baseline) < runs; i-- {
+		record := records[i]
+		if record.TaskID != result.TaskID && record.Status == "completed" && slices.Equal(record.Args, result.Manifest.Args) {
+			baseline = append(baseline, record)
+		}
+	}
+	return findRegressions(result.AllResults, baseline, schedule.ThresholdPct), nil
+}
+
+// findRegressions 按测试类型计算基线的平均吞吐量，返回下降超过thresholdPct的测试类型
+func findRegressions(results []*model.BenchmarkResults, baseline []*model.BenchmarkRecord, thresholdPct float64) []model.BenchmarkRegression {
+	var regressions []model.BenchmarkRegression
+	for _, current := range results {
+		var sum float64
+		var count int
+		for _, record := range baseline {
+			for _, previous := range record.Results {
+				if previous.TestType == current.TestType && previous.TokensPerSecond > 0 {
+					sum += previous.TokensPerSecond
+					count++
+				}
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		average := sum / float64(count)
+		drop := (average - current.TokensPerSecond) / average * 100
+		if drop > thresholdPct {
+			regressions = append(regressions, model.BenchmarkRegression{
+				TestType:        current.TestType,
+				BaselineTPS:     average,
+				TokensPerSecond: current.TokensPerSecond,
+				DropPct:         drop,
+				BaselineRuns:    count,
+			})
+		}
+	}
+	return regressions
+}
+
+// notify 通过事件日志、Webhook和邮件发送性能下降通知
+func (s *BenchmarkScheduler) notify(schedule *model.BenchmarkSchedule, alert *model.BenchmarkRegressionAlert) {
+	var lines []string
+	for _, r := range alert.Regressions {
+		lines = append(lines, fmt.Sprintf("%s: %.2f t/s vs baseline %.2f t/s (-%.1f%%, %d runs)",
+			r.TestType, r.TokensPerSecond, r.BaselineTPS, r.DropPct, r.BaselineRuns))
+	}
+	message := fmt.Sprintf("Scheduled benchmark %s regressed on %s: %s",
+		alert.Schedule, filepath.Base(alert.ModelPath), strings.Join(lines, "; "))
+	slog.Warn("Scheduled benchmark regressed", "schedule", alert.Schedule, "model_path", alert.ModelPath, "regressions", strings.Join(lines, "; "))
+	if s.service.models != nil {
+		s.service.models.Events().Record(EventBenchmarkRegression, "", message, alert)
+	}
+
+	if schedule.WebhookURL != "" {
+		payload, _ := json.Marshal(map[string]interface{}{
+			"event": "benchmark.regression",
+			"alert": alert,
+		})
+		resp, err := webhookClient.Post(schedule.WebhookURL, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			slog.Warn("Failed to send benchmark regression webhook", "schedule", alert.Schedule, "error", err)
+		} else {
+			resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				slog.Warn("Benchmark regression webhook returned an error status", "schedule", alert.Schedule, "status", resp.StatusCode)
+			}
+		}
+	}
+
+	if len(schedule.Email) > 0 {
+		subject := fmt.Sprintf("[llama-switch] Benchmark regression: %s", alert.Schedule)
+		body := fmt.Sprintf("%s\n\nTask: %s\nModel: %s\n\n%s\n", message, alert.TaskID, alert.ModelPath, strings.Join(lines, "\n"))
+		if err := s.sendMail(schedule.Email, subject, body); err != nil {
+			slog.Warn("Failed to send benchmark regression email", "schedule", alert.Schedule, "error", err)
+		}
+	}
+}
+
+// sendMail 通过配置的SMTP服务器发送纯文本邮件
+func (s *BenchmarkScheduler) sendMail(to []string, subject, body string) error {
+	cfg := s.service.config.SMTP
+	if cfg.Addr == "" {
+		return fmt.Errorf("SMTP_ADDR is not configured")
+	}
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		host, _, _ := net.SplitHostPort(cfg.Addr)
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return smtp.SendMail(cfg.Addr, auth, cfg.From, to, []byte(msg.String()))
+}
+
+// load 从文件加载定时任务，无效的任务记录警告后跳过
+func (s *BenchmarkScheduler) load() error {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schedule file: %v", err)
+	}
+
+	var schedules []*model.BenchmarkSchedule
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return fmt.Errorf("failed to parse schedule file: %v", err)
+	}
+	now := time.Now()
+	for _, schedule := range schedules {
+		cron, err := s.validate(schedule)
+		if err != nil {
+			slog.Warn("Skipping benchmark schedule", "schedule", schedule.Name, "error", err)
+			continue
+		}
+		s.entries[schedule.Name] = &scheduleEntry{schedule: schedule, cron: cron, nextRun: cron.next(now)}
+	}
+	return nil
+}
+
+// save 保存定时任务到文件（调用方需持有锁）
+func (s *BenchmarkScheduler) save() error {
+	schedules := make([]*model.BenchmarkSchedule, 0, len(s.entries))
+	for _, name := range slices.Sorted(maps.Keys(s.entries)) {
+		schedules = append(schedules, s.entries[name].schedule)
+	}
+	data, err := json.MarshalIndent(schedules, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize benchmark schedules: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create schedule directory: %v", err)
+	}
+	if err := os.WriteFile(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write schedule file: %v", err)
+	}
+	return nil
+}
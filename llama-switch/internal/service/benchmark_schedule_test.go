@@ -0,0 +1,122 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestBenchmarkScheduler(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{}
+	cfg.Benchmark.HistoryFile = filepath.Join(dir, "history.jsonl")
+	cfg.Benchmark.ScheduleFile = filepath.Join(dir, "schedules.json")
+	s := NewBenchmarkService(cfg, nil)
+
+	invalid := []*model.BenchmarkSchedule{
+		{Name: "", Cron: "@daily", Benchmark: model.BenchmarkConfig{ModelPath: "m.gguf"}},
+		{Name: "bad-cron", Cron: "every night", Benchmark: model.BenchmarkConfig{ModelPath: "m.gguf"}},
+		{Name: "no-model", Cron: "@daily"},
+		{Name: "threshold", Cron: "@daily", Benchmark: model.BenchmarkConfig{ModelPath: "m.gguf"}, ThresholdPct: 120},
+		{Name: "webhook", Cron: "@daily", Benchmark: model.BenchmarkConfig{ModelPath: "m.gguf"}, WebhookURL: "ftp://example.com"},
+		{Name: "email", Cron: "@daily", Benchmark: model.BenchmarkConfig{ModelPath: "m.gguf"}, Email: []string{"ops@example.com"}},
+	}
+	for _, schedule := range invalid {
+		if _, err := s.Schedules().Set(schedule); err == nil {
+			t.Errorf("Set(%s) succeeded, want error", schedule.Name)
+		}
+	}
+
+	status, err := s.Schedules().Set(&model.BenchmarkSchedule{
+		Name: "nightly", Cron: "0 2 * * *", Benchmark: model.BenchmarkConfig{ModelPath: "qwen.gguf"}, ThresholdPct: 10,
+	})
+	if err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	next, err := time.Parse(time.RFC3339, status.NextRun)
+	if err != nil || next.Hour() != 2 || next.Minute() != 0 || !next.After(time.Now()) {
+		t.Errorf("NextRun = %q, want the next 02:00", status.NextRun)
+	}
+
+	// 定时任务在重启后恢复
+	reloaded := NewBenchmarkService(cfg, nil).Schedules().List()
+	if len(reloaded) != 1 || reloaded[0].Name != "nightly" || reloaded[0].ThresholdPct != 10 {
+		t.Errorf("reloaded schedules = %+v", reloaded)
+	}
+
+	// 到期的任务只返回一次
+	if due := s.Schedules().due(next); len(due) != 1 || due[0] != "nightly" {
+		t.Errorf("due = %v, want [nightly]", due)
+	}
+	if due := s.Schedules().due(next); len(due) != 0 {
+		t.Errorf("due = %v after the run was scheduled, want none", due)
+	}
+
+	if err := s.Schedules().Remove("nightly"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := s.Schedules().Remove("nightly"); err == nil {
+		t.Error("Remove of a missing schedule succeeded")
+	}
+}
+
+func TestBenchmarkRegression(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Benchmark.HistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.Benchmark.ScheduleFile = filepath.Join(t.TempDir(), "schedules.json")
+	s := NewBenchmarkService(cfg, nil)
+
+	args := []string{"--model", "/models/qwen.gguf", "--output", "json"}
+	run := func(taskID, status string, args []string, pp, tg float64) {
+		s.history.Record(&model.BenchmarkRecord{TaskID: taskID, Status: status, Args: args, Results: []*model.BenchmarkResults{
+			{TestType: "pp512", TokensPerSecond: pp}, {TestType: "tg128", TokensPerSecond: tg},
+		}})
+	}
+	run("old", "completed", args, 10, 10) // 超出滚动基线的范围
+	run("a", "completed", args, 1000, 50)
+	run("b", "completed", args, 1100, 50)
+	run("failed", "failed", args, 1, 1)
+	run("other", "completed", []string{"--model", "/models/llama.gguf"}, 1, 1)
+	run("c", "completed", args, 900, 50)
+
+	schedule := &model.BenchmarkSchedule{Name: "nightly", ThresholdPct: 10, BaselineRuns: 3}
+	current := &model.BenchmarkStatus{
+		TaskID:     "current",
+		Manifest:   &model.BenchmarkManifest{Args: args, ModelPath: "/models/qwen.gguf"},
+		AllResults: []*model.BenchmarkResults{{TestType: "pp512", TokensPerSecond: 850}, {TestType: "tg128", TokensPerSecond: 48}},
+	}
+	regressions, err := s.Schedules().checkRegression(schedule, current)
+	if err != nil {
+		t.Fatalf("checkRegression failed: %v", err)
+	}
+	if len(regressions) != 1 || regressions[0].TestType != "pp512" || regressions[0].BaselineTPS != 1000 ||
+		regressions[0].DropPct != 15 || regressions[0].BaselineRuns != 3 {
+		t.Fatalf("regressions = %+v, want pp512 dropping 15%% from 1000", regressions)
+	}
+
+	received := make(chan map[string]json.RawMessage, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]json.RawMessage
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer server.Close()
+
+	schedule.WebhookURL = server.URL
+	s.Schedules().notify(schedule, &model.BenchmarkRegressionAlert{Schedule: "nightly", TaskID: "current", Regressions: regressions})
+	select {
+	case payload := <-received:
+		var alert model.BenchmarkRegressionAlert
+		if string(payload["event"]) != `"benchmark.regression"` || json.Unmarshal(payload["alert"], &alert) != nil || alert.TaskID != "current" {
+			t.Errorf("unexpected webhook payload: %s", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not received")
+	}
+}
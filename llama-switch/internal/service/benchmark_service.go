@@ -2,18 +2,21 @@ package service
 
 import (
 	"bufio"
-	"bytes"
+	"cmp"
 	"context"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"llama-switch/internal/apierror"
 	"llama-switch/internal/config"
 	"llama-switch/internal/model"
 
@@ -25,58 +28,127 @@ type BenchmarkService struct {
 	config         *config.Config
 	tasks          map[string]*model.BenchmarkStatus
 	processManager *ProcessManager
+	gpu            GPUProvider
+	history        *BenchmarkHistory
+	schedules      *BenchmarkScheduler
+	baselines      *BenchmarkBaselines
+	models         *ModelService            // 独占GPU时停止或暂停服务中的模型，为nil时不支持独占
+	queue          []*benchmarkJob          // 等待GPU空闲的任务，按提交顺序排列
+	active         map[string]*benchmarkJob // 执行中的任务
+	running        sync.WaitGroup           // 执行中任务的goroutine
+	closing        bool                     // 服务正在关闭，不再启动排队的任务
+	suites         map[string]*model.BenchmarkSuite
+	ctx            context.Context // 后台套件的上下文，关闭时取消
+	cancel         context.CancelFunc
 	mu             sync.RWMutex
 }
 
-// NewBenchmarkService 创建新的基准测试服务
-func NewBenchmarkService(cfg *config.Config) *BenchmarkService {
-	return &BenchmarkService{
+// NewBenchmarkService 创建新的基准测试服务，models用于在测试独占GPU时停止或暂停服务中的模型
+func NewBenchmarkService(cfg *config.Config, models *ModelService) *BenchmarkService {
+	historyPath := cfg.Benchmark.HistoryFile
+	if historyPath == "" {
+		historyPath = defaultBenchmarkHistoryPath()
+	}
+	s := &BenchmarkService{
 		config:         cfg,
 		tasks:          make(map[string]*model.BenchmarkStatus),
 		processManager: NewProcessManager(),
+		gpu:            newGPUProvider(cfg.GPU.Provider),
+		history:        NewBenchmarkHistory(historyPath),
+		models:         models,
+		active:         make(map[string]*benchmarkJob),
+		suites:         make(map[string]*model.BenchmarkSuite),
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
+	schedulePath := cfg.Benchmark.ScheduleFile
+	if schedulePath == "" {
+		schedulePath = defaultSchedulePath()
 	}
+	s.schedules = NewBenchmarkScheduler(s, schedulePath)
+
+	baselinePath := cfg.Benchmark.BaselineFile
+	if baselinePath == "" {
+		baselinePath = defaultBaselinePath()
+	}
+	s.baselines = NewBenchmarkBaselines(baselinePath, s.history)
+	return s
 }
 
-// StartBenchmark 启动基准测试
-func (s *BenchmarkService) StartBenchmark(cfg *model.BenchmarkConfig) (string, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// Schedules 获取定时基准测试调度器
+func (s *BenchmarkService) Schedules() *BenchmarkScheduler {
+	return s.schedules
+}
 
-	// 生成任务ID
-	taskID := uuid.New().String()
+// Baselines 获取固定基线管理器
+func (s *BenchmarkService) Baselines() *BenchmarkBaselines {
+	return s.baselines
+}
+
+// History 获取基准测试历史记录
+func (s *BenchmarkService) History() *BenchmarkHistory {
+	return s.history
+}
 
+// StartBenchmark 启动基准测试
+func (s *BenchmarkService) StartBenchmark(cfg *model.BenchmarkConfig) (string, error) {
 	// 验证模型文件路径
 	modelPath := cfg.ModelPath
 	if !filepath.IsAbs(modelPath) {
-		modelPath = filepath.Join(s.config.ModelsDir, cfg.ModelPath)
+		modelPath = filepath.Join(s.config.Snapshot().ModelsDir, cfg.ModelPath)
 	}
 	if _, err := filepath.Abs(modelPath); err != nil {
 		return "", fmt.Errorf("invalid model path: %v", err)
 	}
 
-	// 构建命令行参数
+	// 使用RPC池时以池中当前健康的端点作为--rpc，记录在请求配置和清单中
+	if cfg.RPCPool != "" {
+		if s.models == nil {
+			return "", fmt.Errorf("RPC pools are not available")
+		}
+		rpc, err := s.models.allocateRPC(cfg.RPCPool)
+		if err != nil {
+			return "", err
+		}
+		resolved := *cfg
+		resolved.Config.RPC = strings.Join(rpc.Endpoints, ",")
+		cfg = &resolved
+		slog.Info("Benchmarking with RPC pool", "model_path", cfg.ModelPath, "pool", rpc.Pool, "rpc", cfg.Config.RPC)
+	}
+
+	args := benchmarkArgs(modelPath, cfg)
+
+	manifest := s.collectManifest(modelPath, args)
+	manifest.VisibleGPUs = cfg.GPUs
+	manifest.RPCPool = cfg.RPCPool
+	return s.run(cfg, args, manifest)
+}
+
+// benchmarkArgs 根据测试配置构建llama-bench命令行参数
+func benchmarkArgs(modelPath string, cfg *model.BenchmarkConfig) []string {
 	args := []string{
 		"--model", modelPath,
 	}
 
-	// 添加配置参数
-	if cfg.Config.NPrompt > 0 {
-		args = append(args, "--n-prompt", strconv.Itoa(cfg.Config.NPrompt))
+	// 添加配置参数，未显式指定的提示、生成、深度和批处理参数使用预设的值列表
+	preset := benchmarkPresets[cfg.Preset]
+	if value := presetValue(cfg.Config.NPrompt, preset.NPrompt); value != "" {
+		args = append(args, "--n-prompt", value)
 	}
-	if cfg.Config.NGen > 0 {
-		args = append(args, "--n-gen", strconv.Itoa(cfg.Config.NGen))
+	if value := presetValue(cfg.Config.NGen, preset.NGen); value != "" {
+		args = append(args, "--n-gen", value)
 	}
 	if cfg.Config.PG != "" {
 		args = append(args, "--pg", cfg.Config.PG)
 	}
-	if cfg.Config.NDepth > 0 {
-		args = append(args, "--n-depth", strconv.Itoa(cfg.Config.NDepth))
+	if value := presetValue(cfg.Config.NDepth, preset.NDepth); value != "" {
+		args = append(args, "--n-depth", value)
 	}
-	if cfg.Config.BatchSize > 0 {
-		args = append(args, "--batch-size", strconv.Itoa(cfg.Config.BatchSize))
+	if value := presetValue(cfg.Config.BatchSize, preset.BatchSize); value != "" {
+		args = append(args, "--batch-size", value)
 	}
-	if cfg.Config.UBatchSize > 0 {
-		args = append(args, "--ubatch-size", strconv.Itoa(cfg.Config.UBatchSize))
+	if value := presetValue(cfg.Config.UBatchSize, preset.UBatchSize); value != "" {
+		args = append(args, "--ubatch-size", value)
 	}
 	if cfg.Config.CacheTypeK != "" {
 		args = append(args, "--cache-type-k", cfg.Config.CacheTypeK)
@@ -111,6 +183,9 @@ func (s *BenchmarkService) StartBenchmark(cfg *model.BenchmarkConfig) (string, e
 	if cfg.Config.FlashAttn > 0 {
 		args = append(args, "--flash-attn", strconv.Itoa(cfg.Config.FlashAttn))
 	}
+	if cfg.Config.RPC != "" {
+		args = append(args, "--rpc", cfg.Config.RPC)
+	}
 	if cfg.Config.Mmap >= 0 {
 		args = append(args, "--mmap", strconv.Itoa(cfg.Config.Mmap))
 	}
@@ -126,18 +201,25 @@ func (s *BenchmarkService) StartBenchmark(cfg *model.BenchmarkConfig) (string, e
 	if cfg.Config.OverrideTensors != "" {
 		args = append(args, "--override-tensors", cfg.Config.OverrideTensors)
 	}
-	if cfg.Config.Repetitions > 0 {
-		args = append(args, "--repetitions", strconv.Itoa(cfg.Config.Repetitions))
+	repetitions := cfg.Config.Repetitions
+	if repetitions == 0 {
+		repetitions = preset.Repetitions
+	}
+	if repetitions > 0 {
+		args = append(args, "--repetitions", strconv.Itoa(repetitions))
 	}
 	if cfg.Config.Priority > 0 {
 		args = append(args, "--prio", strconv.Itoa(cfg.Config.Priority))
 	}
 	if cfg.Config.Delay > 0 {
-		args = append(args, "--delay", strconv.Itoa(cfg.Config.Delay))
+		args = append(args, "--delay", strconv.Itoa(int(cfg.Config.Delay)))
 	}
-	if cfg.Config.Output != "" {
-		args = append(args, "--output", cfg.Config.Output)
+	// 默认使用JSON输出以便稳定解析，表格列变化不影响结果；显式指定的格式保持不变
+	output := cfg.Config.Output
+	if output == "" {
+		output = "json"
 	}
+	args = append(args, "--output", output)
 	if cfg.Config.OutputErr != "" {
 		args = append(args, "--output-err", cfg.Config.OutputErr)
 	}
@@ -147,108 +229,226 @@ func (s *BenchmarkService) StartBenchmark(cfg *model.BenchmarkConfig) (string, e
 	if cfg.Config.Progress > 0 {
 		args = append(args, "--progress")
 	}
+	return args
+}
 
-	// 打印启动命令
-	cmdStr := fmt.Sprintf("%s %s", s.config.LLamaPath.Bench, strings.Join(args, " "))
-	log.Printf("Starting benchmark with command:\n%s\n", cmdStr)
-
-	// 创建命令
-	cmd := exec.Command(s.config.LLamaPath.Bench, args...)
+// run 将llama-bench任务加入队列并保存可复现清单，使用的GPU空闲时在后台执行，结束后写入历史记录
+// cfg为请求的测试配置，复现任务时为nil
+func (s *BenchmarkService) run(cfg *model.BenchmarkConfig, args []string, manifest *model.BenchmarkManifest) (string, error) {
+	// 排队的任务在后台启动，提前检查llama-bench是否存在以便立即返回错误
+	if _, err := exec.LookPath(s.config.Snapshot().LLamaPath.Bench); err != nil {
+		return "", apierror.New(apierror.CodeBinaryMissing, "failed to start benchmark: %v", err)
+	}
 
-	// 创建输出缓冲区
-	var stdoutBuf, stderrBuf bytes.Buffer
+	job := &benchmarkJob{
+		cfg:       cfg,
+		args:      args,
+		manifest:  manifest,
+		gpus:      manifest.VisibleGPUs,
+		exclusive: ExclusiveNone,
+	}
+	if cfg != nil && cfg.Exclusive != "" {
+		job.exclusive = cfg.Exclusive
+	}
+	// 复现任务由用户显式请求，直接排队
+	job.force = cfg == nil || cfg.Force
+	return s.enqueue(job)
+}
 
-	// 设置命令输出
-	cmd.Stdout = &stdoutBuf
-	cmd.Stderr = &stderrBuf
+// enqueue 为任务分配ID并加入队列，保存可复现清单后调度执行；
+// 未指定force时相同GPU上已有排队或运行中的任务返回BenchmarkConflictError
+func (s *BenchmarkService) enqueue(job *benchmarkJob) (string, error) {
+	manifest := job.manifest
+	s.mu.Lock()
+	if !job.force {
+		if err := s.conflict(job); err != nil {
+			s.mu.Unlock()
+			return "", err
+		}
+	}
+	// 生成任务ID
+	job.taskID = uuid.New().String()
+	manifest.TaskID = job.taskID
+	manifest.CreatedAt = time.Now().Format(time.RFC3339)
+	s.pruneTasks(time.Now())
 
 	// 创建任务状态
-	status := &model.BenchmarkStatus{
-		TaskID:    taskID,
-		Status:    "running",
+	s.tasks[job.taskID] = &model.BenchmarkStatus{
+		TaskID:    job.taskID,
+		Status:    "queued",
 		Progress:  0,
 		StartTime: time.Now().Format(time.RFC3339),
+		Manifest:  manifest,
+	}
+	s.queue = append(s.queue, job)
+	s.updateQueuePositions()
+	s.mu.Unlock()
+
+	s.saveManifest(manifest, nil)
+	s.schedule()
+	return job.taskID, nil
+}
+
+// execute 按独占方式腾出GPU后运行llama-bench（或服务基准测试）并收集结果，结束后恢复被停止或暂停的模型并调度后续任务
+func (s *BenchmarkService) execute(job *benchmarkJob) {
+	defer func() {
+		s.restore(job)
+		s.mu.Lock()
+		delete(s.active, job.taskID)
+		s.mu.Unlock()
+		s.schedule()
+		s.running.Done()
+	}()
+
+	displaced := s.displace(job)
+
+	// 在测试实际开始时重新采集环境，排队期间驱动或llama.cpp构建可能已经变化
+	env := s.snapshotEnvironment()
+	s.mu.Lock()
+	job.manifest.BenchmarkEnvironment = env
+	s.mu.Unlock()
+
+	if job.serving != nil {
+		s.executeServing(job, displaced)
+		return
+	}
+
+	// 打印启动命令
+	binary := s.config.Snapshot().LLamaPath.Bench
+	cmdStr := fmt.Sprintf("%s %s", binary, strings.Join(job.args, " "))
+	slog.Info("Starting benchmark", "command", cmdStr)
+
+	// 创建命令
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, binary, job.args...)
+	if env := visibleGPUEnv(s.gpu.Vendor(), job.gpus); len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
 	}
-	s.tasks[taskID] = status
+
+	// 内存中只保留有限的输出，完整输出写入任务的输出文件
+	stdout := s.newBenchmarkOutput(job.taskID, OutputStdout)
+	stderr := s.newBenchmarkOutput(job.taskID, OutputStderr)
+	defer stdout.close()
+	defer stderr.close()
+	cmd.Stdout = stdout.writer()
+	cmd.Stderr = stderr.writer()
+
+	s.mu.Lock()
+	status, exists := s.tasks[job.taskID]
+	if !exists {
+		s.mu.Unlock()
+		return
+	}
+	if status.Status != "running" {
+		// 腾出GPU期间任务已被取消
+		status.DisplacedModels = displaced
+		s.finish(job, benchmarkRecord(status, job.cfg, job.manifest, 0, "", "cancelled"))
+		s.mu.Unlock()
+		return
+	}
+	status.DisplacedModels = displaced
+	status.CancelFunc = cancel
 
 	// 启动命令
 	if err := cmd.Start(); err != nil {
-		delete(s.tasks, taskID)
-		return "", fmt.Errorf("failed to start benchmark: %v", err)
+		status.Status = "failed"
+		status.EndTime = time.Now().Format(time.RFC3339)
+		failure := fmt.Sprintf("failed to start benchmark: %v", err)
+		slog.Error("Failed to start benchmark", "task_id", job.taskID, "error", err)
+		s.finish(job, benchmarkRecord(status, job.cfg, job.manifest, 0, "", failure))
+		s.mu.Unlock()
+		return
 	}
+	s.mu.Unlock()
+	stopThermal := s.watchThermal()
+	started := time.Now()
 
-	// 在goroutine中处理命令执行和结果收集
-	go func() {
-		// 等待命令完成
-		err := cmd.Wait()
+	// 等待命令完成
+	err := cmd.Wait()
+	thermal := stopThermal()
 
-		s.mu.Lock()
-		defer s.mu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		status, exists := s.tasks[taskID]
-		if !exists {
-			return
-		}
+	status.Thermal = thermal
+	job.manifest.Thermal = thermal
 
-		if err != nil {
-			status.Status = "failed"
-			status.EndTime = time.Now().Format(time.RFC3339)
-			log.Printf("Benchmark failed: %v, stderr: %s", err, stderrBuf.String())
-			return
-		}
+	// 任务结束（成功、失败或取消）后写入历史记录
+	var failure string
+	defer func() {
+		s.finish(job, benchmarkRecord(status, job.cfg, job.manifest, time.Since(started), stdout.buffer.String(), failure))
+	}()
 
-		// 处理成功结果
-		fullOutput := stdoutBuf.String()
-		log.Printf("=== Raw benchmark output ===\n%s\n========================", fullOutput)
+	if status.Status == "cancelled" {
+		failure = "cancelled"
+		return
+	}
 
-		// 解析结果
-		result, err := ParseBenchmarkOutput(fullOutput)
-		if err != nil {
-			status.Status = "failed"
-			log.Printf("Failed to parse benchmark output: %v", err)
-			status.EndTime = time.Now().Format(time.RFC3339)
-			return
-		}
+	if err != nil {
+		status.Status = "failed"
+		status.EndTime = time.Now().Format(time.RFC3339)
+		failure = fmt.Sprintf("%v: %s", err, strings.TrimSpace(stderr.buffer.String()))
+		slog.Error("Benchmark failed", "error", err, "stderr", stderr.buffer.String())
+		return
+	}
 
-		if len(result.Tests) == 0 {
-			status.Status = "failed"
-			log.Printf("No test results found in benchmark output")
-			status.EndTime = time.Now().Format(time.RFC3339)
-			return
-		}
+	// 处理成功结果，从输出文件读取完整输出解析，避免内存中的输出被截断
+	fullOutput := stdout.full()
+	slog.Debug("Raw benchmark output", "output", stdout.buffer.String())
 
-		// 保存解析结果
-		var allResults []*model.BenchmarkResults
-		for _, testResult := range result.Tests {
-			benchmarkResult := &model.BenchmarkResults{
-				Model:           testResult.Model,
-				Size:            testResult.Size,
-				Params:          testResult.Params,
-				Backend:         testResult.Backend,
-				GPULayers:       testResult.GPULayers,
-				MMap:            testResult.MMap,
-				TestType:        testResult.TestType,
-				TokensPerSecond: testResult.TokensPerSecond,
-				Variation:       testResult.Variation,
-				TotalTokens:     calculateTotalTokens(testResult.TestType),
-				TotalTime:       calculateTotalTime(testResult.TestType, testResult.TokensPerSecond),
-				MemoryUsed:      0, // 可根据实际情况填充
-			}
-			allResults = append(allResults, benchmarkResult)
-		}
+	// 解析结果
+	result, err := ParseBenchmarkOutput(fullOutput)
+	if err != nil {
+		status.Status = "failed"
+		failure = fmt.Sprintf("failed to parse benchmark output: %v", err)
+		slog.Error("Failed to parse benchmark output", "error", err)
+		status.EndTime = time.Now().Format(time.RFC3339)
+		return
+	}
 
-		// 设置所有测试结果
-		status.AllResults = allResults
-		status.Status = "completed"
-		if len(status.AllResults) > 0 {
-			log.Printf("Benchmark completed with results: %+v", status.AllResults)
-		} else {
-			log.Printf("Benchmark completed but no results available")
-		}
+	if len(result.Tests) == 0 {
+		status.Status = "failed"
+		failure = "no test results found in benchmark output"
+		slog.Warn("No test results found in benchmark output")
 		status.EndTime = time.Now().Format(time.RFC3339)
-	}()
+		return
+	}
+
+	// 保存解析结果
+	var allResults []*model.BenchmarkResults
+	for _, testResult := range result.Tests {
+		benchmarkResult := &model.BenchmarkResults{
+			Model:           testResult.Model,
+			Size:            testResult.Size,
+			Params:          testResult.Params,
+			Backend:         testResult.Backend,
+			GPULayers:       testResult.GPULayers,
+			MMap:            testResult.MMap,
+			TestType:        testResult.TestType,
+			TokensPerSecond: testResult.TokensPerSecond,
+			Variation:       testResult.Variation,
+			TotalTokens:     calculateTotalTokens(testResult.TestType),
+			TotalTime:       calculateTotalTime(testResult.TestType, testResult.TokensPerSecond),
+			MemoryUsed:      0, // 可根据实际情况填充
+		}
+		allResults = append(allResults, benchmarkResult)
+	}
 
-	return taskID, nil
+	// 设置所有测试结果
+	status.AllResults = allResults
+	status.Status = "completed"
+	status.Baseline = s.baselines.Compare(job.manifest.ModelPath, job.taskID, allResults, float64(s.config.Benchmark.RegressionThreshold))
+	if status.Baseline != nil && status.Baseline.Regressed {
+		s.recordBaselineRegression(job, status.Baseline)
+	}
+	s.saveManifest(job.manifest, allResults)
+	if len(status.AllResults) > 0 {
+		slog.Info("Benchmark completed", "results", status.AllResults)
+	} else {
+		slog.Warn("Benchmark completed but no results available")
+	}
+	status.EndTime = time.Now().Format(time.RFC3339)
 }
 
 // GetStatus 获取基准测试状态
@@ -257,11 +457,67 @@ func (s *BenchmarkService) GetStatus(taskID string) (*model.BenchmarkStatus, err
 	defer s.mu.RUnlock()
 
 	status, exists := s.tasks[taskID]
-	if !exists {
-		return nil, fmt.Errorf("task not found: %s", taskID)
+	if exists {
+		return status, nil
+	}
+
+	// 重启前结束的任务从历史记录中读取
+	record, err := s.history.Find(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, apierror.New(apierror.CodeBenchmarkNotFound, "task not found: %s", taskID)
+	}
+	return &model.BenchmarkStatus{
+		TaskID:     record.TaskID,
+		Status:     record.Status,
+		StartTime:  record.StartTime,
+		EndTime:    record.EndTime,
+		AllResults: record.Results,
+		Thermal:    record.Thermal,
+		Baseline:   record.Baseline,
+	}, nil
+}
+
+// Tasks 获取内存中的全部测试任务（排队、运行中和尚未清理的已结束任务）状态的副本，按开始时间从新到旧排列
+func (s *BenchmarkService) Tasks() []*model.BenchmarkStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := make([]*model.BenchmarkStatus, 0, len(s.tasks))
+	for _, status := range s.tasks {
+		task := *status
+		tasks = append(tasks, &task)
 	}
+	slices.SortFunc(tasks, func(a, b *model.BenchmarkStatus) int {
+		return cmp.Or(strings.Compare(b.StartTime, a.StartTime), strings.Compare(a.TaskID, b.TaskID))
+	})
+	return tasks
+}
 
-	return status, nil
+// waitTask 等待测试任务结束，返回结束时状态的副本
+func (s *BenchmarkService) waitTask(ctx context.Context, taskID string) (*model.BenchmarkStatus, error) {
+	ticker := time.NewTicker(benchmarkPollInterval)
+	defer ticker.Stop()
+	for {
+		status, err := s.GetStatus(taskID)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.RLock()
+		result := *status
+		s.mu.RUnlock()
+		switch result.Status {
+		case "completed", "failed", "cancelled":
+			return &result, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
 }
 
 // handleBenchmarkOutput 处理基准测试输出
@@ -322,6 +578,15 @@ func (s *BenchmarkService) ValidateBenchmarkConfig(cfg *model.BenchmarkConfig) e
 	if cfg.ModelPath == "" {
 		return fmt.Errorf("model path is required")
 	}
+	if err := validateGPUSelection(cfg.GPUs, cfg.Exclusive); err != nil {
+		return err
+	}
+	if err := validateWebhookURLs(cfg.WebhookURLs); err != nil {
+		return err
+	}
+	if err := validatePreset(cfg.Preset); err != nil {
+		return err
+	}
 
 	c := cfg.Config
 
@@ -397,6 +662,19 @@ func (s *BenchmarkService) ValidateBenchmarkConfig(cfg *model.BenchmarkConfig) e
 		return fmt.Errorf("invalid delay value: %d (should be >= 0)", c.Delay)
 	}
 
+	// 验证RPC配置
+	if cfg.RPCPool != "" {
+		if c.RPC != "" {
+			return fmt.Errorf("rpc and rpc_pool cannot both be set")
+		}
+		if s.models == nil || !s.models.rpc.has(cfg.RPCPool) {
+			return fmt.Errorf("unknown RPC pool: %s", cfg.RPCPool)
+		}
+	}
+	if _, err := parseRPCWorkers(c.RPC); err != nil {
+		return err
+	}
+
 	// 验证PG参数格式
 	if c.PG != "" {
 		pgParts := strings.Split(c.PG, ",")
@@ -404,8 +682,8 @@ func (s *BenchmarkService) ValidateBenchmarkConfig(cfg *model.BenchmarkConfig) e
 			return fmt.Errorf("invalid pg format: %s (should be 'pp,tg')", c.PG)
 		}
 		for _, part := range pgParts {
-			if _, err := strconv.Atoi(part); err != nil {
-				return fmt.Errorf("invalid pg value: %s (should be integers)", c.PG)
+			if n, err := strconv.Atoi(strings.TrimSpace(part)); err != nil || n <= 0 {
+				return fmt.Errorf("invalid pg value: %s (should be positive integers)", c.PG)
 			}
 		}
 	}
@@ -414,7 +692,8 @@ func (s *BenchmarkService) ValidateBenchmarkConfig(cfg *model.BenchmarkConfig) e
 	if c.OverrideTensors != "" {
 		tensors := strings.Split(c.OverrideTensors, ";")
 		for _, tensor := range tensors {
-			if !strings.Contains(tensor, "=") {
+			pattern, bufferType, found := strings.Cut(tensor, "=")
+			if !found || strings.TrimSpace(pattern) == "" || strings.TrimSpace(bufferType) == "" {
 				return fmt.Errorf("invalid override tensors format: %s (should be '<tensor name pattern>=<buffer type>;...')", c.OverrideTensors)
 			}
 		}
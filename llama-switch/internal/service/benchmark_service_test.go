@@ -2,8 +2,12 @@ package service
 
 import (
 	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
+	"llama-switch/internal/config"
 	"llama-switch/internal/model"
 )
 
@@ -90,3 +94,89 @@ func TestBenchmarkOutputJSON(t *testing.T) {
 		t.Errorf("Expected 2 results in all_results, got %d", len(allResults))
 	}
 }
+
+func TestBenchmarkRequestDecoding(t *testing.T) {
+	body := `{
+		"model_path": "qwen2-32b.gguf",
+		"gpus": [0, 1],
+		"config": {
+			"n_prompt": 512,
+			"n_gen": 128,
+			"pg": "512,128",
+			"batch_size": 2048,
+			"cache_type_k": "f16",
+			"threads": 8,
+			"n_gpu_layers": 99,
+			"split_mode": "layer",
+			"flash_attn": 1,
+			"override_tensors": "blk\\.[0-9]+\\.ffn_.*_exps=CPU;token_embd=CPU",
+			"repetitions": 3
+		}
+	}`
+
+	var cfg model.BenchmarkConfig
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+	if cfg.Config.PG != "512,128" || cfg.Config.OverrideTensors != `blk\.[0-9]+\.ffn_.*_exps=CPU;token_embd=CPU` {
+		t.Fatalf("unexpected decoded config: %+v", cfg.Config)
+	}
+
+	s := &BenchmarkService{}
+	if err := s.ValidateBenchmarkConfig(&cfg); err != nil {
+		t.Fatalf("valid request rejected: %v", err)
+	}
+
+	args := benchmarkArgs(cfg.ModelPath, &cfg)
+	for _, pair := range [][2]string{
+		{"--pg", "512,128"},
+		{"--override-tensors", cfg.Config.OverrideTensors},
+		{"--output", "json"},
+	} {
+		i := slices.Index(args, pair[0])
+		if i < 0 || i+1 >= len(args) || args[i+1] != pair[1] {
+			t.Errorf("args missing %s %s: %v", pair[0], pair[1], args)
+		}
+	}
+
+	tests := []struct {
+		name            string
+		pg              string
+		overrideTensors string
+	}{
+		{"pg single value", "512", ""},
+		{"pg not integer", "512,abc", ""},
+		{"pg zero", "0,128", ""},
+		{"override without buffer type", "", "token_embd="},
+		{"override without pattern", "", "=CPU"},
+		{"override missing separator", "", "token_embd=CPU;output"},
+	}
+	for _, tt := range tests {
+		invalid := cfg
+		invalid.Config.PG = tt.pg
+		invalid.Config.OverrideTensors = tt.overrideTensors
+		if err := s.ValidateBenchmarkConfig(&invalid); err == nil {
+			t.Errorf("%s: ValidateBenchmarkConfig accepted pg=%q override_tensors=%q", tt.name, tt.pg, tt.overrideTensors)
+		}
+	}
+}
+
+func TestBenchmarkTasks(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Benchmark.HistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	s := NewBenchmarkService(cfg, nil)
+	s.tasks["old"] = &model.BenchmarkStatus{TaskID: "old", Status: "completed", StartTime: "2024-05-01T10:00:00Z"}
+	s.tasks["new"] = &model.BenchmarkStatus{TaskID: "new", Status: "queued", StartTime: "2024-05-02T10:00:00Z"}
+
+	// 按开始时间从新到旧排列，返回副本
+	tasks := s.Tasks()
+	if len(tasks) != 2 || tasks[0].TaskID != "new" || tasks[1].TaskID != "old" {
+		t.Fatalf("Tasks() = %+v", tasks)
+	}
+	tasks[0].Status = "running"
+	if s.tasks["new"].Status != "queued" {
+		t.Error("Tasks() returned the live task status")
+	}
+}
@@ -0,0 +1,493 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+)
+
+const (
+	defaultServingMaxTokens = 128     // 每个请求默认生成的token数
+	servingRequestsPerSlot  = 4       // 未指定请求数时每个并发请求发送的请求数
+	maxServingConcurrency   = 256     // 并发请求数上限
+	maxServingErrors        = 5       // 每组测试保留的失败原因数
+	servingOutputLines      = 20      // 启动失败时报告的llama-server输出行数
+	servingStartupTimeout   = 300     // MODEL_STARTUP_TIMEOUT为0时等待llama-server就绪的时间（秒）
+	servingMaxLine          = 1 << 20 // 流式响应单行的最大长度
+
+	// servingPromptText 重复后分词作为测试提示，截取到所需的token数
+	servingPromptText = "The quick brown fox jumps over the lazy dog. "
+)
+
+var (
+	defaultServingConcurrency  = []int{1, 4}
+	defaultServingPromptTokens = []int{512}
+)
+
+// servingClient 发送服务基准测试请求的HTTP客户端，流式请求的时长由任务上下文控制
+var servingClient = &http.Client{}
+
+// ValidateServingConfig 验证服务基准测试配置并填充默认值
+func (s *BenchmarkService) ValidateServingConfig(cfg *model.ServingBenchmarkConfig) error {
+	if cfg.ModelPath == "" {
+		return fmt.Errorf("model path is required")
+	}
+	if err := validateGPUSelection(cfg.GPUs, cfg.Exclusive); err != nil {
+		return err
+	}
+	if err := validateWebhookURLs(cfg.WebhookURLs); err != nil {
+		return err
+	}
+
+	if len(cfg.Concurrency) == 0 {
+		cfg.Concurrency = defaultServingConcurrency
+	}
+	for _, c := range cfg.Concurrency {
+		if c <= 0 || c > maxServingConcurrency {
+			return fmt.Errorf("invalid concurrency value: %d (expected 1-%d)", c, maxServingConcurrency)
+		}
+	}
+	if len(cfg.PromptTokens) == 0 {
+		cfg.PromptTokens = defaultServingPromptTokens
+	}
+	for _, n := range cfg.PromptTokens {
+		if n <= 0 {
+			return fmt.Errorf("invalid prompt tokens value: %d", n)
+		}
+	}
+	if cfg.MaxTokens < 0 {
+		return fmt.Errorf("invalid max tokens value: %d", cfg.MaxTokens)
+	}
+	if cfg.MaxTokens == 0 {
+		cfg.MaxTokens = defaultServingMaxTokens
+	}
+	if cfg.Requests < 0 {
+		return fmt.Errorf("invalid requests value: %d", cfg.Requests)
+	}
+	return nil
+}
+
+// StartServingBenchmark 启动端到端服务基准测试，与llama-bench测试共用队列、状态查询和历史记录
+func (s *BenchmarkService) StartServingBenchmark(cfg *model.ServingBenchmarkConfig) (string, error) {
+	serving := *cfg
+	if !filepath.IsAbs(serving.ModelPath) {
+		serving.ModelPath = filepath.Join(s.config.Snapshot().ModelsDir, serving.ModelPath)
+	}
+
+	manifest := s.collectManifest(serving.ModelPath, nil)
+	return s.runServing(&serving, manifest)
+}
+
+// runServing 将服务基准测试加入队列，llama-server的命令行参数在执行时确定端口后写入清单
+func (s *BenchmarkService) runServing(cfg *model.ServingBenchmarkConfig, manifest *model.BenchmarkManifest) (string, error) {
+	binary := s.config.Snapshot().LLamaPath.Server
+	if _, err := exec.LookPath(binary); err != nil {
+		return "", apierror.New(apierror.CodeBinaryMissing, "failed to start serving benchmark: %v", err)
+	}
+
+	manifest.Binary = binary
+	manifest.VisibleGPUs = cfg.GPUs
+	manifest.Serving = cfg
+	job := &benchmarkJob{
+		manifest:  manifest,
+		gpus:      cfg.GPUs,
+		exclusive: ExclusiveNone,
+		serving:   cfg,
+	}
+	if cfg.Exclusive != "" {
+		job.exclusive = cfg.Exclusive
+	}
+	job.force = cfg.Force
+	return s.enqueue(job)
+}
+
+// executeServing 启动llama-server并依次测试各组提示长度和并发数，每组结束后更新任务进度和结果
+func (s *BenchmarkService) executeServing(job *benchmarkJob, displaced []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s.mu.Lock()
+	status, exists := s.tasks[job.taskID]
+	if !exists {
+		s.mu.Unlock()
+		return
+	}
+	status.DisplacedModels = displaced
+	if status.Status != "running" {
+		// 腾出GPU期间任务已被取消
+		s.finish(job, benchmarkRecord(status, nil, job.manifest, 0, "", "cancelled"))
+		s.mu.Unlock()
+		return
+	}
+	status.CancelFunc = cancel
+	s.mu.Unlock()
+
+	stopThermal := s.watchThermal()
+	started := time.Now()
+	total := len(job.serving.Concurrency) * len(job.serving.PromptTokens)
+	err := s.serve(ctx, job, func(level *model.ServingBenchmarkLevel) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		status.Serving = append(status.Serving, level)
+		status.Progress = float64(len(status.Serving)) * 100 / float64(total)
+	})
+	thermal := stopThermal()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status.Thermal = thermal
+	job.manifest.Thermal = thermal
+	s.saveManifest(job.manifest, nil)
+
+	var failure string
+	defer func() {
+		s.finish(job, benchmarkRecord(status, nil, job.manifest, time.Since(started), "", failure))
+	}()
+
+	if status.Status == "cancelled" {
+		failure = "cancelled"
+		return
+	}
+	status.EndTime = time.Now().Format(time.RFC3339)
+	if err != nil {
+		status.Status = "failed"
+		failure = err.Error()
+		slog.Error("Serving benchmark failed", "task_id", job.taskID, "error", err)
+		return
+	}
+	status.Status = "completed"
+	slog.Info("Serving benchmark completed", "task_id", job.taskID, "result_groups", len(status.Serving))
+}
+
+// serve 在空闲端口上启动llama-server，等待就绪后按提示长度和并发数依次施加负载，结束时停止llama-server
+func (s *BenchmarkService) serve(ctx context.Context, job *benchmarkJob, report func(*model.ServingBenchmarkLevel)) error {
+	cfg := job.serving
+	port, err := freeLocalPort()
+	if err != nil {
+		return err
+	}
+
+	// 默认每个并发请求一个槽位，上下文足够容纳最长的提示和生成长度
+	server := cfg.Server
+	server.Config.Host = defaultBackendHost
+	if server.Config.Parallel <= 0 {
+		server.Config.Parallel = slices.Max(cfg.Concurrency)
+	}
+	if server.Config.CtxSize <= 0 {
+		server.Config.CtxSize = server.Config.Parallel * (slices.Max(cfg.PromptTokens) + cfg.MaxTokens)
+	}
+	args := buildServerArgs(&server, cfg.ModelPath, port)
+	s.mu.Lock()
+	job.manifest.Args = args
+	s.mu.Unlock()
+	binary := s.config.Snapshot().LLamaPath.Server
+	slog.Info("Starting serving benchmark", "command", binary+" "+strings.Join(args, " "))
+
+	serverCtx, stop := context.WithCancel(ctx)
+	cmd := exec.CommandContext(serverCtx, binary, args...)
+	if env := visibleGPUEnv(s.gpu.Vendor(), job.gpus); len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	tail := newOutputTail(servingOutputLines)
+	cmd.Stdout = tail
+	cmd.Stderr = tail
+	if err := cmd.Start(); err != nil {
+		stop()
+		return fmt.Errorf("failed to start llama-server: %v", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	defer func() {
+		stop()
+		<-exited
+	}()
+
+	baseURL := "http://" + net.JoinHostPort(defaultBackendHost, strconv.Itoa(port))
+	timeout := time.Duration(s.config.Startup.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = servingStartupTimeout * time.Second
+	}
+	if err := waitServingReady(ctx, baseURL, exited, timeout, tail); err != nil {
+		return err
+	}
+
+	for _, promptTokens := range cfg.PromptTokens {
+		prompt, err := servingPrompt(ctx, baseURL, promptTokens)
+		if err != nil {
+			return err
+		}
+		for _, concurrency := range cfg.Concurrency {
+			requests := cfg.Requests
+			if requests == 0 {
+				requests = concurrency * servingRequestsPerSlot
+			}
+			level := runServingLevel(ctx, baseURL, prompt, concurrency, requests, cfg.MaxTokens)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			slog.Info("Serving benchmark level finished", "task_id", job.taskID, "prompt_tokens", promptTokens, "concurrency", concurrency,
+				"tokens_per_second", level.OutputTokensPerSecond, "ttft_p50_ms", level.TTFT.P50, "itl_p50_ms", level.ITL.P50, "failed", level.Failed, "requests", level.Requests)
+			report(level)
+			if level.Failed == level.Requests {
+				return fmt.Errorf("all %d requests failed: %s", level.Requests, strings.Join(level.Errors, "; "))
+			}
+		}
+	}
+	return nil
+}
+
+// freeLocalPort 由系统分配一个本地空闲端口
+func freeLocalPort() (int, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(defaultBackendHost, "0"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate port: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// waitServingReady 等待llama-server的/health返回200，进程提前退出或超时返回错误
+func waitServingReady(ctx context.Context, baseURL string, exited <-chan error, timeout time.Duration, tail *outputTail) error {
+	deadline := time.After(timeout)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-exited:
+			return fmt.Errorf("llama-server exited during startup: %v: %s", err, strings.Join(tail.Lines(), "\n"))
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return fmt.Errorf("llama-server not ready after %v", timeout)
+		case <-ticker.C:
+			if probeReady(baseURL + "/health") {
+				return nil
+			}
+		}
+	}
+}
+
+// servingPrompt 通过/tokenize将重复的测试文本分词，返回恰好n个token的提示
+func servingPrompt(ctx context.Context, baseURL string, n int) ([]int, error) {
+	repeat := n/8 + 1
+	for {
+		body, _ := json.Marshal(map[string]interface{}{
+			"content":     strings.Repeat(servingPromptText, repeat),
+			"add_special": false,
+		})
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/tokenize", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := servingClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to tokenize prompt: %v", err)
+		}
+		var result struct {
+			Tokens []int `json:"tokens"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tokenize response: %v", err)
+		}
+		if len(result.Tokens) == 0 {
+			return nil, fmt.Errorf("tokenize returned no tokens")
+		}
+		if len(result.Tokens) >= n {
+			return result.Tokens[:n], nil
+		}
+		repeat *= 2
+	}
+}
+
+// servingSample 单个请求的测量结果
+type servingSample struct {
+	ttft    time.Duration
+	itl     []time.Duration
+	latency time.Duration
+	tokens  int
+	err     error
+}
+
+// runServingLevel 以concurrency个并发请求发送共requests个流式补全请求，汇总吞吐量和延迟分布
+func runServingLevel(ctx context.Context, baseURL string, prompt []int, concurrency, requests, maxTokens int) *model.ServingBenchmarkLevel {
+	samples := make([]servingSample, requests)
+	indexes := make(chan int, requests)
+	for i := range samples {
+		indexes <- i
+	}
+	close(indexes)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range min(concurrency, requests) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					samples[i].err = ctx.Err()
+					continue
+				}
+				samples[i] = streamCompletion(ctx, baseURL, prompt, maxTokens)
+			}
+		}()
+	}
+	wg.Wait()
+	duration := time.Since(start)
+
+	level := &model.ServingBenchmarkLevel{
+		Concurrency:  concurrency,
+		PromptTokens: len(prompt),
+		Requests:     requests,
+		DurationSec:  duration.Seconds(),
+	}
+	var ttft, itl, latency []time.Duration
+	for _, sample := range samples {
+		if sample.err != nil {
+			level.Failed++
+			if msg := sample.err.Error(); len(level.Errors) < maxServingErrors && !slices.Contains(level.Errors, msg) {
+				level.Errors = append(level.Errors, msg)
+			}
+			continue
+		}
+		level.OutputTokens += sample.tokens
+		ttft = append(ttft, sample.ttft)
+		itl = append(itl, sample.itl...)
+		latency = append(latency, sample.latency)
+	}
+	if duration > 0 {
+		level.OutputTokensPerSecond = float64(level.OutputTokens) / duration.Seconds()
+		level.RequestsPerSecond = float64(requests-level.Failed) / duration.Seconds()
+	}
+	level.TTFT = latencyStats(ttft)
+	level.ITL = latencyStats(itl)
+	level.Latency = latencyStats(latency)
+	return level
+}
+
+// streamCompletion 向llama-server的/completion发送流式请求，记录首token延迟和token间延迟
+func streamCompletion(ctx context.Context, baseURL string, prompt []int, maxTokens int) servingSample {
+	body, _ := json.Marshal(map[string]interface{}{
+		"prompt":       prompt,
+		"n_predict":    maxTokens,
+		"stream":       true,
+		"cache_prompt": false,
+		"ignore_eos":   true,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/completion", bytes.NewReader(body))
+	if err != nil {
+		return servingSample{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := servingClient.Do(req)
+	if err != nil {
+		return servingSample{err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return servingSample{err: fmt.Errorf("completion returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))}
+	}
+
+	var sample servingSample
+	var last time.Time
+	chunks, predicted := 0, 0
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), servingMaxLine)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var chunk struct {
+			Content string          `json:"content"`
+			Stop    bool            `json:"stop"`
+			Error   json.RawMessage `json:"error"`
+			Timings struct {
+				PredictedN int `json:"predicted_n"`
+			} `json:"timings"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return servingSample{err: fmt.Errorf("invalid stream chunk: %v", err)}
+		}
+		if len(chunk.Error) > 0 {
+			return servingSample{err: fmt.Errorf("completion error: %s", chunk.Error)}
+		}
+		now := time.Now()
+		if chunk.Content != "" {
+			if chunks == 0 {
+				sample.ttft = now.Sub(start)
+			} else {
+				sample.itl = append(sample.itl, now.Sub(last))
+			}
+			last = now
+			chunks++
+		}
+		if chunk.Stop {
+			predicted = chunk.Timings.PredictedN
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return servingSample{err: err}
+	}
+	if chunks == 0 {
+		return servingSample{err: fmt.Errorf("completion produced no tokens")}
+	}
+	sample.latency = time.Since(start)
+	// 最后一个块的timings包含准确的生成token数，缺失时按内容块计数
+	sample.tokens = chunks
+	if predicted > 0 {
+		sample.tokens = predicted
+	}
+	return sample
+}
+
+// latencyStats 计算延迟的平均值和百分位（最近秩法），单位毫秒
+func latencyStats(durations []time.Duration) model.LatencyStats {
+	if len(durations) == 0 {
+		return model.LatencyStats{}
+	}
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+		return ms(sorted[max(rank-1, 0)])
+	}
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return model.LatencyStats{
+		Mean: ms(total) / float64(len(sorted)),
+		P50:  percentile(50),
+		P90:  percentile(90),
+		P99:  percentile(99),
+		Max:  ms(sorted[len(sorted)-1]),
+	}
+}
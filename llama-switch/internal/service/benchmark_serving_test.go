@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+// fakeCompletionServer 模拟llama-server的/tokenize和流式/completion，每个单词一个token
+func fakeCompletionServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tokenize":
+			var req struct {
+				Content string `json:"content"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			tokens := make([]int, len(strings.Fields(req.Content)))
+			json.NewEncoder(w).Encode(map[string]interface{}{"tokens": tokens})
+		case "/completion":
+			var req struct {
+				Prompt   []int `json:"prompt"`
+				NPredict int   `json:"n_predict"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			if len(req.Prompt) > 100 {
+				http.Error(w, `{"error":"prompt too long"}`, http.StatusBadRequest)
+				return
+			}
+			flusher := w.(http.Flusher)
+			for i := 0; i < req.NPredict; i++ {
+				time.Sleep(time.Millisecond)
+				fmt.Fprintf(w, "data: {\"content\":\"x\",\"stop\":false}\n\n")
+				flusher.Flush()
+			}
+			fmt.Fprintf(w, "data: {\"content\":\"\",\"stop\":true,\"timings\":{\"predicted_n\":%d}}\n\n", req.NPredict)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestRunServingLevel(t *testing.T) {
+	server := fakeCompletionServer(t)
+	defer server.Close()
+	ctx := context.Background()
+
+	prompt, err := servingPrompt(ctx, server.URL, 50)
+	if err != nil || len(prompt) != 50 {
+		t.Fatalf("servingPrompt = %d tokens, %v, want 50", len(prompt), err)
+	}
+
+	level := runServingLevel(ctx, server.URL, prompt, 3, 6, 5)
+	if level.Requests != 6 || level.Failed != 0 || level.OutputTokens != 30 || level.PromptTokens != 50 {
+		t.Fatalf("level = %+v", level)
+	}
+	if level.TTFT.P50 <= 0 || level.ITL.P50 <= 0 || level.Latency.Max < level.TTFT.Max || level.OutputTokensPerSecond <= 0 {
+		t.Errorf("latency stats = ttft %+v itl %+v latency %+v, %.1f tokens/s", level.TTFT, level.ITL, level.Latency, level.OutputTokensPerSecond)
+	}
+
+	level = runServingLevel(ctx, server.URL, make([]int, 200), 2, 2, 5)
+	if level.Failed != 2 || len(level.Errors) != 1 || !strings.Contains(level.Errors[0], "400") {
+		t.Errorf("failing level = %+v", level)
+	}
+}
+
+func TestLatencyStats(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	stats := latencyStats(durations)
+	want := model.LatencyStats{Mean: 50.5, P50: 50, P90: 90, P99: 99, Max: 100}
+	if stats != want {
+		t.Errorf("latencyStats = %+v, want %+v", stats, want)
+	}
+	if stats := latencyStats(nil); stats != (model.LatencyStats{}) {
+		t.Errorf("latencyStats(nil) = %+v, want zero", stats)
+	}
+}
+
+func TestValidateServingConfig(t *testing.T) {
+	s := NewBenchmarkService(&config.Config{}, nil)
+
+	cfg := &model.ServingBenchmarkConfig{ModelPath: "m.gguf"}
+	if err := s.ValidateServingConfig(cfg); err != nil {
+		t.Fatalf("ValidateServingConfig failed: %v", err)
+	}
+	if !slices.Equal(cfg.Concurrency, []int{1, 4}) || !slices.Equal(cfg.PromptTokens, []int{512}) || cfg.MaxTokens != 128 {
+		t.Errorf("defaults = %+v", cfg)
+	}
+
+	for _, invalid := range []*model.ServingBenchmarkConfig{
+		{},
+		{ModelPath: "m.gguf", Concurrency: []int{0}},
+		{ModelPath: "m.gguf", Concurrency: []int{1000}},
+		{ModelPath: "m.gguf", PromptTokens: []int{-1}},
+		{ModelPath: "m.gguf", MaxTokens: -1},
+		{ModelPath: "m.gguf", Requests: -1},
+		{ModelPath: "m.gguf", Exclusive: "always"},
+	} {
+		if err := s.ValidateServingConfig(invalid); err == nil {
+			t.Errorf("ValidateServingConfig(%+v) succeeded, want error", invalid)
+		}
+	}
+}
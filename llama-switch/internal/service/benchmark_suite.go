@@ -0,0 +1,239 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// 标准套件的测试参数
+const (
+	suitePromptTokens = 512 // pp512
+	suiteGenTokens    = 128 // tg128
+)
+
+// 排行榜排序方式
+const (
+	LeaderboardSortTGPerGB = "tg_per_gb" // 每GB生成吞吐量（默认）
+	LeaderboardSortPPPerGB = "pp_per_gb" // 每GB提示处理吞吐量
+	LeaderboardSortTG      = "tg"        // 生成吞吐量
+	LeaderboardSortPP      = "pp"        // 提示处理吞吐量
+)
+
+// ValidateLeaderboardSort 验证排行榜排序方式，为空表示默认排序
+func ValidateLeaderboardSort(sortBy string) error {
+	switch sortBy {
+	case "", LeaderboardSortTGPerGB, LeaderboardSortPPPerGB, LeaderboardSortTG, LeaderboardSortPP:
+		return nil
+	}
+	return fmt.Errorf("invalid sort: %s (expected tg_per_gb, pp_per_gb, tg or pp)", sortBy)
+}
+
+// StartSuite 对模型目录下的所有GGUF模型依次运行标准测试，返回套件ID
+// tmpl为其余测试参数，其中的model_path被忽略；未指定n_prompt、n_gen、pg和preset时使用pp512/tg128
+func (s *BenchmarkService) StartSuite(tmpl *model.BenchmarkConfig) (string, error) {
+	modelsDir := s.config.Snapshot().ModelsDir
+	models, err := listGGUFModels(modelsDir)
+	if err != nil {
+		return "", err
+	}
+	if len(models) == 0 {
+		return "", fmt.Errorf("no GGUF models found in %s", modelsDir)
+	}
+
+	cfg := *tmpl
+	if cfg.Config.NPrompt == 0 && cfg.Config.NGen == 0 && cfg.Config.PG == "" && cfg.Preset == "" {
+		cfg.Config.NPrompt = suitePromptTokens
+		cfg.Config.NGen = suiteGenTokens
+	}
+	cfg.ModelPath = models[0].Path
+	if err := s.ValidateBenchmarkConfig(&cfg); err != nil {
+		return "", err
+	}
+	// 各模型的测试在后台依次提交，提前检查llama-bench是否存在以便立即返回错误
+	if _, err := exec.LookPath(s.config.Snapshot().LLamaPath.Bench); err != nil {
+		return "", apierror.New(apierror.CodeBinaryMissing, "failed to start benchmark: %v", err)
+	}
+
+	suite := &model.BenchmarkSuite{
+		SuiteID:   uuid.New().String(),
+		Status:    "running",
+		StartTime: time.Now().Format(time.RFC3339),
+	}
+	for _, m := range models {
+		suite.Models = append(suite.Models, &model.BenchmarkSuiteModel{
+			Name:      m.Name,
+			ModelPath: m.Path,
+			Size:      m.Size,
+			Status:    "pending",
+		})
+	}
+
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		return "", fmt.Errorf("benchmark service is shutting down")
+	}
+	if !cfg.Force {
+		if err := s.conflict(&benchmarkJob{gpus: cfg.GPUs}); err != nil {
+			s.mu.Unlock()
+			return "", err
+		}
+	}
+	s.suites[suite.SuiteID] = suite
+	s.running.Add(1)
+	s.mu.Unlock()
+
+	slog.Info("Starting benchmark suite", "suite_id", suite.SuiteID, "models", len(suite.Models))
+	go s.runSuite(suite, cfg)
+	return suite.SuiteID, nil
+}
+
+// runSuite 依次测试套件中的模型，前一个模型的测试结束后才提交下一个，服务关闭时停止
+func (s *BenchmarkService) runSuite(suite *model.BenchmarkSuite, tmpl model.BenchmarkConfig) {
+	defer s.running.Done()
+
+	for _, m := range suite.Models {
+		if s.ctx.Err() != nil {
+			break
+		}
+
+		cfg := tmpl
+		cfg.ModelPath = m.ModelPath
+		cfg.Force = true // 套件提交时已检查冲突，之后的模型排在其他测试后执行
+		taskID, err := s.StartBenchmark(&cfg)
+		if err != nil {
+			slog.Error("Failed to start benchmark in suite", "model_name", m.Name, "suite_id", suite.SuiteID, "error", err)
+			s.mu.Lock()
+			m.Status = "failed"
+			m.Error = err.Error()
+			s.mu.Unlock()
+			continue
+		}
+		s.mu.Lock()
+		m.TaskID = taskID
+		m.Status = "queued"
+		s.mu.Unlock()
+
+		result, err := s.waitTask(s.ctx, taskID)
+		if err != nil {
+			break
+		}
+		pp, tg := suiteThroughput(result.AllResults)
+		s.mu.Lock()
+		m.Status = result.Status
+		m.PPTokensPerSecond = pp
+		m.TGTokensPerSecond = tg
+		if result.Status == "completed" && pp == 0 && tg == 0 {
+			m.Error = "no pp or tg results"
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	suite.Status = "completed"
+	if s.ctx.Err() != nil {
+		suite.Status = "cancelled"
+		for _, m := range suite.Models {
+			switch m.Status {
+			case "pending", "queued", "running":
+				m.Status = "cancelled"
+			}
+		}
+	}
+	suite.EndTime = time.Now().Format(time.RFC3339)
+	slog.Info("Benchmark suite finished", "suite_id", suite.SuiteID, "status", suite.Status)
+}
+
+// GetSuite 获取套件状态的副本，已完成的模型按sortBy生成排行榜
+func (s *BenchmarkService) GetSuite(suiteID, sortBy string) (*model.BenchmarkSuite, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	suite, exists := s.suites[suiteID]
+	if !exists {
+		return nil, fmt.Errorf("suite not found: %s", suiteID)
+	}
+
+	result := *suite
+	result.Models = make([]*model.BenchmarkSuiteModel, len(suite.Models))
+	for i, m := range suite.Models {
+		entry := *m
+		// 测试中的模型显示任务的当前状态
+		if entry.Status == "queued" {
+			if status, exists := s.tasks[entry.TaskID]; exists {
+				entry.Status = status.Status
+			}
+		}
+		result.Models[i] = &entry
+	}
+	result.Leaderboard = buildLeaderboard(result.Models, sortBy)
+	return &result, nil
+}
+
+// suiteThroughput 从测试结果中取出提示处理（pp）和生成（tg）吞吐量，不含pp+tg组合测试
+func suiteThroughput(results []*model.BenchmarkResults) (pp, tg float64) {
+	for _, r := range results {
+		switch {
+		case strings.Contains(r.TestType, "+"):
+		case pp == 0 && strings.HasPrefix(r.TestType, "pp"):
+			pp = r.TokensPerSecond
+		case tg == 0 && strings.HasPrefix(r.TestType, "tg"):
+			tg = r.TokensPerSecond
+		}
+	}
+	return pp, tg
+}
+
+// buildLeaderboard 按sortBy从高到低排列已完成测试的模型，吞吐量相同时按名称排序
+func buildLeaderboard(models []*model.BenchmarkSuiteModel, sortBy string) []*model.BenchmarkLeaderboardEntry {
+	var entries []*model.BenchmarkLeaderboardEntry
+	for _, m := range models {
+		if m.Status != "completed" || (m.PPTokensPerSecond == 0 && m.TGTokensPerSecond == 0) {
+			continue
+		}
+		entry := &model.BenchmarkLeaderboardEntry{
+			Name:              m.Name,
+			SizeGB:            float64(m.Size) / (1 << 30),
+			PPTokensPerSecond: m.PPTokensPerSecond,
+			TGTokensPerSecond: m.TGTokensPerSecond,
+			TaskID:            m.TaskID,
+		}
+		if entry.SizeGB > 0 {
+			entry.PPPerGB = entry.PPTokensPerSecond / entry.SizeGB
+			entry.TGPerGB = entry.TGTokensPerSecond / entry.SizeGB
+		}
+		entries = append(entries, entry)
+	}
+
+	key := func(e *model.BenchmarkLeaderboardEntry) float64 {
+		switch sortBy {
+		case LeaderboardSortPPPerGB:
+			return e.PPPerGB
+		case LeaderboardSortTG:
+			return e.TGTokensPerSecond
+		case LeaderboardSortPP:
+			return e.PPTokensPerSecond
+		}
+		return e.TGPerGB
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if key(entries[i]) != key(entries[j]) {
+			return key(entries[i]) > key(entries[j])
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	for i, entry := range entries {
+		entry.Rank = i + 1
+	}
+	return entries
+}
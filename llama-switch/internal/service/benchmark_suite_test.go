@@ -0,0 +1,70 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestSuiteThroughput(t *testing.T) {
+	pp, tg := suiteThroughput([]*model.BenchmarkResults{
+		{TestType: "pp512+tg128", TokensPerSecond: 1},
+		{TestType: "pp512", TokensPerSecond: 900},
+		{TestType: "tg128", TokensPerSecond: 40},
+	})
+	if pp != 900 || tg != 40 {
+		t.Errorf("suiteThroughput = %v, %v, want 900, 40", pp, tg)
+	}
+}
+
+func TestBuildLeaderboard(t *testing.T) {
+	const gb = 1 << 30
+	models := []*model.BenchmarkSuiteModel{
+		{Name: "big.gguf", Size: 8 * gb, Status: "completed", PPTokensPerSecond: 800, TGTokensPerSecond: 40},
+		{Name: "small.gguf", Size: 2 * gb, Status: "completed", PPTokensPerSecond: 1000, TGTokensPerSecond: 30},
+		{Name: "mid.gguf", Size: 4 * gb, Status: "completed", PPTokensPerSecond: 2400, TGTokensPerSecond: 20},
+		{Name: "broken.gguf", Size: gb, Status: "failed"},
+		{Name: "waiting.gguf", Size: gb, Status: "queued"},
+	}
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{"", []string{"small.gguf", "big.gguf", "mid.gguf"}},
+		{LeaderboardSortPPPerGB, []string{"mid.gguf", "small.gguf", "big.gguf"}},
+		{LeaderboardSortTG, []string{"big.gguf", "small.gguf", "mid.gguf"}},
+		{LeaderboardSortPP, []string{"mid.gguf", "small.gguf", "big.gguf"}},
+	}
+	for _, tt := range tests {
+		entries := buildLeaderboard(models, tt.sort)
+		var names []string
+		for i, entry := range entries {
+			names = append(names, entry.Name)
+			if entry.Rank != i+1 {
+				t.Errorf("sort %q: %s rank = %d, want %d", tt.sort, entry.Name, entry.Rank, i+1)
+			}
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("sort %q: leaderboard = %v, want %v", tt.sort, names, tt.want)
+		}
+	}
+
+	if entry := buildLeaderboard(models, "")[0]; entry.SizeGB != 2 || entry.TGPerGB != 15 || entry.PPPerGB != 500 {
+		t.Errorf("small.gguf entry = %+v", entry)
+	}
+	if err := ValidateLeaderboardSort("size"); err == nil {
+		t.Error("ValidateLeaderboardSort(size) succeeded, want error")
+	}
+}
+
+func TestStartSuiteNoModels(t *testing.T) {
+	cfg := &config.Config{ModelsDir: t.TempDir()}
+	cfg.Benchmark.HistoryFile = cfg.ModelsDir + "/history.jsonl"
+	s := NewBenchmarkService(cfg, nil)
+	if _, err := s.StartSuite(&model.BenchmarkConfig{}); err == nil {
+		t.Error("StartSuite with empty models directory succeeded, want error")
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+
+	"llama-switch/internal/model"
+)
+
+// validateWebhookURLs 验证任务结束通知地址，只允许http和https
+func validateWebhookURLs(urls []string) error {
+	for _, webhookURL := range urls {
+		if u, err := url.Parse(webhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook url: %s", webhookURL)
+		}
+	}
+	return nil
+}
+
+// webhooks 任务结束时需要通知的地址：全局配置的地址加上请求中指定的地址（去重）
+func (s *BenchmarkService) webhooks(job *benchmarkJob) []string {
+	urls := slices.Clone(s.config.Benchmark.WebhookURLs)
+	var requested []string
+	if job.cfg != nil {
+		requested = job.cfg.WebhookURLs
+	} else if job.serving != nil {
+		requested = job.serving.WebhookURLs
+	}
+	for _, webhookURL := range requested {
+		if !slices.Contains(urls, webhookURL) {
+			urls = append(urls, webhookURL)
+		}
+	}
+	return urls
+}
+
+// finish 写入已结束任务的历史记录，并在后台发送Webhook通知并发布到事件总线，调用方需持有s.mu
+func (s *BenchmarkService) finish(job *benchmarkJob, record *model.BenchmarkRecord) {
+	s.history.Record(record)
+	kind := "bench"
+	if job.serving != nil {
+		kind = "serving"
+	}
+	benchmarkDuration.WithLabelValues(kind, record.Status).Observe(record.DurationSec)
+
+	// 通知中不包含llama-bench的原始输出，需要时从历史记录中获取
+	notification := *record
+	notification.Output = ""
+	if s.models != nil {
+		s.models.EventBus().Publish("benchmark."+record.Status, &notification)
+	}
+
+	urls := s.webhooks(job)
+	if len(urls) == 0 {
+		return
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":   "benchmark." + record.Status,
+		"task_id": record.TaskID,
+		"record":  &notification,
+	})
+	if err != nil {
+		slog.Warn("Failed to encode benchmark webhook", "task_id", record.TaskID, "error", err)
+		return
+	}
+	for _, webhookURL := range urls {
+		go sendBenchmarkWebhook(webhookURL, record.TaskID, payload)
+	}
+}
+
+// sendBenchmarkWebhook 向一个地址发送任务结束通知，失败时只记录日志
+func sendBenchmarkWebhook(webhookURL, taskID string, payload []byte) {
+	resp, err := webhookClient.Post(webhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		slog.Warn("Failed to send benchmark webhook", "task_id", taskID, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Warn("Benchmark webhook returned an error status", "task_id", taskID, "status", resp.StatusCode)
+	}
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestValidateWebhookURLs(t *testing.T) {
+	if err := validateWebhookURLs([]string{"http://ci.example.com/hook", "https://example.com"}); err != nil {
+		t.Errorf("valid urls rejected: %v", err)
+	}
+	for _, webhookURL := range []string{"ftp://example.com", "example.com/hook", "http://"} {
+		if err := validateWebhookURLs([]string{webhookURL}); err == nil {
+			t.Errorf("validateWebhookURLs accepted %q", webhookURL)
+		}
+	}
+}
+
+func TestBenchmarkWebhookOnCancel(t *testing.T) {
+	received := make(chan map[string]interface{}, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.Benchmark.HistoryFile = filepath.Join(t.TempDir(), "history.jsonl")
+	cfg.Benchmark.WebhookURLs = []string{server.URL + "/global"}
+	s := NewBenchmarkService(cfg, nil)
+
+	// 请求中的地址与全局地址重复时只通知一次
+	job := &benchmarkJob{
+		taskID:   "queued",
+		cfg:      &model.BenchmarkConfig{ModelPath: "m.gguf", WebhookURLs: []string{server.URL + "/global"}},
+		manifest: &model.BenchmarkManifest{ModelPath: "m.gguf"},
+	}
+	if urls := s.webhooks(job); len(urls) != 1 {
+		t.Errorf("webhooks = %v, want 1 url", urls)
+	}
+
+	s.tasks["queued"] = &model.BenchmarkStatus{TaskID: "queued", Status: "queued"}
+	s.queue = append(s.queue, job)
+	if err := s.StopTask("queued"); err != nil {
+		t.Fatalf("StopTask failed: %v", err)
+	}
+
+	select {
+	case payload := <-received:
+		record, _ := payload["record"].(map[string]interface{})
+		if payload["event"] != "benchmark.cancelled" || payload["task_id"] != "queued" || record["model_path"] != "m.gguf" {
+			t.Errorf("unexpected webhook payload: %v", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not received")
+	}
+	select {
+	case payload := <-received:
+		t.Errorf("duplicate webhook received: %v", payload)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
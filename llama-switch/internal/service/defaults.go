@@ -0,0 +1,121 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+// EventDefaultsChanged 运行时修改了默认模型配置
+const EventDefaultsChanged = "defaults_changed"
+
+// ApplyModelDefaults 用全局默认配置（DEFAULT_*、GPU、缓存和内存配置）填充请求中未指定的启动参数
+// specified为请求config中显式指定的字段（包括值为0或false的字段），这些字段保持请求中的值；
+// 默认值为零值的配置项不填充。no_defaults为true时不做任何修改。返回填充的字段
+func (s *ModelService) ApplyModelDefaults(cfg *model.ModelConfig, specified map[string]bool) []string {
+	if cfg.NoDefaults {
+		return nil
+	}
+
+	var applied []string
+	fill := func(field string, isDefault bool, apply func()) {
+		if !isDefault || specified[field] {
+			return
+		}
+		apply()
+		applied = append(applied, field)
+	}
+
+	c := &cfg.Config
+	d := s.config.Snapshot()
+	fill("threads", d.DefaultModel.Threads != 0, func() { c.Threads = d.DefaultModel.Threads })
+	fill("ctx_size", d.DefaultModel.CtxSize > 0, func() { c.CtxSize = d.DefaultModel.CtxSize })
+	fill("batch_size", d.DefaultModel.BatchSize > 0, func() { c.BatchSize = d.DefaultModel.BatchSize })
+	fill("ubatch_size", d.DefaultModel.UBatchSize > 0, func() { c.UBatchSize = d.DefaultModel.UBatchSize })
+	fill("n_gpu_layers", d.GPU.Layers > 0, func() { c.NGPULayers = model.GPULayers(d.GPU.Layers) })
+	fill("split_mode", d.GPU.SplitMode != "", func() { c.SplitMode = d.GPU.SplitMode })
+	fill("main_gpu", d.GPU.MainGPU > 0, func() { c.MainGPU = d.GPU.MainGPU })
+	fill("flash_attn", d.GPU.FlashAttn, func() { c.FlashAttn = true })
+	fill("cache_type_k", d.Cache.TypeK != "", func() { c.CacheTypeK = d.Cache.TypeK })
+	fill("cache_type_v", d.Cache.TypeV != "", func() { c.CacheTypeV = d.Cache.TypeV })
+	fill("mlock", d.Memory.Mlock, func() { c.Mlock = true })
+	fill("no_mmap", !d.Memory.Mmap, func() { c.NoMMap = true })
+	fill("numa", d.Memory.Numa != "", func() { c.Numa = d.Memory.Numa })
+
+	if len(applied) > 0 {
+		slog.Info("Applied default settings to model", "model_name", cfg.ModelName, "fields", strings.Join(applied, ", "))
+	}
+	return applied
+}
+
+// GetDefaults 获取当前的默认模型配置、运行时修改的覆盖项和修改记录
+func (s *ModelService) GetDefaults() (*model.ModelDefaults, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	path := config.DefaultsPath(s.config)
+	overrides, err := config.LoadDefaultsOverrides(path)
+	if err != nil {
+		return nil, err
+	}
+	return &model.ModelDefaults{
+		Defaults:  config.Defaults(s.config),
+		Overrides: overrides.Overrides,
+		File:      path,
+		Changelog: overrides.Changelog,
+	}, nil
+}
+
+// UpdateDefaults 修改默认模型配置，验证通过后立即生效并持久化，重启或重新加载配置后仍然有效
+// 修改记录（修改人、原因和变化的配置项）保存在持久化文件中并记录到事件日志；正在运行的模型不受影响
+func (s *ModelService) UpdateDefaults(req *model.DefaultsUpdateRequest) (*model.DefaultsChange, error) {
+	if len(req.Defaults) == 0 {
+		return nil, fmt.Errorf("defaults is required")
+	}
+
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	next := *s.config
+	if err := config.ApplyDefaults(&next, req.Defaults); err != nil {
+		return nil, err
+	}
+	if err := config.ValidateConfig(&next); err != nil {
+		return nil, fmt.Errorf("invalid defaults: %v", err)
+	}
+	change := &model.DefaultsChange{
+		Changes: config.Diff(s.config, &next),
+		Author:  req.Caller,
+		Note:    req.Author,
+		Reason:  req.Reason,
+		Time:    time.Now().Format(time.RFC3339),
+	}
+	if len(change.Changes) == 0 {
+		return change, nil
+	}
+
+	path := config.DefaultsPath(s.config)
+	overrides, err := config.LoadDefaultsOverrides(path)
+	if err != nil {
+		return nil, err
+	}
+	overrides.Merge(req.Defaults)
+	overrides.Changelog = append(overrides.Changelog, change)
+	if err := overrides.Save(path); err != nil {
+		return nil, fmt.Errorf("failed to persist defaults: %v", err)
+	}
+	config.ApplyDefaults(s.config, req.Defaults)
+
+	fields := make([]string, len(change.Changes))
+	for i, c := range change.Changes {
+		fields[i] = c.Field
+	}
+	message := fmt.Sprintf("Default model settings changed by %s: %s", req.Caller, strings.Join(fields, ", "))
+	slog.Info("Default model settings changed", "author", req.Caller, "fields", strings.Join(fields, ", "))
+	s.events.Record(EventDefaultsChanged, "", message, change)
+	return change, nil
+}
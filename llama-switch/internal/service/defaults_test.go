@@ -0,0 +1,129 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestApplyModelDefaults(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.DefaultModel.CtxSize = 4096
+	cfg.DefaultModel.Threads = 8
+	cfg.GPU.Layers = 99
+	cfg.GPU.FlashAttn = true
+	cfg.Cache.TypeK = "q8_0"
+	cfg.Memory.Mmap = true
+	s := &ModelService{config: cfg}
+
+	// 显式指定为0的字段保持请求中的值
+	mc := &model.ModelConfig{ModelName: "m"}
+	mc.Config.CtxSize = 8192
+	applied := s.ApplyModelDefaults(mc, map[string]bool{"ctx_size": true, "n_gpu_layers": true})
+	if mc.Config.CtxSize != 8192 || mc.Config.NGPULayers != 0 {
+		t.Errorf("specified fields overridden: ctx_size=%d n_gpu_layers=%d", mc.Config.CtxSize, mc.Config.NGPULayers)
+	}
+	if mc.Config.Threads != 8 || !mc.Config.FlashAttn || mc.Config.CacheTypeK != "q8_0" || mc.Config.NoMMap {
+		t.Errorf("defaults not applied: %+v", mc.Config)
+	}
+	if want := []string{"threads", "flash_attn", "cache_type_k"}; !slices.Equal(applied, want) {
+		t.Errorf("applied = %v, want %v", applied, want)
+	}
+
+	noDefaults := &model.ModelConfig{ModelName: "m", NoDefaults: true}
+	if applied := s.ApplyModelDefaults(noDefaults, nil); applied != nil || noDefaults.Config.Threads != 0 {
+		t.Errorf("defaults applied with no_defaults: %v", applied)
+	}
+}
+
+func TestUpdateDefaults(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SSL_CERT_FILE", "")
+	t.Setenv("SSL_KEY_FILE", "")
+	for _, name := range []string{"llama-server", "llama-bench"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "config.yaml")
+	data := fmt.Sprintf(`llama_path:
+  server: %[1]s/llama-server
+  bench: %[1]s/llama-bench
+models_dir: %[1]s
+persistent_dir: %[1]s/state
+default_model:
+  ctx_size: 4096
+`, dir)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	s := &ModelService{config: cfg, events: NewEventLog(filepath.Join(dir, "events.jsonl"), 10)}
+
+	// 不可在运行时修改的配置项、不存在的配置项和无效的值都被拒绝且不修改配置
+	for _, defaults := range []map[string]interface{}{
+		{"server": map[string]interface{}{"port": 9090}},
+		{"default_model": map[string]interface{}{"context": 8192}},
+		{"default_model": map[string]interface{}{"ctx_size": -1}},
+	} {
+		if _, err := s.UpdateDefaults(&model.DefaultsUpdateRequest{Defaults: defaults, Author: "alice"}); err == nil {
+			t.Errorf("UpdateDefaults accepted %v", defaults)
+		}
+	}
+	if cfg.DefaultModel.CtxSize != 4096 || cfg.Server.Port == 9090 {
+		t.Fatalf("config changed by a rejected update: ctx_size=%d port=%d", cfg.DefaultModel.CtxSize, cfg.Server.Port)
+	}
+
+	change, err := s.UpdateDefaults(&model.DefaultsUpdateRequest{
+		Defaults: map[string]interface{}{
+			"default_model": map[string]interface{}{"ctx_size": 16384},
+			"gpu":           map[string]interface{}{"layers": 40},
+		},
+		Author: "alice",
+		Reason: "larger context",
+		Caller: "key:ops",
+	})
+	if err != nil {
+		t.Fatalf("UpdateDefaults failed: %v", err)
+	}
+	if len(change.Changes) != 2 || change.Changes[0].Field != "default_model.ctx_size" || change.Changes[1].Field != "gpu.layers" {
+		t.Errorf("unexpected changes: %+v", change.Changes)
+	}
+	if cfg.DefaultModel.CtxSize != 16384 || cfg.GPU.Layers != 40 {
+		t.Errorf("defaults not applied: ctx_size=%d layers=%d", cfg.DefaultModel.CtxSize, cfg.GPU.Layers)
+	}
+	if _, err := s.UpdateDefaults(&model.DefaultsUpdateRequest{
+		Defaults: map[string]interface{}{"default_model": map[string]interface{}{"threads": 4}},
+		Caller:   "key:ci",
+	}); err != nil {
+		t.Fatalf("UpdateDefaults failed: %v", err)
+	}
+
+	// 修改在重新加载配置后仍然有效，并优先于配置文件
+	reloaded, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if reloaded.DefaultModel.CtxSize != 16384 || reloaded.DefaultModel.Threads != 4 || reloaded.GPU.Layers != 40 {
+		t.Errorf("defaults not persisted: %+v %+v", reloaded.DefaultModel, reloaded.GPU)
+	}
+
+	defaults, err := s.GetDefaults()
+	if err != nil {
+		t.Fatalf("GetDefaults failed: %v", err)
+	}
+	if len(defaults.Changelog) != 2 || defaults.Changelog[0].Author != "key:ops" || defaults.Changelog[0].Note != "alice" || defaults.Changelog[1].Author != "key:ci" {
+		t.Errorf("unexpected changelog: %+v", defaults.Changelog)
+	}
+	if defaults.Defaults["default_model.ctx_size"] != float64(16384) {
+		t.Errorf("unexpected defaults: %v", defaults.Defaults)
+	}
+}
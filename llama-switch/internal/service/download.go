@@ -0,0 +1,265 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+)
+
+// 下载状态
+const (
+	DownloadQueued      = "queued"
+	DownloadDownloading = "downloading"
+	DownloadCompleted   = "completed"
+	DownloadFailed      = "failed"
+)
+
+// downloadClient 下载模型文件的HTTP客户端（大文件下载不设置整体超时）
+var downloadClient = &http.Client{}
+
+// download 单个文件的下载任务
+type download struct {
+	status *model.DownloadStatus
+	done   chan struct{}
+	err    error
+}
+
+// DownloadManager 模型文件下载管理器
+// 限制同时进行的下载数，同一文件的并发请求共享一次下载
+type DownloadManager struct {
+	endpoint  string
+	slots     chan struct{}
+	mu        sync.Mutex
+	downloads map[string]*download // 本地路径到下载任务的映射
+}
+
+// NewDownloadManager 创建模型文件下载管理器
+func NewDownloadManager(endpoint string, maxConcurrent int) *DownloadManager {
+	return &DownloadManager{
+		endpoint:  strings.TrimRight(endpoint, "/"),
+		slots:     make(chan struct{}, maxConcurrent),
+		downloads: make(map[string]*download),
+	}
+}
+
+// Fetch 下载Hugging Face仓库中的文件到本地路径，文件已存在时直接返回
+func (m *DownloadManager) Fetch(repo, file, token, dest string) error {
+	if _, err := os.Stat(dest); err == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	d, exists := m.downloads[dest]
+	if !exists || d.status.Status == DownloadFailed {
+		d = &download{
+			status: &model.DownloadStatus{
+				Repo:      repo,
+				File:      file,
+				Path:      dest,
+				Status:    DownloadQueued,
+				StartTime: time.Now().Format(time.RFC3339),
+			},
+			done: make(chan struct{}),
+		}
+		m.downloads[dest] = d
+		go m.run(d, token)
+	}
+	m.mu.Unlock()
+
+	<-d.done
+	return d.err
+}
+
+// List 获取所有下载任务的状态，按开始时间排序
+func (m *DownloadManager) List() []*model.DownloadStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]*model.DownloadStatus, 0, len(m.downloads))
+	for _, d := range m.downloads {
+		status := *d.status
+		list = append(list, &status)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].StartTime < list[j].StartTime
+	})
+	return list
+}
+
+// run 等待下载槽位后执行下载
+func (m *DownloadManager) run(d *download, token string) {
+	m.slots <- struct{}{}
+	defer func() { <-m.slots }()
+
+	m.update(d, func(s *model.DownloadStatus) { s.Status = DownloadDownloading })
+	slog.Info("Downloading model", "repo", d.status.Repo, "file", d.status.File, "path", d.status.Path)
+
+	err := m.fetch(d, token)
+
+	m.update(d, func(s *model.DownloadStatus) {
+		s.EndTime = time.Now().Format(time.RFC3339)
+		if err != nil {
+			s.Status = DownloadFailed
+			s.Error = err.Error()
+		} else {
+			s.Status = DownloadCompleted
+			s.Progress = 100
+		}
+	})
+	if err != nil {
+		slog.Error("Failed to download model", "repo", d.status.Repo, "file", d.status.File, "error", err)
+	} else {
+		slog.Info("Downloaded model", "repo", d.status.Repo, "file", d.status.File, "bytes", d.status.Downloaded)
+	}
+
+	d.err = err
+	close(d.done)
+}
+
+// fetch 下载文件到临时文件，完成后重命名为目标路径
+func (m *DownloadManager) fetch(d *download, token string) error {
+	url := fmt.Sprintf("%s/%s/resolve/main/%s", m.endpoint, d.status.Repo, d.status.File)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create download request: %v", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download returned status %d", resp.StatusCode)
+	}
+	m.update(d, func(s *model.DownloadStatus) { s.Total = resp.ContentLength })
+
+	if err := os.MkdirAll(filepath.Dir(d.status.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create model directory: %v", err)
+	}
+	partPath := d.status.Path + ".part"
+	f, err := os.Create(partPath)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %v", err)
+	}
+
+	_, err = io.Copy(f, &progressReader{reader: resp.Body, manager: m, download: d})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partPath)
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+
+	if err := os.Rename(partPath, d.status.Path); err != nil {
+		os.Remove(partPath)
+		return fmt.Errorf("failed to move downloaded file: %v", err)
+	}
+	return nil
+}
+
+// update 在锁保护下更新下载状态
+func (m *DownloadManager) update(d *download, fn func(s *model.DownloadStatus)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn(d.status)
+}
+
+// progressReader 读取时更新下载进度
+type progressReader struct {
+	reader   io.Reader
+	manager  *DownloadManager
+	download *download
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.manager.update(r.download, func(s *model.DownloadStatus) {
+			s.Downloaded += int64(n)
+			if s.Total > 0 {
+				s.Progress = float64(s.Downloaded) * 100 / float64(s.Total)
+			}
+		})
+	}
+	return n, err
+}
+
+// ensureModelFile 对使用hf_repo/hf_file的模型，下载文件到模型目录并改为使用本地路径启动
+// 这样显存估算与调度可以基于已知的文件大小进行
+func (s *ModelService) ensureModelFile(cfg *model.ModelConfig) error {
+	c := &cfg.Config
+	if c.HfRepo == "" {
+		return nil
+	}
+	dest, err := s.downloadTarget(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := s.downloads.Fetch(c.HfRepo, c.HfFile, c.HfToken, dest); err != nil {
+		return fmt.Errorf("failed to download %s/%s: %v", c.HfRepo, c.HfFile, err)
+	}
+
+	// 文件已在本地，不再交给llama-server下载
+	clearDownload(cfg)
+	return nil
+}
+
+// downloadTarget 确定Hugging Face模型文件的本地保存路径，未指定model_path时使用文件名
+// model_path来自客户端，保存路径必须位于模型目录内，防止下载的文件写到任意位置
+func (s *ModelService) downloadTarget(cfg *model.ModelConfig) (string, error) {
+	c := &cfg.Config
+	if c.HfFile == "" {
+		return "", fmt.Errorf("hf_file is required when hf_repo is set")
+	}
+
+	if cfg.ModelPath == "" {
+		cfg.ModelPath = filepath.Base(c.HfFile)
+	}
+	modelsDir := s.config.Snapshot().ModelsDir
+	dest := cfg.ModelPath
+	if !filepath.IsAbs(dest) {
+		dest = filepath.Join(modelsDir, dest)
+	}
+	dest = filepath.Clean(dest)
+
+	modelsDir, err := filepath.Abs(modelsDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve models directory: %v", err)
+	}
+	absDest, err := filepath.Abs(dest)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve download destination: %v", err)
+	}
+	rel, err := filepath.Rel(modelsDir, absDest)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", apierror.New(apierror.CodeInvalidRequest,
+			"model_path '%s' must be a file inside the models directory when downloading with hf_repo", cfg.ModelPath)
+	}
+	return dest, nil
+}
+
+// clearDownload 清除已由switcher下载的模型文件的下载参数
+func clearDownload(cfg *model.ModelConfig) {
+	c := &cfg.Config
+	c.HfRepo = ""
+	c.HfFile = ""
+	if c.HfRepoDraft == "" && c.HfRepoV == "" {
+		c.HfToken = ""
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestDownloadManager_FetchSharesDownload(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path != "/org/repo/resolve/main/model.gguf" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("GGUF-data"))
+	}))
+	defer server.Close()
+
+	m := NewDownloadManager(server.URL+"/", 1)
+	dest := filepath.Join(t.TempDir(), "model.gguf")
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = m.Fetch("org/repo", "model.gguf", "secret", dest)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("Fetch failed: %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Expected 1 download request, got %d", n)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "GGUF-data" {
+		t.Errorf("Unexpected file content: %q", data)
+	}
+
+	list := m.List()
+	if len(list) != 1 || list[0].Status != DownloadCompleted || list[0].Downloaded != 9 || list[0].Progress != 100 {
+		t.Errorf("Unexpected download status: %+v", list[0])
+	}
+}
+
+func TestDownloadManager_FetchFailure(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	m := NewDownloadManager(server.URL, 1)
+	dest := filepath.Join(t.TempDir(), "missing.gguf")
+	if err := m.Fetch("org/repo", "missing.gguf", "", dest); err == nil {
+		t.Fatal("Expected error for missing file")
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Error("Partial file left behind")
+	}
+	if list := m.List(); list[0].Status != DownloadFailed || list[0].Error == "" {
+		t.Errorf("Unexpected download status: %+v", list[0])
+	}
+}
+
+func TestDownloadTarget(t *testing.T) {
+	modelsDir := t.TempDir()
+	cfg := &config.Config{ModelsDir: modelsDir}
+	s := &ModelService{config: cfg}
+
+	tests := []struct {
+		name      string
+		modelPath string
+		want      string // 为空表示应拒绝
+	}{
+		{"default file name", "", filepath.Join(modelsDir, "model.gguf")},
+		{"relative path", "qwen/model.gguf", filepath.Join(modelsDir, "qwen", "model.gguf")},
+		{"absolute path inside", filepath.Join(modelsDir, "a", "model.gguf"), filepath.Join(modelsDir, "a", "model.gguf")},
+		{"cleaned path inside", "qwen/../model.gguf", filepath.Join(modelsDir, "model.gguf")},
+		{"relative traversal", "../../etc/cron.d/x", ""},
+		{"absolute outside", "/root/.ssh/authorized_keys", ""},
+		{"models directory itself", ".", ""},
+		{"sibling with common prefix", modelsDir + "-evil/model.gguf", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := &model.ModelConfig{ModelName: "m", ModelPath: tt.modelPath}
+			mc.Config.HfRepo = "org/repo"
+			mc.Config.HfFile = "sub/model.gguf"
+			dest, err := s.downloadTarget(mc)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("downloadTarget(%q) = %q, want error", tt.modelPath, dest)
+				}
+				return
+			}
+			if err != nil || dest != tt.want {
+				t.Errorf("downloadTarget(%q) = %q, %v, want %q", tt.modelPath, dest, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// validateEnvName 检查环境变量名是否合法且在允许列表中
+func (s *ModelService) validateEnvName(name string) error {
+	if name == "" || strings.ContainsAny(name, "= \t\n\x00") {
+		return fmt.Errorf("invalid environment variable name: %q", name)
+	}
+	allowlist := s.config.Snapshot().ModelEnv.Allowlist
+	for _, allowed := range allowlist {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return nil
+			}
+		} else if name == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("environment variable %s is not allowed (allowed: %s)",
+		name, strings.Join(allowlist, ", "))
+}
+
+// modelEnv 将模型环境变量转换为按名称排序的KEY=VALUE列表
+func modelEnv(env map[string]string) []string {
+	if len(env) == 0 {
+		return nil
+	}
+	list := make([]string, 0, len(env))
+	for key, value := range env {
+		list = append(list, key+"="+value)
+	}
+	sort.Strings(list)
+	return list
+}
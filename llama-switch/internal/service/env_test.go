@@ -0,0 +1,36 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"llama-switch/internal/config"
+)
+
+func TestValidateEnvName(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ModelEnv.Allowlist = []string{"CUDA_VISIBLE_DEVICES", "GGML_*"}
+	s := &ModelService{config: cfg}
+
+	for _, name := range []string{"CUDA_VISIBLE_DEVICES", "GGML_CUDA_NO_PINNED", "GGML_"} {
+		if err := s.validateEnvName(name); err != nil {
+			t.Errorf("Expected %s to be allowed, got %v", name, err)
+		}
+	}
+	for _, name := range []string{"LD_PRELOAD", "PATH", "CUDA_VISIBLE_DEVICES_X", "", "GGML_A=B"} {
+		if err := s.validateEnvName(name); err == nil {
+			t.Errorf("Expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestModelEnv(t *testing.T) {
+	env := modelEnv(map[string]string{"HIP_VISIBLE_DEVICES": "1", "CUDA_VISIBLE_DEVICES": "0,1"})
+	want := []string{"CUDA_VISIBLE_DEVICES=0,1", "HIP_VISIBLE_DEVICES=1"}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("modelEnv = %v, want %v", env, want)
+	}
+	if modelEnv(nil) != nil {
+		t.Error("Expected nil for empty env")
+	}
+}
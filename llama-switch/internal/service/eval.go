@@ -0,0 +1,380 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// EventEvalFailed 准确性冒烟测试有用例失败
+const EventEvalFailed = "eval_failed"
+
+// 测试集的触发方式
+const (
+	EvalTriggerManual = "manual" // 通过API手动运行
+	EvalTriggerSwitch = "switch" // 模型切换成功后自动运行
+)
+
+const (
+	evalFileName         = "evals.json"
+	defaultEvalMaxTokens = 128  // 用例未指定时最多生成的token数
+	evalRecentRuns       = 100  // 内存中保留的最近运行结果数
+	evalMaxOutput        = 4096 // 结果中保存的回答的最大长度（字节）
+)
+
+// evalClient 向模型实例发送测试请求的HTTP客户端，超时由每个用例的上下文控制
+var evalClient = &http.Client{}
+
+// EvalManager 准确性冒烟测试管理器：保存用户定义的测试集，通过模型实例的对话接口运行并检查回答
+type EvalManager struct {
+	service *ModelService
+	path    string
+	timeout time.Duration // 单个用例的超时时间
+
+	mu     sync.RWMutex
+	suites map[string]*model.EvalSuite
+	recent []*model.EvalRun // 最近的运行结果（按时间顺序）
+}
+
+// newEvalManager 创建测试集管理器并加载已保存的测试集
+func newEvalManager(s *ModelService, path string, timeout time.Duration) *EvalManager {
+	m := &EvalManager{
+		service: s,
+		path:    path,
+		timeout: timeout,
+		suites:  make(map[string]*model.EvalSuite),
+	}
+	if err := m.load(); err != nil {
+		slog.Warn("Failed to load eval suites", "file", path, "error", err)
+	}
+	return m
+}
+
+// defaultEvalPath 默认测试集文件路径：程序目录下的config/evals.json
+func defaultEvalPath() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return filepath.Join("config", evalFileName)
+	}
+	return filepath.Join(filepath.Dir(exePath), "config", evalFileName)
+}
+
+// validateEvalSuite 验证测试集并填充用例的默认名称
+func validateEvalSuite(suite *model.EvalSuite) error {
+	if suite.Name == "" {
+		return fmt.Errorf("suite name is required")
+	}
+	if len(suite.Cases) == 0 {
+		return fmt.Errorf("at least one case is required")
+	}
+	for i, c := range suite.Cases {
+		if c == nil {
+			return fmt.Errorf("case %d is empty", i+1)
+		}
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case-%d", i+1)
+		}
+		if c.Prompt == "" {
+			return fmt.Errorf("case %s: prompt is required", c.Name)
+		}
+		if len(c.Contains) == 0 && c.Regex == "" {
+			return fmt.Errorf("case %s: contains or regex is required", c.Name)
+		}
+		if c.Regex != "" {
+			if _, err := regexp.Compile(c.Regex); err != nil {
+				return fmt.Errorf("case %s: invalid regex: %v", c.Name, err)
+			}
+		}
+		if c.MaxTokens < 0 {
+			return fmt.Errorf("case %s: invalid max tokens: %d", c.Name, c.MaxTokens)
+		}
+	}
+	return nil
+}
+
+// Set 创建或替换测试集
+func (m *EvalManager) Set(suite *model.EvalSuite) error {
+	if err := validateEvalSuite(suite); err != nil {
+		return err
+	}
+	suite.LastRun = nil
+	suite.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.suites[suite.Name] = suite
+	return m.save()
+}
+
+// Remove 删除测试集
+func (m *EvalManager) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.suites[name]; !exists {
+		return fmt.Errorf("eval suite '%s' not found", name)
+	}
+	delete(m.suites, name)
+	return m.save()
+}
+
+// List 获取所有测试集及其最近一次运行结果，按名称排序
+func (m *EvalManager) List() []*model.EvalSuite {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	suites := make([]*model.EvalSuite, 0, len(m.suites))
+	for _, suite := range m.suites {
+		copied := *suite
+		for i := len(m.recent) - 1; i >= 0; i-- {
+			if m.recent[i].Suite == suite.Name {
+				copied.LastRun = m.recent[i]
+				break
+			}
+		}
+		suites = append(suites, &copied)
+	}
+	sort.Slice(suites, func(i, j int) bool {
+		return suites[i].Name < suites[j].Name
+	})
+	return suites
+}
+
+// Runs 获取最近的运行结果（最新的在前），suite和modelName为空时不过滤
+func (m *EvalManager) Runs(suite, modelName string) []*model.EvalRun {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	runs := make([]*model.EvalRun, 0)
+	for i := len(m.recent) - 1; i >= 0; i-- {
+		run := m.recent[i]
+		if (suite == "" || run.Suite == suite) && (modelName == "" || run.ModelName == modelName) {
+			runs = append(runs, run)
+		}
+	}
+	return runs
+}
+
+// Run 通过运行中的模型实例运行测试集，有用例失败时记录eval_failed事件
+func (m *EvalManager) Run(ctx context.Context, suiteName, modelName, trigger string) (*model.EvalRun, error) {
+	m.mu.RLock()
+	suite, exists := m.suites[suiteName]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("eval suite '%s' not found", suiteName)
+	}
+
+	modelName = m.service.Aliases().Resolve(modelName)
+	backend, err := m.service.GetBackend(modelName)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &model.EvalRun{
+		Suite:     suite.Name,
+		ModelName: modelName,
+		Trigger:   trigger,
+		StartTime: time.Now().Format(time.RFC3339),
+	}
+	for _, c := range suite.Cases {
+		result := m.runCase(ctx, backend.URL.String(), c)
+		if result.Passed {
+			run.Passed++
+		} else {
+			run.Failed++
+		}
+		run.Results = append(run.Results, result)
+	}
+	run.EndTime = time.Now().Format(time.RFC3339)
+
+	m.mu.Lock()
+	m.recent = append(m.recent, run)
+	if len(m.recent) > evalRecentRuns {
+		m.recent = m.recent[len(m.recent)-evalRecentRuns:]
+	}
+	m.mu.Unlock()
+
+	slog.Info("Eval suite finished", "suite", suite.Name, "model_name", modelName, "passed", run.Passed, "failed", run.Failed)
+	if run.Failed > 0 {
+		m.service.Events().Record(EventEvalFailed, modelName,
+			fmt.Sprintf("Eval suite %s failed %d of %d cases on model %s", suite.Name, run.Failed, len(run.Results), modelName), run)
+	}
+	return run, nil
+}
+
+// RunAfterSwitch 在后台对切换成功的模型运行所有设置了run_on_switch且适用于该模型的测试集
+func (m *EvalManager) RunAfterSwitch(modelName string) {
+	m.mu.RLock()
+	var names []string
+	for _, suite := range m.suites {
+		if suite.RunOnSwitch && (len(suite.Models) == 0 || slices.Contains(suite.Models, modelName)) {
+			names = append(names, suite.Name)
+		}
+	}
+	m.mu.RUnlock()
+	if len(names) == 0 {
+		return
+	}
+
+	sort.Strings(names)
+	go func() {
+		for _, name := range names {
+			if _, err := m.Run(context.Background(), name, modelName, EvalTriggerSwitch); err != nil {
+				slog.Error("Failed to run eval suite", "suite", name, "model_name", modelName, "error", err)
+			}
+		}
+	}()
+}
+
+// runCase 发送单个用例的对话请求并检查回答
+func (m *EvalManager) runCase(ctx context.Context, baseURL string, c *model.EvalCase) *model.EvalCaseResult {
+	result := &model.EvalCaseResult{Name: c.Name}
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	started := time.Now()
+	output, err := evalChat(ctx, baseURL, c)
+	result.LatencyMS = time.Since(started).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if len(output) > evalMaxOutput {
+		result.Output = output[:evalMaxOutput]
+	} else {
+		result.Output = output
+	}
+	result.Failures = checkEvalCase(c, output)
+	result.Passed = len(result.Failures) == 0
+	return result
+}
+
+// checkEvalCase 检查回答是否满足用例的断言，返回未满足的断言
+func checkEvalCase(c *model.EvalCase, output string) []string {
+	var failures []string
+	lower := strings.ToLower(output)
+	for _, expected := range c.Contains {
+		if !strings.Contains(lower, strings.ToLower(expected)) {
+			failures = append(failures, fmt.Sprintf("output does not contain %q", expected))
+		}
+	}
+	if c.Regex != "" {
+		// 正则表达式在保存测试集时已验证
+		if re, err := regexp.Compile(c.Regex); err != nil || !re.MatchString(output) {
+			failures = append(failures, fmt.Sprintf("output does not match /%s/", c.Regex))
+		}
+	}
+	return failures
+}
+
+// evalChat 通过llama-server的OpenAI兼容对话接口发送请求（使用模型的对话模板），temperature为0以便结果稳定
+func evalChat(ctx context.Context, baseURL string, c *model.EvalCase) (string, error) {
+	var messages []map[string]string
+	if c.System != "" {
+		messages = append(messages, map[string]string{"role": "system", "content": c.System})
+	}
+	messages = append(messages, map[string]string{"role": "user", "content": c.Prompt})
+	maxTokens := c.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = defaultEvalMaxTokens
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"messages":    messages,
+		"max_tokens":  maxTokens,
+		"temperature": 0,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := evalClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("model returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(data, &completion); err != nil {
+		return "", fmt.Errorf("failed to parse response: %v", err)
+	}
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("response contains no choices")
+	}
+	return completion.Choices[0].Message.Content, nil
+}
+
+// load 从文件加载测试集
+func (m *EvalManager) load() error {
+	data, err := os.ReadFile(m.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read eval file: %v", err)
+	}
+
+	var suites []*model.EvalSuite
+	if err := json.Unmarshal(data, &suites); err != nil {
+		return fmt.Errorf("failed to parse eval file: %v", err)
+	}
+	for _, suite := range suites {
+		if err := validateEvalSuite(suite); err != nil {
+			slog.Warn("Skipping invalid eval suite", "suite", suite.Name, "error", err)
+			continue
+		}
+		m.suites[suite.Name] = suite
+	}
+	return nil
+}
+
+// save 保存测试集到文件（调用方需持有锁）
+func (m *EvalManager) save() error {
+	suites := make([]*model.EvalSuite, 0, len(m.suites))
+	for _, suite := range m.suites {
+		suites = append(suites, suite)
+	}
+	sort.Slice(suites, func(i, j int) bool {
+		return suites[i].Name < suites[j].Name
+	})
+
+	data, err := json.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize eval suites: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("failed to create eval directory: %v", err)
+	}
+	if err := os.WriteFile(m.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write eval file: %v", err)
+	}
+	return nil
+}
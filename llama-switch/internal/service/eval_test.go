@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+func TestValidateEvalSuite(t *testing.T) {
+	tests := []struct {
+		name  string
+		suite model.EvalSuite
+		ok    bool
+	}{
+		{"valid", model.EvalSuite{Name: "s", Cases: []*model.EvalCase{{Prompt: "2+2?", Contains: []string{"4"}}}}, true},
+		{"no name", model.EvalSuite{Cases: []*model.EvalCase{{Prompt: "p", Contains: []string{"x"}}}}, false},
+		{"no cases", model.EvalSuite{Name: "s"}, false},
+		{"no assertion", model.EvalSuite{Name: "s", Cases: []*model.EvalCase{{Prompt: "p"}}}, false},
+		{"bad regex", model.EvalSuite{Name: "s", Cases: []*model.EvalCase{{Prompt: "p", Regex: "("}}}, false},
+	}
+	for _, tt := range tests {
+		if err := validateEvalSuite(&tt.suite); (err == nil) != tt.ok {
+			t.Errorf("%s: validateEvalSuite error = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestCheckEvalCase(t *testing.T) {
+	c := &model.EvalCase{Contains: []string{"Paris"}, Regex: `^\s*The`}
+	if failures := checkEvalCase(c, "The capital of France is paris."); len(failures) != 0 {
+		t.Errorf("unexpected failures: %v", failures)
+	}
+	if failures := checkEvalCase(c, "<|im_start|>assistant London"); len(failures) != 2 {
+		t.Errorf("got %d failures, want 2: %v", len(failures), failures)
+	}
+}
+
+func TestEvalChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+			MaxTokens int `json:"max_tokens"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.MaxTokens != defaultEvalMaxTokens {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"content": "echo: " + req.Messages[1].Content}}},
+		})
+	}))
+	defer server.Close()
+
+	m := &EvalManager{timeout: 5 * time.Second}
+	result := m.runCase(context.Background(), server.URL, &model.EvalCase{
+		Name: "echo", System: "Repeat the question.", Prompt: "hello", Contains: []string{"ECHO: hello"},
+	})
+	if !result.Passed || result.Error != "" {
+		t.Errorf("case failed: %+v", result)
+	}
+}
+
+func TestEvalSuitePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evals.json")
+	m := newEvalManager(nil, path, time.Second)
+	suite := &model.EvalSuite{Name: "smoke", RunOnSwitch: true, Cases: []*model.EvalCase{{Prompt: "2+2?", Regex: `\b4\b`}}}
+	if err := m.Set(suite); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	loaded := newEvalManager(nil, path, time.Second).List()
+	if len(loaded) != 1 || loaded[0].Name != "smoke" || !loaded[0].RunOnSwitch || loaded[0].Cases[0].Name != "case-1" {
+		t.Fatalf("unexpected loaded suites: %+v", loaded)
+	}
+	if err := m.Remove("smoke"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := m.Remove("smoke"); err == nil {
+		t.Error("Remove succeeded for a missing suite")
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+const (
+	eventBusHistory       = 100 // 保留的最近事件数，SSE客户端重连时据此补发
+	eventSubscriberBuffer = 64  // SSE订阅者的缓冲事件数，订阅者处理不及时时丢弃新事件
+)
+
+// EventBus 系统事件总线：模型和基准测试的生命周期事件同时发送给事件订阅（Webhook）和实时事件流（SSE）
+type EventBus struct {
+	webhooks *WebhookManager
+
+	mu          sync.Mutex
+	seq         int64
+	recent      []model.SystemEvent
+	subscribers map[chan model.SystemEvent]struct{}
+}
+
+// NewEventBus 创建事件总线
+func NewEventBus(webhooks *WebhookManager) *EventBus {
+	return &EventBus{
+		webhooks:    webhooks,
+		subscribers: make(map[chan model.SystemEvent]struct{}),
+	}
+}
+
+// EventBus 获取系统事件总线
+func (s *ModelService) EventBus() *EventBus {
+	return s.bus
+}
+
+// Publish 发布一条事件：通知订阅了该事件的Webhook，并推送给所有实时事件流
+func (b *EventBus) Publish(event string, data interface{}) {
+	if b == nil {
+		return
+	}
+	b.webhooks.Publish(event, data)
+
+	// 发布时即序列化，避免之后data被修改影响推送的内容
+	raw, err := json.Marshal(data)
+	if err != nil {
+		slog.Warn("Failed to encode event", "event", event, "error", err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	e := model.SystemEvent{ID: b.seq, Event: event, Time: time.Now().Format(time.RFC3339), Data: raw}
+	b.recent = append(b.recent, e)
+	if len(b.recent) > eventBusHistory {
+		b.recent = b.recent[len(b.recent)-eventBusHistory:]
+	}
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Subscribe 订阅实时事件，返回ID大于lastID的已保留事件（lastID为0时不补发）、接收新事件的通道和取消订阅函数
+func (b *EventBus) Subscribe(lastID int64) ([]model.SystemEvent, <-chan model.SystemEvent, func()) {
+	ch := make(chan model.SystemEvent, eventSubscriberBuffer)
+
+	b.mu.Lock()
+	var missed []model.SystemEvent
+	if lastID > 0 {
+		for _, e := range b.recent {
+			if e.ID > lastID {
+				missed = append(missed, e)
+			}
+		}
+	}
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return missed, ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+func TestEventBusBroadcast(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("X-Webhook-Event")
+	}))
+	defer server.Close()
+
+	webhooks := NewWebhookManager(filepath.Join(t.TempDir(), "webhooks.json"), 1, time.Second)
+	if _, err := webhooks.Create(&model.WebhookRequest{URL: server.URL}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	bus := NewEventBus(webhooks)
+
+	_, events, unsubscribe := bus.Subscribe(0)
+	status := &model.ModelStatus{ModelName: "m", Running: true}
+	bus.Publish(WebhookModelStarted, status)
+	// 发布后修改不影响已推送的内容
+	status.Running = false
+
+	select {
+	case e := <-events:
+		var data model.ModelStatus
+		json.Unmarshal(e.Data, &data)
+		if e.ID != 1 || e.Event != WebhookModelStarted || data.ModelName != "m" || !data.Running {
+			t.Errorf("unexpected event: %+v (%s)", e, e.Data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not broadcast to subscriber")
+	}
+	select {
+	case event := <-received:
+		if event != WebhookModelStarted {
+			t.Errorf("webhook event = %s", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event not delivered to webhook")
+	}
+
+	unsubscribe()
+	bus.Publish(WebhookModelStopped, status)
+	select {
+	case e := <-events:
+		t.Errorf("unsubscribed channel received %+v", e)
+	default:
+	}
+}
+
+func TestEventBusReplay(t *testing.T) {
+	bus := NewEventBus(nil)
+	for i := 0; i < eventBusHistory+10; i++ {
+		bus.Publish(WebhookModelStarted, i)
+	}
+
+	if missed, _, unsubscribe := bus.Subscribe(0); len(missed) != 0 {
+		t.Errorf("Subscribe(0) replayed %d events", len(missed))
+	} else {
+		unsubscribe()
+	}
+
+	missed, _, unsubscribe := bus.Subscribe(eventBusHistory + 7)
+	defer unsubscribe()
+	if len(missed) != 3 || missed[0].ID != eventBusHistory+8 || string(missed[2].Data) != "109" {
+		t.Errorf("unexpected replay: %+v", missed)
+	}
+
+	// 早于保留范围的ID只能补发仍保留的事件
+	if missed, _, unsubscribe := bus.Subscribe(1); len(missed) != eventBusHistory {
+		t.Errorf("replay from 1 = %d events, want %d", len(missed), eventBusHistory)
+	} else {
+		unsubscribe()
+	}
+
+	// 未初始化的事件总线忽略事件
+	var nilBus *EventBus
+	nilBus.Publish(WebhookModelStarted, nil)
+}
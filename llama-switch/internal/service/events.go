@@ -0,0 +1,136 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// 事件类型
+const (
+	EventWatchdogKill          = "watchdog_kill"           // 看门狗结束了卡死的实例
+	EventWatchdogRestart       = "watchdog_restart"        // 看门狗重新启动了实例
+	EventWatchdogRestartFailed = "watchdog_restart_failed" // 看门狗重新启动实例失败
+)
+
+// eventsFileName 默认事件日志文件名
+const eventsFileName = "events.jsonl"
+
+// EventLog 运行事件日志：追加写入JSON Lines文件，并在内存中保留最近的事件供查询
+type EventLog struct {
+	path string
+	size int
+
+	mu     sync.RWMutex
+	recent []model.Event
+}
+
+// NewEventLog 创建事件日志，加载文件中最近的size条事件
+func NewEventLog(path string, size int) *EventLog {
+	l := &EventLog{path: path, size: size}
+	if err := l.load(); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to load events", "file", path, "error", err)
+	}
+	return l
+}
+
+// defaultEventsPath 默认事件日志路径：程序目录下的events.jsonl
+func defaultEventsPath() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return eventsFileName
+	}
+	return filepath.Join(filepath.Dir(exePath), eventsFileName)
+}
+
+// load 从文件加载最近的事件，跳过无法解析的行
+func (l *EventLog) load() error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var event model.Event
+		if json.Unmarshal(scanner.Bytes(), &event) != nil {
+			continue
+		}
+		l.recent = append(l.recent, event)
+		if len(l.recent) > l.size {
+			l.recent = l.recent[1:]
+		}
+	}
+	return scanner.Err()
+}
+
+// Record 记录一条事件
+func (l *EventLog) Record(eventType, modelName, message string, data interface{}) {
+	event := model.Event{
+		Time:      time.Now().Format(time.RFC3339),
+		Type:      eventType,
+		ModelName: modelName,
+		Message:   message,
+		Data:      data,
+	}
+	logger := slog.With("event", eventType)
+	if modelName != "" {
+		logger = logger.With("model_name", modelName)
+	}
+	logger.Info(message)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.recent = append(l.recent, event)
+	if len(l.recent) > l.size {
+		l.recent = l.recent[len(l.recent)-l.size:]
+	}
+	if err := l.append(event); err != nil {
+		slog.Warn("Failed to write event log", "error", err)
+	}
+}
+
+// append 将事件追加写入文件
+func (l *EventLog) append(event model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create event log directory: %v", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %v", err)
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Recent 获取最近的事件（按时间顺序），modelName不为空时只返回该模型的事件，limit大于0时只返回最后limit条
+func (l *EventLog) Recent(modelName string, limit int) []model.Event {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	events := make([]model.Event, 0, len(l.recent))
+	for _, event := range l.recent {
+		if modelName == "" || event.ModelName == modelName {
+			events = append(events, event)
+		}
+	}
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return events
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEventLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	events := NewEventLog(path, 2)
+	events.Record(EventWatchdogKill, "chat", "chat stopped", nil)
+	events.Record(EventWatchdogKill, "embed", "embed stopped", nil)
+	events.Record(EventWatchdogRestart, "chat", "chat restarted", nil)
+
+	// 内存中只保留最近的事件
+	recent := events.Recent("", 0)
+	if len(recent) != 2 || recent[0].ModelName != "embed" || recent[1].Type != EventWatchdogRestart {
+		t.Fatalf("Unexpected recent events: %+v", recent)
+	}
+	if chat := events.Recent("chat", 0); len(chat) != 1 || chat[0].Message != "chat restarted" {
+		t.Errorf("Unexpected events for chat: %+v", chat)
+	}
+	if last := events.Recent("", 1); len(last) != 1 || last[0].Type != EventWatchdogRestart {
+		t.Errorf("Unexpected limited events: %+v", last)
+	}
+
+	// 重新加载时从文件恢复最近的事件
+	reloaded := NewEventLog(path, 10).Recent("", 0)
+	if len(reloaded) != 3 || reloaded[0].Message != "chat stopped" {
+		t.Errorf("Unexpected reloaded events: %+v", reloaded)
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// 显存驱逐策略
+const (
+	EvictionLargest  = "largest"  // 优先驱逐显存占用最多的模型
+	EvictionLRU      = "lru"      // 优先驱逐最久未处理请求的模型
+	EvictionPriority = "priority" // 优先驱逐进程优先级（priority）最低的模型
+)
+
+// evictionOrder 按驱逐策略排列按显存占用从大到小排序的运行中模型，固定（pinned）的模型不参与驱逐，单独返回其名称
+// 排序条件相同时保持显存占用的顺序
+func (s *ModelService) evictionOrder(running []*model.ModelStatus) ([]*model.ModelStatus, []string) {
+	candidates := make([]*model.ModelStatus, 0, len(running))
+	pinned := make([]string, 0)
+	for _, m := range running {
+		if m.Pinned {
+			pinned = append(pinned, m.ModelName)
+			continue
+		}
+		candidates = append(candidates, m)
+	}
+
+	switch s.config.Eviction.Policy {
+	case EvictionLRU:
+		// 从未处理过请求的模型最先驱逐
+		lastActive := make(map[string]int64, len(candidates))
+		for _, m := range candidates {
+			if _, t := s.tracker.Activity(m.ModelName); !t.IsZero() {
+				lastActive[m.ModelName] = t.UnixNano()
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return lastActive[candidates[i].ModelName] < lastActive[candidates[j].ModelName]
+		})
+	case EvictionPriority:
+		priority := make(map[string]int, len(candidates))
+		for _, m := range candidates {
+			priority[m.ModelName] = s.runningConfig(m.ModelName).Config.Priority
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return priority[candidates[i].ModelName] < priority[candidates[j].ModelName]
+		})
+	}
+	return candidates, pinned
+}
+
+// releaseToleranceMB 判断读数不变时允许的波动(MB)
+const releaseToleranceMB = 16
+
+// waitForRelease 停止模型后轮询可用量，直到读数连续EVICTION_STABLE_POLLS次不变
+// （expectIncrease时还要求比停止前增加）或超过EVICTION_RELEASE_TIMEOUT，返回最后的读数
+func (s *ModelService) waitForRelease(available func() (int, error), before int, expectIncrease bool) (int, error) {
+	interval := time.Duration(s.config.Eviction.PollIntervalMS) * time.Millisecond
+	deadline := time.Now().Add(time.Duration(s.config.Eviction.ReleaseTimeout) * time.Second)
+	stablePolls := max(s.config.Eviction.StablePolls, 1)
+
+	last, stable := 0, 0
+	var lastErr error
+	for {
+		time.Sleep(interval)
+		current, err := available()
+		if err != nil {
+			lastErr = err
+		} else {
+			if stable > 0 && abs(current-last) <= releaseToleranceMB {
+				stable++
+			} else {
+				stable = 1
+			}
+			last = current
+			if stable >= stablePolls && (!expectIncrease || current > before) {
+				return current, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			if stable == 0 {
+				return 0, lastErr
+			}
+			return last, nil
+		}
+	}
+}
+
+// abs 整数绝对值
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestEvictionOrder(t *testing.T) {
+	cfg := &config.Config{}
+	s := &ModelService{
+		config:  cfg,
+		tracker: NewRequestTracker(0, time.Second),
+		configs: make(map[string]*model.ModelConfig),
+	}
+	var running []*model.ModelStatus
+	for _, m := range []struct {
+		name     string
+		vram     int
+		priority int
+		pinned   bool
+	}{
+		{"embed", 9000, -1, true},
+		{"large", 8000, 2, false},
+		{"medium", 4000, -1, false},
+		{"small", 1000, 0, false},
+	} {
+		running = append(running, &model.ModelStatus{ModelName: m.name, VRAMUsage: m.vram, Running: true, Pinned: m.pinned})
+		c := &model.ModelConfig{ModelName: m.name, Pinned: m.pinned}
+		c.Config.Priority = m.priority
+		s.setRunningConfig(m.name, c)
+	}
+	// small最近处理过请求，large更早，medium从未处理过请求
+	for _, name := range []string{"large", "small"} {
+		release, err := s.tracker.Acquire(context.Background(), name)
+		if err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+		release()
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	tests := []struct {
+		policy string
+		want   []string
+	}{
+		{EvictionLargest, []string{"large", "medium", "small"}},
+		{EvictionLRU, []string{"medium", "large", "small"}},
+		{EvictionPriority, []string{"medium", "small", "large"}},
+	}
+	for _, tt := range tests {
+		cfg.Eviction.Policy = tt.policy
+		models, pinned := s.evictionOrder(running)
+		names := make([]string, len(models))
+		for i, m := range models {
+			names[i] = m.ModelName
+		}
+		if !slices.Equal(names, tt.want) {
+			t.Errorf("%s eviction order = %v, want %v", tt.policy, names, tt.want)
+		}
+		if !slices.Equal(pinned, []string{"embed"}) {
+			t.Errorf("%s pinned models = %v, want [embed]", tt.policy, pinned)
+		}
+	}
+}
+
+func TestWaitForRelease(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Eviction.PollIntervalMS = 1
+	cfg.Eviction.StablePolls = 3
+	cfg.Eviction.ReleaseTimeout = 1
+	s := &ModelService{config: cfg}
+
+	readings := func(values ...int) func() (int, error) {
+		i := 0
+		return func() (int, error) {
+			v := values[min(i, len(values)-1)]
+			i++
+			return v, nil
+		}
+	}
+
+	// 释放逐步完成，连续3次读数相差不超过16MB时返回最后的读数
+	got, err := s.waitForRelease(readings(1000, 1000, 3000, 5990, 6000, 6005, 9000), 1000, true)
+	if err != nil || got != 6005 {
+		t.Errorf("waitForRelease = %d, %v, want 6005", got, err)
+	}
+
+	// 未使用GPU的模型：读数稳定即返回
+	start := time.Now()
+	if got, _ := s.waitForRelease(readings(1000), 1000, false); got != 1000 || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("waitForRelease without increase = %d after %v", got, time.Since(start))
+	}
+
+	// 读数一直没有增加时等待到超时
+	start = time.Now()
+	if got, _ := s.waitForRelease(readings(1000), 1000, true); got != 1000 || time.Since(start) < time.Second {
+		t.Errorf("waitForRelease returned %d after %v, want timeout", got, time.Since(start))
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"log/slog"
+	"slices"
+
+	"llama-switch/internal/model"
+)
+
+// gpusOverlap 检查两组GPU是否有重叠，为空表示使用所有GPU
+func gpusOverlap(a, b []int) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	for _, gpu := range a {
+		if slices.Contains(b, gpu) {
+			return true
+		}
+	}
+	return false
+}
+
+// modelsOnGPUs 获取使用指定GPU的运行中模型，未自动放置的模型视为使用所有GPU
+func (s *ModelService) modelsOnGPUs(gpus []int) []*model.ModelStatus {
+	var models []*model.ModelStatus
+	for _, m := range s.processManager.GetRunningModels() {
+		if gpusOverlap(m.GPUs, gpus) {
+			models = append(models, m)
+		}
+	}
+	return models
+}
+
+// StopModelsOnGPUs 停止使用指定GPU的运行中模型（包括固定模型），返回它们的启动配置以便之后重新启动
+func (s *ModelService) StopModelsOnGPUs(gpus []int) []*model.ModelConfig {
+	var stopped []*model.ModelConfig
+	for _, m := range s.modelsOnGPUs(gpus) {
+		cfg := s.runningConfig(m.ModelName)
+		if _, err := s.StopModel(m.ModelName); err != nil {
+			slog.Warn("Failed to stop model for exclusive GPU access", "model_name", m.ModelName, "error", err)
+			continue
+		}
+		slog.Info("Stopped model for exclusive GPU access", "model_name", m.ModelName)
+		stopped = append(stopped, cfg)
+	}
+	return stopped
+}
+
+// RestartModels 重新启动之前停止的模型
+func (s *ModelService) RestartModels(configs []*model.ModelConfig) {
+	for _, cfg := range configs {
+		if _, err := s.StartModel(cfg); err != nil {
+			slog.Warn("Failed to restart model after exclusive GPU access", "model_name", cfg.ModelName, "error", err)
+			continue
+		}
+		slog.Info("Restarted model after exclusive GPU access", "model_name", cfg.ModelName)
+	}
+}
+
+// PauseModelsOnGPUs 暂停使用指定GPU的运行中模型进程，返回被暂停的模型名称；
+// 暂停的模型仍占用显存，但不再与基准测试争用GPU算力，期间跳过健康检查
+func (s *ModelService) PauseModelsOnGPUs(gpus []int) []string {
+	var paused []string
+	for _, m := range s.modelsOnGPUs(gpus) {
+		// 先标记再暂停，避免健康检查在暂停期间将模型判定为卡死
+		s.setPaused(m.ModelName, true)
+		if err := suspendProcess(m.ProcessID); err != nil {
+			s.setPaused(m.ModelName, false)
+			slog.Warn("Failed to pause model for exclusive GPU access", "model_name", m.ModelName, "error", err)
+			continue
+		}
+		slog.Info("Paused model for exclusive GPU access", "model_name", m.ModelName)
+		paused = append(paused, m.ModelName)
+	}
+	return paused
+}
+
+// ResumeModels 继续执行之前暂停的模型进程
+func (s *ModelService) ResumeModels(names []string) {
+	for _, name := range names {
+		if m := s.processManager.FindModel(name); m != nil {
+			if err := resumeProcess(m.ProcessID); err != nil {
+				slog.Warn("Failed to resume model after exclusive GPU access", "model_name", name, "error", err)
+			} else {
+				slog.Info("Resumed model after exclusive GPU access", "model_name", name)
+			}
+		}
+		s.setPaused(name, false)
+	}
+}
+
+// setPaused 设置模型的暂停标记
+func (s *ModelService) setPaused(name string, paused bool) {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	if paused {
+		s.paused[name] = true
+	} else {
+		delete(s.paused, name)
+	}
+}
+
+// isPaused 检查模型是否因基准测试独占GPU而暂停
+func (s *ModelService) isPaused(name string) bool {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.paused[name]
+}
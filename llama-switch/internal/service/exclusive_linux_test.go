@@ -0,0 +1,55 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+// waitStopped 等待进程进入（或离开）停止状态，返回最后读取到的/proc状态字符
+func waitStopped(t *testing.T, pid int, stopped bool) string {
+	var state string
+	for range 50 {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			t.Fatalf("read process stat: %v", err)
+		}
+		state = strings.Fields(string(data)[strings.LastIndex(string(data), ")")+1:])[0]
+		if (state == "T") == stopped {
+			return state
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return state
+}
+
+func TestPauseModelsOnGPUs(t *testing.T) {
+	pm := NewProcessManager()
+	if err := pm.StartProcess("sleep", []string{"30"}, ProcessOptions{Output: io.Discard}); err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	pid := pm.GetPID()
+	defer pm.StopProcess()
+
+	s := &ModelService{config: &config.Config{}, processManager: pm, paused: make(map[string]bool)}
+	pm.AddModel(pid, &model.ModelStatus{ModelName: "chat", ProcessID: pid, Running: true, GPUs: []int{1}})
+
+	if paused := s.PauseModelsOnGPUs([]int{0}); len(paused) != 0 {
+		t.Fatalf("paused %v on GPU 0, want none", paused)
+	}
+	paused := s.PauseModelsOnGPUs([]int{1, 2})
+	if state := waitStopped(t, pid, true); len(paused) != 1 || !s.isPaused("chat") || state != "T" {
+		t.Fatalf("paused %v, state %s, want chat stopped", paused, state)
+	}
+
+	s.ResumeModels(paused)
+	if state := waitStopped(t, pid, false); s.isPaused("chat") || state == "T" {
+		t.Errorf("chat still paused after resume, state %s", state)
+	}
+}
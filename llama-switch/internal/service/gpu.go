@@ -0,0 +1,94 @@
+package service
+
+import (
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"llama-switch/internal/model"
+)
+
+// GPU厂商
+const (
+	GPUVendorNVIDIA = "nvidia"
+	GPUVendorAMD    = "amd"
+	GPUVendorIntel  = "intel"
+	GPUVendorApple  = "apple"
+)
+
+// GPUProvider 查询GPU显存的厂商工具
+type GPUProvider interface {
+	// Vendor GPU厂商
+	Vendor() string
+	// FreeMemory 获取每个GPU的可用显存(MB)
+	FreeMemory() ([]int, error)
+	// Devices 获取所有GPU的型号、显存、温度和使用率
+	Devices() ([]model.GPUInfo, error)
+	// ProcessMemory 获取各进程占用的显存(MB)，同一进程使用多个GPU时累加
+	ProcessMemory() (map[int]int, error)
+}
+
+// gpuVendorTools 各厂商的管理工具，工具存在于PATH中即视为检测到该厂商的GPU
+var gpuVendorTools = []struct {
+	vendor string
+	tool   string
+}{
+	{GPUVendorNVIDIA, "nvidia-smi"},
+	{GPUVendorAMD, "rocm-smi"},
+	{GPUVendorIntel, "xpu-smi"},
+}
+
+// DetectGPUVendors 检测本机可用的GPU厂商
+func DetectGPUVendors() []string {
+	vendors := make([]string, 0)
+	for _, t := range gpuVendorTools {
+		if _, err := exec.LookPath(t.tool); err == nil {
+			vendors = append(vendors, t.vendor)
+		}
+	}
+	// Apple Silicon通过Metal使用统一内存
+	if runtime.GOOS == "darwin" && runtime.GOARCH == "arm64" {
+		vendors = append(vendors, GPUVendorApple)
+	}
+	return vendors
+}
+
+// newGPUProvider 创建GPU_PROVIDER指定厂商的显存查询工具
+// auto使用第一个检测到的厂商，都未检测到时使用nvidia-smi
+func newGPUProvider(vendor string) GPUProvider {
+	if vendor == "" || vendor == "auto" {
+		vendor = GPUVendorNVIDIA
+		if detected := DetectGPUVendors(); len(detected) > 0 {
+			vendor = detected[0]
+		}
+	}
+	switch vendor {
+	case GPUVendorAMD:
+		return amdProvider{}
+	case GPUVendorIntel:
+		return intelProvider{}
+	case GPUVendorApple:
+		return &appleProvider{}
+	default:
+		return nvidiaProvider{}
+	}
+}
+
+// freeMemoryOf 提取每个GPU的可用显存(MB)
+func freeMemoryOf(devices []model.GPUInfo) []int {
+	free := make([]int, len(devices))
+	for i, d := range devices {
+		free[i] = d.MemoryFreeMB
+	}
+	return free
+}
+
+// optionalFloat 解析GPU工具输出的数值，不支持的字段（如[N/A]）返回nil
+func optionalFloat(s string) *float64 {
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"llama-switch/internal/model"
+)
+
+// amdProvider 通过rocm-smi查询AMD GPU显存
+type amdProvider struct{}
+
+// Vendor GPU厂商
+func (amdProvider) Vendor() string {
+	return GPUVendorAMD
+}
+
+// FreeMemory 获取每个GPU的可用显存(MB)
+func (amdProvider) FreeMemory() ([]int, error) {
+	output, err := exec.Command("rocm-smi", "--showmeminfo", "vram", "--json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GPU memory: %v", err)
+	}
+	devices, err := parseROCmDevices(output)
+	if err != nil {
+		return nil, err
+	}
+	return freeMemoryOf(devices), nil
+}
+
+// Devices 获取所有GPU的型号、显存、温度和使用率
+func (amdProvider) Devices() ([]model.GPUInfo, error) {
+	output, err := exec.Command("rocm-smi", "--showproductname", "--showmeminfo", "vram", "--showtemp", "--showuse", "--showpower", "--json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GPU devices: %v", err)
+	}
+	return parseROCmDevices(output)
+}
+
+// ProcessMemory 通过rocm-smi查询各进程占用的显存(MB)
+func (amdProvider) ProcessMemory() (map[int]int, error) {
+	output, err := exec.Command("rocm-smi", "--showpids", "--json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GPU processes: %v", err)
+	}
+	return parseROCmPids(output)
+}
+
+// parseROCmDevices 解析rocm-smi --json的输出，按card编号排序
+func parseROCmDevices(output []byte) ([]model.GPUInfo, error) {
+	var cards map[string]map[string]string
+	if err := json.Unmarshal(output, &cards); err != nil {
+		return nil, fmt.Errorf("failed to parse rocm-smi output: %v", err)
+	}
+
+	var devices []model.GPUInfo
+	for name, info := range cards {
+		index, err := strconv.Atoi(strings.TrimPrefix(name, "card"))
+		if err != nil {
+			continue // 非GPU条目（如system）
+		}
+		total, err := strconv.ParseInt(info["VRAM Total Memory (B)"], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse GPU memory of %s: %v", name, err)
+		}
+		used, err := strconv.ParseInt(info["VRAM Total Used Memory (B)"], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse GPU memory of %s: %v", name, err)
+		}
+		device := model.GPUInfo{
+			Index:         index,
+			MemoryTotalMB: int(total / (1024 * 1024)),
+			MemoryUsedMB:  int(used / (1024 * 1024)),
+			MemoryFreeMB:  int((total - used) / (1024 * 1024)),
+			Utilization:   optionalFloat(info["GPU use (%)"]),
+		}
+		// 字段名随rocm-smi版本变化（如Card series/Card Series）
+		for key, value := range info {
+			switch {
+			case strings.EqualFold(key, "Card series"):
+				device.Name = value
+			case strings.HasPrefix(key, "Temperature (Sensor edge)"):
+				device.TemperatureC = optionalFloat(value)
+			case strings.HasSuffix(key, "Graphics Package Power (W)"):
+				device.PowerW = optionalFloat(value)
+			}
+		}
+		devices = append(devices, device)
+	}
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("no GPU memory information available")
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Index < devices[j].Index })
+	return devices, nil
+}
+
+// parseROCmPids 解析rocm-smi --showpids --json的输出
+// 每个进程的值为"名称, GPU数, 显存字节数, SDMA字节数, CU占用"
+func parseROCmPids(output []byte) (map[int]int, error) {
+	var result struct {
+		System map[string]string `json:"system"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse rocm-smi output: %v", err)
+	}
+
+	usage := make(map[int]int)
+	for key, value := range result.System {
+		pid, err := strconv.Atoi(strings.TrimPrefix(key, "PID"))
+		if err != nil {
+			continue
+		}
+		fields := strings.Split(value, ",")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("unexpected GPU process entry: %q", value)
+		}
+		bytes, err := strconv.ParseInt(strings.TrimSpace(fields[2]), 10, 64)
+		if err != nil {
+			continue
+		}
+		usage[pid] += int(bytes / (1024 * 1024))
+	}
+	return usage, nil
+}
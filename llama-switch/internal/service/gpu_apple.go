@@ -0,0 +1,122 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"llama-switch/internal/model"
+)
+
+// appleProvider 查询Apple Silicon上Metal可用的统一内存
+type appleProvider struct {
+	once   sync.Once
+	name   string // system_profiler报告的GPU型号
+	gpuErr error  // system_profiler未报告支持Metal的GPU
+}
+
+// Vendor GPU厂商
+func (*appleProvider) Vendor() string {
+	return GPUVendorApple
+}
+
+// FreeMemory 获取Metal可用的内存(MB)
+func (p *appleProvider) FreeMemory() ([]int, error) {
+	devices, err := p.Devices()
+	if err != nil {
+		return nil, err
+	}
+	return freeMemoryOf(devices), nil
+}
+
+// Devices 获取Metal GPU的状态：总量为物理内存的3/4（Metal默认的工作集上限），
+// 可用量为空闲和非活跃内存之和且不超过总量；统一内存无法获取GPU温度和使用率
+func (p *appleProvider) Devices() ([]model.GPUInfo, error) {
+	// system_profiler较慢，GPU信息只查询一次
+	p.once.Do(func() {
+		output, err := exec.Command("system_profiler", "SPDisplaysDataType", "-json").Output()
+		if err != nil {
+			p.gpuErr = fmt.Errorf("failed to query GPU devices: %v", err)
+			return
+		}
+		p.name, p.gpuErr = parseMetalGPU(output)
+	})
+	if p.gpuErr != nil {
+		return nil, p.gpuErr
+	}
+
+	memsize, err := exec.Command("sysctl", "-n", "hw.memsize").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query physical memory: %v", err)
+	}
+	totalBytes, err := strconv.ParseInt(strings.TrimSpace(string(memsize)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse physical memory: %v", err)
+	}
+	vmStat, err := exec.Command("vm_stat").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GPU memory: %v", err)
+	}
+	availableBytes, err := parseVMStatAvailable(string(vmStat))
+	if err != nil {
+		return nil, err
+	}
+
+	budget := int(totalBytes * 3 / 4 / (1024 * 1024))
+	free := min(int(availableBytes/(1024*1024)), budget)
+	return []model.GPUInfo{{
+		Name:          p.name,
+		MemoryTotalMB: budget,
+		MemoryUsedMB:  budget - free,
+		MemoryFreeMB:  free,
+	}}, nil
+}
+
+// ProcessMemory 统一内存无法区分进程的GPU占用
+func (*appleProvider) ProcessMemory() (map[int]int, error) {
+	return nil, fmt.Errorf("per-process GPU memory is not supported on Apple Silicon")
+}
+
+// parseMetalGPU 从system_profiler SPDisplaysDataType -json的输出中查找支持Metal的GPU，返回其型号
+func parseMetalGPU(output []byte) (string, error) {
+	var result struct {
+		Displays []struct {
+			Model string `json:"sppci_model"`
+			Metal string `json:"spdisplays_mtlgpufamilysupport"`
+		} `json:"SPDisplaysDataType"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", fmt.Errorf("failed to parse system_profiler output: %v", err)
+	}
+	for _, d := range result.Displays {
+		if d.Metal != "" {
+			return d.Model, nil
+		}
+	}
+	return "", fmt.Errorf("no Metal GPU reported by system_profiler")
+}
+
+var (
+	vmStatPageSize = regexp.MustCompile(`page size of (\d+) bytes`)
+	vmStatPages    = regexp.MustCompile(`(?m)^Pages (free|inactive|speculative):\s+(\d+)\.`)
+)
+
+// parseVMStatAvailable 解析vm_stat输出，返回空闲、非活跃和预读页的字节数之和
+func parseVMStatAvailable(output string) (int64, error) {
+	match := vmStatPageSize.FindStringSubmatch(output)
+	if match == nil {
+		return 0, fmt.Errorf("unexpected vm_stat output")
+	}
+	pageSize, _ := strconv.ParseInt(match[1], 10, 64)
+
+	var pages int64
+	for _, m := range vmStatPages.FindAllStringSubmatch(output, -1) {
+		n, _ := strconv.ParseInt(m[2], 10, 64)
+		pages += n
+	}
+	return pages * pageSize, nil
+}
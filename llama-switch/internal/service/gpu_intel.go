@@ -0,0 +1,127 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+
+	"llama-switch/internal/model"
+)
+
+// intelProvider 通过xpu-smi查询Intel GPU显存
+type intelProvider struct{}
+
+// Vendor GPU厂商
+func (intelProvider) Vendor() string {
+	return GPUVendorIntel
+}
+
+// FreeMemory 获取每个GPU的可用显存(MB)
+func (p intelProvider) FreeMemory() ([]int, error) {
+	devices, err := p.Devices()
+	if err != nil {
+		return nil, err
+	}
+	return freeMemoryOf(devices), nil
+}
+
+// Devices 获取所有GPU的状态：型号和总显存来自xpu-smi discovery，已用显存、温度和使用率来自xpu-smi stats
+func (intelProvider) Devices() ([]model.GPUInfo, error) {
+	output, err := exec.Command("xpu-smi", "discovery", "-j").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GPU devices: %v", err)
+	}
+	list, err := parseXPUDeviceList(output)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]model.GPUInfo, 0, len(list))
+	for _, device := range list {
+		id := strconv.Itoa(device.Index)
+		info, err := exec.Command("xpu-smi", "discovery", "-d", id, "-j").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to query GPU %d: %v", device.Index, err)
+		}
+		stats, err := exec.Command("xpu-smi", "stats", "-d", id, "-j").Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to query GPU memory of GPU %d: %v", device.Index, err)
+		}
+		if err := parseXPUDevice(&device, info, stats); err != nil {
+			return nil, fmt.Errorf("GPU %d: %v", device.Index, err)
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
+
+// ProcessMemory xpu-smi不提供可靠的按进程显存统计
+func (intelProvider) ProcessMemory() (map[int]int, error) {
+	return nil, fmt.Errorf("per-process GPU memory is not supported by xpu-smi")
+}
+
+// parseXPUDeviceList 解析xpu-smi discovery -j输出的设备编号和型号
+func parseXPUDeviceList(output []byte) ([]model.GPUInfo, error) {
+	var result struct {
+		DeviceList []struct {
+			DeviceID   int    `json:"device_id"`
+			DeviceName string `json:"device_name"`
+		} `json:"device_list"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse xpu-smi output: %v", err)
+	}
+	if len(result.DeviceList) == 0 {
+		return nil, fmt.Errorf("no GPU memory information available")
+	}
+	devices := make([]model.GPUInfo, len(result.DeviceList))
+	for i, d := range result.DeviceList {
+		devices[i] = model.GPUInfo{Index: d.DeviceID, Name: d.DeviceName}
+	}
+	return devices, nil
+}
+
+// parseXPUDevice 根据单个设备的discovery（总显存字节数）和stats（已用显存MiB、温度、使用率）输出填充设备状态
+func parseXPUDevice(device *model.GPUInfo, discovery, stats []byte) error {
+	var info struct {
+		MemoryPhysicalSize string `json:"memory_physical_size_byte"`
+	}
+	if err := json.Unmarshal(discovery, &info); err != nil {
+		return fmt.Errorf("failed to parse xpu-smi output: %v", err)
+	}
+	total, err := strconv.ParseInt(info.MemoryPhysicalSize, 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse GPU memory size: %v", err)
+	}
+
+	var metrics struct {
+		DeviceLevel []struct {
+			MetricsType string  `json:"metrics_type"`
+			Value       float64 `json:"value"`
+		} `json:"device_level"`
+	}
+	if err := json.Unmarshal(stats, &metrics); err != nil {
+		return fmt.Errorf("failed to parse xpu-smi output: %v", err)
+	}
+
+	device.MemoryTotalMB = int(total / (1024 * 1024))
+	usedFound := false
+	for _, m := range metrics.DeviceLevel {
+		value := m.Value
+		switch m.MetricsType {
+		case "XPUM_STATS_MEMORY_USED":
+			device.MemoryUsedMB = int(value)
+			usedFound = true
+		case "XPUM_STATS_GPU_UTILIZATION":
+			device.Utilization = &value
+		case "XPUM_STATS_GPU_CORE_TEMPERATURE":
+			device.TemperatureC = &value
+		}
+	}
+	if !usedFound {
+		return fmt.Errorf("xpu-smi did not report memory usage")
+	}
+	device.MemoryFreeMB = device.MemoryTotalMB - device.MemoryUsedMB
+	return nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// collectGPUInventory 查询所有GPU的状态，并列出使用各GPU的受管模型：
+// 自动放置的模型归属分配的GPU，其余有GPU层的模型归属所有GPU
+func (s *ModelService) collectGPUInventory() (*model.GPUInventory, error) {
+	devices, err := s.gpu.Devices()
+	if err != nil {
+		return nil, err
+	}
+	for i := range devices {
+		devices[i].Models = make([]string, 0)
+	}
+
+	for _, m := range s.processManager.GetRunningModels() {
+		if len(m.GPUs) > 0 {
+			for _, gpu := range m.GPUs {
+				for i := range devices {
+					if devices[i].Index == gpu {
+						devices[i].Models = append(devices[i].Models, m.ModelName)
+					}
+				}
+			}
+			continue
+		}
+		if s.runningConfig(m.ModelName).Config.NGPULayers <= 0 {
+			continue
+		}
+		for i := range devices {
+			devices[i].Models = append(devices[i].Models, m.ModelName)
+		}
+	}
+
+	return &model.GPUInventory{
+		Provider:  s.gpu.Vendor(),
+		SampledAt: time.Now().Format(time.RFC3339),
+		GPUs:      devices,
+	}, nil
+}
+
+// GPUInventory 获取GPU清单：启用资源采样时返回最近一次采样结果，否则实时查询
+func (s *ModelService) GPUInventory() (*model.GPUInventory, error) {
+	if inventory := s.resources.gpuInventory(); inventory != nil {
+		return inventory, nil
+	}
+	return s.collectGPUInventory()
+}
+
+// EventVRAMLow GPU可用显存低于VRAM_LOW_MB
+const EventVRAMLow = "vram_low"
+
+// sampleGPUs 采样GPU清单并检查持续降频和可用显存，查询失败时保留上一次的结果
+func (r *ResourceSampler) sampleGPUs() {
+	inventory, err := r.service.collectGPUInventory()
+
+	r.mu.Lock()
+	if err != nil {
+		if !r.devicesErr {
+			slog.Warn("GPU inventory unavailable", "error", err)
+		}
+		r.devicesErr = true
+		r.mu.Unlock()
+		return
+	}
+	r.devicesErr = false
+	r.inventory = inventory
+	r.mu.Unlock()
+
+	r.service.observeThermal(inventory.GPUs)
+	r.observeVRAMLow(inventory.GPUs)
+}
+
+// observeVRAMLow 检查各GPU的可用显存，低于阈值时记录事件并通知订阅方一次，恢复后重新检测
+func (r *ResourceSampler) observeVRAMLow(devices []model.GPUInfo) {
+	threshold := int(r.service.config.Resources.VRAMLowMB)
+	if threshold <= 0 {
+		return
+	}
+
+	var low []model.GPUInfo
+	r.mu.Lock()
+	for _, gpu := range devices {
+		if gpu.MemoryTotalMB <= 0 || gpu.MemoryFreeMB >= threshold {
+			delete(r.vramLow, gpu.Index)
+			continue
+		}
+		if !r.vramLow[gpu.Index] {
+			r.vramLow[gpu.Index] = true
+			low = append(low, gpu)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, gpu := range low {
+		r.service.events.Record(EventVRAMLow, "", fmt.Sprintf("GPU %d (%s) has %dMB of %dMB VRAM free, below %dMB (models: %s)",
+			gpu.Index, gpu.Name, gpu.MemoryFreeMB, gpu.MemoryTotalMB, threshold, modelList(gpu.Models)), gpu)
+		r.service.bus.Publish(WebhookVRAMLow, map[string]interface{}{
+			"gpu":          gpu,
+			"threshold_mb": threshold,
+		})
+	}
+}
+
+// gpuInventory 获取最近一次采样的GPU清单
+func (r *ResourceSampler) gpuInventory() *model.GPUInventory {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.inventory
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"llama-switch/internal/model"
+)
+
+// nvidiaProvider 通过nvidia-smi查询NVIDIA GPU显存
+type nvidiaProvider struct{}
+
+// Vendor GPU厂商
+func (nvidiaProvider) Vendor() string {
+	return GPUVendorNVIDIA
+}
+
+// FreeMemory 获取每个GPU的可用显存(MB)
+func (nvidiaProvider) FreeMemory() ([]int, error) {
+	cmd := exec.Command("nvidia-smi", "--query-gpu=memory.free", "--format=csv,noheader,nounits")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GPU memory: %v", err)
+	}
+
+	// 解析输出，获取所有GPU的可用显存
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("no GPU memory information available")
+	}
+
+	var freeMemory []int
+	for _, line := range lines {
+		freeMB, err := strconv.Atoi(strings.TrimSpace(line))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse GPU memory: %v", err)
+		}
+		freeMemory = append(freeMemory, freeMB)
+	}
+
+	return freeMemory, nil
+}
+
+// nvidiaThrottleReasons 查询的降频原因字段，值为Active/Not Active
+var nvidiaThrottleReasons = []string{"hw_slowdown", "hw_thermal_slowdown", "hw_power_brake_slowdown", "sw_thermal_slowdown", "sw_power_cap"}
+
+// Devices 获取所有GPU的型号、显存、温度、使用率、功耗和降频原因
+func (nvidiaProvider) Devices() ([]model.GPUInfo, error) {
+	fields := "index,name,memory.total,memory.used,memory.free,temperature.gpu,utilization.gpu,power.draw,power.limit"
+	for _, reason := range nvidiaThrottleReasons {
+		fields += ",clocks_throttle_reasons." + reason
+	}
+	cmd := exec.Command("nvidia-smi", "--query-gpu="+fields, "--format=csv,noheader,nounits")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GPU devices: %v", err)
+	}
+	return parseNvidiaDevices(string(output))
+}
+
+// parseNvidiaDevices 解析nvidia-smi --query-gpu的输出，不支持的字段为[N/A]
+func parseNvidiaDevices(output string) ([]model.GPUInfo, error) {
+	var devices []model.GPUInfo
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) != 9+len(nvidiaThrottleReasons) {
+			return nil, fmt.Errorf("unexpected GPU line: %q", line)
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		index, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse GPU index: %v", err)
+		}
+		memory := make([]int, 3)
+		for i := range memory {
+			if memory[i], err = strconv.Atoi(fields[2+i]); err != nil {
+				return nil, fmt.Errorf("failed to parse GPU memory: %v", err)
+			}
+		}
+		device := model.GPUInfo{
+			Index:         index,
+			Name:          fields[1],
+			MemoryTotalMB: memory[0],
+			MemoryUsedMB:  memory[1],
+			MemoryFreeMB:  memory[2],
+			TemperatureC:  optionalFloat(fields[5]),
+			Utilization:   optionalFloat(fields[6]),
+			PowerW:        optionalFloat(fields[7]),
+			PowerLimitW:   optionalFloat(fields[8]),
+		}
+		for i, reason := range nvidiaThrottleReasons {
+			if fields[9+i] == "Active" {
+				device.ThrottleReasons = append(device.ThrottleReasons, reason)
+			}
+		}
+		devices = append(devices, device)
+	}
+	if len(devices) == 0 {
+		return nil, fmt.Errorf("no GPU memory information available")
+	}
+	return devices, nil
+}
+
+// ProcessMemory 通过nvidia-smi查询各进程占用的显存(MB)
+func (nvidiaProvider) ProcessMemory() (map[int]int, error) {
+	cmd := exec.Command("nvidia-smi", "--query-compute-apps=pid,used_memory", "--format=csv,noheader,nounits")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GPU processes: %v", err)
+	}
+	return parseGPUProcessMemory(string(output))
+}
+
+// parseGPUProcessMemory 解析nvidia-smi的计算进程列表，同一进程使用多个GPU时累加
+func parseGPUProcessMemory(output string) (map[int]int, error) {
+	usage := make(map[int]int)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("unexpected GPU process line: %q", line)
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse GPU process pid: %v", err)
+		}
+		mb, err := strconv.Atoi(strings.TrimSpace(fields[1]))
+		if err != nil {
+			// 无权限查询时nvidia-smi输出[N/A]
+			continue
+		}
+		usage[pid] += mb
+	}
+	return usage, nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestParseNvidiaDevices(t *testing.T) {
+	output := "0, NVIDIA GeForce RTX 4090, 24564, 20480, 4084, 88, 97, 431.20, 450.00, Not Active, Active, Not Active, Not Active, Not Active\n" +
+		"1, NVIDIA GeForce RTX 3090, 24576, 0, 24576, [N/A], [N/A], [N/A], [N/A], [N/A], [N/A], [N/A], [N/A], [N/A]\n"
+	devices, err := parseNvidiaDevices(output)
+	if err != nil {
+		t.Fatalf("parseNvidiaDevices failed: %v", err)
+	}
+	if len(devices) != 2 || devices[0].Name != "NVIDIA GeForce RTX 4090" || devices[0].MemoryFreeMB != 4084 {
+		t.Fatalf("unexpected devices: %+v", devices)
+	}
+	if devices[0].TemperatureC == nil || *devices[0].TemperatureC != 88 || devices[0].Utilization == nil || *devices[0].Utilization != 97 {
+		t.Errorf("unexpected temperature/utilization: %+v", devices[0])
+	}
+	if devices[0].PowerW == nil || *devices[0].PowerW != 431.2 || !reflect.DeepEqual(devices[0].ThrottleReasons, []string{"hw_thermal_slowdown"}) {
+		t.Errorf("unexpected power/throttle reasons: %+v", devices[0])
+	}
+	if devices[1].Index != 1 || devices[1].TemperatureC != nil || devices[1].Utilization != nil || devices[1].PowerW != nil || devices[1].ThrottleReasons != nil {
+		t.Errorf("expected unsupported fields to be omitted: %+v", devices[1])
+	}
+}
+
+func TestParseROCmDevices(t *testing.T) {
+	output := []byte(`{
+		"card1": {"VRAM Total Memory (B)": "17163091968", "VRAM Total Used Memory (B)": "1073741824", "Card Series": "Navi 21 [Radeon RX 6800]"},
+		"card0": {"VRAM Total Memory (B)": "25753026560", "VRAM Total Used Memory (B)": "10485760", "Temperature (Sensor edge) (C)": "45.0", "GPU use (%)": "3"},
+		"system": {"Driver version": "6.7.0"}
+	}`)
+	devices, err := parseROCmDevices(output)
+	if err != nil {
+		t.Fatalf("parseROCmDevices failed: %v", err)
+	}
+	if want := []int{24550, 15344}; !reflect.DeepEqual(freeMemoryOf(devices), want) {
+		t.Errorf("free memory = %v, want %v", freeMemoryOf(devices), want)
+	}
+	if devices[1].Name != "Navi 21 [Radeon RX 6800]" || *devices[0].TemperatureC != 45 || *devices[0].Utilization != 3 {
+		t.Errorf("unexpected device details: %+v", devices)
+	}
+	if _, err := parseROCmDevices([]byte(`{}`)); err == nil {
+		t.Error("expected error when no GPU is reported")
+	}
+}
+
+func TestParseROCmPids(t *testing.T) {
+	output := []byte(`{"system": {"PID1234": "llama-server, 2, 4294967296, 0, unknown", "PID5678": "python, 1, 0, 0, unknown"}}`)
+	usage, err := parseROCmPids(output)
+	if err != nil {
+		t.Fatalf("parseROCmPids failed: %v", err)
+	}
+	if want := map[int]int{1234: 4096, 5678: 0}; !reflect.DeepEqual(usage, want) {
+		t.Errorf("usage = %v, want %v", usage, want)
+	}
+}
+
+func TestParseXPUDevices(t *testing.T) {
+	devices, err := parseXPUDeviceList([]byte(`{"device_list": [{"device_id": 0, "device_name": "Intel(R) Arc(TM) A770 Graphics"}, {"device_id": 1}]}`))
+	if err != nil || len(devices) != 2 || devices[1].Index != 1 || devices[0].Name != "Intel(R) Arc(TM) A770 Graphics" {
+		t.Fatalf("unexpected device list: %+v, %v", devices, err)
+	}
+
+	discovery := []byte(`{"device_id": 0, "memory_physical_size_byte": "17079205888"}`)
+	stats := []byte(`{"device_id": 0, "device_level": [
+		{"metrics_type": "XPUM_STATS_GPU_UTILIZATION", "value": 12},
+		{"metrics_type": "XPUM_STATS_MEMORY_USED", "value": 4096}
+	]}`)
+	device := &devices[0]
+	if err := parseXPUDevice(device, discovery, stats); err != nil {
+		t.Fatalf("parseXPUDevice failed: %v", err)
+	}
+	if device.MemoryTotalMB != 16288 || device.MemoryFreeMB != 16288-4096 || *device.Utilization != 12 || device.TemperatureC != nil {
+		t.Errorf("unexpected device: %+v", device)
+	}
+}
+
+func TestParseVMStatAvailable(t *testing.T) {
+	output := `Mach Virtual Memory Statistics: (page size of 16384 bytes)
+Pages free:                               10000.
+Pages active:                            500000.
+Pages inactive:                           20000.
+Pages speculative:                         2000.
+Pages throttled:                              0.
+`
+	available, err := parseVMStatAvailable(output)
+	if err != nil {
+		t.Fatalf("parseVMStatAvailable failed: %v", err)
+	}
+	if want := int64(32000 * 16384); available != want {
+		t.Errorf("available = %d, want %d", available, want)
+	}
+
+	name, err := parseMetalGPU([]byte(`{"SPDisplaysDataType": [{"sppci_model": "Apple M2 Max", "spdisplays_mtlgpufamilysupport": "spdisplays_metal3"}]}`))
+	if err != nil || name != "Apple M2 Max" {
+		t.Errorf("parseMetalGPU = %q, %v, want Apple M2 Max", name, err)
+	}
+	if _, err := parseMetalGPU([]byte(`{"SPDisplaysDataType": []}`)); err == nil {
+		t.Error("expected error when no Metal GPU is reported")
+	}
+}
+
+func TestBestFitGPU(t *testing.T) {
+	tests := []struct {
+		free     []int
+		required int
+		want     int
+	}{
+		{[]int{8000, 3000, 5000}, 2500, 1},
+		{[]int{8000, 3000, 5000}, 4000, 2},
+		{[]int{4000, 4000}, 2500, 0},
+		{[]int{2000, 2000}, 2500, -1},
+	}
+	for _, tt := range tests {
+		if got := bestFitGPU(tt.free, tt.required); got != tt.want {
+			t.Errorf("bestFitGPU(%v, %d) = %d, want %d", tt.free, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestAutoTensorSplit(t *testing.T) {
+	s := &ModelService{config: &config.Config{}, gpu: nvidiaProvider{}}
+	newConfig := func(split string) *model.ModelConfig {
+		cfg := &model.ModelConfig{}
+		cfg.Config.NGPULayers = 99
+		cfg.Config.TensorSplit = split
+		return cfg
+	}
+	free := []int{18000, 6000}
+
+	if split, auto := s.autoTensorSplit(newConfig(""), free, nil); auto || split != "" {
+		t.Errorf("Split without auto = %q, %v", split, auto)
+	}
+	if split, auto := s.autoTensorSplit(newConfig("auto"), free, nil); !auto || split != "18000,6000" {
+		t.Errorf("Split for auto request = %q, want 18000,6000", split)
+	}
+	// 已放置到单个GPU时不再分割
+	if split, auto := s.autoTensorSplit(newConfig("auto"), free, []int{0}); !auto || split != "" {
+		t.Errorf("Split for placed model = %q, want empty", split)
+	}
+
+	// 全局启用时只覆盖未指定tensor_split的请求
+	s.config.GPU.AutoTensorSplit = true
+	if split, _ := s.autoTensorSplit(newConfig(""), free, nil); split != "18000,6000" {
+		t.Errorf("Split with GPU_AUTO_TENSOR_SPLIT = %q, want 18000,6000", split)
+	}
+	if _, auto := s.autoTensorSplit(newConfig("3,1"), free, nil); auto {
+		t.Error("Manual tensor_split should be kept")
+	}
+	cfg := newConfig("")
+	cfg.Env = map[string]string{"CUDA_VISIBLE_DEVICES": "1"}
+	if split, _ := s.autoTensorSplit(cfg, free, nil); split != "" {
+		t.Errorf("Split with pinned devices = %q, want empty", split)
+	}
+}
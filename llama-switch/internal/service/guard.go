@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+)
+
+// slotProbeTimeout 查询llama-server插槽状态的超时时间
+const slotProbeTimeout = 2 * time.Second
+
+// ModelBusyError 模型正在使用时拒绝停止或驱逐的错误
+type ModelBusyError struct {
+	ModelName      string
+	ActiveSessions int       // 进行中的会话数
+	LastActive     time.Time // 最后一次请求活动时间
+	IdleWindow     time.Duration
+}
+
+func (e *ModelBusyError) Error() string {
+	if e.ActiveSessions > 0 {
+		return fmt.Sprintf("model '%s' has %d active sessions, use force=true to stop it anyway",
+			e.ModelName, e.ActiveSessions)
+	}
+	return fmt.Sprintf("model '%s' served requests %s ago (within the last %v), use force=true to stop it anyway",
+		e.ModelName, time.Since(e.LastActive).Round(time.Second), e.IdleWindow)
+}
+
+func (e *ModelBusyError) ErrorCode() apierror.Code { return apierror.CodeModelBusy }
+
+// CheckIdle 检查模型是否可以安全停止，正在使用时返回*ModelBusyError
+// 进行中的会话数取代理跟踪的请求数与llama-server报告的处理中插槽数的较大值，
+// 后者可以发现绕过代理直接访问实例的请求
+func (s *ModelService) CheckIdle(name string) error {
+	if !s.config.SwitchGuard.Enabled {
+		return nil
+	}
+
+	active, lastActive := s.tracker.Activity(name)
+	if processing := s.processingSlots(name); processing > active {
+		active = processing
+	}
+
+	window := time.Duration(s.config.SwitchGuard.IdleSeconds) * time.Second
+	if active > 0 || (!lastActive.IsZero() && time.Since(lastActive) < window) {
+		return &ModelBusyError{
+			ModelName:      name,
+			ActiveSessions: active,
+			LastActive:     lastActive,
+			IdleWindow:     window,
+		}
+	}
+	return nil
+}
+
+// DrainAndStopModel 排空进行中的请求后停止模型
+// 排空期间代理拒绝该模型的新请求，等待代理跟踪的请求和llama-server处理中的插槽全部结束，
+// 超过timeout仍未排空时直接停止
+func (s *ModelService) DrainAndStopModel(name string, timeout time.Duration) (*model.ModelStatus, error) {
+	if name == "" {
+		return nil, fmt.Errorf("model_name parameter is required")
+	}
+
+	start := time.Now()
+	if s.drain(name, timeout) {
+		slog.Info("Model drained", "model_name", name, "duration", time.Since(start).Round(time.Millisecond))
+	} else {
+		slog.Warn("Model not drained in time, stopping anyway", "model_name", name, "timeout", timeout)
+	}
+
+	status, err := s.StopModel(name)
+	if err != nil {
+		// 停止失败时恢复接受请求
+		s.tracker.SetDraining(name, false)
+		return nil, err
+	}
+	return status, nil
+}
+
+// drain 拒绝新请求并等待模型空闲，超时返回false
+func (s *ModelService) drain(name string, timeout time.Duration) bool {
+	s.tracker.SetDraining(name, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if _, err := s.tracker.WaitIdle(ctx, name); err != nil {
+		return false
+	}
+
+	// 绕过代理直接访问实例的请求只能通过插槽状态发现
+	for s.processingSlots(name) > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	return true
+}
+
+// processingSlots 查询模型实例正在处理请求的插槽数，实例未启用插槽端点或无法访问时返回0
+func (s *ModelService) processingSlots(name string) int {
+	backend, err := s.GetBackend(name)
+	if err != nil {
+		return 0
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), slotProbeTimeout)
+	defer cancel()
+
+	slots, err := backend.fetchSlots(ctx)
+	if err != nil {
+		return 0
+	}
+
+	processing := 0
+	for _, slot := range slots {
+		if slot.IsProcessing {
+			processing++
+		}
+	}
+	return processing
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"llama-switch/internal/config"
+)
+
+func TestCheckIdle(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.SwitchGuard.Enabled = true
+	cfg.SwitchGuard.IdleSeconds = 60
+	s := &ModelService{
+		config:         cfg,
+		processManager: NewProcessManager(),
+		tracker:        NewRequestTracker(0, time.Second),
+	}
+
+	if err := s.CheckIdle("chat"); err != nil {
+		t.Fatalf("Expected unused model to be idle, got %v", err)
+	}
+
+	release, err := s.tracker.Acquire(context.Background(), "chat")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	var busyErr *ModelBusyError
+	if err := s.CheckIdle("chat"); !errors.As(err, &busyErr) || busyErr.ActiveSessions != 1 {
+		t.Fatalf("Expected busy error with 1 active session, got %v", err)
+	}
+
+	// 请求结束后仍在空闲窗口内
+	release()
+	if err := s.CheckIdle("chat"); !errors.As(err, &busyErr) || busyErr.ActiveSessions != 0 {
+		t.Fatalf("Expected busy error for recently used model, got %v", err)
+	}
+
+	cfg.SwitchGuard.IdleSeconds = 0
+	if err := s.CheckIdle("chat"); err != nil {
+		t.Errorf("Expected idle with zero idle window, got %v", err)
+	}
+
+	cfg.SwitchGuard.Enabled = false
+	s.tracker.Acquire(context.Background(), "chat")
+	if err := s.CheckIdle("chat"); err != nil {
+		t.Errorf("Expected no error with guard disabled, got %v", err)
+	}
+}
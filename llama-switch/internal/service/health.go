@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// HealthMonitor 定期探测运行中模型实例的健康状态
+type HealthMonitor struct {
+	service *ModelService
+
+	mu         sync.RWMutex
+	states     map[string]*model.ModelHealth
+	recovering map[string]bool // 正在由看门狗处理的模型
+}
+
+// newHealthMonitor 创建健康检查器
+func newHealthMonitor(s *ModelService) *HealthMonitor {
+	return &HealthMonitor{
+		service:    s,
+		states:     make(map[string]*model.ModelHealth),
+		recovering: make(map[string]bool),
+	}
+}
+
+// StartHealthMonitor 启动后台健康检查，ctx取消时停止
+func (s *ModelService) StartHealthMonitor(ctx context.Context) {
+	interval := time.Duration(s.config.HealthCheck.Interval) * time.Second
+	if interval <= 0 {
+		slog.Info("Health checking is disabled")
+		return
+	}
+	go s.health.run(ctx, interval)
+}
+
+// run 按间隔探测所有运行中的模型实例
+func (m *HealthMonitor) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.CheckAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll 并发探测所有运行中的模型实例，并清除已停止模型的记录；
+// 因基准测试暂停的模型不探测，保留暂停前的状态
+func (m *HealthMonitor) CheckAll(ctx context.Context) {
+	names := m.service.GetRunningModelNames()
+	probed := make([]string, 0, len(names))
+	for _, name := range names {
+		if !m.service.isPaused(name) {
+			probed = append(probed, name)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range probed {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			m.record(name, m.service.checkHealth(ctx, name))
+		}(name)
+	}
+	wg.Wait()
+
+	running := make(map[string]bool, len(names))
+	for _, name := range names {
+		running[name] = true
+	}
+	m.mu.Lock()
+	for name := range m.states {
+		if !running[name] {
+			delete(m.states, name)
+		}
+	}
+	m.mu.Unlock()
+
+	m.watchStuck(probed)
+}
+
+// record 记录一次探测结果，并在状态变化时输出日志
+func (m *HealthMonitor) record(name string, result *model.ModelHealth) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prev := m.states[name]
+	if prev != nil {
+		result.LastHealthy = prev.LastHealthy
+		if result.Status == HealthUnhealthy {
+			result.ConsecutiveFailures = prev.ConsecutiveFailures + 1
+		}
+	}
+	if result.Status == HealthHealthy {
+		result.LastHealthy = result.LastCheck
+	}
+
+	if prev == nil || prev.Status != result.Status {
+		if result.Error != "" {
+			slog.Warn("Model health changed", "model_name", name, "status", result.Status, "error", result.Error)
+		} else {
+			slog.Info("Model health changed", "model_name", name, "status", result.Status)
+		}
+	}
+	m.states[name] = result
+}
+
+// Get 获取模型最近一次的健康检查结果，尚未探测时返回nil
+func (m *HealthMonitor) Get(name string) *model.ModelHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	state, exists := m.states[name]
+	if !exists {
+		return nil
+	}
+	result := *state
+	return &result
+}
+
+// healthTimeout 单次健康探测的超时时间
+func (s *ModelService) healthTimeout() time.Duration {
+	if s.config.HealthCheck.Timeout > 0 {
+		return time.Duration(s.config.HealthCheck.Timeout) * time.Second
+	}
+	return healthProbeTimeout
+}
+
+// checkHealth 探测模型实例的健康状态
+// llama-server的/health由HTTP线程直接响应，推理循环卡死时仍可能返回200；
+// /slots需要经过任务队列处理，因此额外探测/slots以发现进程存活但已卡死的实例
+func (s *ModelService) checkHealth(ctx context.Context, name string) *model.ModelHealth {
+	result := &model.ModelHealth{LastCheck: time.Now().Format(time.RFC3339)}
+
+	backend, err := s.GetBackend(name)
+	if err != nil {
+		result.Status = HealthOffline
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.healthTimeout())
+	defer cancel()
+
+	unhealthy := func(err error) *model.ModelHealth {
+		result.Status = HealthUnhealthy
+		result.Error = err.Error()
+		result.ConsecutiveFailures = 1
+		return result
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, backend.URL.JoinPath("health").String(), nil)
+	if err != nil {
+		return unhealthy(err)
+	}
+	resp, err := backendClient.Do(req)
+	if err != nil {
+		return unhealthy(fmt.Errorf("health check failed: %v", err))
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusServiceUnavailable:
+		// llama-server在加载模型期间返回503
+		result.Status = HealthLoading
+		return result
+	default:
+		return unhealthy(fmt.Errorf("health endpoint returned %s", resp.Status))
+	}
+
+	slots, err := backend.fetchSlots(ctx)
+	if err != nil && !errors.Is(err, errSlotsUnavailable) {
+		return unhealthy(fmt.Errorf("slots check failed: %v", err))
+	}
+
+	result.Status = HealthHealthy
+	result.SlotsTotal = len(slots)
+	for _, slot := range slots {
+		if slot.IsProcessing {
+			result.SlotsProcessing++
+		}
+	}
+	return result
+}
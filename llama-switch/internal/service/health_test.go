@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestHealthMonitor(t *testing.T) {
+	var state atomic.Value // loading/ready/wedged
+	state.Store("loading")
+	release := make(chan struct{})
+	defer close(release)
+
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			if state.Load() == "loading" {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/slots":
+			// 卡死的实例仍响应/health，但/slots不再返回
+			if state.Load() == "wedged" {
+				select {
+				case <-r.Context().Done():
+				case <-release:
+				}
+				return
+			}
+			w.Write([]byte(`[{"id":0,"is_processing":true},{"id":1,"is_processing":false}]`))
+		}
+	}))
+	defer backend.Close()
+
+	host, portStr, _ := net.SplitHostPort(backend.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	cfg := &config.Config{}
+	cfg.HealthCheck.Timeout = 1
+	s := &ModelService{
+		config:         cfg,
+		processManager: NewProcessManager(),
+		persistentMgr:  config.NewPersistentManager(cfg),
+		configs:        map[string]*model.ModelConfig{"chat": {ModelName: "chat"}},
+	}
+	s.health = newHealthMonitor(s)
+	s.resources = newResourceSampler(s, 1)
+	s.processManager.AddModel(os.Getpid(), &model.ModelStatus{ModelName: "chat", Host: host, Port: port, Running: true})
+
+	if s.health.Get("chat") != nil {
+		t.Fatal("Expected no health result before the first check")
+	}
+
+	s.health.CheckAll(context.Background())
+	if h := s.health.Get("chat"); h == nil || h.Status != HealthLoading {
+		t.Fatalf("Expected loading, got %+v", h)
+	}
+
+	state.Store("ready")
+	s.health.CheckAll(context.Background())
+	h := s.health.Get("chat")
+	if h == nil || h.Status != HealthHealthy || h.SlotsTotal != 2 || h.SlotsProcessing != 1 || h.LastHealthy == "" {
+		t.Fatalf("Expected healthy with 2 slots, 1 processing, got %+v", h)
+	}
+
+	state.Store("wedged")
+	s.health.CheckAll(context.Background())
+	s.health.CheckAll(context.Background())
+	h = s.health.Get("chat")
+	if h == nil || h.Status != HealthUnhealthy || h.ConsecutiveFailures != 2 || h.Error == "" || h.LastHealthy == "" {
+		t.Fatalf("Expected unhealthy after 2 failures, got %+v", h)
+	}
+
+	statuses := s.GetModelStatus("chat")
+	if len(statuses) != 1 || statuses[0].Health == nil || statuses[0].Health.Status != HealthUnhealthy {
+		t.Fatalf("Expected health in model status, got %+v", statuses)
+	}
+
+	// 停止的模型不再保留健康记录
+	s.processManager.RemoveModel(os.Getpid())
+	s.health.CheckAll(context.Background())
+	if h := s.health.Get("chat"); h != nil {
+		t.Errorf("Expected health result to be cleared, got %+v", h)
+	}
+}
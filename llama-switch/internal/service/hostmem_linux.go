@@ -0,0 +1,76 @@
+//go:build linux
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// availableRAM 从/proc/meminfo读取可用内存(MB)
+func availableRAM() (int, error) {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read /proc/meminfo: %v", err)
+	}
+	return parseMemAvailable(string(data))
+}
+
+// totalRAM 从/proc/meminfo读取物理内存总量(MB)
+func totalRAM() (int, error) {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read /proc/meminfo: %v", err)
+	}
+	return parseMeminfo(string(data), "MemTotal")
+}
+
+// parseMemAvailable 解析/proc/meminfo中的MemAvailable（内核估算的不需要换出即可分配的内存）
+func parseMemAvailable(meminfo string) (int, error) {
+	return parseMeminfo(meminfo, "MemAvailable")
+}
+
+// parseMeminfo 解析/proc/meminfo中指定字段的值(MB)
+func parseMeminfo(meminfo, field string) (int, error) {
+	for _, line := range strings.Split(meminfo, "\n") {
+		value, ok := strings.CutPrefix(line, field+":")
+		if !ok {
+			continue
+		}
+		kb, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(value), " kB"))
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse %s: %v", field, err)
+		}
+		return kb / 1024, nil
+	}
+	return 0, fmt.Errorf("%s not found in /proc/meminfo", field)
+}
+
+// cpuModel 从/proc/cpuinfo读取CPU型号，ARM平台没有model name时使用Model字段
+func cpuModel() string {
+	data, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	return parseCPUModel(string(data))
+}
+
+// parseCPUModel 解析/proc/cpuinfo中的CPU型号
+func parseCPUModel(cpuinfo string) string {
+	var fallback string
+	for _, line := range strings.Split(cpuinfo, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "model name":
+			return strings.TrimSpace(value)
+		case "Model":
+			fallback = strings.TrimSpace(value)
+		}
+	}
+	return fallback
+}
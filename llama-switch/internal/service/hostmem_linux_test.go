@@ -0,0 +1,30 @@
+package service
+
+import "testing"
+
+func TestParseMemAvailable(t *testing.T) {
+	meminfo := "MemTotal:       65764932 kB\nMemFree:         1234567 kB\nMemAvailable:   20971520 kB\nBuffers:          123456 kB\n"
+	mb, err := parseMemAvailable(meminfo)
+	if err != nil || mb != 20480 {
+		t.Fatalf("parseMemAvailable = %d, %v, want 20480", mb, err)
+	}
+	if _, err := parseMemAvailable("MemTotal: 1 kB\n"); err == nil {
+		t.Error("expected error when MemAvailable is missing")
+	}
+}
+
+func TestParseMemTotalAndCPUModel(t *testing.T) {
+	meminfo := "MemTotal:       65764932 kB\nMemAvailable:   20971520 kB\n"
+	if mb, err := parseMeminfo(meminfo, "MemTotal"); err != nil || mb != 64223 {
+		t.Errorf("parseMeminfo(MemTotal) = %d, %v, want 64223", mb, err)
+	}
+
+	x86 := "processor\t: 0\nvendor_id\t: AuthenticAMD\nmodel name\t: AMD Ryzen 9 7950X 16-Core Processor\n"
+	if got := parseCPUModel(x86); got != "AMD Ryzen 9 7950X 16-Core Processor" {
+		t.Errorf("parseCPUModel(x86) = %q", got)
+	}
+	arm := "processor\t: 0\nBogoMIPS\t: 108.00\n\nModel\t\t: Raspberry Pi 5 Model B Rev 1.0\n"
+	if got := parseCPUModel(arm); got != "Raspberry Pi 5 Model B Rev 1.0" {
+		t.Errorf("parseCPUModel(arm) = %q", got)
+	}
+}
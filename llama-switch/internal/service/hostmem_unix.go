@@ -0,0 +1,48 @@
+//go:build !linux && !windows
+
+package service
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// availableRAM 通过vm_stat获取可用内存(MB)：空闲、非活跃和预读页之和（macOS）
+func availableRAM() (int, error) {
+	output, err := exec.Command("vm_stat").Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to query available memory: %v", err)
+	}
+	available, err := parseVMStatAvailable(string(output))
+	if err != nil {
+		return 0, err
+	}
+	return int(available / (1024 * 1024)), nil
+}
+
+// totalRAM 通过sysctl获取物理内存总量(MB)，macOS为hw.memsize，BSD为hw.physmem
+func totalRAM() (int, error) {
+	bytes, err := strconv.ParseInt(sysctlValue("hw.memsize", "hw.physmem"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query total memory: %v", err)
+	}
+	return int(bytes / (1024 * 1024)), nil
+}
+
+// cpuModel 通过sysctl获取CPU型号，macOS为machdep.cpu.brand_string，BSD为hw.model
+func cpuModel() string {
+	return sysctlValue("machdep.cpu.brand_string", "hw.model")
+}
+
+// sysctlValue 依次查询sysctl变量，返回第一个非空值
+func sysctlValue(names ...string) string {
+	for _, name := range names {
+		output, err := exec.Command("sysctl", "-n", name).Output()
+		if value := strings.TrimSpace(string(output)); err == nil && value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+//go:build windows
+
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+var procGlobalMemoryStatusEx = kernel32.NewProc("GlobalMemoryStatusEx")
+
+// memoryStatusEx 对应Windows的MEMORYSTATUSEX结构
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+// availableRAM 通过GlobalMemoryStatusEx获取可用物理内存(MB)
+func availableRAM() (int, error) {
+	status, err := globalMemoryStatus()
+	if err != nil {
+		return 0, err
+	}
+	return int(status.AvailPhys / (1024 * 1024)), nil
+}
+
+// totalRAM 通过GlobalMemoryStatusEx获取物理内存总量(MB)
+func totalRAM() (int, error) {
+	status, err := globalMemoryStatus()
+	if err != nil {
+		return 0, err
+	}
+	return int(status.TotalPhys / (1024 * 1024)), nil
+}
+
+// globalMemoryStatus 调用GlobalMemoryStatusEx获取内存状态
+func globalMemoryStatus() (*memoryStatusEx, error) {
+	var status memoryStatusEx
+	status.Length = uint32(unsafe.Sizeof(status))
+	ret, _, err := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status)))
+	if ret == 0 {
+		return nil, fmt.Errorf("failed to query memory status: %v", err)
+	}
+	return &status, nil
+}
+
+// cpuModel 从注册表读取第一个处理器的型号
+func cpuModel() string {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `HARDWARE\DESCRIPTION\System\CentralProcessor\0`, registry.QUERY_VALUE)
+	if err != nil {
+		return ""
+	}
+	defer key.Close()
+	name, _, err := key.GetStringValue("ProcessorNameString")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(name)
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"llama-switch/internal/apierror"
+)
+
+// defaultRetryAfter 拒绝请求时建议客户端的重试间隔
+const defaultRetryAfter = time.Second
+
+// RequestStats 单个模型的请求负载统计
+type RequestStats struct {
+	Limit       int    `json:"limit"`                  // 并发上限（0表示不限制）
+	Active      int    `json:"active"`                 // 正在处理的请求数
+	Queued      int    `json:"queued"`                 // 排队等待的请求数
+	Total       int64  `json:"total"`                  // 累计处理的请求数
+	Rejected    int64  `json:"rejected"`               // 累计拒绝的请求数
+	LastRequest string `json:"last_request,omitempty"` // 最后一次请求时间
+}
+
+// ConcurrencyLimitError 超出并发上限时返回的错误，携带重试信息
+type ConcurrencyLimitError struct {
+	ModelName  string
+	Limit      int
+	Active     int
+	Queued     int
+	RetryAfter time.Duration
+}
+
+func (e *ConcurrencyLimitError) Error() string {
+	return fmt.Sprintf("model '%s' is at its concurrency limit (%d active, %d queued, limit %d)",
+		e.ModelName, e.Active, e.Queued, e.Limit)
+}
+
+func (e *ConcurrencyLimitError) ErrorCode() apierror.Code { return apierror.CodeConcurrencyLimit }
+
+// ModelDrainingError 模型正在排空、不再接受新请求时返回的错误
+type ModelDrainingError struct {
+	ModelName  string
+	RetryAfter time.Duration
+}
+
+func (e *ModelDrainingError) Error() string {
+	return fmt.Sprintf("model '%s' is draining and no longer accepts requests", e.ModelName)
+}
+
+func (e *ModelDrainingError) ErrorCode() apierror.Code { return apierror.CodeModelDraining }
+
+// backendLoad 单个模型的负载状态
+type backendLoad struct {
+	limit       int
+	active      int
+	queued      int
+	total       int64
+	rejected    int64
+	lastRequest time.Time
+	lastActive  time.Time     // 最后一次请求开始或结束的时间
+	draining    bool          // 排空中，拒绝新请求
+	removed     bool          // 已移除（模型已停止），排队者不再等待槽位
+	released    chan struct{} // 有槽位释放时关闭并替换，用于唤醒排队者
+}
+
+// RequestTracker 按模型跟踪进行中的推理请求，超出并发上限时排队或拒绝
+type RequestTracker struct {
+	mu           sync.Mutex
+	backends     map[string]*backendLoad
+	queueSize    int
+	queueTimeout time.Duration
+}
+
+// NewRequestTracker 创建新的请求跟踪器
+func NewRequestTracker(queueSize int, queueTimeout time.Duration) *RequestTracker {
+	return &RequestTracker{
+		backends:     make(map[string]*backendLoad),
+		queueSize:    queueSize,
+		queueTimeout: queueTimeout,
+	}
+}
+
+// load 获取模型负载状态，不存在时创建（调用方需持有锁）
+func (t *RequestTracker) load(name string) *backendLoad {
+	b, exists := t.backends[name]
+	if !exists {
+		b = &backendLoad{released: make(chan struct{})}
+		t.backends[name] = b
+	}
+	return b
+}
+
+// SetLimit 设置模型的并发上限，0表示不限制
+func (t *RequestTracker) SetLimit(name string, limit int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.load(name)
+	b.limit = limit
+	// 上限变化后唤醒排队者重新检查
+	close(b.released)
+	b.released = make(chan struct{})
+}
+
+// Remove 移除模型的负载状态，仍在排队的请求立即失败，而不是等到排队超时
+func (t *RequestTracker) Remove(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if b, exists := t.backends[name]; exists {
+		b.removed = true
+		close(b.released)
+		b.released = make(chan struct{})
+	}
+	delete(t.backends, name)
+}
+
+// SetDraining 设置模型是否处于排空状态，排空中的模型拒绝新请求，已排队的请求不受影响
+func (t *RequestTracker) SetDraining(name string, draining bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.load(name).draining = draining
+}
+
+// WaitIdle 等待模型进行中（含排队）的请求全部结束，ctx取消时返回剩余的请求数
+func (t *RequestTracker) WaitIdle(ctx context.Context, name string) (int, error) {
+	for {
+		t.mu.Lock()
+		b, exists := t.backends[name]
+		if !exists || b.active+b.queued == 0 {
+			t.mu.Unlock()
+			return 0, nil
+		}
+		pending := b.active + b.queued
+		released := b.released
+		t.mu.Unlock()
+
+		// 排队者超时或取消时不会发出通知，因此同时定期重新检查
+		select {
+		case <-released:
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return pending, ctx.Err()
+		}
+	}
+}
+
+// Acquire 为指定模型申请一个请求槽位，返回释放函数
+func (t *RequestTracker) Acquire(ctx context.Context, name string) (func(), error) {
+	t.mu.Lock()
+	b := t.load(name)
+
+	if b.draining {
+		b.rejected++
+		t.mu.Unlock()
+		return nil, &ModelDrainingError{ModelName: name, RetryAfter: defaultRetryAfter}
+	}
+
+	if b.limit > 0 && b.active >= b.limit {
+		if b.queued >= t.queueSize {
+			b.rejected++
+			err := t.limitError(name, b)
+			t.mu.Unlock()
+			return nil, err
+		}
+
+		// 进入队列等待槽位释放
+		b.queued++
+		timer := time.NewTimer(t.queueTimeout)
+		defer timer.Stop()
+
+		for b.limit > 0 && b.active >= b.limit {
+			released := b.released
+			t.mu.Unlock()
+
+			select {
+			case <-released:
+				t.mu.Lock()
+				if b.removed {
+					b.queued--
+					t.mu.Unlock()
+					return nil, apierror.New(apierror.CodeModelNotFound, "model '%s' was stopped while the request was queued", name)
+				}
+			case <-timer.C:
+				t.mu.Lock()
+				b.queued--
+				b.rejected++
+				err := t.limitError(name, b)
+				t.mu.Unlock()
+				return nil, err
+			case <-ctx.Done():
+				t.mu.Lock()
+				b.queued--
+				t.mu.Unlock()
+				return nil, ctx.Err()
+			}
+		}
+		b.queued--
+	}
+
+	b.active++
+	b.total++
+	b.lastRequest = time.Now()
+	b.lastActive = b.lastRequest
+	t.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			b.active--
+			b.lastActive = time.Now()
+			close(b.released)
+			b.released = make(chan struct{})
+		})
+	}, nil
+}
+
+// limitError 构建并发上限错误（调用方需持有锁）
+func (t *RequestTracker) limitError(name string, b *backendLoad) *ConcurrencyLimitError {
+	return &ConcurrencyLimitError{
+		ModelName:  name,
+		Limit:      b.limit,
+		Active:     b.active,
+		Queued:     b.queued,
+		RetryAfter: defaultRetryAfter,
+	}
+}
+
+// Stats 获取指定模型的请求统计
+func (t *RequestTracker) Stats(name string) RequestStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, exists := t.backends[name]
+	if !exists {
+		return RequestStats{}
+	}
+
+	stats := RequestStats{
+		Limit:    b.limit,
+		Active:   b.active,
+		Queued:   b.queued,
+		Total:    b.total,
+		Rejected: b.rejected,
+	}
+	if !b.lastRequest.IsZero() {
+		stats.LastRequest = b.lastRequest.Format(time.RFC3339)
+	}
+	return stats
+}
+
+// Activity 获取指定模型进行中（含排队）的请求数和最后一次请求活动时间
+func (t *RequestTracker) Activity(name string) (int, time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, exists := t.backends[name]
+	if !exists {
+		return 0, time.Time{}
+	}
+	return b.active + b.queued, b.lastActive
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"llama-switch/internal/apierror"
+)
+
+func TestRequestTracker_RejectsBeyondQueue(t *testing.T) {
+	tracker := NewRequestTracker(0, time.Second)
+	tracker.SetLimit("chat", 1)
+
+	release, err := tracker.Acquire(context.Background(), "chat")
+	if err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+	defer release()
+
+	_, err = tracker.Acquire(context.Background(), "chat")
+	var limitErr *ConcurrencyLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Expected ConcurrencyLimitError, got %v", err)
+	}
+	if limitErr.Limit != 1 || limitErr.Active != 1 {
+		t.Errorf("Unexpected limit info: %+v", limitErr)
+	}
+
+	stats := tracker.Stats("chat")
+	if stats.Rejected != 1 {
+		t.Errorf("Expected 1 rejected request, got %d", stats.Rejected)
+	}
+}
+
+func TestRequestTracker_QueuedRequestAdmittedOnRelease(t *testing.T) {
+	tracker := NewRequestTracker(1, 5*time.Second)
+	tracker.SetLimit("chat", 1)
+
+	release, err := tracker.Acquire(context.Background(), "chat")
+	if err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+
+	admitted := make(chan error, 1)
+	go func() {
+		release2, err := tracker.Acquire(context.Background(), "chat")
+		if err == nil {
+			release2()
+		}
+		admitted <- err
+	}()
+
+	// 等待第二个请求进入队列
+	deadline := time.Now().Add(time.Second)
+	for tracker.Stats("chat").Queued != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("request was not queued")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	release()
+	select {
+	case err := <-admitted:
+		if err != nil {
+			t.Fatalf("queued request failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued request was not admitted after release")
+	}
+
+	if stats := tracker.Stats("chat"); stats.Total != 2 || stats.Active != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestRequestTracker_QueueTimeout(t *testing.T) {
+	tracker := NewRequestTracker(1, 20*time.Millisecond)
+	tracker.SetLimit("chat", 1)
+
+	release, err := tracker.Acquire(context.Background(), "chat")
+	if err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+	defer release()
+
+	_, err = tracker.Acquire(context.Background(), "chat")
+	var limitErr *ConcurrencyLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("Expected ConcurrencyLimitError after queue timeout, got %v", err)
+	}
+	if stats := tracker.Stats("chat"); stats.Queued != 0 {
+		t.Errorf("Expected empty queue after timeout, got %d", stats.Queued)
+	}
+}
+
+func TestRequestTracker_Draining(t *testing.T) {
+	tracker := NewRequestTracker(0, time.Second)
+
+	release, err := tracker.Acquire(context.Background(), "chat")
+	if err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+
+	tracker.SetDraining("chat", true)
+	var drainErr *ModelDrainingError
+	if _, err := tracker.Acquire(context.Background(), "chat"); !errors.As(err, &drainErr) {
+		t.Fatalf("Expected ModelDrainingError, got %v", err)
+	}
+
+	// 进行中的请求结束前等待超时
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if pending, err := tracker.WaitIdle(ctx, "chat"); err == nil || pending != 1 {
+		t.Fatalf("Expected timeout with 1 pending request, got %d, %v", pending, err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		release()
+	}()
+	if _, err := tracker.WaitIdle(context.Background(), "chat"); err != nil {
+		t.Fatalf("WaitIdle failed: %v", err)
+	}
+
+	tracker.SetDraining("chat", false)
+	if release, err := tracker.Acquire(context.Background(), "chat"); err != nil {
+		t.Errorf("Expected acquire to succeed after draining is cleared, got %v", err)
+	} else {
+		release()
+	}
+}
+
+func TestRequestTracker_RemoveFailsQueuedRequests(t *testing.T) {
+	tracker := NewRequestTracker(1, 5*time.Second)
+	tracker.SetLimit("chat", 1)
+
+	release, err := tracker.Acquire(context.Background(), "chat")
+	if err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+	defer release()
+
+	queued := make(chan error, 1)
+	go func() {
+		_, err := tracker.Acquire(context.Background(), "chat")
+		queued <- err
+	}()
+	deadline := time.Now().Add(time.Second)
+	for tracker.Stats("chat").Queued != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("request was not queued")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 模型停止后排队的请求立即失败，不等到排队超时
+	tracker.Remove("chat")
+	select {
+	case err := <-queued:
+		if apierror.CodeOf(err) != apierror.CodeModelNotFound {
+			t.Errorf("queued request error = %v, want %s", err, apierror.CodeModelNotFound)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued request still waiting after the model was removed")
+	}
+}
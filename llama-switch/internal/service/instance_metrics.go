@@ -0,0 +1,146 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	"llama-switch/internal/model"
+)
+
+// maxInstanceMetrics 单个实例/metrics输出的最大字节数，超过时视为抓取失败
+const maxInstanceMetrics = 1024 * 1024
+
+// instanceScrapeSuccessDesc 各实例最近一次抓取是否成功
+var instanceScrapeSuccessDesc = prometheus.NewDesc("llama_switch_instance_scrape_success",
+	"Whether the last scrape of a model instance's /metrics endpoint succeeded.", []string{"model_name"}, nil)
+
+// instanceMetrics 抓取时汇总启用了metrics的模型实例的/metrics输出，为每个样本加上model_name标签，
+// 使Prometheus只需抓取switcher一个端点。实例导出的指标族事先未知，因此不在Describe中描述（unchecked collector）
+type instanceMetrics struct {
+	s *ModelService
+}
+
+// instanceScrape 一个实例的抓取结果
+type instanceScrape struct {
+	name     string
+	families map[string]*dto.MetricFamily
+	err      error
+}
+
+// Describe 实现prometheus.Collector，不描述任何指标
+func (c *instanceMetrics) Describe(chan<- *prometheus.Desc) {}
+
+// Collect 并发抓取各实例，导出每个实例的抓取结果和加上model_name标签的实例指标
+func (c *instanceMetrics) Collect(ch chan<- prometheus.Metric) {
+	backends := c.s.GetBackendsWhere(func(cfg *model.ModelConfig) bool { return cfg.Config.Metrics })
+	if len(backends) == 0 {
+		return
+	}
+
+	scrapes := make([]instanceScrape, len(backends))
+	var wg sync.WaitGroup
+	for i, backend := range backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			families, err := c.s.scrapeInstance(backend)
+			scrapes[i] = instanceScrape{name: backend.ModelName, families: families, err: err}
+		}()
+	}
+	wg.Wait()
+	sort.Slice(scrapes, func(i, j int) bool { return scrapes[i].name < scrapes[j].name })
+
+	// 不同实例的同名指标族使用第一个实例的HELP和TYPE，类型不一致的指标族被跳过
+	first := make(map[string]*dto.MetricFamily)
+	for _, scrape := range scrapes {
+		success := 1.0
+		if scrape.err != nil {
+			success = 0
+		}
+		ch <- prometheus.MustNewConstMetric(instanceScrapeSuccessDesc, prometheus.GaugeValue, success, scrape.name)
+
+		for name, f := range scrape.families {
+			if existing, ok := first[name]; ok && existing.GetType() != f.GetType() {
+				continue
+			} else if !ok {
+				first[name] = f
+			}
+			for _, m := range f.Metric {
+				metric, err := withModelName(first[name], m, scrape.name)
+				if err != nil {
+					ch <- prometheus.NewInvalidMetric(prometheus.NewDesc(name, "", nil, nil), err)
+					continue
+				}
+				ch <- metric
+			}
+		}
+	}
+}
+
+// scrapeInstance 抓取并解析实例的/metrics输出
+func (s *ModelService) scrapeInstance(backend *Backend) (map[string]*dto.MetricFamily, error) {
+	data, err := s.readMetrics(context.Background(), backend, maxInstanceMetrics+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxInstanceMetrics {
+		return nil, fmt.Errorf("metrics output exceeds %d bytes", maxInstanceMetrics)
+	}
+	var parser expfmt.TextParser
+	return parser.TextToMetricFamilies(bytes.NewReader(data))
+}
+
+// withModelName 将实例的样本转换为加上model_name标签的指标，实例自身的model_name标签改名为exported_model_name
+func withModelName(family *dto.MetricFamily, m *dto.Metric, name string) (prometheus.Metric, error) {
+	labelNames := []string{"model_name"}
+	labelValues := []string{name}
+	for _, l := range m.GetLabel() {
+		labelName := l.GetName()
+		if labelName == "model_name" {
+			labelName = "exported_model_name"
+		}
+		labelNames = append(labelNames, labelName)
+		labelValues = append(labelValues, l.GetValue())
+	}
+	desc := prometheus.NewDesc(family.GetName(), family.GetHelp(), labelNames, nil)
+
+	var metric prometheus.Metric
+	var err error
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		metric, err = prometheus.NewConstMetric(desc, prometheus.CounterValue, m.GetCounter().GetValue(), labelValues...)
+	case dto.MetricType_GAUGE:
+		metric, err = prometheus.NewConstMetric(desc, prometheus.GaugeValue, m.GetGauge().GetValue(), labelValues...)
+	case dto.MetricType_HISTOGRAM:
+		h := m.GetHistogram()
+		buckets := make(map[float64]uint64, len(h.GetBucket()))
+		for _, b := range h.GetBucket() {
+			buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+		}
+		metric, err = prometheus.NewConstHistogram(desc, h.GetSampleCount(), h.GetSampleSum(), buckets, labelValues...)
+	case dto.MetricType_SUMMARY:
+		sm := m.GetSummary()
+		quantiles := make(map[float64]float64, len(sm.GetQuantile()))
+		for _, q := range sm.GetQuantile() {
+			quantiles[q.GetQuantile()] = q.GetValue()
+		}
+		metric, err = prometheus.NewConstSummary(desc, sm.GetSampleCount(), sm.GetSampleSum(), quantiles, labelValues...)
+	default:
+		metric, err = prometheus.NewConstMetric(desc, prometheus.UntypedValue, m.GetUntyped().GetValue(), labelValues...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if m.TimestampMs != nil {
+		metric = prometheus.NewMetricWithTimestamp(time.UnixMilli(m.GetTimestampMs()), metric)
+	}
+	return metric, nil
+}
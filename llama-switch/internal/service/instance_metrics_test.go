@@ -0,0 +1,96 @@
+package service
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestInstanceMetrics(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`# HELP llamacpp:prompt_tokens_total Number of prompt tokens processed.
+# TYPE llamacpp:prompt_tokens_total counter
+llamacpp:prompt_tokens_total 128
+# TYPE llamacpp:requests_processing gauge
+llamacpp:requests_processing{model_name="inner"} 1
+# TYPE llamacpp:latency_seconds histogram
+llamacpp:latency_seconds_bucket{le="0.5"} 3
+llamacpp:latency_seconds_bucket{le="+Inf"} 4
+llamacpp:latency_seconds_sum 1.5
+llamacpp:latency_seconds_count 4
+`))
+	}))
+	defer backend.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	cfg := &config.Config{}
+	cfg.HealthCheck.Timeout = 1
+	cfg.Metrics.Instances = true
+	withMetrics := func(name string) *model.ModelConfig {
+		c := &model.ModelConfig{ModelName: name}
+		c.Config.Metrics = true
+		return c
+	}
+	s := &ModelService{
+		config:         cfg,
+		processManager: NewProcessManager(),
+		persistentMgr:  config.NewPersistentManager(cfg),
+		configs: map[string]*model.ModelConfig{
+			"chat":   withMetrics("chat"),
+			"broken": withMetrics("broken"),
+			"plain":  {ModelName: "plain"},
+		},
+	}
+	for pid, m := range map[int]struct {
+		name   string
+		server *httptest.Server
+	}{os.Getpid(): {"chat", backend}, os.Getppid(): {"broken", broken}, os.Getpid() + 1: {"plain", backend}} {
+		host, portStr, _ := net.SplitHostPort(m.server.Listener.Addr().String())
+		port, _ := strconv.Atoi(portStr)
+		s.processManager.AddModel(pid, &model.ModelStatus{ModelName: m.name, Host: host, Port: port, Running: true})
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(&instanceMetrics{s: s})
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	var b strings.Builder
+	for _, f := range families {
+		expfmt.MetricFamilyToText(&b, f)
+	}
+	want := `# HELP llama_switch_instance_scrape_success Whether the last scrape of a model instance's /metrics endpoint succeeded.
+# TYPE llama_switch_instance_scrape_success gauge
+llama_switch_instance_scrape_success{model_name="broken"} 0
+llama_switch_instance_scrape_success{model_name="chat"} 1
+# HELP llamacpp:latency_seconds 
+# TYPE llamacpp:latency_seconds histogram
+llamacpp:latency_seconds_bucket{model_name="chat",le="0.5"} 3
+llamacpp:latency_seconds_bucket{model_name="chat",le="+Inf"} 4
+llamacpp:latency_seconds_sum{model_name="chat"} 1.5
+llamacpp:latency_seconds_count{model_name="chat"} 4
+# HELP llamacpp:prompt_tokens_total Number of prompt tokens processed.
+# TYPE llamacpp:prompt_tokens_total counter
+llamacpp:prompt_tokens_total{model_name="chat"} 128
+# HELP llamacpp:requests_processing 
+# TYPE llamacpp:requests_processing gauge
+llamacpp:requests_processing{exported_model_name="inner",model_name="chat"} 1
+`
+	if got := b.String(); got != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+//go:build linux
+
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"llama-switch/internal/model"
+)
+
+// cgroupPeriod cpu.max的调度周期（微秒）
+const cgroupPeriod = 100000
+
+// unsafeCgroupChars cgroup目录名中不允许的字符
+var unsafeCgroupChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// limitHandle 模型进程所在的cgroup
+type limitHandle struct {
+	dir string
+	fd  *os.File // cgroup目录，创建进程时通过CgroupFD直接放入该组
+}
+
+// prepareLimits 为模型创建cgroup v2子组并写入限制，进程启动时直接加入该组
+func prepareLimits(root, name string, limits *model.ResourceLimits, attr *syscall.SysProcAttr) (*limitHandle, error) {
+	if limits == nil {
+		return nil, nil
+	}
+	if root == "" {
+		return nil, fmt.Errorf("cgroup root is not configured")
+	}
+
+	// 只支持cgroup v2（统一层级），v2的每个组都有cgroup.controllers文件
+	if _, err := os.Stat(filepath.Join(filepath.Dir(root), "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("cgroup v2 is not available at %s", filepath.Dir(root))
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cgroup root %s: %v", root, err)
+	}
+	// 为子组启用所需的控制器
+	if controllers := cgroupControllers(limits); controllers != "" {
+		if err := writeCgroupFile(root, "cgroup.subtree_control", controllers); err != nil {
+			return nil, err
+		}
+	}
+
+	dir := filepath.Join(root, unsafeCgroupChars.ReplaceAllString(name, "_"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cgroup %s: %v", dir, err)
+	}
+	h := &limitHandle{dir: dir}
+	if err := writeCgroupLimits(dir, limits); err != nil {
+		h.release()
+		return nil, err
+	}
+
+	fd, err := os.Open(dir)
+	if err != nil {
+		h.release()
+		return nil, fmt.Errorf("failed to open cgroup %s: %v", dir, err)
+	}
+	h.fd = fd
+	attr.UseCgroupFD = true
+	attr.CgroupFD = int(fd.Fd())
+	return h, nil
+}
+
+// cgroupControllers 限制所需的cgroup控制器
+func cgroupControllers(limits *model.ResourceLimits) string {
+	var controllers []string
+	if limits.CPUs > 0 {
+		controllers = append(controllers, "+cpu")
+	}
+	if limits.MemoryMB > 0 {
+		controllers = append(controllers, "+memory")
+	}
+	if len(limits.CPUAffinity) > 0 {
+		controllers = append(controllers, "+cpuset")
+	}
+	return strings.Join(controllers, " ")
+}
+
+// writeCgroupLimits 将限制写入cgroup的接口文件
+func writeCgroupLimits(dir string, limits *model.ResourceLimits) error {
+	if limits.CPUs > 0 {
+		quota := int(limits.CPUs * cgroupPeriod)
+		if err := writeCgroupFile(dir, "cpu.max", fmt.Sprintf("%d %d", quota, cgroupPeriod)); err != nil {
+			return err
+		}
+	}
+	if limits.MemoryMB > 0 {
+		if err := writeCgroupFile(dir, "memory.max", strconv.FormatInt(int64(limits.MemoryMB)*1024*1024, 10)); err != nil {
+			return err
+		}
+	}
+	if len(limits.CPUAffinity) > 0 {
+		cpus := make([]string, len(limits.CPUAffinity))
+		for i, cpu := range limits.CPUAffinity {
+			cpus[i] = strconv.Itoa(cpu)
+		}
+		if err := writeCgroupFile(dir, "cpuset.cpus", strings.Join(cpus, ",")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCgroupFile 写入cgroup接口文件
+func writeCgroupFile(dir, file, value string) error {
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+		return fmt.Errorf("failed to write %s to %s: %v", value, filepath.Join(dir, file), err)
+	}
+	return nil
+}
+
+// attach 进程已在创建时加入cgroup，只需关闭目录
+func (h *limitHandle) attach(pid int) error {
+	if h == nil || h.fd == nil {
+		return nil
+	}
+	err := h.fd.Close()
+	h.fd = nil
+	return err
+}
+
+// release 进程退出后删除cgroup
+func (h *limitHandle) release() {
+	if h == nil {
+		return
+	}
+	if h.fd != nil {
+		h.fd.Close()
+		h.fd = nil
+	}
+	if err := os.Remove(h.dir); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove cgroup", "dir", h.dir, "error", err)
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"llama-switch/internal/model"
+)
+
+func TestWriteCgroupLimits(t *testing.T) {
+	dir := t.TempDir()
+	limits := &model.ResourceLimits{CPUs: 2.5, MemoryMB: 4096, CPUAffinity: []int{0, 2}}
+
+	if got := cgroupControllers(limits); got != "+cpu +memory +cpuset" {
+		t.Errorf("cgroupControllers = %q", got)
+	}
+	if err := writeCgroupLimits(dir, limits); err != nil {
+		t.Fatalf("writeCgroupLimits failed: %v", err)
+	}
+
+	for file, want := range map[string]string{
+		"cpu.max":     "250000 100000",
+		"memory.max":  "4294967296",
+		"cpuset.cpus": "0,2",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", file, data, want)
+		}
+	}
+
+	// 未设置的限制不写入
+	empty := t.TempDir()
+	if err := writeCgroupLimits(empty, &model.ResourceLimits{MemoryMB: 512}); err != nil {
+		t.Fatalf("writeCgroupLimits failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(empty, "cpu.max")); !os.IsNotExist(err) {
+		t.Errorf("Expected cpu.max not to be written, got %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !linux && !windows
+
+package service
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+
+	"llama-switch/internal/model"
+)
+
+// limitHandle 当前平台不支持资源限制
+type limitHandle struct{}
+
+// prepareLimits 当前平台不支持资源限制，指定限制时返回错误
+func prepareLimits(root, name string, limits *model.ResourceLimits, attr *syscall.SysProcAttr) (*limitHandle, error) {
+	if limits == nil {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("resource limits are not supported on %s", runtime.GOOS)
+}
+
+func (h *limitHandle) attach(pid int) error { return nil }
+
+func (h *limitHandle) release() {}
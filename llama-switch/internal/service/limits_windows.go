@@ -0,0 +1,156 @@
+//go:build windows
+
+package service
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"llama-switch/internal/model"
+)
+
+// Job Object相关常量
+const (
+	jobObjectExtendedLimitInformation  = 9
+	jobObjectCPURateControlInformation = 15
+	jobObjectLimitAffinity             = 0x00000010
+	jobObjectLimitProcessMemory        = 0x00000100
+	jobObjectCPURateControlEnable      = 0x1
+	jobObjectCPURateControlHardCap     = 0x4
+	processSetQuota                    = 0x0100
+	processTerminate                   = 0x0001
+)
+
+var (
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject  = kernel32.NewProc("SetInformationJobObject")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+)
+
+// jobObjectBasicLimitInformation 对应JOBOBJECT_BASIC_LIMIT_INFORMATION
+type jobObjectBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+// ioCounters 对应IO_COUNTERS
+type ioCounters struct {
+	ReadOperationCount  uint64
+	WriteOperationCount uint64
+	OtherOperationCount uint64
+	ReadTransferCount   uint64
+	WriteTransferCount  uint64
+	OtherTransferCount  uint64
+}
+
+// jobObjectExtendedLimitInfo 对应JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+type jobObjectExtendedLimitInfo struct {
+	BasicLimitInformation jobObjectBasicLimitInformation
+	IoInfo                ioCounters
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// jobObjectCPURateControlInfo 对应JOBOBJECT_CPU_RATE_CONTROL_INFORMATION
+type jobObjectCPURateControlInfo struct {
+	ControlFlags uint32
+	CPURate      uint32
+}
+
+// limitHandle 模型进程所在的Job Object
+type limitHandle struct {
+	job syscall.Handle
+}
+
+// prepareLimits 为模型创建Job Object并设置限制，进程启动后加入
+func prepareLimits(root, name string, limits *model.ResourceLimits, attr *syscall.SysProcAttr) (*limitHandle, error) {
+	if limits == nil {
+		return nil, nil
+	}
+
+	job, _, err := procCreateJobObjectW.Call(0, 0)
+	if job == 0 {
+		return nil, fmt.Errorf("failed to create job object: %v", err)
+	}
+	h := &limitHandle{job: syscall.Handle(job)}
+
+	if limits.MemoryMB > 0 || len(limits.CPUAffinity) > 0 {
+		var info jobObjectExtendedLimitInfo
+		if limits.MemoryMB > 0 {
+			info.BasicLimitInformation.LimitFlags |= jobObjectLimitProcessMemory
+			info.ProcessMemoryLimit = uintptr(limits.MemoryMB) * 1024 * 1024
+		}
+		if len(limits.CPUAffinity) > 0 {
+			info.BasicLimitInformation.LimitFlags |= jobObjectLimitAffinity
+			for _, cpu := range limits.CPUAffinity {
+				info.BasicLimitInformation.Affinity |= 1 << uint(cpu)
+			}
+		}
+		if err := h.setInformation(jobObjectExtendedLimitInformation, unsafe.Pointer(&info), unsafe.Sizeof(info)); err != nil {
+			h.release()
+			return nil, err
+		}
+	}
+
+	if limits.CPUs > 0 {
+		// CpuRate为占全部CPU时间的万分比
+		rate := uint32(limits.CPUs / float64(runtime.NumCPU()) * 10000)
+		rate = min(max(rate, 1), 10000)
+		info := jobObjectCPURateControlInfo{
+			ControlFlags: jobObjectCPURateControlEnable | jobObjectCPURateControlHardCap,
+			CPURate:      rate,
+		}
+		if err := h.setInformation(jobObjectCPURateControlInformation, unsafe.Pointer(&info), unsafe.Sizeof(info)); err != nil {
+			h.release()
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// setInformation 设置Job Object的限制信息
+func (h *limitHandle) setInformation(class uint32, info unsafe.Pointer, size uintptr) error {
+	ret, _, err := procSetInformationJobObject.Call(uintptr(h.job), uintptr(class), uintptr(info), size)
+	if ret == 0 {
+		return fmt.Errorf("failed to set job object information: %v", err)
+	}
+	return nil
+}
+
+// attach 将已启动的进程加入Job Object
+func (h *limitHandle) attach(pid int) error {
+	if h == nil {
+		return nil
+	}
+	process, err := syscall.OpenProcess(processSetQuota|processTerminate, false, uint32(pid))
+	if err != nil {
+		return fmt.Errorf("failed to open process %d: %v", pid, err)
+	}
+	defer syscall.CloseHandle(process)
+
+	ret, _, err := procAssignProcessToJobObject.Call(uintptr(h.job), uintptr(process))
+	if ret == 0 {
+		return fmt.Errorf("failed to assign process %d to job object: %v", pid, err)
+	}
+	return nil
+}
+
+// release 关闭Job Object句柄
+func (h *limitHandle) release() {
+	if h == nil || h.job == 0 {
+		return
+	}
+	syscall.CloseHandle(h.job)
+	h.job = 0
+}
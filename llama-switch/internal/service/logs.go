@@ -0,0 +1,260 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// logSubscriberBuffer 实时日志订阅者的缓冲行数，订阅者处理不及时时丢弃新行
+const logSubscriberBuffer = 256
+
+// unsafeFileChars 模型名称中不能用于文件名的字符
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// LogManager 模型实例输出日志管理器：将每个llama-server实例的stdout/stderr写入按大小轮转的日志文件
+type LogManager struct {
+	dir      string
+	maxSize  int64
+	maxFiles int
+	console  bool
+
+	mu   sync.Mutex
+	logs map[string]*modelLog
+}
+
+// modelLog 单个模型的日志文件及实时订阅者
+type modelLog struct {
+	manager *LogManager
+	name    string
+	path    string
+
+	mu          sync.Mutex
+	file        *os.File
+	writers     int // 正在写入的进程数（模型重启时新旧进程可能短暂并存）
+	size        int64
+	partial     []byte // 尚未以换行结束的输出
+	subscribers map[chan string]struct{}
+}
+
+// NewLogManager 创建模型日志管理器
+func NewLogManager(dir string, maxSizeMB, maxFiles int, console bool) *LogManager {
+	return &LogManager{
+		dir:      dir,
+		maxSize:  int64(maxSizeMB) << 20,
+		maxFiles: maxFiles,
+		console:  console,
+		logs:     make(map[string]*modelLog),
+	}
+}
+
+// defaultLogDir 默认模型日志目录：程序目录下的logs目录
+func defaultLogDir() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "logs"
+	}
+	return filepath.Join(filepath.Dir(exePath), "logs")
+}
+
+// get 获取模型日志，不存在时创建
+func (m *LogManager) get(name string) *modelLog {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, exists := m.logs[name]
+	if !exists {
+		l = &modelLog{
+			manager:     m,
+			name:        name,
+			path:        filepath.Join(m.dir, unsafeFileChars.ReplaceAllString(name, "_")+".log"),
+			subscribers: make(map[chan string]struct{}),
+		}
+		m.logs[name] = l
+	}
+	return l
+}
+
+// Writer 打开模型的日志文件用于写入进程输出，进程退出后需要关闭
+func (m *LogManager) Writer(name string) (io.WriteCloser, error) {
+	l := m.get(name)
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Tail 获取模型日志的最后n行（包含已轮转的文件）
+func (m *LogManager) Tail(name string, n int) ([]string, error) {
+	l := m.get(name)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var lines []string
+	for i := 0; i <= m.maxFiles && len(lines) < n; i++ {
+		path := l.path
+		if i > 0 {
+			path += "." + strconv.Itoa(i)
+		}
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			if i == 0 {
+				return nil, fmt.Errorf("no logs found for model '%s'", name)
+			}
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read log file: %v", err)
+		}
+		lines = append(splitLines(data), lines...)
+	}
+
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+// Subscribe 订阅模型的实时日志，返回接收日志行的通道和取消订阅函数
+func (m *LogManager) Subscribe(name string) (<-chan string, func()) {
+	l := m.get(name)
+	ch := make(chan string, logSubscriberBuffer)
+
+	l.mu.Lock()
+	l.subscribers[ch] = struct{}{}
+	l.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			l.mu.Lock()
+			delete(l.subscribers, ch)
+			l.mu.Unlock()
+		})
+	}
+}
+
+// open 以追加方式打开日志文件
+func (l *modelLog) open() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil {
+		l.writers++
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %v", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %v", err)
+	}
+	l.file = file
+	l.writers = 1
+	l.size = info.Size()
+	return nil
+}
+
+// Write 写入进程输出，超过大小上限时轮转，并将完整的行推送给订阅者
+func (l *modelLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.manager.console {
+		os.Stdout.Write(p)
+	}
+
+	if l.file != nil {
+		if l.manager.maxSize > 0 && l.size+int64(len(p)) > l.manager.maxSize && l.size > 0 {
+			l.rotate()
+		}
+		if l.file != nil {
+			n, err := l.file.Write(p)
+			l.size += int64(n)
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+
+	l.partial = append(l.partial, p...)
+	for {
+		i := bytes.IndexByte(l.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(bytes.TrimRight(l.partial[:i], "\r"))
+		l.partial = l.partial[i+1:]
+		for ch := range l.subscribers {
+			select {
+			case ch <- line:
+			default:
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// rotate 轮转日志文件：name.log -> name.log.1 -> ... -> name.log.N（调用方需持有锁）
+func (l *modelLog) rotate() {
+	l.file.Close()
+	l.file = nil
+
+	os.Remove(l.path + "." + strconv.Itoa(l.manager.maxFiles))
+	for i := l.manager.maxFiles - 1; i >= 1; i-- {
+		os.Rename(l.path+"."+strconv.Itoa(i), l.path+"."+strconv.Itoa(i+1))
+	}
+	if l.manager.maxFiles > 0 {
+		os.Rename(l.path, l.path+".1")
+	} else {
+		os.Remove(l.path)
+	}
+
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		slog.Warn("Failed to reopen model log file", "model_name", l.name, "error", err)
+		return
+	}
+	l.file = file
+	l.size = 0
+}
+
+// Close 进程退出后调用，最后一个写入者关闭时关闭日志文件
+func (l *modelLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.writers > 0 {
+		l.writers--
+	}
+	if l.writers > 0 || l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// splitLines 将日志内容按行拆分
+func splitLines(data []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		lines = append(lines, string(bytes.TrimRight(scanner.Bytes(), "\r")))
+	}
+	return lines
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogManager_RotateAndTail(t *testing.T) {
+	dir := t.TempDir()
+	m := NewLogManager(dir, 1, 2, false)
+	m.maxSize = 100 // 便于测试轮转
+
+	w, err := m.Writer("qwen/7b")
+	if err != nil {
+		t.Fatalf("Writer failed: %v", err)
+	}
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(w, "line %02d\n", i)
+	}
+	w.Close()
+
+	for _, name := range []string{"qwen_7b.log", "qwen_7b.log.1", "qwen_7b.log.2"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s to exist: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "qwen_7b.log.3")); err == nil {
+		t.Error("Expected at most 2 rotated files")
+	}
+
+	lines, err := m.Tail("qwen/7b", 15)
+	if err != nil {
+		t.Fatalf("Tail failed: %v", err)
+	}
+	if len(lines) != 15 || lines[0] != "line 15" || lines[14] != "line 29" {
+		t.Errorf("Unexpected tail across rotated files: %v", lines)
+	}
+
+	if _, err := m.Tail("missing", 10); err == nil {
+		t.Error("Expected error for model without logs")
+	}
+}
+
+func TestLogManager_Subscribe(t *testing.T) {
+	m := NewLogManager(t.TempDir(), 10, 1, false)
+	lines, unsubscribe := m.Subscribe("chat")
+	defer unsubscribe()
+
+	w, err := m.Writer("chat")
+	if err != nil {
+		t.Fatalf("Writer failed: %v", err)
+	}
+	defer w.Close()
+
+	// 不完整的行在换行到达后才推送
+	w.Write([]byte("server listening"))
+	w.Write([]byte(" on port 8100\r\nslot 0 idle\n"))
+
+	for _, want := range []string{"server listening on port 8100", "slot 0 idle"} {
+		select {
+		case got := <-lines:
+			if got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !windows
+
+package service
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// memlockLimit 获取模型进程可锁定的内存上限（字节），继承自switcher的RLIMIT_MEMLOCK；
+// 不受限制或以root运行（具有CAP_IPC_LOCK）时返回unlimited
+func memlockLimit() (uint64, bool, error) {
+	if os.Geteuid() == 0 {
+		return 0, true, nil
+	}
+	var limit unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_MEMLOCK, &limit); err != nil {
+		return 0, false, fmt.Errorf("failed to get RLIMIT_MEMLOCK: %v", err)
+	}
+	if limit.Cur == unix.RLIM_INFINITY {
+		return 0, true, nil
+	}
+	return limit.Cur, false, nil
+}
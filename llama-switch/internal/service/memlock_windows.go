@@ -0,0 +1,8 @@
+//go:build windows
+
+package service
+
+// memlockLimit Windows没有RLIMIT_MEMLOCK，锁定内存受进程工作集限制，由llama-server自行调整
+func memlockLimit() (uint64, bool, error) {
+	return 0, true, nil
+}
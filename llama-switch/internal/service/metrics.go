@@ -0,0 +1,119 @@
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 指标结果标签的取值
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+// 模型启停的耗时桶（秒）：大模型加载可能需要数分钟
+var modelOpBuckets = []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+// 基准测试的耗时桶（秒）
+var benchmarkBuckets = []float64{10, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200}
+
+var (
+	switchTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "llama_switch_switch_total",
+		Help: "Model start operations (switch, restore, autostart) by model and result.",
+	}, []string{"model_name", "result"})
+	switchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "llama_switch_switch_duration_seconds",
+		Help:    "Time from a start request until the model is ready or the start fails.",
+		Buckets: modelOpBuckets,
+	}, []string{"model_name"})
+	stopTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "llama_switch_stop_total",
+		Help: "Model stop operations by model and result.",
+	}, []string{"model_name", "result"})
+	stopDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "llama_switch_stop_duration_seconds",
+		Help:    "Time taken to stop a model instance.",
+		Buckets: modelOpBuckets,
+	}, []string{"model_name"})
+	restoreFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "llama_switch_restore_failures_total",
+		Help: "Models that could not be restored from the persistent configuration.",
+	}, []string{"model_name"})
+	benchmarkDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "llama_switch_benchmark_duration_seconds",
+		Help:    "Duration of finished benchmark tasks by kind (bench or serving) and status.",
+		Buckets: benchmarkBuckets,
+	}, []string{"kind", "status"})
+)
+
+func init() {
+	prometheus.MustRegister(switchTotal, switchDuration, stopTotal, stopDuration, restoreFailures, benchmarkDuration)
+}
+
+// observeModelOp 记录一次模型启动或停止的结果和耗时
+func observeModelOp(total *prometheus.CounterVec, duration *prometheus.HistogramVec, name string, start time.Time, err error) {
+	result := resultSuccess
+	if err != nil {
+		result = resultFailure
+	}
+	total.WithLabelValues(name, result).Inc()
+	duration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+}
+
+// RegisterMetrics 注册抓取时从服务状态计算的指标：运行中的模型数和各GPU的显存，
+// 启用Metrics.Instances时还包括各模型实例自身的指标
+func (s *ModelService) RegisterMetrics(r prometheus.Registerer) {
+	r.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "llama_switch_models_running",
+			Help: "Number of running model instances.",
+		}, func() float64 {
+			return float64(len(s.processManager.GetRunningModels()))
+		}),
+		&gpuMemoryCollector{s: s},
+	)
+	if s.config.Metrics.Instances {
+		r.MustRegister(&instanceMetrics{s: s})
+	}
+}
+
+// GPU显存指标的描述，标签为GPU编号和名称
+var (
+	gpuMemoryTotalDesc = prometheus.NewDesc("llama_switch_gpu_memory_total_bytes", "Total VRAM per GPU.", []string{"gpu", "name"}, nil)
+	gpuMemoryUsedDesc  = prometheus.NewDesc("llama_switch_gpu_memory_used_bytes", "Used VRAM per GPU.", []string{"gpu", "name"}, nil)
+	gpuMemoryFreeDesc  = prometheus.NewDesc("llama_switch_gpu_memory_free_bytes", "Free VRAM per GPU.", []string{"gpu", "name"}, nil)
+)
+
+// gpuMemoryCollector 按GPU导出显存（启用资源采样时使用最近一次采样结果），查询失败时不导出
+type gpuMemoryCollector struct {
+	s *ModelService
+}
+
+// Describe 实现prometheus.Collector
+func (c *gpuMemoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- gpuMemoryTotalDesc
+	ch <- gpuMemoryUsedDesc
+	ch <- gpuMemoryFreeDesc
+}
+
+// Collect 实现prometheus.Collector
+func (c *gpuMemoryCollector) Collect(ch chan<- prometheus.Metric) {
+	inventory, err := c.s.GPUInventory()
+	if err != nil {
+		return
+	}
+	for _, gpu := range inventory.GPUs {
+		labels := []string{strconv.Itoa(gpu.Index), gpu.Name}
+		ch <- prometheus.MustNewConstMetric(gpuMemoryTotalDesc, prometheus.GaugeValue, mbToBytes(gpu.MemoryTotalMB), labels...)
+		ch <- prometheus.MustNewConstMetric(gpuMemoryUsedDesc, prometheus.GaugeValue, mbToBytes(gpu.MemoryUsedMB), labels...)
+		ch <- prometheus.MustNewConstMetric(gpuMemoryFreeDesc, prometheus.GaugeValue, mbToBytes(gpu.MemoryFreeMB), labels...)
+	}
+}
+
+// mbToBytes 将MB转换为字节
+func mbToBytes(mb int) float64 {
+	return float64(mb) * 1024 * 1024
+}
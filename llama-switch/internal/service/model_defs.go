@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+// modelDefsDirName 默认模型定义目录名
+const modelDefsDirName = "models.d"
+
+// 模型定义事件类型
+const (
+	EventModelDefinitionsChanged = "model_definitions_changed" // 模型定义目录中的定义发生变化
+	EventModelDefinitionError    = "model_definition_error"    // 模型定义文件无法加载或自动启动失败
+)
+
+// modelDefFile 一个模型定义文件的加载结果
+type modelDefFile struct {
+	modTime   time.Time
+	size      int64
+	def       *model.ModelDefinition // 最近一次成功加载的定义，从未加载成功时为nil
+	specified map[string]bool        // 定义的config中显式指定的字段
+	err       error                  // 最近一次加载的错误
+	conflict  error                  // 模型名称已在其他文件中定义
+}
+
+// ModelDefinitions 从模型定义目录（models.d）加载声明式模型定义，定义变化时启动、重启或停止自动启动的模型
+type ModelDefinitions struct {
+	s   *ModelService
+	dir string
+
+	mu    sync.RWMutex
+	files map[string]*modelDefFile // 按文件路径索引
+	defs  map[string]*modelDefFile // 按模型名称索引
+}
+
+// newModelDefinitions 创建模型定义管理器，定义在StartModelDefinitions时加载
+func newModelDefinitions(s *ModelService, dir string) *ModelDefinitions {
+	return &ModelDefinitions{
+		s:     s,
+		dir:   dir,
+		files: make(map[string]*modelDefFile),
+		defs:  make(map[string]*modelDefFile),
+	}
+}
+
+// defaultModelDefsDir 默认模型定义目录：配置文件所在目录下的models.d，未使用配置文件时为程序目录下的models.d
+func defaultModelDefsDir(cfg *config.Config) string {
+	if cfg.File != "" {
+		return filepath.Join(filepath.Dir(cfg.File), modelDefsDirName)
+	}
+	exePath, err := os.Executable()
+	if err != nil {
+		return modelDefsDirName
+	}
+	return filepath.Join(filepath.Dir(exePath), modelDefsDirName)
+}
+
+// StartModelDefinitions 加载模型定义目录并在后台启动自动启动的模型，之后按顺序启动AUTOSTART_MODELS中的模型，
+// 配置了检查间隔时定期检查目录变化
+func (s *ModelService) StartModelDefinitions(ctx context.Context) {
+	d := s.modelDefs
+	updated, _ := d.scan()
+	slog.Info("Loaded model definitions", "count", len(updated), "dir", d.dir)
+
+	interval := time.Duration(s.config.ModelDefs.Interval) * time.Second
+	go func() {
+		// 启动时已恢复的模型保持运行，只启动尚未运行的自动启动模型
+		d.apply(updated, nil, true)
+		s.runAutostart(ctx)
+		if interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				updated, removed := d.scan()
+				if len(updated) == 0 && len(removed) == 0 {
+					continue
+				}
+				message := fmt.Sprintf("Model definitions changed: %d added or updated, %d removed", len(updated), len(removed))
+				slog.Info("Model definitions changed", "updated", len(updated), "removed", len(removed))
+				s.events.Record(EventModelDefinitionsChanged, "", message, map[string]interface{}{
+					"updated": definitionNames(updated),
+					"removed": definitionNames(removed),
+				})
+				d.apply(updated, removed, false)
+			}
+		}
+	}()
+}
+
+// Get 获取模型的定义和定义的config中显式指定的字段，返回的配置可以修改
+func (d *ModelDefinitions) Get(name string) (*model.ModelConfig, map[string]bool, bool) {
+	d.mu.RLock()
+	file, exists := d.defs[name]
+	d.mu.RUnlock()
+	if !exists {
+		return nil, nil, false
+	}
+	return cloneModelConfig(&file.def.ModelConfig), file.specified, true
+}
+
+// List 获取已加载的定义和无法加载的文件
+func (d *ModelDefinitions) List() *model.ModelDefinitionList {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	list := &model.ModelDefinitionList{Dir: d.dir, Definitions: make([]*model.ModelDefinition, 0, len(d.defs))}
+	for _, name := range slices.Sorted(maps.Keys(d.defs)) {
+		def := *d.defs[name].def
+		list.Definitions = append(list.Definitions, &def)
+	}
+	for _, path := range slices.Sorted(maps.Keys(d.files)) {
+		for _, err := range []error{d.files[path].err, d.files[path].conflict} {
+			if err != nil {
+				list.Errors = append(list.Errors, &model.ModelDefinitionError{File: path, Error: err.Error()})
+			}
+		}
+	}
+	return list
+}
+
+// scan 重新加载定义目录中变化的文件，返回新增或变化的定义和被删除的定义（均按模型名称排序）
+// 无法加载的文件保留之前加载成功的定义，避免写入到一半的文件导致模型被停止
+func (d *ModelDefinitions) scan() (updated, removed []*model.ModelDefinition) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to read model definitions directory", "dir", d.dir, "error", err)
+		return nil, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	files := make(map[string]*modelDefFile)
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := strings.ToLower(filepath.Ext(name))
+		if entry.IsDir() || strings.HasPrefix(name, ".") || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		path := filepath.Join(d.dir, name)
+		previous := d.files[path]
+		if previous != nil && previous.modTime.Equal(info.ModTime()) && previous.size == info.Size() {
+			files[path] = previous
+			continue
+		}
+
+		file := &modelDefFile{modTime: info.ModTime(), size: info.Size()}
+		file.def, file.specified, file.err = loadModelDefinition(path)
+		if file.err != nil {
+			d.reportError(file.err)
+			if previous != nil {
+				file.def, file.specified = previous.def, previous.specified
+			}
+		}
+		files[path] = file
+	}
+
+	// 按文件路径顺序建立名称索引，同一模型名称出现在多个文件中时使用第一个
+	defs := make(map[string]*modelDefFile)
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		file := files[path]
+		if file.def == nil {
+			continue
+		}
+		if existing, duplicate := defs[file.def.ModelName]; duplicate {
+			conflict := fmt.Errorf("model definition %s: model '%s' is already defined in %s", path, file.def.ModelName, existing.def.File)
+			if file.conflict == nil || file.conflict.Error() != conflict.Error() {
+				d.reportError(conflict)
+			}
+			file.conflict = conflict
+			continue
+		}
+		file.conflict = nil
+		defs[file.def.ModelName] = file
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(defs)) {
+		if previous, exists := d.defs[name]; !exists || !reflect.DeepEqual(previous.def, defs[name].def) {
+			updated = append(updated, defs[name].def)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(d.defs)) {
+		if _, exists := defs[name]; !exists {
+			removed = append(removed, d.defs[name].def)
+		}
+	}
+	d.files, d.defs = files, defs
+	return updated, removed
+}
+
+// reportError 在日志和事件日志中记录无法加载的定义
+func (d *ModelDefinitions) reportError(err error) {
+	slog.Warn("Invalid model definition", "error", err)
+	d.s.events.Record(EventModelDefinitionError, "", err.Error(), nil)
+}
+
+// apply 按定义的变化启动、重启或停止自动启动的模型，未设置autostart的定义在下一次切换时生效
+// initial为true时（switcher启动）已在运行的模型保持不变
+func (d *ModelDefinitions) apply(updated, removed []*model.ModelDefinition, initial bool) {
+	for _, def := range removed {
+		if !def.Autostart || !d.s.isRunning(def.ModelName) {
+			continue
+		}
+		slog.Info("Model definition was removed, stopping the model", "model_name", def.ModelName)
+		if _, err := d.s.StopModel(def.ModelName); err != nil {
+			slog.Error("Failed to stop model", "model_name", def.ModelName, "error", err)
+		}
+	}
+
+	for _, def := range updated {
+		running := d.s.isRunning(def.ModelName)
+		if !def.Autostart {
+			if running && !initial {
+				slog.Info("Model definition changed, the running instance keeps its config until the next switch", "model_name", def.ModelName)
+			}
+			continue
+		}
+		if running {
+			if initial {
+				continue
+			}
+			slog.Info("Model definition changed, restarting the model", "model_name", def.ModelName)
+			if _, err := d.s.StopModel(def.ModelName); err != nil {
+				slog.Error("Failed to stop model", "model_name", def.ModelName, "error", err)
+				continue
+			}
+		}
+		d.start(def.ModelName)
+	}
+}
+
+// start 按定义启动模型，失败时记录事件
+func (d *ModelDefinitions) start(name string) {
+	cfg, specified, exists := d.Get(name)
+	if !exists {
+		return
+	}
+	d.s.ApplyModelDefaults(cfg, specified)
+
+	err := d.s.ValidateModelConfig(cfg)
+	if err == nil {
+		slog.Info("Autostarting model from its definition", "model_name", name)
+		_, err = d.s.StartModel(cfg)
+	}
+	if err != nil {
+		message := fmt.Sprintf("Failed to autostart model '%s' from its definition: %v", name, err)
+		slog.Error("Failed to autostart model from its definition", "model_name", name, "error", err)
+		d.s.events.Record(EventModelDefinitionError, name, message, nil)
+	}
+}
+
+// isRunning 检查模型是否正在运行
+func (s *ModelService) isRunning(name string) bool {
+	statuses := s.GetModelStatus(name)
+	return len(statuses) > 0 && statuses[0].Running
+}
+
+// loadModelDefinition 加载一个模型定义文件，返回定义和config中显式指定的字段
+func loadModelDefinition(path string) (*model.ModelDefinition, map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read model definition %s: %v", path, err)
+	}
+
+	var def model.ModelDefinition
+	if err := config.Decode(data, filepath.Ext(path), &def); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse model definition %s: %v", path, err)
+	}
+	if def.ModelName == "" {
+		return nil, nil, fmt.Errorf("model definition %s: model_name is required", path)
+	}
+	if def.ModelPath == "" {
+		return nil, nil, fmt.Errorf("model definition %s: model_path is required", path)
+	}
+	def.File = path
+
+	// 与切换请求相同，config中显式指定的字段（包括值为0或false的字段）不使用全局默认配置填充
+	var raw map[string]interface{}
+	config.Decode(data, filepath.Ext(path), &raw)
+	fields, _ := raw["config"].(map[string]interface{})
+	specified := make(map[string]bool, len(fields))
+	for field := range fields {
+		specified[field] = true
+	}
+	return &def, specified, nil
+}
+
+// cloneModelConfig 深拷贝模型配置，避免启动时的修改影响保存的定义
+func cloneModelConfig(cfg *model.ModelConfig) *model.ModelConfig {
+	data, _ := json.Marshal(cfg)
+	var clone model.ModelConfig
+	json.Unmarshal(data, &clone)
+	return &clone
+}
+
+// definitionNames 获取定义的模型名称
+func definitionNames(defs []*model.ModelDefinition) []string {
+	names := make([]string, len(defs))
+	for i, def := range defs {
+		names[i] = def.ModelName
+	}
+	return names
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"llama-switch/internal/config"
+)
+
+func TestModelDefinitionsScan(t *testing.T) {
+	dir := t.TempDir()
+	s := &ModelService{config: &config.Config{}, events: NewEventLog(filepath.Join(t.TempDir(), "events.jsonl"), 10)}
+	d := newModelDefinitions(s, dir)
+
+	write := func(name, content string, age time.Duration) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-age)
+		os.Chtimes(path, mtime, mtime)
+	}
+	write("a.yaml", "model_name: a\nmodel_path: a.gguf\nautostart: true\nconfig:\n  ctx_size: 0\n", time.Hour)
+	write("b.json", `{"model_name": "b", "model_path": "b.gguf"}`, time.Hour)
+	write("dup.yaml", "model_name: a\nmodel_path: other.gguf\n", time.Hour)
+	write("notes.txt", "ignored", time.Hour)
+
+	updated, removed := d.scan()
+	if len(updated) != 2 || updated[0].ModelName != "a" || updated[1].ModelName != "b" || len(removed) != 0 {
+		t.Fatalf("scan = %v, %v", definitionNames(updated), definitionNames(removed))
+	}
+	cfg, specified, exists := d.Get("a")
+	if !exists || cfg.ModelPath != "a.gguf" || !specified["ctx_size"] || specified["threads"] {
+		t.Errorf("Get(a) = %+v, %v, %v", cfg, specified, exists)
+	}
+	if list := d.List(); len(list.Errors) != 1 || list.Errors[0].File != filepath.Join(dir, "dup.yaml") {
+		t.Errorf("expected duplicate error for dup.yaml: %+v", list.Errors)
+	}
+
+	// 未变化的文件不会重新报告
+	if updated, removed := d.scan(); len(updated) != 0 || len(removed) != 0 {
+		t.Errorf("unchanged scan = %v, %v", definitionNames(updated), definitionNames(removed))
+	}
+
+	// 无法解析的文件保留之前的定义，删除的文件移除定义
+	write("a.yaml", "model_name: a\nunknown_field: 1\n", 0)
+	os.Remove(filepath.Join(dir, "b.json"))
+	updated, removed = d.scan()
+	if len(updated) != 0 || len(removed) != 1 || removed[0].ModelName != "b" {
+		t.Errorf("scan after changes = %v, %v", definitionNames(updated), definitionNames(removed))
+	}
+	if _, _, exists := d.Get("a"); !exists {
+		t.Error("definition a dropped after a parse error")
+	}
+	if list := d.List(); len(list.Errors) != 2 {
+		t.Errorf("expected parse and duplicate errors: %+v", list.Errors)
+	}
+}
@@ -2,17 +2,20 @@ package service
 
 import (
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/auth"
 	"llama-switch/internal/config"
 	"llama-switch/internal/model"
 )
@@ -22,24 +25,193 @@ type ModelService struct {
 	config         *config.Config
 	processManager *ProcessManager
 	persistentMgr  *config.PersistentManager
+	tracker        *RequestTracker
+	timeshare      *TimeShareManager
+	aliases        *AliasManager
+	evals          *EvalManager
+	downloads      *DownloadManager
+	operations     *OperationManager
+	routes         *RouteManager
+	logs           *LogManager
+	health         *HealthMonitor
+	resources      *ResourceSampler
+	gpu            GPUProvider
+	events         *EventLog
+	audit          *AuditLog // 修改类API请求的审计日志，禁用时为nil
+	webhooks       *WebhookManager
+	bus            *EventBus // 生命周期事件总线，同时通知Webhook和实时事件流
+	thermal        *thermalMonitor
+	admission      *admissionController
+	rpc            *rpcRegistry
+	vramHistory    *VRAMHistory
+	modelDefs      *ModelDefinitions
+	jwt            *auth.JWTValidator                   // 验证身份提供方签发的JWT，未配置JWT_ISSUER时为nil
+	serverVersions map[string]*model.LlamaServerVersion // 启动或重新加载配置时检测到的llama-server版本，键为构建配置名称（默认为空）
+	configs        map[string]*model.ModelConfig        // 运行中模型的启动配置
+	paused         map[string]bool                      // 为基准测试独占GPU而暂停的模型
+	configMu       sync.RWMutex
+	rerankMu       sync.Mutex // 串行化默认重排序模型的自动启动
+	reloadMu       sync.Mutex // 串行化配置的重新加载
 	mu             sync.RWMutex
 	autoRestore    bool
 }
 
 // NewModelService 创建新的模型服务管理器
 func NewModelService(cfg *config.Config, autoRestore bool) *ModelService {
-	return &ModelService{
+	s := &ModelService{
 		config:         cfg,
 		processManager: NewProcessManager(),
 		persistentMgr:  config.NewPersistentManager(cfg),
+		tracker:        NewRequestTracker(cfg.Proxy.QueueSize, time.Duration(cfg.Proxy.QueueTimeout)*time.Second),
+		downloads:      NewDownloadManager(cfg.Download.HFEndpoint, cfg.Download.MaxConcurrent),
+		routes:         NewRouteManager(),
+		gpu:            newGPUProvider(cfg.GPU.Provider),
+		jwt:            newJWTValidator(cfg),
+		configs:        make(map[string]*model.ModelConfig),
+		paused:         make(map[string]bool),
 		autoRestore:    autoRestore,
 	}
+	if err := s.persistentMgr.Migrate(); err != nil {
+		slog.Warn("Failed to migrate persistent config", "dir", cfg.PersistentDir, "error", err)
+	}
+	s.timeshare = newTimeShareManager(s)
+	s.health = newHealthMonitor(s)
+	s.resources = newResourceSampler(s, cfg.Resources.HistorySize)
+	s.thermal = newThermalMonitor(cfg)
+	s.admission = newAdmissionController()
+	s.rpc = newRPCRegistry(cfg.RPC.Pools)
+
+	aliasPath := cfg.Alias.File
+	if aliasPath == "" {
+		aliasPath = defaultAliasPath()
+	}
+	s.aliases = NewAliasManager(aliasPath, cfg.Alias.WebhookURL)
+
+	evalPath := cfg.Eval.File
+	if evalPath == "" {
+		evalPath = defaultEvalPath()
+	}
+	s.evals = newEvalManager(s, evalPath, time.Duration(cfg.Eval.Timeout)*time.Second)
+
+	logDir := cfg.ModelLog.Dir
+	if logDir == "" {
+		logDir = defaultLogDir()
+	}
+	s.logs = NewLogManager(logDir, int(cfg.ModelLog.MaxSizeMB), cfg.ModelLog.MaxFiles, cfg.ModelLog.Console)
+	s.operations = newOperationManager(s)
+
+	eventsPath := cfg.Events.File
+	if eventsPath == "" {
+		eventsPath = defaultEventsPath()
+	}
+	s.events = NewEventLog(eventsPath, cfg.Events.History)
+
+	webhooksPath := cfg.Webhooks.File
+	if webhooksPath == "" {
+		webhooksPath = defaultWebhooksPath()
+	}
+	s.webhooks = NewWebhookManager(webhooksPath, cfg.Webhooks.MaxAttempts, time.Duration(cfg.Webhooks.RetryDelay)*time.Second)
+	s.bus = NewEventBus(s.webhooks)
+	s.processManager.onCrash = s.modelCrashed
+
+	if cfg.Audit.Enabled {
+		auditPath := cfg.Audit.File
+		if auditPath == "" {
+			auditPath = defaultAuditPath()
+		}
+		s.audit = NewAuditLog(auditPath)
+	}
+
+	historyDir := cfg.VRAMHistory.Dir
+	if historyDir == "" {
+		historyDir = defaultVRAMHistoryDir()
+	}
+	s.vramHistory = NewVRAMHistory(historyDir, int(cfg.VRAMHistory.Interval), cfg.VRAMHistory.MaxSamples)
+
+	modelDefsDir := cfg.ModelDefs.Dir
+	if modelDefsDir == "" {
+		modelDefsDir = defaultModelDefsDir(cfg)
+	}
+	s.modelDefs = newModelDefinitions(s, modelDefsDir)
+
+	s.detectServerVersions()
+	return s
+}
+
+// Tracker 获取推理请求跟踪器
+func (s *ModelService) Tracker() *RequestTracker {
+	return s.tracker
+}
+
+// TimeShare 获取分时共享管理器
+func (s *ModelService) TimeShare() *TimeShareManager {
+	return s.timeshare
+}
+
+// Aliases 获取模型别名管理器
+func (s *ModelService) Aliases() *AliasManager {
+	return s.aliases
+}
+
+// Evals 获取准确性冒烟测试管理器
+func (s *ModelService) Evals() *EvalManager {
+	return s.evals
+}
+
+// Downloads 获取模型下载管理器
+func (s *ModelService) Downloads() *DownloadManager {
+	return s.downloads
+}
+
+// Routes 获取流量路由管理器
+func (s *ModelService) Routes() *RouteManager {
+	return s.routes
+}
+
+// Logs 获取模型日志管理器
+func (s *ModelService) Logs() *LogManager {
+	return s.logs
+}
+
+// Events 获取运行事件日志
+func (s *ModelService) Events() *EventLog {
+	return s.events
+}
+
+// Audit 获取审计日志，禁用时为nil
+func (s *ModelService) Audit() *AuditLog {
+	return s.audit
+}
+
+// VRAMHistory 获取持久化的显存使用历史
+func (s *ModelService) VRAMHistory() *VRAMHistory {
+	return s.vramHistory
+}
+
+// Definitions 获取声明式模型定义
+func (s *ModelService) Definitions() *ModelDefinitions {
+	return s.modelDefs
+}
+
+// GPU 获取显存查询使用的GPU工具
+func (s *ModelService) GPU() GPUProvider {
+	return s.gpu
+}
+
+// Health 获取健康检查器
+func (s *ModelService) Health() *HealthMonitor {
+	return s.health
+}
+
+// Resources 获取资源采样器
+func (s *ModelService) Resources() *ResourceSampler {
+	return s.resources
 }
 
 // RestoreModels 从持久化配置恢复模型
 func (s *ModelService) RestoreModels() error {
 	if !s.autoRestore {
-		log.Println("Auto restore is disabled, skipping model restoration")
+		slog.Info("Auto restore is disabled, skipping model restoration")
 		return nil
 	}
 
@@ -49,7 +221,7 @@ func (s *ModelService) RestoreModels() error {
 	}
 
 	if len(configs) == 0 {
-		log.Println("No models to restore")
+		slog.Info("No models to restore")
 		return nil
 	}
 
@@ -64,35 +236,37 @@ func (s *ModelService) RestoreModels() error {
 
 		// 检查模型是否已在运行
 		if item.LastStatus.Running && item.LastStatus.ProcessID > 0 {
-			// 验证进程是否实际存在
-			if s.processManager.IsProcessRunning(item.LastStatus.ProcessID) {
-				log.Printf("Model %s is already running (PID: %d), skipping restore",
-					modelName, item.LastStatus.ProcessID)
+			// 已被本次运行跟踪的模型（例如重复调用恢复）无需处理
+			if s.processManager.FindModel(modelName) != nil {
 				continue
-			} else {
-				// 进程已终止但状态未更新，修正状态
-				log.Printf("Model %s process (PID: %d) not found, updating status",
-					modelName, item.LastStatus.ProcessID)
-				item.LastStatus.Running = false
-				item.LastStatus.StopTime = time.Now().Format(time.RFC3339)
-				if err := s.persistentMgr.UpdateModelConfig(modelName, item.ModelConfig, &item.LastStatus); err != nil {
-					log.Printf("Warning: Failed to update model status: %v", err)
-				}
+			}
+			// switcher重启前启动的实例仍在运行时直接接管
+			if s.adoptModel(modelName, item) {
+				restoredCount++
 				continue
 			}
+			// 进程已终止但状态未更新（如switcher重启），修正状态后重新启动
+			slog.Warn("Model process not found, restarting it", "model_name", modelName, "pid", item.LastStatus.ProcessID)
+			item.LastStatus.Running = false
+			item.LastStatus.StopTime = time.Now().Format(time.RFC3339)
+			if err := s.persistentMgr.UpdateModelConfig(modelName, item.ModelConfig, &item.LastStatus); err != nil {
+				slog.Warn("Failed to update model status", "model_name", modelName, "error", err)
+			}
 		}
 
 		// 验证模型配置
 		if err := s.ValidateModelConfig(item.ModelConfig); err != nil {
-			log.Printf("Invalid config for model %s: %v", modelName, err)
+			slog.Error("Invalid model config", "model_name", modelName, "error", err)
+			restoreFailures.WithLabelValues(modelName).Inc()
 			lastError = err
 			continue
 		}
 
-		log.Printf("Restoring model: %s", modelName)
+		slog.Info("Restoring model", "model_name", modelName)
 		_, err := s.StartModel(item.ModelConfig)
 		if err != nil {
-			log.Printf("Failed to restore model %s: %v", modelName, err)
+			slog.Error("Failed to restore model", "model_name", modelName, "error", err)
+			restoreFailures.WithLabelValues(modelName).Inc()
 			lastError = err
 			continue
 		}
@@ -100,7 +274,7 @@ func (s *ModelService) RestoreModels() error {
 	}
 
 	if restoredCount > 0 {
-		log.Printf("Successfully restored %d models", restoredCount)
+		slog.Info("Restored models", "count", restoredCount)
 	}
 
 	if lastError != nil {
@@ -112,10 +286,15 @@ func (s *ModelService) RestoreModels() error {
 
 // GetModelList 获取所有GGUF模型列表
 func (s *ModelService) GetModelList() ([]model.ModelInfo, error) {
+	return listGGUFModels(s.config.Snapshot().ModelsDir)
+}
+
+// listGGUFModels 列出目录下的GGUF模型文件，按名称排序
+func listGGUFModels(dir string) ([]model.ModelInfo, error) {
 	var models []model.ModelInfo
 
 	// 读取模型目录
-	entries, err := os.ReadDir(s.config.ModelsDir)
+	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read models directory: %v", err)
 	}
@@ -134,12 +313,12 @@ func (s *ModelService) GetModelList() ([]model.ModelInfo, error) {
 		// 获取文件信息
 		info, err := entry.Info()
 		if err != nil {
-			log.Printf("Warning: failed to get info for %s: %v", entry.Name(), err)
+			slog.Warn("Failed to get file info", "file", entry.Name(), "error", err)
 			continue
 		}
 
 		// 构建模型信息
-		modelPath := filepath.Join(s.config.ModelsDir, entry.Name())
+		modelPath := filepath.Join(dir, entry.Name())
 		models = append(models, model.ModelInfo{
 			Name: entry.Name(),
 			Path: modelPath,
@@ -155,66 +334,100 @@ func (s *ModelService) GetModelList() ([]model.ModelInfo, error) {
 	return models, nil
 }
 
-// freeVRAM 释放足够显存(优先释放大显存模型)
-func (s *ModelService) freeVRAM(required int) error {
-	// 获取按显存使用排序的模型列表
-	models := s.processManager.GetModelsByVRAMUsage()
+// freeVRAM 按驱逐策略（EVICTION_POLICY）依次停止模型直到释放足够显存
+// 固定的模型不会被驱逐，force为false时跳过正在使用的模型，tenant不为空时只驱逐该租户的模型
+func (s *ModelService) freeVRAM(required int, force bool, tenant string) error {
+	// 以实际占用排序，优先驱逐占用最多的模型
+	if usage, err := s.gpu.ProcessMemory(); err == nil {
+		s.refreshVRAMUsage(usage)
+	}
+	// 属于租户的请求只驱逐该租户自己的模型
+	running := tenantModels(s.processManager.GetModelsByVRAMUsage(), tenant)
+	return s.evictModels(running, required, force, "VRAM", s.getTotalAvailableVRAM)
+}
+
+// evictModels 按驱逐策略依次停止running中的模型，直到available报告的可用量(MB)比开始时增加required
+// running按资源占用从大到小排序，resource为释放的资源名称（VRAM/RAM），用于日志和错误信息
+func (s *ModelService) evictModels(running []*model.ModelStatus, required int, force bool, resource string, available func() (int, error)) error {
+	// 获取按驱逐策略排序的模型列表
+	models, pinnedModels := s.evictionOrder(running)
 	if len(models) == 0 {
-		return fmt.Errorf("no running models to free VRAM from")
+		if len(pinnedModels) > 0 {
+			return fmt.Errorf("no running models to free %s from (pinned models are never evicted: %s)", resource, strings.Join(pinnedModels, ", "))
+		}
+		return fmt.Errorf("no running models to free %s from", resource)
 	}
 
-	// 获取初始可用显存
-	initialFree, err := s.getTotalAvailableVRAM()
+	// 获取初始可用量
+	initialFree, err := available()
 	if err != nil {
-		return fmt.Errorf("failed to get initial VRAM: %v", err)
+		return fmt.Errorf("failed to get initial %s: %v", resource, err)
 	}
 
 	stoppedModels := make([]string, 0)
+	busyModels := make([]string, 0)
 	currentFree := initialFree
 
 	for _, m := range models {
-		// 获取停止前的可用显存
+		if !force {
+			if err := s.CheckIdle(m.ModelName); err != nil {
+				slog.Info("Skipping eviction of model", "model_name", m.ModelName, "reason", err)
+				busyModels = append(busyModels, m.ModelName)
+				continue
+			}
+		}
+
+		// 获取停止前的可用量
 		beforeStop := currentFree
 
 		// 尝试停止模型进程
-		if err := s.processManager.stopProcessByPID(m.ProcessID); err != nil {
-			log.Printf("Warning: failed to stop model %s (PID: %d): %v",
-				m.ModelName, m.ProcessID, err)
+		if err := s.processManager.StopPID(m.ProcessID); err != nil {
+			slog.Warn("Failed to stop model", "model_name", m.ModelName, "pid", m.ProcessID, "error", err)
 			continue
 		}
 
-		// 等待显存释放（通常需要一点时间）
-		time.Sleep(1 * time.Second)
-
-		// 获取停止后的可用显存
-		afterStop, err := s.getTotalAvailableVRAM()
+		// 等待驱动释放内存，未使用GPU的模型停止后显存不会增加，读数稳定即可
+		expectIncrease := resource != "VRAM" || m.VRAMUsage > 0
+		afterStop, err := s.waitForRelease(available, beforeStop, expectIncrease)
 		if err != nil {
-			log.Printf("Warning: failed to get VRAM after stopping model %s: %v",
-				m.ModelName, err)
+			slog.Warn("Failed to get free memory after stopping model", "resource", resource, "model_name", m.ModelName, "error", err)
 			continue
 		}
 
-		// 计算实际释放的显存
+		// 计算实际释放的量
 		freedByThisModel := afterStop - beforeStop
 		currentFree = afterStop
 
 		s.processManager.RemoveModel(m.ProcessID)
+		s.tracker.Remove(m.ModelName)
+		s.markStopped(m.ModelName)
 		stoppedModels = append(stoppedModels, m.ModelName)
+		s.bus.Publish(WebhookModelEvicted, map[string]interface{}{
+			"model":    m,
+			"resource": resource,
+			"freed_mb": freedByThisModel,
+		})
 
-		log.Printf("Stopped model %s, freed %dMB VRAM", m.ModelName, freedByThisModel)
+		slog.Info("Stopped model to free memory", "model_name", m.ModelName, "freed_mb", freedByThisModel, "resource", resource)
 
-		// 检查是否已释放足够显存
+		// 检查是否已释放足够的量
 		totalFreed := currentFree - initialFree
 		if totalFreed >= required {
-			log.Printf("Successfully freed %dMB VRAM by stopping models: %s",
-				totalFreed, strings.Join(stoppedModels, ", "))
+			slog.Info("Freed memory by stopping models", "freed_mb", totalFreed, "resource", resource, "models", strings.Join(stoppedModels, ", "))
 			return nil
 		}
 	}
 
 	totalFreed := currentFree - initialFree
-	return fmt.Errorf("could only free %dMB of %dMB required VRAM after stopping models: %s",
-		totalFreed, required, strings.Join(stoppedModels, ", "))
+	skipped := ""
+	if len(busyModels) > 0 {
+		skipped += fmt.Sprintf(" (skipped models in use: %s, use force=true to evict them)", strings.Join(busyModels, ", "))
+	}
+	if len(pinnedModels) > 0 {
+		skipped += fmt.Sprintf(" (pinned models are never evicted: %s)", strings.Join(pinnedModels, ", "))
+	}
+	return fmt.Errorf("could only free %dMB of %dMB required %s after stopping models: %s%s",
+		totalFreed, required, resource, strings.Join(stoppedModels, ", "), skipped)
 }
 
 // StartModel 启动模型服务并返回状态
@@ -227,13 +440,20 @@ func (s *ModelService) StartModel(cfg *model.ModelConfig) (status *model.ModelSt
 	if cfg.ModelName == "" {
 		return nil, fmt.Errorf("model name is required")
 	}
+	start := time.Now()
+	defer func() { observeModelOp(switchTotal, switchDuration, cfg.ModelName, start, err) }()
+
+	// 模型文件不在本地时先下载，以便基于文件大小进行显存检查
+	if err := s.ensureModelFile(cfg); err != nil {
+		return nil, err
+	}
 
 	// 检查是否存在同名模型
 	existingModels := s.GetModelStatus(cfg.ModelName)
 	if len(existingModels) > 0 {
 		// 如果模型已存在且正在运行，直接返回
 		if existingModels[0].Running {
-			return nil, fmt.Errorf("model with name '%s' is already running", cfg.ModelName)
+			return nil, apierror.New(apierror.CodeModelAlreadyRunning, "model with name '%s' is already running", cfg.ModelName)
 		}
 		// 如果模型存在但已停止，从管理器中移除
 		s.processManager.RemoveModel(existingModels[0].ProcessID)
@@ -242,7 +462,7 @@ func (s *ModelService) StartModel(cfg *model.ModelConfig) (status *model.ModelSt
 	// 验证模型文件路径
 	modelPath := cfg.ModelPath
 	if !filepath.IsAbs(modelPath) {
-		modelPath = filepath.Join(s.config.ModelsDir, cfg.ModelPath)
+		modelPath = filepath.Join(s.config.Snapshot().ModelsDir, cfg.ModelPath)
 	}
 	if _, err := filepath.Abs(modelPath); err != nil {
 		return nil, fmt.Errorf("invalid model path: %v", err)
@@ -250,590 +470,271 @@ func (s *ModelService) StartModel(cfg *model.ModelConfig) (status *model.ModelSt
 
 	// 获取模型文件大小
 	fileInfo, err := os.Stat(modelPath)
+	if os.IsNotExist(err) {
+		return nil, apierror.New(apierror.CodeModelFileNotFound, "model file not found: %s", modelPath)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get model file info: %v", err)
 	}
 	modelSizeMB := fileInfo.Size() / (1024 * 1024)
 
+	// 容量决策串行进行，通过检查的模型在启动结束（就绪或失败）前预留估算的显存
+	s.admission.decide.Lock()
+	endAdmission := sync.OnceFunc(s.admission.decide.Unlock)
+	defer endAdmission()
+
+	// auto参数在副本上填入计算出的值，持久化配置中保留auto以便恢复时重新计算
+	requested := cfg
+	resolved := *cfg
+	cfg = &resolved
+
+	// 使用RPC池时以池中健康的端点作为--rpc，池中可分配的显存计入容量
+	var rpc *rpcAllocation
+	if cfg.RPCPool != "" {
+		if rpc, err = s.allocateRPC(cfg.RPCPool); err != nil {
+			return nil, err
+		}
+		cfg.Config.RPC = strings.Join(rpc.Endpoints, ",")
+		slog.Info("Using RPC pool", "pool", rpc.Pool, "model_name", cfg.ModelName, "rpc", cfg.Config.RPC, "free_mb", rpc.FreeMB)
+	}
+	rpcFree := 0
+	if rpc != nil {
+		rpcFree = rpc.FreeMB
+	}
+
+	var offload *model.OffloadDecision
+	if cfg.Config.NGPULayers == model.GPULayersAuto {
+		if offload, err = s.autoOffload(cfg, modelPath, rpcFree); err != nil {
+			return nil, err
+		}
+		cfg.Config.NGPULayers = model.GPULayers(offload.Layers)
+	}
+
 	// 估算所需显存
-	requiredVRAM := min(s.estimateVRAMUsage(cfg), int(modelSizeMB))
+	estimate := s.estimateVRAM(cfg, modelPath, int(modelSizeMB))
+	requiredVRAM := estimate.TotalMB
 
 	// 记录估算信息
-	log.Printf("Model VRAM estimation - FileSize: %dMB, EstimatedVRAM: %dMB",
-		modelSizeMB, requiredVRAM)
+	slog.Info("Model VRAM estimation", "model_name", cfg.ModelName, "file_size_mb", modelSizeMB, "estimated_vram_mb", requiredVRAM, "source", estimate.Source)
 
 	// 检查显存
+	var gpus, freeMemory []int
+	localVRAM, rpcVRAM := requiredVRAM, 0
 	if cfg.ForceVRAM || cfg.Config.NGPULayers > 0 {
-		available, err := s.getTotalAvailableVRAM()
+		if err := s.checkQuota(cfg, requiredVRAM); err != nil {
+			return nil, err
+		}
+		freeMemory, err = s.freeMemory()
 		if err != nil {
 			return nil, fmt.Errorf("failed to check VRAM: %v", err)
 		}
-		log.Printf("Available VRAM: %dMB", available)
+		totalAvailable := rpcFree
+		for _, mem := range freeMemory {
+			totalAvailable += mem
+		}
+		if rpc != nil {
+			slog.Info("Available VRAM", "available_mb", totalAvailable, "per_gpu_mb", freeMemory, "rpc_pool", rpc.Pool, "rpc_free_mb", rpcFree)
+		} else {
+			slog.Info("Available VRAM", "available_mb", totalAvailable, "per_gpu_mb", freeMemory)
+		}
 
-		totalAvailable := available
 		if totalAvailable < requiredVRAM {
 			// 如果强制使用显存，尝试释放
 			if cfg.ForceVRAM {
-				log.Printf("Insufficient VRAM (required: %dMB based on model size %dMB, available: %dMB), freeing VRAM", requiredVRAM, modelSizeMB, totalAvailable)
-				if err := s.freeVRAM(requiredVRAM - totalAvailable); err != nil {
-					return nil, fmt.Errorf("insufficient VRAM (required: %dMB based on model size %dMB, available: %dMB): %v",
-						requiredVRAM, modelSizeMB, totalAvailable, err)
+				slog.Info("Insufficient VRAM, freeing VRAM", "model_name", cfg.ModelName, "required_mb", requiredVRAM, "model_size_mb", modelSizeMB, "available_mb", totalAvailable)
+				if err := s.freeVRAM(requiredVRAM-totalAvailable, cfg.Force, cfg.Tenant); err != nil {
+					return nil, apierror.New(apierror.CodeInsufficientVRAM, "insufficient VRAM (required: %dMB by %s estimate for model size %dMB, available: %dMB): %v",
+						requiredVRAM, estimate.Source, modelSizeMB, totalAvailable, err)
 				}
 			} else {
 				// 如果不强制使用显存，返回错误
-				return nil, fmt.Errorf("insufficient VRAM (required: %dMB based on model size %dMB, available: %dMB). Use force_vram=true to force start",
-					requiredVRAM, modelSizeMB, totalAvailable)
+				return nil, apierror.New(apierror.CodeInsufficientVRAM, "insufficient VRAM (required: %dMB by %s estimate for model size %dMB, available: %dMB). Use force_vram=true to force start",
+					requiredVRAM, estimate.Source, modelSizeMB, totalAvailable)
+			}
+			if freeMemory, err = s.freeMemory(); err != nil {
+				return nil, fmt.Errorf("failed to check VRAM: %v", err)
 			}
 		}
-	}
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// 构建命令行参数
-	args := []string{
-		"--model", modelPath,
-	}
-
-	c := cfg.Config
-
-	// 服务器配置
-	if c.Host != "" {
-		args = append(args, "--host", c.Host)
-	}
-	if c.Port > 0 {
-		args = append(args, "--port", strconv.Itoa(c.Port))
-	}
-	if c.Timeout > 0 {
-		args = append(args, "--timeout", strconv.Itoa(c.Timeout))
-	}
 
-	// 系统资源配置
-	if c.Threads > 0 {
-		args = append(args, "--threads", strconv.Itoa(c.Threads))
-	}
-	if c.ThreadsBatch > 0 {
-		args = append(args, "--threads-batch", strconv.Itoa(c.ThreadsBatch))
-	}
-	if c.CPUMask != "" {
-		args = append(args, "--cpu-mask", c.CPUMask)
-	}
-	if c.CPURange != "" {
-		args = append(args, "--cpu-range", c.CPURange)
-	}
-	if c.CPUStrict > 0 {
-		args = append(args, "--cpu-strict", strconv.Itoa(c.CPUStrict))
-	}
-	if c.Priority > 0 {
-		args = append(args, "--prio", strconv.Itoa(c.Priority))
-	}
-	if c.Poll >= 0 {
-		args = append(args, "--poll", strconv.Itoa(c.Poll))
-	}
+		localFree := 0
+		for _, mem := range freeMemory {
+			localFree += mem
+		}
+		rpcVRAM = rpcShare(requiredVRAM, localFree, rpcFree)
+		localVRAM = requiredVRAM - rpcVRAM
 
-	// 模型参数
-	if c.CtxSize > 0 {
-		args = append(args, "--ctx-size", strconv.Itoa(c.CtxSize))
-	}
-	if c.BatchSize > 0 {
-		args = append(args, "--batch-size", strconv.Itoa(c.BatchSize))
-	}
-	if c.UBatchSize > 0 {
-		args = append(args, "--ubatch-size", strconv.Itoa(c.UBatchSize))
-	}
-	if c.NPredict != 0 {
-		args = append(args, "--n-predict", strconv.Itoa(c.NPredict))
+		gpus = s.placeModel(cfg, freeMemory, requiredVRAM)
+		if len(gpus) > 0 {
+			slog.Info("Placing model on GPUs", "model_name", cfg.ModelName, "gpus", gpus)
+		}
 	}
-	if c.Keep != 0 {
-		args = append(args, "--keep", strconv.Itoa(c.Keep))
+	var splitEnv []string
+	if split, auto := s.autoTensorSplit(cfg, freeMemory, gpus); auto {
+		cfg.Config.TensorSplit = split
+		if split != "" {
+			slog.Info("Splitting model across GPUs by free VRAM", "model_name", cfg.ModelName, "tensor_split", split)
+			splitEnv = s.tensorSplitEnv()
+		}
 	}
 
-	// GPU相关配置
-	if c.NGPULayers > 0 {
-		args = append(args, "--n-gpu-layers", strconv.Itoa(c.NGPULayers))
+	// 检查主机内存（未卸载到GPU的部分）
+	if err := s.checkRAM(cfg, modelPath, int(modelSizeMB)); err != nil {
+		return nil, err
 	}
-	if c.SplitMode != "" {
-		args = append(args, "--split-mode", c.SplitMode)
+	if freeMemory != nil {
+		id := s.admission.reserve(cfg.Tenant, reservation(freeMemory, gpus, localVRAM), requiredVRAM)
+		defer s.admission.release(id)
 	}
-	if c.TensorSplit != "" {
-		args = append(args, "--tensor-split", c.TensorSplit)
-	}
-	if c.MainGPU >= 0 {
-		args = append(args, "--main-gpu", strconv.Itoa(c.MainGPU))
-	}
-	if c.Device != "" {
-		args = append(args, "--device", c.Device)
+	if rpc != nil {
+		id := s.rpc.reserve(rpc.Pool, rpcVRAM)
+		defer s.rpc.release(id)
 	}
+	endAdmission()
 
-	// 内存管理
-	if c.Mlock {
-		args = append(args, "--mlock")
-	}
-	if c.NoMMap {
-		args = append(args, "--no-mmap")
-	}
-	if c.Numa != "" {
-		args = append(args, "--numa", c.Numa)
-	}
-	if c.NoKVOffload {
-		args = append(args, "--no-kv-offload")
-	}
+	// 启动阶段（构建参数、分配端口、启动进程）持有s.mu，等待就绪时释放
+	s.mu.Lock()
+	locked := true
+	defer func() {
+		if locked {
+			s.mu.Unlock()
+		}
+	}()
 
-	// 缓存配置
-	if c.CacheTypeK != "" {
-		args = append(args, "--cache-type-k", c.CacheTypeK)
-	}
-	if c.CacheTypeV != "" {
-		args = append(args, "--cache-type-v", c.CacheTypeV)
-	}
-	if c.DefragThold > 0 {
-		args = append(args, "--defrag-thold", fmt.Sprintf("%.2f", c.DefragThold))
+	// 未指定端口时自动分配，持久化配置中保留为0以便恢复时重新分配
+	port, err := s.assignPort(cfg.Config.Host, cfg.Config.Port)
+	if err != nil {
+		return nil, err
 	}
 
-	// 性能优化
-	if c.FlashAttn {
-		args = append(args, "--flash-attn")
-	}
-	if c.NoPerfTimer {
-		args = append(args, "--no-perf")
-	}
+	// 构建命令行参数
+	args := buildServerArgs(cfg, modelPath, port)
 
-	// RoPE配置
-	if c.RopeScaling != "" {
-		args = append(args, "--rope-scaling", c.RopeScaling)
-	}
-	if c.RopeScale > 0 {
-		args = append(args, "--rope-scale", fmt.Sprintf("%.2f", c.RopeScale))
-	}
-	if c.RopeFreqBase > 0 {
-		args = append(args, "--rope-freq-base", fmt.Sprintf("%.2f", c.RopeFreqBase))
+	binary, err := s.serverBinary(cfg.BackendProfile)
+	if err != nil {
+		return nil, err
 	}
-	if c.RopeFreqScale > 0 {
-		args = append(args, "--rope-freq-scale", fmt.Sprintf("%.2f", c.RopeFreqScale))
+	if _, err := exec.LookPath(binary); err != nil {
+		return nil, apierror.New(apierror.CodeBinaryMissing, "llama-server binary not available: %v", err)
 	}
 
-	// YaRN配置
-	if c.YarnOrigCtx > 0 {
-		args = append(args, "--yarn-orig-ctx", strconv.Itoa(c.YarnOrigCtx))
-	}
-	if c.YarnExtFactor >= 0 {
-		args = append(args, "--yarn-ext-factor", fmt.Sprintf("%.2f", c.YarnExtFactor))
-	}
-	if c.YarnAttnFactor > 0 {
-		args = append(args, "--yarn-attn-factor", fmt.Sprintf("%.2f", c.YarnAttnFactor))
-	}
-	if c.YarnBetaSlow > 0 {
-		args = append(args, "--yarn-beta-slow", fmt.Sprintf("%.2f", c.YarnBetaSlow))
-	}
-	if c.YarnBetaFast > 0 {
-		args = append(args, "--yarn-beta-fast", fmt.Sprintf("%.2f", c.YarnBetaFast))
-	}
+	// 打印启动命令
+	cmdStr := fmt.Sprintf("%s %s", binary, strings.Join(args, " "))
+	slog.Info("Starting model", "model_name", cfg.ModelName, "command", cmdStr)
 
-	// 其他功能
-	if c.Verbose {
-		args = append(args, "--verbose")
-	}
-	if c.LogFile != "" {
-		args = append(args, "--log-file", c.LogFile)
-	}
-	if c.StaticPath != "" {
-		args = append(args, "--path", c.StaticPath)
-	}
-	if c.APIKey != "" {
-		args = append(args, "--api-key", c.APIKey)
-	}
-	if c.SSLKey != "" {
-		args = append(args, "--ssl-key-file", c.SSLKey)
-	}
-	if c.SSLCert != "" {
-		args = append(args, "--ssl-cert-file", c.SSLCert)
+	// 实例输出写入模型日志文件
+	var output io.Writer
+	if writer, err := s.logs.Writer(cfg.ModelName); err != nil {
+		slog.Warn("Failed to open model log file, logging to console", "model_name", cfg.ModelName, "error", err)
+	} else {
+		output = writer
 	}
+	// 同时保留最近的输出，用于启动失败时返回诊断信息
+	tail := newOutputTail(s.config.Startup.OutputLines)
 
-	// 新增参数 - 通用参数
-	if c.Help {
-		args = append(args, "--help")
-	}
-	if c.Version {
-		args = append(args, "--version")
-	}
-	if c.CompletionBash {
-		args = append(args, "--completion-bash")
-	}
-	if c.VerbosePrompt {
-		args = append(args, "--verbose-prompt")
-	}
-	if c.Escape {
-		args = append(args, "--escape")
-	}
-	if c.NoEscape {
-		args = append(args, "--no-escape")
-	}
-	if c.DumpKVCache {
-		args = append(args, "--dump-kv-cache")
-	}
-	if c.CheckTensors {
-		args = append(args, "--check-tensors")
-	}
-	if c.RPC != "" {
-		args = append(args, "--rpc", c.RPC)
-	}
-	if c.Parallel > 0 {
-		args = append(args, "--parallel", strconv.Itoa(c.Parallel))
-	}
-	if c.OverrideTensor != "" {
-		args = append(args, "--override-tensor", c.OverrideTensor)
-	}
-	if c.ListDevices {
-		args = append(args, "--list-devices")
-	}
-	if c.Lora != "" {
-		args = append(args, "--lora", c.Lora)
-	}
-	if c.LoraScaled != "" {
-		args = append(args, "--lora-scaled", c.LoraScaled)
-	}
-	if c.ControlVector != "" {
-		args = append(args, "--control-vector", c.ControlVector)
-	}
-	if c.ControlVectorScaled != "" {
-		args = append(args, "--control-vector-scaled", c.ControlVectorScaled)
-	}
-	if c.ControlVectorLayerRange != "" {
-		args = append(args, "--control-vector-layer-range", c.ControlVectorLayerRange)
-	}
-	if c.ModelUrl != "" {
-		args = append(args, "--model-url", c.ModelUrl)
-	}
-	if c.HfRepo != "" {
-		args = append(args, "--hf-repo", c.HfRepo)
-	}
-	if c.HfRepoDraft != "" {
-		args = append(args, "--hf-repo-draft", c.HfRepoDraft)
-	}
-	if c.HfFile != "" {
-		args = append(args, "--hf-file", c.HfFile)
-	}
-	if c.HfRepoV != "" {
-		args = append(args, "--hf-repo-v", c.HfRepoV)
-	}
-	if c.HfFileV != "" {
-		args = append(args, "--hf-file-v", c.HfFileV)
-	}
-	if c.HfToken != "" {
-		args = append(args, "--hf-token", c.HfToken)
-	}
-	if c.LogDisable {
-		args = append(args, "--log-disable")
-	}
-	if c.LogColors {
-		args = append(args, "--log-colors")
-	}
-	if c.LogVerbose {
-		args = append(args, "--log-verbose")
-	}
-	if c.LogVerbosity > 0 {
-		args = append(args, "--log-verbosity", strconv.Itoa(c.LogVerbosity))
-	}
-	if c.LogPrefix {
-		args = append(args, "--log-prefix")
-	}
-	if c.LogTimestamps {
-		args = append(args, "--log-timestamps")
-	}
-	if c.Samplers != "" {
-		args = append(args, "--samplers", c.Samplers)
-	}
-	if c.Seed > 0 {
-		args = append(args, "--seed", strconv.Itoa(c.Seed))
-	}
-	if c.SamplerSeq != "" {
-		args = append(args, "--sampler-seq", c.SamplerSeq)
-	}
-	if c.IgnoreEOS {
-		args = append(args, "--ignore-eos")
-	}
-	if c.Temp > 0 {
-		args = append(args, "--temp", fmt.Sprintf("%.2f", c.Temp))
-	}
-	if c.TopK > 0 {
-		args = append(args, "--top-k", strconv.Itoa(c.TopK))
-	}
-	if c.TopP > 0 {
-		args = append(args, "--top-p", fmt.Sprintf("%.2f", c.TopP))
-	}
-	if c.MinP > 0 {
-		args = append(args, "--min-p", fmt.Sprintf("%.2f", c.MinP))
-	}
-	if c.XtcProbability > 0 {
-		args = append(args, "--xtc-probability", fmt.Sprintf("%.2f", c.XtcProbability))
-	}
-	if c.XtcThreshold > 0 {
-		args = append(args, "--xtc-threshold", fmt.Sprintf("%.2f", c.XtcThreshold))
-	}
-	if c.Typical > 0 {
-		args = append(args, "--typical", fmt.Sprintf("%.2f", c.Typical))
-	}
-	if c.RepeatLastN > 0 {
-		args = append(args, "--repeat-last-n", strconv.Itoa(c.RepeatLastN))
-	}
-	if c.RepeatPenalty > 0 {
-		args = append(args, "--repeat-penalty", fmt.Sprintf("%.2f", c.RepeatPenalty))
-	}
-	if c.PresencePenalty > 0 {
-		args = append(args, "--presence-penalty", fmt.Sprintf("%.2f", c.PresencePenalty))
-	}
-	if c.FrequencyPenalty > 0 {
-		args = append(args, "--frequency-penalty", fmt.Sprintf("%.2f", c.FrequencyPenalty))
-	}
-	if c.DryMultiplier > 0 {
-		args = append(args, "--dry-multiplier", fmt.Sprintf("%.2f", c.DryMultiplier))
-	}
-	if c.DryBase > 0 {
-		args = append(args, "--dry-base", fmt.Sprintf("%.2f", c.DryBase))
-	}
-	if c.DryAllowedLength > 0 {
-		args = append(args, "--dry-allowed-length", strconv.Itoa(c.DryAllowedLength))
-	}
-	if c.DryPenaltyLastN > 0 {
-		args = append(args, "--dry-penalty-last-n", strconv.Itoa(c.DryPenaltyLastN))
-	}
-	if c.DrySequenceBreaker != "" {
-		args = append(args, "--dry-sequence-breaker", c.DrySequenceBreaker)
-	}
-	if c.DynatempRange > 0 {
-		args = append(args, "--dynatemp-range", fmt.Sprintf("%.2f", c.DynatempRange))
-	}
-	if c.DynatempExp > 0 {
-		args = append(args, "--dynatemp-exp", fmt.Sprintf("%.2f", c.DynatempExp))
-	}
-	if c.Mirostat > 0 {
-		args = append(args, "--mirostat", strconv.Itoa(c.Mirostat))
-	}
-	if c.MirostatLR > 0 {
-		args = append(args, "--mirostat-lr", fmt.Sprintf("%.2f", c.MirostatLR))
-	}
-	if c.MirostatEnt > 0 {
-		args = append(args, "--mirostat-ent", fmt.Sprintf("%.2f", c.MirostatEnt))
-	}
-	if c.LogitBias != "" {
-		args = append(args, "--logit-bias", c.LogitBias)
-	}
-	if c.Grammar != "" {
-		args = append(args, "--grammar", c.Grammar)
-	}
-	if c.GrammarFile != "" {
-		args = append(args, "--grammar-file", c.GrammarFile)
-	}
-	if c.JsonSchema != "" {
-		args = append(args, "--json-schema", c.JsonSchema)
-	}
-	if c.JsonSchemaFile != "" {
-		args = append(args, "--json-schema-file", c.JsonSchemaFile)
-	}
-	if c.NoContextShift {
-		args = append(args, "--no-context-shift")
-	}
-	if c.Special {
-		args = append(args, "--special")
-	}
-	if c.NoWarmup {
-		args = append(args, "--no-warmup")
-	}
-	if c.SpmInfill {
-		args = append(args, "--spm-infill")
-	}
-	if c.Pooling != "" {
-		args = append(args, "--pooling", c.Pooling)
-	}
-	if c.ContBatching {
-		args = append(args, "--cont-batching")
-	}
-	if c.NoContBatching {
-		args = append(args, "--no-cont-batching")
-	}
-	if c.Alias != "" {
-		args = append(args, "--alias", c.Alias)
-	}
-	if c.NoWebui {
-		args = append(args, "--no-webui")
-	}
-	if c.Embedding {
-		args = append(args, "--embedding")
-	}
-	if c.Reranking {
-		args = append(args, "--reranking")
-	}
-	if c.ApiKeyFile != "" {
-		args = append(args, "--api-key-file", c.ApiKeyFile)
-	}
-	if c.ThreadsHttp > 0 {
-		args = append(args, "--threads-http", strconv.Itoa(c.ThreadsHttp))
-	}
-	if c.CacheReuse > 0 {
-		args = append(args, "--cache-reuse", strconv.Itoa(c.CacheReuse))
-	}
-	if c.Metrics {
-		args = append(args, "--metrics")
-	}
-	if c.Slots {
-		args = append(args, "--slots")
-	}
-	if c.Props {
-		args = append(args, "--props")
-	}
-	if c.NoSlots {
-		args = append(args, "--no-slots")
-	}
-	if c.SlotSavePath != "" {
-		args = append(args, "--slot-save-path", c.SlotSavePath)
-	}
-	if c.Jinja {
-		args = append(args, "--jinja")
-	}
-	if c.ReasoningFormat != "" {
-		args = append(args, "--reasoning-format", c.ReasoningFormat)
-	}
-	if c.ChatTemplate != "" {
-		args = append(args, "--chat-template", c.ChatTemplate)
-	}
-	if c.ChatTemplateFile != "" {
-		args = append(args, "--chat-template-file", c.ChatTemplateFile)
-	}
-	if c.SlotPromptSimilarity > 0 {
-		args = append(args, "--slot-prompt-similarity", fmt.Sprintf("%.2f", c.SlotPromptSimilarity))
-	}
-	if c.LoraInitWithoutApply {
-		args = append(args, "--lora-init-without-apply")
-	}
-	if c.DraftMax > 0 {
-		args = append(args, "--draft-max", strconv.Itoa(c.DraftMax))
-	}
-	if c.DraftMin > 0 {
-		args = append(args, "--draft-min", strconv.Itoa(c.DraftMin))
-	}
-	if c.DraftPMin > 0 {
-		args = append(args, "--draft-p-min", fmt.Sprintf("%.2f", c.DraftPMin))
-	}
-	if c.CtxSizeDraft > 0 {
-		args = append(args, "--ctx-size-draft", strconv.Itoa(c.CtxSizeDraft))
-	}
-	if c.DeviceDraft != "" {
-		args = append(args, "--device-draft", c.DeviceDraft)
-	}
-	if c.NGPULayersDraft > 0 {
-		args = append(args, "--n-gpu-layers-draft", strconv.Itoa(c.NGPULayersDraft))
-	}
-	if c.ModelDraft != "" {
-		args = append(args, "--model-draft", c.ModelDraft)
-	}
-	if c.ModelVocoder != "" {
-		args = append(args, "--model-vocoder", c.ModelVocoder)
-	}
-	if c.TtsUseGuideTokens {
-		args = append(args, "--tts-use-guide-tokens")
-	}
-	if c.EmbdBgeSmallEnDefault {
-		args = append(args, "--embd-bge-small-en-default")
-	}
-	if c.EmbdE5SmallEnDefault {
-		args = append(args, "--embd-e5-small-en-default")
-	}
-	if c.EmbdGteSmallDefault {
-		args = append(args, "--embd-gte-small-default")
-	}
-	if c.FimQwen15bDefault {
-		args = append(args, "--fim-qwen-1-5b-default")
-	}
-	if c.FimQwen3bDefault {
-		args = append(args, "--fim-qwen-3b-default")
-	}
-	if c.FimQwen7bDefault {
-		args = append(args, "--fim-qwen-7b-default")
-	}
-	if c.FimQwen7bSpec {
-		args = append(args, "--fim-qwen-7b-spec")
-	}
-	if c.FimQwen14bSpec {
-		args = append(args, "--fim-qwen-14b-spec")
+	// 每个模型在独立的工作目录中运行，相对路径的插槽缓存、日志和临时文件都保存在其中
+	workDir, workEnv, err := s.prepareWorkDir(cfg.ModelName)
+	if err != nil {
+		if closer, ok := output.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, err
 	}
 
-	// 打印启动命令
-	cmdStr := fmt.Sprintf("%s %s", s.config.LLamaPath.Server, strings.Join(args, " "))
-	log.Printf("Starting model service with command:\n%s\n", cmdStr)
-
 	// 启动服务进程
-	if err := s.processManager.StartProcess(s.config.LLamaPath.Server, args); err != nil {
+	opts := ProcessOptions{
+		Name:       cfg.ModelName,
+		Output:     newTeeOutput(output, tail),
+		Limits:     cfg.Limits,
+		CgroupRoot: s.config.Limits.CgroupRoot,
+		Env:        append(append(append(modelEnv(cfg.Env), workEnv...), s.placementEnv(gpus)...), splitEnv...),
+		Priority:   cfg.Config.Priority,
+		Dir:        workDir,
+		Stop:       s.stopOptions(cfg),
+	}
+	if len(opts.Env) > 0 {
+		slog.Info("Model environment", "model_name", cfg.ModelName, "env", strings.Join(opts.Env, " "))
+	}
+	if err := s.processManager.StartProcess(binary, args, opts); err != nil {
 		return nil, fmt.Errorf("failed to start model service: %v", err)
 	}
-	pid := s.processManager.GetPID()
+	pid, exit := s.processManager.lastStarted()
 
 	// 创建并添加模型状态到进程管理器
 	status = &model.ModelStatus{
-		Running:   true,
-		ModelName: cfg.ModelName,
-		ModelPath: modelPath,
-		Port:      cfg.Config.Port,
-		StartTime: time.Now().Format(time.RFC3339),
-		ProcessID: pid,
-		VRAMUsage: requiredVRAM,
+		Running:    true,
+		ModelName:  cfg.ModelName,
+		ModelPath:  modelPath,
+		Host:       cfg.Config.Host,
+		Port:       port,
+		StartTime:  time.Now().Format(time.RFC3339),
+		ProcessID:  pid,
+		VRAMUsage:  localVRAM,
+		WorkDir:    workDir,
+		Backend:    cfg.BackendProfile,
+		GPUs:       gpus,
+		Pinned:     cfg.Pinned,
+		RPCPool:    cfg.RPCPool,
+		RPCUsageMB: rpcVRAM,
+		Tenant:     cfg.Tenant,
+		Offload:    offload,
+
+		VRAMEstimate: estimate,
 	}
 	s.processManager.AddModel(pid, status)
+	s.setRunningConfig(cfg.ModelName, cfg)
+
+	s.setConcurrencyLimit(cfg)
 
 	// 保存模型配置到持久化存储
 	if status != nil {
-		if err := s.persistentMgr.UpdateModelConfig(cfg.ModelName, cfg, status); err != nil {
-			log.Printf("Warning: Failed to save model config: %v", err)
+		if err := s.persistentMgr.UpdateModelConfig(cfg.ModelName, requested, status); err != nil {
+			slog.Warn("Failed to save model config", "model_name", cfg.ModelName, "error", err)
 		}
 	} else {
-		log.Printf("Warning: Cannot save model config - status is nil")
-	}
-
-	// Windows平台需要特殊处理进程检测
-	if runtime.GOOS == "windows" {
-		go func() {
-			time.Sleep(5 * time.Second) // 等待进程稳定
-			if !s.processManager.IsProcessRunning(pid) {
-				log.Printf("Warning: Process %d (model: %s) failed to start", pid, cfg.ModelName)
-				s.processManager.RemoveModel(pid)
-				// 从持久化存储中移除配置
-				if err := s.persistentMgr.RemoveModelConfig(cfg.ModelName); err != nil {
-					log.Printf("Warning: Failed to remove model config: %v", err)
-				}
-			}
-		}()
+		slog.Warn("Cannot save model config, status is nil", "model_name", cfg.ModelName)
 	}
 
-	return status, nil
-}
-
-// getAvailableVRAM 获取当前可用显存(MB)，返回每个GPU的可用显存
-func (s *ModelService) getAvailableVRAM() ([]int, error) {
-	cmd := exec.Command("nvidia-smi", "--query-gpu=memory.free", "--format=csv,noheader,nounits")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to query GPU memory: %v", err)
+	// 等待实例就绪，期间不阻塞其他模型的状态查询和启停
+	timeout := time.Duration(s.config.Startup.Timeout) * time.Second
+	if timeout <= 0 {
+		go s.measureVRAMWhenReady(requested, pid, exit)
+		s.bus.Publish(WebhookModelStarted, status)
+		return status, nil
 	}
+	s.mu.Unlock()
+	locked = false
 
-	// 解析输出，获取所有GPU的可用显存
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	if len(lines) == 0 {
-		return nil, fmt.Errorf("no GPU memory information available")
+	if err := s.waitStartup(cfg.ModelName, exit, tail, timeout); err != nil {
+		slog.Error("Model failed to start", "model_name", cfg.ModelName, "pid", pid, "error", err)
+		s.abortStartup(cfg.ModelName, pid)
+		return nil, err
 	}
+	slog.Info("Model is ready", "model_name", cfg.ModelName, "pid", pid)
 
-	var freeMemory []int
-	for _, line := range lines {
-		freeMB, err := strconv.Atoi(strings.TrimSpace(line))
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse GPU memory: %v", err)
-		}
-		freeMemory = append(freeMemory, freeMB)
+	// 加载完成后以实际显存占用替换估算值
+	s.measureVRAMAfterLoad(requested, pid)
+	if current := s.processManager.FindModel(cfg.ModelName); current != nil && current.ProcessID == pid {
+		status = current
 	}
+	s.bus.Publish(WebhookModelStarted, status)
+	return status, nil
+}
 
-	return freeMemory, nil
+// abortStartup 清理启动失败的模型：结束仍在运行的进程并标记为已停止，避免恢复时反复启动
+func (s *ModelService) abortStartup(name string, pid int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.processManager.StopModel(name); err != nil {
+		s.processManager.RemoveModel(pid)
+	}
+	s.tracker.Remove(name)
+	s.markStopped(name)
 }
 
-// getTotalAvailableVRAM 获取所有GPU的总可用显存(MB)
+// getTotalAvailableVRAM 获取所有GPU扣除预留后的总可用显存(MB)
 func (s *ModelService) getTotalAvailableVRAM() (int, error) {
-	freeMemory, err := s.getAvailableVRAM()
+	freeMemory, err := s.freeMemory()
 	if err != nil {
 		return 0, err
 	}
@@ -845,12 +746,13 @@ func (s *ModelService) getTotalAvailableVRAM() (int, error) {
 	return total, nil
 }
 
-// estimateVRAMUsage 估算模型所需显存(MB)
-func (s *ModelService) estimateVRAMUsage(cfg *model.ModelConfig) int {
-	// 简单估算：每GPU层大约需要200MB显存
-	baseVRAM := 500 // 基础显存需求
-	perLayer := 200 // 每层显存需求
-	return baseVRAM + cfg.Config.NGPULayers*perLayer
+// setConcurrencyLimit 设置模型的代理并发上限，与--parallel槽位数保持一致
+func (s *ModelService) setConcurrencyLimit(cfg *model.ModelConfig) {
+	concurrency := s.config.Proxy.DefaultConcurrency
+	if cfg.Config.Parallel > 0 {
+		concurrency = cfg.Config.Parallel
+	}
+	s.tracker.SetLimit(cfg.ModelName, concurrency)
 }
 
 // StopModel 停止指定模型
@@ -863,26 +765,38 @@ func (s *ModelService) StopModel(model_name string) (*model.ModelStatus, error)
 	defer s.mu.Unlock()
 
 	// 直接从进程管理器停止指定名称的模型
+	start := time.Now()
 	modelStatus, err := s.processManager.StopModel(model_name)
+	observeModelOp(stopTotal, stopDuration, model_name, start, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to stop model '%s': %v", model_name, err)
 	}
+	s.tracker.Remove(model_name)
+	s.markStopped(model_name)
+	s.bus.Publish(WebhookModelStopped, modelStatus)
 
-	// 更新持久化配置中的状态
+	return modelStatus, nil
+}
+
+// markStopped 将持久化配置中的模型状态标记为已停止
+func (s *ModelService) markStopped(modelName string) {
 	configs, err := s.persistentMgr.GetModelConfigs()
 	if err != nil {
-		log.Printf("Warning: Failed to load model configs: %v", err)
-	} else if item, exists := configs[model_name]; exists {
-		// 创建状态副本并更新
-		updatedStatus := item.LastStatus
-		updatedStatus.Running = false
-		updatedStatus.StopTime = time.Now().Format(time.RFC3339)
-		if err := s.persistentMgr.UpdateModelConfig(model_name, item.ModelConfig, &updatedStatus); err != nil {
-			log.Printf("Warning: Failed to update model config: %v", err)
-		}
+		slog.Warn("Failed to load model configs", "error", err)
+		return
+	}
+	item, exists := configs[modelName]
+	if !exists {
+		return
 	}
 
-	return modelStatus, nil
+	// 创建状态副本并更新
+	updatedStatus := item.LastStatus
+	updatedStatus.Running = false
+	updatedStatus.StopTime = time.Now().Format(time.RFC3339)
+	if err := s.persistentMgr.UpdateModelConfig(modelName, item.ModelConfig, &updatedStatus); err != nil {
+		slog.Warn("Failed to update model config", "model_name", modelName, "error", err)
+	}
 }
 
 // StopAllModel 停止所有运行中的模型
@@ -901,12 +815,16 @@ func (s *ModelService) StopAllModel() ([]*model.ModelStatus, error) {
 	var lastError error
 
 	for _, m := range runningModels {
+		start := time.Now()
 		_, err := s.processManager.StopModel(m.ModelName)
+		observeModelOp(stopTotal, stopDuration, m.ModelName, start, err)
 		if err != nil {
-			log.Printf("Failed to stop model '%s': %v", m.ModelName, err)
+			slog.Error("Failed to stop model", "model_name", m.ModelName, "error", err)
 			lastError = err
 			continue
 		}
+		s.tracker.Remove(m.ModelName)
+		s.bus.Publish(WebhookModelStopped, m)
 		stoppedModels = append(stoppedModels, m)
 	}
 
@@ -928,15 +846,20 @@ func (s *ModelService) GetModelStatus(name string) []*model.ModelStatus {
 	// 获取持久化配置中的所有模型
 	persistentConfigs, err := s.persistentMgr.GetModelConfigs()
 	if err != nil {
-		log.Printf("Warning: Failed to load persistent configs: %v", err)
+		slog.Warn("Failed to load persistent configs", "error", err)
 		persistentConfigs = make(map[string]config.ModelConfigItem)
 	}
 
 	// 合并运行中和持久化的模型状态
 	var allModels []*model.ModelStatus
 
-	// 首先添加运行中的模型
-	allModels = append(allModels, runningModels...)
+	// 首先添加运行中的模型，附带最近一次健康检查和资源采样结果
+	for _, m := range runningModels {
+		status := *m
+		status.Health = s.health.Get(m.ModelName)
+		status.Resources = s.resources.Latest(m.ModelName)
+		allModels = append(allModels, &status)
+	}
 
 	// 添加持久化配置中但未运行的模型
 	for modelName, item := range persistentConfigs {
@@ -955,7 +878,8 @@ func (s *ModelService) GetModelStatus(name string) []*model.ModelStatus {
 				ModelName: modelName,
 				Running:   item.LastStatus.Running,
 				ModelPath: item.ModelConfig.ModelPath,
-				Port:      item.ModelConfig.Config.Port,
+				Host:      item.ModelConfig.Config.Host,
+				Port:      item.LastStatus.Port,
 				ProcessID: item.LastStatus.ProcessID,
 				VRAMUsage: item.LastStatus.VRAMUsage,
 			}
@@ -988,11 +912,44 @@ func (s *ModelService) GetModelStatus(name string) []*model.ModelStatus {
 
 // ValidateModelConfig 验证模型配置
 func (s *ModelService) ValidateModelConfig(cfg *model.ModelConfig) error {
-	if cfg.ModelPath == "" {
+	c := cfg.Config
+
+	if cfg.ModelPath == "" && c.HfRepo == "" {
 		return fmt.Errorf("model path is required")
 	}
+	if c.HfRepo != "" && c.HfFile == "" {
+		return fmt.Errorf("hf_file is required when hf_repo is set")
+	}
+	if t := cfg.Transform; t != nil && t.WebhookURL != "" {
+		if u, err := url.Parse(t.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid transform webhook url: %s", t.WebhookURL)
+		}
+	}
 
-	c := cfg.Config
+	for key, value := range cfg.Env {
+		if err := s.validateEnvName(key); err != nil {
+			return err
+		}
+		if strings.ContainsRune(value, 0) {
+			return fmt.Errorf("invalid value for environment variable %s", key)
+		}
+	}
+	if err := s.validateExtraArgs(cfg.ExtraArgs); err != nil {
+		return err
+	}
+	if l := cfg.Limits; l != nil {
+		if l.CPUs < 0 {
+			return fmt.Errorf("invalid cpu limit: %v", l.CPUs)
+		}
+		if l.MemoryMB < 0 {
+			return fmt.Errorf("invalid memory limit: %d", l.MemoryMB)
+		}
+		for _, cpu := range l.CPUAffinity {
+			if cpu < 0 || cpu >= runtime.NumCPU() {
+				return fmt.Errorf("invalid cpu affinity: %d (host has %d cpus)", cpu, runtime.NumCPU())
+			}
+		}
+	}
 
 	// 验证服务器配置
 	if c.Port < 0 || c.Port > 65535 {
@@ -1009,8 +966,8 @@ func (s *ModelService) ValidateModelConfig(cfg *model.ModelConfig) error {
 	if c.ThreadsBatch < -1 {
 		return fmt.Errorf("invalid threads batch number: %d", c.ThreadsBatch)
 	}
-	if c.Priority < 0 || c.Priority > 3 {
-		return fmt.Errorf("invalid priority value: %d (should be between 0 and 3)", c.Priority)
+	if err := validatePriority(c.Priority); err != nil {
+		return err
 	}
 	if c.Poll < 0 || c.Poll > 100 {
 		return fmt.Errorf("invalid poll value: %d (should be between 0 and 100)", c.Poll)
@@ -1028,7 +985,7 @@ func (s *ModelService) ValidateModelConfig(cfg *model.ModelConfig) error {
 	}
 
 	// 验证GPU配置
-	if c.NGPULayers < 0 {
+	if c.NGPULayers < 0 && c.NGPULayers != model.GPULayersAuto {
 		return fmt.Errorf("invalid number of GPU layers: %d", c.NGPULayers)
 	}
 	if c.SplitMode != "" && c.SplitMode != "none" && c.SplitMode != "layer" && c.SplitMode != "row" {
@@ -1201,9 +1158,6 @@ func (s *ModelService) ValidateModelConfig(cfg *model.ModelConfig) error {
 	if c.ApiKeyFile != "" && !filepath.IsAbs(c.ApiKeyFile) {
 		return fmt.Errorf("API key file path must be absolute: %s", c.ApiKeyFile)
 	}
-	if c.SlotSavePath != "" && !filepath.IsAbs(c.SlotSavePath) {
-		return fmt.Errorf("slot save path must be absolute: %s", c.SlotSavePath)
-	}
 	if c.ChatTemplateFile != "" && !filepath.IsAbs(c.ChatTemplateFile) {
 		return fmt.Errorf("chat template file path must be absolute: %s", c.ChatTemplateFile)
 	}
@@ -1213,6 +1167,23 @@ func (s *ModelService) ValidateModelConfig(cfg *model.ModelConfig) error {
 	if c.ModelVocoder != "" && !filepath.IsAbs(c.ModelVocoder) {
 		return fmt.Errorf("vocoder model path must be absolute: %s", c.ModelVocoder)
 	}
+	if err := validateStopConfig(cfg.Stop); err != nil {
+		return err
+	}
+	if _, err := s.serverBinary(cfg.BackendProfile); err != nil {
+		return err
+	}
+	if cfg.RPCPool != "" {
+		if c.RPC != "" {
+			return fmt.Errorf("rpc and rpc_pool cannot both be set")
+		}
+		if !s.rpc.has(cfg.RPCPool) {
+			return fmt.Errorf("unknown RPC pool: %s", cfg.RPCPool)
+		}
+	}
+	if err := s.checkServerFlags(cfg.BackendProfile, buildServerArgs(cfg, cfg.ModelPath, c.Port)); err != nil {
+		return err
+	}
 
-	return nil
+	return s.checkSandbox(cfg)
 }
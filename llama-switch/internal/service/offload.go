@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+
+	"llama-switch/internal/model"
+)
+
+// autoOffload 按当前可用显存（扣除其他启动中模型的预留）计算n_gpu_layers为auto时能卸载的最大层数；
+// 自动放置到单个GPU时按可用显存最多的GPU计算，否则按所有GPU的总和；使用RPC池时加上池中可分配的显存
+func (s *ModelService) autoOffload(cfg *model.ModelConfig, modelPath string, rpcFree int) (*model.OffloadDecision, error) {
+	freeMemory, err := s.freeMemory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check VRAM: %v", err)
+	}
+	free := rpcFree
+	for _, mem := range freeMemory {
+		if s.config.Snapshot().GPU.Placement == PlacementBestFit && len(freeMemory) > 1 && cfg.Config.RPC == "" {
+			free = max(free, mem)
+		} else {
+			free += mem
+		}
+	}
+
+	decision, err := fitGPULayers(cfg, modelPath, free)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Auto offload", "model_name", cfg.ModelName, "layers", decision.Layers, "total_layers", decision.TotalLayers,
+		"free_vram_mb", decision.FreeVRAMMB, "estimated_vram_mb", decision.VRAMMB)
+	return decision, nil
+}
+
+// fitGPULayers 根据GGUF元数据二分查找估算显存不超过free的最大层数，
+// 取值范围为0到模型层数+1（输出层也卸载到GPU）
+func fitGPULayers(cfg *model.ModelConfig, modelPath string, free int) (*model.OffloadDecision, error) {
+	trial := *cfg
+	estimate := func(layers int) (*model.VRAMEstimate, error) {
+		trial.Config.NGPULayers = model.GPULayers(layers)
+		return estimateGGUF(modelPath, &trial)
+	}
+
+	cpu, err := estimate(0)
+	if err != nil {
+		return nil, fmt.Errorf("n_gpu_layers=auto requires GGUF metadata: %v", err)
+	}
+	decision := &model.OffloadDecision{TotalLayers: cpu.Layers, FreeVRAMMB: free}
+	lo, hi := 0, cpu.Layers+1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		e, err := estimate(mid)
+		if err != nil {
+			return nil, err
+		}
+		if e.TotalMB <= free {
+			lo = mid
+			decision.VRAMMB = e.TotalMB
+		} else {
+			hi = mid - 1
+		}
+	}
+	decision.Layers = lo
+	return decision, nil
+}
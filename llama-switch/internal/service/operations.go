@@ -0,0 +1,181 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+)
+
+// 异步操作的状态
+const (
+	OperationPending = "pending" // 等待容量检查、驱逐或下载，实例尚未启动
+	OperationLoading = "loading" // 实例已启动，正在加载模型
+	OperationReady   = "ready"   // 模型已就绪
+	OperationFailed  = "failed"  // 切换失败
+)
+
+const (
+	operationMaxAge       = time.Hour              // 已结束的操作保留的时间
+	operationMaxCount     = 100                    // 最多保留的已结束操作数
+	operationPollInterval = 250 * time.Millisecond // 检查实例是否已启动的间隔
+)
+
+// loadStages llama-server加载模型时的输出标记，按出现顺序排列，进度为到达该阶段时的估算值
+var loadStages = []struct {
+	marker   string
+	stage    string
+	progress float64
+}{
+	{"llama_model_loader: loaded meta data", "reading metadata", 10},
+	{"print_info:", "reading metadata", 15},
+	{"llm_load_print_meta:", "reading metadata", 15},
+	{"load_tensors:", "loading tensors", 30},
+	{"llm_load_tensors:", "loading tensors", 30},
+	{"llama_context:", "creating context", 60},
+	{"llama_new_context_with_model:", "creating context", 60},
+	{"llama_kv_cache", "allocating KV cache", 70},
+	{"warming up", "warming up", 85},
+	{"model loaded", "starting server", 95},
+	{"server is listening", "starting server", 95},
+}
+
+// OperationManager 异步操作管理器：在后台执行切换，从实例输出中跟踪加载进度，
+// 已结束的操作在内存中保留一小时
+type OperationManager struct {
+	service *ModelService
+
+	mu  sync.Mutex
+	ops map[string]*model.Operation
+}
+
+// newOperationManager 创建异步操作管理器
+func newOperationManager(s *ModelService) *OperationManager {
+	return &OperationManager{service: s, ops: make(map[string]*model.Operation)}
+}
+
+// Operations 获取异步操作管理器
+func (s *ModelService) Operations() *OperationManager {
+	return s.operations
+}
+
+// Switch 在后台执行切换，立即返回pending状态的操作；run执行切换并返回就绪的模型状态和加载耗时，
+// 失败时返回与同步切换相同的错误响应
+func (m *OperationManager) Switch(name string, run func() (*model.ModelStatus, time.Duration, *model.Problem)) *model.Operation {
+	op := &model.Operation{
+		ID:        uuid.New().String(),
+		Type:      "switch",
+		ModelName: name,
+		State:     OperationPending,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	m.mu.Lock()
+	m.prune(time.Now())
+	m.ops[op.ID] = op
+	snapshot := *op
+	m.mu.Unlock()
+
+	// 在启动前订阅实例输出，避免错过加载开始时的输出
+	lines, unsubscribe := m.service.logs.Subscribe(name)
+	done := make(chan struct{})
+	go m.watch(op.ID, name, lines, done)
+	go func() {
+		defer unsubscribe()
+		status, loadTime, problem := run()
+		close(done)
+		m.update(op.ID, func(op *model.Operation) {
+			op.EndTime = time.Now().Format(time.RFC3339)
+			if problem != nil {
+				op.State = OperationFailed
+				op.Error = problem
+				return
+			}
+			op.State = OperationReady
+			op.Stage = ""
+			op.Progress = 100
+			op.Model = status
+			op.LoadTime = loadTime.String()
+		})
+	}()
+	return &snapshot
+}
+
+// Get 获取操作的状态
+func (m *OperationManager) Get(id string) (*model.Operation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	op, exists := m.ops[id]
+	if !exists {
+		return nil, apierror.New(apierror.CodeOperationNotFound, "operation not found: %s", id)
+	}
+	snapshot := *op
+	return &snapshot, nil
+}
+
+// watch 跟踪加载进度直到切换结束：实例启动后进入loading，按输出中的标记更新阶段和进度
+func (m *OperationManager) watch(id, name string, lines <-chan string, done <-chan struct{}) {
+	ticker := time.NewTicker(operationPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case line := <-lines:
+			m.update(id, func(op *model.Operation) {
+				if op.State == OperationPending {
+					op.State = OperationLoading
+				}
+				op.LastLog = strings.TrimSpace(line)
+				for _, s := range loadStages {
+					if strings.Contains(line, s.marker) && s.progress > op.Progress {
+						op.Stage, op.Progress = s.stage, s.progress
+					}
+				}
+			})
+		case <-ticker.C:
+			if status := m.service.processManager.FindModel(name); status != nil && status.Running {
+				m.update(id, func(op *model.Operation) {
+					if op.State == OperationPending {
+						op.State = OperationLoading
+					}
+				})
+			}
+		}
+	}
+}
+
+// update 修改未结束的操作
+func (m *OperationManager) update(id string, fn func(op *model.Operation)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if op, exists := m.ops[id]; exists && op.EndTime == "" {
+		fn(op)
+	}
+}
+
+// prune 删除结束超过一小时的操作，已结束的操作超过上限时删除最早结束的（调用方需持有锁）
+func (m *OperationManager) prune(now time.Time) {
+	var finished []*model.Operation
+	for id, op := range m.ops {
+		if op.EndTime == "" {
+			continue
+		}
+		if end, err := time.Parse(time.RFC3339, op.EndTime); err == nil && now.Sub(end) > operationMaxAge {
+			delete(m.ops, id)
+			continue
+		}
+		finished = append(finished, op)
+	}
+	if len(finished) < operationMaxCount {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].EndTime < finished[j].EndTime })
+	for _, op := range finished[:len(finished)-operationMaxCount+1] {
+		delete(m.ops, op.ID)
+	}
+}
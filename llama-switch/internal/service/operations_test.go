@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+)
+
+func TestOperationSwitch(t *testing.T) {
+	s := &ModelService{logs: NewLogManager(t.TempDir(), 1, 1, false), processManager: NewProcessManager()}
+	m := newOperationManager(s)
+
+	// 等待操作满足条件
+	waitFor := func(id string, ok func(op *model.Operation) bool) *model.Operation {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			op, err := m.Get(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok(op) {
+				return op
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("operation did not reach the expected state: %+v", op)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	release := make(chan struct{})
+	op := m.Switch("chat", func() (*model.ModelStatus, time.Duration, *model.Problem) {
+		<-release
+		return &model.ModelStatus{ModelName: "chat", Running: true}, 3 * time.Second, nil
+	})
+	if op.State != OperationPending || op.ModelName != "chat" {
+		t.Fatalf("new operation = %+v", op)
+	}
+
+	// 实例输出推进加载阶段
+	writer, err := s.logs.Writer("chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Write([]byte("llama_model_loader: loaded meta data with 30 key-value pairs\nload_tensors: offloading 32 repeating layers to GPU\n"))
+	current := waitFor(op.ID, func(op *model.Operation) bool { return op.Stage == "loading tensors" })
+	if current.State != OperationLoading || current.Progress != 30 || current.LastLog != "load_tensors: offloading 32 repeating layers to GPU" {
+		t.Errorf("loading operation = %+v", current)
+	}
+	writer.Write([]byte("print_info: file type = Q4_K\n"))
+	writer.Close()
+
+	close(release)
+	ready := waitFor(op.ID, func(op *model.Operation) bool { return op.State == OperationReady })
+	if ready.Progress != 100 || ready.Model == nil || ready.LoadTime != "3s" || ready.EndTime == "" {
+		t.Errorf("ready operation = %+v", ready)
+	}
+
+	// 失败的切换记录错误响应
+	failed := m.Switch("code", func() (*model.ModelStatus, time.Duration, *model.Problem) {
+		return nil, 0, apierror.NewProblem(apierror.CodeInsufficientVRAM, 0, "insufficient VRAM", nil)
+	})
+	current = waitFor(failed.ID, func(op *model.Operation) bool { return op.State == OperationFailed })
+	if current.Error == nil || current.Error.Code != "INSUFFICIENT_VRAM" {
+		t.Errorf("failed operation = %+v", current)
+	}
+
+	if _, err := m.Get("unknown"); apierror.CodeOf(err) != apierror.CodeOperationNotFound {
+		t.Errorf("unknown operation error = %v", err)
+	}
+}
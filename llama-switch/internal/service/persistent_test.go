@@ -0,0 +1,138 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"llama-switch/internal/config"
+)
+
+func TestPersistentConfigMigration(t *testing.T) {
+	dir := t.TempDir()
+	pm := config.NewPersistentManager(&config.Config{PersistentDir: dir})
+	path := filepath.Join(dir, config.ConfigFileName)
+
+	// 没有版本号的早期配置升级到当前版本，原文件保存为备份
+	legacy := `{"update_time":"2024-01-01T00:00:00Z"}`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := pm.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Version != config.ConfigVersion || cfg.Models == nil {
+		t.Errorf("migrated config: version=%q models=%v", cfg.Version, cfg.Models)
+	}
+	backup, err := os.ReadFile(config.MigrationBackupPath(path, ""))
+	if err != nil || string(backup) != legacy {
+		t.Errorf("backup = %q, %v; want the original file", backup, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"version": "`+config.ConfigVersion+`"`) {
+		t.Errorf("migrated config not written back: %s", data)
+	}
+
+	// 更高版本的配置不修改
+	newer := `{"version":"99.0.0","models":{}}`
+	if err := os.WriteFile(path, []byte(newer), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pm.LoadConfig(); err == nil || !strings.Contains(err.Error(), "unsupported config version: 99.0.0") {
+		t.Errorf("LoadConfig error = %v; want unsupported version", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != newer {
+		t.Errorf("config with a newer version was modified: %s", data)
+	}
+}
+
+func TestPersistentDirMigrate(t *testing.T) {
+	exePath, err := os.Executable()
+	if err != nil {
+		t.Skipf("executable path not available: %v", err)
+	}
+	exeConfigDir := filepath.Join(filepath.Dir(exePath), "config")
+
+	tests := []struct {
+		name     string
+		modelDir bool
+		readOnly bool
+		source   func(root string) string // 返回写入原配置的目录
+	}{
+		{name: "program dir", source: func(string) string { return exeConfigDir }},
+		{name: "models dir parent", modelDir: true, source: func(root string) string { return filepath.Join(root, "config") }},
+		{name: "read-only source", readOnly: true, modelDir: true, source: func(root string) string { return filepath.Join(root, "config") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			cfg := &config.Config{PersistentDir: filepath.Join(root, "persistent")}
+			if tt.modelDir {
+				cfg.ModelsDir = filepath.Join(root, "models")
+			}
+			// 程序目录下的config目录是第一个候选位置，其中已有持久化配置时无法测试
+			if _, err := os.Stat(filepath.Join(exeConfigDir, config.ConfigFileName)); err == nil {
+				t.Skipf("%s already contains %s", exeConfigDir, config.ConfigFileName)
+			}
+			sourceDir := tt.source(root)
+			if err := os.MkdirAll(sourceDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			source := filepath.Join(sourceDir, config.ConfigFileName)
+			t.Cleanup(func() {
+				for _, path := range []string{source, source + config.BackupSuffix, source + ".migrated"} {
+					os.RemoveAll(path)
+				}
+			})
+			if err := os.WriteFile(source, []byte(`{"models":{"chat":{}}}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(source+config.BackupSuffix, []byte(`{"models":{}}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.readOnly {
+				// 模拟原位置只读：原文件无法重命名为.migrated
+				if err := os.MkdirAll(filepath.Join(source+".migrated", "keep"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := config.NewPersistentManager(cfg).Migrate(); err != nil {
+				t.Fatalf("Migrate failed: %v", err)
+			}
+			target := filepath.Join(cfg.PersistentDir, config.ConfigFileName)
+			if data, err := os.ReadFile(target); err != nil || string(data) != `{"models":{"chat":{}}}` {
+				t.Errorf("migrated config = %q, %v", data, err)
+			}
+			if data, err := os.ReadFile(target + config.BackupSuffix); err != nil || string(data) != `{"models":{}}` {
+				t.Errorf("migrated backup = %q, %v", data, err)
+			}
+			_, sourceErr := os.Stat(source)
+			if tt.readOnly {
+				if sourceErr != nil {
+					t.Errorf("source not kept when it cannot be renamed: %v", sourceErr)
+				}
+				return
+			}
+			if !os.IsNotExist(sourceErr) {
+				t.Errorf("source still present after migration: %v", sourceErr)
+			}
+			if _, err := os.Stat(source + ".migrated"); err != nil {
+				t.Errorf("source not renamed to .migrated: %v", err)
+			}
+
+			// 新目录中已有持久化配置时不再迁移
+			if err := os.WriteFile(source, []byte(`{"models":{}}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := config.NewPersistentManager(cfg).Migrate(); err != nil {
+				t.Fatalf("second Migrate failed: %v", err)
+			}
+			if _, err := os.Stat(source); err != nil {
+				t.Errorf("source migrated again: %v", err)
+			}
+		})
+	}
+}
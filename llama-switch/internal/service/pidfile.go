@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"llama-switch/internal/config"
+)
+
+// PIDFile switcher自身的PID锁文件，防止多个实例同时管理同一模型目录和持久化配置
+type PIDFile struct {
+	path string
+}
+
+// defaultPIDFilePath 默认PID文件：持久化配置目录（PERSISTENT_DIR，未设置时为程序目录下的config目录）下的llama-switch.pid，
+// 与其保护的model_persistent.json位于同一目录
+func defaultPIDFilePath(cfg *config.Config) string {
+	return filepath.Join(config.PersistentDir(cfg), "llama-switch.pid")
+}
+
+// AcquirePIDFile 创建PID文件（server.pid_file），未设置时使用默认位置
+// 文件已存在时检查记录的进程：进程已退出或PID已被其他程序复用时视为过期锁并接管，
+// 仍是运行中的switcher时返回错误，force为true时无条件接管
+func AcquirePIDFile(cfg *config.Config, force bool) (*PIDFile, error) {
+	path := cfg.Server.PIDFile
+	if path == "" {
+		path = defaultPIDFilePath(cfg)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create pid file directory: %v", err)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = fmt.Fprintf(file, "%d\n", os.Getpid())
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("failed to write pid file: %v", err)
+			}
+			return &PIDFile{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create pid file: %v", err)
+		}
+
+		pid, running := lockHolder(path)
+		switch {
+		case running && !force:
+			return nil, fmt.Errorf("another switcher instance (PID: %d) is using %s, stop it or start with --force-takeover", pid, path)
+		case running:
+			slog.Warn("Taking over pid file from running switcher", "file", path, "pid", pid)
+		default:
+			slog.Info("Removing stale pid file", "file", path, "pid", pid)
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale pid file: %v", err)
+		}
+	}
+	return nil, fmt.Errorf("failed to acquire pid file %s: created concurrently by another instance", path)
+}
+
+// Path 获取PID文件路径
+func (p *PIDFile) Path() string {
+	return p.path
+}
+
+// Release 删除PID文件，文件已被其他实例接管时保留
+func (p *PIDFile) Release() {
+	if pid, err := readPIDFile(p.path); err != nil || pid != os.Getpid() {
+		return
+	}
+	if err := os.Remove(p.path); err != nil {
+		slog.Warn("Failed to remove pid file", "file", p.path, "error", err)
+	}
+}
+
+// readPIDFile 读取PID文件中记录的进程ID
+func readPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid pid file content: %q", strings.TrimSpace(string(data)))
+	}
+	return pid, nil
+}
+
+// lockHolder 获取PID文件记录的进程，并检查该进程是否仍是运行中的switcher
+func lockHolder(path string) (int, bool) {
+	pid, err := readPIDFile(path)
+	// 内容无效或记录的是本进程（例如容器中重启后PID相同）时视为过期
+	if err != nil || pid == os.Getpid() || !processAlive(pid) {
+		return pid, false
+	}
+
+	// PID可能已被其他程序复用：核对命令行中是否包含switcher的可执行文件名，无法读取时保守地视为仍在运行
+	cmdline, err := processCommandLine(pid)
+	if err != nil {
+		return pid, true
+	}
+	exePath, err := os.Executable()
+	if err != nil {
+		return pid, true
+	}
+	return pid, strings.Contains(cmdline, filepath.Base(exePath))
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"llama-switch/internal/config"
+)
+
+// pidFileConfig 使用指定PID文件的配置
+func pidFileConfig(path string) *config.Config {
+	cfg := &config.Config{}
+	cfg.Server.PIDFile = path
+	return cfg
+}
+
+func TestAcquirePIDFileTakesOverStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "llama-switch.pid")
+
+	// 已退出进程的PID
+	cmd := exec.Command("go", "version")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("go not available: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(cmd.Process.Pid)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := AcquirePIDFile(pidFileConfig(path), false)
+	if err != nil {
+		t.Fatalf("Expected stale pid file to be taken over: %v", err)
+	}
+	if pid, err := readPIDFile(path); err != nil || pid != os.Getpid() {
+		t.Fatalf("Expected pid file to contain %d, got %d (%v)", os.Getpid(), pid, err)
+	}
+
+	lock.Release()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected pid file to be removed on release, got %v", err)
+	}
+}
+
+func TestPIDFileReleaseKeepsTakenOverLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "llama-switch.pid")
+	lock, err := AcquirePIDFile(pidFileConfig(path), false)
+	if err != nil {
+		t.Fatalf("AcquirePIDFile failed: %v", err)
+	}
+
+	// 其他实例强制接管后，本实例退出时不删除其PID文件
+	if err := os.WriteFile(path, []byte("999999"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lock.Release()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected taken over pid file to be kept: %v", err)
+	}
+}
+
+func TestAcquirePIDFileDefaultsToPersistentDir(t *testing.T) {
+	dir := t.TempDir()
+	lock, err := AcquirePIDFile(&config.Config{PersistentDir: dir}, false)
+	if err != nil {
+		t.Fatalf("AcquirePIDFile failed: %v", err)
+	}
+	defer lock.Release()
+
+	// 与model_persistent.json位于同一目录
+	if want := filepath.Join(dir, "llama-switch.pid"); lock.Path() != want {
+		t.Errorf("pid file = %s, want %s", lock.Path(), want)
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+
+	"llama-switch/internal/model"
+)
+
+// GPU放置策略
+const (
+	PlacementBestFit = "bestfit" // 放到可用显存最少但足够的单个GPU上
+	PlacementNone    = "none"    // 不限制可见GPU，由llama-server按split_mode分配
+)
+
+// TensorSplitAuto tensor_split为auto时按各GPU可用显存计算比例
+const TensorSplitAuto = "auto"
+
+// gpuVisibilityEnv 各厂商限制进程可见GPU的环境变量
+var gpuVisibilityEnv = map[string]string{
+	GPUVendorNVIDIA: "CUDA_VISIBLE_DEVICES",
+	GPUVendorAMD:    "HIP_VISIBLE_DEVICES",
+	GPUVendorIntel:  "ONEAPI_DEVICE_SELECTOR",
+}
+
+// bestFitGPU 选择可用显存不少于required且剩余最少的GPU，可用显存相同时选择编号小的，都放不下时返回-1
+func bestFitGPU(freeMemory []int, required int) int {
+	best := -1
+	for i, free := range freeMemory {
+		if free < required {
+			continue
+		}
+		if best < 0 || free < freeMemory[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// placeModel 为模型选择GPU，返回分配的GPU编号
+// 只有一个GPU、放置策略为none、请求中已指定可见GPU、使用RPC设备或没有GPU层时不做限制（返回nil）；
+// 单个GPU放不下时由llama-server跨所有GPU分配
+func (s *ModelService) placeModel(cfg *model.ModelConfig, freeMemory []int, required int) []int {
+	envName, ok := gpuVisibilityEnv[s.gpu.Vendor()]
+	if !ok || s.config.Snapshot().GPU.Placement != PlacementBestFit || len(freeMemory) < 2 || cfg.Config.NGPULayers <= 0 || cfg.Config.RPC != "" {
+		return nil
+	}
+	if _, pinned := cfg.Env[envName]; pinned {
+		return nil
+	}
+
+	gpu := bestFitGPU(freeMemory, required)
+	if gpu < 0 {
+		return nil
+	}
+	return []int{gpu}
+}
+
+// placementEnv 将模型限制在分配的GPU上的环境变量
+// 可见GPU中分配的GPU编号为0，因此不需要调整main_gpu
+func (s *ModelService) placementEnv(gpus []int) []string {
+	return visibleGPUEnv(s.gpu.Vendor(), gpus)
+}
+
+// visibleGPUEnv 将进程限制在指定GPU上的环境变量，gpus为空时不限制
+func visibleGPUEnv(vendor string, gpus []int) []string {
+	if len(gpus) == 0 {
+		return nil
+	}
+	ids := make([]string, len(gpus))
+	for i, gpu := range gpus {
+		ids[i] = strconv.Itoa(gpu)
+	}
+
+	switch vendor {
+	case GPUVendorNVIDIA:
+		// nvidia-smi按PCI总线顺序编号，CUDA默认按性能排序
+		return []string{"CUDA_DEVICE_ORDER=PCI_BUS_ID", "CUDA_VISIBLE_DEVICES=" + strings.Join(ids, ",")}
+	case GPUVendorIntel:
+		return []string{"ONEAPI_DEVICE_SELECTOR=level_zero:" + strings.Join(ids, ",")}
+	default:
+		return []string{gpuVisibilityEnv[vendor] + "=" + strings.Join(ids, ",")}
+	}
+}
+
+// autoTensorSplit 按各GPU的可用显存计算--tensor-split比例，第二个返回值表示是否需要自动计算
+// （请求为auto，或启用GPU_AUTO_TENSOR_SPLIT且请求未指定）；
+// 模型已放置到单个GPU、只有一个GPU、split_mode为none、没有GPU层、使用RPC设备（比例需包含远程设备）
+// 或请求自行指定了可见GPU时返回空字符串
+func (s *ModelService) autoTensorSplit(cfg *model.ModelConfig, freeMemory []int, gpus []int) (string, bool) {
+	c := cfg.Config
+	if c.TensorSplit != TensorSplitAuto && (c.TensorSplit != "" || !s.config.Snapshot().GPU.AutoTensorSplit) {
+		return "", false
+	}
+	if len(gpus) > 0 || len(freeMemory) < 2 || c.SplitMode == "none" || c.NGPULayers <= 0 || c.Device != "" || c.RPC != "" {
+		return "", true
+	}
+	if envName, ok := gpuVisibilityEnv[s.gpu.Vendor()]; ok {
+		if _, pinned := cfg.Env[envName]; pinned {
+			return "", true
+		}
+	}
+
+	parts := make([]string, len(freeMemory))
+	total := 0
+	for i, free := range freeMemory {
+		free = max(free, 0)
+		total += free
+		parts[i] = strconv.Itoa(free)
+	}
+	if total == 0 {
+		return "", true
+	}
+	return strings.Join(parts, ","), true
+}
+
+// tensorSplitEnv 自动计算tensor_split时使GPU顺序与显存查询工具一致的环境变量
+func (s *ModelService) tensorSplitEnv() []string {
+	if s.gpu.Vendor() == GPUVendorNVIDIA {
+		return []string{"CUDA_DEVICE_ORDER=PCI_BUS_ID"}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"net"
+	"strconv"
+
+	"llama-switch/internal/apierror"
+)
+
+// assignPort 为启动的模型确定端口（调用方需持有s.mu，保证并发启动的模型不会分配到同一端口）
+// requested大于0时检查该端口是否可用，否则从配置的端口范围中分配空闲端口
+func (s *ModelService) assignPort(host string, requested int) (int, error) {
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = defaultBackendHost
+	}
+
+	used := make(map[int]string)
+	for _, m := range s.processManager.GetRunningModels() {
+		used[m.Port] = m.ModelName
+	}
+
+	if requested > 0 {
+		if owner, exists := used[requested]; exists {
+			return 0, apierror.New(apierror.CodePortInUse, "port %d is already used by model '%s'", requested, owner)
+		}
+		if !portFree(host, requested) {
+			return 0, apierror.New(apierror.CodePortInUse, "port %d is already in use by another process", requested)
+		}
+		return requested, nil
+	}
+
+	r := s.config.ModelPorts
+	for port := r.RangeStart; port <= r.RangeEnd; port++ {
+		if _, exists := used[port]; exists || port == s.config.Server.Port {
+			continue
+		}
+		if portFree(host, port) {
+			return port, nil
+		}
+	}
+	return 0, apierror.New(apierror.CodePortInUse, "no free port in range %d-%d", r.RangeStart, r.RangeEnd)
+}
+
+// portFree 检查端口当前是否可以监听
+func portFree(host string, port int) bool {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestAssignPort(t *testing.T) {
+	// 占用一个端口作为范围起点，模拟被其他进程使用
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer listener.Close()
+	busy := listener.Addr().(*net.TCPAddr).Port
+
+	cfg := &config.Config{}
+	cfg.ModelPorts.RangeStart = busy
+	cfg.ModelPorts.RangeEnd = busy + 20
+	s := &ModelService{config: cfg, processManager: NewProcessManager()}
+
+	port, err := s.assignPort("", 0)
+	if err != nil {
+		t.Fatalf("assignPort failed: %v", err)
+	}
+	if port == busy || port < cfg.ModelPorts.RangeStart || port > cfg.ModelPorts.RangeEnd {
+		t.Fatalf("assignPort = %d, want a free port in range excluding %d", port, busy)
+	}
+
+	// 已分配给运行中模型的端口不会再次分配，显式指定时报告冲突
+	s.processManager.AddModel(os.Getpid(), &model.ModelStatus{ModelName: "chat", Port: port, Running: true})
+	next, err := s.assignPort("", 0)
+	if err != nil {
+		t.Fatalf("assignPort failed: %v", err)
+	}
+	if next == port || next == busy {
+		t.Errorf("assignPort = %d, expected a port other than %d and %d", next, port, busy)
+	}
+	if _, err := s.assignPort("", port); err == nil || !strings.Contains(err.Error(), "chat") {
+		t.Errorf("Expected collision with model chat, got %v", err)
+	}
+	if _, err := s.assignPort("", busy); err == nil {
+		t.Error("Expected error for port in use by another process")
+	}
+}
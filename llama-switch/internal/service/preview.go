@@ -0,0 +1,92 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"llama-switch/internal/model"
+)
+
+// PreviewModel 构建模型的启动命令但不下载文件、不启动进程，用于离线排查参数映射问题
+func (s *ModelService) PreviewModel(cfg *model.ModelConfig) (*model.CommandPreview, error) {
+	// 在副本上解析，避免修改请求中的配置
+	preview := *cfg
+	binary, err := s.serverBinary(preview.BackendProfile)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.CommandPreview{Binary: binary}
+
+	if preview.Config.HfRepo != "" {
+		dest, err := s.downloadTarget(&preview)
+		if err != nil {
+			return nil, err
+		}
+		result.Download = fmt.Sprintf("%s/%s -> %s", preview.Config.HfRepo, preview.Config.HfFile, dest)
+		clearDownload(&preview)
+	}
+
+	rpcFree := 0
+	if preview.RPCPool != "" {
+		if rpc, err := s.allocateRPC(preview.RPCPool); err != nil {
+			result.Warnings = append(result.Warnings, err.Error())
+		} else {
+			preview.Config.RPC = strings.Join(rpc.Endpoints, ",")
+			rpcFree = rpc.FreeMB
+		}
+	}
+
+	modelPath := preview.ModelPath
+	if !filepath.IsAbs(modelPath) {
+		modelPath = filepath.Join(s.config.Snapshot().ModelsDir, modelPath)
+	}
+	if result.Download == "" {
+		if info, err := os.Stat(modelPath); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("model file not accessible: %v", err))
+		} else {
+			if preview.Config.NGPULayers == model.GPULayersAuto {
+				if result.Offload, err = s.autoOffload(&preview, modelPath, rpcFree); err != nil {
+					result.Warnings = append(result.Warnings, err.Error())
+				} else {
+					preview.Config.NGPULayers = model.GPULayers(result.Offload.Layers)
+				}
+			}
+			result.VRAMEstimate = s.estimateVRAM(&preview, modelPath, int(info.Size()/(1024*1024)))
+			result.RAMEstimateMB = estimateRAM(&preview, modelPath, int(info.Size()/(1024*1024)))
+		}
+	}
+
+	if path, err := exec.LookPath(result.Binary); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("llama-server not found: %v", err))
+	} else if abs, err := filepath.Abs(path); err == nil {
+		result.Binary = abs
+	}
+
+	// 端口按当前占用情况分配，实际启动时可能不同
+	port, err := s.assignPort(preview.Config.Host, preview.Config.Port)
+	if err != nil {
+		result.Warnings = append(result.Warnings, err.Error())
+		port = preview.Config.Port
+	}
+
+	result.Args = buildServerArgs(&preview, modelPath, port)
+	result.Dir = s.modelWorkDir(preview.ModelName)
+	result.Env = append(modelEnv(preview.Env), workDirEnv(result.Dir)...)
+	result.Command = commandLine(result.Binary, result.Args)
+	return result, nil
+}
+
+// commandLine 将可执行文件和参数拼接为可复制执行的命令行，包含空白或引号的参数加引号
+func commandLine(binary string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{binary}, args...) {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			arg = `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,11 @@
+package service
+
+import "testing"
+
+func TestCommandLine(t *testing.T) {
+	got := commandLine("/opt/llama/llama-server", []string{"--model", "/models/my model.gguf", "--alias", "", "--chat-template", `say "hi"`})
+	want := `/opt/llama/llama-server --model "/models/my model.gguf" --alias "" --chat-template "say \"hi\""`
+	if got != want {
+		t.Errorf("commandLine = %s, want %s", got, want)
+	}
+}
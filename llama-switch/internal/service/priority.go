@@ -0,0 +1,29 @@
+package service
+
+import "fmt"
+
+// 进程优先级，与llama-server的--prio取值一致
+const (
+	PriorityLow      = -1
+	PriorityNormal   = 0
+	PriorityMedium   = 1
+	PriorityHigh     = 2
+	PriorityRealtime = 3
+)
+
+// priorityNice 各优先级在Unix下对应的nice值
+var priorityNice = map[int]int{
+	PriorityLow:      10,
+	PriorityNormal:   0,
+	PriorityMedium:   -5,
+	PriorityHigh:     -10,
+	PriorityRealtime: -20,
+}
+
+// validatePriority 检查优先级取值，以及当前平台和权限下能否为模型进程设置该优先级
+func validatePriority(priority int) error {
+	if priority < PriorityLow || priority > PriorityRealtime {
+		return fmt.Errorf("invalid priority value: %d (should be between -1 and 3)", priority)
+	}
+	return checkPriorityPermission(priority)
+}
@@ -0,0 +1,70 @@
+//go:build linux
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// I/O优先级相关常量，见ioprio_set(2)
+const (
+	ioprioWhoPgrp    = 2
+	ioprioClassBE    = 2
+	ioprioClassShift = 13
+	capSysNice       = 23
+	rlimitNice       = 13
+)
+
+// priorityIOLevel 各优先级对应的best-effort I/O级别（0最高，7最低）
+var priorityIOLevel = map[int]int{
+	PriorityLow:      7,
+	PriorityMedium:   2,
+	PriorityHigh:     0,
+	PriorityRealtime: 0,
+}
+
+// setProcessPriority 设置进程组（llama-server及其所有线程）的nice值和I/O优先级
+func setProcessPriority(pid, priority int) error {
+	if err := syscall.Setpriority(syscall.PRIO_PGRP, pid, priorityNice[priority]); err != nil {
+		return fmt.Errorf("failed to set nice value: %v", err)
+	}
+	ioprio := ioprioClassBE<<ioprioClassShift | priorityIOLevel[priority]
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoPgrp, uintptr(pid), uintptr(ioprio)); errno != 0 {
+		return fmt.Errorf("failed to set I/O priority: %v", errno)
+	}
+	return nil
+}
+
+// checkPriorityPermission 检查能否提高进程优先级：需要root、CAP_SYS_NICE或足够的RLIMIT_NICE
+func checkPriorityPermission(priority int) error {
+	nice := priorityNice[priority]
+	if nice >= 0 || os.Geteuid() == 0 || hasCapability(capSysNice) {
+		return nil
+	}
+
+	// RLIMIT_NICE允许的最低nice值为20-rlim_cur
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(rlimitNice, &limit); err == nil && 20-int64(limit.Cur) <= int64(nice) {
+		return nil
+	}
+	return fmt.Errorf("priority %d requires root, CAP_SYS_NICE or RLIMIT_NICE >= %d", priority, 20-nice)
+}
+
+// hasCapability 检查当前进程的有效capability集合是否包含指定capability
+func hasCapability(capability uint) bool {
+	data, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "CapEff:"); ok {
+			caps, err := strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+			return err == nil && caps&(1<<capability) != 0
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStartProcessSetsPriority(t *testing.T) {
+	pm := NewProcessManager()
+	if err := pm.StartProcess("sleep", []string{"30"}, ProcessOptions{Output: io.Discard, Priority: PriorityLow}); err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	pid := pm.GetPID()
+	defer pm.StopProcess()
+
+	// /proc/<pid>/stat第19个字段为nice值（进程名可能包含空格，从最后一个右括号之后开始计数）
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		t.Fatalf("Failed to read process stat: %v", err)
+	}
+	fields := strings.Fields(string(data[strings.LastIndexByte(string(data), ')')+2:]))
+	if nice, _ := strconv.Atoi(fields[16]); nice != priorityNice[PriorityLow] {
+		t.Errorf("Expected nice %d, got %d", priorityNice[PriorityLow], nice)
+	}
+}
+
+func TestValidatePriority(t *testing.T) {
+	for _, priority := range []int{-2, 4} {
+		if err := validatePriority(priority); err == nil {
+			t.Errorf("Expected priority %d to be rejected", priority)
+		}
+	}
+	// 降低优先级不需要特权
+	if err := validatePriority(PriorityLow); err != nil {
+		t.Errorf("Expected low priority to be allowed: %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+//go:build !linux && !windows
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// setProcessPriority 设置进程组的nice值（该平台不支持设置I/O优先级）
+func setProcessPriority(pid, priority int) error {
+	if err := syscall.Setpriority(syscall.PRIO_PGRP, pid, priorityNice[priority]); err != nil {
+		return fmt.Errorf("failed to set nice value: %v", err)
+	}
+	return nil
+}
+
+// checkPriorityPermission 检查能否提高进程优先级：需要root
+func checkPriorityPermission(priority int) error {
+	if priorityNice[priority] >= 0 || os.Geteuid() == 0 {
+		return nil
+	}
+	return fmt.Errorf("priority %d requires root", priority)
+}
@@ -0,0 +1,72 @@
+//go:build windows
+
+package service
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// 进程优先级类，见SetPriorityClass
+const (
+	belowNormalPriorityClass = 0x00004000
+	normalPriorityClass      = 0x00000020
+	aboveNormalPriorityClass = 0x00008000
+	highPriorityClass        = 0x00000080
+	realtimePriorityClass    = 0x00000100
+	processSetInformation    = 0x0200
+	tokenElevation           = 20
+)
+
+var procSetPriorityClass = kernel32.NewProc("SetPriorityClass")
+
+// priorityClass 各优先级对应的Windows优先级类
+var priorityClass = map[int]uint32{
+	PriorityLow:      belowNormalPriorityClass,
+	PriorityNormal:   normalPriorityClass,
+	PriorityMedium:   aboveNormalPriorityClass,
+	PriorityHigh:     highPriorityClass,
+	PriorityRealtime: realtimePriorityClass,
+}
+
+// setProcessPriority 设置进程的优先级类
+func setProcessPriority(pid, priority int) error {
+	handle, err := syscall.OpenProcess(processSetInformation, false, uint32(pid))
+	if err != nil {
+		return fmt.Errorf("failed to open process: %v", err)
+	}
+	defer syscall.CloseHandle(handle)
+
+	if ret, _, err := procSetPriorityClass.Call(uintptr(handle), uintptr(priorityClass[priority])); ret == 0 {
+		return fmt.Errorf("failed to set priority class: %v", err)
+	}
+	return nil
+}
+
+// checkPriorityPermission 检查能否设置实时优先级：没有管理员权限时Windows会静默降级为高优先级
+func checkPriorityPermission(priority int) error {
+	if priority != PriorityRealtime || isElevated() {
+		return nil
+	}
+	return fmt.Errorf("priority %d requires running as administrator", priority)
+}
+
+// isElevated 检查当前进程是否以管理员权限运行
+func isElevated() bool {
+	process, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return false
+	}
+	var token syscall.Token
+	if err := syscall.OpenProcessToken(process, syscall.TOKEN_QUERY, &token); err != nil {
+		return false
+	}
+	defer token.Close()
+
+	var elevation, size uint32
+	if err := syscall.GetTokenInformation(token, tokenElevation, (*byte)(unsafe.Pointer(&elevation)), uint32(unsafe.Sizeof(elevation)), &size); err != nil {
+		return false
+	}
+	return elevation != 0
+}
@@ -1,27 +1,75 @@
 package service
 
 import (
-	"context"
 	"fmt"
+	"io"
+	"llama-switch/internal/apierror"
 	"llama-switch/internal/model"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
-	"runtime"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 )
 
+// 停止进程的默认方式和超时时间
+const (
+	defaultStopSignal = syscall.SIGTERM  // 请求进程优雅退出的信号
+	stopGracePeriod   = 10 * time.Second // 发送终止信号后等待进程退出的时间，超时后强制结束
+	killWaitTimeout   = 5 * time.Second  // 强制结束后等待进程退出的时间
+)
+
+// stopSignals 可配置的停止信号（Windows上无法向其他进程发送信号，设置无效）
+var stopSignals = map[string]syscall.Signal{
+	"SIGTERM": syscall.SIGTERM,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGHUP":  syscall.SIGHUP,
+}
+
+// parseStopSignal 解析停止信号名称，空字符串表示默认信号
+func parseStopSignal(name string) (syscall.Signal, error) {
+	if name == "" {
+		return defaultStopSignal, nil
+	}
+	sig, exists := stopSignals[strings.ToUpper(name)]
+	if !exists {
+		return 0, fmt.Errorf("unsupported stop signal: %s (should be SIGTERM, SIGINT, SIGQUIT or SIGHUP)", name)
+	}
+	return sig, nil
+}
+
+// StopOptions 停止进程的方式，零值表示使用默认信号和等待时间
+type StopOptions struct {
+	Signal      syscall.Signal // 请求进程优雅退出的信号
+	GracePeriod time.Duration  // 等待进程退出的时间，超时后强制结束
+}
+
 // ProcessManager 进程管理器
 type ProcessManager struct {
 	mu      sync.Mutex
 	process *os.Process
 	cmd     *exec.Cmd
 	models  map[int]*model.ModelStatus // 跟踪运行中的模型及其显存使用
+	exited  map[int]*processExit       // 由本管理器启动、尚未退出的进程
+	stops   map[int]StopOptions        // 进程的停止方式，未设置时使用默认值
+	stopped map[int]bool               // 正在被停止的进程，退出时不视为崩溃
+	started struct {                   // 最近一次启动的进程，进程退出后仍保留
+		pid  int
+		exit *processExit
+	}
+
+	// onCrash 已跟踪的模型进程在未被停止的情况下退出时调用（不持有锁），reason为退出状态
+	onCrash func(status *model.ModelStatus, reason string)
+}
+
+// processExit 由本管理器启动的进程的退出通知
+type processExit struct {
+	done chan struct{} // 进程退出时关闭
+	err  error         // 进程的退出状态，done关闭后可读
 }
 
 // init 初始化ProcessManager
@@ -29,6 +77,15 @@ func (pm *ProcessManager) init() {
 	if pm.models == nil {
 		pm.models = make(map[int]*model.ModelStatus)
 	}
+	if pm.exited == nil {
+		pm.exited = make(map[int]*processExit)
+	}
+	if pm.stops == nil {
+		pm.stops = make(map[int]StopOptions)
+	}
+	if pm.stopped == nil {
+		pm.stopped = make(map[int]bool)
+	}
 }
 
 // NewProcessManager 创建新的进程管理器
@@ -36,37 +93,102 @@ func NewProcessManager() *ProcessManager {
 	return &ProcessManager{}
 }
 
+// ProcessOptions 启动进程的选项
+type ProcessOptions struct {
+	Name       string                // 进程标识，用于命名cgroup/Job Object
+	Output     io.Writer             // 标准输出和错误输出，为nil时输出到控制台；实现io.Closer时在进程退出后关闭
+	Limits     *model.ResourceLimits // 资源限制，为nil时不限制
+	CgroupRoot string                // Linux下创建cgroup的父目录
+	Env        []string              // 追加到当前环境的环境变量（KEY=VALUE）
+	Priority   int                   // 进程优先级（-1~3），0表示不调整
+	Dir        string                // 工作目录，为空时使用switcher的工作目录
+	Stop       StopOptions           // 停止进程的方式
+}
+
 // StartProcess 启动新进程
-func (pm *ProcessManager) StartProcess(command string, args []string) error {
+func (pm *ProcessManager) StartProcess(command string, args []string, opts ProcessOptions) error {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
+	pm.init()
+	output := opts.Output
 
 	// 创建新的命令
 	cmd := exec.Command(command, args...)
 
-	// 设置进程组ID，这样可以一次性结束所有子进程
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
+	// 设置进程组，这样可以一次性结束所有子进程
+	cmd.SysProcAttr = processAttr()
+	cmd.Dir = opts.Dir
+	if len(opts.Env) > 0 {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
+
+	// 准备资源限制（cgroup/Job Object）
+	limiter, err := prepareLimits(opts.CgroupRoot, opts.Name, opts.Limits, cmd.SysProcAttr)
+	if err != nil {
+		if closer, ok := output.(io.Closer); ok {
+			closer.Close()
+		}
+		return fmt.Errorf("failed to apply resource limits: %v", err)
 	}
 
 	// 设置标准输出和错误输出
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	if output != nil {
+		cmd.Stdout = output
+		cmd.Stderr = output
+	} else {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
 
 	// 启动进程
 	if err := cmd.Start(); err != nil {
+		limiter.release()
+		if closer, ok := output.(io.Closer); ok {
+			closer.Close()
+		}
 		return fmt.Errorf("failed to start process: %v", err)
 	}
+	if err := limiter.attach(cmd.Process.Pid); err != nil {
+		// 无法施加限制时不保留不受限的进程
+		killProcess(cmd.Process.Pid)
+		cmd.Wait()
+		limiter.release()
+		if closer, ok := output.(io.Closer); ok {
+			closer.Close()
+		}
+		return fmt.Errorf("failed to apply resource limits: %v", err)
+	}
+	if opts.Priority != PriorityNormal {
+		// 优先级已在配置验证时检查，设置失败时仍保留进程
+		if err := setProcessPriority(cmd.Process.Pid, opts.Priority); err != nil {
+			slog.Warn("Failed to set process priority", "pid", cmd.Process.Pid, "error", err)
+		}
+	}
 
 	pm.process = cmd.Process
 	pm.cmd = cmd
+	pm.stops[cmd.Process.Pid] = opts.Stop
+	exited := &processExit{done: make(chan struct{})}
+	pm.exited[cmd.Process.Pid] = exited
+	pm.started.pid = cmd.Process.Pid
+	pm.started.exit = exited
 
-	// 在后台等待进程结束
+	// 在后台等待进程结束，这是唯一回收该进程的地方，停止进程时通过exited等待
 	go func() {
 		// 捕获进程退出状态
 		err := cmd.Wait()
+		exited.err = err
+		close(exited.done)
+		limiter.release()
+		if closer, ok := output.(io.Closer); ok {
+			closer.Close()
+		}
 
 		pm.mu.Lock()
+		delete(pm.exited, cmd.Process.Pid)
+		delete(pm.stops, cmd.Process.Pid)
+		expected := pm.stopped[cmd.Process.Pid]
+		delete(pm.stopped, cmd.Process.Pid)
 		// 清理进程状态
 		if pm.process != nil && pm.process.Pid == cmd.Process.Pid {
 			pm.process = nil
@@ -74,15 +196,26 @@ func (pm *ProcessManager) StartProcess(command string, args []string) error {
 		}
 
 		// 清理模型状态
+		var crashed *model.ModelStatus
 		if model, exists := pm.models[cmd.Process.Pid]; exists {
 			delete(pm.models, cmd.Process.Pid)
-			log.Printf("Model '%s' (PID: %d) exited: %v",
-				model.ModelName, cmd.Process.Pid, err)
+			slog.Info("Model process exited", "model_name", model.ModelName, "pid", cmd.Process.Pid, "error", err)
+			if !expected {
+				crashed = model
+			}
 		} else {
-			log.Printf("Process exited (PID: %d): %v",
-				cmd.Process.Pid, err)
+			slog.Info("Process exited", "pid", cmd.Process.Pid, "error", err)
 		}
+		onCrash := pm.onCrash
 		pm.mu.Unlock()
+
+		if crashed != nil && onCrash != nil {
+			reason := "exited normally"
+			if err != nil {
+				reason = err.Error()
+			}
+			onCrash(crashed, reason)
+		}
 	}()
 
 	return nil
@@ -97,40 +230,17 @@ func (pm *ProcessManager) StopProcess() error {
 		return nil
 	}
 
-	// 创建超时上下文
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// 使用通道接收停止结果
-	done := make(chan error, 1)
-	go func() {
-		// 在Windows上，我们需要发送Ctrl+C信号来优雅地关闭进程
-		if err := pm.process.Signal(os.Interrupt); err != nil {
-			// 如果发送中断信号失败，则强制结束进程
-			if err := pm.process.Kill(); err != nil {
-				done <- fmt.Errorf("failed to kill process: %v", err)
-				return
-			}
-		}
-
-		// 等待进程退出
-		_, err := pm.process.Wait()
-		done <- err
-	}()
+	err := pm.stopProcessByPID(pm.process.Pid)
+	pm.process = nil
+	pm.cmd = nil
+	return err
+}
 
-	// 等待停止完成或超时
-	select {
-	case err := <-done:
-		pm.process = nil
-		pm.cmd = nil
-		return err
-	case <-ctx.Done():
-		// 超时后强制终止进程
-		if err := pm.process.Kill(); err != nil {
-			return fmt.Errorf("failed to kill process after timeout: %v", err)
-		}
-		return fmt.Errorf("process termination timed out")
-	}
+// lastStarted 获取最近一次启动的进程PID及其退出通知，进程已快速退出时同样有效
+func (pm *ProcessManager) lastStarted() (int, *processExit) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.started.pid, pm.started.exit
 }
 
 // IsRunning 检查进程是否在运行
@@ -161,22 +271,7 @@ func (pm *ProcessManager) IsProcessRunning(pid int) bool {
 	}
 
 	// 检查系统进程状态
-	if runtime.GOOS == "windows" {
-		// Windows平台使用tasklist检查进程
-		out, err := exec.Command("tasklist", "/fi", fmt.Sprintf("PID eq %d", pid)).Output()
-		if err != nil {
-			return false
-		}
-		return strings.Contains(string(out), strconv.Itoa(pid))
-	} else {
-		// Unix平台使用Signal(0)检查进程
-		process, err := os.FindProcess(pid)
-		if err != nil {
-			return false
-		}
-		err = process.Signal(syscall.Signal(0))
-		return err == nil
-	}
+	return processAlive(pid)
 }
 
 // AddModel 添加运行中的模型
@@ -194,6 +289,14 @@ func (pm *ProcessManager) RemoveModel(pid int) {
 	delete(pm.models, pid)
 }
 
+// SetStopOptions 设置进程的停止方式（用于不是由本管理器启动的进程，例如接管的实例）
+func (pm *ProcessManager) SetStopOptions(pid int, opts StopOptions) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.init()
+	pm.stops[pid] = opts
+}
+
 // UpdateModel 更新指定PID的模型状态
 func (pm *ProcessManager) UpdateModel(pid int, status *model.ModelStatus) {
 	pm.mu.Lock()
@@ -201,6 +304,21 @@ func (pm *ProcessManager) UpdateModel(pid int, status *model.ModelStatus) {
 	pm.models[pid] = status
 }
 
+// setVRAMUsage 更新模型实际占用的显存，以新的状态对象替换，避免与持有旧对象的读取方竞争
+func (pm *ProcessManager) setVRAMUsage(pid int, vramMB int) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	m, exists := pm.models[pid]
+	if !exists || (m.VRAMUsage == vramMB && m.VRAMMeasured) {
+		return
+	}
+	updated := *m
+	updated.VRAMUsage = vramMB
+	updated.VRAMMeasured = true
+	pm.models[pid] = &updated
+}
+
 // GetRunningModels 获取运行中的模型列表
 func (pm *ProcessManager) GetRunningModels() []*model.ModelStatus {
 	pm.mu.Lock()
@@ -212,30 +330,8 @@ func (pm *ProcessManager) GetRunningModels() []*model.ModelStatus {
 
 	for pid, m := range pm.models {
 		// 验证进程是否还在运行
-		var isRunning bool
-		if runtime.GOOS == "windows" {
-			// Windows平台使用tasklist检查进程
-			out, err := exec.Command("tasklist", "/fi", fmt.Sprintf("PID eq %d", pid)).Output()
-			if err != nil {
-				log.Printf("Warning: Failed to check process %d status: %v", pid, err)
-				toRemove = append(toRemove, pid)
-				continue
-			}
-			isRunning = strings.Contains(string(out), strconv.Itoa(pid))
-		} else {
-			// Unix平台使用Signal(0)检查进程
-			process, err := os.FindProcess(pid)
-			if err != nil {
-				log.Printf("Warning: Failed to find process %d: %v", pid, err)
-				toRemove = append(toRemove, pid)
-				continue
-			}
-			err = process.Signal(syscall.Signal(0))
-			isRunning = err == nil
-		}
-
-		if !isRunning {
-			log.Printf("Warning: Process %d (model: %s) is not running", pid, m.ModelName)
+		if !processAlive(pid) {
+			slog.Warn("Model process is not running", "pid", pid, "model_name", m.ModelName)
 			toRemove = append(toRemove, pid)
 			continue
 		}
@@ -246,14 +342,55 @@ func (pm *ProcessManager) GetRunningModels() []*model.ModelStatus {
 
 	// 清理已停止的进程状态
 	for _, pid := range toRemove {
-		log.Printf("Cleaning up stopped model (PID: %d, Name: %s)",
-			pid, pm.models[pid].ModelName)
+		slog.Info("Cleaning up stopped model", "pid", pid, "model_name", pm.models[pid].ModelName)
 		delete(pm.models, pid)
 	}
 
 	return models
 }
 
+// trackedModels 获取所有已跟踪的模型（不检查进程状态），键为PID
+func (pm *ProcessManager) trackedModels() map[int]*model.ModelStatus {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	models := make(map[int]*model.ModelStatus, len(pm.models))
+	for pid, m := range pm.models {
+		models[pid] = m
+	}
+	return models
+}
+
+// isChild 检查进程是否由本管理器启动且尚未退出
+func (pm *ProcessManager) isChild(pid int) bool {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	exit, exists := pm.exited[pid]
+	if !exists {
+		return false
+	}
+	select {
+	case <-exit.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// FindModel 按名称查找已跟踪的模型（不检查进程状态）
+func (pm *ProcessManager) FindModel(name string) *model.ModelStatus {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	for _, m := range pm.models {
+		if m.ModelName == name {
+			return m
+		}
+	}
+	return nil
+}
+
 // GetModelsByVRAMUsage 按显存使用排序(降序)
 func (pm *ProcessManager) GetModelsByVRAMUsage() []*model.ModelStatus {
 	models := pm.GetRunningModels()
@@ -287,7 +424,7 @@ func (pm *ProcessManager) StopModel(model_name string) (*model.ModelStatus, erro
 	}
 
 	if !found {
-		return nil, fmt.Errorf("model '%s' not found", model_name)
+		return nil, apierror.New(apierror.CodeModelNotFound, "model '%s' not found", model_name)
 	}
 
 	// 停止进程
@@ -297,48 +434,70 @@ func (pm *ProcessManager) StopModel(model_name string) (*model.ModelStatus, erro
 
 	// 清理模型状态
 	delete(pm.models, targetPID)
-	log.Printf("Model '%s' (PID: %d) stopped successfully", model_name, targetPID)
+	slog.Info("Model stopped", "model_name", model_name, "pid", targetPID)
 
 	return targetModel, nil
 }
 
-// stopProcessByPID 停止指定PID的进程
+// StopPID 停止指定PID的进程，不清理模型状态
+func (pm *ProcessManager) StopPID(pid int) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	return pm.stopProcessByPID(pid)
+}
+
+// stopProcessByPID 停止指定PID的进程（调用方需持有pm.mu）
+// 先发送停止信号请求进程优雅退出，超过等待时间仍未退出时强制结束整个进程组
 func (pm *ProcessManager) stopProcessByPID(pid int) error {
-	process, err := os.FindProcess(pid)
-	if err != nil {
-		return fmt.Errorf("failed to find process %d: %v", pid, err)
+	pm.init()
+	if _, isChild := pm.exited[pid]; isChild {
+		pm.stopped[pid] = true
+	}
+	opts := pm.stops[pid]
+	if opts.Signal == 0 {
+		opts.Signal = defaultStopSignal
+	}
+	if opts.GracePeriod <= 0 {
+		opts.GracePeriod = stopGracePeriod
 	}
 
-	// 创建超时上下文
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// 使用通道接收停止结果
-	done := make(chan error, 1)
-	go func() {
-		// 发送中断信号
-		if err := process.Signal(os.Interrupt); err != nil {
-			// 如果发送中断信号失败，则强制结束进程
-			if err := process.Kill(); err != nil {
-				done <- fmt.Errorf("failed to kill process %d: %v", pid, err)
-				return
+	// 由本管理器启动的进程由StartProcess中的goroutine回收，这里只等待其退出通知；
+	// 其他进程（例如重启前启动的实例）不是子进程，只能轮询其是否存在
+	exited, isChild := pm.exited[pid]
+	wait := func(timeout time.Duration) bool {
+		if isChild {
+			select {
+			case <-exited.done:
+				return true
+			case <-time.After(timeout):
+				return false
+			}
+		}
+		deadline := time.Now().Add(timeout)
+		for processAlive(pid) {
+			if time.Now().After(deadline) {
+				return false
 			}
+			time.Sleep(100 * time.Millisecond)
 		}
+		return true
+	}
 
-		// 等待进程退出
-		_, err := process.Wait()
-		done <- err
-	}()
+	if err := terminateProcess(pid, opts.Signal); err != nil {
+		slog.Warn("Failed to terminate process gracefully, killing it", "pid", pid, "error", err)
+	} else if wait(opts.GracePeriod) {
+		delete(pm.stops, pid)
+		return nil
+	} else {
+		slog.Warn("Process did not exit in time, killing it", "pid", pid, "grace_period", opts.GracePeriod)
+	}
 
-	// 等待停止完成或超时
-	select {
-	case err := <-done:
-		return err
-	case <-ctx.Done():
-		// 超时后强制终止进程
-		if err := process.Kill(); err != nil {
-			return fmt.Errorf("failed to kill process %d after timeout: %v", pid, err)
-		}
+	if err := killProcess(pid); err != nil && processAlive(pid) {
+		return fmt.Errorf("failed to kill process %d: %v", pid, err)
+	}
+	if !wait(killWaitTimeout) {
 		return fmt.Errorf("process %d termination timed out", pid)
 	}
+	delete(pm.stops, pid)
+	return nil
 }
@@ -0,0 +1,54 @@
+//go:build !windows
+
+package service
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// processAttr 将子进程放入独立的进程组，停止时可以一并结束llama-server派生的子进程
+func processAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminateProcess 向进程组发送停止信号，请求进程优雅退出
+func terminateProcess(pid int, sig syscall.Signal) error {
+	return signalGroup(pid, sig)
+}
+
+// killProcess 向进程组发送SIGKILL，强制结束进程
+func killProcess(pid int) error {
+	return signalGroup(pid, syscall.SIGKILL)
+}
+
+// suspendProcess 向进程组发送SIGSTOP，暂停进程执行
+func suspendProcess(pid int) error {
+	return signalGroup(pid, syscall.SIGSTOP)
+}
+
+// resumeProcess 向进程组发送SIGCONT，继续执行被暂停的进程
+func resumeProcess(pid int) error {
+	return signalGroup(pid, syscall.SIGCONT)
+}
+
+// signalGroup 向进程所在的进程组发送信号
+// 进程不是组长时（例如启动前已存在、从持久化配置恢复的进程）只向进程本身发送
+func signalGroup(pid int, sig syscall.Signal) error {
+	if pgid, err := syscall.Getpgid(pid); err == nil && pgid == pid {
+		if err := syscall.Kill(-pgid, sig); err == nil || !errors.Is(err, syscall.ESRCH) {
+			return err
+		}
+	}
+	return syscall.Kill(pid, sig)
+}
+
+// processAlive 检查指定PID的进程是否存在
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,123 @@
+//go:build !windows
+
+package service
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+func TestStopModelTerminatesProcess(t *testing.T) {
+	pm := NewProcessManager()
+	if err := pm.StartProcess("sleep", []string{"30"}, ProcessOptions{Output: io.Discard}); err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	pid := pm.GetPID()
+	pm.AddModel(pid, &model.ModelStatus{ModelName: "chat", Running: true})
+
+	start := time.Now()
+	if _, err := pm.StopModel("chat"); err != nil {
+		t.Fatalf("StopModel failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= stopGracePeriod {
+		t.Errorf("Expected SIGTERM to stop the process before escalation, took %v", elapsed)
+	}
+	if processAlive(pid) {
+		t.Errorf("Process %d still running after stop", pid)
+	}
+	if pm.FindModel("chat") != nil {
+		t.Error("Expected model to be removed after stop")
+	}
+}
+
+func TestStopModelUsesStopOptions(t *testing.T) {
+	// 忽略SIGTERM的进程（被忽略的信号会由sleep继承）
+	start := func(t *testing.T, stop StopOptions) (*ProcessManager, int) {
+		pm := NewProcessManager()
+		var output lockedOutput
+		opts := ProcessOptions{Output: &output, Stop: stop}
+		if err := pm.StartProcess("sh", []string{"-c", `trap "" TERM; echo ready; sleep 30`}, opts); err != nil {
+			t.Skipf("sh not available: %v", err)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for !strings.Contains(output.String(), "ready") {
+			if time.Now().After(deadline) {
+				t.Fatal("Timed out waiting for the process to ignore SIGTERM")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		pid := pm.GetPID()
+		pm.AddModel(pid, &model.ModelStatus{ModelName: "chat", Running: true})
+		return pm, pid
+	}
+
+	t.Run("signal", func(t *testing.T) {
+		pm, pid := start(t, StopOptions{Signal: syscall.SIGINT, GracePeriod: 5 * time.Second})
+		began := time.Now()
+		if _, err := pm.StopModel("chat"); err != nil {
+			t.Fatalf("StopModel failed: %v", err)
+		}
+		if elapsed := time.Since(began); elapsed >= 5*time.Second {
+			t.Errorf("Expected SIGINT to stop the process before escalation, took %v", elapsed)
+		}
+		if processAlive(pid) {
+			t.Errorf("Process %d still running after stop", pid)
+		}
+	})
+
+	t.Run("grace period", func(t *testing.T) {
+		pm, pid := start(t, StopOptions{GracePeriod: 300 * time.Millisecond})
+		began := time.Now()
+		if _, err := pm.StopModel("chat"); err != nil {
+			t.Fatalf("StopModel failed: %v", err)
+		}
+		if elapsed := time.Since(began); elapsed < 300*time.Millisecond || elapsed >= stopGracePeriod {
+			t.Errorf("Expected escalation after the configured grace period, took %v", elapsed)
+		}
+		if processAlive(pid) {
+			t.Errorf("Process %d still running after stop", pid)
+		}
+	})
+}
+
+func TestStartProcessSetsEnv(t *testing.T) {
+	pm := NewProcessManager()
+	var output lockedOutput
+	opts := ProcessOptions{Output: &output, Env: []string{"GGML_TEST_VALUE=42"}}
+	if err := pm.StartProcess("sh", []string{"-c", "echo value=$GGML_TEST_VALUE"}, opts); err != nil {
+		t.Skipf("sh not available: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(output.String(), "value=42") {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected child to see GGML_TEST_VALUE, got %q", output.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// lockedOutput 可并发读写的进程输出缓冲区
+type lockedOutput struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *lockedOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Write(p)
+}
+
+func (o *lockedOutput) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
@@ -0,0 +1,63 @@
+//go:build windows
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// processAttr 为子进程创建新的进程组，避免控制台的Ctrl+C直接传递给模型实例
+func processAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP,
+	}
+}
+
+// terminateProcess 请求进程优雅退出
+// Windows不支持向其他进程发送信号，忽略配置的停止信号；失败时由调用方强制结束
+func terminateProcess(pid int, sig syscall.Signal) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Signal(os.Interrupt)
+}
+
+// killProcess 强制结束进程及其子进程
+func killProcess(pid int) error {
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid)).Run(); err == nil {
+		return nil
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	if err := process.Kill(); err != nil {
+		return fmt.Errorf("failed to kill process %d: %v", pid, err)
+	}
+	return nil
+}
+
+// suspendProcess Windows不支持暂停其他进程
+func suspendProcess(pid int) error {
+	return fmt.Errorf("suspending processes is not supported on Windows")
+}
+
+// resumeProcess Windows不支持暂停其他进程
+func resumeProcess(pid int) error {
+	return fmt.Errorf("resuming processes is not supported on Windows")
+}
+
+// processAlive 使用tasklist检查指定PID的进程是否存在
+func processAlive(pid int) bool {
+	out, err := exec.Command("tasklist", "/fi", fmt.Sprintf("PID eq %d", pid)).Output()
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(out), strconv.Itoa(pid))
+}
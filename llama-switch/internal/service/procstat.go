@@ -0,0 +1,26 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// processStats 通过gopsutil获取进程累计CPU时间（用户态与内核态之和）和常驻内存(字节)
+func processStats(pid int) (time.Duration, uint64, error) {
+	p, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	times, err := p.Times()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get process times: %v", err)
+	}
+	mem, err := p.MemoryInfo()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get process memory info: %v", err)
+	}
+	cpu := time.Duration((times.User + times.System) * float64(time.Second))
+	return cpu, mem.RSS, nil
+}
@@ -0,0 +1,29 @@
+//go:build linux
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// processCommandLine 读取/proc获取进程的命令行，参数以空格分隔
+func processCommandLine(pid int) (string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.ReplaceAll(string(data), "\x00", " ")), nil
+}
+
+// processZombie 检查进程是否已退出但尚未被父进程回收（/proc/<pid>/stat状态为Z）
+func processZombie(pid int) bool {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false
+	}
+	stat := string(data)
+	end := strings.LastIndexByte(stat, ')')
+	return end >= 0 && strings.HasPrefix(stat[end+1:], " Z")
+}
@@ -0,0 +1,25 @@
+//go:build !linux && !windows
+
+package service
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// processCommandLine 通过ps获取进程的命令行
+func processCommandLine(pid int) (string, error) {
+	out, err := exec.Command("ps", "-o", "command=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to run ps: %v", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// processZombie 通过ps检查进程是否已退出但尚未被父进程回收
+func processZombie(pid int) bool {
+	out, err := exec.Command("ps", "-o", "stat=", "-p", strconv.Itoa(pid)).Output()
+	return err == nil && strings.HasPrefix(strings.TrimSpace(string(out)), "Z")
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package service
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// kernel32 Windows API所在的DLL
+var kernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+// processCommandLine 通过PowerShell查询进程的命令行
+func processCommandLine(pid int) (string, error) {
+	query := fmt.Sprintf("(Get-CimInstance Win32_Process -Filter 'ProcessId=%d').CommandLine", pid)
+	out, err := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", query).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to query command line: %v", err)
+	}
+	cmdline := strings.TrimSpace(string(out))
+	if cmdline == "" {
+		return "", fmt.Errorf("command line of process %d is not available", pid)
+	}
+	return cmdline, nil
+}
+
+// processZombie Windows没有僵尸进程，已退出的进程在句柄关闭后即消失
+func processZombie(pid int) bool {
+	return false
+}
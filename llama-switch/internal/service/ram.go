@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+)
+
+// estimateRAM 估算模型在主机内存中占用的大小(MB)：未卸载到GPU的权重、KV缓存和计算缓冲区，
+// 无法读取GGUF元数据时，纯CPU推理按模型文件大小估算，部分卸载时无法区分，不做检查（返回0）
+func estimateRAM(cfg *model.ModelConfig, modelPath string, modelSizeMB int) int {
+	if l, err := ggufLayout(modelPath, cfg); err == nil {
+		return toMB(l.hostWeights + l.hostKV + l.hostCompute)
+	}
+	if cfg.Config.NGPULayers <= 0 {
+		return modelSizeMB
+	}
+	return 0
+}
+
+// checkRAM 检查主机可用内存是否足够加载模型，保留RAM_RESERVE_MB给系统；
+// 内存不足且请求指定了force_ram时按驱逐策略停止其他模型，启用mlock时还要检查RLIMIT_MEMLOCK
+func (s *ModelService) checkRAM(cfg *model.ModelConfig, modelPath string, modelSizeMB int) error {
+	if !s.config.RAM.CheckEnabled {
+		return nil
+	}
+	required := estimateRAM(cfg, modelPath, modelSizeMB)
+	if required <= 0 {
+		return nil
+	}
+
+	if cfg.Config.Mlock {
+		limit, unlimited, err := memlockLimit()
+		if err != nil {
+			slog.Warn("Failed to check RLIMIT_MEMLOCK", "error", err)
+		} else if !unlimited && uint64(required)*1024*1024 > limit {
+			return fmt.Errorf("mlock requires %dMB but RLIMIT_MEMLOCK is %dMB (raise it with ulimit -l or LimitMEMLOCK=infinity)",
+				required, limit/(1024*1024))
+		}
+	}
+
+	available, err := s.availableRAM()
+	if err != nil {
+		// 无法获取可用内存时不阻止启动
+		slog.Warn("Skipping RAM check", "error", err)
+		return nil
+	}
+	slog.Info("Model RAM estimation", "model_name", cfg.ModelName, "estimated_ram_mb", required, "available_mb", available, "reserve_mb", s.config.RAM.ReserveMB)
+	if available >= required {
+		return nil
+	}
+
+	if !cfg.ForceRAM {
+		return apierror.New(apierror.CodeInsufficientRAM, "insufficient RAM (required: %dMB, available: %dMB after %dMB reserve). Use force_ram=true to evict other models",
+			required, available, s.config.RAM.ReserveMB)
+	}
+	slog.Info("Insufficient RAM, freeing RAM", "model_name", cfg.ModelName, "required_mb", required, "available_mb", available)
+	if err := s.evictModels(tenantModels(s.modelsByRSS(), cfg.Tenant), required-available, cfg.Force, "RAM", s.availableRAM); err != nil {
+		return apierror.New(apierror.CodeInsufficientRAM, "insufficient RAM (required: %dMB, available: %dMB after %dMB reserve): %v",
+			required, available, s.config.RAM.ReserveMB, err)
+	}
+	return nil
+}
+
+// availableRAM 获取扣除保留量后的可用主机内存(MB)
+func (s *ModelService) availableRAM() (int, error) {
+	available, err := availableRAM()
+	if err != nil {
+		return 0, err
+	}
+	return available - int(s.config.RAM.ReserveMB), nil
+}
+
+// modelsByRSS 获取按常驻内存从大到小排序的运行中模型
+func (s *ModelService) modelsByRSS() []*model.ModelStatus {
+	models := s.processManager.GetRunningModels()
+	rss := make(map[int]uint64, len(models))
+	for _, m := range models {
+		if _, bytes, err := processStats(m.ProcessID); err == nil {
+			rss[m.ProcessID] = bytes
+		}
+	}
+	sort.SliceStable(models, func(i, j int) bool {
+		return rss[models[i].ProcessID] > rss[models[j].ProcessID]
+	})
+	return models
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+// EventModelCrashed 模型进程在未被停止的情况下退出
+const EventModelCrashed = "model_crashed"
+
+// StartReconciler 启动后台状态校正，ctx取消时停止
+func (s *ModelService) StartReconciler(ctx context.Context) {
+	interval := time.Duration(s.config.Reconcile.Interval) * time.Second
+	if interval <= 0 {
+		slog.Info("State reconciliation is disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.Reconcile()
+			}
+		}
+	}()
+}
+
+// Reconcile 比对进程管理器、持久化配置和系统进程表，修复不一致之处并返回所做的修正
+func (s *ModelService) Reconcile() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var corrections []string
+	correct := func(format string, args ...interface{}) {
+		message := fmt.Sprintf(format, args...)
+		slog.Info("Reconcile: " + message)
+		corrections = append(corrections, message)
+	}
+
+	// 移除进程已退出或PID已被其他程序复用的模型
+	running := make(map[string]*model.ModelStatus)
+	for pid, m := range s.processManager.trackedModels() {
+		if reason := s.checkTracked(pid, m); reason != "" {
+			s.processManager.RemoveModel(pid)
+			s.tracker.Remove(m.ModelName)
+			s.modelCrashed(m, reason)
+			correct("model %s (PID: %d) %s, removed from running models", m.ModelName, pid, reason)
+			continue
+		}
+		running[m.ModelName] = m
+	}
+
+	// 使持久化状态与实际运行的模型一致，避免恢复时启动已停止的模型或遗漏运行中的模型
+	configs, err := s.persistentMgr.GetModelConfigs()
+	if err != nil {
+		slog.Warn("Reconcile: failed to load model configs", "error", err)
+		return corrections
+	}
+	for name, item := range configs {
+		if item.ModelConfig == nil {
+			continue
+		}
+		m, isRunning := running[name]
+		switch {
+		case item.LastStatus.Running && !isRunning:
+			s.markStopped(name)
+			correct("model %s is recorded as running (PID: %d) but is not, marked stopped", name, item.LastStatus.ProcessID)
+		case isRunning && (!item.LastStatus.Running || item.LastStatus.ProcessID != m.ProcessID):
+			status := *m
+			if err := s.persistentMgr.UpdateModelConfig(name, item.ModelConfig, &status); err != nil {
+				slog.Warn("Reconcile: failed to update model config", "error", err)
+				continue
+			}
+			correct("persisted status of model %s did not match running PID %d, updated", name, m.ProcessID)
+		}
+	}
+	return corrections
+}
+
+// checkTracked 检查已跟踪的模型进程，返回需要移除的原因，进程正常时返回空字符串
+func (s *ModelService) checkTracked(pid int, m *model.ModelStatus) string {
+	if !processAlive(pid) {
+		return "is no longer running"
+	}
+	if processZombie(pid) {
+		return "has exited but was not reaped"
+	}
+
+	// 由本管理器启动且尚未退出的进程不会被复用PID，接管的进程需要核对命令行
+	if s.processManager.isChild(pid) {
+		return ""
+	}
+	if err := s.matchInstance(pid, config.ModelConfigItem{LastStatus: *m}); err != nil {
+		return fmt.Sprintf("was replaced by another program reusing its PID (%v)", err)
+	}
+	return ""
+}
+
+// modelCrashed 记录进程意外退出的模型并通知订阅方，reason为退出状态或校正时发现的原因
+func (s *ModelService) modelCrashed(status *model.ModelStatus, reason string) {
+	s.events.Record(EventModelCrashed, status.ModelName,
+		fmt.Sprintf("Model '%s' (PID: %d) exited unexpectedly: %s", status.ModelName, status.ProcessID, reason), nil)
+	s.bus.Publish(WebhookModelCrashed, map[string]interface{}{
+		"model":  status,
+		"reason": reason,
+	})
+}
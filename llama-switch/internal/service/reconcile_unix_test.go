@@ -0,0 +1,69 @@
+//go:build !windows
+
+package service
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestReconcile(t *testing.T) {
+	// 由其他程序复用了记录的PID的进程
+	other := NewProcessManager()
+	if err := other.StartProcess("sleep", []string{"30"}, ProcessOptions{Output: io.Discard}); err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	pid := other.GetPID()
+	defer other.StopProcess()
+
+	cfg := &config.Config{PersistentDir: t.TempDir()}
+	s := &ModelService{
+		config:         cfg,
+		processManager: NewProcessManager(),
+		persistentMgr:  config.NewPersistentManager(cfg),
+		tracker:        NewRequestTracker(0, time.Second),
+		events:         NewEventLog(filepath.Join(t.TempDir(), "events.jsonl"), 10),
+		configs:        make(map[string]*model.ModelConfig),
+	}
+	reused := &model.ModelStatus{ModelName: "reconcile-reused", ModelPath: "/models/chat.gguf", Port: 8123, ProcessID: pid, Running: true}
+	s.processManager.AddModel(pid, reused)
+	s.persistentMgr.UpdateModelConfig("reconcile-reused", &model.ModelConfig{ModelName: "reconcile-reused"}, reused)
+
+	// 持久化状态为运行中但实际没有运行的模型
+	ghost := &model.ModelStatus{ModelName: "reconcile-ghost", ProcessID: 999999, Running: true}
+	s.persistentMgr.UpdateModelConfig("reconcile-ghost", &model.ModelConfig{ModelName: "reconcile-ghost"}, ghost)
+	defer s.persistentMgr.RemoveModelConfig("reconcile-reused")
+	defer s.persistentMgr.RemoveModelConfig("reconcile-ghost")
+
+	if corrections := s.Reconcile(); len(corrections) != 3 {
+		t.Fatalf("Expected 3 corrections, got %q", corrections)
+	}
+	if s.processManager.FindModel("reconcile-reused") != nil {
+		t.Error("Expected model with reused PID to be removed")
+	}
+	if !processAlive(pid) {
+		t.Error("Reconcile must not kill the process that reused the PID")
+	}
+	if events := s.events.Recent("reconcile-reused", 0); len(events) != 1 || events[0].Type != EventModelCrashed {
+		t.Errorf("Expected a model_crashed event, got %+v", events)
+	}
+	configs, err := s.persistentMgr.GetModelConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"reconcile-reused", "reconcile-ghost"} {
+		if configs[name].LastStatus.Running {
+			t.Errorf("Expected %s to be marked stopped", name)
+		}
+	}
+
+	// 状态一致后不再修正
+	if corrections := s.Reconcile(); len(corrections) != 0 {
+		t.Errorf("Expected no corrections, got %q", corrections)
+	}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/logging"
+	"llama-switch/internal/model"
+)
+
+// EventConfigReloaded 运行时重新加载了配置
+const EventConfigReloaded = "config_reloaded"
+
+// ReloadConfig 重新读取配置文件、.env文件和环境变量，验证通过后使可在运行时生效的配置项立即生效
+// 不重启switcher，也不停止正在运行的模型；验证失败时保持当前配置不变
+func (s *ModelService) ReloadConfig() (*model.ConfigReload, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	next, err := config.LoadConfig(s.config.File)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %v", err)
+	}
+	if err := config.ValidateConfig(next); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %v", err)
+	}
+
+	reload := &model.ConfigReload{File: next.File, Changes: config.Reload(s.config, next)}
+	logging.SetLevel(s.config.Log.Level)
+	binariesChanged := false
+	var fields []string
+	for _, change := range reload.Changes {
+		if !change.Applied {
+			reload.RestartRequired = true
+		}
+		if strings.HasPrefix(change.Field, "llama_path.") {
+			binariesChanged = true
+		}
+		fields = append(fields, change.Field)
+	}
+	// 二进制路径变化后重新检测版本，以便按新构建检查参数
+	if binariesChanged {
+		s.detectServerVersions()
+	}
+
+	message := "Configuration reloaded, no changes"
+	if len(fields) > 0 {
+		message = fmt.Sprintf("Configuration reloaded, changed: %s", strings.Join(fields, ", "))
+		if reload.RestartRequired {
+			message += " (some changes require a restart)"
+		}
+	}
+	slog.Info(message)
+	s.events.Record(EventConfigReloaded, "", message, reload)
+	return reload, nil
+}
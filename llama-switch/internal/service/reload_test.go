@@ -0,0 +1,352 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"llama-switch/internal/auth"
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestReloadConfig(t *testing.T) {
+	dir := t.TempDir()
+	// 环境变量优先于配置文件，清除可能由系统设置的SSL变量
+	t.Setenv("SSL_CERT_FILE", "")
+	t.Setenv("SSL_KEY_FILE", "")
+	for _, name := range []string{"llama-server", "llama-bench"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "config.yaml")
+	writeConfig := func(ctxSize, port int, key string) {
+		data := fmt.Sprintf(`llama_path:
+  server: %[1]s/llama-server
+  bench: %[1]s/llama-bench
+models_dir: %[1]s
+server:
+  port: %[2]d
+default_model:
+  ctx_size: %[3]d
+tenants:
+  api_keys:
+    team-a: %[4]s
+`, dir, port, ctxSize, key)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeConfig(4096, 8080, "key-a")
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	s := &ModelService{config: cfg, events: NewEventLog(filepath.Join(dir, "events.jsonl"), 10)}
+
+	writeConfig(8192, 9090, "key-b")
+	reload, err := s.ReloadConfig()
+	if err != nil {
+		t.Fatalf("ReloadConfig failed: %v", err)
+	}
+	changes := make(map[string]bool)
+	for _, change := range reload.Changes {
+		changes[change.Field] = change.Applied
+		if change.Field == "tenants.api_keys.team-a" && (change.Old != "******" || change.New != "******") {
+			t.Errorf("API key not redacted: %+v", change)
+		}
+	}
+	if applied, ok := changes["default_model.ctx_size"]; !ok || !applied {
+		t.Errorf("default_model.ctx_size change = %v, %v; want applied", applied, ok)
+	}
+	if applied, ok := changes["server.port"]; !ok || applied {
+		t.Errorf("server.port change = %v, %v; want not applied", applied, ok)
+	}
+	if len(reload.Changes) != 3 || !reload.RestartRequired {
+		t.Errorf("unexpected reload report: %+v", reload)
+	}
+	if cfg.DefaultModel.CtxSize != 8192 || cfg.Tenants.APIKeys["team-a"] != "key-b" || cfg.Server.Port != 8080 {
+		t.Errorf("config after reload: ctx_size=%d key=%s port=%d", cfg.DefaultModel.CtxSize, cfg.Tenants.APIKeys["team-a"], cfg.Server.Port)
+	}
+
+	// 无效配置不生效
+	writeConfig(-1, 8080, "key-b")
+	if _, err := s.ReloadConfig(); err == nil {
+		t.Error("ReloadConfig accepted an invalid configuration")
+	}
+	if cfg.DefaultModel.CtxSize != 8192 {
+		t.Errorf("ctx_size changed by a failed reload: %d", cfg.DefaultModel.CtxSize)
+	}
+}
+
+func TestReloadExtraArgsAllowlist(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SSL_CERT_FILE", "")
+	t.Setenv("SSL_KEY_FILE", "")
+	for _, name := range []string{"llama-server", "llama-bench"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "config.yaml")
+	writeConfig := func(allowed string) {
+		data := fmt.Sprintf(`llama_path:
+  server: %[1]s/llama-server
+  bench: %[1]s/llama-bench
+models_dir: %[1]s
+extra_args:
+  allowlist: [%[2]s]
+`, dir, allowed)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeConfig("--spec-replace")
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	s := &ModelService{config: cfg, events: NewEventLog(filepath.Join(dir, "events.jsonl"), 10)}
+	if err := s.validateExtraArgs([]string{"--spec-replace", "a", "b"}); err != nil {
+		t.Fatalf("allowed flag rejected before reload: %v", err)
+	}
+
+	// 重新加载后新加入允许列表的参数被接受，移除的参数被拒绝
+	writeConfig("--override-kv")
+	reload, err := s.ReloadConfig()
+	if err != nil {
+		t.Fatalf("ReloadConfig failed: %v", err)
+	}
+	if len(reload.Changes) != 1 || reload.Changes[0].Field != "extra_args.allowlist" || !reload.Changes[0].Applied {
+		t.Errorf("reload changes = %+v, want extra_args.allowlist applied", reload.Changes)
+	}
+	if err := s.validateExtraArgs([]string{"--override-kv", "key=int:1"}); err != nil {
+		t.Errorf("newly allowed flag rejected after reload: %v", err)
+	}
+	if err := s.validateExtraArgs([]string{"--spec-replace", "a", "b"}); err == nil {
+		t.Error("removed flag still accepted after reload")
+	}
+}
+
+// TestReloadConfigConcurrent 重新加载配置时并发认证请求和合并默认配置，需要以-race运行才能发现数据竞争
+func TestReloadConfigConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SSL_CERT_FILE", "")
+	t.Setenv("SSL_KEY_FILE", "")
+	for _, name := range []string{"llama-server", "llama-bench"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "config.yaml")
+	writeConfig := func(role string, ctxSize int) {
+		data := fmt.Sprintf(`llama_path:
+  server: %[1]s/llama-server
+  bench: %[1]s/llama-bench
+models_dir: %[1]s
+default_model:
+  ctx_size: %[3]d
+security:
+  api_keys:
+    ops: ops-key
+  roles:
+    ops: %[2]s
+tenants:
+  api_keys:
+    team-a: key-a
+`, dir, role, ctxSize)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeConfig("operator", 4096)
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	s := &ModelService{config: cfg, events: NewEventLog(filepath.Join(dir, "events.jsonl"), 10)}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if role, err := s.RoleForKey("ops-key"); err != nil || (role != auth.RoleOperator && role != auth.RoleReadOnly) {
+					t.Errorf("RoleForKey = %v, %v", role, err)
+					return
+				}
+				if tenant, err := s.TenantForKey("key-a"); err != nil || tenant != "team-a" {
+					t.Errorf("TenantForKey = %q, %v", tenant, err)
+					return
+				}
+				s.CallerForKey("ops-key")
+				s.ApplyModelDefaults(&model.ModelConfig{ModelName: "chat"}, nil)
+				config.Redacted(cfg.Snapshot())
+			}
+		}()
+	}
+
+	for i := 0; i < 20; i++ {
+		if i%2 == 0 {
+			writeConfig("readonly", 8192)
+		} else {
+			writeConfig("operator", 4096)
+		}
+		if _, err := s.ReloadConfig(); err != nil {
+			t.Fatalf("ReloadConfig failed: %v", err)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestConfigProfiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SSL_CERT_FILE", "")
+	t.Setenv("SSL_KEY_FILE", "")
+	for _, name := range []string{"llama-server", "llama-bench"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "config.yaml")
+	data := fmt.Sprintf(`llama_path:
+  server: %[1]s/llama-server
+  bench: %[1]s/llama-bench
+models_dir: %[1]s
+server:
+  host: 127.0.0.1
+  port: 8080
+default_model:
+  ctx_size: 4096
+  threads: 4
+security:
+  api_key: base-key
+profiles:
+  dev:
+  prod:
+    server:
+      host: 0.0.0.0
+      port: 9090
+    default_model:
+      ctx_size: 16384
+    security:
+      api_key: prod-key
+  staging:
+    server:
+      port: 8081
+`, dir)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		profile string
+		host    string
+		port    int
+		ctxSize int
+		apiKey  string
+	}{
+		{"", "127.0.0.1", 8080, 4096, "base-key"},
+		// 空的环境配置与顶层配置相同
+		{"dev", "127.0.0.1", 8080, 4096, "base-key"},
+		{"prod", "0.0.0.0", 9090, 16384, "prod-key"},
+		// 只覆盖环境配置中出现的配置项
+		{"staging", "127.0.0.1", 8081, 4096, "base-key"},
+	}
+	for _, tt := range tests {
+		t.Run("profile="+tt.profile, func(t *testing.T) {
+			t.Setenv("LLAMA_SWITCH_PROFILE", tt.profile)
+			cfg, err := config.LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig failed: %v", err)
+			}
+			if cfg.Profile != tt.profile || cfg.Server.Host != tt.host || cfg.Server.Port != tt.port ||
+				cfg.DefaultModel.CtxSize != tt.ctxSize || cfg.Security.APIKey != tt.apiKey {
+				t.Errorf("profile=%s host=%s port=%d ctx_size=%d api_key=%s; want %+v",
+					cfg.Profile, cfg.Server.Host, cfg.Server.Port, cfg.DefaultModel.CtxSize, cfg.Security.APIKey, tt)
+			}
+			if cfg.DefaultModel.Threads != 4 {
+				t.Errorf("threads = %d, want 4 from the top-level config", cfg.DefaultModel.Threads)
+			}
+		})
+	}
+
+	t.Setenv("LLAMA_SWITCH_PROFILE", "qa")
+	_, err := config.LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "profile qa not found (available: dev, prod, staging)") {
+		t.Errorf("LoadConfig with unknown profile = %v, want profile not found error", err)
+	}
+}
+
+func TestConfigEnvVars(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SSL_CERT_FILE", "")
+	t.Setenv("SSL_KEY_FILE", "")
+	for _, name := range []string{"llama-server", "llama-bench"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "config.yaml")
+	data := fmt.Sprintf(`llama_path:
+  server: %[1]s/llama-server
+  bench: %[1]s/llama-bench
+models_dir: %[1]s
+server:
+  port: 9090
+`, dir)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PROXY_QUEUE_TIMEOUT", "1m")
+	t.Setenv("API_KEY", "secret")
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	vars := make(map[string]model.EnvVarInfo)
+	for _, env := range config.EnvVars(cfg) {
+		vars[env.Name] = env
+	}
+	tests := []struct {
+		name, value, source string
+	}{
+		{"PROXY_QUEUE_TIMEOUT", "60", config.SourceEnv},
+		{"SERVER_PORT", "9090", config.SourceFile},
+		{"MODELS_DIR", dir, config.SourceFile},
+		{"DEFAULT_CTX_SIZE", "4096", config.SourceDefault},
+		{"API_KEY", "******", config.SourceEnv},
+	}
+	for _, tt := range tests {
+		env, ok := vars[tt.name]
+		if !ok {
+			t.Errorf("%s not listed", tt.name)
+			continue
+		}
+		if env.Value != tt.value || env.Source != tt.source || env.Field == "" || env.Description == "" {
+			t.Errorf("%s = %+v; want value %q from %s", tt.name, env, tt.value, tt.source)
+		}
+	}
+
+	// 无效的单位导致加载失败
+	t.Setenv("PROXY_QUEUE_TIMEOUT", "1 minute")
+	if _, err := config.LoadConfig(path); err == nil {
+		t.Error("LoadConfig accepted an invalid PROXY_QUEUE_TIMEOUT")
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// StartDefaultReranker 启动配置的默认重排序模型并等待其就绪，返回模型名称
+// 已在运行时直接返回，并发调用只会启动一次
+func (s *ModelService) StartDefaultReranker(ctx context.Context) (string, error) {
+	rc := s.config.Rerank
+	if rc.DefaultModel == "" {
+		return "", fmt.Errorf("no reranking models running and no default reranker configured")
+	}
+
+	s.rerankMu.Lock()
+	defer s.rerankMu.Unlock()
+
+	if _, err := s.GetBackend(rc.DefaultName); err != nil {
+		cfg := &model.ModelConfig{
+			ModelName: rc.DefaultName,
+			ModelPath: rc.DefaultModel,
+		}
+		cfg.Config.Host = defaultBackendHost
+		cfg.Config.Port = rc.DefaultPort
+		cfg.Config.NGPULayers = model.GPULayers(rc.GPULayers)
+		cfg.Config.Reranking = true
+		s.ApplyModelDefaults(cfg, map[string]bool{"host": true, "port": true, "n_gpu_layers": true, "reranking": true})
+
+		slog.Info("No reranking model running, starting default reranker", "model_name", rc.DefaultName, "model_path", rc.DefaultModel)
+		if _, err := s.StartModel(cfg); err != nil {
+			return "", fmt.Errorf("failed to start default reranker: %v", err)
+		}
+	}
+
+	if err := s.WaitForReady(ctx, rc.DefaultName, time.Duration(rc.StartupTimeout)*time.Second); err != nil {
+		return "", fmt.Errorf("default reranker not ready: %v", err)
+	}
+	return rc.DefaultName, nil
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// cpuSample 上一次采样的进程CPU时间，用于计算使用率
+type cpuSample struct {
+	cpu  time.Duration
+	time time.Time
+}
+
+// ResourceSampler 定期采样运行中模型进程的CPU、内存和显存使用
+type ResourceSampler struct {
+	service     *ModelService
+	historySize int
+
+	mu      sync.RWMutex
+	history map[string][]model.ResourceSample // 按模型名称保存的采样历史（旧的在前）
+	lastCPU map[int]cpuSample                 // 按PID保存的上一次CPU时间
+	gpuErr  bool                              // 已记录过显存查询失败，避免重复输出日志
+
+	inventory  *model.GPUInventory // 最近一次采样的GPU清单
+	devicesErr bool                // 已记录过GPU清单查询失败
+	vramLow    map[int]bool        // 可用显存低于阈值且尚未恢复的GPU
+}
+
+// newResourceSampler 创建资源采样器
+func newResourceSampler(s *ModelService, historySize int) *ResourceSampler {
+	if historySize <= 0 {
+		historySize = 1
+	}
+	return &ResourceSampler{
+		service:     s,
+		historySize: historySize,
+		history:     make(map[string][]model.ResourceSample),
+		lastCPU:     make(map[int]cpuSample),
+		vramLow:     make(map[int]bool),
+	}
+}
+
+// StartResourceSampler 启动后台资源采样，ctx取消时停止
+func (s *ModelService) StartResourceSampler(ctx context.Context) {
+	interval := time.Duration(s.config.Resources.SampleInterval) * time.Second
+	if interval <= 0 {
+		slog.Info("Resource sampling is disabled")
+		return
+	}
+	go s.resources.run(ctx, interval)
+}
+
+// run 按间隔采样所有运行中的模型进程
+func (r *ResourceSampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.SampleAll()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SampleAll 采样所有运行中的模型进程，并清除已停止模型的历史
+func (r *ResourceSampler) SampleAll() {
+	models := r.service.processManager.GetRunningModels()
+
+	gpuMemory, err := r.service.gpu.ProcessMemory()
+	r.mu.Lock()
+	if err != nil && !r.gpuErr {
+		slog.Warn("GPU memory per process unavailable", "error", err)
+	}
+	r.gpuErr = err != nil
+	r.mu.Unlock()
+	if err == nil {
+		r.service.refreshVRAMUsage(gpuMemory)
+	}
+	r.sampleGPUs()
+
+	running := make(map[string]bool, len(models))
+	pids := make(map[int]bool, len(models))
+	for _, m := range models {
+		running[m.ModelName] = true
+		pids[m.ProcessID] = true
+
+		sample, err := r.sample(m.ProcessID, time.Now())
+		if err != nil {
+			slog.Warn("Failed to sample model resources", "model_name", m.ModelName, "pid", m.ProcessID, "error", err)
+			continue
+		}
+		sample.GPUMemoryMB = gpuMemory[m.ProcessID]
+		r.record(m.ModelName, sample)
+		r.recordVRAM(m, sample)
+	}
+
+	r.mu.Lock()
+	for name := range r.history {
+		if !running[name] {
+			delete(r.history, name)
+		}
+	}
+	for pid := range r.lastCPU {
+		if !pids[pid] {
+			delete(r.lastCPU, pid)
+		}
+	}
+	r.mu.Unlock()
+}
+
+// sample 采样单个进程，CPU使用率基于与上一次采样之间的CPU时间增量，首次采样为0
+func (r *ResourceSampler) sample(pid int, now time.Time) (model.ResourceSample, error) {
+	cpu, rss, err := processStats(pid)
+	if err != nil {
+		return model.ResourceSample{}, err
+	}
+
+	r.mu.Lock()
+	prev, exists := r.lastCPU[pid]
+	r.lastCPU[pid] = cpuSample{cpu: cpu, time: now}
+	r.mu.Unlock()
+
+	sample := model.ResourceSample{
+		Time:  now.Format(time.RFC3339),
+		RSSMB: int(rss / (1024 * 1024)),
+	}
+	if exists {
+		sample.CPUPercent = cpuPercent(prev, cpuSample{cpu: cpu, time: now})
+	}
+	return sample, nil
+}
+
+// cpuPercent 计算两次采样之间的CPU使用率（相对单核）
+func cpuPercent(prev, cur cpuSample) float64 {
+	wall := cur.time.Sub(prev.time)
+	if wall <= 0 || cur.cpu < prev.cpu {
+		return 0
+	}
+	percent := float64(cur.cpu-prev.cpu) / float64(wall) * 100
+	// 保留一位小数
+	return float64(int(percent*10+0.5)) / 10
+}
+
+// record 追加采样结果，超出历史长度时丢弃最旧的
+func (r *ResourceSampler) record(name string, sample model.ResourceSample) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	history := append(r.history[name], sample)
+	if len(history) > r.historySize {
+		history = history[len(history)-r.historySize:]
+	}
+	r.history[name] = history
+}
+
+// Latest 获取模型最近一次的采样结果，尚未采样时返回nil
+func (r *ResourceSampler) Latest(name string) *model.ResourceSample {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history := r.history[name]
+	if len(history) == 0 {
+		return nil
+	}
+	sample := history[len(history)-1]
+	return &sample
+}
+
+// History 获取模型的采样历史（旧的在前）
+func (r *ResourceSampler) History(name string) []model.ResourceSample {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]model.ResourceSample(nil), r.history[name]...)
+}
+
+// recordVRAM 将进程的实际显存占用写入持久化的显存历史，同时记录实例的上下文大小和并行槽位数
+func (r *ResourceSampler) recordVRAM(m *model.ModelStatus, sample model.ResourceSample) {
+	if sample.GPUMemoryMB <= 0 || r.service.vramHistory == nil {
+		return
+	}
+	cfg := r.service.runningConfig(m.ModelName)
+	r.service.vramHistory.Record(m.ModelName, model.VRAMSample{
+		Time:      sample.Time,
+		ProcessID: m.ProcessID,
+		VRAMMB:    sample.GPUMemoryMB,
+		CtxSize:   cfg.Config.CtxSize,
+		Parallel:  cfg.Config.Parallel,
+	}, time.Now())
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+func TestParseGPUProcessMemory(t *testing.T) {
+	output := "1234, 4096\n1234, 1024\n5678, [N/A]\n"
+	usage, err := parseGPUProcessMemory(output)
+	if err != nil {
+		t.Fatalf("parseGPUProcessMemory failed: %v", err)
+	}
+	if usage[1234] != 5120 {
+		t.Errorf("Expected 5120MB for multi-GPU process, got %d", usage[1234])
+	}
+	if _, exists := usage[5678]; exists {
+		t.Errorf("Expected process with unavailable memory to be skipped, got %v", usage)
+	}
+
+	if usage, err := parseGPUProcessMemory(""); err != nil || len(usage) != 0 {
+		t.Errorf("Expected empty result for no processes, got %v, %v", usage, err)
+	}
+	if _, err := parseGPUProcessMemory("garbage"); err == nil {
+		t.Error("Expected error for malformed output")
+	}
+}
+
+func TestCPUPercent(t *testing.T) {
+	now := time.Now()
+	prev := cpuSample{cpu: time.Second, time: now}
+	// 1秒内使用了1.5秒CPU时间，即1.5个核
+	if got := cpuPercent(prev, cpuSample{cpu: 2500 * time.Millisecond, time: now.Add(time.Second)}); got != 150 {
+		t.Errorf("cpuPercent = %v, want 150", got)
+	}
+	if got := cpuPercent(prev, cpuSample{cpu: 0, time: now.Add(time.Second)}); got != 0 {
+		t.Errorf("Expected 0 for reused PID, got %v", got)
+	}
+}
+
+func TestResourceSamplerHistory(t *testing.T) {
+	r := newResourceSampler(nil, 2)
+
+	first, err := r.sample(os.Getpid(), time.Now())
+	if err != nil {
+		t.Fatalf("sample failed: %v", err)
+	}
+	if first.RSSMB <= 0 {
+		t.Errorf("Expected positive RSS for the test process, got %d", first.RSSMB)
+	}
+
+	for i := 0; i < 3; i++ {
+		r.record("chat", model.ResourceSample{RSSMB: i})
+	}
+	history := r.History("chat")
+	if len(history) != 2 || history[0].RSSMB != 1 || history[1].RSSMB != 2 {
+		t.Fatalf("Expected last 2 samples, got %+v", history)
+	}
+	if latest := r.Latest("chat"); latest == nil || latest.RSSMB != 2 {
+		t.Errorf("Latest = %+v, want RSS 2", latest)
+	}
+	if r.Latest("embed") != nil {
+		t.Error("Expected nil for model without samples")
+	}
+}
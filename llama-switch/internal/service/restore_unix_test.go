@@ -0,0 +1,119 @@
+//go:build !windows
+
+package service
+
+import (
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestRestoreModelsRestartsExitedInstance(t *testing.T) {
+	// 已退出的进程的PID
+	exited := exec.Command("sh", "-c", "true")
+	if err := exited.Run(); err != nil {
+		t.Skipf("sh not available: %v", err)
+	}
+	pid := exited.Process.Pid
+
+	cfg := &config.Config{PersistentDir: t.TempDir()}
+	s := &ModelService{
+		config:         cfg,
+		processManager: NewProcessManager(),
+		persistentMgr:  config.NewPersistentManager(cfg),
+		autoRestore:    true,
+	}
+	// 缺少模型路径使重新启动在验证阶段失败，不需要真正启动llama-server
+	last := &model.ModelStatus{ModelName: "chat", ProcessID: pid, Port: 8123, Running: true, StartTime: "2024-01-01T00:00:00Z"}
+	if err := s.persistentMgr.UpdateModelConfig("chat", &model.ModelConfig{ModelName: "chat"}, last); err != nil {
+		t.Fatal(err)
+	}
+	failuresBefore := counterValue(t, restoreFailures.WithLabelValues("chat"))
+
+	// 进程已不存在时修正持久化状态后尝试重新启动，而不是只标记为已停止
+	err := s.RestoreModels()
+	if err == nil || !strings.Contains(err.Error(), "model path is required") {
+		t.Fatalf("RestoreModels error = %v; want the restart attempt to fail validation", err)
+	}
+	if got := counterValue(t, restoreFailures.WithLabelValues("chat")); got != failuresBefore+1 {
+		t.Errorf("restore failures = %v, want %v", got, failuresBefore+1)
+	}
+	configs, err := s.persistentMgr.GetModelConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := configs["chat"].LastStatus; status.Running || status.StopTime == "" {
+		t.Errorf("persisted status not marked stopped: %+v", status)
+	}
+}
+
+func TestEvictionMarksModelStopped(t *testing.T) {
+	cfg := &config.Config{PersistentDir: t.TempDir()}
+	cfg.Eviction.Policy = EvictionLargest
+	cfg.Eviction.PollIntervalMS = 1
+	cfg.Eviction.StablePolls = 1
+	cfg.Eviction.ReleaseTimeout = 1
+	s := &ModelService{
+		config:         cfg,
+		processManager: NewProcessManager(),
+		persistentMgr:  config.NewPersistentManager(cfg),
+		tracker:        NewRequestTracker(0, time.Second),
+		configs:        make(map[string]*model.ModelConfig),
+	}
+
+	if err := s.processManager.StartProcess("sh", []string{"-c", "sleep 30"}, ProcessOptions{Output: io.Discard}); err != nil {
+		t.Skipf("sh not available: %v", err)
+	}
+	pid := s.processManager.GetPID()
+	defer func() {
+		if processAlive(pid) {
+			s.processManager.StopPID(pid)
+		}
+	}()
+	status := &model.ModelStatus{ModelName: "chat", ProcessID: pid, Running: true, VRAMUsage: 2000}
+	s.processManager.AddModel(pid, status)
+	s.setRunningConfig("chat", &model.ModelConfig{ModelName: "chat", ModelPath: "chat.gguf"})
+	if err := s.persistentMgr.UpdateModelConfig("chat", &model.ModelConfig{ModelName: "chat", ModelPath: "chat.gguf"}, status); err != nil {
+		t.Fatal(err)
+	}
+
+	free := 1000
+	available := func() (int, error) {
+		if !processAlive(pid) {
+			free = 3000
+		}
+		return free, nil
+	}
+	if err := s.evictModels(s.processManager.GetRunningModels(), 1500, true, "VRAM", available); err != nil {
+		t.Fatalf("evictModels failed: %v", err)
+	}
+
+	// 被驱逐的模型在持久化配置中标记为已停止，重启switcher后不会当作运行中的实例处理
+	configs, err := s.persistentMgr.GetModelConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := configs["chat"].LastStatus; last.Running || last.StopTime == "" {
+		t.Errorf("evicted model not marked stopped: %+v", last)
+	}
+	if s.processManager.FindModel("chat") != nil {
+		t.Error("evicted model still tracked")
+	}
+}
+
+// counterValue 读取计数器的当前值
+func counterValue(t *testing.T, c interface{ Write(*dto.Metric) error }) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetCounter().GetValue()
+}
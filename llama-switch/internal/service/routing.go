@@ -0,0 +1,212 @@
+package service
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// latencySampleSize 计算P95延迟时保留的最近请求数
+const latencySampleSize = 1000
+
+// 路由目标角色
+const (
+	RolePrimary = "primary"
+	RoleCanary  = "canary"
+)
+
+// RouteDecision 单个请求的路由结果
+type RouteDecision struct {
+	Route  string // 路由规则名称（逻辑模型名称）
+	Target string // 处理请求的模型
+	Shadow string // 需要复制请求的影子模型（为空表示不复制）
+}
+
+// targetStats 单个路由目标的统计
+type targetStats struct {
+	role      string
+	requests  int64
+	errors    int64
+	latency   time.Duration // 累计延迟
+	tokens    int64         // 累计生成token数
+	tokenTime time.Duration // 报告了token数的请求的累计延迟
+	samples   []time.Duration
+	next      int
+}
+
+// route 流量路由规则
+type route struct {
+	config    model.RouteConfig
+	stats     map[string]*targetStats
+	createdAt time.Time
+}
+
+// RouteManager 流量路由管理器，支持金丝雀和影子路由
+type RouteManager struct {
+	mu     sync.Mutex
+	routes map[string]*route
+	rand   *rand.Rand
+}
+
+// NewRouteManager 创建流量路由管理器
+func NewRouteManager() *RouteManager {
+	return &RouteManager{
+		routes: make(map[string]*route),
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Set 创建或更新路由规则，目标模型变化时重置统计
+func (m *RouteManager) Set(cfg *model.RouteConfig) (*model.RouteStatus, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("route name is required")
+	}
+	if cfg.Primary == "" || cfg.Canary == "" {
+		return nil, fmt.Errorf("both primary and canary models are required")
+	}
+	if cfg.Primary == cfg.Canary {
+		return nil, fmt.Errorf("primary and canary must be different models")
+	}
+	if cfg.Name == cfg.Primary || cfg.Name == cfg.Canary {
+		return nil, fmt.Errorf("route name must differ from its target models")
+	}
+	if cfg.Weight < 0 || cfg.Weight > 100 {
+		return nil, fmt.Errorf("invalid canary weight: %d (should be between 0 and 100)", cfg.Weight)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, exists := m.routes[cfg.Name]
+	if !exists || r.config.Primary != cfg.Primary || r.config.Canary != cfg.Canary {
+		r = &route{
+			stats: map[string]*targetStats{
+				cfg.Primary: {role: RolePrimary},
+				cfg.Canary:  {role: RoleCanary},
+			},
+			createdAt: time.Now(),
+		}
+		m.routes[cfg.Name] = r
+	}
+	r.config = *cfg
+	return r.status(), nil
+}
+
+// Remove 删除路由规则
+func (m *RouteManager) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.routes[name]; !exists {
+		return fmt.Errorf("route '%s' not found", name)
+	}
+	delete(m.routes, name)
+	return nil
+}
+
+// Pick 为请求的模型名称选择路由目标，名称不是路由规则时返回false
+func (m *RouteManager) Pick(name string) (*RouteDecision, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, exists := m.routes[name]
+	if !exists {
+		return nil, false
+	}
+
+	decision := &RouteDecision{Route: name, Target: r.config.Primary}
+	if m.rand.Intn(100) < r.config.Weight {
+		if r.config.Shadow {
+			decision.Shadow = r.config.Canary
+		} else {
+			decision.Target = r.config.Canary
+		}
+	}
+	return decision, true
+}
+
+// Record 记录路由目标处理一次请求的结果，tokens为响应报告的生成token数（未知时为0）
+func (m *RouteManager) Record(routeName, target string, latency time.Duration, failed bool, tokens int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, exists := m.routes[routeName]
+	if !exists {
+		return
+	}
+	stats, exists := r.stats[target]
+	if !exists {
+		return
+	}
+
+	stats.requests++
+	if failed {
+		stats.errors++
+	}
+	stats.latency += latency
+	if tokens > 0 {
+		stats.tokens += int64(tokens)
+		stats.tokenTime += latency
+	}
+	if len(stats.samples) < latencySampleSize {
+		stats.samples = append(stats.samples, latency)
+	} else {
+		stats.samples[stats.next] = latency
+		stats.next = (stats.next + 1) % latencySampleSize
+	}
+}
+
+// List 获取所有路由规则及统计，按名称排序
+func (m *RouteManager) List() []*model.RouteStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]*model.RouteStatus, 0, len(m.routes))
+	for _, r := range m.routes {
+		list = append(list, r.status())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// status 生成路由规则状态（调用方需持有锁）
+func (r *route) status() *model.RouteStatus {
+	status := &model.RouteStatus{
+		RouteConfig: r.config,
+		CreatedAt:   r.createdAt.Format(time.RFC3339),
+	}
+	for _, target := range []string{r.config.Primary, r.config.Canary} {
+		status.Stats = append(status.Stats, r.stats[target].snapshot(target))
+	}
+	return status
+}
+
+// snapshot 生成目标统计快照
+func (s *targetStats) snapshot(target string) *model.RouteTargetStats {
+	snapshot := &model.RouteTargetStats{
+		Model:    target,
+		Role:     s.role,
+		Requests: s.requests,
+		Errors:   s.errors,
+	}
+	if s.requests > 0 {
+		snapshot.AvgLatencyMS = float64(s.latency.Milliseconds()) / float64(s.requests)
+	}
+	if s.tokenTime > 0 {
+		snapshot.TokensPerSecond = float64(s.tokens) / s.tokenTime.Seconds()
+	}
+	if len(s.samples) > 0 {
+		sorted := make([]time.Duration, len(s.samples))
+		copy(sorted, s.samples)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		index := (len(sorted)*95+99)/100 - 1
+		snapshot.P95LatencyMS = float64(sorted[index].Milliseconds())
+	}
+	return snapshot
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+func TestRouteManager_PickByWeight(t *testing.T) {
+	m := NewRouteManager()
+
+	if _, err := m.Set(&model.RouteConfig{Name: "chat", Primary: "v1", Canary: "v2", Weight: 0}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if d, _ := m.Pick("chat"); d.Target != "v1" || d.Shadow != "" {
+			t.Fatalf("Weight 0 picked %+v, want primary only", d)
+		}
+	}
+
+	if _, err := m.Set(&model.RouteConfig{Name: "chat", Primary: "v1", Canary: "v2", Weight: 100}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if d, _ := m.Pick("chat"); d.Target != "v2" {
+			t.Fatalf("Weight 100 picked %+v, want canary", d)
+		}
+	}
+
+	if _, err := m.Set(&model.RouteConfig{Name: "chat", Primary: "v1", Canary: "v2", Weight: 100, Shadow: true}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if d, _ := m.Pick("chat"); d.Target != "v1" || d.Shadow != "v2" {
+		t.Errorf("Shadow mode picked %+v, want primary with canary shadow", d)
+	}
+
+	if _, ok := m.Pick("v1"); ok {
+		t.Error("Expected no route for a plain model name")
+	}
+
+	invalid := []*model.RouteConfig{
+		{Name: "chat", Primary: "v1", Canary: "v1"},
+		{Name: "chat", Primary: "v1", Canary: "v2", Weight: 101},
+		{Name: "v1", Primary: "v1", Canary: "v2"},
+		{Primary: "v1", Canary: "v2"},
+	}
+	for _, cfg := range invalid {
+		if _, err := m.Set(cfg); err == nil {
+			t.Errorf("Expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestRouteManager_Stats(t *testing.T) {
+	m := NewRouteManager()
+	if _, err := m.Set(&model.RouteConfig{Name: "chat", Primary: "v1", Canary: "v2", Weight: 50}); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	for i := 1; i <= 20; i++ {
+		m.Record("chat", "v1", time.Duration(i)*100*time.Millisecond, false, 10)
+	}
+	m.Record("chat", "v2", time.Second, true, 0)
+	m.Record("chat", "v2", time.Second, false, 50)
+	m.Record("chat", "unknown", time.Second, false, 0)
+
+	routes := m.List()
+	if len(routes) != 1 || len(routes[0].Stats) != 2 {
+		t.Fatalf("Unexpected route status: %+v", routes)
+	}
+
+	primary, canary := routes[0].Stats[0], routes[0].Stats[1]
+	if primary.Role != RolePrimary || primary.Requests != 20 || primary.Errors != 0 {
+		t.Errorf("Unexpected primary stats: %+v", primary)
+	}
+	if primary.AvgLatencyMS != 1050 {
+		t.Errorf("AvgLatencyMS = %v, want 1050", primary.AvgLatencyMS)
+	}
+	if primary.P95LatencyMS != 1900 {
+		t.Errorf("P95LatencyMS = %v, want 1900", primary.P95LatencyMS)
+	}
+	// 200 tokens / 21s
+	if got := primary.TokensPerSecond; got < 9.52 || got > 9.53 {
+		t.Errorf("TokensPerSecond = %v, want ~9.52", got)
+	}
+
+	if canary.Role != RoleCanary || canary.Requests != 2 || canary.Errors != 1 || canary.TokensPerSecond != 50 {
+		t.Errorf("Unexpected canary stats: %+v", canary)
+	}
+
+	// 只调整比例时保留统计，更换目标模型时重新统计
+	status, _ := m.Set(&model.RouteConfig{Name: "chat", Primary: "v1", Canary: "v2", Weight: 20})
+	if status.Stats[0].Requests != 20 {
+		t.Error("Expected stats to be kept when only weight changes")
+	}
+	status, _ = m.Set(&model.RouteConfig{Name: "chat", Primary: "v1", Canary: "v3", Weight: 20})
+	if status.Stats[0].Requests != 0 {
+		t.Error("Expected stats to be reset when targets change")
+	}
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+// RPC端点事件类型
+const (
+	EventRPCUnhealthy = "rpc_unhealthy" // rpc-server端点无法连接
+	EventRPCRecovered = "rpc_recovered" // rpc-server端点恢复连接
+)
+
+// rpcRegistry 管理RPC_POOLS中配置的rpc-server端点的健康状态，
+// 并为已分配但尚未启动完成的模型预留池中的显存
+type rpcRegistry struct {
+	mu       sync.Mutex
+	pools    map[string][]*model.RPCEndpointStatus
+	nextID   int
+	reserved map[int]rpcReservation
+}
+
+// rpcReservation 启动中的模型在RPC池中的预留
+type rpcReservation struct {
+	pool     string
+	memoryMB int
+}
+
+// rpcAllocation 为模型分配的RPC池端点和可用显存
+type rpcAllocation struct {
+	Pool      string   // 池名称
+	Endpoints []string // 健康的端点地址
+	FreeMB    int      // 池中可分配的显存(MB)
+}
+
+// newRPCRegistry 根据配置创建RPC池注册表，端点在第一次探测前视为不健康
+func newRPCRegistry(pools map[string]string) *rpcRegistry {
+	r := &rpcRegistry{
+		pools:    make(map[string][]*model.RPCEndpointStatus),
+		reserved: make(map[int]rpcReservation),
+	}
+	for name, spec := range pools {
+		// 配置已在启动时验证
+		endpoints, _ := config.ParseRPCPool(spec)
+		for _, endpoint := range endpoints {
+			r.pools[name] = append(r.pools[name], &model.RPCEndpointStatus{
+				Address:  endpoint.Address,
+				MemoryMB: endpoint.MemoryMB,
+			})
+		}
+	}
+	return r
+}
+
+// has 检查RPC池是否存在
+func (r *rpcRegistry) has(pool string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, exists := r.pools[pool]
+	return exists
+}
+
+// reserve 预留RPC池中的显存，返回用于释放的预留编号
+func (r *rpcRegistry) reserve(pool string, memoryMB int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	r.reserved[r.nextID] = rpcReservation{pool: pool, memoryMB: memoryMB}
+	return r.nextID
+}
+
+// release 释放RPC池中的预留
+func (r *rpcRegistry) release(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.reserved, id)
+}
+
+// probeRPC 检查rpc-server端点是否接受TCP连接
+func probeRPC(ctx context.Context, address string) error {
+	dialer := net.Dialer{Timeout: healthProbeTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// StartRPCMonitor 启动RPC池端点的后台健康检查，ctx取消时停止
+func (s *ModelService) StartRPCMonitor(ctx context.Context) {
+	if len(s.config.RPC.Pools) == 0 {
+		return
+	}
+	interval := time.Duration(s.config.RPC.HealthInterval) * time.Second
+	if interval <= 0 {
+		slog.Info("RPC pool health checking is disabled, endpoints are probed on switch")
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			for _, pool := range slices.Sorted(maps.Keys(s.config.RPC.Pools)) {
+				s.checkRPCPool(ctx, pool)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// checkRPCPool 并发探测RPC池中的所有端点并更新状态，健康状态变化时记录事件
+func (s *ModelService) checkRPCPool(ctx context.Context, pool string) {
+	s.rpc.mu.Lock()
+	endpoints := s.rpc.pools[pool]
+	addresses := make([]string, len(endpoints))
+	for i, endpoint := range endpoints {
+		addresses[i] = endpoint.Address
+	}
+	s.rpc.mu.Unlock()
+
+	errs := make([]error, len(addresses))
+	var wg sync.WaitGroup
+	for i, address := range addresses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = probeRPC(ctx, address)
+		}()
+	}
+	wg.Wait()
+
+	now := time.Now().Format(time.RFC3339)
+	s.rpc.mu.Lock()
+	defer s.rpc.mu.Unlock()
+	for i, endpoint := range endpoints {
+		checked := endpoint.CheckedAt != ""
+		wasHealthy := endpoint.Healthy
+		endpoint.CheckedAt = now
+		endpoint.Healthy = errs[i] == nil
+		endpoint.Error = ""
+		if errs[i] != nil {
+			endpoint.Error = errs[i].Error()
+		}
+
+		switch {
+		case !endpoint.Healthy && (wasHealthy || !checked):
+			slog.Warn("RPC endpoint is unreachable", "endpoint", endpoint.Address, "pool", pool, "error", errs[i])
+			s.events.Record(EventRPCUnhealthy, "", fmt.Sprintf("RPC endpoint %s in pool %s is unreachable: %v",
+				endpoint.Address, pool, errs[i]), *endpoint)
+		case endpoint.Healthy && checked && !wasHealthy:
+			slog.Info("RPC endpoint recovered", "endpoint", endpoint.Address, "pool", pool)
+			s.events.Record(EventRPCRecovered, "", fmt.Sprintf("RPC endpoint %s in pool %s recovered",
+				endpoint.Address, pool), *endpoint)
+		}
+	}
+}
+
+// rpcUsage 统计使用各RPC池的运行中模型及其估算的显存占用，包含启动中模型的预留
+func (s *ModelService) rpcUsage() (map[string]int, map[string][]string) {
+	used := make(map[string]int)
+	models := make(map[string][]string)
+	for _, m := range s.processManager.GetRunningModels() {
+		if m.RPCPool != "" {
+			used[m.RPCPool] += m.RPCUsageMB
+			models[m.RPCPool] = append(models[m.RPCPool], m.ModelName)
+		}
+	}
+	s.rpc.mu.Lock()
+	for _, r := range s.rpc.reserved {
+		used[r.pool] += r.memoryMB
+	}
+	s.rpc.mu.Unlock()
+	return used, models
+}
+
+// RPCPools 获取所有RPC池的端点状态和容量
+func (s *ModelService) RPCPools() []model.RPCPoolStatus {
+	used, models := s.rpcUsage()
+
+	s.rpc.mu.Lock()
+	defer s.rpc.mu.Unlock()
+	pools := make([]model.RPCPoolStatus, 0, len(s.rpc.pools))
+	for _, name := range slices.Sorted(maps.Keys(s.rpc.pools)) {
+		pool := model.RPCPoolStatus{Name: name, UsedMB: used[name], Models: models[name]}
+		if pool.Models == nil {
+			pool.Models = []string{}
+		}
+		for _, endpoint := range s.rpc.pools[name] {
+			pool.Endpoints = append(pool.Endpoints, *endpoint)
+			if endpoint.Healthy {
+				pool.TotalMB += endpoint.MemoryMB
+			}
+		}
+		pool.FreeMB = max(pool.TotalMB-pool.UsedMB, 0)
+		pools = append(pools, pool)
+	}
+	return pools
+}
+
+// allocateRPC 探测RPC池的端点，返回健康的端点和池中可分配的显存，没有健康端点时返回错误
+func (s *ModelService) allocateRPC(pool string) (*rpcAllocation, error) {
+	if !s.rpc.has(pool) {
+		return nil, fmt.Errorf("unknown RPC pool: %s", pool)
+	}
+	s.checkRPCPool(context.Background(), pool)
+
+	for _, status := range s.RPCPools() {
+		if status.Name != pool {
+			continue
+		}
+		allocation := &rpcAllocation{Pool: pool, FreeMB: status.FreeMB}
+		for _, endpoint := range status.Endpoints {
+			if endpoint.Healthy {
+				allocation.Endpoints = append(allocation.Endpoints, endpoint.Address)
+			}
+		}
+		if len(allocation.Endpoints) == 0 {
+			return nil, fmt.Errorf("no healthy endpoints in RPC pool %s", pool)
+		}
+		return allocation, nil
+	}
+	return nil, fmt.Errorf("unknown RPC pool: %s", pool)
+}
+
+// rpcShare 估算模型分配到RPC池的显存：llama-server默认按各设备的可用显存比例分配层
+func rpcShare(required, localFree, rpcFree int) int {
+	if required <= 0 || rpcFree <= 0 {
+		return 0
+	}
+	share := required * rpcFree / (max(localFree, 0) + rpcFree)
+	return min(share, rpcFree)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestParseRPCPool(t *testing.T) {
+	endpoints, err := config.ParseRPCPool("10.0.0.2:50052@24576+ 10.0.0.3:50052@8g")
+	want := []config.RPCEndpoint{{Address: "10.0.0.2:50052", MemoryMB: 24576}, {Address: "10.0.0.3:50052", MemoryMB: 8192}}
+	if err != nil || !reflect.DeepEqual(endpoints, want) {
+		t.Errorf("ParseRPCPool = %+v, %v, want %+v", endpoints, err, want)
+	}
+
+	for _, spec := range []string{"", "10.0.0.2@1024", "10.0.0.2:50052", "10.0.0.2:50052@0", "10.0.0.2:50052@1024+"} {
+		if _, err := config.ParseRPCPool(spec); err == nil {
+			t.Errorf("ParseRPCPool(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestRPCShare(t *testing.T) {
+	tests := []struct {
+		required, localFree, rpcFree, want int
+	}{
+		{8000, 6000, 2000, 2000},
+		{4000, 3000, 1000, 1000},
+		{4000, 0, 8000, 4000},
+		{4000, 6000, 0, 0},
+		{0, 6000, 2000, 0},
+	}
+	for _, tt := range tests {
+		if got := rpcShare(tt.required, tt.localFree, tt.rpcFree); got != tt.want {
+			t.Errorf("rpcShare(%d, %d, %d) = %d, want %d", tt.required, tt.localFree, tt.rpcFree, got, tt.want)
+		}
+	}
+}
+
+func TestAllocateRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	// 关闭的监听地址用作无法连接的端点
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	down := closed.Addr().String()
+	closed.Close()
+	up := listener.Addr().String()
+
+	s := &ModelService{
+		processManager: NewProcessManager(),
+		events:         NewEventLog(filepath.Join(t.TempDir(), "events.jsonl"), 10),
+		rpc: newRPCRegistry(map[string]string{
+			"lab":  up + "@4000+" + down + "@8000",
+			"dead": down + "@8000",
+		}),
+	}
+	s.processManager.AddModel(1, &model.ModelStatus{Running: true, ModelName: "big", RPCPool: "lab", RPCUsageMB: 1000})
+	id := s.rpc.reserve("lab", 500)
+
+	allocation, err := s.allocateRPC("lab")
+	if err != nil {
+		t.Fatalf("allocateRPC failed: %v", err)
+	}
+	if !reflect.DeepEqual(allocation.Endpoints, []string{up}) || allocation.FreeMB != 2500 {
+		t.Errorf("allocation = %+v, want [%s] with 2500MB free", allocation, up)
+	}
+	s.rpc.release(id)
+
+	pools := s.RPCPools()
+	if len(pools) != 2 || pools[1].Name != "lab" || pools[1].TotalMB != 4000 || pools[1].UsedMB != 1000 ||
+		!reflect.DeepEqual(pools[1].Models, []string{"big"}) {
+		t.Errorf("RPCPools = %+v", pools)
+	}
+
+	if _, err := s.allocateRPC("dead"); err == nil || !strings.Contains(err.Error(), "no healthy endpoints") {
+		t.Errorf("allocateRPC(dead) error = %v", err)
+	}
+	if _, err := s.allocateRPC("missing"); err == nil || !strings.Contains(err.Error(), "unknown RPC pool") {
+		t.Errorf("allocateRPC(missing) error = %v", err)
+	}
+
+	// 端点第一次探测失败时记录一次，之后持续失败不重复记录
+	s.checkRPCPool(context.Background(), "lab")
+	unhealthy := 0
+	for _, event := range s.events.Recent("", 0) {
+		if event.Type == EventRPCUnhealthy {
+			unhealthy++
+		}
+	}
+	if unhealthy != 2 {
+		t.Errorf("recorded %d rpc_unhealthy events, want 2 (one per unreachable endpoint)", unhealthy)
+	}
+}
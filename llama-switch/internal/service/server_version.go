@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// versionTimeout 执行llama-server --version的超时时间
+const versionTimeout = 10 * time.Second
+
+// versionPattern 匹配llama-server --version输出中的构建号和提交，例如"version: 4567 (a1b2c3d4)"
+var versionPattern = regexp.MustCompile(`version:\s*(\d+)\s*\(([0-9a-fA-F]+)\)`)
+
+// flagMinBuild 较新的llama-server参数及其最早支持的构建号，
+// 在旧版本上使用这些参数时直接拒绝，而不是启动后才从进程输出中发现
+var flagMinBuild = map[string]int{
+	"--jinja":            4474,
+	"--reasoning-format": 4706,
+}
+
+// detectServerVersion 运行llama-server --version获取构建信息
+func detectServerVersion(binary string) (*model.LlamaServerVersion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+
+	// llama-server将版本信息输出到stderr
+	out, err := exec.CommandContext(ctx, binary, "--version").CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s --version: %v", binary, err)
+	}
+	return parseServerVersion(string(out))
+}
+
+// parseServerVersion 解析llama-server --version的输出
+func parseServerVersion(output string) (*model.LlamaServerVersion, error) {
+	match := versionPattern.FindStringSubmatch(output)
+	if match == nil {
+		return nil, fmt.Errorf("unrecognized version output: %q", strings.TrimSpace(output))
+	}
+	build, err := strconv.Atoi(match[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid build number: %s", match[1])
+	}
+
+	version := &model.LlamaServerVersion{Build: build, Commit: match[2]}
+	for _, line := range strings.Split(output, "\n") {
+		if info, ok := strings.CutPrefix(strings.TrimSpace(line), "built with "); ok {
+			version.BuiltWith = info
+			break
+		}
+	}
+	return version, nil
+}
+
+// serverBinary 获取构建配置对应的llama-server路径，未指定时使用LLAMA_SERVER_PATH
+func (s *ModelService) serverBinary(profile string) (string, error) {
+	paths := s.config.Snapshot().LLamaPath
+	if profile == "" {
+		return paths.Server, nil
+	}
+	binary, exists := paths.Profiles[profile]
+	if !exists {
+		return "", fmt.Errorf("unknown backend profile: %s", profile)
+	}
+	return binary, nil
+}
+
+// detectServerVersions 检测默认llama-server和各构建配置的版本，检测失败的构建不记录
+// 启动时和重新加载配置后二进制路径变化时调用
+func (s *ModelService) detectServerVersions() {
+	versions := make(map[string]*model.LlamaServerVersion)
+	paths := s.config.Snapshot().LLamaPath
+	binaries := map[string]string{"": paths.Server}
+	for name, path := range paths.Profiles {
+		binaries[name] = path
+	}
+	for name, binary := range binaries {
+		version, err := detectServerVersion(binary)
+		label := "llama-server"
+		if name != "" {
+			label = fmt.Sprintf("llama-server profile %s", name)
+		}
+		if err != nil {
+			slog.Warn("Failed to detect llama.cpp version, newer flags will not be checked", "binary", label, "error", err)
+			continue
+		}
+		slog.Info("Detected llama.cpp build", "binary", label, "build", version.Build, "commit", version.Commit)
+		versions[name] = version
+	}
+	s.serverVersions = versions
+}
+
+// ServerVersion 获取启动时检测到的默认llama-server版本，检测失败时返回nil
+func (s *ModelService) ServerVersion() *model.LlamaServerVersion {
+	return s.serverVersions[""]
+}
+
+// ProfileVersions 获取各llama-server构建配置检测到的版本，检测失败的构建为nil
+func (s *ModelService) ProfileVersions() map[string]*model.LlamaServerVersion {
+	profiles := s.config.Snapshot().LLamaPath.Profiles
+	if len(profiles) == 0 {
+		return nil
+	}
+	versions := make(map[string]*model.LlamaServerVersion, len(profiles))
+	for name := range profiles {
+		versions[name] = s.serverVersions[name]
+	}
+	return versions
+}
+
+// checkServerFlags 检查命令行参数是否被所选llama-server构建支持，版本未知时不检查
+func (s *ModelService) checkServerFlags(profile string, args []string) error {
+	version := s.serverVersions[profile]
+	if version == nil {
+		return nil
+	}
+	for _, arg := range args {
+		minBuild, exists := flagMinBuild[arg]
+		if exists && version.Build < minBuild {
+			return fmt.Errorf("flag %s unsupported by your llama-server build b%d (requires b%d or newer)",
+				arg, version.Build, minBuild)
+		}
+	}
+	return nil
+}
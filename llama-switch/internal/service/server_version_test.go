@@ -0,0 +1,46 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"llama-switch/internal/model"
+)
+
+func TestParseServerVersion(t *testing.T) {
+	output := "ggml_cuda_init: found 1 CUDA devices\nversion: 4567 (a1b2c3d4)\nbuilt with cc (GCC) 13.2.0 for x86_64-linux-gnu\n"
+	version, err := parseServerVersion(output)
+	if err != nil {
+		t.Fatalf("parseServerVersion failed: %v", err)
+	}
+	if version.Build != 4567 || version.Commit != "a1b2c3d4" || version.BuiltWith != "cc (GCC) 13.2.0 for x86_64-linux-gnu" {
+		t.Errorf("Unexpected version: %+v", version)
+	}
+
+	if _, err := parseServerVersion("usage: llama-server [options]"); err == nil {
+		t.Error("Expected error for output without a version line")
+	}
+}
+
+func TestCheckServerFlags(t *testing.T) {
+	args := []string{"--model", "chat.gguf", "--reasoning-format", "deepseek"}
+
+	// 版本未知时不检查
+	s := &ModelService{}
+	if err := s.checkServerFlags("", args); err != nil {
+		t.Errorf("Expected no check without a detected version, got %v", err)
+	}
+
+	// 按所选构建配置的版本检查
+	s.serverVersions = map[string]*model.LlamaServerVersion{
+		"":       {Build: 5000},
+		"vulkan": {Build: 4000},
+	}
+	err := s.checkServerFlags("vulkan", args)
+	if err == nil || !strings.Contains(err.Error(), "--reasoning-format unsupported by your llama-server build b4000") {
+		t.Errorf("Expected unsupported flag error, got %v", err)
+	}
+	if err := s.checkServerFlags("", args); err != nil {
+		t.Errorf("Expected flags to be supported by b5000, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"llama-switch/internal/apierror"
+)
+
+// 启动失败原因
+const (
+	StartupExited  = "exited"  // 进程在就绪前退出
+	StartupTimeout = "timeout" // 超时仍未就绪
+)
+
+// maxPartialLine 未以换行结束的输出最多保留的字节数（llama-server加载时会输出不换行的进度点）
+const maxPartialLine = 1024
+
+// ModelStartupError 模型进程未能在启动阶段就绪时返回的错误
+type ModelStartupError struct {
+	ModelName  string
+	Reason     string        // 失败原因：exited或timeout
+	ExitStatus string        // 进程的退出状态（仅exited）
+	Elapsed    time.Duration // 从启动到判定失败的时间
+	LastOutput []string      // 进程最近的输出
+}
+
+func (e *ModelStartupError) Error() string {
+	if e.Reason == StartupExited {
+		return fmt.Sprintf("model '%s' exited during startup after %v: %s",
+			e.ModelName, e.Elapsed.Round(time.Millisecond), e.ExitStatus)
+	}
+	return fmt.Sprintf("model '%s' not ready within %v", e.ModelName, e.Elapsed.Round(time.Second))
+}
+
+func (e *ModelStartupError) ErrorCode() apierror.Code { return apierror.CodeModelStartupFailed }
+
+// outputTail 保留进程最近输出的若干行，用于启动失败时的诊断
+type outputTail struct {
+	mu      sync.Mutex
+	size    int
+	lines   []string
+	partial []byte
+}
+
+// newOutputTail 创建保留最近size行输出的缓冲
+func newOutputTail(size int) *outputTail {
+	return &outputTail{size: size}
+}
+
+// Write 按行保存输出，超出行数时丢弃最旧的行
+func (t *outputTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	data := append(t.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		t.lines = append(t.lines, strings.TrimRight(string(data[:i]), "\r"))
+		data = data[i+1:]
+	}
+	if len(t.lines) > t.size {
+		t.lines = t.lines[len(t.lines)-t.size:]
+	}
+	if len(data) > maxPartialLine {
+		data = data[len(data)-maxPartialLine:]
+	}
+	t.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+// Lines 获取保留的输出行，包含尚未换行的部分
+func (t *outputTail) Lines() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines := append([]string(nil), t.lines...)
+	if len(t.partial) > 0 {
+		lines = append(lines, string(t.partial))
+	}
+	if len(lines) > t.size {
+		lines = lines[len(lines)-t.size:]
+	}
+	return lines
+}
+
+// teeOutput 将进程输出同时写入原输出和诊断缓冲，进程退出时关闭原输出
+type teeOutput struct {
+	io.Writer
+	closer io.Closer
+}
+
+// newTeeOutput 创建同时写入output和tail的输出，output为nil时写入控制台
+func newTeeOutput(output io.Writer, tail *outputTail) *teeOutput {
+	if output == nil {
+		return &teeOutput{Writer: io.MultiWriter(os.Stdout, tail)}
+	}
+	w := &teeOutput{Writer: io.MultiWriter(output, tail)}
+	if closer, ok := output.(io.Closer); ok {
+		w.closer = closer
+	}
+	return w
+}
+
+func (w *teeOutput) Close() error {
+	if w.closer != nil {
+		return w.closer.Close()
+	}
+	return nil
+}
+
+// waitStartup 等待新启动的模型实例的/health返回200，进程提前退出或超时时返回ModelStartupError
+func (s *ModelService) waitStartup(name string, exit *processExit, tail *outputTail, timeout time.Duration) error {
+	start := time.Now()
+	failure := func(reason string) error {
+		err := &ModelStartupError{
+			ModelName:  name,
+			Reason:     reason,
+			Elapsed:    time.Since(start),
+			LastOutput: tail.Lines(),
+		}
+		if reason == StartupExited {
+			err.ExitStatus = "exited normally"
+			if exit.err != nil {
+				err.ExitStatus = exit.err.Error()
+			}
+		}
+		return err
+	}
+
+	backend, err := s.GetBackend(name)
+	if err != nil {
+		// 进程已退出并被清理
+		<-exit.done
+		return failure(StartupExited)
+	}
+	healthURL := backend.URL.JoinPath("health").String()
+
+	deadline := start.Add(timeout)
+	for {
+		select {
+		case <-exit.done:
+			return failure(StartupExited)
+		default:
+		}
+
+		if probeReady(healthURL) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return failure(StartupTimeout)
+		}
+
+		select {
+		case <-exit.done:
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// probeReady 探测实例的/health是否返回200（加载模型期间返回503）
+func probeReady(healthURL string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), healthProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := backendClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOutputTail(t *testing.T) {
+	tail := newOutputTail(3)
+	tail.Write([]byte("line 1\nline 2\r\nli"))
+	tail.Write([]byte("ne 3\nline 4\n"))
+	tail.Write([]byte("...."))
+
+	// 保留最近的行，包含尚未换行的输出
+	want := []string{"line 3", "line 4", "...."}
+	if got := tail.Lines(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Lines() = %q, want %q", got, want)
+	}
+}
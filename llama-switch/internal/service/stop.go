@@ -0,0 +1,46 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// stopOptions 获取模型进程的停止方式：模型配置中的设置优先于全局配置
+func (s *ModelService) stopOptions(cfg *model.ModelConfig) StopOptions {
+	signal, grace := s.config.Stop.Signal, s.config.Stop.GracePeriod
+	if cfg != nil && cfg.Stop != nil {
+		if cfg.Stop.Signal != "" {
+			signal = cfg.Stop.Signal
+		}
+		if cfg.Stop.GracePeriod > 0 {
+			grace = cfg.Stop.GracePeriod
+		}
+	}
+
+	opts := StopOptions{GracePeriod: time.Duration(grace) * time.Second}
+	sig, err := parseStopSignal(signal)
+	if err != nil {
+		// 配置在加载和验证时已检查，这里只在异常情况下回退到默认信号
+		slog.Warn("Invalid stop signal, using default stop signal", "error", err)
+		sig = defaultStopSignal
+	}
+	opts.Signal = sig
+	return opts
+}
+
+// validateStopConfig 验证模型的停止配置
+func validateStopConfig(stop *model.StopConfig) error {
+	if stop == nil {
+		return nil
+	}
+	if _, err := parseStopSignal(stop.Signal); err != nil {
+		return err
+	}
+	if stop.GracePeriod < 0 {
+		return fmt.Errorf("stop grace period must be non-negative: %d", stop.GracePeriod)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/auth"
+	"llama-switch/internal/model"
+	"llama-switch/internal/units"
+)
+
+// ErrUnknownAPIKey 配置了租户时，切换请求未提供有效的API密钥
+var ErrUnknownAPIKey = apierror.New(apierror.CodeInvalidAPIKey, "invalid or missing API key")
+
+// TenantForKey 根据API密钥确定租户：未配置租户时返回空字符串；
+// 全局API_KEY、API_KEYS中的密钥和有效的JWT不属于任何租户，不受配额限制；其他密钥返回ErrUnknownAPIKey
+func (s *ModelService) TenantForKey(key string) (string, error) {
+	cfg := s.config.Snapshot()
+	if len(cfg.Tenants.APIKeys) == 0 {
+		return "", nil
+	}
+	if key == "" {
+		return "", ErrUnknownAPIKey
+	}
+	if admin := cfg.Security.APIKey; admin != "" && subtle.ConstantTimeCompare([]byte(key), []byte(admin)) == 1 {
+		return "", nil
+	}
+	if _, ok := keyName(cfg, key); ok {
+		return "", nil
+	}
+	for name, tenantKey := range cfg.Tenants.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(tenantKey)) == 1 {
+			return name, nil
+		}
+	}
+	if s.jwt != nil && auth.LooksLikeJWT(key) {
+		if _, err := s.jwt.Validate(key); err != nil {
+			return "", fmt.Errorf("%w: %v", ErrUnknownAPIKey, err)
+		}
+		return "", nil
+	}
+	return "", ErrUnknownAPIKey
+}
+
+// tenantQuota 获取租户的显存配额(MB)，0表示不限制
+func (s *ModelService) tenantQuota(tenant string) int {
+	if tenant == "" {
+		return 0
+	}
+	// 配置已在启动时验证
+	quota, _ := units.ParseSize(s.config.Snapshot().Tenants.VRAMQuotas[tenant], units.MB)
+	return int(quota)
+}
+
+// tenantModels 从models中筛选属于租户的模型，tenant为空（管理员）时返回全部
+func tenantModels(models []*model.ModelStatus, tenant string) []*model.ModelStatus {
+	if tenant == "" {
+		return models
+	}
+	filtered := make([]*model.ModelStatus, 0, len(models))
+	for _, m := range models {
+		if m.Tenant == tenant {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
+// tenantUsage 统计租户运行中模型的显存占用（包含RPC池中的部分）和启动中模型的预留
+func (s *ModelService) tenantUsage(tenant string) (int, []string) {
+	used := s.admission.tenantReserved(tenant)
+	names := make([]string, 0)
+	for _, m := range s.processManager.GetRunningModels() {
+		if m.Tenant == tenant {
+			used += m.VRAMUsage + m.RPCUsageMB
+			names = append(names, m.ModelName)
+		}
+	}
+	return used, names
+}
+
+// checkQuota 检查启动模型后租户的显存占用是否超过配额；force_vram时只驱逐该租户自己的模型
+func (s *ModelService) checkQuota(cfg *model.ModelConfig, required int) error {
+	quota := s.tenantQuota(cfg.Tenant)
+	if quota <= 0 {
+		return nil
+	}
+	used, _ := s.tenantUsage(cfg.Tenant)
+	if used+required <= quota {
+		return nil
+	}
+	if required > quota {
+		return apierror.New(apierror.CodeQuotaExceeded, "model requires %dMB VRAM, exceeding the %dMB quota of tenant %s", required, quota, cfg.Tenant)
+	}
+	if !cfg.ForceVRAM {
+		return apierror.New(apierror.CodeQuotaExceeded, "tenant %s VRAM quota exceeded (quota: %dMB, used: %dMB, required: %dMB). Use force_vram=true to evict the tenant's models",
+			cfg.Tenant, quota, used, required)
+	}
+
+	slog.Info("Tenant VRAM quota exceeded, evicting the tenant's models", "tenant", cfg.Tenant, "quota_mb", quota, "used_mb", used, "required_mb", required)
+	if err := s.freeVRAM(used+required-quota, cfg.Force, cfg.Tenant); err != nil {
+		return apierror.New(apierror.CodeQuotaExceeded, "tenant %s VRAM quota exceeded (quota: %dMB, used: %dMB, required: %dMB): %v",
+			cfg.Tenant, quota, used, required, err)
+	}
+	return nil
+}
+
+// Tenants 获取所有租户的显存配额和使用情况
+func (s *ModelService) Tenants() []model.TenantStatus {
+	keys := s.config.Snapshot().Tenants.APIKeys
+	tenants := make([]model.TenantStatus, 0, len(keys))
+	for _, name := range slices.Sorted(maps.Keys(keys)) {
+		used, models := s.tenantUsage(name)
+		tenants = append(tenants, model.TenantStatus{
+			Name:    name,
+			QuotaMB: s.tenantQuota(name),
+			UsedMB:  used,
+			Models:  models,
+		})
+	}
+	return tenants
+}
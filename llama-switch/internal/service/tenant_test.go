@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestTenantForKey(t *testing.T) {
+	cfg := &config.Config{}
+	s := &ModelService{config: cfg}
+	if tenant, err := s.TenantForKey(""); tenant != "" || err != nil {
+		t.Errorf("TenantForKey without tenants = %q, %v", tenant, err)
+	}
+
+	cfg.Security.APIKey = "admin"
+	cfg.Tenants.APIKeys = map[string]string{"team-a": "key-a", "team-b": "key-b"}
+	tests := []struct {
+		key, want string
+		err       error
+	}{
+		{"key-a", "team-a", nil},
+		{"key-b", "team-b", nil},
+		{"admin", "", nil},
+		{"", "", ErrUnknownAPIKey},
+		{"key-c", "", ErrUnknownAPIKey},
+	}
+	for _, tt := range tests {
+		if tenant, err := s.TenantForKey(tt.key); tenant != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("TenantForKey(%q) = %q, %v, want %q, %v", tt.key, tenant, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestCheckQuota(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Tenants.APIKeys = map[string]string{"team-a": "key-a", "team-b": "key-b"}
+	cfg.Tenants.VRAMQuotas = map[string]string{"team-a": "8000"}
+	s := &ModelService{config: cfg, processManager: NewProcessManager(), admission: newAdmissionController()}
+	s.processManager.AddModel(1, &model.ModelStatus{Running: true, ModelName: "a1", Tenant: "team-a", VRAMUsage: 3000, RPCUsageMB: 1000})
+	s.processManager.AddModel(2, &model.ModelStatus{Running: true, ModelName: "b1", Tenant: "team-b", VRAMUsage: 20000})
+	s.admission.reserve("team-a", []int{2000}, 2000)
+
+	tests := []struct {
+		tenant   string
+		required int
+		ok       bool
+	}{
+		{"team-a", 2000, true},
+		{"team-a", 2001, false},
+		{"team-a", 9000, false},
+		{"team-b", 50000, true}, // 未设置配额
+		{"", 50000, true},       // 管理员
+	}
+	for _, tt := range tests {
+		err := s.checkQuota(&model.ModelConfig{Tenant: tt.tenant}, tt.required)
+		if (err == nil) != tt.ok {
+			t.Errorf("checkQuota(%q, %d) = %v, want ok=%v", tt.tenant, tt.required, err, tt.ok)
+		}
+	}
+
+	models := s.processManager.GetRunningModels()
+	if got := tenantModels(models, "team-b"); len(got) != 1 || got[0].ModelName != "b1" {
+		t.Errorf("tenantModels(team-b) = %+v", got)
+	}
+	if got := tenantModels(models, ""); len(got) != 2 {
+		t.Errorf("tenantModels(admin) returned %d models, want 2", len(got))
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+// 事件类型
+const (
+	EventGPUThrottle        = "gpu_throttle"         // GPU持续处于降频状态
+	EventGPUThrottleCleared = "gpu_throttle_cleared" // GPU恢复正常频率
+)
+
+// defaultThermalInterval 资源采样禁用时基准测试期间采样GPU状态的间隔
+const defaultThermalInterval = 5 * time.Second
+
+// thermalMonitor 跟踪各GPU连续处于降频状态的采样次数，达到阈值时告警一次，恢复后重新计数
+type thermalMonitor struct {
+	tempLimit float64
+	samples   int
+
+	mu      sync.Mutex
+	streak  map[int]int  // 按GPU编号保存的连续降频采样次数
+	alerted map[int]bool // 已告警且尚未恢复的GPU
+}
+
+// newThermalMonitor 创建降频监视器
+func newThermalMonitor(cfg *config.Config) *thermalMonitor {
+	return &thermalMonitor{
+		tempLimit: float64(cfg.Thermal.TempLimitC),
+		samples:   max(cfg.Thermal.ThrottleSamples, 1),
+		streak:    make(map[int]int),
+		alerted:   make(map[int]bool),
+	}
+}
+
+// throttleReasons GPU当前的降频原因，温度达到上限时追加over_temperature
+func (m *thermalMonitor) throttleReasons(gpu model.GPUInfo) []string {
+	reasons := append([]string(nil), gpu.ThrottleReasons...)
+	if m.tempLimit > 0 && gpu.TemperatureC != nil && *gpu.TemperatureC >= m.tempLimit {
+		reasons = append(reasons, "over_temperature")
+	}
+	return reasons
+}
+
+// observe 记录一次采样，返回刚达到持续降频阈值的GPU和刚恢复的GPU
+func (m *thermalMonitor) observe(devices []model.GPUInfo) (throttled, cleared []model.GPUInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, gpu := range devices {
+		if len(m.throttleReasons(gpu)) == 0 {
+			m.streak[gpu.Index] = 0
+			if m.alerted[gpu.Index] {
+				delete(m.alerted, gpu.Index)
+				cleared = append(cleared, gpu)
+			}
+			continue
+		}
+		m.streak[gpu.Index]++
+		if m.streak[gpu.Index] >= m.samples && !m.alerted[gpu.Index] {
+			m.alerted[gpu.Index] = true
+			throttled = append(throttled, gpu)
+		}
+	}
+	return throttled, cleared
+}
+
+// observeThermal 根据GPU清单检查持续降频，记录告警和恢复事件
+func (s *ModelService) observeThermal(devices []model.GPUInfo) {
+	if s.thermal == nil {
+		return
+	}
+	throttled, cleared := s.thermal.observe(devices)
+	for _, gpu := range throttled {
+		s.events.Record(EventGPUThrottle, "", fmt.Sprintf("GPU %d (%s) throttled for %d consecutive samples: %s (models: %s)",
+			gpu.Index, gpu.Name, s.thermal.samples, strings.Join(s.thermal.throttleReasons(gpu), ", "), modelList(gpu.Models)), gpu)
+	}
+	for _, gpu := range cleared {
+		s.events.Record(EventGPUThrottleCleared, "", fmt.Sprintf("GPU %d (%s) is no longer throttled", gpu.Index, gpu.Name), gpu)
+	}
+}
+
+// modelList 拼接模型名称，没有模型时返回none
+func modelList(models []string) string {
+	if len(models) == 0 {
+		return "none"
+	}
+	return strings.Join(models, ", ")
+}
+
+// watchThermal 在基准测试期间按间隔采样GPU状态，返回的函数停止采样并汇总结果（没有成功的采样时为nil）
+func (s *BenchmarkService) watchThermal() func() *model.ThermalState {
+	interval := time.Duration(s.config.Resources.SampleInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultThermalInterval
+	}
+	monitor := newThermalMonitor(s.config)
+	state := &model.ThermalState{}
+	reasons := make(map[string]bool)
+
+	sample := func() {
+		devices, err := s.gpu.Devices()
+		if err != nil {
+			return
+		}
+		state.Samples++
+		throttled := false
+		for _, gpu := range devices {
+			state.MaxTemperatureC = maxFloat(state.MaxTemperatureC, gpu.TemperatureC)
+			state.MaxPowerW = maxFloat(state.MaxPowerW, gpu.PowerW)
+			for _, reason := range monitor.throttleReasons(gpu) {
+				throttled = true
+				reasons[reason] = true
+			}
+		}
+		if throttled {
+			state.ThrottledSamples++
+		}
+		if alerts, _ := monitor.observe(devices); len(alerts) > 0 {
+			state.Throttled = true
+		}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			sample()
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() *model.ThermalState {
+		close(done)
+		<-finished
+		if state.Samples == 0 {
+			return nil
+		}
+		for reason := range reasons {
+			state.ThrottleReasons = append(state.ThrottleReasons, reason)
+		}
+		sort.Strings(state.ThrottleReasons)
+		if state.Throttled {
+			slog.Warn("GPU throttled during benchmark, results may be lower than usual", "throttled_samples", state.ThrottledSamples, "samples", state.Samples, "reasons", strings.Join(state.ThrottleReasons, ", "))
+		}
+		return state
+	}
+}
+
+// maxFloat 返回两个可选值中较大的一个
+func maxFloat(current, value *float64) *float64 {
+	if value == nil || (current != nil && *current >= *value) {
+		return current
+	}
+	v := *value
+	return &v
+}
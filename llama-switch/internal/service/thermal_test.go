@@ -0,0 +1,45 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestThermalMonitor(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Thermal.TempLimitC = 85
+	cfg.Thermal.ThrottleSamples = 2
+	m := newThermalMonitor(cfg)
+
+	temp := func(c float64) *float64 { return &c }
+	cool := model.GPUInfo{Index: 0, TemperatureC: temp(60)}
+	hot := model.GPUInfo{Index: 0, TemperatureC: temp(90)}
+	capped := model.GPUInfo{Index: 1, ThrottleReasons: []string{"sw_power_cap"}}
+
+	if got := m.throttleReasons(hot); !reflect.DeepEqual(got, []string{"over_temperature"}) {
+		t.Errorf("throttleReasons(hot) = %v", got)
+	}
+
+	// 第一次降频不告警，连续两次后告警一次
+	if throttled, _ := m.observe([]model.GPUInfo{hot, capped}); len(throttled) != 0 {
+		t.Errorf("Alerted after one sample: %+v", throttled)
+	}
+	if throttled, _ := m.observe([]model.GPUInfo{hot, capped}); len(throttled) != 2 {
+		t.Errorf("Expected both GPUs to alert, got %+v", throttled)
+	}
+	if throttled, _ := m.observe([]model.GPUInfo{hot, capped}); len(throttled) != 0 {
+		t.Errorf("Alerted twice for the same throttling: %+v", throttled)
+	}
+
+	// 恢复后记录一次，重新开始计数
+	throttled, cleared := m.observe([]model.GPUInfo{cool, capped})
+	if len(throttled) != 0 || len(cleared) != 1 || cleared[0].Index != 0 {
+		t.Errorf("observe(cool) = %+v, %+v, want GPU 0 cleared", throttled, cleared)
+	}
+	if throttled, _ := m.observe([]model.GPUInfo{hot}); len(throttled) != 0 {
+		t.Errorf("Alerted right after recovery: %+v", throttled)
+	}
+}
@@ -0,0 +1,405 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// idleWaitTimeout 切换前等待驻留模型请求处理完成的最长时间
+const idleWaitTimeout = 30 * time.Second
+
+// timeShareGroup 分时共享组：组内模型交替驻留同一GPU
+type timeShareGroup struct {
+	swapMu   sync.RWMutex // 切换时持有写锁，申请并发槽位时持有读锁
+	mu       sync.Mutex   // 保护驻留状态字段
+	name     string
+	configs  map[string]*model.ModelConfig
+	order    []string
+	resident string
+	interval time.Duration
+	swaps    int
+	lastSwap time.Time
+	cancel   context.CancelFunc
+}
+
+// TimeShareManager 分时共享组管理器（实验性）
+type TimeShareManager struct {
+	service *ModelService
+	mu      sync.RWMutex
+	groups  map[string]*timeShareGroup
+	members map[string]*timeShareGroup         // 模型名称到所属组的映射
+	load    func(cfg *model.ModelConfig) error // 加载模型，默认为StartModel
+	unload  func(name string) error            // 卸载模型，默认为StopModel
+}
+
+// newTimeShareManager 创建新的分时共享管理器
+func newTimeShareManager(s *ModelService) *TimeShareManager {
+	return &TimeShareManager{
+		service: s,
+		groups:  make(map[string]*timeShareGroup),
+		members: make(map[string]*timeShareGroup),
+		load: func(cfg *model.ModelConfig) error {
+			_, err := s.StartModel(cfg)
+			return err
+		},
+		unload: func(name string) error {
+			_, err := s.StopModel(name)
+			return err
+		},
+	}
+}
+
+// Register 注册分时共享组，并确保其中一个模型驻留
+func (m *TimeShareManager) Register(cfg *model.TimeShareConfig) (*model.TimeShareStatus, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("group name is required")
+	}
+	if len(cfg.Models) != 2 {
+		return nil, fmt.Errorf("a timeshare group requires exactly two models, got %d", len(cfg.Models))
+	}
+	if cfg.Interval < 0 {
+		return nil, fmt.Errorf("invalid swap interval: %d", cfg.Interval)
+	}
+
+	group := &timeShareGroup{
+		name:     cfg.Name,
+		configs:  make(map[string]*model.ModelConfig),
+		interval: time.Duration(cfg.Interval) * time.Second,
+	}
+
+	for _, mc := range cfg.Models {
+		if mc == nil || mc.ModelName == "" {
+			return nil, fmt.Errorf("model name is required for every group member")
+		}
+		if _, dup := group.configs[mc.ModelName]; dup {
+			return nil, fmt.Errorf("duplicate model in group: %s", mc.ModelName)
+		}
+		if err := m.service.ValidateModelConfig(mc); err != nil {
+			return nil, fmt.Errorf("invalid config for model %s: %v", mc.ModelName, err)
+		}
+
+		// 插槽保存需要llama-server启用--slot-save-path
+		if mc.Config.SlotSavePath == "" {
+			mc.Config.SlotSavePath = filepath.Join(m.service.config.TimeShare.SlotDir, mc.ModelName)
+		}
+		if err := os.MkdirAll(mc.Config.SlotSavePath, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create slot directory: %v", err)
+		}
+
+		group.configs[mc.ModelName] = mc
+		group.order = append(group.order, mc.ModelName)
+	}
+
+	m.mu.Lock()
+	if _, exists := m.groups[cfg.Name]; exists {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("timeshare group '%s' already exists", cfg.Name)
+	}
+	for _, name := range group.order {
+		if other, exists := m.members[name]; exists {
+			m.mu.Unlock()
+			return nil, fmt.Errorf("model '%s' already belongs to group '%s'", name, other.name)
+		}
+	}
+	m.groups[cfg.Name] = group
+	for _, name := range group.order {
+		m.members[name] = group
+	}
+	m.mu.Unlock()
+
+	// 确定当前驻留模型
+	var running []string
+	for _, name := range group.order {
+		if _, err := m.service.GetBackend(name); err == nil {
+			running = append(running, name)
+		}
+	}
+
+	var err error
+	switch len(running) {
+	case 0:
+		err = m.swapTo(context.Background(), group, group.order[0])
+	case 1:
+		group.setResident(running[0])
+	default:
+		err = fmt.Errorf("both models of group '%s' are running, stop one first", cfg.Name)
+	}
+	if err != nil {
+		m.Remove(cfg.Name)
+		return nil, err
+	}
+
+	if group.interval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		group.cancel = cancel
+		go m.runSchedule(ctx, group)
+	}
+
+	slog.Info("Registered timeshare group", "group", group.name, "models", group.order, "interval", group.interval)
+	return group.status(), nil
+}
+
+// Remove 移除分时共享组（保留当前驻留模型运行）
+func (m *TimeShareManager) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	group, exists := m.groups[name]
+	if !exists {
+		return fmt.Errorf("timeshare group '%s' not found", name)
+	}
+	if group.cancel != nil {
+		group.cancel()
+	}
+	for _, member := range group.order {
+		delete(m.members, member)
+	}
+	delete(m.groups, name)
+	return nil
+}
+
+// Status 获取所有分时共享组状态
+func (m *TimeShareManager) Status() []*model.TimeShareStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	statuses := make([]*model.TimeShareStatus, 0, len(m.groups))
+	for _, group := range m.groups {
+		statuses = append(statuses, group.status())
+	}
+	return statuses
+}
+
+// Swap 手动切换共享组的驻留模型，target为空时切换到另一个模型
+func (m *TimeShareManager) Swap(ctx context.Context, name, target string) (*model.TimeShareStatus, error) {
+	m.mu.RLock()
+	group, exists := m.groups[name]
+	m.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("timeshare group '%s' not found", name)
+	}
+
+	if target == "" {
+		target = group.next()
+	} else if _, member := group.configs[target]; !member {
+		return nil, fmt.Errorf("model '%s' is not a member of group '%s'", target, name)
+	}
+
+	if err := m.swapTo(ctx, group, target); err != nil {
+		return nil, err
+	}
+	return group.status(), nil
+}
+
+// EnsureResident 确保模型已驻留，如属于共享组且未驻留则按需切换
+func (m *TimeShareManager) EnsureResident(ctx context.Context, modelName string) error {
+	m.mu.RLock()
+	group, exists := m.members[modelName]
+	m.mu.RUnlock()
+	if !exists {
+		return nil
+	}
+
+	// 已驻留时只持有读锁，不与其他请求的申请互斥
+	group.swapMu.RLock()
+	resident := m.isResident(group, modelName)
+	group.swapMu.RUnlock()
+	if resident {
+		return nil
+	}
+	return m.swapTo(ctx, group, modelName)
+}
+
+// Acquire 申请模型的并发槽位。模型属于共享组时在切换锁的读锁下确认模型仍然驻留后申请，
+// 未驻留时先切换；切换在卸载前等待已申请的请求完成，因此申请成功后请求不会转发到已卸载的实例
+func (m *TimeShareManager) Acquire(ctx context.Context, modelName string) (func(), error) {
+	m.mu.RLock()
+	group, exists := m.members[modelName]
+	m.mu.RUnlock()
+	if !exists {
+		return m.service.tracker.Acquire(ctx, modelName)
+	}
+
+	for {
+		group.swapMu.RLock()
+		if m.isResident(group, modelName) {
+			release, err := m.service.tracker.Acquire(ctx, modelName)
+			group.swapMu.RUnlock()
+			return release, err
+		}
+		group.swapMu.RUnlock()
+
+		if err := m.swapTo(ctx, group, modelName); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// runSchedule 按固定间隔交替切换驻留模型
+func (m *TimeShareManager) runSchedule(ctx context.Context, group *timeShareGroup) {
+	ticker := time.NewTicker(group.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.swapTo(ctx, group, group.next()); err != nil {
+				slog.Warn("Scheduled timeshare swap failed", "group", group.name, "error", err)
+			}
+		}
+	}
+}
+
+// swapTo 将共享组的驻留模型切换为目标模型：保存插槽、卸载、加载、恢复插槽
+func (m *TimeShareManager) swapTo(ctx context.Context, group *timeShareGroup, target string) error {
+	group.swapMu.Lock()
+	defer group.swapMu.Unlock()
+
+	previous := group.currentResident()
+	if m.isResident(group, target) {
+		return nil
+	}
+
+	timeout := time.Duration(m.service.config.TimeShare.SwapTimeout) * time.Second
+	slog.Info("Timeshare swap", "group", group.name, "from", previous, "to", target)
+
+	if previous != "" {
+		if backend, err := m.service.GetBackend(previous); err == nil {
+			m.waitIdle(previous)
+			m.saveSlots(backend, group.configs[previous])
+			if err := m.unload(previous); err != nil {
+				return fmt.Errorf("failed to unload model '%s': %v", previous, err)
+			}
+		}
+		// 已卸载，加载目标失败时组内没有驻留模型，下次请求或切换重新加载
+		group.setResident("")
+	}
+
+	if err := m.load(group.configs[target]); err != nil {
+		return fmt.Errorf("failed to load model '%s': %v", target, err)
+	}
+	group.setResident(target)
+
+	if err := m.service.WaitForReady(ctx, target, timeout); err != nil {
+		return fmt.Errorf("model '%s' did not become ready: %v", target, err)
+	}
+	if backend, err := m.service.GetBackend(target); err == nil {
+		m.restoreSlots(backend, group.configs[target])
+	}
+
+	group.mu.Lock()
+	group.swaps++
+	group.lastSwap = time.Now()
+	group.mu.Unlock()
+	slog.Info("Timeshare model is now resident", "group", group.name, "model_name", target)
+	return nil
+}
+
+// isResident 模型是否为共享组的驻留模型且正在运行（调用方需持有切换锁）
+func (m *TimeShareManager) isResident(group *timeShareGroup, name string) bool {
+	if group.currentResident() != name {
+		return false
+	}
+	_, err := m.service.GetBackend(name)
+	return err == nil
+}
+
+// waitIdle 等待模型当前的推理请求处理完成
+func (m *TimeShareManager) waitIdle(name string) {
+	deadline := time.Now().Add(idleWaitTimeout)
+	for m.service.tracker.Stats(name).Active > 0 {
+		if time.Now().After(deadline) {
+			slog.Warn("Model still has active requests, unloading anyway", "model_name", name)
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// saveSlots 保存模型所有插槽的KV缓存
+func (m *TimeShareManager) saveSlots(backend *Backend, cfg *model.ModelConfig) {
+	for id := 0; id < slotCount(cfg); id++ {
+		if err := backend.slotAction(id, "save", slotFileName(cfg, id)); err != nil {
+			slog.Warn("Failed to save model slot", "model_name", cfg.ModelName, "error", err)
+		}
+	}
+}
+
+// restoreSlots 恢复模型之前保存的插槽KV缓存
+func (m *TimeShareManager) restoreSlots(backend *Backend, cfg *model.ModelConfig) {
+	for id := 0; id < slotCount(cfg); id++ {
+		filename := slotFileName(cfg, id)
+		if _, err := os.Stat(filepath.Join(cfg.Config.SlotSavePath, filename)); err != nil {
+			continue
+		}
+		if err := backend.slotAction(id, "restore", filename); err != nil {
+			slog.Warn("Failed to restore model slot", "model_name", cfg.ModelName, "error", err)
+		}
+	}
+}
+
+// slotCount 获取模型配置的插槽数量
+func slotCount(cfg *model.ModelConfig) int {
+	if cfg.Config.Parallel > 0 {
+		return cfg.Config.Parallel
+	}
+	return 1
+}
+
+// slotFileName 插槽缓存文件名
+func slotFileName(cfg *model.ModelConfig, id int) string {
+	return fmt.Sprintf("%s-slot%d.bin", cfg.ModelName, id)
+}
+
+// next 获取组内下一个应驻留的模型
+func (g *timeShareGroup) next() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, name := range g.order {
+		if name != g.resident {
+			return name
+		}
+	}
+	return g.order[0]
+}
+
+// currentResident 获取当前驻留的模型
+func (g *timeShareGroup) currentResident() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.resident
+}
+
+// setResident 设置当前驻留的模型
+func (g *timeShareGroup) setResident(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.resident = name
+}
+
+// status 获取共享组状态
+func (g *timeShareGroup) status() *model.TimeShareStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	status := &model.TimeShareStatus{
+		Name:     g.name,
+		Models:   append([]string(nil), g.order...),
+		Resident: g.resident,
+		Interval: int(g.interval / time.Second),
+		Swaps:    g.swaps,
+	}
+	if !g.lastSwap.IsZero() {
+		status.LastSwap = g.lastSwap.Format(time.RFC3339)
+	}
+	return status
+}
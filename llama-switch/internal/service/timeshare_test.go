@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+// fakeSlotBackend 模拟llama-server：/health直接就绪，保存插槽时像llama-server一样写出缓存文件
+type fakeSlotBackend struct {
+	server *httptest.Server
+	port   int
+}
+
+func newFakeSlotBackend(t *testing.T, cfg *model.ModelConfig, record func(string)) *fakeSlotBackend {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok"}`))
+	})
+	mux.HandleFunc("/slots/", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Filename string `json:"filename"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		id := strings.TrimPrefix(r.URL.Path, "/slots/")
+		action := r.URL.Query().Get("action")
+		if action == "save" {
+			os.WriteFile(filepath.Join(cfg.Config.SlotSavePath, req.Filename), []byte("kv"), 0644)
+		}
+		record(fmt.Sprintf("%s %s slot %s", action, cfg.ModelName, id))
+		w.Write([]byte(`{}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	_, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	return &fakeSlotBackend{server: server, port: port}
+}
+
+func TestTimeShareSwapTo(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.TimeShare.SwapTimeout = 5
+	s := &ModelService{
+		config:         cfg,
+		processManager: NewProcessManager(),
+		tracker:        NewRequestTracker(0, time.Second),
+		configs:        make(map[string]*model.ModelConfig),
+	}
+	m := newTimeShareManager(s)
+
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	takeEvents := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		taken := events
+		events = nil
+		return taken
+	}
+
+	group := &timeShareGroup{name: "pair", configs: make(map[string]*model.ModelConfig)}
+	backends := make(map[string]*fakeSlotBackend)
+	pids := map[string]int{"chat": 1001, "code": 1002}
+	for _, name := range []string{"chat", "code"} {
+		mc := &model.ModelConfig{ModelName: name}
+		mc.Config.SlotSavePath = t.TempDir()
+		if name == "chat" {
+			mc.Config.Parallel = 2
+		}
+		group.configs[name] = mc
+		group.order = append(group.order, name)
+		backends[name] = newFakeSlotBackend(t, mc, record)
+	}
+
+	failLoad := false
+	m.load = func(mc *model.ModelConfig) error {
+		if failLoad {
+			return fmt.Errorf("out of memory")
+		}
+		record("load " + mc.ModelName)
+		s.processManager.AddModel(pids[mc.ModelName], &model.ModelStatus{
+			ModelName: mc.ModelName, Running: true, Host: "127.0.0.1", Port: backends[mc.ModelName].port,
+		})
+		s.setRunningConfig(mc.ModelName, mc)
+		return nil
+	}
+	m.unload = func(name string) error {
+		record("unload " + name)
+		s.processManager.RemoveModel(pids[name])
+		return nil
+	}
+
+	steps := []struct {
+		target string
+		want   []string
+	}{
+		// 首次加载时没有保存过的插槽，不恢复
+		{"chat", []string{"load chat"}},
+		// 切换前保存驻留模型的每个插槽，然后卸载
+		{"code", []string{"save chat slot 0", "save chat slot 1", "unload chat", "load code"}},
+		// 切回时恢复之前保存的插槽
+		{"chat", []string{"save code slot 0", "unload code", "load chat", "restore chat slot 0", "restore chat slot 1"}},
+		// 目标模型已驻留且在运行时不做任何操作
+		{"chat", nil},
+	}
+	for i, step := range steps {
+		if err := m.swapTo(context.Background(), group, step.target); err != nil {
+			t.Fatalf("step %d: swapTo(%s) failed: %v", i, step.target, err)
+		}
+		if got := takeEvents(); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("step %d: swapTo(%s) events = %q, want %q", i, step.target, got, step.want)
+		}
+		if resident := group.currentResident(); resident != step.target {
+			t.Errorf("step %d: resident = %s, want %s", i, resident, step.target)
+		}
+	}
+	if status := group.status(); status.Swaps != 3 || status.LastSwap == "" {
+		t.Errorf("status = %+v, want 3 swaps with last swap time", status)
+	}
+
+	// 加载失败时返回错误，不计入切换次数
+	failLoad = true
+	err := m.swapTo(context.Background(), group, "code")
+	if err == nil || !strings.Contains(err.Error(), "failed to load model 'code'") {
+		t.Errorf("swapTo with failing load = %v, want load error", err)
+	}
+	// 驻留模型已卸载，组内不再记录驻留模型
+	if status := group.status(); status.Swaps != 3 || status.Resident != "" {
+		t.Errorf("status = %+v after failed swap, want 3 swaps and no resident", status)
+	}
+	takeEvents()
+	failLoad = false
+
+	// 申请槽位时未驻留的成员先切换为驻留
+	m.groups["pair"] = group
+	m.members["chat"], m.members["code"] = group, group
+	release, err := m.Acquire(context.Background(), "chat")
+	if err != nil {
+		t.Fatalf("Acquire(chat) failed: %v", err)
+	}
+	if got, want := takeEvents(), []string{"load chat", "restore chat slot 0", "restore chat slot 1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Acquire(chat) events = %q, want %q", got, want)
+	}
+
+	// 申请成功的请求结束前切换不卸载模型
+	swapped := make(chan error, 1)
+	go func() { swapped <- m.swapTo(context.Background(), group, "code") }()
+	time.Sleep(300 * time.Millisecond)
+	if got := takeEvents(); len(got) != 0 {
+		t.Errorf("swap ran while a request was in flight: %q", got)
+	}
+	release()
+	if err := <-swapped; err != nil {
+		t.Fatalf("swapTo(code) failed: %v", err)
+	}
+	if got, want := takeEvents(), []string{"save chat slot 0", "save chat slot 1", "unload chat", "load code", "restore code slot 0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("swapTo(code) events = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"log/slog"
+	"reflect"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// refreshVRAMUsage 用GPU工具按进程报告的实际占用更新运行中模型的显存使用量，
+// 未出现在列表中的进程（未使用GPU或无权限查询）保留原值
+func (s *ModelService) refreshVRAMUsage(usage map[int]int) {
+	for pid, status := range s.processManager.trackedModels() {
+		if mb := usage[pid]; mb > 0 {
+			if status.VRAMUsage != mb {
+				slog.Info("Measured model VRAM usage", "model_name", status.ModelName, "pid", pid, "vram_mb", mb, "previous_mb", status.VRAMUsage)
+			}
+			s.processManager.setVRAMUsage(pid, mb)
+		}
+	}
+}
+
+// measureVRAMAfterLoad 在实例就绪后读取实际显存占用，并保存到持久化状态供下次启动时估算
+func (s *ModelService) measureVRAMAfterLoad(cfg *model.ModelConfig, pid int) {
+	usage, err := s.gpu.ProcessMemory()
+	if err != nil {
+		return
+	}
+	mb := usage[pid]
+	if mb <= 0 {
+		return
+	}
+	s.refreshVRAMUsage(map[int]int{pid: mb})
+
+	status := s.processManager.FindModel(cfg.ModelName)
+	if status == nil || status.ProcessID != pid {
+		return
+	}
+	if err := s.persistentMgr.UpdateModelConfig(cfg.ModelName, cfg, status); err != nil {
+		slog.Warn("Failed to save measured VRAM usage", "error", err)
+	}
+}
+
+// measureVRAMWhenReady 不等待就绪（MODEL_STARTUP_TIMEOUT为0）启动时在后台等待实例就绪后测量显存，
+// 实例在就绪前退出或被停止、替换时放弃
+func (s *ModelService) measureVRAMWhenReady(cfg *model.ModelConfig, pid int, exit *processExit) {
+	for {
+		status := s.processManager.FindModel(cfg.ModelName)
+		if status == nil || status.ProcessID != pid || !status.Running {
+			return
+		}
+		backend, err := s.GetBackend(cfg.ModelName)
+		if err != nil {
+			return
+		}
+		if probeReady(backend.URL.JoinPath("health").String()) {
+			slog.Info("Model is ready", "model_name", cfg.ModelName, "pid", pid)
+			s.measureVRAMAfterLoad(cfg, pid)
+			return
+		}
+
+		select {
+		case <-exit.done:
+			return
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// estimateVRAM 估算启动模型所需的显存：
+// 同一模型上次以相同参数运行时测得过实际占用则使用该值，其次根据GGUF元数据计算，
+// 两者都没有时才使用按层数的粗略估算（来源标记为heuristic并记录警告）
+func (s *ModelService) estimateVRAM(cfg *model.ModelConfig, modelPath string, modelSizeMB int) *model.VRAMEstimate {
+	if measured := s.previousVRAM(cfg); measured > 0 {
+		return &model.VRAMEstimate{Source: VRAMSourceMeasured, TotalMB: measured}
+	}
+	estimate, err := estimateGGUF(modelPath, cfg)
+	if err == nil {
+		return estimate
+	}
+	slog.Warn("Cannot read GGUF metadata, using heuristic VRAM estimate", "model_name", cfg.ModelName, "model_path", modelPath, "error", err)
+	return &model.VRAMEstimate{
+		Source:  VRAMSourceHeuristic,
+		TotalMB: heuristicVRAM(cfg, modelSizeMB),
+		Error:   err.Error(),
+	}
+}
+
+// heuristicVRAM 无法读取GGUF元数据时的最后手段：500MB基础占用加每个GPU层200MB，不超过模型文件大小
+func heuristicVRAM(cfg *model.ModelConfig, modelSizeMB int) int {
+	return min(500+int(cfg.Config.NGPULayers)*200, modelSizeMB)
+}
+
+// previousVRAM 获取持久化状态中相同模型文件和参数的上一次实际显存占用，没有时返回0
+func (s *ModelService) previousVRAM(cfg *model.ModelConfig) int {
+	configs, err := s.persistentMgr.GetModelConfigs()
+	if err != nil {
+		return 0
+	}
+	item, exists := configs[cfg.ModelName]
+	if !exists || item.ModelConfig == nil || !item.LastStatus.VRAMMeasured {
+		return 0
+	}
+	if item.ModelConfig.ModelPath != cfg.ModelPath || !sameVRAMParams(item.ModelConfig, cfg) {
+		return 0
+	}
+	return item.LastStatus.VRAMUsage
+}
+
+// sameVRAMParams 比较两个配置中除监听地址外的llama-server参数是否相同
+func sameVRAMParams(a, b *model.ModelConfig) bool {
+	ca, cb := a.Config, b.Config
+	ca.Host, cb.Host = "", ""
+	ca.Port, cb.Port = 0, 0
+	return reflect.DeepEqual(ca, cb)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"fmt"
+
+	"llama-switch/internal/gguf"
+	"llama-switch/internal/model"
+)
+
+// 显存估算来源
+const (
+	VRAMSourceMeasured  = "measured"  // 上次以相同参数运行时的实际占用
+	VRAMSourceGGUF      = "gguf"      // 根据GGUF元数据计算
+	VRAMSourceHeuristic = "heuristic" // 按层数粗略估算
+)
+
+// 估算参数
+const (
+	defaultEstimateCtx     = 4096 // 模型未记录训练上下文长度时使用的上下文大小
+	defaultEstimateUBatch  = 512  // llama-server默认的物理批大小
+	gpuRuntimeOverheadMB   = 256  // GPU运行时（如CUDA上下文）自身占用的显存
+	activationBytesPerEmbd = 16   // 每个token每个嵌入维度的中间结果字节数（f32，约4个同时存活的缓冲区）
+)
+
+// memoryLayout 根据GGUF元数据计算的模型内存分布（字节），分为卸载到GPU的部分和留在主机内存中的部分
+type memoryLayout struct {
+	layers, offloaded, ctx uint64
+
+	gpuWeights, gpuKV, gpuCompute    uint64
+	hostWeights, hostKV, hostCompute uint64
+}
+
+// ggufLayout 读取GGUF元数据，按n_gpu_layers计算权重、KV缓存和计算缓冲区在GPU和主机内存中的分布
+func ggufLayout(path string, cfg *model.ModelConfig) (*memoryLayout, error) {
+	f, err := gguf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	arch := f.String("general.architecture")
+	if arch == "" {
+		return nil, fmt.Errorf("GGUF metadata missing general.architecture")
+	}
+	key := func(name string) string { return arch + "." + name }
+
+	nLayers, ok := f.Uint(key("block_count"))
+	if !ok || nLayers == 0 {
+		return nil, fmt.Errorf("GGUF metadata missing %s", key("block_count"))
+	}
+	nEmbd, _ := f.Uint(key("embedding_length"))
+	nHead, _ := f.Uint(key("attention.head_count"))
+	c := cfg.Config
+	l := &memoryLayout{layers: nLayers}
+
+	// 卸载的层：llama.cpp将最后n_gpu_layers层放到GPU上，超过层数时输出层也放到GPU上
+	if c.NGPULayers > 0 {
+		l.offloaded = min(uint64(c.NGPULayers), nLayers)
+	}
+	firstGPULayer := int(nLayers - l.offloaded)
+	outputOffloaded := int(c.NGPULayers) > int(nLayers)
+
+	hasOutput := false
+	var tokenEmbd uint64
+	for _, t := range f.Tensors {
+		layer := t.Layer()
+		switch {
+		case layer >= firstGPULayer:
+			l.gpuWeights += t.Bytes()
+		case layer >= 0:
+			l.hostWeights += t.Bytes()
+		case t.Name == "output.weight" || t.Name == "output_norm.weight":
+			if t.Name == "output.weight" {
+				hasOutput = true
+			}
+			if outputOffloaded {
+				l.gpuWeights += t.Bytes()
+			} else {
+				l.hostWeights += t.Bytes()
+			}
+		default:
+			// 词嵌入等其余张量始终在主机内存中
+			if t.Name == "token_embd.weight" {
+				tokenEmbd = t.Bytes()
+			}
+			l.hostWeights += t.Bytes()
+		}
+	}
+	// 输出层与词嵌入共享权重时，llama.cpp在GPU上复制一份词嵌入
+	if outputOffloaded && !hasOutput {
+		l.gpuWeights += tokenEmbd
+	}
+
+	// KV缓存：每层 ctx * n_head_kv * (key_length + value_length)，按缓存类型计算字节数
+	l.ctx = uint64(c.CtxSize)
+	if l.ctx == 0 {
+		if trained, ok := f.Uint(key("context_length")); ok && trained > 0 {
+			l.ctx = trained
+		} else {
+			l.ctx = defaultEstimateCtx
+		}
+	}
+	headK, headV := uint64(0), uint64(0)
+	if nHead > 0 {
+		headK, headV = nEmbd/nHead, nEmbd/nHead
+	}
+	if n, ok := f.Uint(key("attention.key_length")); ok {
+		headK = n
+	}
+	if n, ok := f.Uint(key("attention.value_length")); ok {
+		headV = n
+	}
+	kvHeads := func(layer int) uint64 {
+		if perLayer, ok := f.UintArray(key("attention.head_count_kv")); ok && layer < len(perLayer) {
+			return perLayer[layer]
+		}
+		if n, ok := f.Uint(key("attention.head_count_kv")); ok {
+			return n
+		}
+		return nHead
+	}
+	for layer := 0; layer < int(nLayers); layer++ {
+		heads := kvHeads(layer)
+		k, okK := gguf.CacheTypeBytes(c.CacheTypeK, l.ctx*heads*headK)
+		v, okV := gguf.CacheTypeBytes(c.CacheTypeV, l.ctx*heads*headV)
+		if !okK || !okV {
+			return nil, fmt.Errorf("unsupported cache type: %s/%s", c.CacheTypeK, c.CacheTypeV)
+		}
+		// 禁用KV卸载时所有层的KV缓存都在主机内存中
+		if layer >= firstGPULayer && !c.NoKVOffload {
+			l.gpuKV += k + v
+		} else {
+			l.hostKV += k + v
+		}
+	}
+
+	// 计算缓冲区：每个微批的中间结果、未启用Flash Attention时的注意力矩阵，以及输出层所在一侧的logits
+	ubatch := uint64(defaultEstimateUBatch)
+	if c.UBatchSize > 0 {
+		ubatch = uint64(c.UBatchSize)
+	}
+	if c.BatchSize > 0 {
+		ubatch = min(ubatch, uint64(c.BatchSize))
+	}
+	nVocab, ok := f.ArrayLen("tokenizer.ggml.tokens")
+	if !ok {
+		nVocab, _ = f.Uint(key("vocab_size"))
+	}
+	activations := ubatch * nEmbd * activationBytesPerEmbd
+	if !c.FlashAttn {
+		activations += ubatch * l.ctx * nHead * 4
+	}
+	logits := ubatch * nVocab * 4
+	if l.offloaded > 0 {
+		l.gpuCompute = activations
+		if outputOffloaded {
+			l.gpuCompute += logits
+		}
+	}
+	if l.offloaded < nLayers {
+		l.hostCompute = activations
+	}
+	if !outputOffloaded {
+		l.hostCompute += logits
+	}
+	return l, nil
+}
+
+// estimateGGUF 根据GGUF元数据估算显存：卸载到GPU的层的量化权重、这些层的KV缓存以及计算缓冲区
+func estimateGGUF(path string, cfg *model.ModelConfig) (*model.VRAMEstimate, error) {
+	l, err := ggufLayout(path, cfg)
+	if err != nil {
+		return nil, err
+	}
+	estimate := &model.VRAMEstimate{
+		Source:          VRAMSourceGGUF,
+		WeightsMB:       toMB(l.gpuWeights),
+		KVCacheMB:       toMB(l.gpuKV),
+		ComputeMB:       toMB(l.gpuCompute),
+		Layers:          int(l.layers),
+		OffloadedLayers: int(l.offloaded),
+		ContextSize:     int(l.ctx),
+	}
+	if l.offloaded > 0 {
+		estimate.OverheadMB = gpuRuntimeOverheadMB
+	}
+	estimate.TotalMB = estimate.WeightsMB + estimate.KVCacheMB + estimate.ComputeMB + estimate.OverheadMB
+	return estimate, nil
+}
+
+// toMB 将字节数向上取整为MB
+func toMB(bytes uint64) int {
+	return int((bytes + 1024*1024 - 1) / (1024 * 1024))
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+// VRAMHistory 持久化的模型显存使用历史：每个模型一个JSON Lines文件，按间隔追加样本
+type VRAMHistory struct {
+	dir        string
+	interval   time.Duration
+	maxSamples int
+
+	mu     sync.Mutex
+	last   map[string]time.Time // 按模型名称保存的最近一次记录时间
+	counts map[string]int       // 按模型名称保存的文件中的样本数（首次写入时统计）
+}
+
+// NewVRAMHistory 创建显存使用历史，interval为0时不记录
+func NewVRAMHistory(dir string, interval, maxSamples int) *VRAMHistory {
+	return &VRAMHistory{
+		dir:        dir,
+		interval:   time.Duration(interval) * time.Second,
+		maxSamples: max(maxSamples, 1),
+		last:       make(map[string]time.Time),
+		counts:     make(map[string]int),
+	}
+}
+
+// defaultVRAMHistoryDir 默认显存历史目录：程序目录下的vram_history目录
+func defaultVRAMHistoryDir() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "vram_history"
+	}
+	return filepath.Join(filepath.Dir(exePath), "vram_history")
+}
+
+// path 模型的历史文件路径
+func (h *VRAMHistory) path(name string) string {
+	return filepath.Join(h.dir, unsafeFileChars.ReplaceAllString(name, "_")+".jsonl")
+}
+
+// Record 记录一条样本，距离该模型上一次记录不足记录间隔时忽略
+func (h *VRAMHistory) Record(name string, sample model.VRAMSample, now time.Time) {
+	if h.interval <= 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if last, exists := h.last[name]; exists && now.Sub(last) < h.interval {
+		return
+	}
+	h.last[name] = now
+
+	if err := h.append(name, sample); err != nil {
+		slog.Warn("Failed to record VRAM history", "model_name", name, "error", err)
+	}
+}
+
+// append 追加样本，样本数超过上限约10%时重写文件只保留最近的样本
+func (h *VRAMHistory) append(name string, sample model.VRAMSample) error {
+	count, counted := h.counts[name]
+	if !counted {
+		samples, err := h.read(name)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		count = len(samples)
+	}
+
+	data, err := json.Marshal(sample)
+	if err != nil {
+		return fmt.Errorf("failed to encode VRAM sample: %v", err)
+	}
+	if err := os.MkdirAll(h.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create VRAM history directory: %v", err)
+	}
+	file, err := os.OpenFile(h.path(name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open VRAM history: %v", err)
+	}
+	_, err = file.Write(append(data, '\n'))
+	file.Close()
+	if err != nil {
+		return err
+	}
+	count++
+	h.counts[name] = count
+
+	if count > h.maxSamples+h.maxSamples/10 {
+		return h.compact(name)
+	}
+	return nil
+}
+
+// compact 重写历史文件，只保留最近的maxSamples条样本
+func (h *VRAMHistory) compact(name string) error {
+	samples, err := h.read(name)
+	if err != nil {
+		return err
+	}
+	if len(samples) > h.maxSamples {
+		samples = samples[len(samples)-h.maxSamples:]
+	}
+
+	tmp := h.path(name) + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to compact VRAM history: %v", err)
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, sample := range samples {
+		if err := encoder.Encode(sample); err != nil {
+			file.Close()
+			os.Remove(tmp)
+			return fmt.Errorf("failed to compact VRAM history: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to compact VRAM history: %v", err)
+	}
+	file.Close()
+	if err := os.Rename(tmp, h.path(name)); err != nil {
+		return fmt.Errorf("failed to compact VRAM history: %v", err)
+	}
+	h.counts[name] = len(samples)
+	return nil
+}
+
+// read 读取模型的全部样本，跳过无法解析的行
+func (h *VRAMHistory) read(name string) ([]model.VRAMSample, error) {
+	file, err := os.Open(h.path(name))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var samples []model.VRAMSample
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var sample model.VRAMSample
+		if json.Unmarshal(scanner.Bytes(), &sample) != nil {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	return samples, scanner.Err()
+}
+
+// Samples 获取模型的样本（旧的在前），since不为零时只返回此后的样本，limit大于0时只返回最后limit条
+func (h *VRAMHistory) Samples(name string, since time.Time, limit int) ([]model.VRAMSample, error) {
+	h.mu.Lock()
+	samples, err := h.read(name)
+	h.mu.Unlock()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read VRAM history: %v", err)
+	}
+
+	if !since.IsZero() {
+		filtered := samples[:0]
+		for _, sample := range samples {
+			if t, err := time.Parse(time.RFC3339, sample.Time); err == nil && !t.Before(since) {
+				filtered = append(filtered, sample)
+			}
+		}
+		samples = filtered
+	}
+	if limit > 0 && len(samples) > limit {
+		samples = samples[len(samples)-limit:]
+	}
+	return samples, nil
+}
+
+// VRAMTrend 计算最后一次运行（与最后一条样本进程ID相同的连续样本）的显存使用趋势，没有样本时返回nil
+func VRAMTrend(samples []model.VRAMSample) *model.VRAMTrend {
+	if len(samples) == 0 {
+		return nil
+	}
+	start := len(samples) - 1
+	for start > 0 && samples[start-1].ProcessID == samples[len(samples)-1].ProcessID {
+		start--
+	}
+	run := samples[start:]
+
+	trend := &model.VRAMTrend{
+		Since:    run[0].Time,
+		Samples:  len(run),
+		MinMB:    run[0].VRAMMB,
+		MaxMB:    run[0].VRAMMB,
+		LatestMB: run[len(run)-1].VRAMMB,
+	}
+	total := 0
+	var base time.Time
+	var xs, ys []float64
+	for _, sample := range run {
+		trend.MinMB = min(trend.MinMB, sample.VRAMMB)
+		trend.MaxMB = max(trend.MaxMB, sample.VRAMMB)
+		total += sample.VRAMMB
+		if t, err := time.Parse(time.RFC3339, sample.Time); err == nil {
+			if base.IsZero() {
+				base = t
+			}
+			xs = append(xs, t.Sub(base).Hours())
+			ys = append(ys, float64(sample.VRAMMB))
+		}
+	}
+	trend.AvgMB = total / len(run)
+	trend.GrowthMBPerHour = slope(xs, ys)
+	return trend
+}
+
+// slope 最小二乘法计算的斜率，样本不足或横坐标相同时为0，保留一位小数
+func slope(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	if n < 2 {
+		return 0
+	}
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var cov, variance float64
+	for i := range xs {
+		cov += (xs[i] - meanX) * (ys[i] - meanY)
+		variance += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if variance == 0 {
+		return 0
+	}
+	return math.Round(cov/variance*10) / 10
+}
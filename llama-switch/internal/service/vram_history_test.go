@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"llama-switch/internal/model"
+)
+
+func TestVRAMHistory(t *testing.T) {
+	h := NewVRAMHistory(t.TempDir(), 60, 10)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// 记录间隔内的样本被忽略
+	for i := 0; i < 30; i++ {
+		now := start.Add(time.Duration(i*30) * time.Second)
+		h.Record("chat", model.VRAMSample{Time: now.Format(time.RFC3339), ProcessID: 1, VRAMMB: 1000 + i}, now)
+	}
+	samples, err := h.Samples("chat", time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("Samples failed: %v", err)
+	}
+	// 共15条，每超过上限10%（12条）时重写为最近10条，最后又追加了1条
+	if len(samples) != 11 || samples[len(samples)-1].VRAMMB != 1028 {
+		t.Errorf("Unexpected samples after compaction: %+v", samples)
+	}
+
+	since := start.Add(12 * time.Minute)
+	if samples, _ := h.Samples("chat", since, 0); len(samples) != 3 {
+		t.Errorf("Samples since %v = %d, want 3", since, len(samples))
+	}
+	if samples, _ := h.Samples("chat", time.Time{}, 2); len(samples) != 2 || samples[1].VRAMMB != 1028 {
+		t.Errorf("Samples with limit = %+v", samples)
+	}
+	if samples, err := h.Samples("missing", time.Time{}, 0); err != nil || samples != nil {
+		t.Errorf("Samples of unknown model = %v, %v", samples, err)
+	}
+}
+
+func TestVRAMTrend(t *testing.T) {
+	sample := func(pid int, minute int, mb int) model.VRAMSample {
+		return model.VRAMSample{
+			Time:      time.Date(2024, 1, 1, 0, minute, 0, 0, time.UTC).Format(time.RFC3339),
+			ProcessID: pid,
+			VRAMMB:    mb,
+		}
+	}
+	// 只统计最后一次运行：30分钟内从6000MB增长到6300MB
+	trend := VRAMTrend([]model.VRAMSample{
+		sample(1, 0, 9000),
+		sample(2, 10, 6000),
+		sample(2, 25, 6150),
+		sample(2, 40, 6300),
+	})
+	want := model.VRAMTrend{
+		Since: sample(2, 10, 0).Time, Samples: 3, MinMB: 6000, MaxMB: 6300, AvgMB: 6150, LatestMB: 6300, GrowthMBPerHour: 600,
+	}
+	if trend == nil || *trend != want {
+		t.Errorf("VRAMTrend = %+v, want %+v", trend, want)
+	}
+	if VRAMTrend(nil) != nil {
+		t.Error("Expected nil trend without samples")
+	}
+}
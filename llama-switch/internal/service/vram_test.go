@@ -0,0 +1,335 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestRefreshVRAMUsage(t *testing.T) {
+	s := &ModelService{config: &config.Config{}, processManager: NewProcessManager()}
+	pid := os.Getpid()
+	s.processManager.AddModel(pid, &model.ModelStatus{ModelName: "chat", ProcessID: pid, VRAMUsage: 2500, Running: true})
+	before := s.processManager.FindModel("chat")
+
+	// 未出现在nvidia-smi列表中的进程保留估算值
+	s.refreshVRAMUsage(map[int]int{pid + 1: 100})
+	if status := s.processManager.FindModel("chat"); status.VRAMUsage != 2500 || status.VRAMMeasured {
+		t.Fatalf("Unexpected status after unrelated refresh: %+v", status)
+	}
+
+	s.refreshVRAMUsage(map[int]int{pid: 1234})
+	status := s.processManager.FindModel("chat")
+	if status.VRAMUsage != 1234 || !status.VRAMMeasured {
+		t.Fatalf("Expected measured usage of 1234MB, got %+v", status)
+	}
+	// 更新替换状态对象，不修改调用方已持有的对象
+	if before.VRAMUsage != 2500 {
+		t.Errorf("Expected previously returned status to be unchanged, got %dMB", before.VRAMUsage)
+	}
+}
+
+// fakeGPU 报告固定进程显存占用的GPU工具
+type fakeGPU struct {
+	usage map[int]int
+}
+
+func (fakeGPU) Vendor() string                        { return GPUVendorNVIDIA }
+func (fakeGPU) FreeMemory() ([]int, error)            { return []int{8000}, nil }
+func (fakeGPU) Devices() ([]model.GPUInfo, error)     { return nil, nil }
+func (g fakeGPU) ProcessMemory() (map[int]int, error) { return g.usage, nil }
+
+func TestMeasureVRAMWhenReady(t *testing.T) {
+	// 模拟加载中的llama-server：ready之前/health返回503
+	var ready atomic.Bool
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ready.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer backend.Close()
+	_, portStr, _ := net.SplitHostPort(backend.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	const pid = 4242
+	cfg := &config.Config{PersistentDir: t.TempDir()}
+	s := &ModelService{
+		config:         cfg,
+		processManager: NewProcessManager(),
+		persistentMgr:  config.NewPersistentManager(cfg),
+		gpu:            fakeGPU{usage: map[int]int{pid: 3100}},
+	}
+	mc := &model.ModelConfig{ModelName: "chat", ModelPath: "chat.gguf"}
+	s.processManager.AddModel(pid, &model.ModelStatus{ModelName: "chat", ProcessID: pid, Host: "127.0.0.1", Port: port, VRAMUsage: 2500, Running: true})
+
+	// 实例就绪前退出时放弃测量
+	exited := &processExit{done: make(chan struct{})}
+	close(exited.done)
+	s.measureVRAMWhenReady(mc, pid, exited)
+	if status := s.processManager.FindModel("chat"); status.VRAMMeasured {
+		t.Fatalf("measured VRAM of an instance that exited before ready: %+v", status)
+	}
+
+	// 就绪后以实际占用替换估算值并持久化
+	exit := &processExit{done: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		s.measureVRAMWhenReady(mc, pid, exit)
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if status := s.processManager.FindModel("chat"); status.VRAMMeasured {
+		t.Fatalf("measured VRAM before the instance was ready: %+v", status)
+	}
+	ready.Store(true)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("measurement did not finish after the instance became ready")
+	}
+	if status := s.processManager.FindModel("chat"); status.VRAMUsage != 3100 || !status.VRAMMeasured {
+		t.Errorf("status after ready = %+v, want measured 3100MB", status)
+	}
+	if measured := s.previousVRAM(mc); measured != 3100 {
+		t.Errorf("persisted measurement = %dMB, want 3100MB", measured)
+	}
+}
+
+func TestSameVRAMParams(t *testing.T) {
+	a := &model.ModelConfig{ModelPath: "chat.gguf"}
+	a.Config.NGPULayers = 20
+	a.Config.CtxSize = 4096
+	a.Config.Port = 8081
+
+	b := *a
+	b.Config.Port = 8082
+	b.Config.Host = "0.0.0.0"
+	if !sameVRAMParams(a, &b) {
+		t.Error("Expected configs differing only in listen address to match")
+	}
+
+	b.Config.CtxSize = 8192
+	if sameVRAMParams(a, &b) {
+		t.Error("Expected configs with different ctx_size not to match")
+	}
+}
+
+// writeTestGGUF 写入一个只有头部的GGUF文件：4层llama模型，每层一个1024x1024的f16张量，
+// 嵌入维度4096，32个注意力头，8个KV头，词表32000，输出层4096x32000（f16）
+func writeTestGGUF(t *testing.T) string {
+	var buf bytes.Buffer
+	put := func(v interface{}) { binary.Write(&buf, binary.LittleEndian, v) }
+	str := func(s string) { put(uint64(len(s))); buf.WriteString(s) }
+
+	put(uint32(0x46554747))
+	put(uint32(3))
+	put(uint64(5)) // 张量个数
+	put(uint64(6)) // 元数据个数
+	str("general.architecture")
+	put(uint32(8))
+	str("llama")
+	for _, kv := range []struct {
+		key   string
+		value uint32
+	}{
+		{"llama.block_count", 4},
+		{"llama.embedding_length", 4096},
+		{"llama.attention.head_count", 32},
+		{"llama.attention.head_count_kv", 8},
+		{"llama.vocab_size", 32000},
+	} {
+		str(kv.key)
+		put(uint32(4))
+		put(kv.value)
+	}
+	tensor := func(name string, dims ...uint64) {
+		str(name)
+		put(uint32(len(dims)))
+		for _, d := range dims {
+			put(d)
+		}
+		put(uint32(1)) // f16
+		put(uint64(0))
+	}
+	for i := 0; i < 4; i++ {
+		tensor("blk."+string(rune('0'+i))+".attn_q.weight", 1024, 1024)
+	}
+	tensor("output.weight", 4096, 32000)
+
+	path := filepath.Join(t.TempDir(), "model.gguf")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEstimateGGUF(t *testing.T) {
+	path := writeTestGGUF(t)
+	newConfig := func(layers int) *model.ModelConfig {
+		cfg := &model.ModelConfig{ModelPath: path}
+		cfg.Config.NGPULayers = model.GPULayers(layers)
+		cfg.Config.CtxSize = 4096
+		cfg.Config.FlashAttn = true
+		return cfg
+	}
+
+	// 卸载2层：权重2x2MB，KV缓存每层4096*8*128*2字节*2(K+V)=16MB，计算缓冲区512*4096*16字节=32MB
+	estimate, err := estimateGGUF(path, newConfig(2))
+	if err != nil {
+		t.Fatalf("estimateGGUF failed: %v", err)
+	}
+	want := model.VRAMEstimate{
+		Source: VRAMSourceGGUF, TotalMB: 324, WeightsMB: 4, KVCacheMB: 32, ComputeMB: 32, OverheadMB: 256,
+		Layers: 4, OffloadedLayers: 2, ContextSize: 4096,
+	}
+	if *estimate != want {
+		t.Errorf("Partial offload estimate = %+v, want %+v", *estimate, want)
+	}
+
+	// 全部卸载时输出层和logits缓冲区也在GPU上
+	estimate, err = estimateGGUF(path, newConfig(99))
+	if err != nil {
+		t.Fatalf("estimateGGUF failed: %v", err)
+	}
+	if estimate.WeightsMB != 258 || estimate.KVCacheMB != 64 || estimate.ComputeMB != 95 || estimate.TotalMB != 673 {
+		t.Errorf("Full offload estimate = %+v", *estimate)
+	}
+
+	// 量化KV缓存和禁用KV卸载
+	cfg := newConfig(99)
+	cfg.Config.CacheTypeK, cfg.Config.CacheTypeV = "q8_0", "q8_0"
+	if estimate, _ = estimateGGUF(path, cfg); estimate.KVCacheMB != 34 {
+		t.Errorf("q8_0 KV cache = %dMB, want 34MB", estimate.KVCacheMB)
+	}
+	cfg.Config.NoKVOffload = true
+	if estimate, _ = estimateGGUF(path, cfg); estimate.KVCacheMB != 0 {
+		t.Errorf("KV cache with no_kv_offload = %dMB, want 0", estimate.KVCacheMB)
+	}
+
+	// CPU推理不占用显存
+	if estimate, _ = estimateGGUF(path, newConfig(0)); estimate.TotalMB != 0 {
+		t.Errorf("CPU-only estimate = %+v, want 0MB", *estimate)
+	}
+}
+
+func TestEstimateVRAMSources(t *testing.T) {
+	cfg := &config.Config{PersistentDir: t.TempDir()}
+	s := &ModelService{config: cfg, persistentMgr: config.NewPersistentManager(cfg)}
+	newConfig := func(path string, layers int) *model.ModelConfig {
+		mc := &model.ModelConfig{ModelName: "chat", ModelPath: path}
+		mc.Config.NGPULayers = model.GPULayers(layers)
+		mc.Config.CtxSize = 4096
+		mc.Config.FlashAttn = true
+		return mc
+	}
+
+	// 可读取GGUF元数据时不使用粗略估算
+	gguf := writeTestGGUF(t)
+	if estimate := s.estimateVRAM(newConfig(gguf, 2), gguf, 300); estimate.Source != VRAMSourceGGUF || estimate.TotalMB != 324 || estimate.Error != "" {
+		t.Errorf("GGUF model estimate = %+v, want gguf 324MB", *estimate)
+	}
+
+	// 无法读取GGUF元数据时才按层数估算，标记来源并给出原因，且不超过模型文件大小
+	broken := filepath.Join(t.TempDir(), "broken.gguf")
+	if err := os.WriteFile(broken, []byte("not a gguf file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		layers, sizeMB, want int
+	}{
+		{10, 8000, 2500},
+		{99, 4000, 4000},
+	} {
+		estimate := s.estimateVRAM(newConfig(broken, tc.layers), broken, tc.sizeMB)
+		if estimate.Source != VRAMSourceHeuristic || estimate.TotalMB != tc.want || estimate.Error == "" {
+			t.Errorf("heuristic estimate for %d layers, %dMB file = %+v, want %dMB with error", tc.layers, tc.sizeMB, *estimate, tc.want)
+		}
+	}
+
+	// 上次以相同参数运行时的实测值优先
+	mc := newConfig(broken, 10)
+	if err := s.persistentMgr.UpdateModelConfig("chat", mc, &model.ModelStatus{ModelName: "chat", VRAMUsage: 3100, VRAMMeasured: true}); err != nil {
+		t.Fatal(err)
+	}
+	if estimate := s.estimateVRAM(mc, broken, 8000); estimate.Source != VRAMSourceMeasured || estimate.TotalMB != 3100 {
+		t.Errorf("estimate with measured usage = %+v, want measured 3100MB", *estimate)
+	}
+}
+
+func TestEstimateRAM(t *testing.T) {
+	path := writeTestGGUF(t)
+	tests := []struct {
+		layers int
+		want   int
+	}{
+		// CPU推理：全部权重258MB、KV缓存64MB、计算缓冲区32MB+logits 63MB
+		{0, 417},
+		// 卸载2层：剩余2层权重4MB、输出层250MB、这2层的KV缓存32MB以及主机上的计算缓冲区
+		{2, 381},
+		// 全部卸载时主机内存只保留未卸载的张量
+		{99, 0},
+	}
+	for _, tt := range tests {
+		cfg := &model.ModelConfig{ModelPath: path}
+		cfg.Config.NGPULayers = model.GPULayers(tt.layers)
+		cfg.Config.CtxSize = 4096
+		cfg.Config.FlashAttn = true
+		if got := estimateRAM(cfg, path, 1); got != tt.want {
+			t.Errorf("estimateRAM(n_gpu_layers=%d) = %dMB, want %dMB", tt.layers, got, tt.want)
+		}
+	}
+
+	// 无法读取GGUF时纯CPU推理按文件大小估算，部分卸载不做检查
+	cfg := &model.ModelConfig{}
+	if got := estimateRAM(cfg, "missing.gguf", 1234); got != 1234 {
+		t.Errorf("CPU-only fallback = %dMB, want 1234MB", got)
+	}
+	cfg.Config.NGPULayers = 10
+	if got := estimateRAM(cfg, "missing.gguf", 1234); got != 0 {
+		t.Errorf("Offloaded fallback = %dMB, want 0", got)
+	}
+}
+
+func TestFitGPULayers(t *testing.T) {
+	path := writeTestGGUF(t)
+	cfg := &model.ModelConfig{ModelPath: path}
+	cfg.Config.NGPULayers = model.GPULayersAuto
+	cfg.Config.CtxSize = 4096
+	cfg.Config.FlashAttn = true
+
+	// 每层权重2MB、KV缓存16MB，另有计算缓冲区32MB和运行时256MB；输出层和logits共313MB
+	tests := []struct {
+		free, want int
+	}{
+		{100000, 5},
+		{400, 4},
+		{310, 1},
+		{100, 0},
+	}
+	for _, tt := range tests {
+		decision, err := fitGPULayers(cfg, path, tt.free)
+		if err != nil {
+			t.Fatalf("fitGPULayers failed: %v", err)
+		}
+		if decision.Layers != tt.want || decision.TotalLayers != 4 || decision.VRAMMB > tt.free {
+			t.Errorf("fitGPULayers(free=%dMB) = %+v, want %d layers", tt.free, *decision, tt.want)
+		}
+	}
+
+	if _, err := fitGPULayers(cfg, filepath.Join(t.TempDir(), "missing.gguf"), 1000); err == nil {
+		t.Error("Expected error without GGUF metadata")
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"llama-switch/internal/model"
+)
+
+// maxMetricsSnapshot 诊断快照中保存的/metrics输出的最大字节数
+const maxMetricsSnapshot = 64 * 1024
+
+// watchStuck 检查连续探测失败的实例，进程仍然存在时交给看门狗处理
+func (m *HealthMonitor) watchStuck(names []string) {
+	threshold := m.service.config.Watchdog.Failures
+	if threshold <= 0 {
+		return
+	}
+
+	for _, name := range names {
+		state := m.Get(name)
+		if state == nil || state.Status != HealthUnhealthy || state.ConsecutiveFailures < threshold {
+			continue
+		}
+
+		m.mu.Lock()
+		busy := m.recovering[name]
+		m.recovering[name] = true
+		m.mu.Unlock()
+		if busy {
+			continue
+		}
+
+		// 重启需要等待实例就绪，在后台处理以免阻塞其他实例的探测
+		go func(name string, state *model.ModelHealth) {
+			defer func() {
+				m.mu.Lock()
+				delete(m.recovering, name)
+				m.mu.Unlock()
+			}()
+			m.service.recoverStuck(name, state)
+		}(name, state)
+	}
+}
+
+// recoverStuck 采集卡死实例的诊断快照，结束进程并按配置重新启动
+func (s *ModelService) recoverStuck(name string, health *model.ModelHealth) {
+	status := s.processManager.FindModel(name)
+	if status == nil || !status.Running {
+		return
+	}
+	// 进程已退出的实例由运行状态校正处理
+	if !processAlive(status.ProcessID) {
+		return
+	}
+
+	cfg := s.runningConfig(name)
+	snapshot := s.captureSnapshot(name, status.ProcessID, health, cfg)
+
+	if _, err := s.StopModel(name); err != nil {
+		slog.Error("Watchdog failed to stop stuck model", "model_name", name, "error", err)
+		return
+	}
+	s.events.Record(EventWatchdogKill, name,
+		fmt.Sprintf("Model '%s' (PID: %d) stopped after %d consecutive failed health checks: %s",
+			name, status.ProcessID, health.ConsecutiveFailures, health.Error),
+		snapshot)
+
+	if !s.config.Watchdog.Restart || cfg == nil {
+		return
+	}
+	restart := *cfg
+	if _, err := s.StartModel(&restart); err != nil {
+		s.events.Record(EventWatchdogRestartFailed, name,
+			fmt.Sprintf("Failed to restart model '%s': %v", name, err), nil)
+		return
+	}
+	newStatus := s.processManager.FindModel(name)
+	pid := 0
+	if newStatus != nil {
+		pid = newStatus.ProcessID
+	}
+	s.events.Record(EventWatchdogRestart, name,
+		fmt.Sprintf("Model '%s' restarted (PID: %d)", name, pid), nil)
+}
+
+// captureSnapshot 采集卡死实例的诊断信息：健康检查结果、最近日志和/metrics
+func (s *ModelService) captureSnapshot(name string, pid int, health *model.ModelHealth, cfg *model.ModelConfig) *model.WatchdogSnapshot {
+	snapshot := &model.WatchdogSnapshot{ProcessID: pid, Health: health}
+
+	if n := s.config.Watchdog.LogLines; n > 0 {
+		if lines, err := s.logs.Tail(name, n); err == nil {
+			snapshot.LastLogs = lines
+		}
+	}
+
+	if cfg != nil && cfg.Config.Metrics {
+		metrics, err := s.fetchMetrics(name)
+		if err != nil {
+			snapshot.MetricsError = err.Error()
+		} else {
+			snapshot.Metrics = metrics
+		}
+	}
+	return snapshot
+}
+
+// fetchMetrics 获取实例/metrics的输出，超过maxMetricsSnapshot的部分截断
+func (s *ModelService) fetchMetrics(name string) (string, error) {
+	backend, err := s.GetBackend(name)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := s.readMetrics(context.Background(), backend, maxMetricsSnapshot)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// readMetrics 读取实例/metrics的输出，最多读取limit字节
+func (s *ModelService) readMetrics(ctx context.Context, backend *Backend, limit int64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.healthTimeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, backend.URL.JoinPath("metrics").String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := backendClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("metrics request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("metrics endpoint returned %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metrics: %v", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,419 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+)
+
+// 可订阅的事件类型
+const (
+	WebhookModelStarted       = "model.started"       // 模型启动并就绪
+	WebhookModelStopped       = "model.stopped"       // 模型被停止
+	WebhookModelCrashed       = "model.crashed"       // 模型进程在未被停止的情况下退出
+	WebhookModelEvicted       = "model.evicted"       // 为启动其他模型释放显存或内存而被驱逐
+	WebhookBenchmarkCompleted = "benchmark.completed" // 基准测试完成
+	WebhookBenchmarkFailed    = "benchmark.failed"    // 基准测试失败
+	WebhookBenchmarkCancelled = "benchmark.cancelled" // 基准测试被取消
+	WebhookVRAMLow            = "vram.low"            // GPU可用显存低于VRAM_LOW_MB
+	webhookTest               = "webhook.test"        // 测试通知，只发送给被测试的订阅
+)
+
+// WebhookEvents 可订阅的事件类型
+var WebhookEvents = []string{
+	WebhookModelStarted, WebhookModelStopped, WebhookModelCrashed, WebhookModelEvicted,
+	WebhookBenchmarkCompleted, WebhookBenchmarkFailed, WebhookBenchmarkCancelled, WebhookVRAMLow,
+}
+
+// 通知的投递状态
+const (
+	DeliveryPending   = "pending"   // 等待投递或重试
+	DeliveryDelivered = "delivered" // 接收方返回了2xx
+	DeliveryFailed    = "failed"    // 达到最多尝试次数仍未成功
+)
+
+const (
+	webhooksFileName     = "webhooks.json"
+	webhookMaxDeliveries = 50 // 每个订阅保留的最近投递记录数
+)
+
+// webhookRecord 订阅的持久化结构
+type webhookRecord struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Secret      string   `json:"secret"`
+	Description string   `json:"description,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+// webhookEntry 订阅及其在本次运行中的投递记录
+type webhookEntry struct {
+	webhookRecord
+	deliveries []*model.WebhookDelivery // 最近的投递记录（旧的在前）
+	delivered  int
+	failed     int
+}
+
+// WebhookManager 事件订阅管理器：事件发生时向订阅的地址POST带HMAC-SHA256签名的通知，
+// 失败时按指数退避重试。订阅保存在文件中，投递记录只保存在内存中
+type WebhookManager struct {
+	path        string
+	maxAttempts int
+	retryDelay  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*webhookEntry
+}
+
+// NewWebhookManager 创建事件订阅管理器并加载已保存的订阅
+func NewWebhookManager(path string, maxAttempts int, retryDelay time.Duration) *WebhookManager {
+	m := &WebhookManager{
+		path:        path,
+		maxAttempts: max(maxAttempts, 1),
+		retryDelay:  retryDelay,
+		entries:     make(map[string]*webhookEntry),
+	}
+	if err := m.load(); err != nil {
+		slog.Warn("Failed to load webhooks", "file", path, "error", err)
+	}
+	return m
+}
+
+// defaultWebhooksPath 默认订阅文件路径：与持久化配置同在程序目录下的config目录
+func defaultWebhooksPath() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return filepath.Join("config", webhooksFileName)
+	}
+	return filepath.Join(filepath.Dir(exePath), "config", webhooksFileName)
+}
+
+// Webhooks 获取事件订阅管理器
+func (s *ModelService) Webhooks() *WebhookManager {
+	return s.webhooks
+}
+
+// Create 注册订阅，未指定密钥时生成随机密钥；返回的订阅包含密钥，之后的查询不再返回
+func (m *WebhookManager) Create(req *model.WebhookRequest) (*model.WebhookSubscription, error) {
+	if req.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	if err := validateWebhookURLs([]string{req.URL}); err != nil {
+		return nil, err
+	}
+	events := make([]string, 0, len(req.Events))
+	for _, event := range req.Events {
+		if !slices.Contains(WebhookEvents, event) {
+			return nil, fmt.Errorf("unknown event type: %s (expected one of %s)", event, strings.Join(WebhookEvents, ", "))
+		}
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+	secret := req.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate webhook secret: %v", err)
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	entry := &webhookEntry{webhookRecord: webhookRecord{
+		ID:          uuid.New().String(),
+		URL:         req.URL,
+		Events:      events,
+		Secret:      secret,
+		Description: req.Description,
+		CreatedAt:   time.Now().Format(time.RFC3339),
+	}}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[entry.ID] = entry
+	if err := m.save(); err != nil {
+		delete(m.entries, entry.ID)
+		return nil, err
+	}
+	slog.Info("Webhook registered", "webhook_id", entry.ID, "url", entry.URL)
+
+	sub := entry.subscription()
+	sub.Secret = secret
+	return sub, nil
+}
+
+// List 获取所有订阅（按注册时间排序，不含密钥）
+func (m *WebhookManager) List() []*model.WebhookSubscription {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subs := make([]*model.WebhookSubscription, 0, len(m.entries))
+	for _, entry := range m.entries {
+		subs = append(subs, entry.subscription())
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].CreatedAt != subs[j].CreatedAt {
+			return subs[i].CreatedAt < subs[j].CreatedAt
+		}
+		return subs[i].ID < subs[j].ID
+	})
+	return subs
+}
+
+// Get 获取订阅（不含密钥）
+func (m *WebhookManager) Get(id string) (*model.WebhookSubscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, exists := m.entries[id]
+	if !exists {
+		return nil, apierror.New(apierror.CodeWebhookNotFound, "webhook not found: %s", id)
+	}
+	return entry.subscription(), nil
+}
+
+// Deliveries 获取订阅最近的投递记录（最新的在前）
+func (m *WebhookManager) Deliveries(id string) ([]*model.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, exists := m.entries[id]
+	if !exists {
+		return nil, apierror.New(apierror.CodeWebhookNotFound, "webhook not found: %s", id)
+	}
+	deliveries := make([]*model.WebhookDelivery, 0, len(entry.deliveries))
+	for i := len(entry.deliveries) - 1; i >= 0; i-- {
+		delivery := *entry.deliveries[i]
+		deliveries = append(deliveries, &delivery)
+	}
+	return deliveries, nil
+}
+
+// Delete 删除订阅，尚未完成的重试随之停止
+func (m *WebhookManager) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, exists := m.entries[id]
+	if !exists {
+		return apierror.New(apierror.CodeWebhookNotFound, "webhook not found: %s", id)
+	}
+	delete(m.entries, id)
+	if err := m.save(); err != nil {
+		m.entries[id] = entry
+		return err
+	}
+	slog.Info("Webhook removed", "webhook_id", id)
+	return nil
+}
+
+// Test 向订阅发送一条webhook.test通知，返回待投递的记录
+func (m *WebhookManager) Test(id string) (*model.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, exists := m.entries[id]
+	if !exists {
+		return nil, apierror.New(apierror.CodeWebhookNotFound, "webhook not found: %s", id)
+	}
+	delivery := m.enqueue(entry, webhookTest, map[string]string{"message": "Test notification from llama-switch"})
+	if delivery == nil {
+		return nil, fmt.Errorf("failed to encode test notification")
+	}
+	copied := *delivery
+	return &copied, nil
+}
+
+// Publish 向订阅了该事件的所有地址发送通知，在后台投递
+func (m *WebhookManager) Publish(event string, data interface{}) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, entry := range m.entries {
+		if len(entry.Events) == 0 || slices.Contains(entry.Events, event) {
+			m.enqueue(entry, event, data)
+		}
+	}
+}
+
+// enqueue 为订阅创建投递记录并在后台投递（调用方需持有锁）
+func (m *WebhookManager) enqueue(entry *webhookEntry, event string, data interface{}) *model.WebhookDelivery {
+	now := time.Now().Format(time.RFC3339)
+	delivery := &model.WebhookDelivery{
+		ID:        uuid.New().String(),
+		Event:     event,
+		Status:    DeliveryPending,
+		CreatedAt: now,
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":    delivery.ID,
+		"event": event,
+		"time":  now,
+		"data":  data,
+	})
+	if err != nil {
+		slog.Warn("Failed to encode webhook", "event", event, "error", err)
+		return nil
+	}
+	entry.deliveries = append(entry.deliveries, delivery)
+	if len(entry.deliveries) > webhookMaxDeliveries {
+		entry.deliveries = entry.deliveries[len(entry.deliveries)-webhookMaxDeliveries:]
+	}
+	go m.deliver(entry.ID, delivery, payload)
+	return delivery
+}
+
+// deliver 投递一条通知，失败时按指数退避重试，订阅被删除后停止
+func (m *WebhookManager) deliver(id string, delivery *model.WebhookDelivery, payload []byte) {
+	delay := m.retryDelay
+	for {
+		m.mu.Lock()
+		entry, exists := m.entries[id]
+		m.mu.Unlock()
+		if !exists {
+			return
+		}
+
+		code, err := postWebhook(entry.URL, entry.Secret, delivery.Event, delivery.ID, payload)
+		if m.recordAttempt(id, delivery, code, err, delay) {
+			return
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// recordAttempt 记录一次投递结果，返回是否已结束（成功或达到最多尝试次数）
+func (m *WebhookManager) recordAttempt(id string, delivery *model.WebhookDelivery, code int, err error, delay time.Duration) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.StatusCode = code
+	delivery.LastAttempt = now.Format(time.RFC3339)
+	delivery.NextAttempt = ""
+	delivery.Error = ""
+	entry := m.entries[id]
+	if err == nil {
+		delivery.Status = DeliveryDelivered
+		if entry != nil {
+			entry.delivered++
+		}
+		return true
+	}
+
+	delivery.Error = err.Error()
+	if delivery.Attempts >= m.maxAttempts {
+		delivery.Status = DeliveryFailed
+		if entry != nil {
+			entry.failed++
+		}
+		slog.Warn("Webhook notification failed", "webhook_id", id, "event", delivery.Event, "delivery_id", delivery.ID, "attempts", delivery.Attempts, "error", err)
+		return true
+	}
+	delivery.NextAttempt = now.Add(delay).Format(time.RFC3339)
+	return false
+}
+
+// postWebhook 发送一次通知，非2xx响应视为失败
+func postWebhook(url, secret, event, deliveryID string, payload []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", event)
+	req.Header.Set("X-Webhook-Delivery", deliveryID)
+	req.Header.Set("X-Webhook-Signature", "sha256="+signWebhook(secret, payload))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// signWebhook 计算请求体的HMAC-SHA256签名（十六进制）
+func signWebhook(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// subscription 构建不含密钥的订阅视图（调用方需持有锁）
+func (e *webhookEntry) subscription() *model.WebhookSubscription {
+	sub := &model.WebhookSubscription{
+		ID:          e.ID,
+		URL:         e.URL,
+		Events:      slices.Clone(e.Events),
+		Description: e.Description,
+		CreatedAt:   e.CreatedAt,
+		Delivered:   e.delivered,
+		Failed:      e.failed,
+	}
+	if len(e.deliveries) > 0 {
+		last := *e.deliveries[len(e.deliveries)-1]
+		sub.LastDelivery = &last
+	}
+	return sub
+}
+
+// load 从文件加载订阅
+func (m *WebhookManager) load() error {
+	data, err := os.ReadFile(m.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read webhooks file: %v", err)
+	}
+	var records []webhookRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return fmt.Errorf("failed to parse webhooks file: %v", err)
+	}
+	for _, record := range records {
+		m.entries[record.ID] = &webhookEntry{webhookRecord: record}
+	}
+	return nil
+}
+
+// save 保存订阅到文件，文件包含签名密钥，只允许所有者读写（调用方需持有锁）
+func (m *WebhookManager) save() error {
+	records := make([]webhookRecord, 0, len(m.entries))
+	for _, entry := range m.entries {
+		records = append(records, entry.webhookRecord)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].CreatedAt < records[j].CreatedAt })
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize webhooks: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("failed to create webhooks directory: %v", err)
+	}
+	if err := os.WriteFile(m.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write webhooks file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"llama-switch/internal/apierror"
+	"llama-switch/internal/model"
+)
+
+// waitDelivery 等待订阅的最近一次投递结束
+func waitDelivery(t *testing.T, m *WebhookManager, id string) *model.WebhookDelivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		deliveries, err := m.Deliveries(id)
+		if err != nil {
+			t.Fatalf("Deliveries failed: %v", err)
+		}
+		if len(deliveries) > 0 && deliveries[0].Status != DeliveryPending {
+			return deliveries[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("delivery for webhook %s did not finish", id)
+	return nil
+}
+
+func TestWebhookSignedDeliveryWithRetry(t *testing.T) {
+	var calls atomic.Int32
+	received := make(chan map[string]interface{}, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 第一次返回500，验证重试
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if got, want := r.Header.Get("X-Webhook-Signature"), "sha256="+signWebhook("s3cret", body); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		if r.Header.Get("X-Webhook-Event") != WebhookModelStarted || r.Header.Get("X-Webhook-Delivery") == "" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+		var payload map[string]interface{}
+		json.Unmarshal(body, &payload)
+		received <- payload
+	}))
+	defer server.Close()
+
+	m := NewWebhookManager(filepath.Join(t.TempDir(), "webhooks.json"), 3, 10*time.Millisecond)
+	sub, err := m.Create(&model.WebhookRequest{URL: server.URL, Events: []string{WebhookModelStarted}, Secret: "s3cret"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// 未订阅的事件不投递
+	m.Publish(WebhookModelStopped, map[string]string{"model": "m"})
+	m.Publish(WebhookModelStarted, map[string]string{"model": "m"})
+
+	delivery := waitDelivery(t, m, sub.ID)
+	if delivery.Status != DeliveryDelivered || delivery.Attempts != 2 || delivery.StatusCode != http.StatusOK {
+		t.Errorf("unexpected delivery: %+v", delivery)
+	}
+	payload := <-received
+	if data, _ := payload["data"].(map[string]interface{}); payload["event"] != WebhookModelStarted || payload["id"] != delivery.ID || data["model"] != "m" {
+		t.Errorf("unexpected payload: %v", payload)
+	}
+	if deliveries, _ := m.Deliveries(sub.ID); len(deliveries) != 1 {
+		t.Errorf("deliveries = %d, want 1", len(deliveries))
+	}
+	got, _ := m.Get(sub.ID)
+	if got.Delivered != 1 || got.Failed != 0 || got.LastDelivery == nil {
+		t.Errorf("unexpected counters: %+v", got)
+	}
+}
+
+func TestWebhookDeliveryFailure(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	m := NewWebhookManager(filepath.Join(t.TempDir(), "webhooks.json"), 2, time.Millisecond)
+	sub, err := m.Create(&model.WebhookRequest{URL: server.URL})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := m.Test(sub.ID); err != nil {
+		t.Fatalf("Test failed: %v", err)
+	}
+
+	delivery := waitDelivery(t, m, sub.ID)
+	if delivery.Status != DeliveryFailed || delivery.Attempts != 2 || delivery.StatusCode != http.StatusBadGateway || delivery.Error == "" {
+		t.Errorf("unexpected delivery: %+v", delivery)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+	if got, _ := m.Get(sub.ID); got.Failed != 1 {
+		t.Errorf("failed = %d, want 1", got.Failed)
+	}
+}
+
+func TestWebhookPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.json")
+	m := NewWebhookManager(path, 1, time.Second)
+	sub, err := m.Create(&model.WebhookRequest{
+		URL:         "http://127.0.0.1:1/hook",
+		Events:      []string{WebhookVRAMLow, WebhookVRAMLow, WebhookModelCrashed},
+		Description: "alerts",
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if len(sub.Secret) != 64 {
+		t.Errorf("generated secret = %q, want 64 hex chars", sub.Secret)
+	}
+	if len(sub.Events) != 2 {
+		t.Errorf("events = %v, want duplicates removed", sub.Events)
+	}
+
+	reloaded := NewWebhookManager(path, 1, time.Second)
+	subs := reloaded.List()
+	if len(subs) != 1 || subs[0].ID != sub.ID || subs[0].Description != "alerts" {
+		t.Fatalf("reloaded subscriptions = %+v", subs)
+	}
+	if subs[0].Secret != "" {
+		t.Error("List exposed the webhook secret")
+	}
+
+	if err := reloaded.Delete(sub.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if subs := NewWebhookManager(path, 1, time.Second).List(); len(subs) != 0 {
+		t.Errorf("subscriptions after delete = %+v", subs)
+	}
+}
+
+func TestWebhookValidation(t *testing.T) {
+	m := NewWebhookManager(filepath.Join(t.TempDir(), "webhooks.json"), 1, time.Second)
+	for _, req := range []*model.WebhookRequest{
+		{},
+		{URL: "ftp://example.com/hook"},
+		{URL: "http://example.com/hook", Events: []string{"model.exploded"}},
+	} {
+		if _, err := m.Create(req); err == nil {
+			t.Errorf("Create accepted %+v", req)
+		}
+	}
+
+	if _, err := m.Get("unknown"); apierror.CodeOf(err) != apierror.CodeWebhookNotFound {
+		t.Errorf("Get error = %v, want %s", err, apierror.CodeWebhookNotFound)
+	}
+	if err := m.Delete("unknown"); apierror.CodeOf(err) != apierror.CodeWebhookNotFound {
+		t.Errorf("Delete error = %v, want %s", err, apierror.CodeWebhookNotFound)
+	}
+
+	// 未初始化的管理器忽略事件
+	var nilManager *WebhookManager
+	nilManager.Publish(WebhookModelStarted, nil)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"llama-switch/internal/model"
+)
+
+// defaultWorkDirRoot 默认模型工作目录的父目录：程序目录下的workdirs目录
+func defaultWorkDirRoot() string {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "workdirs"
+	}
+	return filepath.Join(filepath.Dir(exePath), "workdirs")
+}
+
+// modelWorkDir 获取模型实例的工作目录
+func (s *ModelService) modelWorkDir(name string) string {
+	root := s.config.WorkDir.Root
+	if root == "" {
+		root = defaultWorkDirRoot()
+	}
+	return filepath.Join(root, unsafeFileChars.ReplaceAllString(name, "_"))
+}
+
+// prepareWorkDir 创建模型的工作目录（仅当前用户可访问）并清空其中的临时目录
+// 返回工作目录以及将临时文件指向该目录的环境变量
+func (s *ModelService) prepareWorkDir(name string) (string, []string, error) {
+	dir := s.modelWorkDir(name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", nil, fmt.Errorf("failed to create work directory: %v", err)
+	}
+	if err := os.Chmod(dir, 0700); err != nil {
+		return "", nil, fmt.Errorf("failed to restrict work directory: %v", err)
+	}
+
+	tmpDir := filepath.Join(dir, "tmp")
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return "", nil, fmt.Errorf("failed to clean temp directory: %v", err)
+	}
+	if err := os.Mkdir(tmpDir, 0700); err != nil {
+		return "", nil, fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	return dir, workDirEnv(dir), nil
+}
+
+// workDirEnv 将临时目录指向工作目录的环境变量
+func workDirEnv(dir string) []string {
+	tmpDir := filepath.Join(dir, "tmp")
+	return []string{"TMPDIR=" + tmpDir, "TEMP=" + tmpDir, "TMP=" + tmpDir}
+}
+
+// checkSandbox 沙箱模式下检查模型参数中的文件路径：
+// llama-server写入的文件只能位于模型的工作目录中，读取的文件只能位于工作目录或模型目录中
+func (s *ModelService) checkSandbox(cfg *model.ModelConfig) error {
+	if !s.config.WorkDir.Sandbox {
+		return nil
+	}
+	c := cfg.Config
+	workDir := s.modelWorkDir(cfg.ModelName)
+
+	writes := []struct{ param, path string }{
+		{"slot_save_path", c.SlotSavePath},
+		{"log_file", c.LogFile},
+	}
+	for _, w := range writes {
+		if w.path != "" && !withinDir(workDir, resolvePath(workDir, w.path)) {
+			return fmt.Errorf("%s must be inside the model work directory %s in sandbox mode: %s", w.param, workDir, w.path)
+		}
+	}
+
+	reads := []struct{ param, path string }{
+		{"lora", c.Lora},
+		{"lora_scaled", c.LoraScaled},
+		{"control_vector", c.ControlVector},
+		{"control_vector_scaled", c.ControlVectorScaled},
+		{"grammar_file", c.GrammarFile},
+		{"json_schema_file", c.JsonSchemaFile},
+		{"api_key_file", c.ApiKeyFile},
+		{"chat_template_file", c.ChatTemplateFile},
+		{"model_draft", c.ModelDraft},
+		{"model_vocoder", c.ModelVocoder},
+		{"static_path", c.StaticPath},
+	}
+	for _, r := range reads {
+		if r.path == "" {
+			continue
+		}
+		path := resolvePath(workDir, r.path)
+		if !withinDir(workDir, path) && !withinDir(s.config.Snapshot().ModelsDir, path) {
+			return fmt.Errorf("%s must be inside the models directory or the model work directory in sandbox mode: %s", r.param, r.path)
+		}
+	}
+	return nil
+}
+
+// resolvePath 将相对路径解析为相对于工作目录的路径（llama-server在工作目录中运行），并解析已存在路径中的符号链接
+func resolvePath(workDir, path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(workDir, path)
+	}
+	return evalSymlinks(filepath.Clean(path))
+}
+
+// evalSymlinks 解析路径中的符号链接，路径不存在时解析其已存在的上级目录
+func evalSymlinks(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path
+	}
+	return filepath.Join(evalSymlinks(parent), filepath.Base(path))
+}
+
+// withinDir 检查路径是否位于目录中
+func withinDir(dir, path string) bool {
+	if dir == "" {
+		return false
+	}
+	rel, err := filepath.Rel(evalSymlinks(filepath.Clean(dir)), path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"llama-switch/internal/config"
+	"llama-switch/internal/model"
+)
+
+func TestPrepareWorkDir(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.WorkDir.Root = t.TempDir()
+	s := &ModelService{config: cfg}
+
+	dir, env, err := s.prepareWorkDir("team/chat")
+	if err != nil {
+		t.Fatalf("prepareWorkDir failed: %v", err)
+	}
+	if dir != filepath.Join(cfg.WorkDir.Root, "team_chat") {
+		t.Errorf("Unexpected work directory: %s", dir)
+	}
+	stale := filepath.Join(dir, "tmp", "stale")
+	if err := os.WriteFile(stale, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 每次启动时清空临时目录
+	if _, env, err = s.prepareWorkDir("team/chat"); err != nil {
+		t.Fatalf("prepareWorkDir failed: %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Expected temp directory to be cleaned, got %v", err)
+	}
+	if len(env) == 0 || env[0] != "TMPDIR="+filepath.Join(dir, "tmp") {
+		t.Errorf("Unexpected temp environment: %v", env)
+	}
+}
+
+func TestCheckSandbox(t *testing.T) {
+	cfg := &config.Config{ModelsDir: t.TempDir()}
+	cfg.WorkDir.Root = t.TempDir()
+	cfg.WorkDir.Sandbox = true
+	s := &ModelService{config: cfg}
+	workDir := s.modelWorkDir("chat")
+
+	tests := []struct {
+		name    string
+		modify  func(c *model.ModelConfig)
+		wantErr bool
+	}{
+		{"relative slot path", func(c *model.ModelConfig) { c.Config.SlotSavePath = "slots" }, false},
+		{"slot path in work dir", func(c *model.ModelConfig) { c.Config.SlotSavePath = filepath.Join(workDir, "slots") }, false},
+		{"slot path escaping work dir", func(c *model.ModelConfig) { c.Config.SlotSavePath = "../other" }, true},
+		{"log file outside work dir", func(c *model.ModelConfig) { c.Config.LogFile = "/etc/llama.log" }, true},
+		{"lora in models dir", func(c *model.ModelConfig) { c.Config.Lora = filepath.Join(cfg.ModelsDir, "adapter.gguf") }, false},
+		{"grammar outside allowed dirs", func(c *model.ModelConfig) { c.Config.GrammarFile = "/etc/passwd" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &model.ModelConfig{ModelName: "chat"}
+			tt.modify(c)
+			if err := s.checkSandbox(c); (err != nil) != tt.wantErr {
+				t.Errorf("checkSandbox() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+// Package tlsserver 为switcher的HTTP服务器提供HTTPS：证书文件轮换后自动重新加载、
+// 可选的客户端证书验证（mTLS）和HTTP到HTTPS的重定向
+package tlsserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// checkInterval 检查证书文件是否变化的最小间隔，在TLS握手时检查
+const checkInterval = 10 * time.Second
+
+// Certificates 服务器证书和客户端CA，文件修改后在下一次握手时重新加载，
+// 新文件无法加载时（如证书和私钥只更新了一个）继续使用之前的证书
+type Certificates struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mu        sync.Mutex
+	config    *tls.Config
+	modTimes  []time.Time
+	checkedAt time.Time
+}
+
+// Load 加载服务器证书和私钥，clientCAFile不为空时要求客户端提供由其中的CA签发的证书
+func Load(certFile, keyFile, clientCAFile string) (*Certificates, error) {
+	c := &Certificates{certFile: certFile, keyFile: keyFile, clientCAFile: clientCAFile}
+	modTimes, err := c.stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.load(modTimes); err != nil {
+		return nil, err
+	}
+	c.checkedAt = time.Now()
+	return c, nil
+}
+
+// TLSConfig 服务器使用的TLS配置，每次握手时使用当前加载的证书
+func (c *Certificates) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return c.current(), nil
+		},
+	}
+}
+
+// ClientAuth 是否要求客户端证书
+func (c *Certificates) ClientAuth() bool {
+	return c.clientCAFile != ""
+}
+
+// current 获取当前的TLS配置，距离上次检查超过checkInterval时先检查文件是否变化
+func (c *Certificates) current() *tls.Config {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.checkedAt) >= checkInterval {
+		c.checkedAt = time.Now()
+		c.reload()
+	}
+	return c.config
+}
+
+// reload 文件的修改时间变化时重新加载，失败时记录警告并保留之前的证书
+func (c *Certificates) reload() {
+	modTimes, err := c.stat()
+	if err != nil {
+		slog.Warn("Failed to check TLS certificate files", "error", err)
+		return
+	}
+	if slices.EqualFunc(modTimes, c.modTimes, time.Time.Equal) {
+		return
+	}
+	if err := c.load(modTimes); err != nil {
+		// 记录新的修改时间，避免每次检查都重复报告同一错误
+		c.modTimes = modTimes
+		slog.Warn("Keeping the current TLS certificate, failed to load the updated files", "error", err)
+		return
+	}
+	slog.Info("Reloaded TLS certificate", "file", c.certFile)
+}
+
+// stat 获取证书、私钥和客户端CA文件的修改时间
+func (c *Certificates) stat() ([]time.Time, error) {
+	files := []string{c.certFile, c.keyFile}
+	if c.clientCAFile != "" {
+		files = append(files, c.clientCAFile)
+	}
+	modTimes := make([]time.Time, len(files))
+	for i, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// load 读取证书文件并生成TLS配置
+func (c *Certificates) load(modTimes []time.Time) error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %v", err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if c.clientCAFile != "" {
+		data, err := os.ReadFile(c.clientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no certificates found in client CA file %s", c.clientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	c.config = config
+	c.modTimes = modTimes
+	return nil
+}
+
+// RedirectHandler 将HTTP请求重定向到httpsPort上相同主机、路径和查询参数的HTTPS地址，
+// 使用308以便客户端保持请求方法和请求体
+func RedirectHandler(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		} else if net.ParseIP(host) != nil && net.ParseIP(host).To4() == nil {
+			host = "[" + host + "]"
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,163 @@
+package tlsserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert 生成自签名证书，写入dir下的name.crt和name.key，返回证书和私钥路径
+func writeCert(t *testing.T, dir, name string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// handshake 与配置完成一次TLS握手，返回服务器证书的CN
+func handshake(t *testing.T, config *tls.Config, clientCerts []tls.Certificate) (string, error) {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+	server := tls.Server(serverConn, config)
+	go func() {
+		server.Handshake()
+		server.Close()
+	}()
+	client := tls.Client(clientConn, &tls.Config{InsecureSkipVerify: true, Certificates: clientCerts})
+	if err := client.Handshake(); err != nil {
+		return "", err
+	}
+	// TLS 1.3中服务器在第一次读取时才报告客户端证书错误
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := client.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return client.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+}
+
+func TestCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "first")
+	certs, err := Load(certFile, keyFile, "")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	config := certs.TLSConfig()
+	if cn, err := handshake(t, config, nil); err != nil || cn != "first" {
+		t.Fatalf("handshake = %q, %v; want first", cn, err)
+	}
+
+	// 轮换证书后，下一次检查时使用新证书
+	second, secondKey := writeCert(t, t.TempDir(), "second")
+	for _, f := range [][2]string{{second, certFile}, {secondKey, keyFile}} {
+		data, _ := os.ReadFile(f[0])
+		os.WriteFile(f[1], data, 0644)
+		os.Chtimes(f[1], time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	}
+	certs.checkedAt = time.Time{}
+	if cn, err := handshake(t, config, nil); err != nil || cn != "second" {
+		t.Fatalf("handshake after rotation = %q, %v; want second", cn, err)
+	}
+
+	// 只更新了证书、私钥不匹配时继续使用之前的证书
+	third, _ := writeCert(t, t.TempDir(), "third")
+	data, _ := os.ReadFile(third)
+	os.WriteFile(certFile, data, 0644)
+	os.Chtimes(certFile, time.Now().Add(2*time.Minute), time.Now().Add(2*time.Minute))
+	certs.checkedAt = time.Time{}
+	if cn, err := handshake(t, config, nil); err != nil || cn != "second" {
+		t.Fatalf("handshake after a partial update = %q, %v; want second", cn, err)
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.crt"), keyFile, ""); err == nil {
+		t.Error("Load accepted a missing certificate")
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCert(t, dir, "server")
+	caFile, caKey := writeCert(t, dir, "client")
+	certs, err := Load(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !certs.ClientAuth() {
+		t.Error("ClientAuth() = false with a client CA")
+	}
+
+	if _, err := handshake(t, certs.TLSConfig(), nil); err == nil {
+		t.Error("handshake without a client certificate succeeded")
+	}
+	clientCert, err := tls.LoadX509KeyPair(caFile, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cn, err := handshake(t, certs.TLSConfig(), []tls.Certificate{clientCert}); err != nil || cn != "server" {
+		t.Errorf("handshake with a client certificate = %q, %v", cn, err)
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		host string
+		port int
+		want string
+	}{
+		{"example.com:8080", 8443, "https://example.com:8443/api/v1/models?limit=1"},
+		{"example.com", 443, "https://example.com/api/v1/models?limit=1"},
+		{"[::1]:8080", 443, "https://[::1]/api/v1/models?limit=1"},
+		{"[::1]:8080", 8443, "https://[::1]:8443/api/v1/models?limit=1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/models?limit=1", nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		RedirectHandler(tt.port).ServeHTTP(rec, req)
+		if rec.Code != http.StatusPermanentRedirect || rec.Header().Get("Location") != tt.want {
+			t.Errorf("redirect %s -> %d %s; want %s", tt.host, rec.Code, rec.Header().Get("Location"), tt.want)
+		}
+	}
+}
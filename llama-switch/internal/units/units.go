@@ -0,0 +1,163 @@
+// Package units 解析配置和API中带单位的大小（如"8g"、"4096m"）和时间（如"30s"、"10m"）
+package units
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 大小单位（按1024换算）
+const (
+	B  int64 = 1
+	KB       = 1024 * B
+	MB       = 1024 * KB
+	GB       = 1024 * MB
+	TB       = 1024 * GB
+)
+
+// sizeSuffixes 大小单位后缀（不区分大小写），k、kb、kib均表示1024字节
+var sizeSuffixes = map[string]int64{
+	"b": B,
+	"k": KB, "kb": KB, "kib": KB,
+	"m": MB, "mb": MB, "mib": MB,
+	"g": GB, "gb": GB, "gib": GB,
+	"t": TB, "tb": TB, "tib": TB,
+}
+
+// unitNames 错误信息中使用的单位名称
+var unitNames = map[int64]string{B: "bytes", KB: "KB", MB: "MB", GB: "GB", TB: "TB"}
+
+// ParseSize 解析大小并换算为unit的整数倍：不带单位的数字以unit为单位，带单位时支持b、k、m、g、t
+// （及kb/kib等写法，按1024换算）和小数（如"1.5g"），换算结果不是unit的整数倍时返回错误
+func ParseSize(s string, unit int64) (int64, error) {
+	value := strings.TrimSpace(s)
+	if value == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n, nil
+	}
+
+	end := len(value)
+	for end > 0 && (value[end-1] < '0' || value[end-1] > '9') && value[end-1] != '.' {
+		end--
+	}
+	number, suffix := strings.TrimSpace(value[:end]), strings.ToLower(strings.TrimSpace(value[end:]))
+	multiplier, known := sizeSuffixes[suffix]
+	if !known {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q (expected a number of %s or a value like 512m, 8g)", s, value[end:], unitNames[unit])
+	}
+	x, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: expected a number of %s or a value like 512m, 8g", s, unitNames[unit])
+	}
+
+	bytes := x * float64(multiplier)
+	n := bytes / float64(unit)
+	if n != math.Trunc(n) {
+		return 0, fmt.Errorf("invalid size %q: not a whole number of %s", s, unitNames[unit])
+	}
+	if math.Abs(n) > math.MaxInt32 {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+	return int64(n), nil
+}
+
+// ParseDuration 解析时间并换算为unit的整数倍：不带单位的数字以unit为单位，
+// 带单位时使用Go的时间格式（ms、s、m、h，可组合如"1h30m"），换算结果不是unit的整数倍时返回错误
+func ParseDuration(s string, unit time.Duration) (int64, error) {
+	value := strings.TrimSpace(s)
+	if value == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: expected a number of %s or a value like 30s, 10m, 1h30m", s, unitName(unit))
+	}
+	if d%unit != 0 {
+		return 0, fmt.Errorf("invalid duration %q: not a whole number of %s", s, unitName(unit))
+	}
+	return int64(d / unit), nil
+}
+
+// unitName 错误信息中使用的时间单位名称
+func unitName(unit time.Duration) string {
+	switch unit {
+	case time.Millisecond:
+		return "milliseconds"
+	case time.Second:
+		return "seconds"
+	case time.Minute:
+		return "minutes"
+	}
+	return unit.String()
+}
+
+// Megabytes 以MB为单位的大小，JSON中可以是整数（MB）或带单位的字符串（如"8g"、"4096m"）
+type Megabytes int
+
+// UnmarshalJSON 解析整数或带单位的字符串
+func (v *Megabytes) UnmarshalJSON(data []byte) error {
+	return unmarshal(data, v, func(s string) (int64, error) { return ParseSize(s, MB) })
+}
+
+// Kilobytes 以KB为单位的大小，JSON中可以是整数（KB）或带单位的字符串（如"512k"、"4m"）
+type Kilobytes int
+
+// UnmarshalJSON 解析整数或带单位的字符串
+func (v *Kilobytes) UnmarshalJSON(data []byte) error {
+	return unmarshal(data, v, func(s string) (int64, error) { return ParseSize(s, KB) })
+}
+
+// Seconds 以秒为单位的时间，JSON中可以是整数（秒）或带单位的字符串（如"30s"、"10m"）
+type Seconds int
+
+// UnmarshalJSON 解析整数或带单位的字符串
+func (v *Seconds) UnmarshalJSON(data []byte) error {
+	return unmarshal(data, v, func(s string) (int64, error) { return ParseDuration(s, time.Second) })
+}
+
+// Duration 转换为time.Duration
+func (v Seconds) Duration() time.Duration {
+	return time.Duration(v) * time.Second
+}
+
+// Milliseconds 以毫秒为单位的时间，JSON中可以是整数（毫秒）或带单位的字符串（如"250ms"、"2s"）
+type Milliseconds int
+
+// UnmarshalJSON 解析整数或带单位的字符串
+func (v *Milliseconds) UnmarshalJSON(data []byte) error {
+	return unmarshal(data, v, func(s string) (int64, error) { return ParseDuration(s, time.Millisecond) })
+}
+
+// Duration 转换为time.Duration
+func (v Milliseconds) Duration() time.Duration {
+	return time.Duration(v) * time.Millisecond
+}
+
+// unmarshal 解析JSON整数或由parse解析的字符串
+func unmarshal[T ~int](data []byte, v *T, parse func(string) (int64, error)) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		n, err := parse(s)
+		if err != nil {
+			return err
+		}
+		*v = T(n)
+		return nil
+	}
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("invalid value %s: expected an integer or a string with a unit", data)
+	}
+	*v = T(n)
+	return nil
+}
@@ -0,0 +1,103 @@
+package units
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value string
+		unit  int64
+		want  int64
+		err   string
+	}{
+		{"4096", MB, 4096, ""},
+		{"8g", MB, 8192, ""},
+		{"8GB", MB, 8192, ""},
+		{"8 GiB", MB, 8192, ""},
+		{"4096m", MB, 4096, ""},
+		{"1.5g", MB, 1536, ""},
+		{"512k", KB, 512, ""},
+		{"4m", KB, 4096, ""},
+		{"1t", MB, 1048576, ""},
+		{"100k", MB, 0, "not a whole number of MB"},
+		{"8x", MB, 0, "unknown unit"},
+		{"g", MB, 0, "expected a number of MB"},
+		{"", MB, 0, "empty size"},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.value, tt.unit)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseSize(%q) error = %v, want %q", tt.value, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		unit  time.Duration
+		want  int64
+		err   string
+	}{
+		{"30", time.Second, 30, ""},
+		{"30s", time.Second, 30, ""},
+		{"10m", time.Second, 600, ""},
+		{"1h30m", time.Second, 5400, ""},
+		{"250ms", time.Millisecond, 250, ""},
+		{"2s", time.Millisecond, 2000, ""},
+		{"1500ms", time.Second, 0, "not a whole number of seconds"},
+		{"10 minutes", time.Second, 0, "expected a number of seconds"},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.value, tt.unit)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseDuration(%q) error = %v, want %q", tt.value, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %d, %v; want %d", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	var v struct {
+		Reserve Megabytes    `json:"reserve"`
+		Limit   Kilobytes    `json:"limit"`
+		Timeout Seconds      `json:"timeout"`
+		Poll    Milliseconds `json:"poll"`
+	}
+	if err := json.Unmarshal([]byte(`{"reserve":"2g","limit":512,"timeout":"10m","poll":"1s"}`), &v); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if v.Reserve != 2048 || v.Limit != 512 || v.Timeout != 600 || v.Poll != 1000 {
+		t.Errorf("unexpected values: %+v", v)
+	}
+	if v.Timeout.Duration() != 10*time.Minute || v.Poll.Duration() != time.Second {
+		t.Errorf("unexpected durations: %v %v", v.Timeout.Duration(), v.Poll.Duration())
+	}
+
+	// 序列化保持为整数，与之前的配置和API输出相同
+	data, _ := json.Marshal(v)
+	if string(data) != `{"reserve":2048,"limit":512,"timeout":600,"poll":1000}` {
+		t.Errorf("Marshal = %s", data)
+	}
+
+	for _, input := range []string{`{"reserve":"2x"}`, `{"timeout":true}`, `{"timeout":"1.5s"}`} {
+		if err := json.Unmarshal([]byte(input), &v); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded", input)
+		}
+	}
+}
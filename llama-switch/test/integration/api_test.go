@@ -0,0 +1,1821 @@
+//go:build integration
+
+package integration
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSwitchProxyAndStop(t *testing.T) {
+	h := newHarness(t, 8000)
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	code, resp := h.api(http.MethodGet, "/api/v1/models", nil)
+	if code != http.StatusOK || !strings.Contains(string(resp.Data), "chat.gguf") {
+		t.Fatalf("model list missing chat.gguf (%d): %s", code, resp.Data)
+	}
+
+	if _, resp := h.switchModel("chat", "chat.gguf", false, nil); !resp.Success {
+		t.Fatalf("switch failed: %s", resp.Error)
+	}
+	if !h.runningModels()["chat"] {
+		t.Fatal("chat not reported as running")
+	}
+
+	// 通过推理代理转发到模拟实例
+	code, reply := h.chat("chat")
+	if code != http.StatusOK || !strings.Contains(reply, "chat.gguf") {
+		t.Fatalf("proxied chat failed (%d): %s", code, reply)
+	}
+
+	// 实例输出写入模型日志
+	code, resp = h.api(http.MethodGet, "/api/v1/model/chat/logs?tail=10", nil)
+	if code != http.StatusOK || !strings.Contains(string(resp.Data), "mockllama: serving chat.gguf") {
+		t.Fatalf("model logs missing startup line (%d): %s", code, resp.Data)
+	}
+
+	code, data := h.do(http.MethodGet, "/v1/models", nil)
+	if code != http.StatusOK || !strings.Contains(string(data), `"chat"`) {
+		t.Fatalf("/v1/models missing chat (%d): %s", code, data)
+	}
+
+	// 刚处理过请求的模型需要force或drain才能停止
+	code, resp = h.api(http.MethodPost, "/api/v1/model/stop", map[string]interface{}{"model_name": "chat"})
+	if code != http.StatusConflict || !strings.Contains(string(resp.Data), `"active_sessions":0`) {
+		t.Fatalf("expected 409 for recently used model, got %d: %s", code, resp.Data)
+	}
+	if !h.runningModels()["chat"] {
+		t.Fatal("chat stopped despite switch guard")
+	}
+
+	// 排空：没有进行中的请求时立即停止
+	if code, resp := h.api(http.MethodPost, "/api/v1/model/stop", map[string]interface{}{"model_name": "chat", "drain": true, "drain_timeout": 5}); code != http.StatusOK {
+		t.Fatalf("stop failed (%d): %s", code, resp.Error)
+	}
+	if h.runningModels()["chat"] {
+		t.Fatal("chat still running after stop")
+	}
+	if code, reply := h.chat("chat"); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for stopped model, got %d: %s", code, reply)
+	}
+}
+
+func TestSwitchReportsStartupFailure(t *testing.T) {
+	h := newHarness(t, 8000)
+	h.createModel("broken.gguf", 1)
+	h.start()
+
+	// 实例在就绪前退出时返回失败原因和实例输出
+	code, resp := h.api(http.MethodPost, "/api/v1/model/switch", map[string]interface{}{
+		"model_name": "broken",
+		"model_path": "broken.gguf",
+		"config":     map[string]interface{}{"host": "127.0.0.1", "port": freePort(t)},
+	})
+	var failure struct {
+		Reason     string   `json:"reason"`
+		ExitStatus string   `json:"exit_status"`
+		LastOutput []string `json:"last_output"`
+	}
+	json.Unmarshal(resp.Data, &failure)
+	if code != http.StatusBadGateway || resp.Code != "MODEL_STARTUP_FAILED" || failure.Reason != "exited" || failure.ExitStatus == "" {
+		t.Fatalf("unexpected startup failure response (%d): %s", code, resp.Data)
+	}
+	if !strings.Contains(strings.Join(failure.LastOutput, "\n"), "invalid magic") {
+		t.Fatalf("startup failure missing instance output: %v", failure.LastOutput)
+	}
+	if h.runningModels()["broken"] {
+		t.Fatal("failed model reported as running")
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	h := newHarness(t, 8000)
+	h.start()
+
+	// 错误以application/problem+json返回，带有稳定的错误码
+	req, _ := http.NewRequest(http.MethodPost, h.baseURL+"/api/v1/model/stop", strings.NewReader(`{"model_name":"missing"}`))
+	req.Header.Set("Content-Type", "application/json")
+	res, err := h.httpClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var problem struct {
+		Type    string `json:"type"`
+		Status  int    `json:"status"`
+		Detail  string `json:"detail"`
+		Code    string `json:"code"`
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+	json.NewDecoder(res.Body).Decode(&problem)
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound || res.Header.Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("stop of unknown model returned %d (%s)", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	if problem.Code != "MODEL_NOT_FOUND" || problem.Status != http.StatusNotFound || problem.Type != "urn:llama-switch:error:MODEL_NOT_FOUND" ||
+		problem.Success || problem.Error == "" || problem.Error != problem.Detail {
+		t.Fatalf("unexpected problem: %+v", problem)
+	}
+
+	// 模型文件不存在
+	code, resp := h.api(http.MethodPost, "/api/v1/model/switch", map[string]interface{}{
+		"model_name": "ghost",
+		"model_path": "ghost.gguf",
+		"config":     map[string]interface{}{"host": "127.0.0.1", "port": freePort(t)},
+	})
+	if code != http.StatusNotFound || resp.Code != "MODEL_FILE_NOT_FOUND" {
+		t.Fatalf("expected MODEL_FILE_NOT_FOUND, got %d %s: %s", code, resp.Code, resp.Error)
+	}
+
+	code, resp = h.api(http.MethodGet, "/api/v1/benchmark/status?task_id=unknown-task", nil)
+	if code != http.StatusNotFound || resp.Code != "BENCHMARK_NOT_FOUND" {
+		t.Fatalf("expected BENCHMARK_NOT_FOUND, got %d %s: %s", code, resp.Code, resp.Error)
+	}
+
+	// 没有具体错误码的请求错误使用通用错误码
+	code, resp = h.api(http.MethodPost, "/api/v1/model/switch", map[string]interface{}{})
+	if code != http.StatusBadRequest || resp.Code != "INVALID_REQUEST" {
+		t.Fatalf("expected INVALID_REQUEST, got %d %s: %s", code, resp.Code, resp.Error)
+	}
+}
+
+func TestSwitchDryRun(t *testing.T) {
+	h := newHarness(t, 8000)
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	port := freePort(t)
+	code, resp := h.api(http.MethodPost, "/api/v1/model/switch?dry_run=true", map[string]interface{}{
+		"model_name": "chat",
+		"model_path": "chat.gguf",
+		"env":        map[string]string{"CUDA_VISIBLE_DEVICES": "1"},
+		"config":     map[string]interface{}{"host": "127.0.0.1", "port": port, "ctx_size": 4096},
+	})
+	var preview struct {
+		Binary string   `json:"binary"`
+		Args   []string `json:"args"`
+		Env    []string `json:"env"`
+	}
+	json.Unmarshal(resp.Data, &preview)
+	if code != http.StatusOK || preview.Binary == "" {
+		t.Fatalf("unexpected dry run response (%d): %s", code, resp.Data)
+	}
+	args := strings.Join(preview.Args, " ")
+	for _, want := range []string{"--port " + strconv.Itoa(port), "--ctx-size 4096", "chat.gguf"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("dry run args missing %q: %v", want, preview.Args)
+		}
+	}
+	if !slices.Contains(preview.Env, "CUDA_VISIBLE_DEVICES=1") {
+		t.Errorf("dry run env missing CUDA_VISIBLE_DEVICES: %v", preview.Env)
+	}
+	if h.runningModels()["chat"] {
+		t.Fatal("dry run started the model")
+	}
+}
+
+func TestUnsupportedFlagRejected(t *testing.T) {
+	h := newHarness(t, 8000, "MOCK_LLAMA_BUILD=4000")
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	// 检测到的llama-server版本不支持--reasoning-format时，切换请求直接失败
+	code, resp := h.api(http.MethodPost, "/api/v1/model/switch", map[string]interface{}{
+		"model_name": "chat",
+		"model_path": "chat.gguf",
+		"config":     map[string]interface{}{"host": "127.0.0.1", "port": freePort(t), "reasoning_format": "deepseek"},
+	})
+	if code != http.StatusBadRequest || !strings.Contains(resp.Message, "unsupported by your llama-server build b4000") {
+		t.Fatalf("expected unsupported flag error, got %d: %s", code, resp.Message)
+	}
+	if h.runningModels()["chat"] {
+		t.Fatal("model started with an unsupported flag")
+	}
+}
+
+func TestBackendProfiles(t *testing.T) {
+	mockServer := filepath.Join(binDir, exeName("llama-server"))
+	h := newHarness(t, 8000, "LLAMA_SERVER_PROFILES=cpu="+mockServer)
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	code, resp := h.api(http.MethodGet, "/api/v1/capabilities", nil)
+	var caps struct {
+		BackendProfiles map[string]*struct {
+			Build int `json:"build"`
+		} `json:"backend_profiles"`
+	}
+	json.Unmarshal(resp.Data, &caps)
+	if code != http.StatusOK || caps.BackendProfiles["cpu"] == nil || caps.BackendProfiles["cpu"].Build != 5000 {
+		t.Fatalf("capabilities missing detected backend profile (%d): %s", code, resp.Data)
+	}
+
+	// 未配置的构建在启动前被拒绝
+	code, resp = h.api(http.MethodPost, "/api/v1/model/switch", map[string]interface{}{
+		"model_name":      "chat",
+		"model_path":      "chat.gguf",
+		"backend_profile": "vulkan",
+		"config":          map[string]interface{}{"host": "127.0.0.1", "port": freePort(t)},
+	})
+	if code != http.StatusBadRequest || !strings.Contains(resp.Message, "unknown backend profile") {
+		t.Fatalf("expected unknown profile error, got %d: %s", code, resp.Message)
+	}
+
+	port := freePort(t)
+	code, resp = h.api(http.MethodPost, "/api/v1/model/switch", map[string]interface{}{
+		"model_name":      "chat",
+		"model_path":      "chat.gguf",
+		"backend_profile": "cpu",
+		"config":          map[string]interface{}{"host": "127.0.0.1", "port": port},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("switch with backend profile failed (%d): %s", code, resp.Message)
+	}
+	h.waitModel(port)
+	var switched struct {
+		Model struct {
+			Backend string `json:"backend_profile"`
+		} `json:"model"`
+	}
+	json.Unmarshal(resp.Data, &switched)
+	if switched.Model.Backend != "cpu" {
+		t.Errorf("expected status to report backend profile cpu, got %s", resp.Data)
+	}
+}
+
+func TestWatchdogRestartsStuckModel(t *testing.T) {
+	h := newHarness(t, 8000,
+		"HEALTH_CHECK_INTERVAL=1",
+		"HEALTH_CHECK_TIMEOUT=1",
+		"WATCHDOG_FAILURES=2",
+		"EVENTS_FILE="+filepath.Join(t.TempDir(), "events.jsonl"))
+	h.createModel("stuck.gguf", 1)
+	h.start()
+
+	if _, resp := h.switchModel("stuck", "stuck.gguf", false, map[string]interface{}{"metrics": true}); !resp.Success {
+		t.Fatalf("switch failed: %s", resp.Message)
+	}
+
+	// 实例/slots不响应，连续两次探测失败后被结束并重新启动
+	type event struct {
+		Type string `json:"type"`
+		Data struct {
+			Metrics string `json:"metrics"`
+		} `json:"data"`
+	}
+	deadline := time.Now().Add(20 * time.Second)
+	for {
+		_, resp := h.api(http.MethodGet, "/api/v1/events?model_name=stuck", nil)
+		var events []event
+		json.Unmarshal(resp.Data, &events)
+		types := make([]string, len(events))
+		for i, e := range events {
+			types[i] = e.Type
+		}
+		if len(events) >= 2 && events[0].Type == "watchdog_kill" && events[1].Type == "watchdog_restart" {
+			if !strings.Contains(events[0].Data.Metrics, "llamacpp:prompt_tokens_total") {
+				t.Errorf("expected metrics in diagnostic snapshot, got %q", events[0].Data.Metrics)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("watchdog did not restart stuck model, events: %v", types)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	if !h.runningModels()["stuck"] {
+		t.Error("expected stuck model to be running again after restart")
+	}
+}
+
+func TestMeasuredVRAMUsage(t *testing.T) {
+	h := newHarness(t, 8000, "MOCK_LLAMA_VRAM_MB=1234")
+	h.createModel("chat.gguf", 3000)
+	h.start()
+
+	// 估算值为500+10*200=2500MB，加载后替换为nvidia-smi报告的实际占用
+	_, resp := h.switchModel("chat", "chat.gguf", false, gpuLayers(10))
+	var switched struct {
+		Model struct {
+			VRAMUsage    int  `json:"vram_usage"`
+			VRAMMeasured bool `json:"vram_measured"`
+		} `json:"model"`
+	}
+	json.Unmarshal(resp.Data, &switched)
+	if !resp.Success || switched.Model.VRAMUsage != 1234 || !switched.Model.VRAMMeasured {
+		t.Fatalf("expected measured VRAM usage of 1234MB, got: %s", resp.Data)
+	}
+}
+
+func TestPerGPUPlacement(t *testing.T) {
+	h := newHarness(t, 0, "MOCK_GPU_TOTAL_MB=3000,3000")
+	h.createModel("big.gguf", 5000)
+	h.createModel("chat.gguf", 3000)
+	h.createModel("code.gguf", 3000)
+	h.start()
+
+	placement := func(resp *apiResponse) []int {
+		var switched struct {
+			Model struct {
+				GPUs []int `json:"gpus"`
+			} `json:"model"`
+		}
+		json.Unmarshal(resp.Data, &switched)
+		return switched.Model.GPUs
+	}
+
+	// 500+20*200=4500MB放不下单个GPU，跨GPU运行
+	_, resp := h.switchModel("big", "big.gguf", false, gpuLayers(20))
+	if !resp.Success || len(placement(resp)) != 0 {
+		t.Fatalf("expected big model to span GPUs, got: %s %s", resp.Error, resp.Data)
+	}
+	if code, resp := h.api(http.MethodPost, "/api/v1/model/stop", map[string]interface{}{"model_name": "big"}); code != http.StatusOK {
+		t.Fatalf("stop failed (%d): %s", code, resp.Error)
+	}
+
+	// 2500MB的模型先放到GPU0，GPU0剩余500MB后下一个模型放到GPU1
+	_, resp = h.switchModel("chat", "chat.gguf", false, gpuLayers(10))
+	if gpus := placement(resp); !resp.Success || len(gpus) != 1 || gpus[0] != 0 {
+		t.Fatalf("expected chat on GPU 0, got: %s %s", resp.Error, resp.Data)
+	}
+	_, resp = h.switchModel("code", "code.gguf", false, gpuLayers(10))
+	if gpus := placement(resp); !resp.Success || len(gpus) != 1 || gpus[0] != 1 {
+		t.Fatalf("expected code on GPU 1, got: %s %s", resp.Error, resp.Data)
+	}
+
+	// GPU清单由后台采样刷新，列出每个GPU上的模型
+	var inventory struct {
+		GPUs []struct {
+			Index        int      `json:"index"`
+			MemoryFreeMB int      `json:"memory_free_mb"`
+			TemperatureC *float64 `json:"temperature_c"`
+			Models       []string `json:"models"`
+		} `json:"gpus"`
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		code, resp := h.api(http.MethodGet, "/api/v1/gpu", nil)
+		if code != http.StatusOK {
+			t.Fatalf("gpu inventory failed (%d): %s", code, resp.Error)
+		}
+		json.Unmarshal(resp.Data, &inventory)
+		if len(inventory.GPUs) == 2 && slices.Equal(inventory.GPUs[0].Models, []string{"chat"}) && slices.Equal(inventory.GPUs[1].Models, []string{"code"}) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("gpu inventory does not list placed models: %s", resp.Data)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	if gpu := inventory.GPUs[1]; gpu.MemoryFreeMB != 500 || gpu.TemperatureC == nil {
+		t.Errorf("unexpected GPU 1 state: %+v", gpu)
+	}
+}
+
+func TestEmbeddingsRouting(t *testing.T) {
+	h := newHarness(t, 8000)
+	h.createModel("chat.gguf", 1)
+	h.createModel("embed.gguf", 1)
+	h.start()
+
+	h.switchModel("chat", "chat.gguf", false, nil)
+	if _, resp := h.switchModel("embed", "embed.gguf", false, map[string]interface{}{"embedding": true}); !resp.Success {
+		t.Fatalf("switch embed failed: %s", resp.Error)
+	}
+
+	// 未指定模型时路由到唯一的嵌入模型
+	code, data := h.do(http.MethodPost, "/v1/embeddings", map[string]interface{}{"input": []string{"a", "b"}})
+	var embeddings struct {
+		Model string            `json:"model"`
+		Data  []json.RawMessage `json:"data"`
+	}
+	json.Unmarshal(data, &embeddings)
+	if code != http.StatusOK || embeddings.Model != "embed.gguf" || len(embeddings.Data) != 2 {
+		t.Fatalf("unexpected embeddings response (%d): %s", code, data)
+	}
+
+	// 未启用embedding的模型被拒绝
+	if code, data := h.do(http.MethodPost, "/v1/embeddings", map[string]interface{}{"model": "chat", "input": "a"}); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for non-embedding model, got %d: %s", code, data)
+	}
+}
+
+func TestRerankStartsDefaultReranker(t *testing.T) {
+	port := freePort(t)
+	h := newHarness(t, 8000,
+		"RERANK_DEFAULT_MODEL=rerank.gguf",
+		"RERANK_DEFAULT_NAME=reranker",
+		"RERANK_DEFAULT_PORT="+strconv.Itoa(port),
+	)
+	h.createModel("rerank.gguf", 1)
+	h.start()
+
+	// 没有重排序模型运行时自动启动默认重排序模型
+	code, data := h.do(http.MethodPost, "/v1/rerank", map[string]interface{}{
+		"query":     "hello",
+		"documents": []string{"a", "b", "c"},
+	})
+	var rerank struct {
+		Model   string            `json:"model"`
+		Results []json.RawMessage `json:"results"`
+	}
+	json.Unmarshal(data, &rerank)
+	if code != http.StatusOK || rerank.Model != "rerank.gguf" || len(rerank.Results) != 3 {
+		t.Fatalf("unexpected rerank response (%d): %s", code, data)
+	}
+	if !h.runningModels()["reranker"] {
+		t.Fatal("default reranker not running")
+	}
+}
+
+func TestRestoreAfterRestart(t *testing.T) {
+	h := newHarness(t, 8000)
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	port, resp := h.switchModel("chat", "chat.gguf", false, nil)
+	if !resp.Success {
+		t.Fatalf("switch failed: %s", resp.Error)
+	}
+
+	// 重启后之前运行的模型自动恢复
+	h.restart()
+	h.waitModel(port)
+	if !h.runningModels()["chat"] {
+		t.Fatal("chat not restored after restart")
+	}
+	if code, reply := h.chat("chat"); code != http.StatusOK {
+		t.Fatalf("proxied chat after restore failed (%d): %s", code, reply)
+	}
+}
+
+func TestEvictionFreesVRAM(t *testing.T) {
+	// 模拟3000MB显存，每个模型占用2000MB
+	h := newHarness(t, 3000)
+	h.createModel("a.gguf", 2000)
+	h.createModel("b.gguf", 2000)
+	h.start()
+
+	if _, resp := h.switchModel("a", "a.gguf", false, gpuLayers(10)); !resp.Success {
+		t.Fatalf("switch a failed: %s", resp.Error)
+	}
+
+	// 资源采样器按PID统计模拟实例的显存
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		code, resp := h.api(http.MethodGet, "/api/v1/model/a/resources", nil)
+		if code == http.StatusOK && strings.Contains(string(resp.Data), `"gpu_memory_mb":2000`) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("resource samples missing GPU memory (%d): %s", code, resp.Data)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	// 模型状态中的CPU和内存使用取自资源采样器
+	code, resp := h.api(http.MethodGet, "/api/v1/model/status?model_name=a", nil)
+	var status struct {
+		Performance map[string]string `json:"performance"`
+	}
+	if err := json.Unmarshal(resp.Data, &status); code != http.StatusOK || err != nil {
+		t.Fatalf("model status failed (%d): %s", code, resp.Data)
+	}
+	perf := status.Performance
+	if !strings.HasSuffix(perf["cpu_usage"], "%") || !strings.HasSuffix(perf["memory_usage"], "MB") || perf["memory_usage"] == "256MB" ||
+		perf["vram_usage"] != "2000MB" || perf["uptime"] == "n/a" {
+		t.Errorf("unexpected performance: %v", perf)
+	}
+
+	// 不强制时显存不足直接拒绝
+	if _, resp := h.switchModel("b", "b.gguf", false, gpuLayers(10)); resp.Success || !strings.Contains(resp.Error, "insufficient VRAM") {
+		t.Fatalf("expected insufficient VRAM error, got: %+v", resp)
+	}
+
+	// 强制使用显存时驱逐已运行的模型
+	if _, resp := h.switchModel("b", "b.gguf", true, gpuLayers(10)); !resp.Success {
+		t.Fatalf("forced switch b failed: %s", resp.Error)
+	}
+	running := h.runningModels()
+	if running["a"] || !running["b"] {
+		t.Fatalf("expected only b running after eviction, got %v", running)
+	}
+}
+
+func TestPinnedModelNotEvicted(t *testing.T) {
+	h := newHarness(t, 3000)
+	h.createModel("embed.gguf", 2000)
+	h.createModel("chat.gguf", 2000)
+	h.start()
+
+	port := freePort(t)
+	code, resp := h.api(http.MethodPost, "/api/v1/model/switch", map[string]interface{}{
+		"model_name": "embed",
+		"model_path": "embed.gguf",
+		"pinned":     true,
+		"config":     map[string]interface{}{"host": "127.0.0.1", "port": port, "n_gpu_layers": 10},
+	})
+	if code != http.StatusOK || !strings.Contains(string(resp.Data), `"pinned":true`) {
+		t.Fatalf("switch pinned model failed (%d): %s", code, resp.Data)
+	}
+	h.waitModel(port)
+
+	// 固定的模型即使强制驱逐也不会被停止
+	_, resp = h.switchModel("chat", "chat.gguf", true, gpuLayers(10))
+	if resp.Success || !strings.Contains(resp.Error, "pinned models are never evicted: embed") {
+		t.Fatalf("expected pinned model to block eviction, got: %+v", resp)
+	}
+	if !h.runningModels()["embed"] {
+		t.Fatal("pinned model was evicted")
+	}
+}
+
+func TestGPUThrottleEvent(t *testing.T) {
+	h := newHarness(t, 8000,
+		"MOCK_GPU_TEMP_C=95",
+		"GPU_THROTTLE_SAMPLES=2",
+		"EVENTS_FILE="+filepath.Join(t.TempDir(), "events.jsonl"))
+	h.start()
+
+	// 温度超过GPU_TEMP_LIMIT_C（默认85）连续两次采样后记录告警
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		_, resp := h.api(http.MethodGet, "/api/v1/events", nil)
+		if strings.Contains(string(resp.Data), `"type":"gpu_throttle"`) {
+			if !strings.Contains(string(resp.Data), "over_temperature") {
+				t.Errorf("expected throttle reason in event, got %s", resp.Data)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no gpu_throttle event recorded: %s", resp.Data)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	_, resp := h.api(http.MethodGet, "/api/v1/gpu", nil)
+	if !strings.Contains(string(resp.Data), `"power_w":150`) {
+		t.Errorf("expected power draw in GPU inventory, got %s", resp.Data)
+	}
+}
+
+func TestConcurrentSwitchReservesVRAM(t *testing.T) {
+	h := newHarness(t, 3000, "MOCK_LLAMA_LOAD_DELAY_MS=1000")
+	h.createModel("a.gguf", 2000)
+	h.createModel("b.gguf", 2000)
+	h.start()
+
+	// 加载期间显存尚未被占用，第二个请求应看到第一个模型的预留而失败
+	var wg sync.WaitGroup
+	results := make([]*apiResponse, 2)
+	for i, name := range []string{"a", "b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, results[i] = h.switchModel(name, name+".gguf", false, gpuLayers(10))
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, resp := range results {
+		if resp.Success {
+			succeeded++
+		} else if !strings.Contains(resp.Error, "insufficient VRAM") {
+			t.Errorf("unexpected failure: %+v", resp)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("expected exactly one switch to succeed, got %d: %+v", succeeded, results)
+	}
+}
+
+func TestVRAMHistory(t *testing.T) {
+	h := newHarness(t, 8000, "VRAM_HISTORY_INTERVAL=1")
+	h.createModel("chat.gguf", 2000)
+	h.start()
+
+	if _, resp := h.switchModel("chat", "chat.gguf", false, gpuLayers(5)); !resp.Success {
+		t.Fatalf("switch failed: %+v", resp)
+	}
+
+	// 资源采样每秒写入一条显存样本（模拟实例占用500MB + 5层*200MB）
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		code, resp := h.api(http.MethodGet, "/api/v1/model/chat/vram/history", nil)
+		var history struct {
+			Samples []struct {
+				VRAMMB int `json:"vram_mb"`
+			} `json:"samples"`
+			Trend struct {
+				Samples  int `json:"samples"`
+				LatestMB int `json:"latest_mb"`
+			} `json:"trend"`
+		}
+		json.Unmarshal(resp.Data, &history)
+		if code == http.StatusOK && len(history.Samples) >= 2 {
+			if history.Trend.LatestMB != 1500 || history.Trend.Samples != len(history.Samples) {
+				t.Errorf("unexpected VRAM history: %s", resp.Data)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no VRAM history recorded (%d): %s", code, resp.Data)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	if code, _ := h.api(http.MethodGet, "/api/v1/model/chat/vram/history?since=yesterday", nil); code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid since, got %d", code)
+	}
+}
+
+func TestRAMAdmission(t *testing.T) {
+	h := newHarness(t, 3000, "RAM_RESERVE_MB=100000000")
+	h.createModel("cpu.gguf", 10)
+	h.start()
+
+	// CPU推理的模型在可用内存不足时被拒绝
+	_, resp := h.switchModel("cpu", "cpu.gguf", false, gpuLayers(0))
+	if resp.Success || !strings.Contains(resp.Error, "insufficient RAM") {
+		t.Fatalf("expected insufficient RAM error, got: %+v", resp)
+	}
+
+	// 卸载到GPU且无法读取GGUF元数据的模型不检查主机内存
+	port, resp := h.switchModel("cpu", "cpu.gguf", false, gpuLayers(10))
+	if !resp.Success {
+		t.Fatalf("switch offloaded model failed: %+v", resp)
+	}
+	h.waitModel(port)
+}
+
+func TestAutoGPULayersRequiresGGUF(t *testing.T) {
+	h := newHarness(t, 3000)
+	h.createModel("plain.gguf", 10)
+	h.start()
+
+	// 模拟模型文件不含GGUF元数据，无法计算层数
+	_, resp := h.switchModel("plain", "plain.gguf", false, map[string]interface{}{"n_gpu_layers": "auto"})
+	if resp.Success || !strings.Contains(resp.Error, "n_gpu_layers=auto requires GGUF metadata") {
+		t.Fatalf("expected GGUF metadata error, got: %+v", resp)
+	}
+
+	_, resp = h.switchModel("plain", "plain.gguf", false, map[string]interface{}{"n_gpu_layers": "all"})
+	if resp.Success {
+		t.Fatalf("expected invalid n_gpu_layers to be rejected, got: %+v", resp)
+	}
+}
+
+// gpuLayers 构建只设置GPU层数的模型参数
+func gpuLayers(n int) map[string]interface{} {
+	return map[string]interface{}{"n_gpu_layers": n}
+}
+
+func TestRPCPool(t *testing.T) {
+	// 模拟的rpc-server端点只需要接受连接
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	up := listener.Addr().String()
+	down := fmt.Sprintf("127.0.0.1:%d", freePort(t))
+
+	h := newHarness(t, 3000, "RPC_POOLS=lab="+up+"@4000+"+down+"@4000")
+	h.createModel("big.gguf", 4000)
+	h.start()
+
+	// 本地显存放不下，使用RPC池时池中健康端点的显存计入容量
+	_, resp := h.switchModel("big", "big.gguf", false, gpuLayers(20))
+	if resp.Success || !strings.Contains(resp.Error, "insufficient VRAM") {
+		t.Fatalf("expected insufficient VRAM without RPC pool, got: %+v", resp)
+	}
+	port := freePort(t)
+	code, resp := h.api(http.MethodPost, "/api/v1/model/switch", map[string]interface{}{
+		"model_name": "big",
+		"model_path": "big.gguf",
+		"rpc_pool":   "lab",
+		"config":     map[string]interface{}{"host": "127.0.0.1", "port": port, "n_gpu_layers": 20},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("switch with RPC pool failed (%d): %+v", code, resp)
+	}
+	h.waitModel(port)
+
+	_, resp = h.api(http.MethodGet, "/api/v1/rpc/pools", nil)
+	var pools []struct {
+		Name      string `json:"name"`
+		Endpoints []struct {
+			Address string `json:"address"`
+			Healthy bool   `json:"healthy"`
+		} `json:"endpoints"`
+		TotalMB int      `json:"total_mb"`
+		UsedMB  int      `json:"used_mb"`
+		Models  []string `json:"models"`
+	}
+	json.Unmarshal(resp.Data, &pools)
+	if len(pools) != 1 || len(pools[0].Endpoints) != 2 || !pools[0].Endpoints[0].Healthy || pools[0].Endpoints[1].Healthy {
+		t.Fatalf("unexpected RPC pools: %s", resp.Data)
+	}
+	if pools[0].TotalMB != 4000 || pools[0].UsedMB <= 0 || !slices.Contains(pools[0].Models, "big") {
+		t.Errorf("unexpected RPC pool usage: %s", resp.Data)
+	}
+
+	_, resp = h.api(http.MethodPost, "/api/v1/model/switch", map[string]interface{}{
+		"model_name": "other",
+		"model_path": "big.gguf",
+		"rpc_pool":   "missing",
+		"config":     map[string]interface{}{"host": "127.0.0.1", "port": freePort(t)},
+	})
+	if resp.Success || !strings.Contains(resp.Error+resp.Message, "unknown RPC pool") {
+		t.Errorf("expected unknown RPC pool error, got: %+v", resp)
+	}
+}
+
+func TestTenantQuota(t *testing.T) {
+	h := newHarness(t, 8000, "TENANT_API_KEYS=team-a=key-a,team-b=key-b", "TENANT_VRAM_QUOTAS=team-a=2500")
+	h.createModel("small.gguf", 1500)
+	h.createModel("big.gguf", 3000)
+	h.start()
+
+	// 配置了租户时切换请求必须携带有效的密钥
+	code, _ := h.api(http.MethodPost, "/api/v1/model/switch", map[string]interface{}{"model_name": "a1", "model_path": "small.gguf"})
+	if code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without API key, got %d", code)
+	}
+
+	h.apiKey = "key-b"
+	if _, resp := h.switchModel("b1", "big.gguf", false, gpuLayers(20)); !resp.Success {
+		t.Fatalf("switch b1 failed: %+v", resp)
+	}
+	h.apiKey = "key-a"
+	if _, resp := h.switchModel("a1", "small.gguf", false, gpuLayers(20)); !resp.Success {
+		t.Fatalf("switch a1 failed: %+v", resp)
+	}
+
+	// 超过配额时拒绝，force_vram时只驱逐该租户自己的模型
+	_, resp := h.switchModel("a2", "small.gguf", false, gpuLayers(20))
+	if resp.Success || !strings.Contains(resp.Error, "tenant team-a VRAM quota exceeded") {
+		t.Fatalf("expected quota error, got: %+v", resp)
+	}
+	if _, resp := h.switchModel("a2", "small.gguf", true, gpuLayers(20)); !resp.Success {
+		t.Fatalf("forced switch a2 failed: %+v", resp)
+	}
+	running := h.runningModels()
+	if running["a1"] || !running["a2"] || !running["b1"] {
+		t.Fatalf("expected only team-a's model to be evicted, running: %v", running)
+	}
+
+	_, resp = h.api(http.MethodGet, "/api/v1/tenants", nil)
+	var tenants []struct {
+		Name    string   `json:"name"`
+		QuotaMB int      `json:"quota_mb"`
+		UsedMB  int      `json:"used_mb"`
+		Models  []string `json:"models"`
+	}
+	json.Unmarshal(resp.Data, &tenants)
+	if len(tenants) != 2 || tenants[0].Name != "team-a" || tenants[0].QuotaMB != 2500 ||
+		!slices.Equal(tenants[0].Models, []string{"a2"}) || !slices.Equal(tenants[1].Models, []string{"b1"}) {
+		t.Errorf("unexpected tenants: %s", resp.Data)
+	}
+}
+
+func TestRoleBasedAccess(t *testing.T) {
+	h := newHarness(t, 8000, "API_KEY=admin-key", "API_KEYS=viewer=ro-key,ops=op-key", "API_KEY_ROLES=ops=operator")
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	// 启用认证后API路由需要有效的密钥，健康检查不需要
+	if code, _ := h.api(http.MethodGet, "/api/v1/models", nil); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without API key, got %d", code)
+	}
+	h.apiKey = "wrong-key"
+	if code, _ := h.api(http.MethodGet, "/api/v1/models", nil); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with an unknown API key, got %d", code)
+	}
+	if code, _ := h.do(http.MethodGet, "/health", nil); code != http.StatusOK {
+		t.Fatalf("expected /health to stay public, got %d", code)
+	}
+
+	defaults := map[string]interface{}{"defaults": map[string]interface{}{"default_model": map[string]interface{}{"ctx_size": 8192}}}
+	tests := []struct {
+		key, method, path string
+		payload           interface{}
+		want              int
+	}{
+		{"ro-key", http.MethodGet, "/api/v1/model/status", nil, http.StatusOK},
+		{"ro-key", http.MethodGet, "/api/v1/config", nil, http.StatusOK},
+		{"ro-key", http.MethodPost, "/api/v1/model/stop", map[string]interface{}{"model_name": "chat"}, http.StatusForbidden},
+		{"ro-key", http.MethodPatch, "/api/v1/config/defaults", defaults, http.StatusForbidden},
+		{"op-key", http.MethodPatch, "/api/v1/config/defaults", defaults, http.StatusForbidden},
+		{"op-key", http.MethodPost, "/api/v1/admin/reload", nil, http.StatusForbidden},
+		{"admin-key", http.MethodPatch, "/api/v1/config/defaults", defaults, http.StatusOK},
+	}
+	for _, tt := range tests {
+		h.apiKey = tt.key
+		if code, resp := h.api(tt.method, tt.path, tt.payload); code != tt.want {
+			t.Errorf("%s %s with %s = %d (%s), want %d", tt.method, tt.path, tt.key, code, resp.Error, tt.want)
+		}
+	}
+
+	// 只读密钥不能切换模型，操作员密钥可以切换和停止模型
+	h.apiKey = "ro-key"
+	if code, _ := h.api(http.MethodPost, "/api/v1/model/switch", map[string]interface{}{"model_name": "chat", "model_path": "chat.gguf"}); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a read-only switch, got %d", code)
+	}
+	h.apiKey = "op-key"
+	if _, resp := h.switchModel("chat", "chat.gguf", false, nil); !resp.Success {
+		t.Fatalf("operator switch failed: %+v", resp)
+	}
+	if code, resp := h.api(http.MethodPost, "/api/v1/model/stop", map[string]interface{}{"model_name": "chat"}); code != http.StatusOK {
+		t.Fatalf("operator stop failed (%d): %s", code, resp.Error)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	h := newHarness(t, 8000, "RATE_LIMIT_PER_MINUTE=3", "RATE_LIMIT_SWITCH_PER_MINUTE=1")
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	for i := 0; i < 3; i++ {
+		if code, _ := h.api(http.MethodGet, "/api/v1/model/status", nil); code != http.StatusOK {
+			t.Fatalf("request %d = %d, want 200", i+1, code)
+		}
+	}
+	code, resp := h.api(http.MethodGet, "/api/v1/model/status", nil)
+	var data struct {
+		Limit      int `json:"limit"`
+		RetryAfter int `json:"retry_after"`
+	}
+	json.Unmarshal(resp.Data, &data)
+	if code != http.StatusTooManyRequests || resp.Success || data.Limit != 3 || data.RetryAfter <= 0 || data.RetryAfter > 20 {
+		t.Fatalf("request over the limit = %d %+v %s", code, resp, resp.Data)
+	}
+
+	// 切换请求单独计数，健康检查不受限制
+	if _, resp := h.switchModel("chat", "chat.gguf", false, nil); !resp.Success {
+		t.Fatalf("switch failed: %+v", resp)
+	}
+	if code, _ := h.api(http.MethodPost, "/api/v1/model/switch", map[string]interface{}{"model_name": "chat", "model_path": "chat.gguf"}); code != http.StatusTooManyRequests {
+		t.Errorf("second switch = %d, want 429", code)
+	}
+	if code, _ := h.do(http.MethodGet, "/health", nil); code != http.StatusOK {
+		t.Errorf("/health = %d, want 200", code)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	h := newHarness(t, 8000, "CORS_ALLOWED_ORIGINS=https://dash.example.com", "API_KEY=admin-key")
+	h.start()
+
+	request := func(method, origin string, header map[string]string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, h.baseURL+"/api/v1/model/status", nil)
+		req.Header.Set("Origin", origin)
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		resp, err := h.httpClient().Do(req)
+		if err != nil {
+			t.Fatalf("%s request failed: %v", method, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	// 预检请求不携带API密钥，在认证之前响应
+	resp := request(http.MethodOptions, "https://dash.example.com", map[string]string{
+		"Access-Control-Request-Method":  "GET",
+		"Access-Control-Request-Headers": "authorization",
+	})
+	if resp.StatusCode != http.StatusNoContent ||
+		resp.Header.Get("Access-Control-Allow-Origin") != "https://dash.example.com" ||
+		!strings.Contains(resp.Header.Get("Access-Control-Allow-Headers"), "Authorization") ||
+		!strings.Contains(resp.Header.Get("Access-Control-Allow-Methods"), "PATCH") {
+		t.Errorf("preflight = %d %v", resp.StatusCode, resp.Header)
+	}
+
+	resp = request(http.MethodGet, "https://dash.example.com", map[string]string{"Authorization": "Bearer admin-key"})
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Access-Control-Allow-Origin") != "https://dash.example.com" {
+		t.Errorf("GET from an allowed origin = %d %v", resp.StatusCode, resp.Header)
+	}
+
+	// 其他来源不返回CORS响应头，浏览器会拒绝读取响应
+	resp = request(http.MethodOptions, "https://evil.example.com", map[string]string{"Access-Control-Request-Method": "POST"})
+	if resp.Header.Get("Access-Control-Allow-Origin") != "" || resp.Header.Get("Access-Control-Allow-Methods") != "" {
+		t.Errorf("preflight from another origin returned CORS headers: %v", resp.Header)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	h := newHarness(t, 8000, "API_KEY=admin-key")
+	h.start()
+
+	// 文档无需API密钥即可获取
+	status, body := h.do(http.MethodGet, "/api/v1/openapi.json", nil)
+	if status != http.StatusOK {
+		t.Fatalf("GET /api/v1/openapi.json = %d: %s", status, body)
+	}
+	var doc struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("openapi = %q", doc.OpenAPI)
+	}
+	for _, op := range []string{"post /api/v1/model/switch", "get /api/v1/model/status", "post /api/v1/benchmark", "get /api/v1/benchmark/status", "delete /api/v1/benchmark/{task_id}",
+		"put /api/v2/models/{name}", "delete /api/v2/benchmarks/{id}", "get /health"} {
+		method, path, _ := strings.Cut(op, " ")
+		if _, ok := doc.Paths[path][method]; !ok {
+			t.Errorf("document is missing %s", op)
+		}
+	}
+	if _, ok := doc.Paths["/api/v1/timeshare"]; ok {
+		t.Error("document includes timeshare routes that are not registered")
+	}
+	if role := doc.Paths["/api/v1/model/switch"]["post"]["x-required-role"]; role != "operator" {
+		t.Errorf("switch x-required-role = %v", role)
+	}
+	if doc.Paths["/api/v1/model/switch"]["post"]["deprecated"] != true || doc.Paths["/api/v1/models"]["get"]["deprecated"] != nil {
+		t.Error("only v1 operations replaced by v2 should be deprecated")
+	}
+	if _, ok := doc.Paths["/api/v2/models/{name}"]["put"]["responses"].(map[string]interface{})["201"]; !ok {
+		t.Error("PUT /api/v2/models/{name} should document a 201 response")
+	}
+
+	// 所有引用都指向components中的定义
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				if _, exists := doc.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]; !exists {
+					t.Errorf("unresolved reference %s", ref)
+				}
+			}
+			for _, value := range v {
+				walk(value)
+			}
+		case []interface{}:
+			for _, value := range v {
+				walk(value)
+			}
+		}
+	}
+	var raw interface{}
+	json.Unmarshal(body, &raw)
+	walk(raw)
+	if _, ok := doc.Components.Schemas["ModelConfig"]; !ok {
+		t.Error("ModelConfig schema is missing")
+	}
+
+	status, body = h.do(http.MethodGet, "/docs", nil)
+	if status != http.StatusOK || !strings.Contains(string(body), "/api/v1/openapi.json") {
+		t.Errorf("GET /docs = %d: %s", status, body)
+	}
+}
+
+func TestAPIV2(t *testing.T) {
+	h := newHarness(t, 8000)
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	// v2直接返回资源，需要读取响应头
+	request := func(method, path string, payload interface{}) (*http.Response, []byte) {
+		t.Helper()
+		var body io.Reader
+		if payload != nil {
+			data, _ := json.Marshal(payload)
+			body = bytes.NewReader(data)
+		}
+		req, _ := http.NewRequest(method, h.baseURL+path, body)
+		res, err := h.httpClient().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer res.Body.Close()
+		data, _ := io.ReadAll(res.Body)
+		return res, data
+	}
+	type modelResource struct {
+		ModelName string `json:"model_name"`
+		Running   bool   `json:"running"`
+		Port      int    `json:"port"`
+		Requests  *struct {
+			Total int `json:"total"`
+		} `json:"requests"`
+	}
+
+	// 路径中的名称与请求体不一致
+	res, body := request(http.MethodPut, "/api/v2/models/chat", map[string]interface{}{"model_name": "other", "model_path": "chat.gguf"})
+	if res.StatusCode != http.StatusBadRequest {
+		t.Fatalf("mismatched model_name = %d: %s", res.StatusCode, body)
+	}
+
+	port := freePort(t)
+	res, body = request(http.MethodPut, "/api/v2/models/chat", map[string]interface{}{
+		"model_path": "chat.gguf",
+		"config":     map[string]interface{}{"host": "127.0.0.1", "port": port},
+	})
+	var created modelResource
+	json.Unmarshal(body, &created)
+	if res.StatusCode != http.StatusCreated || res.Header.Get("Location") != "/api/v2/models/chat" {
+		t.Fatalf("PUT /api/v2/models/chat = %d (Location %q): %s", res.StatusCode, res.Header.Get("Location"), body)
+	}
+	if created.ModelName != "chat" || !created.Running || created.Port != port || created.Requests == nil {
+		t.Fatalf("unexpected model resource: %s", body)
+	}
+	h.waitModel(port)
+
+	res, body = request(http.MethodGet, "/api/v2/models", nil)
+	var list struct {
+		Items []modelResource `json:"items"`
+	}
+	json.Unmarshal(body, &list)
+	if res.StatusCode != http.StatusOK || len(list.Items) != 1 || list.Items[0].ModelName != "chat" {
+		t.Fatalf("GET /api/v2/models = %d: %s", res.StatusCode, body)
+	}
+	if res.Header.Get("Deprecation") != "" {
+		t.Error("v2 response has a Deprecation header")
+	}
+
+	// 已被v2替代的v1路由带有弃用提示，其他v1路由不带
+	res, _ = request(http.MethodGet, "/api/v1/model/status?model_name=chat", nil)
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(res.Header.Get("Deprecation"), "@") ||
+		res.Header.Get("Link") != `</api/v2/models>; rel="successor-version"` {
+		t.Fatalf("v1 status headers: %d Deprecation=%q Link=%q", res.StatusCode, res.Header.Get("Deprecation"), res.Header.Get("Link"))
+	}
+	if res, _ = request(http.MethodGet, "/api/v1/models", nil); res.Header.Get("Deprecation") != "" {
+		t.Error("GET /api/v1/models has no v2 successor but is marked deprecated")
+	}
+
+	res, body = request(http.MethodDelete, "/api/v2/models/chat?force=true", nil)
+	var stopped modelResource
+	json.Unmarshal(body, &stopped)
+	if res.StatusCode != http.StatusOK || stopped.ModelName != "chat" {
+		t.Fatalf("DELETE /api/v2/models/chat = %d: %s", res.StatusCode, body)
+	}
+	if h.runningModels()["chat"] {
+		t.Fatal("chat still running after DELETE")
+	}
+	res, body = request(http.MethodGet, "/api/v2/models/missing", nil)
+	if res.StatusCode != http.StatusNotFound || res.Header.Get("Content-Type") != "application/problem+json" || !strings.Contains(string(body), "MODEL_NOT_FOUND") {
+		t.Fatalf("GET of unknown model = %d: %s", res.StatusCode, body)
+	}
+	if res, _ = request(http.MethodPost, "/api/v2/models/chat", nil); res.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/v2/models/chat = %d, want 405", res.StatusCode)
+	}
+
+	// 基准测试
+	res, body = request(http.MethodGet, "/api/v2/benchmarks", nil)
+	if res.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != `{"items":[],"total":0}` {
+		t.Fatalf("GET /api/v2/benchmarks = %d: %s", res.StatusCode, body)
+	}
+	res, body = request(http.MethodGet, "/api/v2/benchmarks/unknown-task", nil)
+	if res.StatusCode != http.StatusNotFound || !strings.Contains(string(body), "BENCHMARK_NOT_FOUND") {
+		t.Fatalf("GET unknown benchmark = %d: %s", res.StatusCode, body)
+	}
+	if res, body = request(http.MethodDelete, "/api/v2/benchmarks/unknown-task", nil); res.StatusCode != http.StatusNotFound {
+		t.Fatalf("DELETE unknown benchmark = %d: %s", res.StatusCode, body)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	h := newHarness(t, 8000)
+	h.createModel("chat.gguf", 1)
+	h.createModel("code.gguf", 1)
+	h.start()
+
+	send := func(method, path, key string, payload interface{}) (int, string, []byte) {
+		t.Helper()
+		data, _ := json.Marshal(payload)
+		req, _ := http.NewRequest(method, h.baseURL+path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+		resp, err := h.httpClient().Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header.Get("Idempotent-Replayed"), body
+	}
+
+	// 重试返回第一次请求的响应，不会得到MODEL_ALREADY_RUNNING
+	switchReq := map[string]interface{}{"model_name": "chat", "model_path": "chat.gguf"}
+	code, replayed, first := send(http.MethodPost, "/api/v1/model/switch", "switch-1", switchReq)
+	if code != http.StatusOK || replayed != "" {
+		t.Fatalf("first switch = %d (replayed %q): %s", code, replayed, first)
+	}
+	code, replayed, retry := send(http.MethodPost, "/api/v1/model/switch", "switch-1", switchReq)
+	if code != http.StatusOK || replayed != "true" || !bytes.Equal(first, retry) {
+		t.Fatalf("retried switch = %d (replayed %q): %s", code, replayed, retry)
+	}
+
+	// 相同的键用于不同的请求
+	code, _, body := send(http.MethodPost, "/api/v1/model/switch", "switch-1", map[string]interface{}{"model_name": "code", "model_path": "code.gguf"})
+	if code != http.StatusUnprocessableEntity || !strings.Contains(string(body), "IDEMPOTENCY_KEY_REUSED") {
+		t.Fatalf("reused key = %d: %s", code, body)
+	}
+
+	// 第一次请求仍在加载时，重试等待其结束并返回相同的响应，模型只启动一次
+	var wg sync.WaitGroup
+	results := make([][]byte, 2)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, _, body := send(http.MethodPut, "/api/v2/models/code", "put-code", map[string]interface{}{"model_path": "code.gguf"})
+			if code != http.StatusCreated {
+				t.Errorf("concurrent PUT = %d: %s", code, body)
+			}
+			results[i] = body
+		}()
+	}
+	wg.Wait()
+	if !bytes.Equal(results[0], results[1]) {
+		t.Errorf("concurrent retries got different responses:\n%s\n%s", results[0], results[1])
+	}
+	if running := h.runningModels(); !running["chat"] || !running["code"] {
+		t.Errorf("unexpected running models: %v", running)
+	}
+
+	// 停止请求同样可以重试
+	stopReq := map[string]interface{}{"model_name": "chat"}
+	if code, _, body := send(http.MethodPost, "/api/v1/model/stop", "stop-1", stopReq); code != http.StatusOK {
+		t.Fatalf("stop = %d: %s", code, body)
+	}
+	if code, replayed, body := send(http.MethodPost, "/api/v1/model/stop", "stop-1", stopReq); code != http.StatusOK || replayed != "true" {
+		t.Fatalf("retried stop = %d (replayed %q): %s", code, replayed, body)
+	}
+}
+
+func TestAsyncSwitch(t *testing.T) {
+	h := newHarness(t, 8000, "MOCK_LLAMA_LOAD_DELAY_MS=1500")
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	type operation struct {
+		ID       string  `json:"id"`
+		State    string  `json:"state"`
+		Stage    string  `json:"stage"`
+		Progress float64 `json:"progress"`
+		Model    *struct {
+			Port int `json:"port"`
+		} `json:"model"`
+		Error *struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	getOperation := func(id string) operation {
+		t.Helper()
+		code, resp := h.api(http.MethodGet, "/api/v1/operations/"+id, nil)
+		if code != http.StatusOK {
+			t.Fatalf("GET operation %s = %d: %s", id, code, resp.Error)
+		}
+		var op operation
+		json.Unmarshal(resp.Data, &op)
+		return op
+	}
+	waitOperation := func(id string) operation {
+		t.Helper()
+		deadline := time.Now().Add(15 * time.Second)
+		for {
+			op := getOperation(id)
+			if op.State == "ready" || op.State == "failed" || time.Now().After(deadline) {
+				return op
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	// 切换请求立即返回操作，加载期间可以看到实例输出的加载阶段
+	code, resp := h.api(http.MethodPost, "/api/v1/model/switch?async=true", map[string]interface{}{"model_name": "chat", "model_path": "chat.gguf"})
+	var op operation
+	json.Unmarshal(resp.Data, &op)
+	if code != http.StatusAccepted || op.ID == "" || op.State != "pending" {
+		t.Fatalf("async switch = %d: %s", code, resp.Data)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for op.State != "loading" || op.Stage != "loading tensors" {
+		if time.Now().After(deadline) {
+			t.Fatalf("operation never reported loading tensors: %+v", op)
+		}
+		time.Sleep(50 * time.Millisecond)
+		op = getOperation(op.ID)
+	}
+	if op = waitOperation(op.ID); op.State != "ready" || op.Progress != 100 || op.Model == nil {
+		t.Fatalf("operation did not become ready: %+v", op)
+	}
+	h.waitModel(op.Model.Port)
+
+	// 启动失败记录在操作中，校验失败仍然直接返回错误
+	code, resp = h.api(http.MethodPost, "/api/v1/model/switch?async=true", map[string]interface{}{"model_name": "ghost", "model_path": "ghost.gguf"})
+	json.Unmarshal(resp.Data, &op)
+	if code != http.StatusAccepted {
+		t.Fatalf("async switch of a missing file = %d: %s", code, resp.Error)
+	}
+	if op = waitOperation(op.ID); op.State != "failed" || op.Error == nil || op.Error.Code != "MODEL_FILE_NOT_FOUND" {
+		t.Errorf("failed operation = %+v", op)
+	}
+	if code, _ := h.api(http.MethodPost, "/api/v1/model/switch?async=true&dry_run=true", map[string]interface{}{"model_name": "chat", "model_path": "chat.gguf"}); code != http.StatusBadRequest {
+		t.Errorf("async dry run = %d, want 400", code)
+	}
+	if code, resp := h.api(http.MethodGet, "/api/v1/operations/unknown", nil); code != http.StatusNotFound || resp.Code != "OPERATION_NOT_FOUND" {
+		t.Errorf("unknown operation = %d %s", code, resp.Code)
+	}
+}
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	h := newHarness(t, 8000, "MOCK_LLAMA_LOAD_DELAY_MS=1500")
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	// 切换请求进行中时关闭switcher，请求应完成后才停止模型
+	port := freePort(t)
+	result := make(chan int, 1)
+	go func() {
+		code, _ := h.api(http.MethodPost, "/api/v1/model/switch", map[string]interface{}{
+			"model_name": "chat",
+			"model_path": "chat.gguf",
+			"config":     map[string]interface{}{"host": "127.0.0.1", "port": port},
+		})
+		result <- code
+	}()
+	time.Sleep(500 * time.Millisecond)
+	h.stop()
+
+	select {
+	case code := <-result:
+		if code != http.StatusOK {
+			t.Fatalf("in-flight switch = %d, want 200", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("in-flight switch did not return")
+	}
+
+	// 排空后模型实例被停止
+	if resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/health", port)); err == nil {
+		resp.Body.Close()
+		t.Errorf("model instance still running after shutdown")
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	h := newHarness(t, 8000, "API_KEY=admin-key", "API_KEYS=viewer=ro-key,ops=op-key", "API_KEY_ROLES=ops=operator")
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	// 操作员切换模型，只读密钥停止模型被拒绝，读取请求不记录
+	h.apiKey = "op-key"
+	if _, resp := h.switchModel("chat", "chat.gguf", false, map[string]interface{}{"api_key": "upstream-secret"}); !resp.Success {
+		t.Fatalf("switch failed: %+v", resp)
+	}
+	h.api(http.MethodGet, "/api/v1/model/status", nil)
+	h.apiKey = "ro-key"
+	if code, _ := h.api(http.MethodPost, "/api/v1/model/stop", map[string]string{"model_name": "chat"}); code != http.StatusForbidden {
+		t.Fatalf("read-only stop = %d, want 403", code)
+	}
+
+	// 查询审计日志需要管理员角色
+	if code, _ := h.api(http.MethodGet, "/api/v1/audit", nil); code != http.StatusForbidden {
+		t.Fatalf("read-only audit query = %d, want 403", code)
+	}
+	h.apiKey = "admin-key"
+	code, resp := h.api(http.MethodGet, "/api/v1/audit?model_name=chat", nil)
+	if code != http.StatusOK {
+		t.Fatalf("audit query = %d: %s", code, resp.Error)
+	}
+	var entries []struct {
+		Caller    string                 `json:"caller"`
+		Role      string                 `json:"role"`
+		Route     string                 `json:"route"`
+		ModelName string                 `json:"model_name"`
+		Request   map[string]interface{} `json:"request"`
+		Status    int                    `json:"status"`
+		Outcome   string                 `json:"outcome"`
+	}
+	json.Unmarshal(resp.Data, &entries)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %s", resp.Data)
+	}
+	switched, denied := entries[0], entries[1]
+	if switched.Caller != "key:ops" || switched.Role != "operator" || switched.Route != "/api/v1/model/switch" || switched.Outcome != "success" {
+		t.Errorf("switch entry = %+v", switched)
+	}
+	if config, _ := switched.Request["config"].(map[string]interface{}); config["api_key"] != "[redacted]" {
+		t.Errorf("secret not redacted in %v", switched.Request)
+	}
+	if denied.Caller != "key:viewer" || denied.Status != http.StatusForbidden || denied.Outcome != "denied" {
+		t.Errorf("denied entry = %+v", denied)
+	}
+
+	if code, resp := h.api(http.MethodGet, "/api/v1/audit?outcome=denied&caller=key:viewer", nil); code != http.StatusOK || !strings.Contains(string(resp.Data), "/api/v1/model/stop") {
+		t.Errorf("filtered audit query = %d: %s", code, resp.Data)
+	}
+}
+
+func TestAdminIPFilterAndProxyListener(t *testing.T) {
+	proxyPort := freePort(t)
+	h := newHarness(t, 8000, "ADMIN_ALLOWED_CIDRS=127.0.0.1", fmt.Sprintf("PROXY_PORT=%d", proxyPort))
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	// 推理代理只在单独的端口上提供
+	if _, resp := h.switchModel("chat", "chat.gguf", false, nil); !resp.Success {
+		t.Fatalf("switch failed: %+v", resp)
+	}
+	if code, _ := h.chat("chat"); code != http.StatusNotFound {
+		t.Errorf("proxy on the API port = %d, want 404", code)
+	}
+	proxyURL := fmt.Sprintf("http://127.0.0.1:%d", proxyPort)
+	body := strings.NewReader(`{"model":"chat","messages":[{"role":"user","content":"hello"}]}`)
+	resp, err := http.Post(proxyURL+"/v1/chat/completions", "application/json", body)
+	if err != nil {
+		t.Fatalf("proxy request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("proxy on the proxy port = %d, want 200", resp.StatusCode)
+	}
+	if resp, err := http.Get(proxyURL + "/api/v1/model/status"); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("management API on the proxy port = %d, want 404", resp.StatusCode)
+		}
+	}
+
+	// 不在允许列表中的客户端不能访问管理API，推理代理和健康检查不受影响
+	h.stop()
+	h.env = append(h.env, "ADMIN_ALLOWED_CIDRS=10.0.0.0/8")
+	h.start()
+	code, problem := h.api(http.MethodGet, "/api/v1/model/status", nil)
+	if code != http.StatusForbidden || problem.Code != "FORBIDDEN" {
+		t.Errorf("management API from a disallowed address = %d %s", code, problem.Code)
+	}
+	if code, _ := h.do(http.MethodGet, "/health", nil); code != http.StatusOK {
+		t.Errorf("health check from a disallowed address = %d", code)
+	}
+	if err := waitHealthy(proxyURL+"/health", 5*time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	h := newHarness(t, 8000)
+	h.createModel("alpha.gguf", 3)
+	h.createModel("beta.gguf", 1)
+	h.createModel("gamma.gguf", 4)
+	h.createModel("delta.gguf", 2)
+	h.start()
+
+	// 列表接口需要读取X-Total-Count响应头
+	list := func(path string) (int, string, []byte) {
+		t.Helper()
+		res, err := h.httpClient().Get(h.baseURL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return res.StatusCode, res.Header.Get("X-Total-Count"), body
+	}
+	names := func(body []byte) string {
+		t.Helper()
+		var resp struct {
+			Data []struct {
+				Name string `json:"name"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			t.Fatalf("invalid model list: %s", body)
+		}
+		var got []string
+		for _, m := range resp.Data {
+			got = append(got, m.Name)
+		}
+		return strings.Join(got, ",")
+	}
+
+	// 按大小降序排列后跳过第一个，取两个
+	code, total, body := list("/api/v1/models?sort=-size&limit=2&offset=1")
+	if code != http.StatusOK || total != "4" || names(body) != "alpha.gguf,delta.gguf" {
+		t.Fatalf("sorted page = %d (total %s): %s", code, total, body)
+	}
+	// 过滤后的总数
+	code, total, body = list("/api/v1/models?min_size=2&max_size=3MB&sort=name")
+	if code != http.StatusOK || total != "2" || names(body) != "alpha.gguf,delta.gguf" {
+		t.Fatalf("filtered list = %d (total %s): %s", code, total, body)
+	}
+	if code, _, body = list("/api/v1/models?sort=mtime"); code != http.StatusBadRequest || !strings.Contains(string(body), "invalid sort value") {
+		t.Errorf("unknown sort key = %d: %s", code, body)
+	}
+	if code, _, _ = list("/api/v1/models?limit=-1"); code != http.StatusBadRequest {
+		t.Errorf("negative limit = %d, want 400", code)
+	}
+
+	// 模型状态使用列表参数时总是返回数组
+	h.switchModel("beta", "beta.gguf", false, nil)
+	h.switchModel("alpha", "alpha.gguf", false, nil)
+	code, total, body = list("/api/v1/model/status?sort=name&limit=1")
+	var status struct {
+		Data []struct {
+			Model struct {
+				ModelName string `json:"model_name"`
+			} `json:"model"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &status); err != nil || code != http.StatusOK || total != "2" || len(status.Data) != 1 || status.Data[0].Model.ModelName != "alpha" {
+		t.Fatalf("status page = %d (total %s): %s", code, total, body)
+	}
+	code, total, body = list("/api/v2/models?name=BET")
+	if code != http.StatusOK || total != "1" || !strings.Contains(string(body), `"model_name":"beta"`) || !strings.Contains(string(body), `"total":1`) {
+		t.Fatalf("v2 filtered models = %d (total %s): %s", code, total, body)
+	}
+}
+
+func TestWebhookSubscriptions(t *testing.T) {
+	type notification struct {
+		event     string
+		delivery  string
+		signature string
+		body      []byte
+	}
+	received := make(chan notification, 8)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- notification{
+			event:     r.Header.Get("X-Webhook-Event"),
+			delivery:  r.Header.Get("X-Webhook-Delivery"),
+			signature: r.Header.Get("X-Webhook-Signature"),
+			body:      body,
+		}
+	}))
+	defer receiver.Close()
+
+	webhooksFile := filepath.Join(t.TempDir(), "webhooks.json")
+	h := newHarness(t, 8000, "WEBHOOKS_FILE="+webhooksFile)
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	code, resp := h.api(http.MethodPost, "/api/v1/webhooks", map[string]interface{}{
+		"url":    receiver.URL,
+		"events": []string{"model.started", "model.stopped"},
+		"secret": "hook-secret",
+	})
+	if code != http.StatusOK {
+		t.Fatalf("register webhook = %d: %s", code, resp.Error)
+	}
+	var sub struct {
+		ID     string `json:"id"`
+		Secret string `json:"secret"`
+	}
+	json.Unmarshal(resp.Data, &sub)
+	if sub.ID == "" || sub.Secret != "hook-secret" {
+		t.Fatalf("unexpected subscription: %s", resp.Data)
+	}
+	if code, _ := h.api(http.MethodPost, "/api/v1/webhooks", map[string]interface{}{"url": receiver.URL, "events": []string{"model.exploded"}}); code != http.StatusBadRequest {
+		t.Errorf("unknown event = %d, want 400", code)
+	}
+
+	if _, resp := h.switchModel("chat", "chat.gguf", false, nil); !resp.Success {
+		t.Fatalf("switch failed: %+v", resp)
+	}
+	if code, resp := h.api(http.MethodPost, "/api/v1/model/stop", map[string]string{"model_name": "chat"}); code != http.StatusOK {
+		t.Fatalf("stop = %d: %s", code, resp.Error)
+	}
+
+	// 通知按事件顺序到达，签名为以密钥计算的HMAC-SHA256
+	for _, want := range []string{"model.started", "model.stopped"} {
+		select {
+		case n := <-received:
+			mac := hmac.New(sha256.New, []byte("hook-secret"))
+			mac.Write(n.body)
+			if expected := "sha256=" + hex.EncodeToString(mac.Sum(nil)); n.signature != expected {
+				t.Errorf("%s signature = %q, want %q", n.event, n.signature, expected)
+			}
+			var payload struct {
+				ID    string `json:"id"`
+				Event string `json:"event"`
+				Data  struct {
+					ModelName string `json:"model_name"`
+				} `json:"data"`
+			}
+			json.Unmarshal(n.body, &payload)
+			if n.event != want || payload.Event != want || payload.ID != n.delivery || payload.Data.ModelName != "chat" {
+				t.Errorf("unexpected %s notification: %s", want, n.body)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("%s notification not received", want)
+		}
+	}
+
+	// 投递记录和统计，密钥不再返回
+	var deliveries []struct {
+		Event    string `json:"event"`
+		Status   string `json:"status"`
+		Attempts int    `json:"attempts"`
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		code, resp := h.api(http.MethodGet, "/api/v1/webhooks/"+sub.ID+"/deliveries", nil)
+		if code != http.StatusOK {
+			t.Fatalf("deliveries = %d: %s", code, resp.Error)
+		}
+		json.Unmarshal(resp.Data, &deliveries)
+		if len(deliveries) == 2 && deliveries[0].Status == "delivered" && deliveries[1].Status == "delivered" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if len(deliveries) != 2 || deliveries[0].Event != "model.stopped" || deliveries[0].Status != "delivered" || deliveries[0].Attempts != 1 {
+		t.Errorf("unexpected deliveries: %+v", deliveries)
+	}
+	code, resp = h.api(http.MethodGet, "/api/v1/webhooks/"+sub.ID, nil)
+	if code != http.StatusOK || strings.Contains(string(resp.Data), "hook-secret") || !strings.Contains(string(resp.Data), `"delivered":2`) {
+		t.Errorf("get webhook = %d: %s", code, resp.Data)
+	}
+
+	if code, _ := h.api(http.MethodPost, "/api/v1/webhooks/"+sub.ID+"/test", nil); code != http.StatusAccepted {
+		t.Errorf("test webhook = %d, want 202", code)
+	}
+	select {
+	case n := <-received:
+		if n.event != "webhook.test" {
+			t.Errorf("test notification event = %s", n.event)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("test notification not received")
+	}
+
+	// 订阅在重启后保留，删除后查询返回WEBHOOK_NOT_FOUND
+	h.restart()
+	if code, _ := h.api(http.MethodGet, "/api/v1/webhooks/"+sub.ID, nil); code != http.StatusOK {
+		t.Errorf("webhook after restart = %d, want 200", code)
+	}
+	if code, _ := h.api(http.MethodDelete, "/api/v1/webhooks/"+sub.ID, nil); code != http.StatusOK {
+		t.Errorf("delete webhook = %d", code)
+	}
+	code, body := h.do(http.MethodGet, "/api/v1/webhooks/"+sub.ID, nil)
+	if code != http.StatusNotFound || !strings.Contains(string(body), "WEBHOOK_NOT_FOUND") {
+		t.Errorf("deleted webhook = %d: %s", code, body)
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	h := newHarness(t, 8000)
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	// 未知的事件类型被拒绝
+	req, _ := http.NewRequest(http.MethodGet, h.baseURL+"/api/v1/events?types=model.exploded", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown event type stream = %v, %v", resp, err)
+	} else {
+		resp.Body.Close()
+	}
+
+	open := func(lastID string) (*http.Response, <-chan string) {
+		req, _ := http.NewRequest(http.MethodGet, h.baseURL+"/api/v1/events?types=model.started,model.stopped", nil)
+		req.Header.Set("Accept", "text/event-stream")
+		if lastID != "" {
+			req.Header.Set("Last-Event-ID", lastID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("open event stream: %v", err)
+		}
+		if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "text/event-stream" {
+			t.Fatalf("event stream = %d %s", resp.StatusCode, ct)
+		}
+		lines := make(chan string, 32)
+		go func() {
+			defer close(lines)
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				if line := scanner.Text(); strings.HasPrefix(line, "id: ") || strings.HasPrefix(line, "data: ") {
+					lines <- line
+				}
+			}
+		}()
+		return resp, lines
+	}
+	next := func(lines <-chan string) string {
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(10 * time.Second):
+			t.Fatal("no event received")
+			return ""
+		}
+	}
+	type streamEvent struct {
+		ID    int64  `json:"id"`
+		Event string `json:"event"`
+		Data  struct {
+			ModelName string `json:"model_name"`
+		} `json:"data"`
+	}
+	readEvent := func(lines <-chan string) streamEvent {
+		id := next(lines)
+		var e streamEvent
+		json.Unmarshal([]byte(strings.TrimPrefix(next(lines), "data: ")), &e)
+		if id != fmt.Sprintf("id: %d", e.ID) {
+			t.Errorf("id line %q does not match event %+v", id, e)
+		}
+		return e
+	}
+
+	resp, lines := open("")
+	if _, r := h.switchModel("chat", "chat.gguf", false, nil); !r.Success {
+		t.Fatalf("switch failed: %+v", r)
+	}
+	if code, r := h.api(http.MethodPost, "/api/v1/model/stop", map[string]string{"model_name": "chat"}); code != http.StatusOK {
+		t.Fatalf("stop = %d: %s", code, r.Error)
+	}
+	started, stopped := readEvent(lines), readEvent(lines)
+	if started.Event != "model.started" || started.Data.ModelName != "chat" || stopped.Event != "model.stopped" || stopped.ID <= started.ID {
+		t.Errorf("unexpected events: %+v, %+v", started, stopped)
+	}
+	resp.Body.Close()
+
+	// 重连时携带Last-Event-ID补发之后的事件
+	resp, lines = open(strconv.FormatInt(started.ID, 10))
+	defer resp.Body.Close()
+	if replayed := readEvent(lines); replayed.ID != stopped.ID || replayed.Event != "model.stopped" {
+		t.Errorf("replayed event = %+v, want %+v", replayed, stopped)
+	}
+
+	// 没有Accept: text/event-stream时仍返回事件日志
+	if code, r := h.api(http.MethodGet, "/api/v1/events", nil); code != http.StatusOK || !r.Success {
+		t.Errorf("event log = %d: %+v", code, r)
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	h := newHarness(t, 8000, "API_KEY=admin-key", "API_KEYS=viewer=ro-key")
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	// 配置了API密钥时需要认证
+	if code, _ := h.do(http.MethodGet, "/metrics", nil); code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated /metrics = %d, want 401", code)
+	}
+
+	h.apiKey = "admin-key"
+	if _, resp := h.switchModel("chat", "chat.gguf", false, map[string]interface{}{"metrics": true}); !resp.Success {
+		t.Fatalf("switch failed: %s", resp.Error)
+	}
+	if code, _ := h.chat("chat"); code != http.StatusOK {
+		t.Fatalf("proxied chat = %d", code)
+	}
+
+	h.apiKey = "ro-key"
+	scrape := func() string {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, h.baseURL+"/metrics", nil)
+		req.Header.Set("Authorization", "Bearer "+h.apiKey)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("scrape failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain; version=0.0.4") {
+			t.Fatalf("/metrics = %d %s: %s", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+		}
+		return string(body)
+	}
+
+	body := scrape()
+	for _, want := range []string{
+		"llama_switch_models_running 1\n",
+		`llama_switch_switch_total{model_name="chat",result="success"} 1` + "\n",
+		`llama_switch_switch_duration_seconds_count{model_name="chat"} 1` + "\n",
+		`llama_switch_proxy_requests_total{code="200",model_name="chat"} 1` + "\n",
+		`llama_switch_proxy_request_duration_seconds_count{model_name="chat"} 1` + "\n",
+		`llama_switch_gpu_memory_total_bytes{gpu="0",`,
+		"# TYPE llama_switch_gpu_memory_free_bytes gauge\n",
+		// 以metrics参数启动的实例的指标加上model_name标签转发
+		`llama_switch_instance_scrape_success{model_name="chat"} 1` + "\n",
+		"# TYPE llamacpp:prompt_tokens_total counter\n",
+		`llamacpp:prompt_tokens_total{model_name="chat"} 128` + "\n",
+		`llamacpp:requests_processing{model_name="chat"} 0` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+
+	h.apiKey = "admin-key"
+	if code, resp := h.api(http.MethodPost, "/api/v1/model/stop", map[string]interface{}{"model_name": "chat", "force": true}); code != http.StatusOK {
+		t.Fatalf("stop = %d: %s", code, resp.Error)
+	}
+	body = scrape()
+	for _, want := range []string{
+		"llama_switch_models_running 0\n",
+		`llama_switch_stop_total{model_name="chat",result="success"} 1` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q after stop:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "llamacpp:") {
+		t.Errorf("metrics of the stopped instance still exported:\n%s", body)
+	}
+}
+
+func TestStructuredLogging(t *testing.T) {
+	h := newHarness(t, 8000, "LOG_FORMAT=json", "LOG_LEVEL=info")
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	// 客户端提供的请求ID在响应头中返回，并出现在该请求的日志中
+	data, _ := json.Marshal(map[string]interface{}{
+		"model_name": "chat",
+		"model_path": "chat.gguf",
+		"config":     map[string]interface{}{"host": "127.0.0.1", "port": freePort(t)},
+	})
+	req, _ := http.NewRequest(http.MethodPost, h.baseURL+"/api/v1/model/switch", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "it-switch-1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("switch failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Request-ID") != "it-switch-1" {
+		t.Fatalf("switch = %d, X-Request-ID %q", resp.StatusCode, resp.Header.Get("X-Request-ID"))
+	}
+
+	// 未提供时由switcher生成
+	resp, err = http.Get(h.baseURL + "/api/v1/model/status")
+	if err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	resp.Body.Close()
+	if len(resp.Header.Get("X-Request-ID")) != 16 {
+		t.Errorf("generated X-Request-ID = %q", resp.Header.Get("X-Request-ID"))
+	}
+
+	found := map[string]bool{}
+	for _, line := range strings.Split(h.logs.String(), "\n") {
+		if !strings.Contains(line, `"msg":`) {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Errorf("log line is not JSON: %s", line)
+			continue
+		}
+		switch {
+		case entry["msg"] == "Incoming request" && entry["request_id"] == "it-switch-1" && entry["path"] == "/api/v1/model/switch":
+			found["request"] = true
+		case entry["msg"] == "Model started successfully" && entry["request_id"] == "it-switch-1" && entry["model_name"] == "chat":
+			found["handler"] = true
+		case entry["msg"] == "Model is ready" && entry["model_name"] == "chat" && entry["level"] == "INFO":
+			found["service"] = true
+		case entry["level"] == "DEBUG":
+			t.Errorf("debug entry logged at info level: %s", line)
+		}
+	}
+	for _, want := range []string{"request", "handler", "service"} {
+		if !found[want] {
+			t.Errorf("missing %s log entry:\n%s", want, h.logs.String())
+		}
+	}
+}
@@ -0,0 +1,401 @@
+//go:build integration
+
+// Package integration 使用模拟llama-server和模拟nvidia-smi对完整HTTP API进行集成测试，无需GPU
+//
+// 运行方式：go test -tags=integration ./test/integration/...
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// binDir 测试二进制文件目录（llama-switch、模拟llama-server、模拟nvidia-smi）
+var binDir string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "llama-switch-integration")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create bin dir: %v\n", err)
+		os.Exit(1)
+	}
+	binDir = dir
+
+	for pkg, name := range map[string]string{
+		"llama-switch/cmd/server":                 "llama-switch",
+		"llama-switch/test/integration/mockllama": "llama-server",
+		"llama-switch/test/integration/mocksmi":   "nvidia-smi",
+	} {
+		out, err := exec.Command("go", "build", "-o", filepath.Join(binDir, exeName(name)), pkg).CombinedOutput()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to build %s: %v\n%s", pkg, err, out)
+			os.RemoveAll(binDir)
+			os.Exit(1)
+		}
+	}
+
+	code := m.Run()
+	os.RemoveAll(binDir)
+	os.Exit(code)
+}
+
+// exeName 按平台补全可执行文件扩展名
+func exeName(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+// harness 单个测试用例的运行环境：独立的程序目录、模型目录和模拟GPU状态
+type harness struct {
+	t         *testing.T
+	dir       string
+	modelsDir string
+	serverBin string
+	baseURL   string
+	env       []string
+	cmd       *exec.Cmd
+	exited    chan struct{}
+	logs      *lockedBuffer
+	apiKey    string       // 非空时作为Authorization: Bearer发送
+	client    *http.Client // 访问switcher使用的客户端，为nil时使用http.DefaultClient
+}
+
+// lockedBuffer 并发安全的日志缓冲
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// newHarness 创建测试环境，gpuTotalMB为模拟GPU的总显存
+func newHarness(t *testing.T, gpuTotalMB int, extraEnv ...string) *harness {
+	t.Helper()
+
+	dir := t.TempDir()
+	h := &harness{
+		t:         t,
+		dir:       dir,
+		modelsDir: filepath.Join(dir, "models"),
+		serverBin: filepath.Join(dir, exeName("llama-switch")),
+		logs:      &lockedBuffer{},
+	}
+	stateDir := filepath.Join(dir, "gpu-state")
+	for _, d := range []string{h.modelsDir, stateDir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", d, err)
+		}
+	}
+
+	// 持久化配置保存在程序目录下，每个测试使用独立的程序副本
+	data, err := os.ReadFile(filepath.Join(binDir, exeName("llama-switch")))
+	if err != nil {
+		t.Fatalf("failed to read server binary: %v", err)
+	}
+	if err := os.WriteFile(h.serverBin, data, 0755); err != nil {
+		t.Fatalf("failed to copy server binary: %v", err)
+	}
+
+	port := freePort(t)
+	h.baseURL = fmt.Sprintf("http://127.0.0.1:%d", port)
+	mockServer := filepath.Join(binDir, exeName("llama-server"))
+	h.env = append(os.Environ(),
+		"PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"),
+		"LLAMA_SERVER_PATH="+mockServer,
+		"LLAMA_BENCH_PATH="+mockServer,
+		"MODELS_DIR="+h.modelsDir,
+		"SERVER_HOST=127.0.0.1",
+		fmt.Sprintf("SERVER_PORT=%d", port),
+		fmt.Sprintf("MOCK_GPU_TOTAL_MB=%d", gpuTotalMB),
+		"MOCK_GPU_STATE_DIR="+stateDir,
+		"MODEL_LOG_DIR="+t.TempDir(),
+		"RESOURCE_SAMPLE_INTERVAL=1",
+		"VRAM_HISTORY_DIR="+t.TempDir(),
+		// 避免继承系统中用于CA证书的SSL_CERT_FILE
+		"SSL_CERT_FILE=",
+		"SSL_KEY_FILE=",
+	)
+	h.env = append(h.env, extraEnv...)
+
+	t.Cleanup(func() {
+		h.stop()
+		if t.Failed() {
+			t.Logf("server logs:\n%s", h.logs.String())
+		}
+	})
+	return h
+}
+
+// freePort 获取一个空闲的本地端口
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to allocate port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// createModel 在模型目录中创建指定大小的GGUF文件（稀疏文件，不占用实际磁盘空间）
+func (h *harness) createModel(name string, sizeMB int64) string {
+	h.t.Helper()
+	path := filepath.Join(h.modelsDir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		h.t.Fatalf("failed to create model file: %v", err)
+	}
+	defer f.Close()
+	if err := f.Truncate(sizeMB * 1024 * 1024); err != nil {
+		h.t.Fatalf("failed to size model file: %v", err)
+	}
+	return path
+}
+
+// start 启动switcher并等待其就绪
+func (h *harness) start() {
+	h.t.Helper()
+
+	cmd := exec.Command(h.serverBin)
+	cmd.Dir = h.dir
+	cmd.Env = h.env
+	cmd.Stdout = h.logs
+	cmd.Stderr = h.logs
+	if err := cmd.Start(); err != nil {
+		h.t.Fatalf("failed to start server: %v", err)
+	}
+	h.cmd = cmd
+	h.exited = make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(h.exited)
+	}()
+
+	ready := make(chan error, 1)
+	go func() {
+		ready <- waitHealthyWith(h.httpClient(), h.baseURL+"/health", 15*time.Second)
+	}()
+	select {
+	case err := <-ready:
+		if err != nil {
+			h.t.Fatalf("server not ready: %v", err)
+		}
+	case <-h.exited:
+		h.cmd = nil
+		h.t.Fatal("server exited during startup")
+	}
+}
+
+// stop 优雅关闭switcher（会停止其管理的所有模型）
+func (h *harness) stop() {
+	if h.cmd == nil {
+		return
+	}
+
+	if runtime.GOOS == "windows" || h.cmd.Process.Signal(os.Interrupt) != nil {
+		h.cmd.Process.Kill()
+	}
+	select {
+	case <-h.exited:
+	case <-time.After(20 * time.Second):
+		h.cmd.Process.Kill()
+		<-h.exited
+	}
+	h.cmd = nil
+}
+
+// restart 重启switcher
+func (h *harness) restart() {
+	h.t.Helper()
+	h.stop()
+	h.start()
+}
+
+// waitHealthy 轮询健康检查接口直到返回200
+func waitHealthy(url string, timeout time.Duration) error {
+	return waitHealthyWith(http.DefaultClient, url, timeout)
+}
+
+// waitHealthyWith 使用指定的客户端轮询健康检查接口直到返回200
+func waitHealthyWith(client *http.Client, url string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		resp, err := client.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("%s not healthy after %v", url, timeout)
+}
+
+// apiResponse switcher API的通用响应
+type apiResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Error   string          `json:"error"`
+	Code    string          `json:"code"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// do 发送请求并返回状态码和响应体
+func (h *harness) do(method, path string, payload interface{}) (int, []byte) {
+	h.t.Helper()
+
+	var body io.Reader
+	if payload != nil {
+		data, _ := json.Marshal(payload)
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, h.baseURL+path, body)
+	if err != nil {
+		h.t.Fatalf("failed to build request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+h.apiKey)
+	}
+
+	resp, err := h.httpClient().Do(req)
+	if err != nil {
+		h.t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, data
+}
+
+// httpClient 访问switcher使用的客户端
+func (h *harness) httpClient() *http.Client {
+	if h.client != nil {
+		return h.client
+	}
+	return http.DefaultClient
+}
+
+// api 调用管理API并解析通用响应
+func (h *harness) api(method, path string, payload interface{}) (int, *apiResponse) {
+	h.t.Helper()
+	code, data := h.do(method, path, payload)
+	var resp apiResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		h.t.Fatalf("%s %s returned invalid JSON (%d): %s", method, path, code, data)
+	}
+	return code, &resp
+}
+
+// switchModel 通过API启动模型并等待模拟实例就绪，返回实例端口
+// config为额外的模型参数，host和port由测试环境分配
+func (h *harness) switchModel(name, file string, forceVRAM bool, config map[string]interface{}) (int, *apiResponse) {
+	h.t.Helper()
+	port := freePort(h.t)
+	if config == nil {
+		config = make(map[string]interface{})
+	}
+	config["host"] = "127.0.0.1"
+	config["port"] = port
+
+	code, resp := h.api(http.MethodPost, "/api/v1/model/switch", map[string]interface{}{
+		"model_name": name,
+		"model_path": file,
+		"force_vram": forceVRAM,
+		"config":     config,
+	})
+	if code == http.StatusOK {
+		h.waitModel(port)
+	}
+	return port, resp
+}
+
+// waitModel 等待指定端口上的模拟实例就绪
+func (h *harness) waitModel(port int) {
+	h.t.Helper()
+	if err := waitHealthy(fmt.Sprintf("http://127.0.0.1:%d/health", port), 10*time.Second); err != nil {
+		h.t.Fatalf("model not ready: %v", err)
+	}
+}
+
+// runningModels 获取运行中的模型名称
+func (h *harness) runningModels() map[string]bool {
+	h.t.Helper()
+	code, resp := h.api(http.MethodGet, "/api/v1/model/status", nil)
+	if code != http.StatusOK {
+		h.t.Fatalf("status request failed (%d): %s", code, resp.Error)
+	}
+
+	// 单个模型时data为对象，多个模型时为数组
+	type entry struct {
+		Model struct {
+			ModelName string `json:"model_name"`
+			Running   bool   `json:"running"`
+		} `json:"model"`
+	}
+	var entries []entry
+	if len(resp.Data) > 0 && resp.Data[0] == '{' {
+		var single entry
+		if err := json.Unmarshal(resp.Data, &single); err != nil {
+			h.t.Fatalf("failed to parse status: %v", err)
+		}
+		entries = append(entries, single)
+	} else if len(resp.Data) > 0 {
+		if err := json.Unmarshal(resp.Data, &entries); err != nil {
+			h.t.Fatalf("failed to parse status: %v", err)
+		}
+	}
+
+	running := make(map[string]bool)
+	for _, e := range entries {
+		if e.Model.Running {
+			running[e.Model.ModelName] = true
+		}
+	}
+	return running
+}
+
+// chat 通过推理代理发送聊天请求，返回状态码和回复内容
+func (h *harness) chat(modelName string) (int, string) {
+	h.t.Helper()
+	code, data := h.do(http.MethodPost, "/v1/chat/completions", map[string]interface{}{
+		"model":    modelName,
+		"messages": []map[string]string{{"role": "user", "content": "hello"}},
+	})
+	var resp struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	json.Unmarshal(data, &resp)
+	if len(resp.Choices) == 0 {
+		return code, strings.TrimSpace(string(data))
+	}
+	return code, resp.Choices[0].Message.Content
+}
@@ -0,0 +1,98 @@
+//go:build integration
+
+package integration
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// startIdentityProvider 启动测试用的OIDC身份提供方，返回签发方地址和用RS256签发令牌的函数
+func startIdentityProvider(t *testing.T) (string, func(claims map[string]interface{}) string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": server.URL, "jwks_uri": server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "key-1", "n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes())},
+		}})
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	sign := func(claims map[string]interface{}) string {
+		header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "key-1", "typ": "JWT"})
+		payload, _ := json.Marshal(claims)
+		input := b64(header) + "." + b64(payload)
+		digest := crypto.SHA256.New()
+		digest.Write([]byte(input))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest.Sum(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return input + "." + b64(signature)
+	}
+	return server.URL, sign
+}
+
+func TestJWTAuthentication(t *testing.T) {
+	issuer, sign := startIdentityProvider(t)
+	h := newHarness(t, 8000, "API_KEY=admin-key", "JWT_ISSUER="+issuer, "JWT_AUDIENCE=llama-switch",
+		"JWT_ROLE_CLAIM=realm_access.roles", "JWT_ROLE_MAP=llm-ops=operator,llm-viewers=readonly")
+	h.createModel("chat.gguf", 1)
+	h.start()
+
+	token := func(exp time.Time, roles ...string) string {
+		return sign(map[string]interface{}{
+			"iss":          issuer,
+			"aud":          "llama-switch",
+			"sub":          "alice",
+			"exp":          exp.Unix(),
+			"realm_access": map[string]interface{}{"roles": roles},
+		})
+	}
+	valid := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name, key, method, path string
+		want                    int
+		code                    string
+	}{
+		{"viewer", token(valid, "llm-viewers"), http.MethodGet, "/api/v1/model/status", http.StatusOK, ""},
+		{"viewer", token(valid, "llm-viewers"), http.MethodPost, "/api/v1/model/stop", http.StatusForbidden, "FORBIDDEN"},
+		{"operator", token(valid, "llm-ops"), http.MethodPost, "/api/v1/admin/reload", http.StatusForbidden, "FORBIDDEN"},
+		{"unmapped", token(valid, "admin"), http.MethodGet, "/api/v1/model/status", http.StatusForbidden, "FORBIDDEN"},
+		{"expired", token(time.Now().Add(-time.Hour), "llm-ops"), http.MethodGet, "/api/v1/model/status", http.StatusUnauthorized, "INVALID_API_KEY"},
+		{"static key", "admin-key", http.MethodGet, "/api/v1/model/status", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		h.apiKey = tt.key
+		code, resp := h.api(tt.method, tt.path, nil)
+		if code != tt.want || resp.Code != tt.code {
+			t.Errorf("%s: %s %s = %d %s (%s), want %d %s", tt.name, tt.method, tt.path, code, resp.Code, resp.Error, tt.want, tt.code)
+		}
+	}
+
+	// 有多个角色时取权限最高的角色，操作员令牌可以切换模型
+	h.apiKey = token(valid, "llm-viewers", "llm-ops")
+	if _, resp := h.switchModel("chat", "chat.gguf", false, nil); !resp.Success {
+		t.Fatalf("operator token switch failed: %+v", resp)
+	}
+}
@@ -0,0 +1,222 @@
+// mockllama 集成测试用的模拟llama-server
+// 接受llama-server的命令行参数，在--host/--port上提供/health、/completion、/metrics等接口的固定响应，
+// 并在MOCK_GPU_STATE_DIR中登记自身占用的显存（及CUDA_VISIBLE_DEVICES指定的GPU），供模拟nvidia-smi统计；
+// 模型文件名以broken开头时模拟加载失败，输出错误后退出，以stuck开头时模拟推理循环卡死（/slots不响应）；
+// --version输出MOCK_LLAMA_BUILD指定的构建号（默认5000）
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// 与switcher的估算方式保持一致：基础500MB + 每层200MB，且不超过模型文件大小
+const (
+	baseVRAM     = 500
+	perLayerVRAM = 200
+)
+
+func main() {
+	args := parseArgs(os.Args[1:])
+	if args["--version"] != "" {
+		build := os.Getenv("MOCK_LLAMA_BUILD")
+		if build == "" {
+			build = "5000"
+		}
+		fmt.Fprintf(os.Stderr, "version: %s (0123abc)\nbuilt with mock for %s\n", build, "integration tests")
+		return
+	}
+
+	host := args["--host"]
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	port := args["--port"]
+	if port == "" {
+		port = "8080"
+	}
+	modelPath := args["--model"]
+	modelName := filepath.Base(modelPath)
+	if strings.HasPrefix(modelName, "broken") {
+		fmt.Fprintf(os.Stderr, "llama_model_load: error loading model: invalid magic in %s\n", modelName)
+		os.Exit(1)
+	}
+
+	// 与llama-server一样输出加载阶段，MOCK_LLAMA_LOAD_DELAY_MS模拟加载模型的耗时，期间显存尚未被占用
+	fmt.Fprintf(os.Stderr, "llama_model_loader: loaded meta data with 24 key-value pairs and 291 tensors from %s\n", modelPath)
+	fmt.Fprintf(os.Stderr, "load_tensors: offloading %s repeating layers to GPU\n", args["--n-gpu-layers"])
+	if delay, err := strconv.Atoi(os.Getenv("MOCK_LLAMA_LOAD_DELAY_MS")); err == nil {
+		time.Sleep(time.Duration(delay) * time.Millisecond)
+	}
+	stateFile := registerVRAM(modelPath, args["--n-gpu-layers"])
+	if stateFile != "" {
+		defer os.Remove(stateFile)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/completion", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"content": "mock completion from " + modelName,
+			"model":   modelName,
+			"stop":    true,
+		})
+	})
+	mux.HandleFunc("/v1/completions", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"object":  "text_completion",
+			"model":   modelName,
+			"choices": []map[string]interface{}{{"index": 0, "text": "mock completion from " + modelName}},
+		})
+	})
+	mux.HandleFunc("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"object": "chat.completion",
+			"model":  modelName,
+			"choices": []map[string]interface{}{{
+				"index":   0,
+				"message": map[string]string{"role": "assistant", "content": "mock reply from " + modelName},
+			}},
+		})
+	})
+	mux.HandleFunc("/v1/embeddings", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input json.RawMessage `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		count := 1
+		var inputs []string
+		if json.Unmarshal(req.Input, &inputs) == nil {
+			count = len(inputs)
+		}
+		data := make([]map[string]interface{}, count)
+		for i := range data {
+			data[i] = map[string]interface{}{"object": "embedding", "index": i, "embedding": []float64{float64(i), 0.5}}
+		}
+		writeJSON(w, map[string]interface{}{
+			"object": "list",
+			"model":  modelName,
+			"data":   data,
+			"usage":  map[string]int{"prompt_tokens": count, "total_tokens": count},
+		})
+	})
+	rerank := func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Documents []string `json:"documents"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		results := make([]map[string]interface{}, len(req.Documents))
+		for i := range results {
+			results[i] = map[string]interface{}{"index": i, "relevance_score": 1.0 / float64(i+1)}
+		}
+		writeJSON(w, map[string]interface{}{"model": modelName, "results": results})
+	}
+	mux.HandleFunc("/rerank", rerank)
+	mux.HandleFunc("/v1/rerank", rerank)
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprintln(w, "# HELP llamacpp:prompt_tokens_total Number of prompt tokens processed.")
+		fmt.Fprintln(w, "# TYPE llamacpp:prompt_tokens_total counter")
+		fmt.Fprintln(w, "llamacpp:prompt_tokens_total 128")
+		fmt.Fprintln(w, "# HELP llamacpp:tokens_predicted_total Number of generation tokens processed.")
+		fmt.Fprintln(w, "# TYPE llamacpp:tokens_predicted_total counter")
+		fmt.Fprintln(w, "llamacpp:tokens_predicted_total 256")
+		fmt.Fprintln(w, "# HELP llamacpp:requests_processing Number of requests processing.")
+		fmt.Fprintln(w, "# TYPE llamacpp:requests_processing gauge")
+		fmt.Fprintln(w, "llamacpp:requests_processing 0")
+	})
+	mux.HandleFunc("/slots", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(modelName, "stuck") {
+			<-r.Context().Done()
+			return
+		}
+		writeJSON(w, []map[string]interface{}{{"id": 0, "is_processing": false}})
+	})
+	mux.HandleFunc("/slots/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"id_slot": 0, "n_saved": 0})
+	})
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		log.Fatalf("mockllama: failed to listen: %v", err)
+	}
+	log.Printf("mockllama: serving %s on %s", modelName, listener.Addr())
+
+	// 收到中断信号时清理显存登记后退出
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		if stateFile != "" {
+			os.Remove(stateFile)
+		}
+		os.Exit(0)
+	}()
+
+	if err := http.Serve(listener, mux); err != nil {
+		log.Fatalf("mockllama: serve failed: %v", err)
+	}
+}
+
+// parseArgs 解析"--flag value"形式的参数，无值的开关记为"true"
+func parseArgs(argv []string) map[string]string {
+	args := make(map[string]string)
+	for i := 0; i < len(argv); i++ {
+		if i+1 < len(argv) && len(argv[i+1]) > 0 && argv[i+1][0] != '-' {
+			args[argv[i]] = argv[i+1]
+			i++
+			continue
+		}
+		args[argv[i]] = "true"
+	}
+	return args
+}
+
+// registerVRAM 在状态目录中登记模拟的显存占用，返回登记文件路径
+func registerVRAM(modelPath, layersArg string) string {
+	dir := os.Getenv("MOCK_GPU_STATE_DIR")
+	layers, _ := strconv.Atoi(layersArg)
+	if dir == "" || layers <= 0 {
+		return ""
+	}
+
+	usage := baseVRAM + layers*perLayerVRAM
+	if info, err := os.Stat(modelPath); err == nil {
+		usage = min(usage, int(info.Size()/(1024*1024)))
+	}
+	// MOCK_LLAMA_VRAM_MB模拟实际占用与switcher估算不同的情况
+	if actual, err := strconv.Atoi(os.Getenv("MOCK_LLAMA_VRAM_MB")); err == nil {
+		usage = actual
+	}
+
+	// 限制为单个可见GPU时登记GPU编号
+	entry := strconv.Itoa(usage)
+	if gpu, err := strconv.Atoi(os.Getenv("CUDA_VISIBLE_DEVICES")); err == nil {
+		entry += "@" + strconv.Itoa(gpu)
+	}
+
+	path := filepath.Join(dir, strconv.Itoa(os.Getpid()))
+	if err := os.WriteFile(path, []byte(entry), 0644); err != nil {
+		log.Printf("mockllama: failed to register VRAM usage: %v", err)
+		return ""
+	}
+	return path
+}
+
+// writeJSON 返回JSON响应
+func writeJSON(w http.ResponseWriter, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,125 @@
+// mocksmi 集成测试用的模拟nvidia-smi
+// 各GPU的总显存由MOCK_GPU_TOTAL_MB指定（多个GPU以逗号分隔），已用显存为MOCK_GPU_STATE_DIR中模拟llama-server按PID登记的占用：
+// 登记了GPU编号（"MB@GPU"）的计入该GPU，否则平均分到所有GPU
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// registration 模拟llama-server登记的显存占用
+type registration struct {
+	mb  int
+	gpu int // 限制可见GPU时的GPU编号，否则为-1
+}
+
+func main() {
+	var totals []int
+	for _, s := range strings.Split(os.Getenv("MOCK_GPU_TOTAL_MB"), ",") {
+		total, _ := strconv.Atoi(strings.TrimSpace(s))
+		totals = append(totals, total)
+	}
+	processes := registeredVRAM(os.Getenv("MOCK_GPU_STATE_DIR"))
+	used := make([]int, len(totals))
+	for _, p := range processes {
+		if p.gpu >= 0 && p.gpu < len(totals) {
+			used[p.gpu] += p.mb
+			continue
+		}
+		for i := range used {
+			used[i] += p.mb / len(totals)
+		}
+	}
+
+	var fields []string
+	for _, arg := range os.Args[1:] {
+		if query, ok := strings.CutPrefix(arg, "--query-gpu="); ok {
+			fields = strings.Split(query, ",")
+		}
+		// 按进程列出显存占用（只支持pid,used_memory）
+		if strings.HasPrefix(arg, "--query-compute-apps=") {
+			for pid, p := range processes {
+				fmt.Printf("%d, %d\n", pid, p.mb)
+			}
+			return
+		}
+	}
+	if len(fields) == 0 {
+		for i, total := range totals {
+			fmt.Printf("Mock GPU %d: %dMiB / %dMiB\n", i, used[i], total)
+		}
+		return
+	}
+
+	for gpu, total := range totals {
+		values := make([]string, len(fields))
+		for i, field := range fields {
+			switch strings.TrimSpace(field) {
+			case "memory.free":
+				values[i] = strconv.Itoa(max(total-used[gpu], 0))
+			case "memory.used":
+				values[i] = strconv.Itoa(used[gpu])
+			case "memory.total":
+				values[i] = strconv.Itoa(total)
+			case "index":
+				values[i] = strconv.Itoa(gpu)
+			case "temperature.gpu":
+				values[i] = "45"
+				if temp := os.Getenv("MOCK_GPU_TEMP_C"); temp != "" {
+					values[i] = temp
+				}
+			case "power.draw":
+				values[i] = "150.00"
+			case "power.limit":
+				values[i] = "300.00"
+			case "utilization.gpu":
+				values[i] = "[N/A]"
+			case "name":
+				values[i] = "Mock GPU"
+			case "uuid":
+				values[i] = fmt.Sprintf("GPU-00000000-0000-0000-0000-%012d", gpu)
+			default:
+				values[i] = "0"
+				if strings.HasPrefix(field, "clocks_throttle_reasons.") {
+					values[i] = "Not Active"
+				}
+			}
+		}
+		fmt.Println(strings.Join(values, ", "))
+	}
+}
+
+// registeredVRAM 读取状态目录中按PID登记的显存占用(MB)
+func registeredVRAM(dir string) map[int]registration {
+	processes := make(map[int]registration)
+	if dir == "" {
+		return processes
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return processes
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		r := registration{gpu: -1}
+		mb, gpu, pinned := strings.Cut(strings.TrimSpace(string(data)), "@")
+		r.mb, _ = strconv.Atoi(mb)
+		if pinned {
+			r.gpu, _ = strconv.Atoi(gpu)
+		}
+		processes[pid] = r
+	}
+	return processes
+}
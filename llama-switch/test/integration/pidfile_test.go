@@ -0,0 +1,27 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecondInstanceRefused(t *testing.T) {
+	h := newHarness(t, 8000)
+	h.start()
+
+	// 同一程序目录下的第二个实例因PID锁文件拒绝启动
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, h.serverBin)
+	cmd.Env = append(append([]string(nil), h.env...), "SERVER_PORT="+strconv.Itoa(freePort(t)))
+	output, err := cmd.CombinedOutput()
+	if err == nil || !strings.Contains(string(output), "another switcher instance") {
+		t.Fatalf("expected second instance to be refused, got %v: %s", err, output)
+	}
+}
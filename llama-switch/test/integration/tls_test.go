@@ -0,0 +1,83 @@
+//go:build integration
+
+package integration
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeServerCert 为127.0.0.1生成自签名证书，写入dir，返回证书、私钥路径和信任该证书的证书池
+func writeServerCert(t *testing.T, dir string) (string, string, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "llama-switch-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return certFile, keyFile, pool
+}
+
+func TestHTTPSWithRedirect(t *testing.T) {
+	certFile, keyFile, pool := writeServerCert(t, t.TempDir())
+	redirectPort := freePort(t)
+	h := newHarness(t, 8000, "SSL_CERT_FILE="+certFile, "SSL_KEY_FILE="+keyFile, fmt.Sprintf("HTTP_REDIRECT_PORT=%d", redirectPort))
+	h.baseURL = strings.Replace(h.baseURL, "http://", "https://", 1)
+	h.client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	h.start()
+
+	code, resp := h.api(http.MethodGet, "/api/v1/capabilities", nil)
+	if code != http.StatusOK || !strings.Contains(string(resp.Data), `"tls":true`) {
+		t.Fatalf("capabilities over HTTPS (%d): %s", code, resp.Data)
+	}
+
+	// 明文请求重定向到HTTPS，保留路径和查询参数
+	noFollow := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	redirect, err := noFollow.Get(fmt.Sprintf("http://127.0.0.1:%d/api/v1/models?limit=1", redirectPort))
+	if err != nil {
+		t.Fatalf("request to the redirect port failed: %v", err)
+	}
+	redirect.Body.Close()
+	if want := h.baseURL + "/api/v1/models?limit=1"; redirect.StatusCode != http.StatusPermanentRedirect || redirect.Header.Get("Location") != want {
+		t.Errorf("redirect = %d %s; want 308 %s", redirect.StatusCode, redirect.Header.Get("Location"), want)
+	}
+}